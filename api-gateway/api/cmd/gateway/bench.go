@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/bench"
+)
+
+// isBench reports whether the command line asks for benchmark mode (`gateway -bench ...`).
+func isBench(args []string) bool {
+	return len(args) > 0 && (args[0] == "-bench" || args[0] == "--bench")
+}
+
+// runBench drives synthetic trainers against a running gateway and prints commit latency
+// percentiles and error rates. It returns the process exit code.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	opts := bench.Options{}
+	var (
+		clusters  string
+		adminKey  string
+		jsonOut   bool
+		errorRate float64
+	)
+	fs.StringVar(&opts.Target, "target", "http://localhost:9000", "base URL of the gateway under test")
+	fs.IntVar(&opts.Trainers, "trainers", 10, "number of synthetic trainers")
+	fs.Float64Var(&opts.Rate, "rate", 1, "commits per second started by each trainer")
+	fs.IntVar(&opts.PayloadSize, "payload-size", 1024, "bytes of model payload per commit")
+	fs.DurationVar(&opts.Duration, "duration", time.Minute, "how long to keep starting commits")
+	fs.IntVar(&opts.MaxInFlight, "max-inflight", 256, "commits awaiting a response before further ticks are dropped")
+	fs.DurationVar(&opts.Timeout, "timeout", 30*time.Second, "timeout of each request")
+	fs.StringVar(&opts.Prefix, "prefix", "bench-trainer", "trainer name prefix")
+	fs.StringVar(&opts.Seed, "seed", "nebula-bench", "seed the trainer keys are derived from")
+	fs.StringVar(&opts.State, "state", "bench-state", "state of the synthetic trainers")
+	fs.StringVar(&clusters, "clusters", "bench-cluster", "comma-separated clusters the trainers are spread over")
+	fs.BoolVar(&opts.Register, "register", false, "enroll the trainers before the run")
+	fs.StringVar(&opts.AuthSecret, "auth-secret", os.Getenv("AUTH_JWT_SECRET"), "the gateway's AUTH_JWT_SECRET, for registration tokens")
+	fs.StringVar(&adminKey, "admin-key", "", "admin Ed25519 private key (PEM) that signs the trainers' credentials")
+	fs.StringVar(&opts.JobID, "job-id", os.Getenv("GATEWAY_JOB_ID"), "job_id written into the trainers' credentials")
+	fs.BoolVar(&jsonOut, "json", false, "print the report as JSON")
+	fs.Float64Var(&errorRate, "max-error-rate", 1, "exit with status 1 when the error rate exceeds this fraction")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	for _, cluster := range strings.Split(clusters, ",") {
+		if cluster = strings.TrimSpace(cluster); cluster != "" {
+			opts.Clusters = append(opts.Clusters, cluster)
+		}
+	}
+	if adminKey != "" {
+		key, err := loadEd25519PrivateKey(adminKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load admin key: %v\n", err)
+			return 2
+		}
+		opts.AdminKey = key
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := bench.Run(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench failed: %v\n", err)
+		return 1
+	}
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	} else {
+		printReport(report)
+	}
+	if report.ErrorRate > errorRate {
+		return 1
+	}
+	return 0
+}
+
+func printReport(report *bench.Report) {
+	fmt.Printf("target       %s\n", report.Target)
+	fmt.Printf("trainers     %d at %g commits/s each, %d-byte payloads\n", report.Trainers, report.Rate, report.PayloadSize)
+	fmt.Printf("duration     %.1fs\n", report.Duration)
+	fmt.Printf("commits      %d sent, %d succeeded, %d failed, %d dropped\n", report.Sent, report.Succeeded, report.Failed, report.Dropped)
+	fmt.Printf("error rate   %.2f%%\n", report.ErrorRate*100)
+	fmt.Printf("throughput   %.2f commits/s\n", report.Throughput)
+	fmt.Printf("latency ms   mean %.1f  p50 %.1f  p95 %.1f  p99 %.1f  max %.1f\n", report.Latency.Mean, report.Latency.P50, report.Latency.P95, report.Latency.P99, report.Latency.Max)
+	kinds := make([]string, 0, len(report.Errors))
+	for kind := range report.Errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("  %-10s %d\n", kind, report.Errors[kind])
+	}
+}
+
+func loadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key in %s is not an Ed25519 private key", path)
+	}
+	return priv, nil
+}
@@ -2,30 +2,90 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 
+	"github.com/nebula/api-gateway/internal/aggregations"
+	"github.com/nebula/api-gateway/internal/anchoring"
+	"github.com/nebula/api-gateway/internal/artifacts"
+	"github.com/nebula/api-gateway/internal/audit"
+	"github.com/nebula/api-gateway/internal/bridge"
+	"github.com/nebula/api-gateway/internal/cache"
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/consistency"
+	"github.com/nebula/api-gateway/internal/contributions"
 	"github.com/nebula/api-gateway/internal/convergence"
 	"github.com/nebula/api-gateway/internal/data"
+	"github.com/nebula/api-gateway/internal/did"
+	"github.com/nebula/api-gateway/internal/discovery"
+	"github.com/nebula/api-gateway/internal/envelope"
+	"github.com/nebula/api-gateway/internal/evaluations"
+	"github.com/nebula/api-gateway/internal/events"
+	"github.com/nebula/api-gateway/internal/export"
+	"github.com/nebula/api-gateway/internal/flags"
+	"github.com/nebula/api-gateway/internal/jobs"
+	"github.com/nebula/api-gateway/internal/keyexchange"
+	"github.com/nebula/api-gateway/internal/membership"
 	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/nation"
+	"github.com/nebula/api-gateway/internal/openapi"
+	"github.com/nebula/api-gateway/internal/overview"
+	"github.com/nebula/api-gateway/internal/passthrough"
+	"github.com/nebula/api-gateway/internal/profile"
 	"github.com/nebula/api-gateway/internal/registry"
+	"github.com/nebula/api-gateway/internal/revocation"
+	"github.com/nebula/api-gateway/internal/rounds"
+	"github.com/nebula/api-gateway/internal/routing"
+	"github.com/nebula/api-gateway/internal/selection"
+	"github.com/nebula/api-gateway/internal/snapshot"
+	"github.com/nebula/api-gateway/internal/storage"
+	"github.com/nebula/api-gateway/internal/submissions"
+	"github.com/nebula/api-gateway/internal/tokens"
+	"github.com/nebula/api-gateway/internal/verification"
+	"github.com/nebula/api-gateway/internal/webhooks"
 	"github.com/nebula/api-gateway/internal/whitelist"
 )
 
 func main() {
-	cfg, err := common.LoadConfig()
+	if isBench(os.Args[1:]) {
+		os.Exit(runBench(os.Args[2:]))
+	}
+	if isSeed(os.Args[1:]) {
+		os.Exit(runSeed(os.Args[2:]))
+	}
+	if isSnapshotImport(os.Args[1:]) {
+		os.Exit(runSnapshotImport(os.Args[2:]))
+	}
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON configuration file; environment variables override its settings")
+	validateOnly := flag.Bool("validate-config", false, "print the resolved configuration and exit")
+	flag.Parse()
+
+	cfg, err := common.LoadConfigFrom(*configPath)
+	if *validateOnly {
+		os.Exit(validateConfig(cfg, err))
+	}
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
-	fabric := common.NewFabricClient(cfg)
-	if err := fabric.WaitForChannelReady(2 * time.Minute); err != nil {
-		log.Fatalf("fabric channel not ready: %v", err)
+	tracer := common.NewTracer(cfg)
+	go tracer.Run(context.Background())
+	metrics := common.NewMetrics()
+	fabric := common.NewFabricClient(cfg, tracer, metrics)
+	wallet, err := common.OpenWallet(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize wallet: %v", err)
+	}
+	if err := fabric.UseWallet(wallet); err != nil {
+		log.Fatalf("failed to initialize wallet: %v", err)
 	}
-	store, err := registry.NewStore(cfg.TrainerDBPath)
+	store, err := registry.NewStore(cfg.TrainerStore, cfg.TrainerDBPath)
 	if err != nil {
 		log.Fatalf("failed to initialize trainer store: %v", err)
 	}
@@ -37,47 +97,377 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to initialize authenticator: %v", err)
 	}
+	if cfg.AuthJWKSURL != "" {
+		auth.EnableJWKS(common.NewJWKS(cfg.AuthJWKSURL, cfg.AuthJWKSRefresh), cfg.AuthIssuers, cfg.AuthAudience)
+	}
+	if len(cfg.RateLimits) > 0 {
+		auth.EnableRateLimit(common.NewRateLimiter(cfg.RateLimits, metrics))
+	}
+	if len(cfg.APIKeyRoutes) > 0 {
+		keyStores := []common.APIKeyStore{common.StaticAPIKeys(cfg.APIKeys)}
+		if stored, ok := store.(common.APIKeyStore); ok {
+			keyStores = append(keyStores, stored)
+		}
+		auth.EnableAPIKeys(cfg.APIKeyRoutes, common.NewRateLimiter(nil, metrics), keyStores...)
+	}
+	if cfg.AuthzPolicyPath != "" {
+		policy, err := common.LoadPolicy(cfg.AuthzPolicyPath)
+		if err != nil {
+			log.Fatalf("failed to load authorization policy: %v", err)
+		}
+		auth.EnablePolicy(policy)
+	}
+	tokenIssuer, err := common.NewTokenIssuer(cfg.AuthTokenKey, cfg.AuthTokenTTL)
+	if err != nil {
+		log.Fatalf("failed to initialize token issuer: %v", err)
+	}
+	if len(cfg.AuthTokenKey) == 0 {
+		log.Printf("AUTH_TOKEN_SIGNING_KEY not set; tokens from /auth/token are signed with a per-process key")
+	}
+	sessions := tokens.NewSessionStore(cfg.AuthRefreshTTL)
+	auth.EnableTokenIssuer(tokenIssuer, sessions)
 
 	regSvc := registry.NewService(cfg, fabric, store, verifier)
+	regSvc.EnableSessionRevocation(func(jwtSub, reason string) {
+		sessions.RevokeSubject(jwtSub, "gateway", reason)
+	})
+	if cfg.FabricCAURL != "" {
+		ca, err := registry.NewCAClient(cfg)
+		if err != nil {
+			log.Fatalf("failed to initialize fabric CA client: %v", err)
+		}
+		regSvc.EnableEnrollment(ca, wallet)
+	}
 	dataSvc := data.NewService(cfg, fabric, store)
-	modelSvc := models.NewService(cfg, fabric, store)
+	roundSvc := rounds.NewService(cfg, fabric, store)
+	modelSvc := models.NewService(cfg, fabric, store, roundSvc)
 	whitelistSvc := whitelist.NewService(cfg, fabric)
-	convergenceSvc := convergence.NewService(cfg, fabric, store, whitelistSvc)
+	evaluationSvc := evaluations.NewService(cfg, fabric, store)
+	convergenceSvc := convergence.NewService(cfg, fabric, store, whitelistSvc, evaluationSvc)
+	selectionSvc := selection.NewService(cfg, whitelistSvc)
+	anchorSvc := anchoring.NewService(cfg, fabric)
+	didSvc := did.NewService(cfg, fabric, store)
+	nationSvc := nation.NewService(cfg, fabric, store)
+	verificationSvc := verification.NewService(cfg, fabric, store)
+	revocationSvc := revocation.NewService(cfg, fabric)
+	artifactSvc := artifacts.NewService(cfg, modelSvc)
+	jobSvc := jobs.NewService(cfg, fabric)
+	contributionSvc := contributions.NewService(cfg, fabric, store)
+	aggregationSvc := aggregations.NewService(cfg, fabric, store)
+	flagSvc := flags.NewService(cfg, fabric, store)
+	keySvc := keyexchange.NewService(cfg, fabric, store)
+	tokenSvc := tokens.NewService(cfg, store, tokenIssuer, sessions)
+	profileSvc := profile.NewService(cfg, fabric, store, roundSvc)
+	routingSvc := routing.NewService(cfg, fabric)
+	overviewSvc := overview.NewService(cfg, fabric, whitelistSvc, jobSvc, roundSvc, convergenceSvc, modelSvc)
+	exportSvc := export.NewService(cfg, fabric, roundSvc)
+	membershipSvc := membership.NewService(cfg, fabric, store)
+	passthroughSvc := passthrough.NewService(cfg, fabric)
+	consistencySvc := consistency.NewService(cfg, fabric, store, metrics)
+	snapshotSvc := snapshot.NewService(cfg, fabric)
+
+	queryCache := common.NewQueryCache(cfg, metrics)
+	whitelistSvc.EnableCache(queryCache)
+	jobSvc.EnableCache(queryCache)
+	regSvc.EnableCache(queryCache)
+	membershipSvc.EnableCache(queryCache)
+	payloads, err := storage.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize payload storage: %v", err)
+	}
+	modelSvc.EnableStorage(payloads)
+	dataSvc.EnableStorage(payloads)
+	envelopes, err := envelope.Open(cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize payload encryption: %v", err)
+	}
+	modelSvc.EnableEncryption(envelopes)
+	convergenceSvc.EnableEncryption(envelopes)
+
+	lifecycle := common.NewLifecycleBus()
+	regSvc.EnableEvents(lifecycle)
+	roundSvc.EnableEvents(lifecycle)
+	convergenceSvc.EnableEvents(lifecycle)
+	webhookSvc, err := webhooks.NewService(cfg)
+	if err != nil {
+		log.Fatalf("failed to load webhooks: %v", err)
+	}
+	lifecycle.Listen(webhookSvc.Notify)
+	bridgeSvc, err := bridge.NewService(cfg, fabric)
+	if err != nil {
+		log.Fatalf("failed to initialize event bridge: %v", err)
+	}
+	lifecycle.Listen(bridgeSvc.Notify)
+
+	payloadGuard, err := common.NewPayloadGuard(cfg, "/artifacts")
+	if err != nil {
+		log.Fatalf("failed to load payload schemas: %v", err)
+	}
+
+	auditSvc, err := audit.NewService(cfg, fabric)
+	if err != nil {
+		log.Fatalf("failed to open audit log: %v", err)
+	}
+
+	eventHub := events.NewHub(cfg, fabric)
+	idempotency := common.NewIdempotencyStore(cfg.IdempotencyTTL)
+	submissionTracker := submissions.NewTracker(cfg, "/artifacts", "/auth/")
+	auth.EnableAsync(submissionTracker)
+
+	go fabric.RunHealthChecks(context.Background())
+	if cfg.PeerDiscovery {
+		go fabric.RunTopologyDiscovery(context.Background())
+	}
 
-	if err := regSvc.SyncWhitelist(context.Background()); err != nil {
-		log.Fatalf("failed to sync trainer whitelist: %v", err)
+	// The ledger-dependent startup runs in the background so the server answers probes while
+	// the channel comes up; until it is ready other routes return 503.
+	var registryLoaded atomic.Bool
+	startup := common.NewStartup(cfg)
+	startupSteps := []common.StartupStep{
+		{Name: "channel", Run: func(context.Context) error { return fabric.ProbeChannels() }},
+		{Name: "registry", Run: func(ctx context.Context) error {
+			if cfg.TrainerStoreRehydrate {
+				restored, err := regSvc.RehydrateFromLedger(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to rehydrate trainer store from ledger: %w", err)
+				}
+				if restored > 0 {
+					log.Printf("restored %d trainer enrollment(s) from the on-chain whitelist", restored)
+				}
+			}
+			if err := regSvc.SyncWhitelist(ctx); err != nil {
+				return fmt.Errorf("failed to sync trainer whitelist: %w", err)
+			}
+			registryLoaded.Store(true)
+			return nil
+		}},
+	}
+
+	discoverySvc := discovery.NewService(cfg)
+	discoverySvc.RegisterModule("registry", true, "/auth/register-trainer", "/auth/register-trainers", "/auth/trainers/{did}", "/auth/trainers/{did}/updates", "/auth/me", "/admin/whitelist/{jwt_sub}", "/admin/whitelist/{jwt_sub}/deactivate", "/admin/whitelist/{jwt_sub}/reactivate", "/admin/whitelist/removed", "/admin/api-keys", "/admin/api-keys/{id}")
+	discoverySvc.RegisterModule("tokens", true, "/auth/challenge", "/auth/token", "/auth/refresh", "/auth/sessions", "/auth/sessions/{id}")
+	discoverySvc.RegisterModule("data", true, "/data/commit", "/data/{data_id}")
+	discoverySvc.RegisterModule("models", true, "/cluster/models", "/state/models", "/nation/models", "/{layer}/models/batch", "/models/{id}/lineage", "/models/{id}/history", "/models/by-hash/{hash}")
+	discoverySvc.RegisterModule("whitelist", true, "/whitelist", "/whitelist/capabilities", "/whitelist/hierarchy", "/whitelist/states/{id}", "/whitelist/clusters/{id}")
+	discoverySvc.RegisterModule("convergence", true, "/state/convergence", "/state/convergence/all", "/state/convergence/list", "/state/convergence/reset", "/state/convergence/history", "/state/convergence/disputes", "/state/convergence/disputes/{dispute_id}", "/state/convergence/disputes/{dispute_id}/resolve", "/state/convergence/dispute-window")
+	discoverySvc.RegisterModule("nation", true, "/nation/convergence", "/nation/convergence/all", "/nation/convergence/list", "/nation/convergence/reset", "/nation/convergence/history", "/nation/models", "/nation/aggregations", "/nation/aggregations/{round}", "/nation/states", "/nation/candidates", "/nation/candidates/{model_id}", "/nation/candidates/{model_id}/review", "/nation/global-models", "/nation/global-models/{version}")
+	discoverySvc.RegisterModule("verification", true, "/verification/pending", "/verification/states", "/verification/states/{state_id}")
+	discoverySvc.RegisterModule("selection", true, "/selection/rounds")
+	discoverySvc.RegisterModule("rounds", true, "/rounds/current", "/rounds/start", "/rounds/close")
+	discoverySvc.RegisterModule("keyexchange", true, "/rounds/{round}/keys")
+	discoverySvc.RegisterModule("evaluations", true, "/evaluations", "/evaluations/consensus", "/evaluations/runs")
+	discoverySvc.RegisterModule("artifacts", artifactSvc.Enabled(), "/artifacts", "/artifacts/{cid}")
+	discoverySvc.RegisterModule("jobs", true, "/job-contract/jobs", "/job-contract/jobs/{id}", "/job-contract/jobs/{id}/start", "/job-contract/jobs/{id}/complete", "/job-contract/jobs/{id}/archive", "/job-contract/training-config", "/job-contract/training-config/versions", "/job-contract/training-config/versions/{version}", "/job-contract/training-config/history")
+	discoverySvc.RegisterModule("contributions", true, "/contributions", "/contributions/{node_id}")
+	discoverySvc.RegisterModule("aggregations", true, "/aggregations", "/aggregations/{model_id}")
+	discoverySvc.RegisterModule("flags", true, "/flags", "/flags/threshold", "/flags/tallies", "/flags/tallies/{node_id}", "/flags/tallies/{node_id}/reinstate")
+	discoverySvc.RegisterModule("anchoring", anchorSvc.Enabled(), "/anchors")
+	discoverySvc.RegisterModule("revocation", true, "/revocations", "/revocations/{vc_hash}")
+	discoverySvc.RegisterModule("did", true, "/did-contract/dids", "/did-contract/dids/{did}")
+	discoverySvc.RegisterModule("events", true, "/state/convergence/stream", "/nation/convergence/stream")
+	discoverySvc.RegisterStream("/state/convergence/stream", "sse", "convergence")
+	discoverySvc.RegisterStream("/nation/convergence/stream", "sse", "convergence")
+	discoverySvc.RegisterModule("submissions", true, "/submissions", "/submissions/{id}")
+	discoverySvc.RegisterModule("routing", true, "/admin/routes")
+	discoverySvc.RegisterModule("audit", auditSvc.Enabled(), "/admin/audit")
+	discoverySvc.RegisterModule("cache", true, "/admin/cache", "/admin/cache/invalidate")
+	discoverySvc.RegisterModule("consistency", true, "/admin/consistency")
+	discoverySvc.RegisterModule("overview", true, "/admin/overview", "/state/{stateId}/overview")
+	discoverySvc.RegisterModule("export", true, "/admin/export/rounds/{jobId}")
+	discoverySvc.RegisterModule("snapshot", true, "/admin/snapshot")
+	discoverySvc.RegisterModule("webhooks", true, "/admin/webhooks", "/admin/webhooks/{id}", "/admin/webhooks/{id}/deliveries", "/admin/webhooks/{id}/test")
+	discoverySvc.RegisterModule("event-bridge", bridgeSvc.Enabled(), "/admin/event-bridge")
+	discoverySvc.RegisterModule("openapi", true, "/openapi.json", "/docs")
+
+	channelRouter := common.NewChannelRouter(cfg)
+	for module, channel := range cfg.ModuleChannels {
+		endpoints, ok := discoverySvc.Endpoints(module)
+		if !ok {
+			log.Fatalf("FABRIC_MODULE_CHANNELS: unknown module %s", module)
+		}
+		channelRouter.Pin(channel, endpoints...)
+	}
+
+	readiness := common.NewReadiness(cfg.ReadinessTimeout)
+	readiness.Add("startup", startup.Check)
+	readiness.Add("channel", fabric.CheckChannel)
+	readiness.Add("peers", fabric.CheckPeers)
+	readiness.Add("registry", func(context.Context) error {
+		if !registryLoaded.Load() {
+			return errors.New("trainer store not loaded")
+		}
+		return nil
+	})
+	if cfg.IPFSAPIURL != "" {
+		readiness.Add("ipfs", func(ctx context.Context) error {
+			return storage.PingIPFS(ctx, cfg.IPFSAPIURL)
+		})
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/health", healthHandler(cfg))
-	registry.NewHTTPHandler(regSvc).RegisterRoutes(mux, auth)
-	data.NewHTTPHandler(dataSvc, store).RegisterRoutes(mux, auth)
-	models.NewHTTPHandler(modelSvc, store).RegisterRoutes(mux, auth)
-	whitelist.NewHTTPHandler(whitelistSvc).RegisterRoutes(mux, auth)
-	convergence.NewHTTPHandler(convergenceSvc).RegisterRoutes(mux, auth)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "9000"
-	}
-	addr := fmt.Sprintf(":%s", port)
+	mux.HandleFunc("/health", healthHandler(cfg, fabric))
+	mux.HandleFunc("/healthz", livenessHandler(time.Now()))
+	mux.HandleFunc("/readyz", readiness.Handler())
+	mux.HandleFunc("/metrics", metrics.Handler())
+	mux.HandleFunc("/health/peers", peerHealthHandler(fabric))
+	discoveryHandler := discovery.NewHTTPHandler(discoverySvc)
+	discoveryHandler.RegisterRoutes(mux)
+
+	spec := openapi.NewSpec("Nebula API Gateway", discovery.APIVersion)
+	describeOperational(spec)
+	discoveryHandler.Describe(spec)
+	handlers := []apiHandler{
+		registry.NewHTTPHandler(regSvc),
+		tokens.NewHTTPHandler(tokenSvc),
+		profile.NewHTTPHandler(profileSvc),
+		data.NewHTTPHandler(dataSvc, store),
+		models.NewHTTPHandler(modelSvc, store, idempotency),
+		whitelist.NewHTTPHandler(whitelistSvc),
+		convergence.NewHTTPHandler(convergenceSvc, eventHub, idempotency),
+		selection.NewHTTPHandler(selectionSvc),
+		evaluations.NewHTTPHandler(evaluationSvc, store),
+		anchoring.NewHTTPHandler(anchorSvc),
+		rounds.NewHTTPHandler(roundSvc),
+		did.NewHTTPHandler(didSvc),
+		nation.NewHTTPHandler(nationSvc),
+		verification.NewHTTPHandler(verificationSvc),
+		revocation.NewHTTPHandler(revocationSvc),
+		artifacts.NewHTTPHandler(artifactSvc, store),
+		jobs.NewHTTPHandler(jobSvc),
+		contributions.NewHTTPHandler(contributionSvc),
+		aggregations.NewHTTPHandler(aggregationSvc),
+		flags.NewHTTPHandler(flagSvc),
+		keyexchange.NewHTTPHandler(keySvc),
+		submissions.NewHTTPHandler(submissionTracker, store),
+		routing.NewHTTPHandler(routingSvc),
+		overview.NewHTTPHandler(overviewSvc),
+		export.NewHTTPHandler(exportSvc),
+		membership.NewHTTPHandler(membershipSvc),
+		passthrough.NewHTTPHandler(passthroughSvc),
+		consistency.NewHTTPHandler(consistencySvc),
+		snapshot.NewHTTPHandler(snapshotSvc),
+		cache.NewHTTPHandler(queryCache),
+		audit.NewHTTPHandler(auditSvc),
+		webhooks.NewHTTPHandler(webhookSvc),
+		bridge.NewHTTPHandler(bridgeSvc),
+	}
+	for _, handler := range handlers {
+		handler.RegisterRoutes(mux, auth)
+		handler.Describe(spec)
+	}
+	mux.HandleFunc("/openapi.json", spec.Handler())
+	mux.HandleFunc("/docs", spec.DocsHandler("/openapi.json"))
+
+	handler := startup.Middleware(payloadGuard.Middleware(channelRouter.Middleware(mux)), "/health", "/readyz", "/metrics", "/openapi.json", "/docs", discovery.WellKnownPath)
+	if cfg.ResponseETags {
+		handler = common.ETagMiddleware(handler)
+	}
+	if cfg.ResponseCompression {
+		handler = common.NewCompressor(cfg).Middleware(handler)
+	}
+
+	addr := fmt.Sprintf(":%s", cfg.Port)
 	log.Printf("api gateway listening on %s", addr)
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      mux,
+		Handler:      common.NewCORS(cfg.CORS).Middleware(common.RequestIDMiddleware(tracer.Middleware(auditSvc.Middleware(handler)))),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	go func() {
+		if err := startup.Run(context.Background(), startupSteps...); err != nil {
+			return
+		}
+		go anchorSvc.Run(cfg.ModuleContext(context.Background(), "anchoring"))
+		go eventHub.Run(cfg.ModuleContext(context.Background(), "events"))
+		go auditSvc.Run(cfg.ModuleContext(context.Background(), "audit"))
+		go roundSvc.Run(cfg.ModuleContext(context.Background(), "rounds"))
+		go routingSvc.Watch(context.Background())
+		go consistencySvc.Run(cfg.ModuleContext(context.Background(), "consistency"))
+		go webhookSvc.Run(cfg.ModuleContext(context.Background(), "webhooks"))
+		go bridgeSvc.Run(cfg.ModuleContext(context.Background(), "event-bridge"))
+	}()
 	log.Fatal(srv.ListenAndServe())
 }
 
-func healthHandler(cfg *common.Config) http.HandlerFunc {
+// validateConfig prints the resolved configuration, or the reason it failed to load, and
+// returns the process exit code.
+func validateConfig(cfg *common.Config, loadErr error) int {
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", loadErr)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]any{
+		"config_file": common.ConfigFilePath(),
+		"sources":     common.ConfigSources(),
+		"config":      cfg.Resolved(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print configuration: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// apiHandler is implemented by every module's HTTP handler: it mounts its routes and
+// documents them in the OpenAPI spec.
+type apiHandler interface {
+	RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator)
+	Describe(spec *openapi.Spec)
+}
+
+// describeOperational documents the routes main mounts itself.
+func describeOperational(spec *openapi.Spec) {
+	api := spec.Tag("operations")
+	api.Add(http.MethodGet, "/health", openapi.Operation{Summary: "Report gateway liveness and configuration", Public: true, Response: map[string]any{"status": "", "chaincode": "", "default_peer": "", "job_id": ""}})
+	api.Add(http.MethodGet, "/healthz", openapi.Operation{Summary: "Report process liveness", Description: "Answers as long as the process serves HTTP; it checks no dependency, so a failing ledger never restarts the gateway.", Public: true, Response: map[string]any{"status": "", "uptime_seconds": 0}})
+	api.Add(http.MethodGet, "/readyz", openapi.Operation{Summary: "Report readiness with per-dependency checks", Description: "Checks the startup sequence, the channel, peer breakers, the trainer store and, when IPFS_API_URL is set, IPFS. Returns 503 while any check fails.", Public: true, Response: map[string]any{"status": "", "checks": []common.DependencyStatus{}}, Errors: []int{http.StatusServiceUnavailable}})
+	api.Add(http.MethodGet, "/health/peers", openapi.Operation{Summary: "Report peer circuit breaker states", Public: true, Response: map[string]any{"healthy": 0, "total": 0, "peers": []common.PeerStatus{}}, Errors: []int{http.StatusServiceUnavailable}})
+	api.Add(http.MethodGet, "/metrics", openapi.Operation{Summary: "Expose Prometheus metrics", Public: true, Response: "", Produces: "text/plain"})
+	api.Add(http.MethodGet, "/openapi.json", openapi.Operation{Summary: "Read this OpenAPI document", Public: true, Response: map[string]any{}})
+	api.Add(http.MethodGet, "/docs", openapi.Operation{Summary: "Browse the API with Swagger UI", Public: true, Response: "", Produces: "text/html"})
+}
+
+func peerHealthHandler(fabric *common.FabricClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		peers := fabric.PeerStatuses()
+		healthy := 0
+		for _, peer := range peers {
+			if peer.State == common.BreakerClosed {
+				healthy++
+			}
+		}
+		status := http.StatusOK
+		if healthy == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		common.WriteJSON(w, status, map[string]any{
+			"healthy": healthy,
+			"total":   len(peers),
+			"peers":   peers,
+		})
+	}
+}
+
+// livenessHandler serves /healthz. It only proves the process is serving requests; dependency
+// failures belong in /readyz so they take the pod out of rotation instead of restarting it.
+func livenessHandler(started time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		common.WriteJSON(w, http.StatusOK, map[string]any{
+			"status":         "ok",
+			"uptime_seconds": int64(time.Since(started).Seconds()),
+		})
+	}
+}
+
+func healthHandler(cfg *common.Config, fabric *common.FabricClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		common.WriteJSON(w, http.StatusOK, map[string]any{
 			"status":       "ok",
 			"chaincode":    cfg.Chaincode,
-			"default_peer": cfg.DefaultPeer,
+			"default_peer": fabric.Routing().DefaultPeer,
 			"job_id":       cfg.JobID,
 		})
 	}
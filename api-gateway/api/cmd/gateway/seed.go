@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/envelope"
+	"github.com/nebula/api-gateway/internal/jobs"
+	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/registry"
+	"github.com/nebula/api-gateway/internal/rounds"
+	"github.com/nebula/api-gateway/internal/seed"
+	"github.com/nebula/api-gateway/internal/storage"
+)
+
+// isSeed reports whether the command line asks to seed a demo environment
+// (`gateway seed ...`).
+func isSeed(args []string) bool {
+	return len(args) > 0 && (args[0] == "seed" || args[0] == "-seed" || args[0] == "--seed")
+}
+
+// runSeed applies a YAML scenario to the configured network and trainer store through the
+// gateway's services. It returns the process exit code.
+func runSeed(args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or JSON configuration file; environment variables override its settings")
+	adminKey := fs.String("admin-key", "", "admin Ed25519 private key (PEM) that signs the trainers' credentials")
+	validity := fs.Duration("credential-validity", 365*24*time.Hour, "how long the trainers' credentials stay valid")
+	keysDir := fs.String("keys-dir", "", "directory to write the private keys derived from key_seed to, one <subject>.pem each")
+	dryRun := fs.Bool("dry-run", false, "only check the scenario")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gateway seed [flags] scenario.yaml")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	cfg, err := common.LoadConfigFrom(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 2
+	}
+	scenario, err := seed.Load(fs.Arg(0), cfg.JobID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid scenario: %v\n", err)
+		return 2
+	}
+	if *keysDir != "" {
+		if err := writeTrainerKeys(*keysDir, scenario); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write trainer keys: %v\n", err)
+			return 1
+		}
+	}
+	if *dryRun {
+		fmt.Printf("scenario ok: %d state(s), %d trainer(s)\n", len(scenario.States), len(scenario.Trainers))
+		return 0
+	}
+	if *adminKey == "" && len(scenario.Trainers) > 0 {
+		fmt.Fprintln(os.Stderr, "-admin-key is required to enroll trainers")
+		return 2
+	}
+	var key ed25519.PrivateKey
+	if *adminKey != "" {
+		if key, err = loadEd25519PrivateKey(*adminKey); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load admin key: %v\n", err)
+			return 2
+		}
+	}
+
+	metrics := common.NewMetrics()
+	fabric := common.NewFabricClient(cfg, common.NewTracer(cfg), metrics)
+	wallet, err := common.OpenWallet(cfg)
+	if err == nil {
+		err = fabric.UseWallet(wallet)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize wallet: %v\n", err)
+		return 1
+	}
+	store, err := registry.NewStore(cfg.TrainerStore, cfg.TrainerDBPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize trainer store: %v\n", err)
+		return 1
+	}
+	verifier, err := registry.NewVCVerifier(cfg.AdminPublicKey, cfg.JobID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize VC verifier: %v\n", err)
+		return 1
+	}
+	regSvc := registry.NewService(cfg, fabric, store, verifier)
+	if cfg.FabricCAURL != "" {
+		ca, err := registry.NewCAClient(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to initialize fabric CA client: %v\n", err)
+			return 1
+		}
+		regSvc.EnableEnrollment(ca, wallet)
+	}
+	modelSvc := models.NewService(cfg, fabric, store, rounds.NewService(cfg, fabric, store))
+	payloads, err := storage.Open(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize payload storage: %v\n", err)
+		return 1
+	}
+	modelSvc.EnableStorage(payloads)
+	envelopes, err := envelope.Open(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize payload encryption: %v\n", err)
+		return 1
+	}
+	modelSvc.EnableEncryption(envelopes)
+	seeder := seed.NewSeeder(cfg, store, regSvc, jobs.NewService(cfg, fabric), modelSvc, key, *validity)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = seeder.Run(ctx, scenario, func(step *seed.Step) {
+		line := fmt.Sprintf("%-16s %-32s %s", step.Kind, step.Subject, step.Outcome)
+		if step.Detail != "" {
+			line += " (" + step.Detail + ")"
+		}
+		fmt.Println(line)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeTrainerKeys saves the keys derived from the scenario's key_seed as PKCS#8 PEM files.
+func writeTrainerKeys(dir string, scenario *seed.Scenario) error {
+	keys := scenario.PrivateKeys()
+	subjects := make([]string, 0, len(keys))
+	for subject := range keys {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		der, err := x509.MarshalPKCS8PrivateKey(keys[subject])
+		if err != nil {
+			return err
+		}
+		if filepath.Base(subject) != subject {
+			return fmt.Errorf("subject %q cannot name a key file", subject)
+		}
+		path := filepath.Join(dir, subject+".pem")
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
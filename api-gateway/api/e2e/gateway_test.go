@@ -0,0 +1,227 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// gateway is an HTTP client for the gateway under test that can mint every token the flow
+// needs: HS256 tokens from the shared secret and EdDSA runtime tokens from trainer keys.
+type gateway struct {
+	url        string
+	authSecret string
+	adminKey   ed25519.PrivateKey
+	client     *http.Client
+	// provision, when set, creates the MSP of a Fabric identity label before registration.
+	provision func(label string, index int) error
+
+	mu         sync.Mutex
+	identities int
+}
+
+// actor is a registered node acting in one role.
+type actor struct {
+	Subject string
+	Role    common.Role
+	State   string
+	Cluster string
+	key     ed25519.PrivateKey
+}
+
+func newActor(t *testing.T, subject string, role common.Role, state, cluster string) *actor {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &actor{Subject: subject, Role: role, State: state, Cluster: cluster, key: key}
+}
+
+// adminActor is the operator; it is never registered and only uses HS256 tokens.
+func adminActor() *actor {
+	return &actor{Subject: "admin", Role: common.RoleAdmin, State: "nation"}
+}
+
+// waitReady polls /readyz until the gateway reports every dependency ready.
+func (g *gateway) waitReady(ctx context.Context) error {
+	var last string
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.url+"/readyz", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := g.client.Do(req)
+		if err == nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			last = fmt.Sprintf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+		} else {
+			last = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gateway at %s not ready (%s): %w", g.url, last, ctx.Err())
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// register enrolls a through /auth/register-trainer with a credential signed by the admin
+// key, as an operator would with vctool.
+func (g *gateway) register(t *testing.T, a *actor) {
+	t.Helper()
+	if g.provision != nil {
+		g.mu.Lock()
+		index := g.identities
+		g.identities++
+		g.mu.Unlock()
+		if err := g.provision(a.Subject, index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC()
+	did := "did:nebula:" + a.Subject
+	credential := map[string]any{
+		"subject":     did,
+		"valid_from":  now.Add(-time.Minute).Format(time.RFC3339),
+		"valid_until": now.Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	unsigned, err := registry.Canonicalize(credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential["signature"] = base64.StdEncoding.EncodeToString(ed25519.Sign(g.adminKey, unsigned))
+	signed, err := registry.Canonicalize(credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.do(t, http.MethodPost, "/auth/register-trainer", g.sharedToken(t, a), map[string]any{
+		"did":        did,
+		"nodeId":     a.Subject,
+		"vc":         json.RawMessage(signed),
+		"public_key": base64.StdEncoding.EncodeToString(a.key.Public().(ed25519.PublicKey)),
+		"state":      a.State,
+		"cluster":    a.Cluster,
+	}, http.StatusOK, nil)
+}
+
+// sharedToken returns an HS256 token for a, accepted by every endpoint but the model APIs.
+func (g *gateway) sharedToken(t *testing.T, a *actor) string {
+	t.Helper()
+	return signToken(t, "HS256", a, func(unsigned []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(g.authSecret))
+		mac.Write(unsigned)
+		return mac.Sum(nil)
+	})
+}
+
+// runtimeToken returns an EdDSA token signed with a's registered key, for the model APIs.
+func (g *gateway) runtimeToken(t *testing.T, a *actor) string {
+	t.Helper()
+	return signToken(t, "EdDSA", a, func(unsigned []byte) []byte {
+		return ed25519.Sign(a.key, unsigned)
+	})
+}
+
+func signToken(t *testing.T, alg string, a *actor, sign func([]byte) []byte) string {
+	t.Helper()
+	header, err := json.Marshal(&common.TokenHeader{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := json.Marshal(&common.JWTClaims{
+		Subject: a.Subject,
+		State:   a.State,
+		Cluster: a.Cluster,
+		Role:    string(a.Role),
+		Expiry:  json.Number(strconv.FormatInt(time.Now().Add(30*time.Minute).Unix(), 10)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(unsigned)))
+}
+
+// do sends a JSON request and fails the test unless the response has status want. A non-nil
+// out receives the decoded response body.
+func (g *gateway) do(t *testing.T, method, path, token string, body any, want int, out any) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader([]byte(common.MustJSON(body)))
+	}
+	req, err := http.NewRequest(method, g.url+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, want, bytes.TrimSpace(payload))
+	}
+	if out != nil {
+		if err := json.Unmarshal(payload, out); err != nil {
+			t.Fatalf("%s %s: decode response: %v: %s", method, path, err, payload)
+		}
+	}
+}
+
+// reject sends a JSON request and fails the test unless the gateway refuses it with an error
+// whose message contains want.
+func (g *gateway) reject(t *testing.T, method, path, token string, body any, want string) {
+	t.Helper()
+	req, err := http.NewRequest(method, g.url+path, strings.NewReader(common.MustJSON(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var failure struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+		t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	if resp.StatusCode < http.StatusBadRequest || !strings.Contains(failure.Message, want) {
+		t.Fatalf("%s %s: status %d %q, want an error containing %q", method, path, resp.StatusCode, failure.Message, want)
+	}
+}
@@ -0,0 +1,133 @@
+//go:build e2e
+
+// Package e2e runs the cross-layer training flow against a real gateway. By default TestMain
+// boots the docker-compose stack of api-gateway/ (orderer, three peers, the bootstrap CLI and
+// the gateway) from a scratch copy, with fresh crypto material, and tears it down afterwards:
+//
+//	cd api-gateway/api && go test -tags=e2e -v -timeout 30m ./e2e
+//
+// The stack uses the fixed container names and ports of docker-compose.yaml, so stop a
+// development stack first. Environment variables:
+//
+//	E2E_GATEWAY_URL  use a gateway that is already running instead of booting one; its
+//	                 E2E_AUTH_SECRET (AUTH_JWT_SECRET) and E2E_ADMIN_KEY (path of the admin
+//	                 Ed25519 key PEM) are then required, and every registered node ID
+//	                 needs an MSP under the gateway's users/ folder (or FABRIC_CA_URL)
+//	E2E_KEEP=1       leave the booted stack running
+//	E2E_LOGS=1       print the service logs before tearing down, even when tests pass
+//	E2E_BOOT_TIMEOUT how long to wait for the stack to become ready (default 15m)
+package e2e
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gw is the gateway under test, set by TestMain.
+var gw *gateway
+
+func TestMain(m *testing.M) {
+	os.Exit(runMain(m))
+}
+
+func runMain(m *testing.M) int {
+	if url := strings.TrimSpace(os.Getenv("E2E_GATEWAY_URL")); url != "" {
+		existing, err := existingGateway(url)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+			return 2
+		}
+		gw = existing
+		return m.Run()
+	}
+
+	timeout := 15 * time.Minute
+	if raw := os.Getenv("E2E_BOOT_TIMEOUT"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: invalid E2E_BOOT_TIMEOUT: %v\n", err)
+			return 2
+		}
+		timeout = parsed
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+		return 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	network, booted, err := startNetwork(ctx, root)
+	code := 1
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: failed to start the network: %v\n", err)
+	} else {
+		gw = booted
+		code = m.Run()
+	}
+	if network != nil {
+		if os.Getenv("E2E_KEEP") == "1" && err == nil {
+			fmt.Fprintf(os.Stderr, "e2e: leaving compose project %s running from %s\n", composeProject, network.dir)
+		} else {
+			network.stop(code != 0 || os.Getenv("E2E_LOGS") == "1")
+		}
+	}
+	return code
+}
+
+func existingGateway(url string) (*gateway, error) {
+	secret := os.Getenv("E2E_AUTH_SECRET")
+	if secret == "" {
+		return nil, errors.New("E2E_AUTH_SECRET is required with E2E_GATEWAY_URL")
+	}
+	keyPath := os.Getenv("E2E_ADMIN_KEY")
+	if keyPath == "" {
+		return nil, errors.New("E2E_ADMIN_KEY is required with E2E_GATEWAY_URL")
+	}
+	key, err := loadAdminKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	g := &gateway{
+		url:        strings.TrimRight(url, "/"),
+		authSecret: secret,
+		adminKey:   key,
+		client:     &http.Client{Timeout: time.Minute},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := g.waitReady(ctx); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func loadAdminKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key in %s is not an Ed25519 private key", path)
+	}
+	return priv, nil
+}
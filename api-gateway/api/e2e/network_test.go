@@ -0,0 +1,194 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	composeProject = "nebula-e2e"
+	toolsImage     = "hyperledger/fabric-tools:2.5"
+	gatewayURL     = "http://localhost:9000"
+	// usersPath is where the gateway looks up the MSP of each Fabric identity label.
+	usersPath = "organizations/peerOrganizations/org1.nebula.com/users"
+)
+
+// stackFiles are the parts of api-gateway/ that docker-compose.yaml mounts or builds.
+var stackFiles = []string{"docker-compose.yaml", "configtx", "scripts", "chaincode", "api"}
+
+// network is a compose stack booted from a scratch copy of api-gateway/, so the generated
+// crypto material and ledger never touch the working tree.
+type network struct {
+	dir   string
+	env   []string
+	users int
+}
+
+// startNetwork generates crypto material and channel artifacts, brings the compose stack up
+// and waits until the gateway reports ready.
+func startNetwork(ctx context.Context, root string) (*network, *gateway, error) {
+	dir, err := os.MkdirTemp("", "nebula-e2e-")
+	if err != nil {
+		return nil, nil, err
+	}
+	n := &network{dir: dir, users: 8}
+	for _, name := range stackFiles {
+		if err := copyTree(filepath.Join(root, name), filepath.Join(dir, name)); err != nil {
+			return n, nil, fmt.Errorf("copy %s: %w", name, err)
+		}
+	}
+	if err := copyTree(filepath.Join("testdata", "crypto-config.yaml"), filepath.Join(dir, "crypto-config.yaml")); err != nil {
+		return n, nil, err
+	}
+	if err := n.generateArtifacts(ctx); err != nil {
+		return n, nil, fmt.Errorf("generate artifacts: %w", err)
+	}
+
+	_, adminKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return n, nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return n, nil, err
+	}
+	gw := &gateway{
+		url:        gatewayURL,
+		authSecret: hex.EncodeToString(secret),
+		adminKey:   adminKey,
+		client:     &http.Client{Timeout: time.Minute},
+		provision:  n.provisionIdentity,
+	}
+	n.env = append(os.Environ(),
+		"AUTH_JWT_SECRET="+gw.authSecret,
+		"ADMIN_PUBLIC_KEY="+base64.StdEncoding.EncodeToString(adminKey.Public().(ed25519.PublicKey)),
+		"GATEWAY_JOB_ID=",
+	)
+	if err := n.compose(ctx, "up", "-d", "--build"); err != nil {
+		return n, nil, err
+	}
+	if err := gw.waitReady(ctx); err != nil {
+		return n, nil, err
+	}
+	return n, gw, nil
+}
+
+// generateArtifacts runs cryptogen and configtxgen in the Fabric tools image, as the quick
+// start does on the host.
+func (n *network) generateArtifacts(ctx context.Context) error {
+	script := strings.Join([]string{
+		"cryptogen generate --config=crypto-config.yaml --output=organizations",
+		"mkdir -p system-genesis-block channel-artifacts",
+		"configtxgen -profile NebulaGenesis -channelID system-channel -outputBlock system-genesis-block/genesis.block",
+		"configtxgen -profile NebulaChannel -channelID nebulachannel -outputCreateChannelTx channel-artifacts/nebula-channel.tx",
+		"configtxgen -profile NebulaChannel -channelID nebulachannel -asOrg Org1MSP -outputAnchorPeersUpdate channel-artifacts/Org1MSPanchors.tx",
+	}, " && ")
+	return run(ctx, "", nil, "docker", "run", "--rm",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"-v", n.dir+":/work", "-w", "/work", "-e", "FABRIC_CFG_PATH=/work/configtx",
+		toolsImage, "sh", "-c", script)
+}
+
+// provisionIdentity gives the Fabric identity label its own MSP: the n-th call copies
+// User<n>@org1.nebula.com, so every registered trainer has a distinct client ID on-chain.
+func (n *network) provisionIdentity(label string, index int) error {
+	if index >= n.users {
+		return fmt.Errorf("the e2e network has %d user identities; %s needs more", n.users, label)
+	}
+	users := filepath.Join(n.dir, usersPath)
+	source := filepath.Join(users, fmt.Sprintf("User%d@org1.nebula.com", index+1), "msp")
+	return copyTree(source, filepath.Join(users, label, "msp"))
+}
+
+func (n *network) compose(ctx context.Context, args ...string) error {
+	return run(ctx, n.dir, n.env, "docker", append([]string{"compose", "-p", composeProject}, args...)...)
+}
+
+// stop prints the service logs when asked and tears the stack down, including the chaincode
+// containers the peers launched and the files containers wrote as root.
+func (n *network) stop(logs bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if logs {
+		_ = n.compose(ctx, "logs", "--no-color", "--tail", "200")
+	}
+	_ = n.compose(ctx, "down", "-v", "--remove-orphans")
+	var ids bytes.Buffer
+	list := exec.CommandContext(ctx, "docker", "ps", "-aq", "--filter", "name=dev-peer")
+	list.Stdout = &ids
+	if list.Run() == nil && len(bytes.TrimSpace(ids.Bytes())) > 0 {
+		_ = run(ctx, "", nil, "docker", append([]string{"rm", "-f"}, strings.Fields(ids.String())...)...)
+	}
+	_ = run(ctx, "", nil, "docker", "run", "--rm", "-v", n.dir+":/work", toolsImage, "sh", "-c", "rm -rf /work/*")
+	_ = os.RemoveAll(n.dir)
+}
+
+// run executes a command with its output on stderr, so progress shows under go test -v.
+func run(ctx context.Context, dir string, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// copyTree copies a file or directory, skipping the markers a previous bootstrap left behind.
+func copyTree(source, target string) error {
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if name == ".bootstrap-ready" || strings.HasSuffix(name, ".tar.gz") || strings.HasPrefix(name, ".gateway_hash") {
+			return nil
+		}
+		destination := filepath.Join(target, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(destination, info.Mode().Perm()|0o700)
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,204 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/convergence"
+	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/whitelist"
+)
+
+// TestFullRound drives one training round through every layer: trainers commit cluster models,
+// cluster aggregators commit aggregates and report cluster convergence, the state aggregator
+// commits a state model and reports to the nation, and the central checker declares the state
+// and the nation converged. It then reads the ledger back through the API.
+//
+// Every run uses fresh node, cluster and state IDs and its own convergence job, so it can be
+// repeated against a long-lived gateway.
+func TestFullRound(t *testing.T) {
+	run := strconv.FormatInt(time.Now().UnixNano(), 36)
+	state := "e2e-" + run + "-state"
+	clusters := []string{"e2e-" + run + "-c1", "e2e-" + run + "-c2"}
+	job := "e2e-" + run
+
+	node := func(name string) string { return "e2e-" + run + "-" + name }
+	trainers := []*actor{
+		newActor(t, node("t1"), common.RoleTrainer, state, clusters[0]),
+		newActor(t, node("t2"), common.RoleTrainer, state, clusters[0]),
+		newActor(t, node("t3"), common.RoleTrainer, state, clusters[1]),
+	}
+	aggregators := []*actor{
+		newActor(t, node("agg1"), common.RoleAggregator, state, clusters[0]),
+		newActor(t, node("agg2"), common.RoleAggregator, state, clusters[1]),
+	}
+	stateAggregator := newActor(t, node("sagg"), common.RoleAggregator, state, "")
+	checker := newActor(t, node("checker"), common.RoleCentralChecker, state, "")
+	everyone := append(append(append([]*actor{}, trainers...), aggregators...), stateAggregator, checker)
+
+	for _, a := range everyone {
+		gw.register(t, a)
+	}
+
+	// Trainers commit their local models.
+	clusterModels := map[string][]string{}
+	for i, trainer := range trainers {
+		var result models.CommitResult
+		gw.do(t, http.MethodPost, "/cluster/models", gw.runtimeToken(t, trainer), map[string]any{
+			"cluster_id": trainer.Cluster,
+			"payload":    map[string]any{"artifact": "ipfs://" + trainer.Subject, "epoch": 1},
+			"metrics":    map[string]float64{"accuracy": 0.8 + float64(i)/100, "loss": 0.4, "num_samples": 1000},
+		}, http.StatusCreated, &result)
+		if result.NodeID != trainer.Subject || result.ScopeID != trainer.Cluster || result.TxID == "" {
+			t.Fatalf("unexpected commit result for %s: %+v", trainer.Subject, result)
+		}
+		clusterModels[trainer.Cluster] = append(clusterModels[trainer.Cluster], result.DataID)
+	}
+
+	// Cluster aggregators commit the aggregate of their cluster and report convergence.
+	var aggregates []string
+	for _, aggregator := range aggregators {
+		var result models.CommitResult
+		gw.do(t, http.MethodPost, "/cluster/models", gw.runtimeToken(t, aggregator), map[string]any{
+			"cluster_id":       aggregator.Cluster,
+			"payload":          map[string]any{"artifact": "ipfs://" + aggregator.Subject},
+			"parent_model_ids": clusterModels[aggregator.Cluster],
+		}, http.StatusCreated, &result)
+		aggregates = append(aggregates, result.DataID)
+		gw.do(t, http.MethodPost, "/state/convergence", gw.sharedToken(t, aggregator), &convergence.CommitRequest{
+			StateID:   state,
+			ClusterID: aggregator.Cluster,
+			JobID:     job,
+			Round:     1,
+			Payload:   map[string]any{"model_id": result.DataID},
+		}, http.StatusCreated, nil)
+	}
+
+	// The state aggregator rolls the clusters up and reports to the nation.
+	var stateModel models.CommitResult
+	gw.do(t, http.MethodPost, "/state/models", gw.runtimeToken(t, stateAggregator), map[string]any{
+		"state_id":         state,
+		"payload":          map[string]any{"artifact": "ipfs://" + stateAggregator.Subject},
+		"parent_model_ids": aggregates,
+	}, http.StatusCreated, &stateModel)
+	gw.do(t, http.MethodPost, "/nation/convergence", gw.sharedToken(t, stateAggregator), &convergence.CommitRequest{
+		StateID: state,
+		JobID:   job,
+		Round:   1,
+		Payload: map[string]any{"model_id": stateModel.DataID},
+	}, http.StatusCreated, nil)
+
+	// The central checker declares the state and then the nation converged; declarations win once.
+	declareState := &convergence.DeclareRequest{StateID: state, JobID: job, Round: 1, Payload: map[string]any{"notes": "all clusters reported"}}
+	gw.do(t, http.MethodPost, "/state/convergence/all", gw.sharedToken(t, checker), declareState, http.StatusCreated, nil)
+	gw.reject(t, http.MethodPost, "/state/convergence/all", gw.sharedToken(t, checker), declareState, "already declared converged")
+	gw.do(t, http.MethodPost, "/nation/convergence/all", gw.sharedToken(t, checker), &convergence.DeclareRequest{
+		JobID:   job,
+		Round:   1,
+		Payload: map[string]any{"notes": "all states reported"},
+	}, http.StatusCreated, nil)
+
+	t.Run("whitelist", func(t *testing.T) {
+		registered := map[string]string{}
+		admin := gw.sharedToken(t, adminActor())
+		for page := 1; ; page++ {
+			var result whitelist.HierarchyResult
+			gw.do(t, http.MethodGet, "/whitelist?per_page=100&page="+strconv.Itoa(page), admin, nil, http.StatusOK, &result)
+			for _, state := range result.States {
+				for _, cluster := range state.Clusters {
+					for _, entry := range cluster.Nodes {
+						registered[entry.NodeID] = entry.Cluster
+					}
+				}
+			}
+			if !result.HasMore {
+				break
+			}
+		}
+		for _, a := range everyone {
+			cluster, ok := registered[a.Subject]
+			if !ok || cluster != a.Cluster {
+				t.Errorf("whitelist entry of %s: found %v, cluster %q; want cluster %q", a.Subject, ok, cluster, a.Cluster)
+			}
+		}
+	})
+
+	t.Run("cluster models", func(t *testing.T) {
+		var result models.ListResult
+		gw.do(t, http.MethodGet, "/cluster/models?scope_id="+url.QueryEscape(clusters[0]), gw.runtimeToken(t, trainers[0]), nil, http.StatusOK, &result)
+		want := append(append([]string{}, clusterModels[clusters[0]]...), aggregates[0])
+		var got []string
+		for _, item := range result.Items {
+			got = append(got, item.DataID)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if result.Total != len(want) || !equal(got, want) {
+			t.Fatalf("cluster %s lists %v (total %d), want %v", clusters[0], got, result.Total, want)
+		}
+	})
+
+	t.Run("state model lineage", func(t *testing.T) {
+		var record models.ModelRecord
+		gw.do(t, http.MethodGet, "/state/models/"+stateModel.DataID, gw.runtimeToken(t, stateAggregator), nil, http.StatusOK, &record)
+		if record.ScopeID != state || record.Owner != stateAggregator.Subject || !equal(record.ParentModelIDs, aggregates) {
+			t.Fatalf("unexpected state model: %+v", record)
+		}
+	})
+
+	t.Run("state convergence", func(t *testing.T) {
+		var status convergence.StateStatus
+		query := url.Values{"stateId": {state}, "job_id": {job}, "round": {"1"}}
+		gw.do(t, http.MethodGet, "/state/convergence?"+query.Encode(), gw.sharedToken(t, checker), nil, http.StatusOK, &status)
+		if !status.IsConverged || status.DeclaredBy != checker.Subject || status.JobID != job || status.Round != 1 {
+			t.Fatalf("unexpected state convergence: %+v", status)
+		}
+		if len(status.Clusters) != len(clusters) {
+			t.Fatalf("state convergence has %d clusters, want %d", len(status.Clusters), len(clusters))
+		}
+		for _, cluster := range status.Clusters {
+			if !cluster.IsConverged {
+				t.Errorf("cluster %s is not converged", cluster.ClusterID)
+			}
+		}
+
+		// Other rounds of the job stay empty.
+		var other convergence.StateStatus
+		query.Set("round", "2")
+		gw.do(t, http.MethodGet, "/state/convergence?"+query.Encode(), gw.sharedToken(t, checker), nil, http.StatusOK, &other)
+		if other.IsConverged || len(other.Clusters) != 0 {
+			t.Fatalf("round 2 of %s is not empty: %+v", job, other)
+		}
+	})
+
+	t.Run("nation convergence", func(t *testing.T) {
+		var status convergence.NationStatus
+		query := url.Values{"job_id": {job}, "round": {"1"}}
+		gw.do(t, http.MethodGet, "/nation/convergence?"+query.Encode(), gw.sharedToken(t, checker), nil, http.StatusOK, &status)
+		if !status.IsConverged || status.DeclaredBy != checker.Subject {
+			t.Fatalf("unexpected nation convergence: %+v", status)
+		}
+		if len(status.States) != 1 || status.States[0].StateID != state || status.States[0].SourceID != stateAggregator.Subject {
+			t.Fatalf("unexpected nation states: %+v", status.States)
+		}
+	})
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
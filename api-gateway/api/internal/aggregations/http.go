@@ -0,0 +1,226 @@
+package aggregations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the `/aggregations` endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the aggregations HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/aggregations` (record and list), `/aggregations/{modelId}` (the
+// aggregation that produced a model) and the `/aggregations/lease` endpoints.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	mux.Handle("/aggregations", auth.RequireAuth(http.HandlerFunc(h.handleCollection), readers...))
+	mux.Handle("/aggregations/", auth.RequireAuth(http.HandlerFunc(h.handleModel), readers...))
+	mux.Handle("/aggregations/lease", auth.RequireAuth(http.HandlerFunc(h.handleLease), readers...))
+	mux.Handle("/aggregations/lease/renew", auth.RequireAuth(http.HandlerFunc(h.handleLeaseRenew), common.RoleAggregator))
+	mux.Handle("/aggregations/lease/release", auth.RequireAuth(http.HandlerFunc(h.handleLeaseRelease), common.RoleAggregator))
+}
+
+// Describe documents the aggregation endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("aggregations")
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	api.Add(http.MethodPost, "/aggregations", openapi.Operation{
+		Summary:     "Record how an aggregated model was produced",
+		Description: "The output model must already be committed by the caller to layer/scope_id; if it names parent_model_ids they must be exactly the input models. Each model's aggregation can be recorded once.",
+		Roles:       []common.Role{common.RoleAggregator},
+		Body:        RecordRequest{},
+		Response:    Aggregation{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/aggregations", openapi.Operation{
+		Summary: "List recorded aggregations",
+		Roles:   readers,
+		Query: []openapi.Param{
+			{Name: "job_id", Description: "Defaults to GATEWAY_JOB_ID."},
+			{Name: "layer"},
+			{Name: "scope_id", Description: "Requires layer."},
+			{Name: "round", Type: "integer", Description: "Requires layer and scope_id."},
+		},
+		Response: map[string]any{"items": []*Aggregation{}},
+	})
+	api.Add(http.MethodGet, "/aggregations/{model_id}", openapi.Operation{Summary: "Read the aggregation that produced a model", Roles: readers, Response: Aggregation{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/aggregations/lease", openapi.Operation{
+		Summary:     "Acquire the aggregation lease of a round",
+		Description: "Grants the caller the exclusive right to aggregate the round for ttl_seconds (default 300, at most 3600). While the lease is active other aggregators cannot acquire it or record an aggregation for the round. The holder acquiring again extends the lease.",
+		Roles:       []common.Role{common.RoleAggregator},
+		Body:        LeaseRequest{},
+		Response:    Lease{},
+		Errors:      []int{http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/aggregations/lease", openapi.Operation{
+		Summary: "Read the latest aggregation lease of a round",
+		Roles:   readers,
+		Query: []openapi.Param{
+			{Name: "job_id", Description: "Defaults to GATEWAY_JOB_ID."},
+			{Name: "scope_id", Required: true},
+			{Name: "round", Type: "integer", Required: true},
+		},
+		Response: Lease{},
+		Errors:   []int{http.StatusNotFound},
+	})
+	api.Add(http.MethodPost, "/aggregations/lease/renew", openapi.Operation{Summary: "Extend the caller's aggregation lease by ttl_seconds from now", Roles: []common.Role{common.RoleAggregator}, Body: LeaseRequest{}, Response: Lease{}, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodPost, "/aggregations/lease/release", openapi.Operation{Summary: "Release the caller's aggregation lease", Roles: []common.Role{common.RoleAggregator}, Body: LeaseRequest{}, Response: Lease{}, Errors: []int{http.StatusConflict}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can record aggregations"))
+			return
+		}
+		var req RecordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Record(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	case http.MethodGet:
+		query := r.URL.Query()
+		filter := ListFilter{
+			JobID:   query.Get("job_id"),
+			Layer:   query.Get("layer"),
+			ScopeID: query.Get("scope_id"),
+		}
+		if raw := strings.TrimSpace(query.Get("round")); raw != "" {
+			round, err := strconv.Atoi(raw)
+			if err != nil || round < 1 {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+				return
+			}
+			filter.Round = round
+		}
+		records, err := h.svc.List(r.Context(), authCtx, filter)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": records})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleModel serves `/aggregations/{modelId}`.
+func (h *HTTPHandler) handleModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	record, err := h.svc.Get(r.Context(), authCtx, strings.TrimPrefix(r.URL.Path, "/aggregations/"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, record)
+}
+
+// handleLease serves `/aggregations/lease`: POST acquires, GET reads.
+func (h *HTTPHandler) handleLease(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can acquire aggregation leases"))
+			return
+		}
+		h.handleLeaseTransition(w, r, authCtx, h.svc.AcquireLease)
+	case http.MethodGet:
+		query := r.URL.Query()
+		round, err := strconv.Atoi(strings.TrimSpace(query.Get("round")))
+		if err != nil || round < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+			return
+		}
+		lease, err := h.svc.GetLease(r.Context(), authCtx, query.Get("job_id"), query.Get("scope_id"), round)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, lease)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleLeaseRenew(w http.ResponseWriter, r *http.Request) {
+	h.handleLeasePost(w, r, h.svc.RenewLease)
+}
+
+func (h *HTTPHandler) handleLeaseRelease(w http.ResponseWriter, r *http.Request) {
+	h.handleLeasePost(w, r, h.svc.ReleaseLease)
+}
+
+func (h *HTTPHandler) handleLeasePost(w http.ResponseWriter, r *http.Request, apply leaseTransition) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	h.handleLeaseTransition(w, r, authCtx, apply)
+}
+
+type leaseTransition func(ctx context.Context, authCtx *common.AuthContext, req *LeaseRequest) (*Lease, error)
+
+func (h *HTTPHandler) handleLeaseTransition(w http.ResponseWriter, r *http.Request, authCtx *common.AuthContext, apply leaseTransition) {
+	var req LeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	lease, err := apply(r.Context(), authCtx, &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, lease)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,118 @@
+package aggregations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// DefaultLeaseTTL is the lease duration when a request does not name one.
+const DefaultLeaseTTL = 5 * time.Minute
+
+// Lease mirrors the on-chain AggregationLease.
+type Lease struct {
+	JobID      string `json:"job_id"`
+	ScopeID    string `json:"scope_id"`
+	Round      int    `json:"round"`
+	Holder     string `json:"holder"`
+	AcquiredAt string `json:"acquired_at"`
+	RenewedAt  string `json:"renewed_at,omitempty"`
+	ExpiresAt  string `json:"expires_at"`
+	ReleasedAt string `json:"released_at,omitempty"`
+	TxID       string `json:"tx_id"`
+	Active     bool   `json:"active"`
+}
+
+// LeaseRequest names the round a lease covers. An empty JobID selects GATEWAY_JOB_ID and a
+// zero TTLSeconds DefaultLeaseTTL; release ignores TTLSeconds.
+type LeaseRequest struct {
+	JobID      string `json:"job_id,omitempty"`
+	ScopeID    string `json:"scope_id"`
+	Round      int    `json:"round"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"`
+}
+
+// AcquireLease grants the caller the exclusive right to aggregate the round.
+func (s *Service) AcquireLease(ctx context.Context, authCtx *common.AuthContext, req *LeaseRequest) (*Lease, error) {
+	return s.submitLease(ctx, authCtx, "AcquireAggregationLease", req, true)
+}
+
+// RenewLease extends the caller's lease.
+func (s *Service) RenewLease(ctx context.Context, authCtx *common.AuthContext, req *LeaseRequest) (*Lease, error) {
+	return s.submitLease(ctx, authCtx, "RenewAggregationLease", req, true)
+}
+
+// ReleaseLease gives up the caller's lease before it expires.
+func (s *Service) ReleaseLease(ctx context.Context, authCtx *common.AuthContext, req *LeaseRequest) (*Lease, error) {
+	return s.submitLease(ctx, authCtx, "ReleaseAggregationLease", req, false)
+}
+
+// GetLease returns the latest lease of a round, active or not.
+func (s *Service) GetLease(ctx context.Context, authCtx *common.AuthContext, jobID, scopeID string, round int) (*Lease, error) {
+	args, err := s.leaseArgs("GetAggregationLease", &LeaseRequest{JobID: jobID, ScopeID: scopeID, Round: round})
+	if err != nil {
+		return nil, err
+	}
+	var lease Lease
+	if err := s.query(ctx, authCtx, args, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (s *Service) submitLease(ctx context.Context, authCtx *common.AuthContext, function string, req *LeaseRequest, withTTL bool) (*Lease, error) {
+	args, err := s.leaseArgs(function, req)
+	if err != nil {
+		return nil, err
+	}
+	if withTTL {
+		ttl := int(DefaultLeaseTTL.Seconds())
+		switch {
+		case req.TTLSeconds < 0:
+			return nil, common.NewStatusError(http.StatusBadRequest, "ttl_seconds must be a positive integer")
+		case req.TTLSeconds > 0:
+			ttl = req.TTLSeconds
+		}
+		args = append(args, strconv.Itoa(ttl))
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, rec.FabricClientID, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var lease Lease
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (s *Service) leaseArgs(function string, req *LeaseRequest) ([]string, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	scopeID := strings.TrimSpace(req.ScopeID)
+	switch {
+	case scopeID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "scope_id is required")
+	case req.Round < 1:
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	jobID := strings.TrimSpace(req.JobID)
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	return []string{function, jobID, scopeID, strconv.Itoa(req.Round)}, nil
+}
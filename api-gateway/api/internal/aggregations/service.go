@@ -0,0 +1,199 @@
+package aggregations
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service records and reads the composition of aggregated models, so anyone can verify which
+// models, weighted how, produced each cluster, state or nation model.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs an aggregations service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Aggregation mirrors the on-chain AggregationRecord.
+type Aggregation struct {
+	JobID         string    `json:"job_id"`
+	Layer         string    `json:"layer"`
+	ScopeID       string    `json:"scope_id"`
+	Round         int       `json:"round"`
+	InputModelIDs []string  `json:"input_model_ids"`
+	OutputModelID string    `json:"output_model_id"`
+	Algorithm     string    `json:"algorithm"`
+	Weights       []float64 `json:"weights,omitempty"`
+	Aggregator    string    `json:"aggregator"`
+	TxID          string    `json:"tx_id"`
+	RecordedAt    string    `json:"recorded_at"`
+}
+
+// RecordRequest is the payload for recording an aggregation. An empty JobID selects
+// GATEWAY_JOB_ID. Weights are optional; when given there is one per input model.
+type RecordRequest struct {
+	JobID         string    `json:"job_id,omitempty"`
+	Layer         string    `json:"layer"`
+	ScopeID       string    `json:"scope_id"`
+	Round         int       `json:"round"`
+	InputModelIDs []string  `json:"input_model_ids"`
+	OutputModelID string    `json:"output_model_id"`
+	Algorithm     string    `json:"algorithm"`
+	Weights       []float64 `json:"weights,omitempty"`
+}
+
+// ListFilter narrows a listing to a job, then a layer, scope and round. Each filter requires
+// the ones before it.
+type ListFilter struct {
+	JobID   string
+	Layer   string
+	ScopeID string
+	Round   int
+}
+
+// Record stores how the caller produced an aggregated model. The output model must already
+// be committed by the caller.
+func (s *Service) Record(ctx context.Context, authCtx *common.AuthContext, req *RecordRequest) (*Aggregation, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	layer := strings.ToLower(strings.TrimSpace(req.Layer))
+	scopeID := strings.TrimSpace(req.ScopeID)
+	switch {
+	case layer == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "layer is required")
+	case scopeID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "scope_id is required")
+	case req.Round < 1:
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	case strings.TrimSpace(req.OutputModelID) == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "output_model_id is required")
+	case len(req.InputModelIDs) == 0:
+		return nil, common.NewStatusError(http.StatusBadRequest, "input_model_ids must name at least one model")
+	case strings.TrimSpace(req.Algorithm) == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "algorithm is required")
+	case len(req.Weights) > 0 && len(req.Weights) != len(req.InputModelIDs):
+		return nil, common.NewStatusError(http.StatusBadRequest, "weights must have one entry per input model")
+	}
+	for _, weight := range req.Weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return nil, common.NewStatusError(http.StatusBadRequest, "weights must be finite, non-negative numbers")
+		}
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	jobID := strings.TrimSpace(req.JobID)
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	weights := ""
+	if len(req.Weights) > 0 {
+		weights = common.MustJSON(req.Weights)
+	}
+	args := []string{
+		"RecordAggregation",
+		jobID,
+		layer,
+		scopeID,
+		strconv.Itoa(req.Round),
+		common.MustJSON(req.InputModelIDs),
+		strings.TrimSpace(req.OutputModelID),
+		strings.TrimSpace(req.Algorithm),
+		weights,
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, rec.FabricClientID, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var record Aggregation
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Get returns the aggregation that produced a model.
+func (s *Service) Get(ctx context.Context, authCtx *common.AuthContext, outputModelID string) (*Aggregation, error) {
+	outputModelID = strings.TrimSpace(outputModelID)
+	if outputModelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model id is required")
+	}
+	var record Aggregation
+	if err := s.query(ctx, authCtx, []string{"ReadAggregation", outputModelID}, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// List returns the aggregations matching filter; an empty JobID selects GATEWAY_JOB_ID.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, filter ListFilter) ([]*Aggregation, error) {
+	jobID := strings.TrimSpace(filter.JobID)
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	round := ""
+	if filter.Round > 0 {
+		round = strconv.Itoa(filter.Round)
+	}
+	var records []*Aggregation
+	if err := s.query(ctx, authCtx, []string{"ListAggregations", jobID, strings.TrimSpace(filter.Layer), strings.TrimSpace(filter.ScopeID), round}, &records); err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*Aggregation{}
+	}
+	return records, nil
+}
+
+func (s *Service) query(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "already recorded"), strings.Contains(msg, "is held by"), strings.Contains(msg, "no active aggregation lease"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "no aggregation lease"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "trainer not authorized"), strings.Contains(msg, "trainer credential revoked"), strings.Contains(msg, "trainer deactivated"),
+		strings.Contains(msg, "trainer suspended"),
+		strings.Contains(msg, "not the caller"), strings.Contains(msg, "is not permitted to call"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"), strings.Contains(msg, "requires"),
+		strings.Contains(msg, "do not match"), strings.Contains(msg, "belongs to"), strings.Contains(msg, "more than once"),
+		strings.Contains(msg, "cannot be an input"), strings.Contains(msg, "expected"), strings.Contains(msg, "invalid"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package anchoring
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes anchor receipts and a manual trigger.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the anchoring HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the `/anchors` endpoints.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/anchors", auth.RequireAuth(http.HandlerFunc(h.handleAnchors), common.RoleAdmin, common.RoleCentralChecker))
+}
+
+// Describe documents the anchoring endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("anchoring")
+	api.Add(http.MethodGet, "/anchors", openapi.Operation{
+		Summary:     "List anchor receipts",
+		Description: "With anchor_id the single matching Receipt is returned instead of the list.",
+		Roles:       []common.Role{common.RoleAdmin, common.RoleCentralChecker},
+		Query:       []openapi.Param{{Name: "anchor_id", Description: "Return only this receipt"}},
+		Response:    map[string]any{"endpoint": "", "interval": "", "items": []*Receipt{}},
+	})
+	api.Add(http.MethodPost, "/anchors", openapi.Operation{Summary: "Anchor the current ledger state now", Roles: []common.Role{common.RoleAdmin}, Response: Receipt{}, Status: http.StatusCreated})
+}
+
+func (h *HTTPHandler) handleAnchors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if anchorID := strings.TrimSpace(r.URL.Query().Get("anchor_id")); anchorID != "" {
+			receipt, err := h.svc.Get(r.Context(), anchorID)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			common.WriteJSON(w, http.StatusOK, receipt)
+			return
+		}
+		receipts, err := h.svc.List(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{
+			"endpoint": h.svc.cfg.AnchorEndpoint,
+			"interval": h.svc.cfg.AnchorInterval.String(),
+			"items":    receipts,
+		})
+	case http.MethodPost:
+		authCtx, ok := common.AuthContextFrom(r.Context())
+		if !ok {
+			common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+			return
+		}
+		if !authCtx.Permits(common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only admins can trigger anchoring"))
+			return
+		}
+		receipt, err := h.svc.Anchor(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, receipt)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
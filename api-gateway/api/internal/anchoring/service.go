@@ -0,0 +1,228 @@
+package anchoring
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Service periodically publishes a digest of the ledger to an external anchoring endpoint
+// and stores the returned receipt on-chain.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	client *http.Client
+	mu     sync.Mutex
+}
+
+// NewService constructs an anchoring service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Receipt is an anchor receipt as stored on the ledger.
+type Receipt struct {
+	AnchorID    string            `json:"anchor_id"`
+	Digest      string            `json:"digest"`
+	BlockHeight uint64            `json:"block_height"`
+	BlockHash   string            `json:"block_hash"`
+	Namespaces  map[string]string `json:"namespaces"`
+	Endpoint    string            `json:"endpoint"`
+	Receipt     string            `json:"receipt"`
+	AnchoredAt  string            `json:"anchored_at"`
+	RecordedAt  string            `json:"recorded_at"`
+}
+
+// anchorRequest is the document POSTed to the external endpoint.
+type anchorRequest struct {
+	AnchorID    string            `json:"anchor_id"`
+	Digest      string            `json:"digest"`
+	Channel     string            `json:"channel"`
+	Chaincode   string            `json:"chaincode"`
+	BlockHeight uint64            `json:"block_height"`
+	BlockHash   string            `json:"block_hash"`
+	Namespaces  map[string]string `json:"namespaces"`
+	Timestamp   string            `json:"timestamp"`
+}
+
+type ledgerStateDigest struct {
+	Digest     string            `json:"digest"`
+	Namespaces map[string]string `json:"namespaces"`
+	KeyCounts  map[string]int    `json:"key_counts"`
+}
+
+// Enabled reports whether an external anchoring endpoint is configured.
+func (s *Service) Enabled() bool {
+	return s.cfg.AnchorEndpoint != ""
+}
+
+// Run anchors on every ANCHOR_INTERVAL tick until the context is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.Enabled() || s.cfg.AnchorInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.AnchorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			receipt, err := s.Anchor(ctx)
+			if err != nil {
+				log.Printf("ledger anchoring failed: %v", err)
+				continue
+			}
+			log.Printf("anchored ledger digest %s at block %d (%s)", receipt.Digest, receipt.BlockHeight, receipt.AnchorID)
+		}
+	}
+}
+
+// Anchor computes the current digest, publishes it and records the receipt on-chain.
+func (s *Service) Anchor(ctx context.Context) (*Receipt, error) {
+	if !s.Enabled() {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "ANCHOR_ENDPOINT is not configured")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
+	if peerName == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	info, err := s.fabric.ChannelInfo(ctx, peerName)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, s.cfg.AdminIdentity, []string{"ComputeStateDigest", common.MustJSON(s.cfg.AnchorNamespaces)})
+	if err != nil {
+		return nil, err
+	}
+	var state ledgerStateDigest
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	target := s.cfg.Target(ctx)
+	req := &anchorRequest{
+		AnchorID:    common.GeneratePrefixedID("anchor"),
+		Channel:     target.Channel,
+		Chaincode:   target.Chaincode,
+		BlockHeight: info.Height,
+		BlockHash:   info.CurrentBlockHash,
+		Namespaces:  state.Namespaces,
+		Timestamp:   now.Format(time.RFC3339),
+	}
+	// Bind the state digest to the block the peer reported so the anchor pins both.
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%s", state.Digest, info.Height, info.CurrentBlockHash)))
+	req.Digest = hex.EncodeToString(sum[:])
+
+	receipt, err := s.publish(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"RecordAnchorReceipt",
+		req.AnchorID,
+		req.Digest,
+		strconv.FormatUint(req.BlockHeight, 10),
+		req.BlockHash,
+		common.MustJSON(req.Namespaces),
+		s.cfg.AnchorEndpoint,
+		receipt,
+		req.Timestamp,
+	}
+	if _, err := s.fabric.InvokeChaincode(ctx, peerName, s.cfg.AdminIdentity, args); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, req.AnchorID)
+}
+
+// List returns every anchor receipt recorded on the ledger.
+func (s *Service) List(ctx context.Context) ([]*Receipt, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListAnchorReceipts"})
+	if err != nil {
+		return nil, err
+	}
+	var receipts []*Receipt
+	if err := json.Unmarshal(raw, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// Get returns a single anchor receipt.
+func (s *Service) Get(ctx context.Context, anchorID string) (*Receipt, error) {
+	anchorID = strings.TrimSpace(anchorID)
+	if anchorID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "anchor_id is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ReadAnchorReceipt", anchorID})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, common.NewStatusError(http.StatusNotFound, err.Error())
+		}
+		return nil, err
+	}
+	var receipt Receipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// publish POSTs the anchor document and returns the endpoint's receipt. JSON responses
+// carrying a `receipt`, `tx_hash` or `id` field are reduced to that value; anything else
+// is stored verbatim.
+func (s *Service) publish(ctx context.Context, req *anchorRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.AnchorEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	common.InjectTraceparent(ctx, httpReq.Header)
+	if s.cfg.AnchorAuthToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.cfg.AnchorAuthToken)
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return "", common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("anchor endpoint unreachable: %v", err))
+	}
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("anchor endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(payload))))
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(payload, &fields); err == nil {
+		for _, key := range []string{"receipt", "tx_hash", "id"} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				return value, nil
+			}
+		}
+	}
+	receipt := strings.TrimSpace(string(payload))
+	if receipt == "" {
+		return "", common.NewStatusError(http.StatusBadGateway, "anchor endpoint returned an empty receipt")
+	}
+	return receipt, nil
+}
@@ -0,0 +1,140 @@
+package artifacts
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// HTTPHandler exposes the `/artifacts` endpoints.
+type HTTPHandler struct {
+	svc   *Service
+	store registry.Store
+}
+
+// NewHTTPHandler wires the artifacts HTTP handler.
+func NewHTTPHandler(svc *Service, store registry.Store) *HTTPHandler {
+	return &HTTPHandler{svc: svc, store: store}
+}
+
+// RegisterRoutes mounts upload and download; callers authenticate with runtime EdDSA tokens.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	keyFunc := registry.TrainerKeyFunc(h.store)
+	mux.Handle("/artifacts", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleUpload)))
+	mux.Handle("/artifacts/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleDownload)))
+}
+
+// Describe documents the artifact endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("artifacts")
+	const runtimeToken = "Requires a trainer runtime token (EdDSA) or a token from /auth/token."
+	api.Add(http.MethodPost, "/artifacts", openapi.Operation{
+		Summary:     "Upload an artifact to IPFS",
+		Description: runtimeToken + " Setting layer and scope_id also registers the CID as a model.",
+		Query: []openapi.Param{
+			{Name: "name", Description: "File name recorded with the upload"},
+			{Name: "layer", Description: "Aggregation layer to register the model under"},
+			{Name: "scope_id", Description: "Scope to register the model under"},
+			{Name: "round", Description: "Training round of the model", Type: "integer"},
+			{Name: "parent_model_ids", Description: "Comma separated parent model IDs"},
+		},
+		Body:     []byte{},
+		Consumes: "application/octet-stream",
+		Response: Artifact{},
+		Status:   http.StatusCreated,
+		Errors:   []int{http.StatusRequestEntityTooLarge},
+	})
+	api.Add(http.MethodGet, "/artifacts/{cid}", openapi.Operation{Summary: "Download an artifact", Description: runtimeToken, Response: []byte{}, Produces: "application/octet-stream", Errors: []int{http.StatusNotFound}})
+}
+
+// handleUpload streams the raw request body to IPFS. Query parameters: `name`, and to
+// register the CID on-chain `layer`, `scope_id`, optional `round` and `parent_model_ids`
+// (comma separated).
+func (h *HTTPHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	query := r.URL.Query()
+	opts := &UploadOptions{
+		Name:           query.Get("name"),
+		Layer:          strings.ToLower(strings.TrimSpace(query.Get("layer"))),
+		ScopeID:        strings.TrimSpace(query.Get("scope_id")),
+		IdempotencyKey: common.IdempotencyKey(r),
+	}
+	if raw := strings.TrimSpace(query.Get("round")); raw != "" {
+		round, err := strconv.Atoi(raw)
+		if err != nil || round < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+			return
+		}
+		opts.Round = round
+	}
+	for _, id := range strings.Split(query.Get("parent_model_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			opts.ParentModelIDs = append(opts.ParentModelIDs, id)
+		}
+	}
+	if r.ContentLength > 0 && h.svc.cfg.ArtifactMaxBytes > 0 && r.ContentLength > h.svc.cfg.ArtifactMaxBytes {
+		common.WriteErrorWithCode(w, http.StatusRequestEntityTooLarge, h.svc.tooLarge())
+		return
+	}
+	h.extendDeadlines(w)
+	artifact, err := h.svc.Upload(r.Context(), authCtx, r.Body, opts)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusCreated, artifact)
+}
+
+func (h *HTTPHandler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	cid := strings.TrimPrefix(r.URL.Path, "/artifacts/")
+	h.extendDeadlines(w)
+	content, err := h.svc.Download(r.Context(), cid)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer content.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+cid+`"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("artifact %s download interrupted: %v", cid, err)
+	}
+}
+
+// extendDeadlines lifts the server's read/write timeouts for a transfer, which can take far
+// longer than an ordinary API call. Transfers stay bounded by ARTIFACT_TRANSFER_TIMEOUT.
+func (h *HTTPHandler) extendDeadlines(w http.ResponseWriter) {
+	controller := http.NewResponseController(w)
+	deadline := time.Now().Add(h.svc.cfg.ArtifactTransferTimeout)
+	_ = controller.SetReadDeadline(deadline)
+	_ = controller.SetWriteDeadline(deadline)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
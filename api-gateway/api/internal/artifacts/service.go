@@ -0,0 +1,249 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/models"
+)
+
+// Service streams model artifacts to and from an IPFS node through its Kubo RPC API and can
+// register the resulting CID as a model reference.
+type Service struct {
+	cfg    *common.Config
+	models *models.Service
+	client *http.Client
+}
+
+// NewService constructs an artifacts service. The HTTP client has no overall timeout because
+// uploads and downloads are bounded by the request context instead.
+func NewService(cfg *common.Config, modelSvc *models.Service) *Service {
+	return &Service{cfg: cfg, models: modelSvc, client: &http.Client{}}
+}
+
+// Artifact describes stored content.
+type Artifact struct {
+	CID    string               `json:"cid"`
+	SHA256 string               `json:"sha256"`
+	Size   int64                `json:"size"`
+	Name   string               `json:"name,omitempty"`
+	Pinned bool                 `json:"pinned"`
+	Model  *models.CommitResult `json:"model,omitempty"`
+}
+
+// UploadOptions controls naming and the optional on-chain registration of an upload.
+type UploadOptions struct {
+	Name string
+	// Layer and ScopeID register the CID as a model reference when set.
+	Layer          string
+	ScopeID        string
+	Round          int
+	ParentModelIDs []string
+	IdempotencyKey string
+}
+
+// ErrArtifactTooLarge is returned when an upload exceeds ARTIFACT_MAX_BYTES.
+var ErrArtifactTooLarge = errors.New("artifact exceeds the maximum size")
+
+type ipfsAddResponse struct {
+	Name string `json:"Name"`
+	Hash string `json:"Hash"`
+	Size string `json:"Size"`
+}
+
+// Enabled reports whether an IPFS API endpoint is configured.
+func (s *Service) Enabled() bool {
+	return s.cfg.IPFSAPIURL != ""
+}
+
+// Upload streams content to IPFS, pinning it and computing its SHA-256 on the way. When
+// opts names a layer, the CID is committed as a model reference; if that commit fails the
+// pin is removed again so no orphaned artifact is kept.
+func (s *Service) Upload(ctx context.Context, authCtx *common.AuthContext, content io.Reader, opts *UploadOptions) (*Artifact, error) {
+	if !s.Enabled() {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "IPFS_API_URL is not configured")
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	register := strings.TrimSpace(opts.Layer) != ""
+	if register && strings.TrimSpace(opts.ScopeID) == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "scope_id is required to register an artifact")
+	}
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		name = "artifact"
+	}
+
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(content, hash), limit: s.cfg.ArtifactMaxBytes}
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	streamed := make(chan error, 1)
+	go func() {
+		part, err := form.CreateFormFile("file", name)
+		if err == nil {
+			_, err = io.Copy(part, counter)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+		streamed <- err
+	}()
+
+	query := url.Values{"pin": {"true"}, "cid-version": {"1"}, "quieter": {"true"}}
+	resp, err := s.call(ctx, "add", query, body, form.FormDataContentType())
+	if err != nil {
+		body.CloseWithError(err)
+		if errors.Is(<-streamed, ErrArtifactTooLarge) {
+			return nil, s.tooLarge()
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var added ipfsAddResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&added)
+	if err := <-streamed; err != nil {
+		s.unpin(added.Hash)
+		if errors.Is(err, ErrArtifactTooLarge) {
+			return nil, s.tooLarge()
+		}
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode IPFS add response: %w", decodeErr)
+	}
+	artifact := &Artifact{
+		CID:    added.Hash,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+		Size:   counter.n,
+		Name:   strings.TrimSpace(opts.Name),
+		Pinned: true,
+	}
+	if !register {
+		return artifact, nil
+	}
+	payload, err := json.Marshal(map[string]any{
+		"artifact_cid":  artifact.CID,
+		"artifact_hash": "sha256:" + artifact.SHA256,
+		"artifact_size": artifact.Size,
+		"artifact_name": artifact.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.models.Commit(ctx, authCtx, opts.Layer, opts.ScopeID, payload, &models.CommitOptions{
+		Round:          opts.Round,
+		ParentModelIDs: opts.ParentModelIDs,
+		IdempotencyKey: opts.IdempotencyKey,
+	})
+	if err != nil {
+		s.unpin(artifact.CID)
+		return nil, err
+	}
+	artifact.Model = result
+	return artifact, nil
+}
+
+// Download opens the content stored under cid. The caller must close the returned reader.
+func (s *Service) Download(ctx context.Context, cid string) (io.ReadCloser, error) {
+	if !s.Enabled() {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "IPFS_API_URL is not configured")
+	}
+	cid = strings.TrimSpace(cid)
+	if !validCID(cid) {
+		return nil, common.NewStatusError(http.StatusBadRequest, "invalid CID")
+	}
+	resp, err := s.call(ctx, "cat", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// call POSTs to a Kubo RPC endpoint and returns the response when it succeeded.
+func (s *Service) call(ctx context.Context, command string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	endpoint := strings.TrimRight(s.cfg.IPFSAPIURL, "/") + "/api/v0/" + command + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	common.InjectTraceparent(ctx, req.Header)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("IPFS %s failed: %w", command, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	var failure struct {
+		Message string `json:"Message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	if strings.Contains(failure.Message, "not found") || strings.Contains(failure.Message, "invalid") {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("IPFS %s: %s", command, failure.Message))
+	}
+	return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("IPFS %s returned %d: %s", command, resp.StatusCode, failure.Message))
+}
+
+// unpin releases a pin taken by Upload. Failures are only logged; the content is garbage
+// collected by the node once unpinned.
+func (s *Service) unpin(cid string) {
+	if cid == "" {
+		return
+	}
+	resp, err := s.call(context.Background(), "pin/rm", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		log.Printf("failed to unpin artifact %s: %v", cid, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func (s *Service) tooLarge() error {
+	return common.NewStatusError(http.StatusRequestEntityTooLarge, fmt.Sprintf("artifact exceeds %d bytes", s.cfg.ArtifactMaxBytes))
+}
+
+// countingReader counts bytes and fails once more than limit bytes were read.
+type countingReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.limit > 0 && c.n > c.limit {
+		return n, ErrArtifactTooLarge
+	}
+	return n, err
+}
+
+func validCID(cid string) bool {
+	if cid == "" || len(cid) > 128 {
+		return false
+	}
+	for _, r := range cid {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the audit trail to admins.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the audit HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/audit`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/audit", auth.RequireAuth(http.HandlerFunc(h.handleList), common.RoleAdmin))
+}
+
+// Describe documents the audit endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("audit")
+	api.Add(http.MethodGet, "/admin/audit", openapi.Operation{
+		Summary:     "Search the audit trail",
+		Description: "Entries cover every state-changing request and every /admin/ request, newest first. `last_anchor` is the latest batch anchored on-chain when AUDIT_ANCHOR_BATCH is set.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Query: []openapi.Param{
+			{Name: "actor", Description: "Only entries by this subject (API keys are apikey:<id>)."},
+			{Name: "route", Description: "Only entries whose path starts with this prefix."},
+			{Name: "from", Description: "RFC3339 timestamp, inclusive."},
+			{Name: "until", Description: "RFC3339 timestamp, inclusive."},
+			{Name: "limit", Type: "integer", Description: fmt.Sprintf("At most this many entries (default %d, at most %d).", defaultLimit, maxLimit)},
+		},
+		Response: map[string]any{"items": []*Entry{}, "last_anchor": Batch{}},
+		Errors:   []int{http.StatusServiceUnavailable},
+	})
+}
+
+func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	filter := Filter{
+		Actor: strings.TrimSpace(query.Get("actor")),
+		Route: strings.TrimSpace(query.Get("route")),
+	}
+	for name, target := range map[string]*string{"from": &filter.From, "until": &filter.Until} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, name+" must be an RFC3339 timestamp"))
+			return
+		}
+		*target = at.UTC().Format(time.RFC3339)
+	}
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "limit must be a positive integer"))
+			return
+		}
+		filter.Limit = limit
+	}
+	entries, err := h.svc.List(filter)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": entries, "last_anchor": h.svc.LastAnchor()})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
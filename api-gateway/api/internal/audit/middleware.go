@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Middleware appends an entry for every state-changing request and every request under
+// /admin/, whatever its outcome, except for paths under AUDIT_EXCLUDE.
+func (s *Service) Middleware(next http.Handler) http.Handler {
+	if !s.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.audited(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		note := &common.AuditNote{}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(common.WithAuditNote(r.Context(), note)))
+		actor, role, transactions := note.Close()
+		entry := Entry{
+			Time:         time.Now().UTC().Format(time.RFC3339),
+			RequestID:    w.Header().Get(common.RequestIDHeader),
+			Actor:        actor,
+			Role:         role,
+			Method:       r.Method,
+			Route:        r.URL.Path,
+			Status:       recorder.status,
+			Result:       ResultSuccess,
+			Transactions: transactions,
+		}
+		if recorder.status >= http.StatusBadRequest {
+			entry.Result = ResultFailure
+		}
+		if _, err := s.Append(entry); err != nil {
+			log.Printf("audit: failed to record %s %s by %q: %v", r.Method, r.URL.Path, actor, err)
+		}
+	})
+}
+
+func (s *Service) audited(r *http.Request) bool {
+	for _, prefix := range s.cfg.AuditExclude {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return strings.HasPrefix(r.URL.Path, "/admin/")
+	}
+	return true
+}
+
+// statusRecorder captures the response status while still exposing Flush for SSE streams.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,343 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Entry results.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Default and maximum number of entries one query returns.
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Entry is one audited request. Entries are chained: Hash is the SHA-256 of the entry
+// serialized with an empty Hash, and PrevHash is the previous entry's Hash, so editing or
+// dropping an entry breaks every hash after it.
+type Entry struct {
+	Seq          uint64           `json:"seq"`
+	Time         string           `json:"time"`
+	RequestID    string           `json:"request_id,omitempty"`
+	Actor        string           `json:"actor,omitempty"`
+	Role         common.Role      `json:"role,omitempty"`
+	Method       string           `json:"method"`
+	Route        string           `json:"route"`
+	Status       int              `json:"status"`
+	Result       string           `json:"result"`
+	Transactions []common.AuditTx `json:"transactions,omitempty"`
+	PrevHash     string           `json:"prev_hash"`
+	Hash         string           `json:"hash"`
+}
+
+// Batch is an on-chain anchor of the entries FirstSeq..LastSeq; Digest is the Hash of entry
+// LastSeq.
+type Batch struct {
+	BatchID    string `json:"batch_id"`
+	FirstSeq   uint64 `json:"first_seq"`
+	LastSeq    uint64 `json:"last_seq"`
+	Digest     string `json:"digest"`
+	RecordedBy string `json:"recorded_by,omitempty"`
+	RecordedAt string `json:"recorded_at,omitempty"`
+	TxID       string `json:"tx_id,omitempty"`
+}
+
+// Filter selects entries. From and Until are inclusive RFC3339 bounds.
+type Filter struct {
+	Actor string
+	Route string
+	From  string
+	Until string
+	Limit int
+}
+
+// Service appends audit entries to a local JSON-lines file and, when AUDIT_ANCHOR_BATCH is
+// set, anchors them on-chain in batches.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+
+	mu   sync.Mutex
+	file *os.File
+	seq  uint64
+	head string
+	// anchored is the last batch recorded on-chain; it is kept next to the log.
+	anchored Batch
+	wake     chan struct{}
+}
+
+// NewService opens the audit log at AUDIT_LOG_PATH and recovers its sequence and hash chain.
+// With AUDIT_LOG=false the service is disabled and audits nothing.
+func NewService(cfg *common.Config, fabric *common.FabricClient) (*Service, error) {
+	s := &Service{cfg: cfg, fabric: fabric, wake: make(chan struct{}, 1)}
+	if !cfg.AuditLog {
+		return s, nil
+	}
+	if err := common.EnsureDir(cfg.AuditLogPath); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(cfg.AuditLogPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recover(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %w", cfg.AuditLogPath, err)
+	}
+	s.file = file
+	if raw, err := os.ReadFile(s.anchorPath()); err == nil {
+		if err := json.Unmarshal(raw, &s.anchored); err != nil {
+			return nil, fmt.Errorf("audit anchor state %s: %w", s.anchorPath(), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Enabled reports whether requests are audited.
+func (s *Service) Enabled() bool {
+	return s.file != nil
+}
+
+// AnchorEnabled reports whether entries are anchored on-chain.
+func (s *Service) AnchorEnabled() bool {
+	return s.Enabled() && s.cfg.AuditAnchorBatch > 0
+}
+
+// recover reads the last entry to continue its chain. A partial final line, left by a crash
+// mid-write, is truncated; any other unreadable line is an error.
+func (s *Service) recover(file *os.File) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("audit: dropping partial entry at the end of %s", s.cfg.AuditLogPath)
+				return file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		s.seq, s.head = entry.Seq, entry.Hash
+		offset += int64(len(line))
+	}
+}
+
+func (s *Service) anchorPath() string {
+	return s.cfg.AuditLogPath + ".anchor.json"
+}
+
+// Append chains entry to the log and writes it; Seq, PrevHash and Hash are assigned here.
+func (s *Service) Append(entry Entry) (*Entry, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.Seq = s.seq + 1
+	entry.PrevHash = s.head
+	entry.Hash = ""
+	unsigned, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(unsigned)
+	entry.Hash = hex.EncodeToString(sum[:])
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	s.seq, s.head = entry.Seq, entry.Hash
+	if s.AnchorEnabled() && s.seq-s.anchored.LastSeq >= uint64(s.cfg.AuditAnchorBatch) {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return &entry, nil
+}
+
+// List returns the entries matching filter, newest first.
+func (s *Service) List(filter Filter) ([]*Entry, error) {
+	if !s.Enabled() {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "AUDIT_LOG is disabled")
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	file, err := os.Open(s.cfg.AuditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	// Keep the newest Limit matches in a ring while scanning forward.
+	ring := make([]*Entry, 0, filter.Limit)
+	next := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The final line may be mid-write.
+			continue
+		}
+		if !filter.matches(&entry) {
+			continue
+		}
+		if len(ring) < filter.Limit {
+			ring = append(ring, &entry)
+			continue
+		}
+		ring[next] = &entry
+		next = (next + 1) % filter.Limit
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(ring))
+	for i := len(ring) - 1; i >= 0; i-- {
+		entries = append(entries, ring[(next+i)%len(ring)])
+	}
+	return entries, nil
+}
+
+func (f Filter) matches(entry *Entry) bool {
+	if f.Actor != "" && entry.Actor != f.Actor {
+		return false
+	}
+	if f.Route != "" && !strings.HasPrefix(entry.Route, f.Route) {
+		return false
+	}
+	// RFC3339 UTC timestamps order lexically.
+	if f.From != "" && entry.Time < f.From {
+		return false
+	}
+	if f.Until != "" && entry.Time > f.Until {
+		return false
+	}
+	return true
+}
+
+// LastAnchor returns the most recent on-chain batch, or nil before the first.
+func (s *Service) LastAnchor() *Batch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.anchored.BatchID == "" {
+		return nil
+	}
+	batch := s.anchored
+	return &batch
+}
+
+// Run anchors a batch whenever AUDIT_ANCHOR_BATCH entries are pending, and whatever is
+// pending every AUDIT_ANCHOR_INTERVAL, until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.AnchorEnabled() {
+		return
+	}
+	interval := s.cfg.AuditAnchorInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+		batch, err := s.Anchor(ctx)
+		if err != nil {
+			log.Printf("audit: anchoring failed: %v", err)
+			continue
+		}
+		if batch != nil {
+			log.Printf("audit: anchored entries %d-%d as %s", batch.FirstSeq, batch.LastSeq, batch.BatchID)
+		}
+	}
+}
+
+// Anchor records the entries appended since the last batch on-chain. It returns nil when
+// nothing is pending.
+func (s *Service) Anchor(ctx context.Context) (*Batch, error) {
+	s.mu.Lock()
+	batch := Batch{
+		BatchID:  common.GeneratePrefixedID("audit"),
+		FirstSeq: s.anchored.LastSeq + 1,
+		LastSeq:  s.seq,
+		Digest:   s.head,
+	}
+	s.mu.Unlock()
+	if batch.LastSeq < batch.FirstSeq {
+		return nil, nil
+	}
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
+	if peerName == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{
+		"RecordAuditBatch",
+		batch.BatchID,
+		strconv.FormatUint(batch.FirstSeq, 10),
+		strconv.FormatUint(batch.LastSeq, 10),
+		batch.Digest,
+	}
+	payload, receipt, err := s.fabric.SubmitChaincode(ctx, peerName, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &batch); err != nil {
+			return nil, err
+		}
+	}
+	batch.TxID = receipt.TxID
+	state, err := json.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := common.AtomicWriteFile(s.anchorPath(), state, 0o600); err != nil {
+		return nil, err
+	}
+	s.anchored = batch
+	return &batch, nil
+}
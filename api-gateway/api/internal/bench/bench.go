@@ -0,0 +1,394 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Options configures a benchmark run against a running gateway.
+type Options struct {
+	// Target is the gateway base URL, e.g. http://localhost:9000.
+	Target string
+	// Trainers is the number of synthetic trainers committing concurrently.
+	Trainers int
+	// Rate is the commits per second each trainer starts, whether or not earlier commits have
+	// answered, so slow responses do not hide queueing from the measured latencies.
+	Rate float64
+	// PayloadSize is the size in bytes of each committed model payload.
+	PayloadSize int
+	// Duration is how long commits are started for.
+	Duration time.Duration
+	// MaxInFlight bounds the commits awaiting a response across all trainers; ticks that find
+	// it reached are counted as dropped instead of being sent.
+	MaxInFlight int
+	// Timeout bounds each HTTP request.
+	Timeout time.Duration
+
+	// Prefix names the trainers <prefix>-001, <prefix>-002, ...; Seed derives their Ed25519
+	// keys, so reruns with the same prefix and seed reuse earlier registrations.
+	Prefix   string
+	Seed     string
+	State    string
+	Clusters []string
+
+	// Register enrolls the trainers before the run. It needs the AUTH_JWT_SECRET of the target
+	// (AuthSecret) for registration tokens and the admin key (AdminKey) to sign their
+	// credentials; JobID must match the gateway's GATEWAY_JOB_ID when that is set.
+	Register   bool
+	AuthSecret string
+	AdminKey   ed25519.PrivateKey
+	JobID      string
+
+	Client *http.Client
+}
+
+// Report summarizes a run. Latencies cover every commit that received a response, successful
+// or not; transport failures and timeouts only count as errors.
+type Report struct {
+	Target      string         `json:"target"`
+	Trainers    int            `json:"trainers"`
+	Rate        float64        `json:"rate_per_trainer"`
+	PayloadSize int            `json:"payload_size"`
+	Duration    float64        `json:"duration_seconds"`
+	Sent        int            `json:"sent"`
+	Succeeded   int            `json:"succeeded"`
+	Failed      int            `json:"failed"`
+	Dropped     int            `json:"dropped"`
+	ErrorRate   float64        `json:"error_rate"`
+	Throughput  float64        `json:"throughput"`
+	Latency     Latency        `json:"latency_ms"`
+	Errors      map[string]int `json:"errors,omitempty"`
+}
+
+// Latency holds commit latency statistics in milliseconds.
+type Latency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Trainer is a synthetic trainer identity.
+type Trainer struct {
+	Subject string
+	DID     string
+	State   string
+	Cluster string
+	key     ed25519.PrivateKey
+}
+
+// Trainers derives the synthetic trainer identities of opts.
+func Trainers(opts Options) []*Trainer {
+	trainers := make([]*Trainer, 0, opts.Trainers)
+	for i := 1; i <= opts.Trainers; i++ {
+		subject := fmt.Sprintf("%s-%03d", opts.Prefix, i)
+		seed := sha256.Sum256([]byte(opts.Seed + ":" + subject))
+		trainers = append(trainers, &Trainer{
+			Subject: subject,
+			DID:     "did:nebula:" + subject,
+			State:   opts.State,
+			Cluster: opts.Clusters[(i-1)%len(opts.Clusters)],
+			key:     ed25519.NewKeyFromSeed(seed[:]),
+		})
+	}
+	return trainers
+}
+
+func (o *Options) validate() error {
+	switch {
+	case strings.TrimSpace(o.Target) == "":
+		return errors.New("target is required")
+	case o.Trainers < 1:
+		return errors.New("trainers must be positive")
+	case o.Rate <= 0:
+		return errors.New("rate must be positive")
+	case o.PayloadSize < 0:
+		return errors.New("payload size must not be negative")
+	case o.Duration <= 0:
+		return errors.New("duration must be positive")
+	case o.MaxInFlight < 1:
+		return errors.New("max in-flight must be positive")
+	case strings.TrimSpace(o.State) == "":
+		return errors.New("state is required")
+	case len(o.Clusters) == 0:
+		return errors.New("at least one cluster is required")
+	case o.Register && (o.AuthSecret == "" || len(o.AdminKey) == 0):
+		return errors.New("registering trainers needs the auth secret and the admin key")
+	}
+	o.Target = strings.TrimRight(strings.TrimSpace(o.Target), "/")
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: o.Timeout}
+	}
+	return nil
+}
+
+// Run registers the trainers when asked, then has every trainer commit cluster models at the
+// configured rate for the configured duration and reports the results.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	trainers := Trainers(opts)
+	if opts.Register {
+		for _, trainer := range trainers {
+			if err := register(ctx, opts, trainer); err != nil {
+				return nil, fmt.Errorf("failed to register %s: %w", trainer.Subject, err)
+			}
+		}
+	}
+	// Runtime tokens outlive the run so commits still in flight at the end authenticate.
+	expires := time.Now().Add(opts.Duration + 10*time.Minute)
+	tokens := make([]string, len(trainers))
+	for i, trainer := range trainers {
+		token, err := trainer.runtimeToken(expires)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = token
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		results  []result
+		dropped  int
+		inFlight = make(chan struct{}, opts.MaxInFlight)
+	)
+	runCtx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+	started := time.Now()
+	interval := time.Duration(float64(time.Second) / opts.Rate)
+	for i, trainer := range trainers {
+		wg.Add(1)
+		go func(trainer *Trainer, token string) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-runCtx.Done():
+					return
+				case <-ticker.C:
+				}
+				select {
+				case inFlight <- struct{}{}:
+				default:
+					mu.Lock()
+					dropped++
+					mu.Unlock()
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-inFlight }()
+					// Commits outlive runCtx so the last ones are measured, not cancelled.
+					r := commit(ctx, opts, trainer, token)
+					mu.Lock()
+					results = append(results, r)
+					mu.Unlock()
+				}()
+			}
+		}(trainer, tokens[i])
+	}
+	wg.Wait()
+	return summarize(opts, results, dropped, time.Since(started)), nil
+}
+
+type result struct {
+	latency time.Duration
+	// responded is false for transport failures, which have no meaningful latency.
+	responded bool
+	err       string
+}
+
+func commit(ctx context.Context, opts Options, trainer *Trainer, token string) result {
+	payload := make([]byte, (opts.PayloadSize+1)/2)
+	if _, err := rand.Read(payload); err != nil {
+		return result{err: "payload: " + err.Error()}
+	}
+	body := common.MustJSON(map[string]any{
+		"cluster_id": trainer.Cluster,
+		"payload":    map[string]string{"weights": hex.EncodeToString(payload)[:opts.PayloadSize]},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.Target+"/cluster/models", strings.NewReader(body))
+	if err != nil {
+		return result{err: err.Error()}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return result{err: "transport"}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	r := result{latency: time.Since(start), responded: true}
+	if resp.StatusCode >= http.StatusBadRequest {
+		r.err = "status " + strconv.Itoa(resp.StatusCode)
+	}
+	return r
+}
+
+func summarize(opts Options, results []result, dropped int, elapsed time.Duration) *Report {
+	report := &Report{
+		Target:      opts.Target,
+		Trainers:    opts.Trainers,
+		Rate:        opts.Rate,
+		PayloadSize: opts.PayloadSize,
+		Duration:    elapsed.Seconds(),
+		Sent:        len(results),
+		Dropped:     dropped,
+	}
+	latencies := make([]time.Duration, 0, len(results))
+	var total time.Duration
+	for _, r := range results {
+		if r.err == "" {
+			report.Succeeded++
+		} else {
+			report.Failed++
+			if report.Errors == nil {
+				report.Errors = make(map[string]int)
+			}
+			report.Errors[r.err]++
+		}
+		if r.responded {
+			latencies = append(latencies, r.latency)
+			total += r.latency
+		}
+	}
+	if report.Sent > 0 {
+		report.ErrorRate = float64(report.Failed) / float64(report.Sent)
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Succeeded) / elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.Latency = Latency{
+			Mean: milliseconds(total / time.Duration(len(latencies))),
+			P50:  milliseconds(percentile(latencies, 50)),
+			P95:  milliseconds(percentile(latencies, 95)),
+			P99:  milliseconds(percentile(latencies, 99)),
+			Max:  milliseconds(latencies[len(latencies)-1]),
+		}
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// register enrolls trainer with a credential signed by the admin key, as an operator would
+// with vctool and a registration token.
+func register(ctx context.Context, opts Options, trainer *Trainer) error {
+	now := time.Now().UTC()
+	credential := map[string]any{
+		"subject":     trainer.DID,
+		"job_id":      opts.JobID,
+		"valid_from":  now.Add(-time.Minute).Format(time.RFC3339),
+		"valid_until": now.Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	unsigned, err := registry.Canonicalize(credential)
+	if err != nil {
+		return err
+	}
+	credential["signature"] = base64.StdEncoding.EncodeToString(ed25519.Sign(opts.AdminKey, unsigned))
+	signed, err := registry.Canonicalize(credential)
+	if err != nil {
+		return err
+	}
+	token, err := signToken(&common.TokenHeader{Alg: "HS256", Typ: "JWT"}, trainer.claims(now.Add(5*time.Minute)), func(unsigned []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(opts.AuthSecret))
+		mac.Write(unsigned)
+		return mac.Sum(nil)
+	})
+	if err != nil {
+		return err
+	}
+	body := common.MustJSON(map[string]any{
+		"did":        trainer.DID,
+		"nodeId":     trainer.Subject,
+		"vc":         json.RawMessage(signed),
+		"public_key": base64.StdEncoding.EncodeToString(trainer.key.Public().(ed25519.PublicKey)),
+		"state":      trainer.State,
+		"cluster":    trainer.Cluster,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.Target+"/auth/register-trainer", strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+func (t *Trainer) claims(expires time.Time) *common.JWTClaims {
+	return &common.JWTClaims{
+		Subject: t.Subject,
+		State:   t.State,
+		Cluster: t.Cluster,
+		Role:    string(common.RoleTrainer),
+		Expiry:  json.Number(strconv.FormatInt(expires.Unix(), 10)),
+	}
+}
+
+// runtimeToken signs a trainer runtime token with the trainer's own key.
+func (t *Trainer) runtimeToken(expires time.Time) (string, error) {
+	return signToken(&common.TokenHeader{Alg: "EdDSA", Typ: "JWT"}, t.claims(expires), func(unsigned []byte) []byte {
+		return ed25519.Sign(t.key, unsigned)
+	})
+}
+
+func signToken(header *common.TokenHeader, claims *common.JWTClaims, sign func([]byte) []byte) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(unsigned))), nil
+}
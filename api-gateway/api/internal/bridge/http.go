@@ -0,0 +1,41 @@
+package bridge
+
+import (
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the event bridge status.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires an event bridge HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `GET /admin/event-bridge`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/event-bridge", auth.RequireAuth(http.HandlerFunc(h.handleStatus), common.RoleAdmin))
+}
+
+// Describe documents the event bridge endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	spec.Tag("event-bridge").Add(http.MethodGet, "/admin/event-bridge", openapi.Operation{
+		Summary:     "Inspect the Kafka/NATS event bridge",
+		Description: "Reports the transport, the next block scanned for chaincode events and the publish counters since startup.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Response:    Status{},
+	})
+}
+
+func (h *HTTPHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, h.svc.Status())
+}
@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaPublisher produces through a Confluent-compatible Kafka REST proxy (API v2), which
+// keeps the gateway free of a native Kafka client. Credentials in the URL are sent as basic
+// auth.
+type kafkaPublisher struct {
+	base     string
+	user     string
+	password string
+	client   *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func newKafkaPublisher(raw string) (*kafkaPublisher, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("EVENT_BRIDGE_URL must be the http(s) URL of a Kafka REST proxy, got %q", raw)
+	}
+	p := &kafkaPublisher{client: &http.Client{Timeout: 10 * time.Second}}
+	if parsed.User != nil {
+		p.user = parsed.User.Username()
+		p.password, _ = parsed.User.Password()
+		parsed.User = nil
+	}
+	p.base = strings.TrimRight(parsed.String(), "/")
+	return p, nil
+}
+
+func (p *kafkaPublisher) publish(ctx context.Context, topic, key string, value []byte) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{"records": {{Key: key, Value: value}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.base+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if p.user != "" {
+		req.SetBasicAuth(p.user, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("kafka rest proxy answered %d: %s", resp.StatusCode, strings.TrimSpace(string(answer)))
+	}
+	var produced kafkaProduceResponse
+	if json.Unmarshal(answer, &produced) == nil {
+		for _, offset := range produced.Offsets {
+			if offset.ErrorCode != nil && *offset.ErrorCode != 0 {
+				return fmt.Errorf("kafka rejected the record on %s: %s (code %d)", topic, offset.Error, *offset.ErrorCode)
+			}
+		}
+	}
+	return nil
+}
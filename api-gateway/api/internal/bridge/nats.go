@@ -0,0 +1,167 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout bounds connecting and each publish.
+const natsTimeout = 10 * time.Second
+
+// natsPublisher speaks the NATS core text protocol over one connection, dialled on first
+// use and again after any failure. "nats://" connects in plain TCP and "tls://" with TLS;
+// user:password or a lone token in the URL authenticate the connection.
+type natsPublisher struct {
+	address  string
+	useTLS   bool
+	host     string
+	user     string
+	password string
+	token    string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	writer *bufio.Writer
+}
+
+func newNATSPublisher(raw string) (*natsPublisher, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "nats" && parsed.Scheme != "tls") || parsed.Host == "" {
+		return nil, fmt.Errorf("EVENT_BRIDGE_URL must be a nats:// or tls:// server URL, got %q", raw)
+	}
+	p := &natsPublisher{address: parsed.Host, useTLS: parsed.Scheme == "tls", host: parsed.Hostname()}
+	if parsed.Port() == "" {
+		p.address = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if parsed.User != nil {
+		if password, ok := parsed.User.Password(); ok {
+			p.user, p.password = parsed.User.Username(), password
+		} else {
+			p.token = parsed.User.Username()
+		}
+	}
+	return p, nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, subject, _ string, value []byte) error {
+	if strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("invalid NATS subject %q", subject)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		if err := p.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	conn := p.conn
+	_ = conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	fmt.Fprintf(p.writer, "PUB %s %d\r\n", subject, len(value))
+	p.writer.Write(value)
+	p.writer.WriteString("\r\n")
+	if err := p.writer.Flush(); err != nil {
+		p.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// connectLocked dials the server, reads its INFO, authenticates and waits for the PONG that
+// confirms the CONNECT was accepted. A reader goroutine then answers PINGs and drops the
+// connection when the server reports an error or hangs up.
+func (p *natsPublisher) connectLocked(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: natsTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if p.useTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: p.host, MinVersion: tls.VersionTLS12}}).DialContext(ctx, "tcp", p.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", p.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS at %s: %w", p.address, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(natsTimeout))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("NATS server at %s did not greet with INFO", p.address)
+	}
+	options := map[string]any{"verbose": false, "pedantic": false, "name": "nebula-gateway", "lang": "go", "version": "1", "protocol": 0}
+	if p.user != "" {
+		options["user"], options["pass"] = p.user, p.password
+	}
+	if p.token != "" {
+		options["auth_token"] = p.token
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return err
+	}
+	for {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("NATS server at %s closed the connection: %w", p.address, err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return errors.New("NATS refused the connection: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+	p.conn, p.writer = conn, bufio.NewWriter(conn)
+	go p.read(conn, reader)
+	return nil
+}
+
+func (p *natsPublisher) read(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			p.mu.Lock()
+			if p.conn == conn {
+				p.writer.WriteString("PONG\r\n")
+				p.writer.Flush()
+			}
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("event bridge: NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	p.mu.Lock()
+	if p.conn == conn {
+		p.closeLocked()
+	}
+	p.mu.Unlock()
+}
+
+func (p *natsPublisher) closeLocked() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.writer = nil, nil
+}
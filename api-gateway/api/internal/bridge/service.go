@@ -0,0 +1,325 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Sources of bridged messages.
+const (
+	SourceGateway   = "gateway"
+	SourceChaincode = "chaincode"
+)
+
+const (
+	// queueSize bounds the messages waiting to be published; beyond it they are dropped and
+	// counted rather than holding up the request or block scan that produced them.
+	queueSize = 1024
+	// publishAttempts and publishBackoff bound how long one message may hold the queue
+	// while the broker is unreachable.
+	publishAttempts = 5
+	publishBackoff  = 500 * time.Millisecond
+)
+
+// Message is what the bridge publishes: a lifecycle event, or a chaincode event converted to
+// one with type "chaincode.<name>", its JSON payload as data and the block it was found in.
+type Message struct {
+	*common.LifecycleEvent
+	Source      string `json:"source"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+}
+
+// Status reports what the bridge has done since the gateway started.
+type Status struct {
+	Enabled         bool   `json:"enabled"`
+	Transport       string `json:"transport"`
+	ChaincodeEvents bool   `json:"chaincode_events"`
+	Channel         string `json:"channel,omitempty"`
+	NextBlock       uint64 `json:"next_block,omitempty"`
+	Queued          int    `json:"queued"`
+	Published       int    `json:"published"`
+	Failed          int    `json:"failed"`
+	Dropped         int    `json:"dropped"`
+	LastError       string `json:"last_error,omitempty"`
+	LastErrorAt     string `json:"last_error_at,omitempty"`
+	LastPublishedAt string `json:"last_published_at,omitempty"`
+}
+
+// cursor is the persisted block scan position.
+type cursor struct {
+	Channel   string `json:"channel"`
+	NextBlock uint64 `json:"next_block"`
+}
+
+// publisher sends one message to a topic of the broker.
+type publisher interface {
+	publish(ctx context.Context, topic, key string, value []byte) error
+}
+
+type envelope struct {
+	topic string
+	key   string
+	value []byte
+}
+
+// Service republishes gateway lifecycle events and chaincode events to Kafka or NATS so
+// orchestration outside the gateway can consume them without polling.
+type Service struct {
+	cfg       *common.Config
+	fabric    *common.FabricClient
+	publisher publisher
+	queue     chan *envelope
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewService builds the bridge EventBridge selects. A bridge set to "none" accepts events
+// and discards them.
+func NewService(cfg *common.Config, fabric *common.FabricClient) (*Service, error) {
+	s := &Service{
+		cfg:    cfg,
+		fabric: fabric,
+		queue:  make(chan *envelope, queueSize),
+		status: Status{Transport: cfg.EventBridge, ChaincodeEvents: cfg.EventBridgeChaincodeEvents},
+	}
+	var err error
+	switch cfg.EventBridge {
+	case "kafka":
+		s.publisher, err = newKafkaPublisher(cfg.EventBridgeURL)
+	case "nats":
+		s.publisher, err = newNATSPublisher(cfg.EventBridgeURL)
+	default:
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.status.Enabled = true
+	return s, nil
+}
+
+// Enabled reports whether a broker is configured.
+func (s *Service) Enabled() bool {
+	return s.publisher != nil
+}
+
+// Status returns the bridge counters.
+func (s *Service) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Queued = len(s.queue)
+	return status
+}
+
+// Notify queues a lifecycle event; it is the bridge's LifecycleBus listener.
+func (s *Service) Notify(event *common.LifecycleEvent) {
+	s.enqueue(&Message{LifecycleEvent: event, Source: SourceGateway})
+}
+
+// Topic returns where messages of eventType go, or "" when the type is switched off.
+func (s *Service) Topic(eventType string) string {
+	topic, ok := s.cfg.EventBridgeTopics[eventType]
+	if !ok {
+		if prefix, _, found := strings.Cut(eventType, "."); found {
+			topic, ok = s.cfg.EventBridgeTopics[prefix+".*"]
+		}
+	}
+	if !ok {
+		topic, ok = s.cfg.EventBridgeTopics["*"]
+	}
+	if !ok {
+		return s.cfg.EventBridgeTopicPrefix + eventType
+	}
+	if strings.EqualFold(topic, "off") {
+		return ""
+	}
+	return topic
+}
+
+func (s *Service) enqueue(message *Message) {
+	if !s.Enabled() || message.LifecycleEvent == nil {
+		return
+	}
+	topic := s.Topic(message.Type)
+	if topic == "" {
+		return
+	}
+	value, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("event bridge: failed to encode %s event: %v", message.Type, err)
+		return
+	}
+	key := message.JobID
+	if key == "" {
+		key = message.Type
+	}
+	select {
+	case s.queue <- &envelope{topic: topic, key: key, value: value}:
+	default:
+		s.mu.Lock()
+		s.status.Dropped++
+		s.mu.Unlock()
+	}
+}
+
+// Run publishes queued messages and, with EventBridgeChaincodeEvents, scans every new block
+// of the context's channel for chaincode events until the context is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	if s.cfg.EventBridgeChaincodeEvents {
+		go s.follow(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-s.queue:
+			s.publish(ctx, item)
+		}
+	}
+}
+
+// publish sends one message, retrying with backoff before counting it as failed. Messages
+// stay in order, so a broker outage holds the queue for at most a few seconds per message.
+func (s *Service) publish(ctx context.Context, item *envelope) {
+	backoff := publishBackoff
+	var err error
+	for attempt := 1; attempt <= publishAttempts; attempt++ {
+		if err = s.publisher.publish(ctx, item.topic, item.key, item.value); err == nil {
+			s.mu.Lock()
+			s.status.Published++
+			s.status.LastPublishedAt = time.Now().UTC().Format(time.RFC3339)
+			s.mu.Unlock()
+			return
+		}
+		if attempt == publishAttempts {
+			break
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	log.Printf("event bridge: failed to publish to %s: %v", item.topic, err)
+	s.mu.Lock()
+	s.status.Failed++
+	s.status.LastError = err.Error()
+	s.status.LastErrorAt = time.Now().UTC().Format(time.RFC3339)
+	s.mu.Unlock()
+}
+
+// follow scans new blocks every EventsPollInterval. Without a saved cursor it starts at the
+// current height, so earlier blocks are not replayed.
+func (s *Service) follow(ctx context.Context) {
+	interval := s.cfg.EventsPollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	channel := s.cfg.Target(ctx).Channel
+	next, resume := s.loadCursor(channel)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		peerName := s.fabric.SelectPeer(common.PeerRead)
+		info, err := s.fabric.ChannelInfo(ctx, peerName)
+		if err != nil {
+			log.Printf("event bridge: %v", err)
+		} else {
+			if !resume {
+				next, resume = info.Height, true
+				s.saveCursor(channel, next)
+			}
+			for next < info.Height && ctx.Err() == nil {
+				events, err := s.fabric.BlockEvents(ctx, peerName, "", next)
+				if err != nil {
+					log.Printf("event bridge: block %d: %v", next, err)
+					break
+				}
+				for _, event := range events {
+					if event.ChaincodeID == s.cfg.Target(ctx).Chaincode {
+						s.enqueue(chaincodeMessage(event))
+					}
+				}
+				next++
+				s.saveCursor(channel, next)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// chaincodeMessage converts a chaincode event. Its payload becomes the data when it is JSON
+// and a string otherwise; a job_id in the payload keys the message.
+func chaincodeMessage(event *common.ChaincodeEvent) *Message {
+	var data any = string(event.Payload)
+	var fields struct {
+		JobID string `json:"job_id"`
+	}
+	if json.Valid(event.Payload) {
+		data = json.RawMessage(event.Payload)
+		_ = json.Unmarshal(event.Payload, &fields)
+	}
+	return &Message{
+		LifecycleEvent: &common.LifecycleEvent{
+			ID:         common.GeneratePrefixedID("evt"),
+			Type:       SourceChaincode + "." + event.Name,
+			OccurredAt: event.Timestamp,
+			JobID:      fields.JobID,
+			TxID:       event.TxID,
+			Data:       data,
+		},
+		Source:      SourceChaincode,
+		BlockNumber: event.BlockNumber,
+	}
+}
+
+func (s *Service) loadCursor(channel string) (uint64, bool) {
+	raw, err := os.ReadFile(s.cfg.EventBridgeCursorPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("event bridge: failed to read cursor: %v", err)
+		}
+		return 0, false
+	}
+	var saved cursor
+	if err := json.Unmarshal(raw, &saved); err != nil || saved.Channel != channel {
+		return 0, false
+	}
+	s.setNextBlock(channel, saved.NextBlock)
+	return saved.NextBlock, true
+}
+
+func (s *Service) saveCursor(channel string, next uint64) {
+	s.setNextBlock(channel, next)
+	if err := common.AtomicWriteFile(s.cfg.EventBridgeCursorPath, []byte(common.MustJSON(cursor{Channel: channel, NextBlock: next})), 0o600); err != nil {
+		log.Printf("event bridge: %v", fmt.Errorf("failed to save cursor: %w", err))
+	}
+}
+
+func (s *Service) setNextBlock(channel string, next uint64) {
+	s.mu.Lock()
+	s.status.Channel = channel
+	s.status.NextBlock = next
+	s.mu.Unlock()
+}
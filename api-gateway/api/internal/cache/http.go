@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the query cache's admin endpoints.
+type HTTPHandler struct {
+	cache *common.QueryCache
+}
+
+// NewHTTPHandler wires the cache HTTP handler.
+func NewHTTPHandler(cache *common.QueryCache) *HTTPHandler {
+	return &HTTPHandler{cache: cache}
+}
+
+// InvalidateRequest names the namespaces to clear; none clears every namespace.
+type InvalidateRequest struct {
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// RegisterRoutes mounts `/admin/cache` and `/admin/cache/invalidate`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/cache", auth.RequireAuth(http.HandlerFunc(h.handleStats), common.RoleAdmin))
+	mux.Handle("/admin/cache/invalidate", auth.RequireAuth(http.HandlerFunc(h.handleInvalidate), common.RoleAdmin))
+}
+
+// Describe documents the cache endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("cache")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodGet, "/admin/cache", openapi.Operation{
+		Summary:  "Report query cache namespaces, entries and hit rates",
+		Roles:    admin,
+		Response: map[string]any{"namespaces": []common.CacheStats{}},
+	})
+	api.Add(http.MethodPost, "/admin/cache/invalidate", openapi.Operation{
+		Summary:     "Clear query cache namespaces",
+		Description: "Drops the named namespaces, or every namespace when none are given, so the next reads go to the ledger.",
+		Roles:       admin,
+		Body:        InvalidateRequest{},
+		Response:    map[string]any{"namespaces": []common.CacheStats{}},
+	})
+}
+
+func (h *HTTPHandler) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"namespaces": h.cache.Stats()})
+}
+
+func (h *HTTPHandler) handleInvalidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req InvalidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	var namespaces []string
+	for _, name := range req.Namespaces {
+		if name = strings.TrimSpace(name); name != "" {
+			namespaces = append(namespaces, name)
+		}
+	}
+	h.cache.Invalidate("admin", namespaces...)
+	common.WriteJSON(w, http.StatusOK, map[string]any{"namespaces": h.cache.Stats()})
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// APIKeyHeader carries an API key. "Authorization: ApiKey <key>" is accepted as well.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeySubjectPrefix prefixes the subject of requests authenticated with an API key, so
+// they can never collide with a JWT subject.
+const APIKeySubjectPrefix = "apikey:"
+
+// apiKeyIDPattern restricts key IDs to characters that survive headers, paths and env lists.
+var apiKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// APIKey is a service-account credential. Keys are presented as "<id>.<secret>"; only the
+// hex SHA-256 of the secret is ever stored.
+type APIKey struct {
+	ID    string `json:"id"`
+	Hash  string `json:"hash,omitempty"`
+	Roles []Role `json:"roles"`
+	// State is reported as the caller's state claim; most service accounts leave it empty.
+	State string `json:"state,omitempty"`
+	// RateLimit replaces RATE_LIMITS for this key when set.
+	RateLimit   *RateLimit `json:"rate_limit,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   string     `json:"created_at,omitempty"`
+}
+
+// APIKeyStore resolves API keys by ID.
+type APIKeyStore interface {
+	LookupAPIKey(id string) (*APIKey, bool)
+}
+
+// StaticAPIKeys serves the keys configured in API_KEYS.
+type StaticAPIKeys map[string]*APIKey
+
+// LookupAPIKey implements APIKeyStore.
+func (k StaticAPIKeys) LookupAPIKey(id string) (*APIKey, bool) {
+	key, ok := k[id]
+	return key, ok
+}
+
+// ValidateAPIKeyID reports whether id can name an API key.
+func ValidateAPIKeyID(id string) error {
+	if !apiKeyIDPattern.MatchString(id) {
+		return errors.New("api key id must be 1-64 letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// GenerateAPIKey returns a new key for id in its presented form and the hash to store.
+func GenerateAPIKey(id string) (string, string, error) {
+	if err := ValidateAPIKeyID(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return id + "." + encoded, HashAPIKeySecret(encoded), nil
+}
+
+// HashAPIKeySecret is the stored form of an API key secret. Secrets are 256 random bits, so
+// an unsalted digest is enough.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyFrom returns the API key a request presents, if any.
+func apiKeyFrom(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key, true
+	}
+	scheme, key, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if ok && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key), true
+	}
+	return "", false
+}
+
+// EnableAPIKeys accepts API keys from stores on the routes whose path starts with one of
+// routes ("*" matches every route). Keys with their own rate limit are throttled by limiter.
+func (a *Authenticator) EnableAPIKeys(routes []string, limiter *RateLimiter, stores ...APIKeyStore) {
+	a.apiKeyRoutes = routes
+	a.apiKeyLimiter = limiter
+	a.apiKeys = stores
+}
+
+func (a *Authenticator) apiKeyRoute(path string) bool {
+	for _, route := range a.apiKeyRoutes {
+		if route == "*" || strings.HasPrefix(path, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateAPIKey resolves the key presented on r. The caller acts with the first of the
+// key's roles the route allows, or its first role when the route allows any.
+func (a *Authenticator) authenticateAPIKey(r *http.Request, presented string, allowedRoles []Role) (*AuthContext, *APIKey, error) {
+	if len(a.apiKeys) == 0 || !a.apiKeyRoute(r.URL.Path) {
+		return nil, nil, errors.New("api keys are not accepted on this route")
+	}
+	id, secret, ok := strings.Cut(presented, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, nil, errors.New("api key must be in the format <id>.<secret>")
+	}
+	var key *APIKey
+	for _, store := range a.apiKeys {
+		if found, ok := store.LookupAPIKey(id); ok {
+			key = found
+			break
+		}
+	}
+	if key == nil {
+		return nil, nil, errors.New("unknown api key")
+	}
+	digest := HashAPIKeySecret(secret)
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(strings.ToLower(key.Hash))) != 1 {
+		return nil, nil, errors.New("invalid api key")
+	}
+	if len(key.Roles) == 0 {
+		return nil, nil, errors.New("api key has no roles")
+	}
+	role := key.Roles[0]
+	for _, candidate := range key.Roles {
+		if candidate.Allowed(allowedRoles...) {
+			role = candidate
+			break
+		}
+	}
+	subject := APIKeySubjectPrefix + key.ID
+	return &AuthContext{
+		Subject: subject,
+		NodeID:  subject,
+		State:   key.State,
+		Role:    role,
+	}, key, nil
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// AuditTx is a chaincode transaction a request submitted. TxID is empty when the submission
+// failed. Query marks an evaluation that was noted explicitly with NoteAuditQuery.
+type AuditTx struct {
+	Function string `json:"function"`
+	ArgsHash string `json:"args_hash"`
+	TxID     string `json:"tx_id,omitempty"`
+	Query    bool   `json:"query,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AuditNote collects who made an audited request and which transactions it submitted. The
+// audit middleware places it in the request context; RequireAuth and SubmitChaincode fill it.
+type AuditNote struct {
+	mu           sync.Mutex
+	closed       bool
+	actor        string
+	role         Role
+	transactions []AuditTx
+}
+
+type auditNoteKey struct{}
+
+// WithAuditNote attaches note to ctx.
+func WithAuditNote(ctx context.Context, note *AuditNote) context.Context {
+	return context.WithValue(ctx, auditNoteKey{}, note)
+}
+
+func auditNoteFrom(ctx context.Context) *AuditNote {
+	note, _ := ctx.Value(auditNoteKey{}).(*AuditNote)
+	return note
+}
+
+// Close returns what was noted and ignores later notes, such as those of a write that
+// continues asynchronously after its response.
+func (n *AuditNote) Close() (string, Role, []AuditTx) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	return n.actor, n.role, n.transactions
+}
+
+func noteAuditCaller(ctx context.Context, authCtx *AuthContext) {
+	note := auditNoteFrom(ctx)
+	if note == nil {
+		return
+	}
+	note.mu.Lock()
+	defer note.mu.Unlock()
+	if !note.closed {
+		note.actor, note.role = authCtx.Subject, authCtx.Role
+	}
+}
+
+// NoteAuditQuery notes a chaincode query in the request's audit entry. Queries are not noted
+// by default; callers whose reads must be traceable, such as the admin passthrough, note them.
+func NoteAuditQuery(ctx context.Context, args []string, err error) {
+	tx := AuditTx{Function: chaincodeFunction(args), ArgsHash: hashArgs(args), Query: true}
+	if err != nil {
+		tx.Error = err.Error()
+	}
+	noteAudit(ctx, tx)
+}
+
+func noteAuditTx(ctx context.Context, args []string, receipt *TxReceipt, err error) {
+	tx := AuditTx{Function: chaincodeFunction(args), ArgsHash: hashArgs(args)}
+	if receipt != nil {
+		tx.TxID = receipt.TxID
+	}
+	if err != nil {
+		tx.Error = err.Error()
+	}
+	noteAudit(ctx, tx)
+}
+
+func noteAudit(ctx context.Context, tx AuditTx) {
+	note := auditNoteFrom(ctx)
+	if note == nil {
+		return
+	}
+	note.mu.Lock()
+	defer note.mu.Unlock()
+	if !note.closed {
+		note.transactions = append(note.transactions, tx)
+	}
+}
+
+// hashArgs digests a call's arguments after the function name, so the audit trail can be
+// matched against a transaction without storing its payload.
+func hashArgs(args []string) string {
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	sum := sha256.Sum256([]byte(MustJSON(args)))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -22,6 +23,10 @@ const (
 	RoleAggregator     Role = "aggregator"
 	RoleAdmin          Role = "admin"
 	RoleCentralChecker Role = "central_checker"
+	RoleValidator      Role = "validator"
+
+	// RoleStateCoordinator reads the dashboard of the state named in its token's state claim.
+	RoleStateCoordinator Role = "state_coordinator"
 )
 
 // AuthContext contains the caller identity resolved from the JWT.
@@ -35,11 +40,55 @@ type AuthContext struct {
 	Token   string
 	Claims  *JWTClaims
 	Header  *TokenHeader
+
+	// AllowedBy names the authorization policy rule that allowed the request, if any.
+	AllowedBy string
 }
 
 // Authenticator validates and parses incoming JWT bearer tokens.
 type Authenticator struct {
 	secret []byte
+
+	// jwks verifies asymmetric tokens issued by an external identity provider. issuers maps
+	// each accepted iss claim to the roles it may grant (empty means any role) and audiences
+	// lists the aud values accepted from those tokens.
+	jwks      *JWKS
+	issuers   map[string][]Role
+	audiences []string
+
+	// tokens verifies the gateway's own tokens from /auth/token on every route, and sessions
+	// rejects those whose session has been revoked.
+	tokens   *TokenIssuer
+	sessions SessionChecker
+
+	// limiter throttles authenticated callers per subject and route class.
+	limiter *RateLimiter
+
+	// async takes over writes whose caller asked for an asynchronous response.
+	async AsyncSubmitter
+
+	// apiKeys authenticate service accounts on the routes matching apiKeyRoutes; keys with
+	// their own rate limit are throttled by apiKeyLimiter instead of limiter.
+	apiKeys       []APIKeyStore
+	apiKeyRoutes  []string
+	apiKeyLimiter *RateLimiter
+
+	// policy, when set, decides authenticated requests ahead of the routes' own roles.
+	policy *Policy
+}
+
+// SessionChecker reports whether the session behind a gateway-issued token is still active.
+type SessionChecker interface {
+	SessionActive(id string) bool
+}
+
+// AsyncSubmitter runs an authenticated request in the background and answers it with a
+// handle the caller polls for the outcome.
+type AsyncSubmitter interface {
+	// Accepts reports whether the request asked for, and may use, asynchronous handling.
+	Accepts(r *http.Request) bool
+	// Submit answers the request immediately and serves it through next in the background.
+	Submit(w http.ResponseWriter, r *http.Request, next http.Handler)
 }
 
 // NewAuthenticator constructs an Authenticator instance.
@@ -50,6 +99,41 @@ func NewAuthenticator(secret string) (*Authenticator, error) {
 	return &Authenticator{secret: []byte(secret)}, nil
 }
 
+// EnableJWKS accepts RS256, ES256, and EdDSA tokens signed by the keys published at the
+// provider's JWKS endpoint, provided their issuer is allowlisted and, when audiences are
+// configured, their aud claim names one of them.
+func (a *Authenticator) EnableJWKS(jwks *JWKS, issuers map[string][]Role, audiences []string) {
+	a.jwks = jwks
+	a.issuers = issuers
+	a.audiences = audiences
+}
+
+// EnableTokenIssuer accepts tokens minted by issuer on every route, including the ones that
+// otherwise require a trainer-signed token, as long as sessions reports their sid active.
+func (a *Authenticator) EnableTokenIssuer(issuer *TokenIssuer, sessions SessionChecker) {
+	a.tokens = issuer
+	a.sessions = sessions
+}
+
+// EnableRateLimit applies limiter to every authenticated route, keyed by JWT subject.
+func (a *Authenticator) EnableRateLimit(limiter *RateLimiter) {
+	a.limiter = limiter
+}
+
+// EnableAsync lets callers of authenticated routes opt into asynchronous handling.
+func (a *Authenticator) EnableAsync(submitter AsyncSubmitter) {
+	a.async = submitter
+}
+
+// Throttle rate limits an unauthenticated handler under class, keyed by client address. It
+// is a no-op when rate limiting is disabled.
+func (a *Authenticator) Throttle(class string, next http.Handler) http.Handler {
+	if a.limiter == nil {
+		return next
+	}
+	return a.limiter.Middleware(class, next)
+}
+
 // TokenHeader describes the JWT header fields the gateway cares about.
 type TokenHeader struct {
 	Alg string `json:"alg"`
@@ -59,13 +143,47 @@ type TokenHeader struct {
 
 // JWTClaims captures the subset of claims required by the gateway.
 type JWTClaims struct {
-	Subject string      `json:"sub"`
-	State   string      `json:"state"`
-	Cluster string      `json:"cluster,omitempty"`
-	Nation  string      `json:"nation,omitempty"`
-	Role    string      `json:"role"`
-	Expiry  json.Number `json:"exp"`
-	Issued  json.Number `json:"iat,omitempty"`
+	Subject  string      `json:"sub"`
+	State    string      `json:"state"`
+	Cluster  string      `json:"cluster,omitempty"`
+	Nation   string      `json:"nation,omitempty"`
+	Role     string      `json:"role"`
+	Expiry   json.Number `json:"exp"`
+	Issued   json.Number `json:"iat,omitempty"`
+	Issuer   string      `json:"iss,omitempty"`
+	Audience Audience    `json:"aud,omitempty"`
+	// SessionID is set on gateway-issued tokens and names their revocable session.
+	SessionID string `json:"sid,omitempty"`
+}
+
+// Audience is the aud claim, which may be a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON accepts both the string and the array form of the aud claim.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("aud claim must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether the audience includes any of the given values.
+func (a Audience) Contains(values ...string) bool {
+	for _, entry := range a {
+		for _, value := range values {
+			if entry == value {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // KeySpec instructs the authenticator how to verify a token signature.
@@ -73,9 +191,12 @@ type KeySpec struct {
 	Algorithm string
 	Secret    []byte
 	PublicKey []byte
+	// Key holds RSA and ECDSA public keys for RS256 and ES256.
+	Key crypto.PublicKey
 }
 
-// KeyFunc resolves the verification key for the token being processed.
+// KeyFunc resolves the verification key for the token being processed. Returning a nil
+// spec without an error falls back to the keys the route would use without a KeyFunc.
 type KeyFunc func(header *TokenHeader, claims *JWTClaims) (*KeySpec, error)
 
 // RequireAuth wraps an HTTP handler with JWT authentication and optional role checks.
@@ -84,18 +205,44 @@ func (a *Authenticator) RequireAuth(next http.Handler, allowedRoles ...Role) htt
 }
 
 // RequireAuthWithKeyFunc allows callers to override the verification key on a per-token basis.
+// Requests presenting an API key are authenticated with it instead, on the routes
+// EnableAPIKeys selected.
 func (a *Authenticator) RequireAuthWithKeyFunc(keyFunc KeyFunc, next http.Handler, allowedRoles ...Role) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authCtx, err := a.authenticateRequest(r, keyFunc)
+		var (
+			authCtx *AuthContext
+			apiKey  *APIKey
+			err     error
+		)
+		if presented, ok := apiKeyFrom(r); ok {
+			authCtx, apiKey, err = a.authenticateAPIKey(r, presented, allowedRoles)
+		} else {
+			authCtx, err = a.authenticateRequest(r, keyFunc)
+		}
 		if err != nil {
 			WriteErrorWithCode(w, http.StatusUnauthorized, ErrInvalidCredentials)
 			return
 		}
-		if len(allowedRoles) > 0 && !authCtx.Role.Allowed(allowedRoles...) {
-			WriteErrorWithCode(w, http.StatusForbidden, fmt.Errorf("role %s is not permitted", authCtx.Role))
+		noteAuditCaller(r.Context(), authCtx)
+		if err := a.authorize(r, authCtx, allowedRoles); err != nil {
+			WriteErrorWithCode(w, http.StatusForbidden, err)
 			return
 		}
+		switch {
+		case apiKey != nil && apiKey.RateLimit != nil && a.apiKeyLimiter != nil:
+			if !a.apiKeyLimiter.admitWith(w, *apiKey.RateLimit, RateClassOf(r), "sub:"+authCtx.Subject) {
+				return
+			}
+		case a.limiter != nil:
+			if !a.limiter.admit(w, RateClassOf(r), "sub:"+authCtx.Subject) {
+				return
+			}
+		}
 		ctx := WithAuthContext(r.Context(), authCtx)
+		if a.async != nil && a.async.Accepts(r) {
+			a.async.Submit(w, r.WithContext(ctx), next)
+			return
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -132,6 +279,9 @@ func (a *Authenticator) parseToken(tokenString string, keyFunc KeyFunc) (*AuthCo
 	if err := a.verifySignature(unsigned, signatureSegment, &header, &claims, keyFunc); err != nil {
 		return nil, err
 	}
+	if a.sessions != nil && claims.Issuer == GatewayIssuer && !a.sessions.SessionActive(claims.SessionID) {
+		return nil, errors.New("token session has been revoked or has expired")
+	}
 
 	if claims.Expiry == "" {
 		return nil, errors.New("token missing exp claim")
@@ -180,14 +330,27 @@ func (a *Authenticator) verifySignature(unsigned, signatureSegment string, heade
 		return verifyHMACSignature(unsigned, signatureSegment, keySpec.Secret)
 	case "EDDSA":
 		return verifyEd25519Signature(unsigned, signatureSegment, keySpec.PublicKey)
+	case "RS256":
+		return verifyRSASignature(unsigned, signatureSegment, keySpec.Key)
+	case "ES256":
+		return verifyECDSASignature(unsigned, signatureSegment, keySpec.Key)
 	default:
 		return fmt.Errorf("unsupported signing algorithm %s", keySpec.Algorithm)
 	}
 }
 
 func (a *Authenticator) resolveKey(header *TokenHeader, claims *JWTClaims, keyFunc KeyFunc) (*KeySpec, error) {
+	if a.tokens != nil && claims.Issuer == GatewayIssuer {
+		return a.tokens.keySpec(header)
+	}
 	if keyFunc != nil {
-		return keyFunc(header, claims)
+		spec, err := keyFunc(header, claims)
+		if spec != nil || err != nil {
+			return spec, err
+		}
+	}
+	if a.jwks != nil && !strings.EqualFold(header.Alg, "HS256") {
+		return a.providerKey(header, claims)
 	}
 	if len(a.secret) == 0 {
 		return nil, errors.New("shared-secret authentication is disabled")
@@ -198,6 +361,29 @@ func (a *Authenticator) resolveKey(header *TokenHeader, claims *JWTClaims, keyFu
 	return &KeySpec{Algorithm: "HS256", Secret: a.secret}, nil
 }
 
+// providerKey checks the issuer, audience, and issuer role grants of an identity-provider
+// token before looking up its signing key in the JWKS cache.
+func (a *Authenticator) providerKey(header *TokenHeader, claims *JWTClaims) (*KeySpec, error) {
+	issuer := strings.TrimSpace(claims.Issuer)
+	roles, ok := a.issuers[issuer]
+	if !ok {
+		return nil, fmt.Errorf("issuer %q is not allowed", issuer)
+	}
+	if len(roles) > 0 {
+		role, err := ParseRole(claims.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !role.Allowed(roles...) {
+			return nil, fmt.Errorf("issuer %q may not grant role %s", issuer, role)
+		}
+	}
+	if len(a.audiences) > 0 && !claims.Audience.Contains(a.audiences...) {
+		return nil, errors.New("token audience is not accepted")
+	}
+	return a.jwks.Key(header.KID, header.Alg)
+}
+
 func verifyHMACSignature(unsigned, signatureSegment string, secret []byte) error {
 	signature, err := base64.RawURLEncoding.DecodeString(signatureSegment)
 	if err != nil {
@@ -245,6 +431,10 @@ func ParseRole(value string) (Role, error) {
 		return RoleAdmin, nil
 	case string(RoleCentralChecker):
 		return RoleCentralChecker, nil
+	case string(RoleValidator):
+		return RoleValidator, nil
+	case string(RoleStateCoordinator):
+		return RoleStateCoordinator, nil
 	default:
 		return "", fmt.Errorf("unknown role %s", value)
 	}
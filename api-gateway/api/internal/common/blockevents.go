@@ -0,0 +1,174 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ChaincodeEvent is an event a valid transaction of a block set through SetEvent.
+type ChaincodeEvent struct {
+	BlockNumber uint64 `json:"block_number"`
+	TxID        string `json:"tx_id"`
+	ChaincodeID string `json:"chaincode_id"`
+	Name        string `json:"name"`
+	Payload     []byte `json:"payload"`
+	// Timestamp is the transaction's channel header timestamp in RFC 3339.
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// BlockEvents fetches block number of the context's channel through qscc and returns the
+// chaincode events of its valid transactions.
+func (f *FabricClient) BlockEvents(ctx context.Context, peerName, identity string, number uint64) ([]*ChaincodeEvent, error) {
+	block, err := f.qsccBlock(ctx, peerName, identity, "GetBlockByNumber", strconv.FormatUint(number, 10))
+	if err != nil {
+		return nil, err
+	}
+	return chaincodeEvents(block)
+}
+
+// Field numbers of the Fabric protos walked by chaincodeEvents.
+const (
+	headerTypeEndorserTransaction = 3
+	// metadataTransactionsFilter is the BlockMetadata index holding one validation code per
+	// transaction; 0 is VALID.
+	metadataTransactionsFilter = 2
+)
+
+// chaincodeEvents walks Block -> Envelope -> Payload -> Transaction -> ChaincodeAction ->
+// ChaincodeEvent without the Fabric protos, skipping transactions the committer marked
+// invalid.
+func chaincodeEvents(block []byte) ([]*ChaincodeEvent, error) {
+	number, err := blockNumber(block)
+	if err != nil {
+		return nil, err
+	}
+	var filter []byte
+	if metadata, ok := protoField(block, 3, 2); ok {
+		if entries := protoFields(metadata, 1, 2); len(entries) > metadataTransactionsFilter {
+			filter = entries[metadataTransactionsFilter]
+		}
+	}
+	data, _ := protoField(block, 2, 2)
+	var events []*ChaincodeEvent
+	for index, envelope := range protoFields(data, 1, 2) {
+		if index < len(filter) && filter[index] != 0 {
+			continue
+		}
+		payload, ok := protoField(envelope, 1, 2)
+		if !ok {
+			return nil, fmt.Errorf("block %d transaction %d has no payload", number, index)
+		}
+		header, _ := protoField(payload, 1, 2)
+		channelHeader, _ := protoField(header, 1, 2)
+		if protoVarint(channelHeader, 1) != headerTypeEndorserTransaction {
+			continue
+		}
+		txID, _ := protoField(channelHeader, 5, 2)
+		timestamp := ""
+		if ts, ok := protoField(channelHeader, 3, 2); ok {
+			timestamp = time.Unix(int64(protoVarint(ts, 1)), int64(protoVarint(ts, 2))).UTC().Format(time.RFC3339)
+		}
+		transaction, _ := protoField(payload, 2, 2)
+		for _, action := range protoFields(transaction, 1, 2) {
+			actionPayload, _ := protoField(action, 2, 2)
+			endorsed, _ := protoField(actionPayload, 2, 2)
+			response, _ := protoField(endorsed, 1, 2)
+			extension, _ := protoField(response, 2, 2)
+			raw, ok := protoField(extension, 2, 2)
+			if !ok {
+				continue
+			}
+			name, _ := protoField(raw, 3, 2)
+			if len(name) == 0 {
+				continue
+			}
+			chaincodeID, _ := protoField(raw, 1, 2)
+			eventPayload, _ := protoField(raw, 4, 2)
+			event := &ChaincodeEvent{
+				BlockNumber: number,
+				TxID:        string(txID),
+				ChaincodeID: string(chaincodeID),
+				Name:        string(name),
+				Payload:     eventPayload,
+				Timestamp:   timestamp,
+			}
+			if id, ok := protoField(raw, 2, 2); ok && event.TxID == "" {
+				event.TxID = string(id)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// protoFields returns every occurrence of field number with the given wire type, as
+// protoField does for the first.
+func protoFields(message []byte, number uint64, wireType uint64) [][]byte {
+	var values [][]byte
+	for len(message) > 0 {
+		value, rest, ok := protoNext(message, number, wireType)
+		if !ok {
+			break
+		}
+		values = append(values, value)
+		message = rest
+	}
+	return values
+}
+
+// protoNext finds the next occurrence of the field and returns it with the rest of the
+// message after it.
+func protoNext(message []byte, number uint64, wireType uint64) ([]byte, []byte, bool) {
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, nil, false
+		}
+		message = message[n:]
+		field, kind := key>>3, key&7
+		var value []byte
+		switch kind {
+		case 0:
+			_, n = binary.Uvarint(message)
+			if n <= 0 {
+				return nil, nil, false
+			}
+			value, message = message[:n], message[n:]
+		case 1:
+			if len(message) < 8 {
+				return nil, nil, false
+			}
+			value, message = message[:8], message[8:]
+		case 2:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return nil, nil, false
+			}
+			value, message = message[n:n+int(length)], message[n+int(length):]
+		case 5:
+			if len(message) < 4 {
+				return nil, nil, false
+			}
+			value, message = message[:4], message[4:]
+		default:
+			return nil, nil, false
+		}
+		if field == number && kind == wireType {
+			return value, message, true
+		}
+	}
+	return nil, nil, false
+}
+
+// protoVarint reads a varint field, zero when absent as proto3 omits zero values.
+func protoVarint(message []byte, number uint64) uint64 {
+	raw, ok := protoField(message, number, 0)
+	if !ok {
+		return 0
+	}
+	value, _ := binary.Uvarint(raw)
+	return value
+}
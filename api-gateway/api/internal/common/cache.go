@@ -0,0 +1,159 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache namespaces for ledger reads that change rarely.
+const (
+	CacheWhitelist      = "whitelist"
+	CacheTrainingConfig = "training_config"
+)
+
+// QueryCache keeps decoded ledger query results in memory for a per-namespace TTL. Entries
+// are keyed by the channel target of the request, so channels never share results. Cached
+// values are shared between callers and must not be modified.
+type QueryCache struct {
+	cfg *Config
+
+	mu         sync.Mutex
+	namespaces map[string]*cacheNamespace
+
+	hits          *CounterVec
+	misses        *CounterVec
+	invalidations *CounterVec
+}
+
+type cacheNamespace struct {
+	entries map[string]cacheEntry
+	hits    uint64
+	misses  uint64
+	// generation advances on invalidation so loads that started before it are not cached.
+	generation uint64
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// CacheStats describes one namespace for the admin endpoint.
+type CacheStats struct {
+	Namespace string `json:"namespace"`
+	TTL       string `json:"ttl"`
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+}
+
+// NewQueryCache builds a cache whose TTLs come from CACHE_TTL and CACHE_TTLS.
+func NewQueryCache(cfg *Config, metrics *Metrics) *QueryCache {
+	return &QueryCache{
+		cfg:           cfg,
+		namespaces:    map[string]*cacheNamespace{},
+		hits:          metrics.Counter("cache_hits_total", "Ledger reads answered from the query cache.", "cache"),
+		misses:        metrics.Counter("cache_misses_total", "Ledger reads the query cache had to load.", "cache"),
+		invalidations: metrics.Counter("cache_invalidations_total", "Query cache namespaces cleared.", "cache", "reason"),
+	}
+}
+
+// TTL returns how long namespace entries live; zero disables caching for it.
+func (c *QueryCache) TTL(namespace string) time.Duration {
+	if ttl, ok := c.cfg.CacheTTLs[namespace]; ok {
+		return ttl
+	}
+	return c.cfg.CacheTTL
+}
+
+// CachedLoad returns the cached value of key in namespace, calling load and caching its
+// result on a miss. Errors are not cached. A nil cache always loads.
+func CachedLoad[T any](ctx context.Context, c *QueryCache, namespace, key string, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+	ttl := c.TTL(namespace)
+	if ttl <= 0 {
+		return load()
+	}
+	key = c.cfg.Target(ctx).Name + "\x00" + key
+	now := time.Now()
+	c.mu.Lock()
+	ns := c.namespace(namespace)
+	if entry, ok := ns.entries[key]; ok && now.Before(entry.expires) {
+		ns.hits++
+		c.mu.Unlock()
+		c.hits.Inc(namespace)
+		return entry.value.(T), nil
+	}
+	ns.misses++
+	generation := ns.generation
+	c.mu.Unlock()
+	c.misses.Inc(namespace)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	if ns := c.namespace(namespace); ns.generation == generation {
+		ns.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops every entry of the namespaces (all of them when none are given) and
+// counts the reason.
+func (c *QueryCache) Invalidate(reason string, namespaces ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if len(namespaces) == 0 {
+		namespaces = sortedKeys(c.namespaces)
+	}
+	for _, name := range namespaces {
+		ns := c.namespace(name)
+		ns.entries = map[string]cacheEntry{}
+		ns.generation++
+	}
+	c.mu.Unlock()
+	for _, name := range namespaces {
+		c.invalidations.Inc(name, reason)
+	}
+}
+
+// Stats reports every namespace used so far, with expired entries pruned.
+func (c *QueryCache) Stats() []CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	stats := make([]CacheStats, 0, len(c.namespaces))
+	for _, name := range sortedKeys(c.namespaces) {
+		ns := c.namespaces[name]
+		for key, entry := range ns.entries {
+			if !now.Before(entry.expires) {
+				delete(ns.entries, key)
+			}
+		}
+		stats = append(stats, CacheStats{
+			Namespace: name,
+			TTL:       c.TTL(name).String(),
+			Entries:   len(ns.entries),
+			Hits:      ns.hits,
+			Misses:    ns.misses,
+		})
+	}
+	return stats
+}
+
+func (c *QueryCache) namespace(name string) *cacheNamespace {
+	ns, ok := c.namespaces[name]
+	if !ok {
+		ns = &cacheNamespace{entries: map[string]cacheEntry{}}
+		c.namespaces[name] = ns
+	}
+	return ns
+}
@@ -0,0 +1,176 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultChannel is the selector of the FABRIC_CHANNEL/FABRIC_CHAINCODE pair.
+const DefaultChannel = "default"
+
+// ChannelHeader selects a configured channel for one request; the `channel` query
+// parameter does the same.
+const ChannelHeader = "X-Fabric-Channel"
+
+// ChannelTarget is a channel/chaincode pair the gateway may invoke, addressed by selector.
+type ChannelTarget struct {
+	Name      string `json:"name"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+}
+
+type channelKey struct{}
+
+// WithChannel routes Fabric calls made with the returned context to the named target.
+func WithChannel(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, channelKey{}, name)
+}
+
+// Target returns the channel/chaincode pair for ctx, defaulting to FABRIC_CHANNEL.
+func (c *Config) Target(ctx context.Context) ChannelTarget {
+	if name, ok := ctx.Value(channelKey{}).(string); ok {
+		if target, ok := c.Channels[name]; ok {
+			return target
+		}
+	}
+	return ChannelTarget{Name: DefaultChannel, Channel: c.Channel, Chaincode: c.Chaincode}
+}
+
+// ModuleContext pins ctx to the channel FABRIC_MODULE_CHANNELS assigns module, if any.
+// Modules that read another module's records use it so cross-module lookups follow that
+// module's channel.
+func (c *Config) ModuleContext(ctx context.Context, module string) context.Context {
+	if name, ok := c.ModuleChannels[module]; ok {
+		return WithChannel(ctx, name)
+	}
+	return ctx
+}
+
+// ChannelTargets lists the configured targets, default first.
+func (c *Config) ChannelTargets() []ChannelTarget {
+	targets := []ChannelTarget{{Name: DefaultChannel, Channel: c.Channel, Chaincode: c.Chaincode}}
+	for _, name := range sortedKeys(c.Channels) {
+		if name != DefaultChannel {
+			targets = append(targets, c.Channels[name])
+		}
+	}
+	return targets
+}
+
+// parseChannels reads FABRIC_CHANNELS selector=channel[/chaincode] pairs; the chaincode
+// defaults to FABRIC_CHAINCODE.
+func parseChannels(raw map[string]string, channel, chaincode string) (map[string]ChannelTarget, error) {
+	targets := map[string]ChannelTarget{DefaultChannel: {Name: DefaultChannel, Channel: channel, Chaincode: chaincode}}
+	for name, value := range raw {
+		if name == DefaultChannel {
+			return nil, fmt.Errorf("FABRIC_CHANNELS: %q is reserved for FABRIC_CHANNEL", DefaultChannel)
+		}
+		channelName, chaincodeName, _ := strings.Cut(value, "/")
+		channelName, chaincodeName = strings.TrimSpace(channelName), strings.TrimSpace(chaincodeName)
+		if channelName == "" {
+			return nil, fmt.Errorf("FABRIC_CHANNELS: %s needs a channel name", name)
+		}
+		if chaincodeName == "" {
+			chaincodeName = chaincode
+		}
+		targets[name] = ChannelTarget{Name: name, Channel: channelName, Chaincode: chaincodeName}
+	}
+	return targets, nil
+}
+
+// channelNames lists the distinct channels across all targets.
+func (c *Config) channelNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, target := range c.ChannelTargets() {
+		if !seen[target.Channel] {
+			seen[target.Channel] = true
+			names = append(names, target.Channel)
+		}
+	}
+	return names
+}
+
+// ChannelRouter assigns requests a channel target: an explicit selector from the request
+// wins, otherwise the target pinned to the route's module.
+type ChannelRouter struct {
+	cfg    *Config
+	routes []channelRoute
+}
+
+type channelRoute struct {
+	segments []string
+	target   string
+}
+
+// NewChannelRouter constructs a router with no pinned routes.
+func NewChannelRouter(cfg *Config) *ChannelRouter {
+	return &ChannelRouter{cfg: cfg}
+}
+
+// Pin routes requests matching any endpoint template (e.g. `/data/{data_id}`) to target.
+func (r *ChannelRouter) Pin(target string, endpoints ...string) {
+	for _, endpoint := range endpoints {
+		r.routes = append(r.routes, channelRoute{segments: strings.Split(strings.Trim(endpoint, "/"), "/"), target: target})
+	}
+	// Prefer routes with more literal segments when templates overlap.
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return literalSegments(r.routes[i].segments) > literalSegments(r.routes[j].segments)
+	})
+}
+
+// Middleware tags each request's context with its channel target. Unknown selectors are
+// rejected with 400 so a typo never falls back to the default channel.
+func (r *ChannelRouter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimSpace(req.Header.Get(ChannelHeader))
+		if name == "" {
+			name = strings.TrimSpace(req.URL.Query().Get("channel"))
+		}
+		if name != "" {
+			if _, ok := r.cfg.Channels[name]; !ok {
+				WriteErrorWithCode(w, http.StatusBadRequest, NewStatusError(http.StatusBadRequest, fmt.Sprintf("unknown channel %q; allowed: %s", name, strings.Join(sortedKeys(r.cfg.Channels), ", "))))
+				return
+			}
+		} else {
+			name = r.match(req.URL.Path)
+		}
+		if name != "" {
+			req = req.WithContext(WithChannel(req.Context(), name))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (r *ChannelRouter) match(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range r.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range route.segments {
+			if !strings.HasPrefix(segment, "{") && segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.target
+		}
+	}
+	return ""
+}
+
+func literalSegments(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,174 @@
+package common
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Compressor gzips responses for clients that accept it. Bodies smaller than minBytes, binary
+// downloads and SSE streams are sent as they are.
+type Compressor struct {
+	minBytes int
+	pool     sync.Pool
+}
+
+// NewCompressor builds the compression middleware from the response settings.
+func NewCompressor(cfg *Config) *Compressor {
+	return &Compressor{
+		minBytes: cfg.CompressionMinBytes,
+		pool: sync.Pool{New: func() any {
+			writer, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+			return writer
+		}},
+	}
+}
+
+// Middleware compresses next's responses when the request sends Accept-Encoding: gzip.
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writer := &gzipWriter{ResponseWriter: w, compressor: c, status: http.StatusOK}
+		defer writer.close()
+		next.ServeHTTP(writer, r)
+	})
+}
+
+// gzipWriter holds back the start of a response until it knows whether it is worth
+// compressing: the body must reach minBytes (or be flushed) and have a compressible type.
+type gzipWriter struct {
+	http.ResponseWriter
+	compressor  *Compressor
+	status      int
+	wroteHeader bool
+	pending     []byte
+	gz          *gzip.Writer
+	// decided is set once the response is either compressing (gz != nil) or passing through.
+	decided bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	if !w.compressible() {
+		w.startPlain()
+	}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if !w.decided && !w.compressible() {
+		w.startPlain()
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.pending = append(w.pending, p...)
+	if len(w.pending) >= w.compressor.minBytes {
+		w.startGzip()
+	}
+	return len(p), nil
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		if w.compressible() && len(w.pending) > 0 {
+			w.startGzip()
+		} else {
+			w.startPlain()
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether the status and headers so far allow compression.
+func (w *gzipWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		return true
+	}
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	return strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json")
+}
+
+func (w *gzipWriter) startPlain() {
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.pending) > 0 {
+		_, _ = w.ResponseWriter.Write(w.pending)
+		w.pending = nil
+	}
+}
+
+func (w *gzipWriter) startGzip() {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.pending))
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		// The compressed bytes differ from the tagged ones, so the tag can only stay weak.
+		header.Set("ETag", "W/"+etag)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.gz = w.compressor.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, _ = w.gz.Write(w.pending)
+	w.pending = nil
+}
+
+// close sends a response that never reached minBytes as it is and finishes the gzip stream.
+func (w *gzipWriter) close() {
+	if !w.decided {
+		w.startPlain()
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.compressor.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip (and does not refuse it
+// with q=0).
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		params = strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return params != "q=0" && params != "q=0.0" && params != "q=0.000"
+	}
+	return false
+}
@@ -1,20 +1,30 @@
 package common
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Config captures all runtime settings used by the API gateway.
 type Config struct {
+	// Port is the HTTP listen port.
+	Port string
+
 	Channel         string
 	Chaincode       string
 	MSPID           string
 	OrgCryptoPath   string
+	OrgDomain       string
 	AdminIdentity   string
 	AdminMSPPath    string
 	OrdererEndpoint string
@@ -22,29 +32,292 @@ type Config struct {
 	OrdererTLSCA    string
 	FabricCfgPath   string
 	Peers           map[string]PeerConfig
-	DefaultPeer     string
-	AuthSecret      string
-	TrainerDBPath   string
-	AdminPublicKey  []byte
-	JobID           string
+	// Orgs holds the identity profile of each org the gateway transacts as, including
+	// DefaultOrg; StateOrgs assigns trainer states to an org.
+	Orgs      map[string]OrgProfile
+	StateOrgs map[string]string
+	// Channels maps channel selectors to channel/chaincode pairs; "default" is always
+	// FABRIC_CHANNEL/FABRIC_CHAINCODE. ModuleChannels pins modules to a selector.
+	Channels       map[string]ChannelTarget
+	ModuleChannels map[string]string
+	DefaultPeer    string
+	AuthSecret     string
+	TrainerDBPath  string
+	TrainerStore   string
+	AdminPublicKey []byte
+	JobID          string
+
+	// EndorsementOrgs maps channel selectors to the orgs (and peer counts) that must endorse
+	// invokes; EndorsementDiscovery asks the peers' discovery service instead, caching each
+	// layout for EndorsementDiscoveryTTL.
+	EndorsementOrgs         map[string]map[string]int
+	EndorsementDiscovery    bool
+	EndorsementDiscoveryTTL time.Duration
+
+	// TrainerStoreRehydrate restores missing enrollments from the on-chain whitelist at startup.
+	TrainerStoreRehydrate bool
+	// DIDRegistryRequired makes trainer registration require an active DID in the on-chain registry.
+	DIDRegistryRequired bool
+
+	// FabricCAURL enables per-trainer identity enrollment against a Fabric CA (empty disables).
+	// The CA admin's fabric-ca-client home registers identities; enrolled ones are kept in the
+	// WalletType wallet under WalletPath, classified by the FabricCAMSPConfig NodeOU template.
+	FabricCAURL       string
+	FabricCAName      string
+	FabricCATLSCert   string
+	FabricCAAdminHome string
+	FabricCAMSPConfig string
+	WalletType        string
+	WalletPath        string
+	// WalletEncryption seals wallet private keys at rest: "none", "secret" (AES-256-GCM under
+	// a key derived from WalletSecret with WalletKDF) or "kms" (a transit engine's named key).
+	WalletEncryption    string
+	WalletSecret        string
+	WalletKDF           string
+	WalletKDFIterations int
+	WalletKMSURL        string
+	WalletKMSKey        string
+	WalletKMSToken      string
+
+	EvaluationMetric   string
+	EvaluationQuorum   int
+	EvaluationMinScore float64
+
+	AnchorEndpoint   string
+	AnchorAuthToken  string
+	AnchorInterval   time.Duration
+	AnchorNamespaces []string
+
+	EventsPollInterval time.Duration
+
+	// RoundDeadlineInterval is how often the round scheduler looks for open rounds past their
+	// deadline; zero disables it. RoundMinQuorum overrides the jobs' min_clients when positive.
+	RoundDeadlineInterval time.Duration
+	RoundMinQuorum        int
+
+	IPFSAPIURL              string
+	ArtifactMaxBytes        int64
+	ArtifactTransferTimeout time.Duration
+
+	// StorageDriver moves payloads of at least StorageOffloadBytes off-chain: "none",
+	// "filesystem" (under StoragePath), "ipfs" (IPFSAPIURL) or "s3" (StorageS3). Only a
+	// pointer carrying the content's SHA-256 is committed.
+	StorageDriver       string
+	StorageOffloadBytes int
+	StoragePath         string
+	StorageS3           S3Config
+
+	// ModelDuplicates decides what happens to a model commit whose payload hash matches a
+	// model already committed to the same layer, scope and round: "allow" commits it and
+	// reports the earlier models, "reject" refuses it with 409.
+	ModelDuplicates string
+
+	// PayloadEncryption seals model and convergence payloads before they are committed:
+	// "none", "secret" (per-job data keys wrapped under a key derived from PayloadSecret with
+	// WalletKDF) or "kms" (wrapped by a transit engine's named key). Wrapped data keys are kept
+	// in PayloadKeyringPath, and only PayloadDecryptRoles read payloads decrypted.
+	PayloadEncryption   string
+	PayloadSecret       string
+	PayloadKMSURL       string
+	PayloadKMSKey       string
+	PayloadKMSToken     string
+	PayloadKeyringPath  string
+	PayloadDecryptRoles []Role
+
+	// WebhooksPath stores the registered webhooks with their signing secrets. Each delivery
+	// is tried up to WebhookMaxAttempts times, WebhookTimeout each, waiting WebhookRetryBackoff
+	// before the first retry and twice as long before every later one.
+	WebhooksPath        string
+	WebhookMaxAttempts  int
+	WebhookRetryBackoff time.Duration
+	WebhookTimeout      time.Duration
+
+	// EventBridge republishes lifecycle events and, with EventBridgeChaincodeEvents, the
+	// chaincode events of every new block to "kafka" (through the REST proxy at
+	// EventBridgeURL) or "nats" (the server at EventBridgeURL); "none" turns it off. An event
+	// goes to EventBridgeTopics[type], else EventBridgeTopicPrefix followed by its type; a
+	// topic of "off" drops the type. EventBridgeCursorPath remembers the next block to scan.
+	EventBridge                string
+	EventBridgeURL             string
+	EventBridgeTopics          map[string]string
+	EventBridgeTopicPrefix     string
+	EventBridgeChaincodeEvents bool
+	EventBridgeCursorPath      string
+
+	StateDatabase string
+
+	FabricRetry RetryPolicy
+	// FabricQueryTimeout bounds each peer query and FabricInvokeTimeout each invoke attempt;
+	// 0 leaves them bounded only by the request.
+	FabricQueryTimeout  time.Duration
+	FabricInvokeTimeout time.Duration
+	// FabricFanOutFunctions lists the query functions sent to every healthy peer at once,
+	// answered by the first peer to reply.
+	FabricFanOutFunctions []string
+	// FabricReceiptBlocks looks up the block of each committed transaction so write
+	// responses can report it; it costs one extra qscc query per invoke.
+	FabricReceiptBlocks bool
+	// FabricJSONArgs sends multi-argument chaincode calls to their JSON payload overloads
+	// instead of the positional functions.
+	FabricJSONArgs bool
+	// FabricTransport is how chaincode calls reach the ledger: "cli" runs the peer CLI
+	// against the configured network, "mock" serves them from an in-process ledger for local
+	// development (see FabricTransportMock).
+	FabricTransport string
+
+	// PeerDiscovery replaces the static peer/orderer topology with the one the channel's
+	// discovery service reports, refreshed every PeerDiscoveryInterval.
+	PeerDiscovery         bool
+	PeerDiscoveryInterval time.Duration
+
+	// ConfigReloadInterval is how often the configuration file is checked for peer routing
+	// changes (0 disables).
+	ConfigReloadInterval time.Duration
+
+	// CacheTTL is how long ledger reads stay in the query cache (0 disables it); CacheTTLs
+	// overrides it per namespace.
+	CacheTTL  time.Duration
+	CacheTTLs map[string]time.Duration
+
+	IdempotencyTTL time.Duration
+	// SubmissionTTL is how long the outcome of an asynchronous write stays pollable.
+	SubmissionTTL time.Duration
+
+	// AuthJWKSURL points at an external identity provider's JWKS document; empty disables
+	// asymmetric provider tokens. AuthIssuers maps each accepted issuer to the roles it may
+	// grant (empty means any) and AuthAudience lists the accepted aud values.
+	AuthJWKSURL     string
+	AuthJWKSRefresh time.Duration
+	AuthIssuers     map[string][]Role
+	AuthAudience    []string
+
+	// AuthTokenKey signs the gateway's own /auth/token tokens (Ed25519 seed or private key;
+	// empty generates one per process). AuthTokenTTL bounds their lifetime and
+	// AuthChallengeTTL how long a sign-in challenge can be answered. AuthRefreshTTL is the
+	// absolute lifetime of a session and its refresh tokens.
+	AuthTokenKey     []byte
+	AuthTokenTTL     time.Duration
+	AuthChallengeTTL time.Duration
+	AuthRefreshTTL   time.Duration
+
+	// RateLimits maps route classes (read, write, auth) to token buckets; a class without an
+	// entry is not limited.
+	RateLimits map[string]RateLimit
+
+	// APIKeys are the service-account keys from API_KEYS, by ID. APIKeyRoutes lists the path
+	// prefixes that accept API keys ("*" for every authenticated route).
+	APIKeys      map[string]*APIKey
+	APIKeyRoutes []string
+
+	// AuthzPolicyPath is the authorization policy file evaluated on every authenticated
+	// request (see Policy); empty keeps the built-in role checks alone.
+	AuthzPolicyPath string
+
+	// CORS lets browser applications call the gateway; no allowed origin disables it.
+	CORS CORSConfig
+
+	// AuditLog enables the append-only audit trail of state-changing and admin requests at
+	// AuditLogPath; AuditExclude lists path prefixes left out of it. With AuditAnchorBatch
+	// set, every batch of that many entries (or whatever accumulated after
+	// AuditAnchorInterval) is anchored on-chain.
+	AuditLog            bool
+	AuditLogPath        string
+	AuditExclude        []string
+	AuditAnchorBatch    int
+	AuditAnchorInterval time.Duration
+
+	// ConsistencyInterval is how often the consistency checker compares peers (0 disables the
+	// background check); ConsistencyNamespaces are the key ranges it digests on every peer
+	// and ConsistencyMaxLag the block lag it tolerates before reporting a peer as stale.
+	ConsistencyInterval   time.Duration
+	ConsistencyNamespaces []string
+	ConsistencyMaxLag     int
+
+	// AdminChaincodeFunctions lists the chaincode functions admins may call through the
+	// /admin/chaincode passthrough; empty refuses every call.
+	AdminChaincodeFunctions []string
+
+	// MaxBodyBytes caps request bodies (0 disables); BodyLimits overrides it per route and
+	// PayloadSchemas maps routes to JSON schema files their bodies must satisfy.
+	MaxBodyBytes   int64
+	BodyLimits     map[string]int64
+	PayloadSchemas map[string]string
+
+	// ResponseCompression gzips responses of at least CompressionMinBytes for clients that
+	// accept it; ResponseETags tags GET responses with a content hash and answers a matching
+	// If-None-Match with 304.
+	ResponseCompression bool
+	CompressionMinBytes int
+	ResponseETags       bool
+
+	// ResponseFieldRoles maps JSON response fields to the roles allowed to see them; whitelist
+	// and model responses drop them for every other role.
+	ResponseFieldRoles map[string][]Role
+
+	// ReadPeers and WritePeers name the peers queries and invokes may use; empty allows every
+	// peer.
+	ReadPeers  []string
+	WritePeers []string
+	// PeerSelection is the strategy SelectPeer spreads requests with (see PeerSelection*).
+	PeerSelection        string
+	PeerBreakerThreshold int
+	PeerBreakerCooldown  time.Duration
+	PeerHealthInterval   time.Duration
+	// ReadinessTimeout bounds each dependency check of /readyz.
+	ReadinessTimeout time.Duration
+	// StartupBackoff and StartupBackoffMax pace the retries of the background startup steps.
+	StartupBackoff    time.Duration
+	StartupBackoffMax time.Duration
+
+	// Tracing follows the standard OTEL_* variables; an empty endpoint disables export.
+	TracingEndpoint    string
+	TracingHeaders     map[string]string
+	TracingServiceName string
+	TracingSampleRatio float64
 
 	mspCache map[string]string
 	mspMu    sync.RWMutex
 }
 
-// PeerConfig captures the TLS material and address for an endorsing peer.
+// S3Config locates the S3-compatible bucket, e.g. on MinIO, payloads are offloaded to.
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// RichQueriesEnabled reports whether the peers use CouchDB and accept selector queries.
+func (c *Config) RichQueriesEnabled() bool {
+	return c.StateDatabase == "couchdb"
+}
+
+// PeerConfig captures the TLS material and address for an endorsing peer and the org whose
+// identities it is called with.
 type PeerConfig struct {
 	Name    string
 	Address string
 	TLSPath string
+	Org     string
 }
 
-// LoadConfig builds a Config instance from environment variables.
+// LoadConfig builds a Config instance from environment variables and, when CONFIG_FILE is
+// set, the configuration file it names.
 func LoadConfig() (*Config, error) {
+	return LoadConfigFrom(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadConfigFrom builds a Config from the file at path (empty for none) with environment
+// variables taking precedence over its values.
+func LoadConfigFrom(path string) (*Config, error) {
+	if err := loadConfigFile(path); err != nil {
+		return nil, err
+	}
 	channel := fallbackEnv("FABRIC_CHANNEL", "nebulachannel")
 	chaincode := fallbackEnv("FABRIC_CHAINCODE", "basic")
 	mspID := fallbackEnv("MSP_ID", "Org1MSP")
-	orgPath := os.Getenv("ORG_CRYPTO_PATH")
+	orgPath := setting("ORG_CRYPTO_PATH")
 	if orgPath == "" {
 		return nil, errors.New("ORG_CRYPTO_PATH must be set")
 	}
@@ -55,11 +328,43 @@ func LoadConfig() (*Config, error) {
 	peerDomain := fallbackEnv("ORG_DOMAIN", "org1.nebula.com")
 	fabricCfgPath := fallbackEnv("FABRIC_CFG_PATH", "/etc/hyperledger/fabric")
 	trainerDBPath := fallbackEnv("TRAINER_DB_PATH", "/data/trainers.json")
-	adminKey, err := parseAdminKey(os.Getenv("ADMIN_PUBLIC_KEY"))
+	adminKey, err := parseAdminKey(setting("ADMIN_PUBLIC_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	channels, err := parseChannels(mapEnv("FABRIC_CHANNELS"), channel, chaincode)
 	if err != nil {
 		return nil, err
 	}
-	peers, err := parsePeerConfig(os.Getenv("PEER_ENDPOINTS"), orgPath, peerDomain)
+	moduleChannels := mapEnv("FABRIC_MODULE_CHANNELS")
+	for module, name := range moduleChannels {
+		if _, ok := channels[name]; !ok {
+			return nil, fmt.Errorf("FABRIC_MODULE_CHANNELS: module %s uses unknown channel %s", module, name)
+		}
+	}
+	orgs, err := parseOrgProfiles(mapEnv("ORG_PROFILES"), OrgProfile{Name: DefaultOrg, MSPID: mspID, CryptoPath: orgPath, Domain: peerDomain, AdminIdentity: admin})
+	if err != nil {
+		return nil, err
+	}
+	stateOrgs := mapEnv("STATE_ORGS")
+	for state, org := range stateOrgs {
+		if _, ok := orgs[org]; !ok {
+			return nil, fmt.Errorf("STATE_ORGS: state %s uses unknown org %s", state, org)
+		}
+	}
+	endorsementOrgs, err := parseEndorsementOrgs(mapEnv("ENDORSEMENT_ORGS"), channels, orgs)
+	if err != nil {
+		return nil, err
+	}
+	endorsementDiscovery, err := boolEnv("ENDORSEMENT_DISCOVERY", false)
+	if err != nil {
+		return nil, err
+	}
+	endorsementDiscoveryTTL, err := durationEnv("ENDORSEMENT_DISCOVERY_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := parsePeerConfig(setting("PEER_ENDPOINTS"), orgs)
 	if err != nil {
 		return nil, err
 	}
@@ -73,20 +378,391 @@ func LoadConfig() (*Config, error) {
 			break
 		}
 	}
-	authSecret := os.Getenv("AUTH_JWT_SECRET")
+	authSecret := setting("AUTH_JWT_SECRET")
 	if authSecret == "" {
 		return nil, errors.New("AUTH_JWT_SECRET must be set")
 	}
+	jwksURL := strings.TrimSpace(setting("AUTH_JWKS_URL"))
+	jwksRefresh, err := durationEnv("AUTH_JWKS_REFRESH", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	authIssuers, err := issuerEnv("AUTH_JWT_ISSUERS")
+	if err != nil {
+		return nil, err
+	}
+	if jwksURL != "" && len(authIssuers) == 0 {
+		return nil, errors.New("AUTH_JWT_ISSUERS must list the accepted issuers when AUTH_JWKS_URL is set")
+	}
+	apiKeys, err := apiKeyEnv("API_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	corsCredentials, err := boolEnv("CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return nil, err
+	}
+	corsMaxAge, err := durationEnv("CORS_MAX_AGE", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	corsOrigins := listEnv("CORS_ALLOWED_ORIGINS", nil)
+	for _, origin := range corsOrigins {
+		if origin == "*" && corsCredentials {
+			return nil, errors.New("CORS_ALLOW_CREDENTIALS cannot be combined with CORS_ALLOWED_ORIGINS=*; list the origins")
+		}
+	}
+	auditLog, err := boolEnv("AUDIT_LOG", true)
+	if err != nil {
+		return nil, err
+	}
+	auditAnchorBatch, err := intEnv("AUDIT_ANCHOR_BATCH", 0)
+	if err != nil {
+		return nil, err
+	}
+	if auditAnchorBatch < 0 {
+		return nil, errors.New("AUDIT_ANCHOR_BATCH must not be negative")
+	}
+	auditAnchorInterval, err := durationEnv("AUDIT_ANCHOR_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	var tokenKey []byte
+	if raw := strings.TrimSpace(setting("AUTH_TOKEN_SIGNING_KEY")); raw != "" {
+		if tokenKey, err = base64.StdEncoding.DecodeString(raw); err != nil {
+			return nil, fmt.Errorf("failed to decode AUTH_TOKEN_SIGNING_KEY: %w", err)
+		}
+	}
+	tokenTTL, err := durationEnv("AUTH_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	challengeTTL, err := durationEnv("AUTH_CHALLENGE_TTL", 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := durationEnv("AUTH_REFRESH_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	rateLimits, err := rateLimitEnv("RATE_LIMITS", map[string]RateLimit{
+		RateClassRead:  {Rate: 20, Burst: 40},
+		RateClassWrite: {Rate: 5, Burst: 10},
+		RateClassAuth:  {Rate: 1, Burst: 5},
+	})
+	if err != nil {
+		return nil, err
+	}
+	maxBodyBytes, err := intEnv("MAX_BODY_BYTES", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	consistencyInterval, err := durationEnv("CONSISTENCY_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	consistencyMaxLag, err := intEnv("CONSISTENCY_MAX_LAG", 2)
+	if err != nil {
+		return nil, err
+	}
+	responseCompression, err := boolEnv("RESPONSE_COMPRESSION", true)
+	if err != nil {
+		return nil, err
+	}
+	compressionMinBytes, err := intEnv("COMPRESSION_MIN_BYTES", 1024)
+	if err != nil {
+		return nil, err
+	}
+	responseETags, err := boolEnv("RESPONSE_ETAGS", true)
+	if err != nil {
+		return nil, err
+	}
+	responseFieldRoles, err := fieldRolesEnv("RESPONSE_FIELD_ROLES", DefaultResponseFieldRoles)
+	if err != nil {
+		return nil, err
+	}
+	bodyLimits := map[string]int64{}
+	for route, value := range mapEnv("BODY_LIMITS") {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("BODY_LIMITS: limit for %s must be a non-negative integer", route)
+		}
+		bodyLimits[route] = limit
+	}
+	evalQuorum, err := intEnv("EVALUATION_QUORUM", 1)
+	if err != nil {
+		return nil, err
+	}
+	evalMinScore, err := floatEnv("EVALUATION_MIN_SCORE", 0)
+	if err != nil {
+		return nil, err
+	}
+	anchorInterval, err := durationEnv("ANCHOR_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	eventsPollInterval, err := durationEnv("EVENTS_POLL_INTERVAL", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	roundDeadlineInterval, err := durationEnv("ROUND_DEADLINE_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	roundMinQuorum, err := intEnv("ROUND_MIN_QUORUM", 0)
+	if err != nil {
+		return nil, err
+	}
+	if roundMinQuorum < 0 {
+		return nil, errors.New("ROUND_MIN_QUORUM must not be negative")
+	}
+	rehydrate, err := boolEnv("TRAINER_STORE_REHYDRATE", true)
+	if err != nil {
+		return nil, err
+	}
+	didRequired, err := boolEnv("DID_REGISTRY_REQUIRED", false)
+	if err != nil {
+		return nil, err
+	}
+	retryAttempts, err := intEnv("FABRIC_RETRY_MAX_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	retryInitial, err := durationEnv("FABRIC_RETRY_INITIAL_BACKOFF", 200*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	retryMax, err := durationEnv("FABRIC_RETRY_MAX_BACKOFF", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	queryTimeout, err := durationEnv("FABRIC_QUERY_TIMEOUT", 20*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	invokeTimeout, err := durationEnv("FABRIC_INVOKE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	receiptBlocks, err := boolEnv("FABRIC_RECEIPT_BLOCKS", true)
+	if err != nil {
+		return nil, err
+	}
+	jsonArgs, err := boolEnv("FABRIC_JSON_ARGS", true)
+	if err != nil {
+		return nil, err
+	}
+	peerDiscovery, err := boolEnv("PEER_DISCOVERY", false)
+	if err != nil {
+		return nil, err
+	}
+	fabricTransport := strings.ToLower(fallbackEnv("FABRIC_TRANSPORT", FabricTransportCLI))
+	switch fabricTransport {
+	case FabricTransportCLI:
+	case FabricTransportMock:
+		if peerDiscovery || endorsementDiscovery {
+			return nil, errors.New("FABRIC_TRANSPORT=mock cannot be combined with PEER_DISCOVERY or ENDORSEMENT_DISCOVERY")
+		}
+	default:
+		return nil, fmt.Errorf("FABRIC_TRANSPORT must be cli or mock, got %q", fabricTransport)
+	}
+	peerDiscoveryInterval, err := durationEnv("PEER_DISCOVERY_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	reloadInterval, err := durationEnv("CONFIG_RELOAD_INTERVAL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTL, err := durationEnv("CACHE_TTL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTLs := map[string]time.Duration{}
+	for namespace, value := range mapEnv("CACHE_TTLS") {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("CACHE_TTLS: ttl for %s must be a non-negative duration", namespace)
+		}
+		cacheTTLs[namespace] = ttl
+	}
+	idempotencyTTL, err := durationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	submissionTTL, err := durationEnv("SUBMISSION_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	readPeers := listEnv("READ_PEERS", nil)
+	writePeers := listEnv("WRITE_PEERS", nil)
+	if !peerDiscovery {
+		// Discovered peers join after startup, so pools can only be checked against static peers.
+		for setting, pool := range map[string][]string{"READ_PEERS": readPeers, "WRITE_PEERS": writePeers} {
+			for _, name := range pool {
+				if _, ok := peers[name]; !ok {
+					return nil, fmt.Errorf("%s: peer %s is not in PEER_ENDPOINTS", setting, name)
+				}
+			}
+		}
+	}
+	peerSelection := strings.ToLower(fallbackEnv("PEER_SELECTION_STRATEGY", PeerSelectionRoundRobin))
+	switch peerSelection {
+	case PeerSelectionRoundRobin, PeerSelectionLeastLatency, PeerSelectionConsistentHash:
+	default:
+		return nil, fmt.Errorf("PEER_SELECTION_STRATEGY must be round_robin, least_latency or consistent_hash, got %q", peerSelection)
+	}
+	breakerThreshold, err := intEnv("PEER_BREAKER_THRESHOLD", 3)
+	if err != nil {
+		return nil, err
+	}
+	breakerCooldown, err := durationEnv("PEER_BREAKER_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	healthInterval, err := durationEnv("PEER_HEALTH_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	readinessTimeout, err := durationEnv("READINESS_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	startupBackoff, err := durationEnv("STARTUP_BACKOFF", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	startupBackoffMax, err := durationEnv("STARTUP_BACKOFF_MAX", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if startupBackoff <= 0 || startupBackoffMax < startupBackoff {
+		return nil, errors.New("STARTUP_BACKOFF must be positive and not above STARTUP_BACKOFF_MAX")
+	}
+	artifactMaxBytes, err := intEnv("ARTIFACT_MAX_BYTES", 512<<20)
+	if err != nil {
+		return nil, err
+	}
+	artifactTimeout, err := durationEnv("ARTIFACT_TRANSFER_TIMEOUT", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	storageDriver := strings.ToLower(fallbackEnv("STORAGE_DRIVER", "none"))
+	storageOffload, err := intEnv("STORAGE_OFFLOAD_BYTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	storageS3 := S3Config{
+		Endpoint:  strings.TrimSpace(setting("STORAGE_S3_ENDPOINT")),
+		Bucket:    strings.TrimSpace(setting("STORAGE_S3_BUCKET")),
+		Region:    fallbackEnv("STORAGE_S3_REGION", "us-east-1"),
+		AccessKey: setting("STORAGE_S3_ACCESS_KEY"),
+		SecretKey: setting("STORAGE_S3_SECRET_KEY"),
+	}
+	switch storageDriver {
+	case "none", "filesystem":
+	case "ipfs":
+		if strings.TrimSpace(setting("IPFS_API_URL")) == "" {
+			return nil, errors.New("STORAGE_DRIVER=ipfs requires IPFS_API_URL")
+		}
+	case "s3":
+		if storageS3.Endpoint == "" || storageS3.Bucket == "" {
+			return nil, errors.New("STORAGE_DRIVER=s3 requires STORAGE_S3_ENDPOINT and STORAGE_S3_BUCKET")
+		}
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER must be none, filesystem, ipfs or s3, got %q", storageDriver)
+	}
+	modelDuplicates := strings.ToLower(fallbackEnv("MODEL_DUPLICATES", "allow"))
+	if modelDuplicates != "allow" && modelDuplicates != "reject" {
+		return nil, fmt.Errorf("MODEL_DUPLICATES must be allow or reject, got %q", modelDuplicates)
+	}
+	walletEncryption := strings.ToLower(fallbackEnv("WALLET_ENCRYPTION", WalletEncryptionNone))
+	walletKDFIterations, err := intEnv("WALLET_KDF_ITERATIONS", 600000)
+	if err != nil {
+		return nil, err
+	}
+	switch walletEncryption {
+	case WalletEncryptionNone:
+	case WalletEncryptionSecret:
+		if setting("WALLET_SECRET") == "" {
+			return nil, errors.New("WALLET_SECRET must be set when WALLET_ENCRYPTION=secret")
+		}
+	case WalletEncryptionKMS:
+		if setting("WALLET_KMS_URL") == "" || setting("WALLET_KMS_KEY") == "" {
+			return nil, errors.New("WALLET_KMS_URL and WALLET_KMS_KEY must be set when WALLET_ENCRYPTION=kms")
+		}
+	default:
+		return nil, fmt.Errorf("WALLET_ENCRYPTION must be none, secret or kms, got %q", walletEncryption)
+	}
+	payloadEncryption := strings.ToLower(fallbackEnv("PAYLOAD_ENCRYPTION", WalletEncryptionNone))
+	payloadKMSURL := strings.TrimSpace(fallbackEnv("PAYLOAD_KMS_URL", setting("WALLET_KMS_URL")))
+	switch payloadEncryption {
+	case WalletEncryptionNone:
+	case WalletEncryptionSecret:
+		if setting("PAYLOAD_SECRET") == "" {
+			return nil, errors.New("PAYLOAD_SECRET must be set when PAYLOAD_ENCRYPTION=secret")
+		}
+	case WalletEncryptionKMS:
+		if payloadKMSURL == "" || setting("PAYLOAD_KMS_KEY") == "" {
+			return nil, errors.New("PAYLOAD_KMS_URL (or WALLET_KMS_URL) and PAYLOAD_KMS_KEY must be set when PAYLOAD_ENCRYPTION=kms")
+		}
+	default:
+		return nil, fmt.Errorf("PAYLOAD_ENCRYPTION must be none, secret or kms, got %q", payloadEncryption)
+	}
+	var payloadDecryptRoles []Role
+	for _, name := range listEnv("PAYLOAD_DECRYPT_ROLES", []string{string(RoleAdmin), string(RoleCentralChecker), string(RoleAggregator), string(RoleTrainer), string(RoleValidator)}) {
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("PAYLOAD_DECRYPT_ROLES: %w", err)
+		}
+		payloadDecryptRoles = append(payloadDecryptRoles, role)
+	}
+	webhookAttempts, err := intEnv("WEBHOOK_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	webhookBackoff, err := durationEnv("WEBHOOK_RETRY_BACKOFF", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	webhookTimeout, err := durationEnv("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if webhookAttempts < 1 || webhookBackoff <= 0 || webhookTimeout <= 0 {
+		return nil, errors.New("WEBHOOK_MAX_ATTEMPTS, WEBHOOK_RETRY_BACKOFF and WEBHOOK_TIMEOUT must be positive")
+	}
+	eventBridge := strings.ToLower(fallbackEnv("EVENT_BRIDGE", "none"))
+	eventBridgeURL := strings.TrimSpace(setting("EVENT_BRIDGE_URL"))
+	switch eventBridge {
+	case "none":
+	case "kafka", "nats":
+		if eventBridgeURL == "" {
+			return nil, fmt.Errorf("EVENT_BRIDGE=%s requires EVENT_BRIDGE_URL", eventBridge)
+		}
+	default:
+		return nil, fmt.Errorf("EVENT_BRIDGE must be none, kafka or nats, got %q", eventBridge)
+	}
+	eventBridgeChaincode, err := boolEnv("EVENT_BRIDGE_CHAINCODE_EVENTS", true)
+	if err != nil {
+		return nil, err
+	}
+	sampleRatio, err := floatEnv("OTEL_TRACES_SAMPLER_ARG", 1)
+	if err != nil {
+		return nil, err
+	}
 	host, _, found := strings.Cut(ordererEndpoint, ":")
 	if !found || host == "" {
 		host = ordererEndpoint
 	}
 
 	return &Config{
+		Port:            fallbackEnv("PORT", "9000"),
 		Channel:         channel,
 		Chaincode:       chaincode,
 		MSPID:           mspID,
 		OrgCryptoPath:   orgPath,
+		OrgDomain:       peerDomain,
 		AdminIdentity:   admin,
 		AdminMSPPath:    adminMSPPath,
 		OrdererEndpoint: ordererEndpoint,
@@ -94,12 +770,178 @@ func LoadConfig() (*Config, error) {
 		OrdererTLSCA:    ordererTLS,
 		FabricCfgPath:   fabricCfgPath,
 		Peers:           peers,
+		Orgs:            orgs,
+		StateOrgs:       stateOrgs,
+		Channels:        channels,
+		ModuleChannels:  moduleChannels,
 		DefaultPeer:     defaultPeer,
 		AuthSecret:      authSecret,
 		TrainerDBPath:   trainerDBPath,
+		TrainerStore:    fallbackEnv("TRAINER_STORE", "file"),
 		AdminPublicKey:  adminKey,
-		JobID:           os.Getenv("GATEWAY_JOB_ID"),
+		JobID:           setting("GATEWAY_JOB_ID"),
 		mspCache:        map[string]string{},
+
+		EndorsementOrgs:         endorsementOrgs,
+		EndorsementDiscovery:    endorsementDiscovery,
+		EndorsementDiscoveryTTL: endorsementDiscoveryTTL,
+
+		TrainerStoreRehydrate: rehydrate,
+		DIDRegistryRequired:   didRequired,
+
+		FabricCAURL:       strings.TrimSpace(setting("FABRIC_CA_URL")),
+		FabricCAName:      setting("FABRIC_CA_NAME"),
+		FabricCATLSCert:   setting("FABRIC_CA_TLS_CERT"),
+		FabricCAAdminHome: fallbackEnv("FABRIC_CA_ADMIN_HOME", fmt.Sprintf("%s/users/%s", orgPath, admin)),
+		FabricCAMSPConfig: fallbackEnv("FABRIC_CA_MSP_CONFIG", orgPath+"/msp/config.yaml"),
+		WalletType:        fallbackEnv("WALLET_TYPE", "file"),
+		WalletPath:        fallbackEnv("WALLET_PATH", "/data/wallet"),
+
+		WalletEncryption:    walletEncryption,
+		WalletSecret:        setting("WALLET_SECRET"),
+		WalletKDF:           strings.ToLower(fallbackEnv("WALLET_KDF", KDFPBKDF2)),
+		WalletKDFIterations: walletKDFIterations,
+		WalletKMSURL:        strings.TrimSpace(setting("WALLET_KMS_URL")),
+		WalletKMSKey:        setting("WALLET_KMS_KEY"),
+		WalletKMSToken:      setting("WALLET_KMS_TOKEN"),
+
+		EvaluationMetric:   fallbackEnv("EVALUATION_METRIC", "accuracy"),
+		EvaluationQuorum:   evalQuorum,
+		EvaluationMinScore: evalMinScore,
+
+		AnchorEndpoint:   strings.TrimSpace(setting("ANCHOR_ENDPOINT")),
+		AnchorAuthToken:  setting("ANCHOR_AUTH_TOKEN"),
+		AnchorInterval:   anchorInterval,
+		AnchorNamespaces: listEnv("ANCHOR_NAMESPACES", []string{"whitelist:", "model:", "conv~state", "conv~nation", "conv~job~state", "conv~job~nation", "eval:"}),
+
+		EventsPollInterval: eventsPollInterval,
+
+		RoundDeadlineInterval: roundDeadlineInterval,
+		RoundMinQuorum:        roundMinQuorum,
+
+		IPFSAPIURL:              strings.TrimSpace(setting("IPFS_API_URL")),
+		ArtifactMaxBytes:        int64(artifactMaxBytes),
+		ArtifactTransferTimeout: artifactTimeout,
+
+		StorageDriver:       storageDriver,
+		StorageOffloadBytes: storageOffload,
+		StoragePath:         fallbackEnv("STORAGE_PATH", "/data/payloads"),
+		StorageS3:           storageS3,
+
+		ModelDuplicates: modelDuplicates,
+
+		PayloadEncryption:   payloadEncryption,
+		PayloadSecret:       setting("PAYLOAD_SECRET"),
+		PayloadKMSURL:       payloadKMSURL,
+		PayloadKMSKey:       setting("PAYLOAD_KMS_KEY"),
+		PayloadKMSToken:     fallbackEnv("PAYLOAD_KMS_TOKEN", setting("WALLET_KMS_TOKEN")),
+		PayloadKeyringPath:  fallbackEnv("PAYLOAD_KEYRING_PATH", filepath.Join(filepath.Dir(trainerDBPath), "payload-keys.json")),
+		PayloadDecryptRoles: payloadDecryptRoles,
+
+		WebhooksPath:        fallbackEnv("WEBHOOKS_PATH", filepath.Join(filepath.Dir(trainerDBPath), "webhooks.json")),
+		WebhookMaxAttempts:  webhookAttempts,
+		WebhookRetryBackoff: webhookBackoff,
+		WebhookTimeout:      webhookTimeout,
+
+		EventBridge:                eventBridge,
+		EventBridgeURL:             eventBridgeURL,
+		EventBridgeTopics:          mapEnv("EVENT_BRIDGE_TOPICS"),
+		EventBridgeTopicPrefix:     fallbackEnv("EVENT_BRIDGE_TOPIC_PREFIX", "nebula."),
+		EventBridgeChaincodeEvents: eventBridgeChaincode,
+		EventBridgeCursorPath:      fallbackEnv("EVENT_BRIDGE_CURSOR_PATH", filepath.Join(filepath.Dir(trainerDBPath), "event-bridge.json")),
+
+		StateDatabase: strings.ToLower(fallbackEnv("STATE_DATABASE", "leveldb")),
+
+		FabricRetry: RetryPolicy{
+			MaxAttempts:    retryAttempts,
+			InitialBackoff: retryInitial,
+			MaxBackoff:     retryMax,
+		},
+		FabricQueryTimeout:  queryTimeout,
+		FabricInvokeTimeout: invokeTimeout,
+		FabricFanOutFunctions: listEnv("FABRIC_FANOUT_FUNCTIONS", []string{
+			"GetCurrentRound",
+			"ListNationConvergence", "ListNationConvergenceInRound",
+			"ListStateConvergence", "ListStateConvergenceInRound",
+			"ReadNationConvergence",
+			"ReadStateConvergence", "ReadStateConvergenceInRound",
+		}),
+		FabricReceiptBlocks: receiptBlocks,
+		FabricJSONArgs:      jsonArgs,
+		FabricTransport:     fabricTransport,
+
+		PeerDiscovery:         peerDiscovery,
+		PeerDiscoveryInterval: peerDiscoveryInterval,
+
+		ConfigReloadInterval: reloadInterval,
+
+		CacheTTL:  cacheTTL,
+		CacheTTLs: cacheTTLs,
+
+		IdempotencyTTL: idempotencyTTL,
+		SubmissionTTL:  submissionTTL,
+
+		AuthJWKSURL:     jwksURL,
+		AuthJWKSRefresh: jwksRefresh,
+		AuthIssuers:     authIssuers,
+		AuthAudience:    listEnv("AUTH_JWT_AUDIENCE", nil),
+
+		AuthTokenKey:     tokenKey,
+		AuthTokenTTL:     tokenTTL,
+		AuthChallengeTTL: challengeTTL,
+		AuthRefreshTTL:   refreshTTL,
+
+		RateLimits: rateLimits,
+
+		APIKeys:      apiKeys,
+		APIKeyRoutes: listEnv("API_KEY_ROUTES", nil),
+
+		AuthzPolicyPath: strings.TrimSpace(setting("AUTHZ_POLICY_FILE")),
+
+		CORS: CORSConfig{
+			AllowedOrigins:   corsOrigins,
+			AllowedMethods:   listEnv("CORS_ALLOWED_METHODS", defaultCORSMethods),
+			AllowedHeaders:   listEnv("CORS_ALLOWED_HEADERS", defaultCORSHeaders),
+			ExposedHeaders:   listEnv("CORS_EXPOSED_HEADERS", defaultCORSExposed),
+			AllowCredentials: corsCredentials,
+			MaxAge:           corsMaxAge,
+		},
+
+		AuditLog:            auditLog,
+		AuditLogPath:        fallbackEnv("AUDIT_LOG_PATH", filepath.Join(filepath.Dir(trainerDBPath), "audit.jsonl")),
+		AuditExclude:        listEnv("AUDIT_EXCLUDE", []string{"/auth/challenge", "/auth/token", "/auth/refresh"}),
+		AuditAnchorBatch:    auditAnchorBatch,
+		AuditAnchorInterval: auditAnchorInterval,
+
+		ConsistencyInterval:   consistencyInterval,
+		ConsistencyNamespaces: listEnv("CONSISTENCY_NAMESPACES", []string{"whitelist:"}),
+		ConsistencyMaxLag:     consistencyMaxLag,
+
+		AdminChaincodeFunctions: listEnv("ADMIN_CHAINCODE_FUNCTIONS", nil),
+
+		MaxBodyBytes:   int64(maxBodyBytes),
+		BodyLimits:     bodyLimits,
+		PayloadSchemas: mapEnv("PAYLOAD_SCHEMAS"),
+
+		ResponseCompression: responseCompression,
+		CompressionMinBytes: compressionMinBytes,
+		ResponseETags:       responseETags,
+		ResponseFieldRoles:  responseFieldRoles,
+
+		ReadPeers:            readPeers,
+		WritePeers:           writePeers,
+		PeerSelection:        peerSelection,
+		PeerBreakerThreshold: breakerThreshold,
+		PeerBreakerCooldown:  breakerCooldown,
+		PeerHealthInterval:   healthInterval,
+		ReadinessTimeout:     readinessTimeout,
+		StartupBackoff:       startupBackoff,
+		StartupBackoffMax:    startupBackoffMax,
+
+		TracingEndpoint:    tracesEndpoint(),
+		TracingHeaders:     mapEnv("OTEL_EXPORTER_OTLP_HEADERS"),
+		TracingServiceName: fallbackEnv("OTEL_SERVICE_NAME", "nebula-api-gateway"),
+		TracingSampleRatio: sampleRatio,
 	}, nil
 }
 
@@ -117,7 +959,7 @@ func parseAdminKey(raw string) ([]byte, error) {
 	return key, nil
 }
 
-func parsePeerConfig(spec, orgPath, domain string) (map[string]PeerConfig, error) {
+func parsePeerConfig(spec string, orgs map[string]OrgProfile) (map[string]PeerConfig, error) {
 	if spec == "" {
 		return nil, errors.New("PEER_ENDPOINTS must be provided")
 	}
@@ -132,10 +974,11 @@ func parsePeerConfig(spec, orgPath, domain string) (map[string]PeerConfig, error
 		if len(parts) != 2 {
 			return nil, fmt.Errorf("invalid peer entry %s", entry)
 		}
-		name := parts[0]
-		addr := parts[1]
-		tlsPath := fmt.Sprintf("%s/peers/%s.%s/tls/ca.crt", orgPath, name, domain)
-		peers[name] = PeerConfig{Name: name, Address: addr, TLSPath: tlsPath}
+		peer, err := newPeerConfig(orgs, parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		peers[parts[0]] = peer
 	}
 	if len(peers) == 0 {
 		return nil, errors.New("no peers configured")
@@ -143,32 +986,237 @@ func parsePeerConfig(spec, orgPath, domain string) (map[string]PeerConfig, error
 	return peers, nil
 }
 
-// MSPPathForIdentity resolves the MSP folder for the requested Fabric identity.
+// MSPPathForIdentity resolves the MSP folder for the requested Fabric identity of the
+// default org.
 func (c *Config) MSPPathForIdentity(identity string) (string, error) {
-	c.mspMu.RLock()
-	if path, ok := c.mspCache[identity]; ok {
-		c.mspMu.RUnlock()
-		return path, nil
+	return c.mspPath(DefaultOrg, identity)
+}
+
+func intEnv(key string, fallback int) (int, error) {
+	raw := strings.TrimSpace(setting(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return value, nil
+}
+
+func floatEnv(key string, fallback float64) (float64, error) {
+	raw := strings.TrimSpace(setting(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	return value, nil
+}
+
+func boolEnv(key string, fallback bool) (bool, error) {
+	raw := strings.TrimSpace(setting(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+	return value, nil
+}
+
+func durationEnv(key string, fallback time.Duration) (time.Duration, error) {
+	raw := strings.TrimSpace(setting(key))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 30m or 1h: %w", key, err)
+	}
+	return value, nil
+}
+
+func listEnv(key string, fallback []string) []string {
+	raw := strings.TrimSpace(setting(key))
+	if raw == "" {
+		return fallback
 	}
-	c.mspMu.RUnlock()
+	var values []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
 
-	var path string
-	if identity == "" || identity == c.AdminIdentity {
-		path = c.AdminMSPPath
-	} else {
-		path = fmt.Sprintf("%s/users/%s/msp", c.OrgCryptoPath, identity)
+// tracesEndpoint prefers the signal-specific endpoint and otherwise appends the OTLP/HTTP
+// traces path to the base endpoint.
+func tracesEndpoint() string {
+	if endpoint := strings.TrimSpace(setting("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); endpoint != "" {
+		return endpoint
 	}
-	if _, err := os.Stat(path); err != nil {
-		return "", fmt.Errorf("fabric identity %s not found at %s: %w", identity, path, err)
+	base := strings.TrimRight(strings.TrimSpace(setting("OTEL_EXPORTER_OTLP_ENDPOINT")), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/v1/traces"
+}
+
+// mapEnv parses comma-separated key=value pairs, e.g. OTEL_EXPORTER_OTLP_HEADERS.
+func mapEnv(key string) map[string]string {
+	result := map[string]string{}
+	for _, pair := range strings.Split(setting(key), ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		result[name] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// rateLimitEnv overrides the default buckets with class=rate:burst pairs, e.g.
+// "write=2:5,read=50:100". A rate of 0 disables the class; "off" disables rate limiting.
+func rateLimitEnv(key string, defaults map[string]RateLimit) (map[string]RateLimit, error) {
+	raw := strings.TrimSpace(setting(key))
+	if strings.EqualFold(raw, "off") {
+		return map[string]RateLimit{}, nil
+	}
+	limits := map[string]RateLimit{}
+	for class, limit := range defaults {
+		limits[class] = limit
+	}
+	for class, value := range mapEnv(key) {
+		rateText, burstText, _ := strings.Cut(value, ":")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateText), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("%s: rate for %s must be a non-negative number", key, class)
+		}
+		burst := 0
+		if strings.TrimSpace(burstText) != "" {
+			if burst, err = strconv.Atoi(strings.TrimSpace(burstText)); err != nil || burst < 0 {
+				return nil, fmt.Errorf("%s: burst for %s must be a non-negative integer", key, class)
+			}
+		}
+		if rate == 0 {
+			delete(limits, class)
+			continue
+		}
+		limits[class] = RateLimit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
+
+// fieldRolesEnv overrides the default field visibility with field=role|role pairs, e.g.
+// "public_key=admin|central_checker|aggregator,signature=admin". A field set to * is visible
+// to every role; "off" disables redaction.
+func fieldRolesEnv(key string, defaults map[string][]Role) (map[string][]Role, error) {
+	if strings.EqualFold(strings.TrimSpace(setting(key)), "off") {
+		return map[string][]Role{}, nil
+	}
+	fields := map[string][]Role{}
+	for field, roles := range defaults {
+		fields[field] = roles
+	}
+	for field, value := range mapEnv(key) {
+		if value == "*" {
+			delete(fields, field)
+			continue
+		}
+		var roles []Role
+		for _, name := range strings.Split(value, "|") {
+			role, err := ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", key, field, err)
+			}
+			roles = append(roles, role)
+		}
+		fields[field] = roles
+	}
+	return fields, nil
+}
+
+// apiKeyEnv parses id=hash:role|role[:rate[:burst]] entries, e.g.
+// "ci-seeder=<sha256 hex>:admin:1:5". hash is the hex SHA-256 of the key's secret.
+func apiKeyEnv(key string) (map[string]*APIKey, error) {
+	keys := map[string]*APIKey{}
+	for id, value := range mapEnv(key) {
+		if err := ValidateAPIKeyID(id); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		parts := strings.Split(value, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("%s: entry for %s must be hash:roles[:rate[:burst]]", key, id)
+		}
+		hash := strings.ToLower(strings.TrimSpace(parts[0]))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("%s: hash for %s must be a hex SHA-256 digest", key, id)
+		}
+		apiKey := &APIKey{ID: id, Hash: hash}
+		for _, name := range strings.Split(parts[1], "|") {
+			role, err := ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", key, id, err)
+			}
+			apiKey.Roles = append(apiKey.Roles, role)
+		}
+		if len(parts) > 2 {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+			if err != nil || rate < 0 {
+				return nil, fmt.Errorf("%s: rate for %s must be a non-negative number", key, id)
+			}
+			limit := &RateLimit{Rate: rate}
+			if len(parts) > 3 {
+				if limit.Burst, err = strconv.Atoi(strings.TrimSpace(parts[3])); err != nil || limit.Burst < 0 {
+					return nil, fmt.Errorf("%s: burst for %s must be a non-negative integer", key, id)
+				}
+			}
+			if rate > 0 {
+				apiKey.RateLimit = limit
+			}
+		}
+		keys[id] = apiKey
+	}
+	return keys, nil
+}
+
+// issuerEnv parses a comma-separated issuer allowlist. Each entry is an issuer, optionally
+// followed by =role|role to limit the roles that issuer may grant.
+func issuerEnv(key string) (map[string][]Role, error) {
+	issuers := map[string][]Role{}
+	for _, entry := range listEnv(key, nil) {
+		issuer, grants, _ := strings.Cut(entry, "=")
+		issuer = strings.TrimSpace(issuer)
+		if issuer == "" {
+			return nil, fmt.Errorf("%s contains an entry without an issuer", key)
+		}
+		var roles []Role
+		for _, name := range strings.Split(grants, "|") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			role, err := ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			roles = append(roles, role)
+		}
+		issuers[issuer] = roles
 	}
-	c.mspMu.Lock()
-	c.mspCache[identity] = path
-	c.mspMu.Unlock()
-	return path, nil
+	return issuers, nil
 }
 
 func fallbackEnv(key, fallback string) string {
-	val := os.Getenv(key)
+	val := setting(key)
 	if val == "" {
 		return fallback
 	}
@@ -0,0 +1,579 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// settingKind is the type a configuration setting is validated against.
+type settingKind int
+
+const (
+	kindString settingKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindDuration
+	kindList
+	kindMap
+	kindRateLimits
+	kindIssuers
+)
+
+// configSettings lists every setting LoadConfig reads. A configuration file may set any of
+// them; environment variables of the same name override the file.
+var configSettings = map[string]settingKind{
+	"PORT":                               kindString,
+	"FABRIC_CHANNEL":                     kindString,
+	"FABRIC_CHAINCODE":                   kindString,
+	"FABRIC_CFG_PATH":                    kindString,
+	"FABRIC_CHANNELS":                    kindMap,
+	"FABRIC_MODULE_CHANNELS":             kindMap,
+	"MSP_ID":                             kindString,
+	"ORG_CRYPTO_PATH":                    kindString,
+	"ORG_DOMAIN":                         kindString,
+	"ORG_PROFILES":                       kindMap,
+	"STATE_ORGS":                         kindMap,
+	"ENDORSEMENT_ORGS":                   kindMap,
+	"ENDORSEMENT_DISCOVERY":              kindBool,
+	"ENDORSEMENT_DISCOVERY_TTL":          kindDuration,
+	"ADMIN_IDENTITY":                     kindString,
+	"ADMIN_PUBLIC_KEY":                   kindString,
+	"ORDERER_ENDPOINT":                   kindString,
+	"ORDERER_TLS_CA":                     kindString,
+	"PEER_ENDPOINTS":                     kindMap,
+	"DEFAULT_PEER":                       kindString,
+	"GATEWAY_JOB_ID":                     kindString,
+	"TRAINER_DB_PATH":                    kindString,
+	"TRAINER_STORE":                      kindString,
+	"TRAINER_STORE_REHYDRATE":            kindBool,
+	"DID_REGISTRY_REQUIRED":              kindBool,
+	"FABRIC_CA_URL":                      kindString,
+	"FABRIC_CA_NAME":                     kindString,
+	"FABRIC_CA_TLS_CERT":                 kindString,
+	"FABRIC_CA_ADMIN_HOME":               kindString,
+	"FABRIC_CA_MSP_CONFIG":               kindString,
+	"WALLET_TYPE":                        kindString,
+	"WALLET_PATH":                        kindString,
+	"WALLET_ENCRYPTION":                  kindString,
+	"WALLET_SECRET":                      kindString,
+	"WALLET_KDF":                         kindString,
+	"WALLET_KDF_ITERATIONS":              kindInt,
+	"WALLET_KMS_URL":                     kindString,
+	"WALLET_KMS_KEY":                     kindString,
+	"WALLET_KMS_TOKEN":                   kindString,
+	"STATE_DATABASE":                     kindString,
+	"EVALUATION_METRIC":                  kindString,
+	"EVALUATION_QUORUM":                  kindInt,
+	"EVALUATION_MIN_SCORE":               kindFloat,
+	"ANCHOR_ENDPOINT":                    kindString,
+	"ANCHOR_AUTH_TOKEN":                  kindString,
+	"ANCHOR_INTERVAL":                    kindDuration,
+	"ANCHOR_NAMESPACES":                  kindList,
+	"EVENTS_POLL_INTERVAL":               kindDuration,
+	"ROUND_DEADLINE_INTERVAL":            kindDuration,
+	"ROUND_MIN_QUORUM":                   kindInt,
+	"IPFS_API_URL":                       kindString,
+	"ARTIFACT_MAX_BYTES":                 kindInt,
+	"ARTIFACT_TRANSFER_TIMEOUT":          kindDuration,
+	"STORAGE_DRIVER":                     kindString,
+	"STORAGE_OFFLOAD_BYTES":              kindInt,
+	"STORAGE_PATH":                       kindString,
+	"STORAGE_S3_ENDPOINT":                kindString,
+	"STORAGE_S3_BUCKET":                  kindString,
+	"STORAGE_S3_REGION":                  kindString,
+	"STORAGE_S3_ACCESS_KEY":              kindString,
+	"STORAGE_S3_SECRET_KEY":              kindString,
+	"MODEL_DUPLICATES":                   kindString,
+	"PAYLOAD_ENCRYPTION":                 kindString,
+	"PAYLOAD_SECRET":                     kindString,
+	"PAYLOAD_KMS_URL":                    kindString,
+	"PAYLOAD_KMS_KEY":                    kindString,
+	"PAYLOAD_KMS_TOKEN":                  kindString,
+	"PAYLOAD_KEYRING_PATH":               kindString,
+	"PAYLOAD_DECRYPT_ROLES":              kindList,
+	"WEBHOOKS_PATH":                      kindString,
+	"WEBHOOK_MAX_ATTEMPTS":               kindInt,
+	"WEBHOOK_RETRY_BACKOFF":              kindDuration,
+	"WEBHOOK_TIMEOUT":                    kindDuration,
+	"EVENT_BRIDGE":                       kindString,
+	"EVENT_BRIDGE_URL":                   kindString,
+	"EVENT_BRIDGE_TOPICS":                kindMap,
+	"EVENT_BRIDGE_TOPIC_PREFIX":          kindString,
+	"EVENT_BRIDGE_CHAINCODE_EVENTS":      kindBool,
+	"EVENT_BRIDGE_CURSOR_PATH":           kindString,
+	"FABRIC_RETRY_MAX_ATTEMPTS":          kindInt,
+	"FABRIC_RETRY_INITIAL_BACKOFF":       kindDuration,
+	"FABRIC_RETRY_MAX_BACKOFF":           kindDuration,
+	"FABRIC_QUERY_TIMEOUT":               kindDuration,
+	"FABRIC_INVOKE_TIMEOUT":              kindDuration,
+	"FABRIC_FANOUT_FUNCTIONS":            kindList,
+	"FABRIC_RECEIPT_BLOCKS":              kindBool,
+	"FABRIC_JSON_ARGS":                   kindBool,
+	"FABRIC_TRANSPORT":                   kindString,
+	"PEER_DISCOVERY":                     kindBool,
+	"PEER_DISCOVERY_INTERVAL":            kindDuration,
+	"CONFIG_RELOAD_INTERVAL":             kindDuration,
+	"CACHE_TTL":                          kindDuration,
+	"CACHE_TTLS":                         kindMap,
+	"IDEMPOTENCY_TTL":                    kindDuration,
+	"SUBMISSION_TTL":                     kindDuration,
+	"AUTH_JWT_SECRET":                    kindString,
+	"AUTH_JWKS_URL":                      kindString,
+	"AUTH_JWKS_REFRESH":                  kindDuration,
+	"AUTH_JWT_ISSUERS":                   kindIssuers,
+	"AUTH_JWT_AUDIENCE":                  kindList,
+	"AUTH_TOKEN_SIGNING_KEY":             kindString,
+	"AUTH_TOKEN_TTL":                     kindDuration,
+	"AUTH_CHALLENGE_TTL":                 kindDuration,
+	"AUTH_REFRESH_TTL":                   kindDuration,
+	"RATE_LIMITS":                        kindRateLimits,
+	"API_KEYS":                           kindMap,
+	"API_KEY_ROUTES":                     kindList,
+	"AUTHZ_POLICY_FILE":                  kindString,
+	"CORS_ALLOWED_ORIGINS":               kindList,
+	"CORS_ALLOWED_METHODS":               kindList,
+	"CORS_ALLOWED_HEADERS":               kindList,
+	"CORS_EXPOSED_HEADERS":               kindList,
+	"CORS_ALLOW_CREDENTIALS":             kindBool,
+	"CORS_MAX_AGE":                       kindDuration,
+	"AUDIT_LOG":                          kindBool,
+	"AUDIT_LOG_PATH":                     kindString,
+	"AUDIT_EXCLUDE":                      kindList,
+	"AUDIT_ANCHOR_BATCH":                 kindInt,
+	"AUDIT_ANCHOR_INTERVAL":              kindDuration,
+	"CONSISTENCY_INTERVAL":               kindDuration,
+	"CONSISTENCY_NAMESPACES":             kindList,
+	"CONSISTENCY_MAX_LAG":                kindInt,
+	"ADMIN_CHAINCODE_FUNCTIONS":          kindList,
+	"MAX_BODY_BYTES":                     kindInt,
+	"RESPONSE_COMPRESSION":               kindBool,
+	"COMPRESSION_MIN_BYTES":              kindInt,
+	"RESPONSE_ETAGS":                     kindBool,
+	"RESPONSE_FIELD_ROLES":               kindMap,
+	"BODY_LIMITS":                        kindMap,
+	"PAYLOAD_SCHEMAS":                    kindMap,
+	"PEER_SELECTION_STRATEGY":            kindString,
+	"READ_PEERS":                         kindList,
+	"WRITE_PEERS":                        kindList,
+	"PEER_BREAKER_THRESHOLD":             kindInt,
+	"PEER_BREAKER_COOLDOWN":              kindDuration,
+	"PEER_HEALTH_INTERVAL":               kindDuration,
+	"READINESS_TIMEOUT":                  kindDuration,
+	"STARTUP_BACKOFF":                    kindDuration,
+	"STARTUP_BACKOFF_MAX":                kindDuration,
+	"OTEL_EXPORTER_OTLP_ENDPOINT":        kindString,
+	"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": kindString,
+	"OTEL_EXPORTER_OTLP_HEADERS":         kindMap,
+	"OTEL_SERVICE_NAME":                  kindString,
+	"OTEL_TRACES_SAMPLER_ARG":            kindFloat,
+}
+
+// configFile holds the settings read from the configuration file, encoded in the same
+// formats as their environment variables.
+var configFile struct {
+	mu     sync.RWMutex
+	path   string
+	values map[string]string
+}
+
+// setting returns the environment value of key, falling back to the configuration file.
+func setting(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	configFile.mu.RLock()
+	defer configFile.mu.RUnlock()
+	return configFile.values[key]
+}
+
+// loadConfigFile reads and validates the file at path and makes its settings visible to
+// setting. An empty path clears any previously loaded file.
+func loadConfigFile(path string) error {
+	var values map[string]string
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if values, err = parseConfigFile(path, data); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	configFile.mu.Lock()
+	configFile.path = path
+	configFile.values = values
+	configFile.mu.Unlock()
+	return nil
+}
+
+// parseConfigFile decodes a YAML or JSON document into settings. Keys are setting names in
+// any case; nested mappings join their keys with underscores, so
+//
+//	fabric:
+//	  retry:
+//	    max_attempts: 5
+//
+// sets FABRIC_RETRY_MAX_ATTEMPTS. Unknown keys and values of the wrong type are reported
+// together.
+func parseConfigFile(path string, data []byte) (map[string]string, error) {
+	var (
+		document any
+		err      error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&document)
+	} else {
+		document, err = parseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, ok := document.(map[string]any)
+	if !ok {
+		return nil, errors.New("the document must be a mapping of settings")
+	}
+	values := map[string]string{}
+	var problems []error
+	flattenConfig("", root, values, &problems)
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return values, nil
+}
+
+func flattenConfig(prefix string, mapping map[string]any, values map[string]string, problems *[]error) {
+	for _, name := range sortedKeys(mapping) {
+		key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		value := mapping[name]
+		kind, known := configSettings[key]
+		switch {
+		case known:
+			if value == nil {
+				continue
+			}
+			if _, set := values[key]; set {
+				*problems = append(*problems, fmt.Errorf("%s is set more than once", key))
+				continue
+			}
+			encoded, err := encodeSetting(kind, value)
+			if err != nil {
+				*problems = append(*problems, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			values[key] = encoded
+		case isMapping(value):
+			flattenConfig(key, value.(map[string]any), values, problems)
+		default:
+			*problems = append(*problems, fmt.Errorf("%s: unknown setting", key))
+		}
+	}
+}
+
+// encodeSetting validates value against kind and renders it in the environment format.
+func encodeSetting(kind settingKind, value any) (string, error) {
+	switch kind {
+	case kindList:
+		if items, ok := value.([]any); ok {
+			return encodeList(items, "item")
+		}
+	case kindMap:
+		if mapping, ok := value.(map[string]any); ok {
+			pairs := make([]string, 0, len(mapping))
+			for _, name := range sortedKeys(mapping) {
+				text, ok := scalarString(mapping[name])
+				if !ok {
+					return "", fmt.Errorf("value for %s must be a scalar", name)
+				}
+				pairs = append(pairs, escapeMapPart(name)+"="+escapeMapPart(text))
+			}
+			return strings.Join(pairs, ","), nil
+		}
+	case kindRateLimits:
+		if mapping, ok := value.(map[string]any); ok {
+			pairs := make([]string, 0, len(mapping))
+			for _, class := range sortedKeys(mapping) {
+				limit, err := encodeRateLimit(mapping[class])
+				if err != nil {
+					return "", fmt.Errorf("%s: %w", class, err)
+				}
+				pairs = append(pairs, class+"="+limit)
+			}
+			return strings.Join(pairs, ","), nil
+		}
+	case kindIssuers:
+		switch typed := value.(type) {
+		case []any:
+			return encodeList(typed, "issuer")
+		case map[string]any:
+			entries := make([]string, 0, len(typed))
+			for _, issuer := range sortedKeys(typed) {
+				if strings.ContainsAny(issuer, ",=") {
+					return "", fmt.Errorf("issuer %q may not contain ',' or '='", issuer)
+				}
+				var roles []string
+				switch grants := typed[issuer].(type) {
+				case nil:
+				case []any:
+					for _, grant := range grants {
+						role, ok := scalarString(grant)
+						if !ok {
+							return "", fmt.Errorf("roles for %s must be strings", issuer)
+						}
+						roles = append(roles, role)
+					}
+				default:
+					text, ok := scalarString(grants)
+					if !ok {
+						return "", fmt.Errorf("roles for %s must be a list", issuer)
+					}
+					roles = strings.Split(text, "|")
+				}
+				for _, role := range roles {
+					if _, err := ParseRole(strings.TrimSpace(role)); err != nil {
+						return "", fmt.Errorf("%s: %w", issuer, err)
+					}
+				}
+				entry := issuer
+				if len(roles) > 0 {
+					entry += "=" + strings.Join(roles, "|")
+				}
+				entries = append(entries, entry)
+			}
+			return strings.Join(entries, ","), nil
+		}
+	}
+
+	text, ok := scalarString(value)
+	if !ok {
+		return "", fmt.Errorf("must be %s", kindDescription(kind))
+	}
+	var err error
+	switch kind {
+	case kindInt:
+		_, err = strconv.Atoi(text)
+	case kindFloat:
+		_, err = strconv.ParseFloat(text, 64)
+	case kindBool:
+		_, err = strconv.ParseBool(text)
+	case kindDuration:
+		_, err = time.ParseDuration(text)
+	}
+	if err != nil {
+		return "", fmt.Errorf("must be %s, got %q", kindDescription(kind), text)
+	}
+	return text, nil
+}
+
+func encodeList(items []any, noun string) (string, error) {
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		text, ok := scalarString(item)
+		if !ok {
+			return "", fmt.Errorf("each %s must be a scalar", noun)
+		}
+		if strings.Contains(text, ",") {
+			return "", fmt.Errorf("%s %q may not contain ','", noun, text)
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, ","), nil
+}
+
+// encodeRateLimit accepts either "rate:burst" or {rate: 5, burst: 10}.
+func encodeRateLimit(value any) (string, error) {
+	if mapping, ok := value.(map[string]any); ok {
+		rate, ok := scalarString(mapping["rate"])
+		if _, err := strconv.ParseFloat(rate, 64); !ok || err != nil {
+			return "", errors.New("rate must be a number")
+		}
+		for name := range mapping {
+			if name != "rate" && name != "burst" {
+				return "", fmt.Errorf("unknown field %s", name)
+			}
+		}
+		if mapping["burst"] == nil {
+			return rate, nil
+		}
+		burst, ok := scalarString(mapping["burst"])
+		if _, err := strconv.Atoi(burst); !ok || err != nil {
+			return "", errors.New("burst must be an integer")
+		}
+		return rate + ":" + burst, nil
+	}
+	text, ok := scalarString(value)
+	if !ok {
+		return "", errors.New("must be rate:burst or a {rate, burst} mapping")
+	}
+	return text, nil
+}
+
+// escapeMapPart percent-encodes the characters mapEnv splits on; mapEnv decodes them again.
+func escapeMapPart(text string) string {
+	return strings.NewReplacer("%", "%25", ",", "%2C", "=", "%3D", "+", "%2B").Replace(text)
+}
+
+func scalarString(value any) (string, bool) {
+	switch typed := value.(type) {
+	case string:
+		return typed, true
+	case json.Number:
+		return typed.String(), true
+	case bool:
+		return strconv.FormatBool(typed), true
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func isMapping(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+func kindDescription(kind settingKind) string {
+	switch kind {
+	case kindInt:
+		return "an integer"
+	case kindFloat:
+		return "a number"
+	case kindBool:
+		return "a boolean"
+	case kindDuration:
+		return "a duration such as 30m or 1h"
+	case kindList:
+		return "a list or comma-separated string"
+	case kindMap:
+		return "a mapping or key=value string"
+	case kindRateLimits:
+		return "a mapping of route classes or \"off\""
+	case kindIssuers:
+		return "a list or mapping of issuers"
+	}
+	return "a string"
+}
+
+func sortedKeys[V any](mapping map[string]V) []string {
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigFilePath returns the configuration file the current settings were loaded from.
+func ConfigFilePath() string {
+	configFile.mu.RLock()
+	defer configFile.mu.RUnlock()
+	return configFile.path
+}
+
+// ConfigSources reports where each configured setting came from: "env" or "file".
+// Settings left at their defaults are omitted.
+func ConfigSources() map[string]string {
+	configFile.mu.RLock()
+	defer configFile.mu.RUnlock()
+	sources := map[string]string{}
+	for key := range configSettings {
+		if os.Getenv(key) != "" {
+			sources[key] = "env"
+		} else if _, ok := configFile.values[key]; ok {
+			sources[key] = "file"
+		}
+	}
+	return sources
+}
+
+// redactedConfigFields hold credentials and are never printed.
+var redactedConfigFields = map[string]bool{
+	"AuthSecret":      true,
+	"APIKeys":         true,
+	"AnchorAuthToken": true,
+	"AuthTokenKey":    true,
+	"TracingHeaders":  true,
+	"WalletSecret":    true,
+	"WalletKMSToken":  true,
+}
+
+// Resolved renders the configuration for display with secrets redacted: durations as
+// strings and byte slices as base64.
+func (c *Config) Resolved() map[string]any {
+	value := reflect.ValueOf(c).Elem()
+	resolved := map[string]any{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if redactedConfigFields[field.Name] {
+			resolved[field.Name] = redact(value.Field(i))
+			continue
+		}
+		resolved[field.Name] = resolvedValue(value.Field(i))
+	}
+	return resolved
+}
+
+func redact(value reflect.Value) any {
+	if value.Kind() == reflect.Map {
+		masked := map[string]string{}
+		for _, key := range value.MapKeys() {
+			masked[fmt.Sprint(key.Interface())] = "[redacted]"
+		}
+		return masked
+	}
+	if value.IsZero() {
+		return ""
+	}
+	return "[redacted]"
+}
+
+func resolvedValue(value reflect.Value) any {
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		fields := map[string]any{}
+		for i := 0; i < value.NumField(); i++ {
+			if field := value.Type().Field(i); field.IsExported() {
+				fields[field.Name] = resolvedValue(value.Field(i))
+			}
+		}
+		return fields
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(value.Bytes())
+		}
+		items := make([]any, value.Len())
+		for i := range items {
+			items[i] = resolvedValue(value.Index(i))
+		}
+		return items
+	case reflect.Map:
+		entries := map[string]any{}
+		for _, key := range value.MapKeys() {
+			entries[fmt.Sprint(key.Interface())] = resolvedValue(value.MapIndex(key))
+		}
+		return entries
+	}
+	return value.Interface()
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lets browser applications, such as the admin dashboard, call the gateway.
+type CORSConfig struct {
+	// AllowedOrigins lists exact origins ("https://dashboard.example.com") or "*". Empty
+	// disables CORS.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight answer.
+	MaxAge time.Duration
+}
+
+// Default CORS lists; they cover every header the gateway reads or sets.
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", IdempotencyKeyHeader, APIKeyHeader, ChannelHeader, RequestIDHeader, "Prefer", "If-None-Match", "traceparent"}
+	defaultCORSExposed = []string{"Retry-After", "Location", "ETag", "Idempotent-Replayed", "Preference-Applied", RequestIDHeader, "traceparent"}
+)
+
+// CORS answers preflight requests and adds the CORS response headers for allowed origins.
+type CORS struct {
+	cfg       CORSConfig
+	anyOrigin bool
+	origins   map[string]bool
+	methods   string
+	headers   string
+	exposed   string
+	maxAge    string
+	headerOK  map[string]bool
+}
+
+// NewCORS returns nil when no origin is allowed; a nil CORS passes requests through.
+func NewCORS(cfg CORSConfig) *CORS {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil
+	}
+	c := &CORS{
+		cfg:      cfg,
+		origins:  map[string]bool{},
+		methods:  strings.Join(cfg.AllowedMethods, ", "),
+		headers:  strings.Join(cfg.AllowedHeaders, ", "),
+		exposed:  strings.Join(cfg.ExposedHeaders, ", "),
+		maxAge:   strconv.Itoa(int(cfg.MaxAge.Seconds())),
+		headerOK: map[string]bool{},
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			c.anyOrigin = true
+			continue
+		}
+		c.origins[strings.TrimRight(origin, "/")] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		c.headerOK[strings.ToLower(header)] = true
+	}
+	return c
+}
+
+func (c *CORS) allowedOrigin(origin string) bool {
+	return c.anyOrigin || c.origins[origin]
+}
+
+// Middleware wraps the whole mux so preflights are answered before authentication, and error
+// responses from inner middleware still carry the headers browsers need to read them.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !c.allowedOrigin(origin) {
+			if preflight {
+				WriteErrorWithCode(w, http.StatusForbidden, NewStatusError(http.StatusForbidden, "origin "+origin+" is not allowed"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		c.writeOrigin(w, origin)
+		if !preflight {
+			if c.exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			if header = strings.ToLower(strings.TrimSpace(header)); header != "" && !c.headerOK[header] {
+				WriteErrorWithCode(w, http.StatusForbidden, NewStatusError(http.StatusForbidden, "header "+header+" is not allowed"))
+				return
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", c.methods)
+		if c.headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", c.headers)
+		}
+		if c.cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", c.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) writeOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin && !c.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
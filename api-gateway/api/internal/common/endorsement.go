@@ -0,0 +1,231 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseEndorsementOrgs reads ENDORSEMENT_ORGS selector=org|org entries: the orgs whose peers
+// must endorse invokes on that channel selector. An org listed twice needs two peers.
+func parseEndorsementOrgs(raw map[string]string, channels map[string]ChannelTarget, orgs map[string]OrgProfile) (map[string]map[string]int, error) {
+	policies := map[string]map[string]int{}
+	for selector, value := range raw {
+		if _, ok := channels[selector]; !ok {
+			return nil, fmt.Errorf("ENDORSEMENT_ORGS: unknown channel %s", selector)
+		}
+		required := map[string]int{}
+		for _, org := range strings.Split(value, "|") {
+			if org = strings.TrimSpace(org); org == "" {
+				continue
+			}
+			if _, ok := orgs[org]; !ok {
+				return nil, fmt.Errorf("ENDORSEMENT_ORGS: channel %s uses unknown org %s", selector, org)
+			}
+			required[org]++
+		}
+		policies[selector] = required
+	}
+	return policies, nil
+}
+
+// endorsementCache remembers the org layouts the discovery service reported per channel
+// target.
+type endorsementCache struct {
+	mu      sync.Mutex
+	layouts map[string]cachedLayout
+}
+
+type cachedLayout struct {
+	required map[string]int
+	expires  time.Time
+}
+
+// endorsingPeers returns the peers an invoke on target is sent to: peerName first, then
+// enough write peers of each other org the endorsement policy requires. Without a policy the
+// invoke is endorsed by peerName alone.
+func (f *FabricClient) endorsingPeers(ctx context.Context, target ChannelTarget, peerName string) ([]string, error) {
+	required := f.requiredOrgs(ctx, target, peerName)
+	if len(required) == 0 {
+		return []string{peerName}, nil
+	}
+	routes := f.routes.Load()
+	peers := []string{peerName}
+	chosen := map[string]int{routes.peers[peerName].Org: 1}
+	now := time.Now()
+	for _, org := range sortedKeys(required) {
+		var candidates []string
+		for _, name := range routes.names {
+			if routes.peers[name].Org == org && name != peerName && f.inPool(PeerWrite, name) {
+				candidates = append(candidates, name)
+			}
+		}
+		// Peers with a closed breaker go first; open ones are still used when nothing else is left.
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return routes.breakers[candidates[i]].available(now, f.cfg.PeerBreakerCooldown) &&
+				!routes.breakers[candidates[j]].available(now, f.cfg.PeerBreakerCooldown)
+		})
+		for _, name := range candidates {
+			if chosen[org] >= required[org] {
+				break
+			}
+			peers = append(peers, name)
+			chosen[org]++
+		}
+		if chosen[org] < required[org] {
+			return nil, NewStatusError(http.StatusServiceUnavailable, fmt.Sprintf("endorsement needs %d peer(s) of org %s but %d are routed", required[org], org, chosen[org]))
+		}
+	}
+	return peers, nil
+}
+
+// requiredOrgs resolves the endorsement layout for target: the discovery service's when
+// ENDORSEMENT_DISCOVERY is on and it answers, otherwise ENDORSEMENT_ORGS.
+func (f *FabricClient) requiredOrgs(ctx context.Context, target ChannelTarget, peerName string) map[string]int {
+	if f.cfg.EndorsementDiscovery {
+		required, err := f.discoveredOrgs(ctx, target, peerName)
+		if err == nil {
+			return required
+		}
+		log.Printf("endorser discovery for %s/%s failed, using ENDORSEMENT_ORGS: %v", target.Channel, target.Chaincode, err)
+	}
+	return f.cfg.EndorsementOrgs[target.Name]
+}
+
+// discoveredOrgs asks peerName's discovery service for the endorsers of target and returns
+// the orgs of its first layout, cached for ENDORSEMENT_DISCOVERY_TTL.
+func (f *FabricClient) discoveredOrgs(ctx context.Context, target ChannelTarget, peerName string) (map[string]int, error) {
+	key := target.Channel + "/" + target.Chaincode
+	f.endorsements.mu.Lock()
+	cached, ok := f.endorsements.layouts[key]
+	f.endorsements.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.required, nil
+	}
+
+	output, err := f.runDiscover(ctx, peerName, "endorsers", "--channel", target.Channel, "--chaincode", target.Chaincode)
+	if err != nil {
+		return nil, err
+	}
+	required, err := f.parseEndorsers(output)
+	if err != nil {
+		return nil, err
+	}
+	f.endorsements.mu.Lock()
+	f.endorsements.layouts[key] = cachedLayout{required: required, expires: time.Now().Add(f.cfg.EndorsementDiscoveryTTL)}
+	f.endorsements.mu.Unlock()
+	return required, nil
+}
+
+// discoveredEndorsers is the part of `discover endorsers` output the gateway reads.
+type discoveredEndorsers struct {
+	EndorsersByGroups map[string][]struct {
+		MSPID string `json:"MSPID"`
+	} `json:"EndorsersByGroups"`
+	Layout []struct {
+		QuantitiesByGroup map[string]int `json:"quantities_by_group"`
+	} `json:"Layout"`
+}
+
+// parseEndorsers maps the first layout's groups to configured orgs by MSP ID.
+func (f *FabricClient) parseEndorsers(output []byte) (map[string]int, error) {
+	raw := string(output)
+	if idx := strings.Index(raw, "["); idx != -1 {
+		raw = raw[idx:]
+	}
+	var descriptors []discoveredEndorsers
+	if err := json.Unmarshal([]byte(raw), &descriptors); err != nil {
+		return nil, fmt.Errorf("failed to parse discover output: %w", err)
+	}
+	if len(descriptors) == 0 || len(descriptors[0].Layout) == 0 {
+		return nil, fmt.Errorf("discovery returned no endorsement layout")
+	}
+	orgsByMSP := map[string]string{}
+	for name, org := range f.cfg.Orgs {
+		orgsByMSP[org.MSPID] = name
+	}
+	descriptor := descriptors[0]
+	required := map[string]int{}
+	for group, quantity := range descriptor.Layout[0].QuantitiesByGroup {
+		endorsers := descriptor.EndorsersByGroups[group]
+		if len(endorsers) == 0 {
+			return nil, fmt.Errorf("discovery group %s lists no endorsers", group)
+		}
+		org, ok := orgsByMSP[endorsers[0].MSPID]
+		if !ok {
+			return nil, fmt.Errorf("endorsers of %s are not in a configured org", endorsers[0].MSPID)
+		}
+		required[org] += quantity
+	}
+	return required, nil
+}
+
+// runDiscover runs a `discover` CLI command against peerName's discovery service as the
+// peer's org admin.
+func (f *FabricClient) runDiscover(ctx context.Context, peerName string, args ...string) ([]byte, error) {
+	peer, ok := f.routes.Load().peers[peerName]
+	if !ok {
+		return nil, fmt.Errorf("peer %s is not configured", peerName)
+	}
+	return f.discoverWith(ctx, peer, args...)
+}
+
+// discoverWith runs a `discover` CLI command against peer, bounded by FABRIC_QUERY_TIMEOUT;
+// the process is killed when ctx is done.
+func (f *FabricClient) discoverWith(ctx context.Context, peer PeerConfig, args ...string) ([]byte, error) {
+	_, mspPath, err := f.identityMSP(peer.Org, "")
+	if err != nil {
+		return nil, err
+	}
+	userCert, err := firstFile(filepath.Join(mspPath, "signcerts"))
+	if err != nil {
+		return nil, err
+	}
+	userKey, err := firstFile(filepath.Join(mspPath, "keystore"))
+	if err != nil {
+		return nil, err
+	}
+	command := []string{
+		"--peerTLSCA", peer.TLSPath,
+		"--userKey", userKey,
+		"--userCert", userCert,
+		"--MSP", f.cfg.Orgs[peer.Org].MSPID,
+	}
+	command = append(command, args...)
+	command = append(command, "--server", peer.Address)
+	ctx, cancel := withTimeout(ctx, f.cfg.FabricQueryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "discover", command...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("FABRIC_CFG_PATH=%s", f.cfg.FabricCfgPath))
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return nil, fmt.Errorf("discover %s abandoned: %w", args[0], ctxErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("discover %s failed: %s", args[0], SanitizeCLIError(string(output)))
+	}
+	return output, nil
+}
+
+// firstFile returns the path of the first regular file in dir.
+func firstFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no file in %s", dir)
+}
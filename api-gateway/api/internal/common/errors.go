@@ -2,6 +2,8 @@ package common
 
 import (
 	"errors"
+	"net/http"
+	"strings"
 )
 
 var (
@@ -10,10 +12,46 @@ var (
 	ErrInvalidCredentials = errors.New("invalid or missing credentials")
 )
 
-// StatusError conveys an HTTP response code alongside the error message.
+// ErrorCode is the stable, machine-readable classification of an error response. Clients
+// should branch on it rather than on messages, which may change.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	CodeInvalidPayload       ErrorCode = "INVALID_PAYLOAD"
+	CodeUnauthenticated      ErrorCode = "UNAUTHENTICATED"
+	CodeForbidden            ErrorCode = "FORBIDDEN"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed     ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeConflict             ErrorCode = "CONFLICT"
+	CodeAlreadyExists        ErrorCode = "ALREADY_EXISTS"
+	CodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeInputLimitExceeded   ErrorCode = "INPUT_LIMIT_EXCEEDED"
+	CodeUnprocessable        ErrorCode = "UNPROCESSABLE"
+	CodeRateLimited          ErrorCode = "RATE_LIMITED"
+	CodeTrainerNotRegistered ErrorCode = "TRAINER_NOT_REGISTERED"
+	CodeTrainerDeactivated   ErrorCode = "TRAINER_DEACTIVATED"
+	CodeTrainerRevoked       ErrorCode = "TRAINER_REVOKED"
+	CodeTrainerSuspended     ErrorCode = "TRAINER_SUSPENDED"
+	CodeAttestationFailed    ErrorCode = "ATTESTATION_FAILED"
+	CodeLedgerConflict       ErrorCode = "LEDGER_CONFLICT"
+	CodePeerUnavailable      ErrorCode = "PEER_UNAVAILABLE"
+	CodeChaincodeError       ErrorCode = "CHAINCODE_ERROR"
+	CodeNotImplemented       ErrorCode = "NOT_IMPLEMENTED"
+	CodeUnavailable          ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeStarting             ErrorCode = "STARTING"
+	CodeTimeout              ErrorCode = "TIMEOUT"
+	CodeInternal             ErrorCode = "INTERNAL"
+)
+
+// StatusError conveys an HTTP response code alongside the error message. ErrorCode and
+// Details are optional; without an ErrorCode the response code is derived from the message
+// and status (see ErrorCodeOf).
 type StatusError struct {
-	Code int
-	Msg  string
+	Code      int
+	Msg       string
+	ErrorCode ErrorCode
+	Details   any
 }
 
 func (e *StatusError) Error() string {
@@ -25,6 +63,11 @@ func NewStatusError(code int, msg string) error {
 	return &StatusError{Code: code, Msg: msg}
 }
 
+// NewCodedError builds a status error with an explicit error code and optional details.
+func NewCodedError(status int, code ErrorCode, msg string, details any) error {
+	return &StatusError{Code: status, Msg: msg, ErrorCode: code, Details: details}
+}
+
 // AsStatusError reports the embedded status error for centralized handling.
 func AsStatusError(err error) (*StatusError, bool) {
 	var se *StatusError
@@ -33,3 +76,97 @@ func AsStatusError(err error) (*StatusError, bool) {
 	}
 	return nil, false
 }
+
+// errorCodeFragments classifies errors that carry no explicit code by their message. Most of
+// them come back from the peer CLI, where only the text survives.
+var errorCodeFragments = []struct {
+	fragment string
+	code     ErrorCode
+}{
+	{"trainer not registered", CodeTrainerNotRegistered},
+	{"trainer not authorized", CodeTrainerNotRegistered},
+	{"trainer deactivated", CodeTrainerDeactivated},
+	{"trainer credential revoked", CodeTrainerRevoked},
+	{"trainer suspended", CodeTrainerSuspended},
+	{chaincodeInputLimitError, CodeInputLimitExceeded},
+	{"MVCC_READ_CONFLICT", CodeLedgerConflict},
+	{"PHANTOM_READ_CONFLICT", CodeLedgerConflict},
+	{"ProposalResponsePayloads do not match", CodeLedgerConflict},
+	{"no fabric peers configured", CodePeerUnavailable},
+	{"connection refused", CodePeerUnavailable},
+	{"transport is closing", CodePeerUnavailable},
+	{"code = Unavailable", CodePeerUnavailable},
+	{"failed to create new connection", CodePeerUnavailable},
+	{"timed out waiting for txid", CodeTimeout},
+	{"context deadline exceeded", CodeTimeout},
+	{"already exists", CodeAlreadyExists},
+}
+
+// statusErrorCodes is the fallback code of each response status.
+var statusErrorCodes = map[int]ErrorCode{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthenticated,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusNotImplemented:        CodeNotImplemented,
+	http.StatusBadGateway:            CodePeerUnavailable,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+	http.StatusGatewayTimeout:        CodeTimeout,
+}
+
+// ErrorCodeOf classifies err, answered with status: an explicit StatusError code wins, then
+// a known message fragment, then the status itself. Unclassified server errors from the peer
+// CLI are chaincode errors.
+func ErrorCodeOf(status int, err error) ErrorCode {
+	if se, ok := AsStatusError(err); ok && se.ErrorCode != "" {
+		return se.ErrorCode
+	}
+	if err != nil {
+		msg := err.Error()
+		for _, candidate := range errorCodeFragments {
+			if strings.Contains(msg, candidate.fragment) {
+				return candidate.code
+			}
+		}
+		if status >= http.StatusInternalServerError && strings.Contains(msg, "peer command failed") {
+			return CodeChaincodeError
+		}
+	}
+	if code, ok := statusErrorCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return CodeInvalidRequest
+	}
+	return CodeInternal
+}
+
+// ErrorEnvelope is the body of every error response.
+type ErrorEnvelope struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Details   any       `json:"details,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// NewErrorEnvelope describes err, answered with status, for the request requestID.
+func NewErrorEnvelope(status int, err error, requestID string) ErrorEnvelope {
+	envelope := ErrorEnvelope{Code: ErrorCodeOf(status, err), Message: err.Error(), RequestID: requestID}
+	if se, ok := AsStatusError(err); ok {
+		envelope.Details = se.Details
+	}
+	return envelope
+}
+
+// StatusOf is the response status of err: its StatusError code, or 500.
+func StatusOf(err error) int {
+	if se, ok := AsStatusError(err); ok {
+		return se.Code
+	}
+	return http.StatusInternalServerError
+}
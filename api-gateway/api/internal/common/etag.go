@@ -0,0 +1,144 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETagMiddleware tags successful GET and HEAD responses with a weak ETag derived from a hash
+// of the body and answers a request whose If-None-Match already names it with 304, so
+// dashboards polling large listings only download them when they change. Responses that set
+// their own ETag keep it and stream through unbuffered; streamed responses (those that
+// Flush) are passed through untouched.
+func ETagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writer := &etagWriter{ResponseWriter: w, match: r.Header.Get("If-None-Match"), status: http.StatusOK}
+		next.ServeHTTP(writer, r)
+		writer.finish()
+	})
+}
+
+// etagWriter buffers a response until the handler returns so its hash can be taken before
+// anything is sent.
+type etagWriter struct {
+	http.ResponseWriter
+	match  string
+	status int
+	body   bytes.Buffer
+	// passthrough is set once the response is being written straight through; notModified
+	// when it was answered with 304 and the body is discarded.
+	passthrough bool
+	notModified bool
+	decided     bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.decided {
+		if w.passthrough && !w.notModified {
+			w.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	w.status = code
+	if w.ResponseWriter.Header().Get("ETag") != "" {
+		w.startPassthrough()
+	}
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if !w.decided && w.ResponseWriter.Header().Get("ETag") != "" {
+		w.startPassthrough()
+	}
+	if w.notModified {
+		return len(p), nil
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.body.Write(p)
+}
+
+func (w *etagWriter) Flush() {
+	if !w.decided {
+		w.startPassthrough()
+	}
+	if w.notModified {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startPassthrough sends the status and whatever is buffered and writes the rest of the
+// response directly. A handler-set ETag the client already holds still earns a 304.
+func (w *etagWriter) startPassthrough() {
+	w.decided = true
+	w.passthrough = true
+	if w.status == http.StatusOK && etagMatches(w.match, w.ResponseWriter.Header().Get("ETag")) {
+		w.notModified = true
+		w.writeNotModified()
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// finish sends a buffered response, tagging it when it succeeded.
+func (w *etagWriter) finish() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if w.status != http.StatusOK {
+		w.ResponseWriter.WriteHeader(w.status)
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+	sum := sha256.Sum256(w.body.Bytes())
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	w.ResponseWriter.Header().Set("ETag", etag)
+	if etagMatches(w.match, etag) {
+		w.writeNotModified()
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
+
+func (w *etagWriter) writeNotModified() {
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+}
+
+// etagMatches applies If-None-Match's weak comparison: "*" or any listed tag equal to etag
+// once W/ prefixes are ignored.
+func etagMatches(header, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
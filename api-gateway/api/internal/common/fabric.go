@@ -2,24 +2,78 @@ package common
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"os/exec"
-	"sort"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
+// chaincodeInputLimitError prefixes the chaincode's structured error for transactions that
+// exceed its on-chain input limits; such calls are answered with 413.
+const chaincodeInputLimitError = "input limit exceeded: "
+
 // FabricClient shells out to the Fabric peer CLI to submit/evaluate chaincode transactions.
 type FabricClient struct {
-	cfg       *Config
-	peerNames []string
-	peerIndex uint32
+	cfg     *Config
+	tracer  *Tracer
+	retry   RetryPolicy
+	routes  atomic.Pointer[peerRoutes]
+	orderer atomic.Pointer[ordererRoute]
+	// peerIndex rotates round-robin selection, separately for reads and writes.
+	peerIndex [2]uint32
+
+	// wallet holds enrolled identities that take precedence over the org crypto folders;
+	// mspDir is where their MSP folders are materialized for the peer CLI.
+	wallet Wallet
+	mspDir string
+
+	endorsements endorsementCache
+	// tlsDir holds the TLS roots of discovered peers and orderers.
+	tlsDir string
+	// mock replaces the peer CLI under FABRIC_TRANSPORT=mock.
+	mock *mockLedger
+
+	retries   *CounterVec
+	exhausted *CounterVec
+
+	// fanOut holds the query functions answered by every healthy peer at once.
+	fanOut     map[string]bool
+	divergence *CounterVec
 }
 
-// NewFabricClient wires a FabricClient with the gateway configuration.
-func NewFabricClient(cfg *Config) *FabricClient {
-	return &FabricClient{cfg: cfg, peerNames: buildPeerOrder(cfg)}
+// NewFabricClient wires a FabricClient with the gateway configuration. Each peer command is
+// recorded as a client span on the tracer, and invoke retries are counted in metrics.
+func NewFabricClient(cfg *Config, tracer *Tracer, metrics *Metrics) *FabricClient {
+	client := &FabricClient{
+		cfg:       cfg,
+		tracer:    tracer,
+		retry:     cfg.FabricRetry,
+		retries:   metrics.Counter("fabric_invoke_retries_total", "Chaincode invokes retried after a transient failure.", "function", "reason"),
+		exhausted: metrics.Counter("fabric_invoke_retries_exhausted_total", "Chaincode invokes that still failed transiently after the last attempt.", "function", "reason"),
+
+		fanOut:     map[string]bool{},
+		divergence: metrics.Counter("fabric_fanout_divergence_total", "Fan-out queries whose peers returned different answers.", "function"),
+	}
+	for _, function := range cfg.FabricFanOutFunctions {
+		client.fanOut[function] = true
+	}
+	client.endorsements.layouts = map[string]cachedLayout{}
+	if cfg.FabricTransport == FabricTransportMock {
+		client.mock = newMockLedger()
+	}
+	client.routes.Store(newPeerRoutes(cfg.Peers, cfg.DefaultPeer, nil))
+	client.orderer.Store(&ordererRoute{Endpoint: cfg.OrdererEndpoint, Host: cfg.OrdererHost, TLSCA: cfg.OrdererTLSCA})
+	return client
 }
 
 // Config exposes the underlying configuration.
@@ -27,83 +81,388 @@ func (f *FabricClient) Config() *Config {
 	return f.cfg
 }
 
-// WaitForChannelReady ensures at least one peer has joined the channel before serving traffic.
-func (f *FabricClient) WaitForChannelReady(timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
-	peerNames := f.peerNames
+// ProbeChannels checks once that at least one peer has joined each configured channel. The
+// startup sequence retries it with backoff until it passes (see Startup).
+func (f *FabricClient) ProbeChannels() error {
+	peerNames := f.routes.Load().names
 	if len(peerNames) == 0 {
 		return fmt.Errorf("no peers configured")
 	}
-
-	var lastErr error
-	for time.Now().Before(deadline) {
+	for _, channel := range f.cfg.channelNames() {
+		var lastErr error
 		for _, peerName := range peerNames {
-			if _, err := f.runPeerCommand(peerName, "", []string{"channel", "getinfo", "-c", f.cfg.Channel}); err == nil {
-				return nil
-			} else {
-				lastErr = err
+			ctx, cancel := withTimeout(context.Background(), f.cfg.FabricQueryTimeout)
+			_, lastErr = f.runPeerCommand(ctx, peerName, "", []string{"channel", "getinfo", "-c", channel})
+			cancel()
+			if lastErr == nil {
+				break
 			}
 		}
-		time.Sleep(5 * time.Second)
+		if lastErr != nil {
+			return fmt.Errorf("channel %s: %w", channel, lastErr)
+		}
 	}
-	if lastErr == nil {
-		lastErr = fmt.Errorf("channel readiness timed out")
+	return nil
+}
+
+// ChannelInfo describes the ledger height and tip hashes reported by a peer.
+type ChannelInfo struct {
+	Height            uint64 `json:"height"`
+	CurrentBlockHash  string `json:"currentBlockHash"`
+	PreviousBlockHash string `json:"previousBlockHash"`
+}
+
+// ChannelInfo asks the peer for the current height and block hashes of the context's channel.
+func (f *FabricClient) ChannelInfo(ctx context.Context, peerName string) (*ChannelInfo, error) {
+	peerName, err := f.peerFor(ctx, PeerRead, peerName, "")
+	if err != nil {
+		return nil, err
+	}
+	return f.channelInfo(ctx, peerName)
+}
+
+// PeerChannelInfo asks exactly peerName, bypassing peer selection, for the context's channel
+// height, so callers can compare what individual peers report.
+func (f *FabricClient) PeerChannelInfo(ctx context.Context, peerName string) (*ChannelInfo, error) {
+	if _, ok := f.routes.Load().peers[peerName]; !ok {
+		return nil, fmt.Errorf("peer %s is not configured", peerName)
+	}
+	return f.channelInfo(ctx, peerName)
+}
+
+func (f *FabricClient) channelInfo(ctx context.Context, peerName string) (*ChannelInfo, error) {
+	ctx, span := f.startSpan(ctx, "channel getinfo", peerName, "")
+	defer span.End()
+	ctx, cancel := withTimeout(ctx, f.cfg.FabricQueryTimeout)
+	defer cancel()
+	output, err := f.runPeerCommand(ctx, peerName, "", []string{"channel", "getinfo", "-c", f.cfg.Target(ctx).Channel})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	raw := string(output)
+	if idx := strings.Index(raw, "{"); idx != -1 {
+		raw = raw[idx:]
+	}
+	var info ChannelInfo
+	if err := json.Unmarshal([]byte(raw), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse channel info: %w", err)
 	}
-	return lastErr
+	return &info, nil
 }
 
-// QueryChaincode evaluates the provided function/args on the target peer.
-func (f *FabricClient) QueryChaincode(peerName, identity string, args []string) ([]byte, error) {
-	payload := map[string]any{"Args": args}
-	return f.runPeerCommand(peerName, identity, []string{
+// QueryChaincode evaluates the provided function/args on the target peer, against the
+// channel/chaincode selected by ctx (see WithChannel). Functions listed in
+// FABRIC_FANOUT_FUNCTIONS are sent to every healthy peer instead (see fanOutQuery).
+func (f *FabricClient) QueryChaincode(ctx context.Context, peerName, identity string, args []string) ([]byte, error) {
+	peerName, err := f.peerFor(ctx, PeerRead, peerName, identity)
+	if err != nil {
+		return nil, err
+	}
+	if f.fanOut[chaincodeFunction(args)] {
+		if peers := f.fanOutPeers(ctx); len(peers) > 1 {
+			return f.fanOutQuery(ctx, peers, identity, args)
+		}
+	}
+	return f.queryPeer(ctx, peerName, identity, args)
+}
+
+// QueryPeer evaluates args on exactly peerName, bypassing peer selection and fan-out, so
+// callers can compare what individual peers report.
+func (f *FabricClient) QueryPeer(ctx context.Context, peerName, identity string, args []string) ([]byte, error) {
+	if _, ok := f.routes.Load().peers[peerName]; !ok {
+		return nil, fmt.Errorf("peer %s is not configured", peerName)
+	}
+	return f.queryPeer(ctx, peerName, identity, args)
+}
+
+// queryPeer evaluates args on peerName.
+func (f *FabricClient) queryPeer(ctx context.Context, peerName, identity string, args []string) ([]byte, error) {
+	ctx, span := f.startSpan(ctx, "chaincode query", peerName, chaincodeFunction(args))
+	defer span.End()
+	ctx, cancel := withTimeout(ctx, f.cfg.FabricQueryTimeout)
+	defer cancel()
+	target := f.cfg.Target(ctx)
+	payload := map[string]any{"Args": f.transportArgs(args)}
+	started := time.Now()
+	output, err := f.runPeerCommand(ctx, peerName, identity, append([]string{
 		"chaincode", "query",
-		"-C", f.cfg.Channel,
-		"-n", f.cfg.Chaincode,
+		"-C", target.Channel,
+		"-n", target.Chaincode,
 		"-c", MustJSON(payload),
-	})
+	}, transientArgs(ctx)...))
+	if err == nil {
+		f.observeLatency(peerName, time.Since(started))
+	}
+	span.RecordError(err)
+	return output, err
+}
+
+// InvokeChaincode submits a proposal, waits for commit and returns the transaction's
+// receipt. MVCC conflicts and transient peer/orderer errors are retried with exponential
+// backoff per the configured RetryPolicy.
+func (f *FabricClient) InvokeChaincode(ctx context.Context, peerName, identity string, args []string) (*TxReceipt, error) {
+	_, receipt, err := f.SubmitChaincode(ctx, peerName, identity, args)
+	return receipt, err
+}
+
+// SubmitChaincode behaves like InvokeChaincode and also returns the chaincode's response
+// payload as reported by the peer CLI. The submission is noted in the request's audit entry.
+func (f *FabricClient) SubmitChaincode(ctx context.Context, peerName, identity string, args []string) ([]byte, *TxReceipt, error) {
+	payload, receipt, err := f.submitChaincode(ctx, peerName, identity, args)
+	noteAuditTx(ctx, args, receipt, err)
+	return payload, receipt, err
+}
+
+func (f *FabricClient) submitChaincode(ctx context.Context, peerName, identity string, args []string) ([]byte, *TxReceipt, error) {
+	peerName, err := f.peerFor(ctx, PeerWrite, peerName, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	function := chaincodeFunction(args)
+	ctx, span := f.startSpan(ctx, "chaincode invoke", peerName, function)
+	defer span.End()
+	target := f.cfg.Target(ctx)
+	endorsers, err := f.endorsingPeers(ctx, target, peerName)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+	if len(endorsers) > 1 {
+		span.SetAttribute("fabric.endorsers", strings.Join(endorsers, ","))
+	}
+	attempts := f.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var output []byte
+	for attempt := 1; attempt <= attempts; attempt++ {
+		span.SetAttribute("fabric.attempts", attempt)
+		output, err = f.invokeOnce(ctx, target, endorsers, identity, args, transientArgs(ctx))
+		reason := retryReason(err)
+		if reason == "" {
+			break
+		}
+		if attempt == attempts {
+			f.exhausted.Inc(function, reason)
+			break
+		}
+		f.retries.Inc(function, reason)
+		if waitErr := sleepContext(ctx, f.retry.backoff(attempt)); waitErr != nil {
+			break
+		}
+	}
+	span.RecordError(err)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := parseInvokePayload(output)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt := parseTxReceipt(output)
+	if receipt == nil {
+		receipt = &TxReceipt{}
+	}
+	if receipt.TxID != "" {
+		span.SetAttribute("fabric.tx_id", receipt.TxID)
+		if f.cfg.FabricReceiptBlocks {
+			if number, err := f.BlockNumberForTx(ctx, peerName, identity, receipt.TxID); err == nil {
+				receipt.BlockNumber = number
+			} else {
+				log.Printf("block lookup for tx %s failed: %v", receipt.TxID, err)
+			}
+		}
+	}
+	return payload, receipt, nil
 }
 
-// InvokeChaincode submits a proposal and waits for commit.
-func (f *FabricClient) InvokeChaincode(peerName, identity string, args []string) error {
-	payload := map[string]any{"Args": args}
-	_, err := f.runPeerCommand(peerName, identity, []string{
+// invokeOnce sends the proposal to every endorser and submits it as the first one's org,
+// giving up after FabricInvokeTimeout. extra is appended to the CLI flags, e.g. the
+// transient data of the call.
+func (f *FabricClient) invokeOnce(ctx context.Context, target ChannelTarget, endorsers []string, identity string, args, extra []string) ([]byte, error) {
+	routes := f.routes.Load()
+	orderer := f.orderer.Load()
+	payload := map[string]any{"Args": f.transportArgs(args)}
+	command := []string{
 		"chaincode", "invoke",
-		"-o", f.cfg.OrdererEndpoint,
-		"--ordererTLSHostnameOverride", f.cfg.OrdererHost,
-		"-C", f.cfg.Channel,
-		"-n", f.cfg.Chaincode,
+		"-o", orderer.Endpoint,
+		"--ordererTLSHostnameOverride", orderer.Host,
+		"-C", target.Channel,
+		"-n", target.Chaincode,
 		"--waitForEvent",
 		"--tls",
-		"--cafile", f.cfg.OrdererTLSCA,
-		"--peerAddresses", f.cfg.Peers[peerName].Address,
-		"--tlsRootCertFiles", f.cfg.Peers[peerName].TLSPath,
-		"-c", MustJSON(payload),
-	})
-	return err
+		"--cafile", orderer.TLSCA,
+	}
+	for _, name := range endorsers {
+		peer, ok := routes.peers[name]
+		if !ok {
+			return nil, fmt.Errorf("peer %s is not configured", name)
+		}
+		command = append(command, "--peerAddresses", peer.Address, "--tlsRootCertFiles", peer.TLSPath)
+	}
+	command = append(command, "-c", MustJSON(payload))
+	command = append(command, extra...)
+	ctx, cancel := withTimeout(ctx, f.cfg.FabricInvokeTimeout)
+	defer cancel()
+	return f.runPeerCommand(ctx, endorsers[0], identity, command)
 }
 
-// SelectPeer returns the next peer using a round-robin strategy.
-func (f *FabricClient) SelectPeer() string {
-	if len(f.peerNames) == 0 {
+// SelectPeer returns the next peer of op's pool per PEER_SELECTION_STRATEGY, skipping peers
+// whose circuit breaker is open. It returns "" when no peer of the pool is routed. When every breaker is open it returns the preferred peer anyway so
+// requests still surface the underlying error. With consistent hashing the caller is only
+// known once the request is made, so QueryChaincode and SubmitChaincode replace this pick with
+// the caller's peer (see peerFor).
+func (f *FabricClient) SelectPeer(op PeerOperation) string {
+	routes := f.routes.Load()
+	var candidates []string
+	for _, name := range routes.names {
+		if f.inPool(op, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
 		return ""
 	}
-	idx := atomic.AddUint32(&f.peerIndex, 1)
-	pos := int((idx - 1) % uint32(len(f.peerNames)))
-	return f.peerNames[pos]
+	return f.pickPeer(routes, op, candidates, "")
 }
 
-func (f *FabricClient) runPeerCommand(peerName, identity string, args []string) ([]byte, error) {
-	peerCfg, ok := f.cfg.Peers[peerName]
+func (f *FabricClient) nextIndex(op PeerOperation) uint32 {
+	return atomic.AddUint32(&f.peerIndex[op], 1) - 1
+}
+
+// startSpan opens a client span annotated with the channel, chaincode function and peer.
+func (f *FabricClient) startSpan(ctx context.Context, operation, peerName, function string) (context.Context, *Span) {
+	name := "fabric " + operation
+	if function != "" {
+		name += " " + function
+	}
+	ctx, span := f.tracer.Start(ctx, name, SpanKindClient)
+	target := f.cfg.Target(ctx)
+	span.SetAttribute("fabric.channel", target.Channel)
+	span.SetAttribute("fabric.chaincode", target.Chaincode)
+	span.SetAttribute("fabric.peer", peerName)
+	if peer, ok := f.routes.Load().peers[peerName]; ok {
+		span.SetAttribute("server.address", peer.Address)
+	}
+	if function != "" {
+		span.SetAttribute("fabric.function", function)
+	}
+	return ctx, span
+}
+
+// invokePayloadPattern matches the response the CLI logs after a successful invoke:
+// `Chaincode invoke successful. result: status:200 payload:"..."`.
+var invokePayloadPattern = regexp.MustCompile(`result: status:\d+ payload:"((?:[^"\\]|\\.)*)"`)
+
+// parseInvokePayload extracts the chaincode response from CLI output. The payload is printed
+// in protobuf text format, which uses C-style escapes including \'. Invokes of functions
+// without a return value have no payload.
+func parseInvokePayload(output []byte) ([]byte, error) {
+	match := invokePayloadPattern.FindSubmatch(output)
+	if match == nil {
+		return nil, nil
+	}
+	escaped := string(match[1])
+	decoded := make([]byte, 0, len(escaped))
+	for len(escaped) > 0 {
+		if strings.HasPrefix(escaped, `\'`) {
+			decoded = append(decoded, '\'')
+			escaped = escaped[2:]
+			continue
+		}
+		value, multibyte, tail, err := strconv.UnquoteChar(escaped, '"')
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse invoke result: %w", err)
+		}
+		if value < utf8.RuneSelf || !multibyte {
+			decoded = append(decoded, byte(value))
+		} else {
+			decoded = utf8.AppendRune(decoded, value)
+		}
+		escaped = tail
+	}
+	return decoded, nil
+}
+
+// transportArgs returns the arguments sent to the peer: the JSON overload of the call when
+// FABRIC_JSON_ARGS is on, the positional call otherwise. Spans and metrics keep the
+// positional function name.
+func (f *FabricClient) transportArgs(args []string) []string {
+	if !f.cfg.FabricJSONArgs {
+		return args
+	}
+	return jsonPayloadArgs(args)
+}
+
+func chaincodeFunction(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// runPeerCommand executes the peer CLI and feeds connectivity failures to the peer's breaker.
+// Commands abandoned because the caller went away say nothing about the peer and are not
+// recorded.
+func (f *FabricClient) runPeerCommand(ctx context.Context, peerName, identity string, args []string) ([]byte, error) {
+	output, err := f.execPeerCommand(ctx, peerName, identity, args)
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	f.recordPeerResult(peerName, err, isPeerUnavailable(err))
+	return output, err
+}
+
+// execPeerCommand runs one peer command, killing it once ctx is done. A command cut short by
+// its deadline is answered with 504.
+func (f *FabricClient) execPeerCommand(ctx context.Context, peerName, identity string, args []string) ([]byte, error) {
+	peerCfg, ok := f.routes.Load().peers[peerName]
 	if !ok {
 		return nil, fmt.Errorf("peer %s is not configured", peerName)
 	}
-	mspPath, err := f.cfg.MSPPathForIdentity(identity)
+	if err := ctx.Err(); err != nil {
+		return nil, peerContextError(err)
+	}
+	var output []byte
+	var err error
+	if f.mock != nil {
+		output, err = f.mock.run(identity, args)
+		if se, ok := err.(*StatusError); ok {
+			return nil, se
+		}
+	} else {
+		output, err = f.runPeerCLI(ctx, peerCfg, identity, args)
+	}
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return nil, peerContextError(ctxErr)
+	}
+	if err != nil {
+		cleaned := SanitizeCLIError(string(output))
+		if idx := strings.Index(cleaned, chaincodeInputLimitError); idx >= 0 {
+			// The chaincode appends the exceeded limit as JSON; pass it on as the details.
+			var details map[string]any
+			detail := cleaned[idx+len(chaincodeInputLimitError):]
+			if end := strings.LastIndex(detail, "}"); end >= 0 {
+				_ = json.Unmarshal([]byte(detail[:end+1]), &details)
+			}
+			return nil, &StatusError{Code: http.StatusRequestEntityTooLarge, Msg: cleaned, ErrorCode: CodeInputLimitExceeded, Details: details}
+		}
+		return nil, fmt.Errorf("peer command failed: %s", cleaned)
+	}
+	return bytes.TrimSpace(output), nil
+}
+
+// runPeerCLI runs the peer CLI against peerCfg, signing as identity; the process is killed
+// when ctx is done.
+func (f *FabricClient) runPeerCLI(ctx context.Context, peerCfg PeerConfig, identity string, args []string) ([]byte, error) {
+	mspID, mspPath, err := f.identityMSP(peerCfg.Org, identity)
 	if err != nil {
 		return nil, err
 	}
-	cmd := exec.Command("peer", args...)
+	cmd := exec.CommandContext(ctx, "peer", args...)
 	env := append(os.Environ(),
-		fmt.Sprintf("CORE_PEER_LOCALMSPID=%s", f.cfg.MSPID),
+		fmt.Sprintf("CORE_PEER_LOCALMSPID=%s", mspID),
 		fmt.Sprintf("CORE_PEER_MSPCONFIGPATH=%s", mspPath),
 		"CORE_PEER_TLS_ENABLED=true",
 		fmt.Sprintf("CORE_PEER_TLS_ROOTCERT_FILE=%s", peerCfg.TLSPath),
@@ -111,32 +470,58 @@ func (f *FabricClient) runPeerCommand(peerName, identity string, args []string)
 		fmt.Sprintf("FABRIC_CFG_PATH=%s", f.cfg.FabricCfgPath),
 	)
 	cmd.Env = env
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		cleaned := SanitizeCLIError(string(output))
-		return nil, fmt.Errorf("peer command failed: %s", cleaned)
+	return cmd.CombinedOutput()
+}
+
+// peerContextError reports a peer command abandoned because ctx ended: a deadline becomes a
+// 504 (whose message keeps "context deadline exceeded" so breakers count the slow peer), a
+// cancelation is returned as is.
+func peerContextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &StatusError{Code: http.StatusGatewayTimeout, Msg: fmt.Sprintf("peer command timed out: %v", err)}
 	}
-	return bytes.TrimSpace(output), nil
+	return err
 }
 
-func buildPeerOrder(cfg *Config) []string {
-	if len(cfg.Peers) == 0 {
-		return nil
+// withTimeout bounds ctx by timeout; 0 leaves it unbounded.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	names := make([]string, 0, len(cfg.Peers))
-	if cfg.DefaultPeer != "" {
-		if _, ok := cfg.Peers[cfg.DefaultPeer]; ok {
-			names = append(names, cfg.DefaultPeer)
-		}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// UseWallet makes identities enrolled into wallet sign the calls made as their label, ahead of
+// the org crypto folders. Call it before serving requests.
+func (f *FabricClient) UseWallet(wallet Wallet) error {
+	dir, err := os.MkdirTemp("", "gateway-msp-")
+	if err != nil {
+		return fmt.Errorf("failed to prepare wallet MSP folder: %w", err)
 	}
-	var remaining []string
-	for name := range cfg.Peers {
-		if name == cfg.DefaultPeer {
-			continue
+	f.wallet = wallet
+	f.mspDir = dir
+	return nil
+}
+
+// identityMSP resolves the MSP ID and folder identity signs with: its wallet entry when it
+// has one, otherwise its folder under org's crypto path.
+func (f *FabricClient) identityMSP(org, identity string) (string, string, error) {
+	if f.wallet != nil && identity != "" {
+		stored, err := f.wallet.Get(identity)
+		switch {
+		case err == nil:
+			path, err := materializeMSP(f.mspDir, stored)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to prepare wallet identity %s: %w", identity, err)
+			}
+			return stored.MSPID, path, nil
+		case !errors.Is(err, ErrIdentityNotFound):
+			return "", "", err
 		}
-		remaining = append(remaining, name)
 	}
-	sort.Strings(remaining)
-	names = append(names, remaining...)
-	return names
+	path, err := f.cfg.mspPath(org, identity)
+	if err != nil {
+		return "", "", err
+	}
+	return f.cfg.Orgs[org].MSPID, path, nil
 }
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+)
+
+// fanOutAnswer is one peer's reply to a fan-out query.
+type fanOutAnswer struct {
+	peer   string
+	output []byte
+	err    error
+}
+
+// definitive reports whether the answer is the peer's view of the ledger: a result, or an
+// error from a peer that was reachable (such as a chaincode "not found"). Only a result
+// settles a query early; a lagging peer may not have the record yet.
+func (a fanOutAnswer) definitive() bool {
+	return a.err == nil || !isPeerUnavailable(a.err)
+}
+
+// same reports whether two definitive answers agree.
+func (a fanOutAnswer) same(other fanOutAnswer) bool {
+	if (a.err == nil) != (other.err == nil) {
+		return false
+	}
+	if a.err != nil {
+		return a.err.Error() == other.err.Error()
+	}
+	return bytes.Equal(a.output, other.output)
+}
+
+// fanOutPeers lists the read peers of the context's org whose breaker admits a request.
+func (f *FabricClient) fanOutPeers(ctx context.Context) []string {
+	org := f.cfg.OrgFor(ctx)
+	routes := f.routes.Load()
+	now := time.Now()
+	var peers []string
+	for _, name := range routes.names {
+		if routes.peers[name].Org == org && f.inPool(PeerRead, name) && routes.breakers[name].available(now, f.cfg.PeerBreakerCooldown) {
+			peers = append(peers, name)
+		}
+	}
+	return peers
+}
+
+// fanOutQuery sends args to every peer concurrently and returns the first result, so a slow
+// or lagging peer cannot hold up critical reads such as convergence status during round
+// transitions. A chaincode error is only returned once every peer has answered without a
+// result, and connectivity failures only when no peer was reachable. The remaining peers are
+// still heard out in the background, up to FABRIC_QUERY_TIMEOUT, and any that disagree with
+// the returned answer are logged and counted in fabric_fanout_divergence_total. If the
+// request ends before any peer answers, every query is abandoned.
+func (f *FabricClient) fanOutQuery(ctx context.Context, peers []string, identity string, args []string) ([]byte, error) {
+	queryCtx, cancel := context.WithCancel(withoutCancel{ctx})
+	answers := make(chan fanOutAnswer, len(peers))
+	for _, peer := range peers {
+		go func(peer string) {
+			output, err := f.queryPeer(queryCtx, peer, identity, args)
+			answers <- fanOutAnswer{peer: peer, output: output, err: err}
+		}(peer)
+	}
+	function := chaincodeFunction(args)
+	var refused []fanOutAnswer
+	var last fanOutAnswer
+	for pending := len(peers); pending > 0; pending-- {
+		select {
+		case answer := <-answers:
+			switch {
+			case answer.err == nil:
+				for _, earlier := range refused {
+					f.noteDivergence(function, answer, earlier)
+				}
+				go f.compareFanOut(function, answer, answers, pending-1, cancel)
+				return answer.output, nil
+			case answer.definitive():
+				refused = append(refused, answer)
+			default:
+				last = answer
+			}
+		case <-ctx.Done():
+			cancel()
+			return nil, ctx.Err()
+		}
+	}
+	cancel()
+	if len(refused) == 0 {
+		return nil, last.err
+	}
+	for _, other := range refused[1:] {
+		if !other.same(refused[0]) {
+			f.noteDivergence(function, refused[0], other)
+		}
+	}
+	return nil, refused[0].err
+}
+
+// compareFanOut waits for the pending answers of a fan-out query and reports the peers whose
+// definitive answer differs from first.
+func (f *FabricClient) compareFanOut(function string, first fanOutAnswer, answers <-chan fanOutAnswer, pending int, cancel context.CancelFunc) {
+	defer cancel()
+	for ; pending > 0; pending-- {
+		answer := <-answers
+		if !answer.definitive() || answer.same(first) {
+			continue
+		}
+		f.noteDivergence(function, first, answer)
+	}
+}
+
+// noteDivergence counts and logs a peer whose answer differs from the returned one.
+func (f *FabricClient) noteDivergence(function string, returned, other fanOutAnswer) {
+	f.divergence.Inc(function)
+	log.Printf("fan-out divergence: %s answered differently on %s than on %s", function, other.peer, returned.peer)
+}
+
+// withoutCancel keeps a context's values (channel target, trace span, transient data) but not
+// its cancellation or deadline.
+type withoutCancel struct {
+	parent context.Context
+}
+
+func (c withoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c withoutCancel) Done() <-chan struct{}       { return nil }
+func (c withoutCancel) Err() error                  { return nil }
+func (c withoutCancel) Value(key any) any           { return c.parent.Value(key) }
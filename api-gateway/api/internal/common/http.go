@@ -18,10 +18,14 @@ func WriteError(w http.ResponseWriter, err error) {
 	WriteErrorWithCode(w, http.StatusInternalServerError, err)
 }
 
-// WriteErrorWithCode logs and responds with the provided status code.
+// WriteErrorWithCode logs and responds with the provided status code. The body is an
+// ErrorEnvelope; its request_id is read back from the response header RequestIDMiddleware set.
 func WriteErrorWithCode(w http.ResponseWriter, code int, err error) {
-	log.Printf("error: %v", err)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	requestID := w.Header().Get(RequestIDHeader)
+	if requestID != "" {
+		log.Printf("error [%s]: %v", requestID, err)
+	} else {
+		log.Printf("error: %v", err)
+	}
+	WriteJSON(w, code, NewErrorEnvelope(code, err, requestID))
 }
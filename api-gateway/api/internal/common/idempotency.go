@@ -0,0 +1,153 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without repeating its side effects.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore remembers the response of POST requests carrying an Idempotency-Key so a
+// retry with the same key (per caller and route) replays it instead of re-executing.
+type IdempotencyStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+type idempotentResponse struct {
+	fingerprint string
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// NewIdempotencyStore creates an in-memory store that keeps responses for ttl.
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{ttl: ttl, entries: map[string]*idempotentResponse{}}
+}
+
+// IdempotencyKey returns the trimmed header value, or "" when absent.
+func IdempotencyKey(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+}
+
+// Middleware must run after authentication so keys are scoped to the caller. Requests without
+// the header, and non-POST requests, pass straight through. Reusing a key with a different
+// body is rejected with 422; a retry that arrives while the first attempt is still running
+// gets 409. Server errors are not cached so the client can retry them.
+func (s *IdempotencyStore) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := IdempotencyKey(r)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			WriteErrorWithCode(w, http.StatusBadRequest, NewStatusError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		subject := ""
+		if authCtx, ok := AuthContextFrom(r.Context()); ok {
+			subject = authCtx.Subject
+		}
+		cacheKey := strings.Join([]string{subject, r.URL.Path, key}, "\x00")
+
+		entry, owner, err := s.begin(cacheKey, fingerprint)
+		if err != nil {
+			se, _ := AsStatusError(err)
+			WriteErrorWithCode(w, se.Code, err)
+			return
+		}
+		if !owner {
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(entry.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+		recorder := &bufferingRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		s.finish(cacheKey, recorder)
+	})
+}
+
+// begin claims the key for this request, or returns the completed entry to replay.
+func (s *IdempotencyStore) begin(cacheKey, fingerprint string) (*idempotentResponse, bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		if entry.done && now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	if entry, ok := s.entries[cacheKey]; ok {
+		if entry.fingerprint != fingerprint {
+			return nil, false, NewStatusError(http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request body")
+		}
+		if !entry.done {
+			return nil, false, NewStatusError(http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+		}
+		return entry, false, nil
+	}
+	s.entries[cacheKey] = &idempotentResponse{fingerprint: fingerprint}
+	return nil, true, nil
+}
+
+func (s *IdempotencyStore) finish(cacheKey string, recorder *bufferingRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if recorder.status >= http.StatusInternalServerError {
+		delete(s.entries, cacheKey)
+		return
+	}
+	entry := s.entries[cacheKey]
+	entry.done = true
+	entry.status = recorder.status
+	entry.header = recorder.Header().Clone()
+	entry.body = recorder.body.Bytes()
+	entry.expires = time.Now().Add(s.ttl)
+}
+
+// bufferingRecorder writes through to the client while keeping a copy of the response.
+type bufferingRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *bufferingRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *bufferingRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
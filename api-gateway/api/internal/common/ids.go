@@ -2,6 +2,7 @@ package common
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"strings"
@@ -20,3 +21,14 @@ func GeneratePrefixedID(prefix string) string {
 	}
 	return fmt.Sprintf("%s-%s", prefix, hex.EncodeToString(buf[:]))
 }
+
+// DeterministicID derives a stable identifier from the supplied parts, so the same logical
+// request (e.g. a retried commit with an Idempotency-Key) always maps to the same ledger key.
+func DeterministicID(prefix string, parts ...string) string {
+	prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "-")
+	if prefix == "" {
+		prefix = "id"
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return fmt.Sprintf("%s-%s", prefix, hex.EncodeToString(sum[:16]))
+}
@@ -0,0 +1,113 @@
+package common
+
+import "strings"
+
+// chaincodeJSONArgs lists, for every multi-argument chaincode function, the payload field of
+// each positional argument in order. The chaincode exposes a <Function>JSON overload for each
+// of them that takes one JSON object with these fields.
+var chaincodeJSONArgs = map[string][]string{
+	"AcquireAggregationLease":              {"job_id", "scope_id", "round", "ttl"},
+	"AddRevokedVCHash":                     {"vc_hash", "reason"},
+	"ApplyClusteringPlan":                  {"assignments", "reason"},
+	"ChallengeConvergence":                 {"state_id", "reason"},
+	"ChallengeConvergenceInRound":          {"job_id", "round", "state_id", "reason"},
+	"CloseRound":                           {"job_id", "layer", "scope_id", "round"},
+	"CommitAttestedModel":                  {"data_id", "layer", "scope_id", "payload", "parent_model_ids", "job_id", "round", "model_hash", "signature"},
+	"CommitData":                           {"data_id", "payload"},
+	"CommitModel":                          {"data_id", "layer", "scope_id", "payload", "parent_model_ids"},
+	"CommitModelInRound":                   {"data_id", "job_id", "layer", "scope_id", "round", "payload", "parent_model_ids"},
+	"CommitModelWithMetadata":              {"data_id", "layer", "scope_id", "payload", "parent_model_ids", "job_id", "round", "model_hash", "signature", "metadata"},
+	"CommitNationAggregation":              {"round", "model_cid", "states", "metadata"},
+	"CommitNationStateConvergence":         {"state_id", "payload"},
+	"CommitNationStateConvergenceInRound":  {"job_id", "round", "state_id", "payload"},
+	"CommitStateClusterConvergence":        {"state_id", "cluster_id", "payload"},
+	"CommitStateClusterConvergenceInRound": {"job_id", "round", "state_id", "cluster_id", "payload"},
+	"CompleteJob":                          {"job_id", "final_model_id"},
+	"CreateDID":                            {"did", "document"},
+	"CreateJob":                            {"job_id", "name", "description"},
+	"DeactivateWhitelistEntry":             {"jwt_sub", "reason"},
+	"DeclareNationConvergenceInRound":      {"job_id", "round", "payload"},
+	"DeclareStateConvergence":              {"state_id", "payload"},
+	"DeclareStateConvergenceInRound":       {"job_id", "round", "state_id", "payload"},
+	"ExpireRound":                          {"job_id", "layer", "scope_id", "round", "min_quorum"},
+	"ExportStatePage":                      {"namespace", "page_size", "bookmark"},
+	"FlagModel":                            {"model_id", "reason", "evidence_hash"},
+	"GetAggregationLease":                  {"job_id", "scope_id", "round"},
+	"GetCurrentRound":                      {"job_id", "layer", "scope_id"},
+	"GetEvaluationConsensus":               {"model_id", "metric"},
+	"GetModelLineage":                      {"model_id", "max_depth"},
+	"GetTrainingConfigVersion":             {"job_id", "version"},
+	"ListAggregations":                     {"job_id", "layer", "scope_id", "round"},
+	"ListContributions":                    {"node_id", "job_id"},
+	"ListConvergenceDisputesInRound":       {"job_id", "round"},
+	"ListConvergenceHistory":               {"scope", "state_id"},
+	"ListKeyShares":                        {"job_id", "round"},
+	"ListLatestModels":                     {"layer", "scope_ids"},
+	"ListModelFlags":                       {"node_id", "model_id"},
+	"ListModels":                           {"layer", "scope_id", "page", "per_page"},
+	"ListModelsFiltered":                   {"filter", "page", "per_page"},
+	"ListModelsPage":                       {"layer", "scope_id", "page_size", "bookmark", "total"},
+	"ListNationConvergenceInRound":         {"job_id", "round"},
+	"ListPendingStateModelsInRound":        {"job_id", "round"},
+	"ListRoundModels":                      {"job_id", "layer", "scope_id", "round"},
+	"ListStateConvergenceInRound":          {"job_id", "round"},
+	"ListStateVerificationsInRound":        {"job_id", "round"},
+	"ListWhitelist":                        {"page", "per_page"},
+	"ListWhitelistByCapability":            {"filter", "page", "per_page"},
+	"ListWhitelistByCluster":               {"cluster_id", "state_id", "page", "per_page"},
+	"ListWhitelistByState":                 {"state_id", "page", "per_page"},
+	"ListWhitelistHierarchy":               {"page", "per_page"},
+	"ListWhitelistPage":                    {"page_size", "bookmark", "total"},
+	"NominateGlobalModel":                  {"model_id", "note"},
+	"PublishGlobalModel":                   {"model_id", "model_cid", "model_hash"},
+	"PublishKeyShare":                      {"job_id", "round", "public_key"},
+	"QueryModels":                          {"filter", "page_size", "bookmark"},
+	"ReadStateConvergenceInRound":          {"job_id", "round", "state_id"},
+	"RecordAggregation":                    {"job_id", "layer", "scope_id", "round", "input_model_ids", "output_model_id", "algorithm", "weights"},
+	"RecordAnchorReceipt":                  {"anchor_id", "digest", "block_height", "block_hash", "namespaces", "endpoint", "receipt", "anchored_at"},
+	"RecordAuditBatch":                     {"batch_id", "first_seq", "last_seq", "digest"},
+	"RecordContribution":                   {"job_id", "round", "node_id", "samples", "loss_delta", "model_hash"},
+	"RecordEvaluationRun":                  {"model_id", "dataset_hash", "metrics"},
+	"RecordStateVerification":              {"state_id", "model_id", "result", "evidence_hash", "note"},
+	"RecordStateVerificationInRound":       {"job_id", "round", "state_id", "model_id", "result", "evidence_hash", "note"},
+	"RecordWhitelistEntry":                 {"jwt_sub", "did", "node_id", "state", "cluster", "vc_hash", "public_key", "registered", "capabilities"},
+	"RegisterTrainer":                      {"did", "node_id", "vc_hash", "public_key", "state", "cluster"},
+	"ReinstateNode":                        {"node_id", "reason"},
+	"ReleaseAggregationLease":              {"job_id", "scope_id", "round"},
+	"RemoveWhitelistEntry":                 {"jwt_sub", "reason"},
+	"RenewAggregationLease":                {"job_id", "scope_id", "round", "ttl"},
+	"ResetConvergence":                     {"scope", "state_id", "reason"},
+	"ResetConvergenceInRound":              {"job_id", "round", "scope", "state_id", "reason"},
+	"ResolveConvergenceDispute":            {"dispute_id", "decision", "note"},
+	"ReviewGlobalCandidate":                {"model_id", "decision", "note"},
+	"StartRound":                           {"job_id", "layer", "scope_id"},
+	"SubmitEvaluation":                     {"model_id", "dataset_id", "metrics", "signature"},
+	"UpdateDIDDocument":                    {"did", "document"},
+	"UpdateJob":                            {"job_id", "name", "description"},
+	"UpdateTrainer":                        {"did", "jwt_sub", "public_key", "state", "cluster", "reason", "requested_by"},
+	"UpsertTrainingConfig":                 {"job_id", "config"},
+	"UpsertTrainingConfigWithFreeze":       {"job_id", "config", "freeze"},
+}
+
+// jsonPayloadArgs rewrites a positional call (function name followed by its arguments) into
+// the call of the function's JSON overload. Calls to functions without an overload, and calls
+// whose argument count does not match the table, are returned unchanged.
+func jsonPayloadArgs(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	function := args[0]
+	contract := ""
+	if prefix, name, found := strings.Cut(function, ":"); found {
+		contract, function = prefix+":", name
+	}
+	fields, ok := chaincodeJSONArgs[function]
+	if !ok || len(fields) != len(args)-1 {
+		return args
+	}
+	payload := make(map[string]string, len(fields))
+	for i, field := range fields {
+		payload[field] = args[i+1]
+	}
+	return []string{contract + function + "JSON", MustJSON(payload)}
+}
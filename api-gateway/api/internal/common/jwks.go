@@ -0,0 +1,245 @@
+package common
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefetchInterval bounds how often an unknown kid may trigger a refetch, so tokens with
+// made-up key ids cannot hammer the identity provider.
+const jwksRefetchInterval = 30 * time.Second
+
+// JWKS caches the signing keys published by an external identity provider. Keys are refreshed
+// once the cache is older than the refresh interval, and early when a token names a kid the
+// cache does not know yet (the provider rotated its keys).
+type JWKS struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*KeySpec
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewJWKS constructs a key cache for the JWKS document served at url.
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = 10 * time.Minute
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		keys:    map[string]*KeySpec{},
+	}
+}
+
+// Key returns the verification key for kid. An empty kid is accepted when the provider
+// publishes exactly one key.
+func (j *JWKS) Key(kid, alg string) (*KeySpec, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	_, known := j.lookup(kid)
+	stale := now.Sub(j.fetchedAt) > j.refresh
+	if stale || (!known && now.Sub(j.lastAttempt) > jwksRefetchInterval) {
+		j.lastAttempt = now
+		keys, err := j.fetch()
+		switch {
+		case err == nil:
+			j.keys = keys
+			j.fetchedAt = now
+		case len(j.keys) == 0:
+			return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+		default:
+			log.Printf("jwks: refresh failed, keeping %d cached key(s): %v", len(j.keys), err)
+		}
+	}
+	key, ok := j.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("no JWKS key matches kid %q", kid)
+	}
+	if !strings.EqualFold(key.Algorithm, alg) {
+		return nil, fmt.Errorf("JWKS key %q is for %s, token uses %s", kid, key.Algorithm, alg)
+	}
+	return key, nil
+}
+
+func (j *JWKS) lookup(kid string) (*KeySpec, bool) {
+	if kid == "" {
+		if len(j.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) fetch() (map[string]*KeySpec, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), j.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, j.url)
+	}
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+	keys := map[string]*KeySpec{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		spec, err := jwk.keySpec()
+		if err != nil {
+			log.Printf("jwks: skipping key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = spec
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS document contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// jsonWebKey holds the RFC 7517 fields needed for RSA, P-256 and Ed25519 keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) keySpec() (*KeySpec, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeKeyParam(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeKeyParam(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported RSA exponent")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &KeySpec{Algorithm: "RS256", Key: key}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeKeyParam(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeKeyParam(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point is not on P-256")
+		}
+		return &KeySpec{Algorithm: "ES256", Key: key}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeKeyParam(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return &KeySpec{Algorithm: "EdDSA", PublicKey: x}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeKeyParam(value string) ([]byte, error) {
+	if value == "" {
+		return nil, errors.New("missing value")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+func verifyRSASignature(unsigned, signatureSegment string, key crypto.PublicKey) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("RS256 requires an RSA public key")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(signatureSegment)
+	if err != nil {
+		return fmt.Errorf("invalid token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(unsigned))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+		return errors.New("invalid token signature")
+	}
+	return nil
+}
+
+func verifyECDSASignature(unsigned, signatureSegment string, key crypto.PublicKey) error {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("ES256 requires an ECDSA public key")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(signatureSegment)
+	if err != nil {
+		return fmt.Errorf("invalid token signature: %w", err)
+	}
+	if len(signature) != 64 {
+		return errors.New("invalid token signature")
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	digest := sha256.Sum256([]byte(unsigned))
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return errors.New("invalid token signature")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// Lifecycle event types, published once the transaction behind them has committed.
+const (
+	EventTrainerRegistered = "trainer.registered"
+	EventRoundOpened       = "round.opened"
+	EventRoundClosed       = "round.closed"
+	EventStateConverged    = "state.converged"
+	EventNationConverged   = "nation.converged"
+)
+
+// LifecycleEventTypes lists every lifecycle event type.
+var LifecycleEventTypes = []string{EventTrainerRegistered, EventRoundOpened, EventRoundClosed, EventStateConverged, EventNationConverged}
+
+// IsLifecycleEventType reports whether name is one of LifecycleEventTypes.
+func IsLifecycleEventType(name string) bool {
+	for _, candidate := range LifecycleEventTypes {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleEvent is a training milestone the gateway drove, as delivered to webhooks and
+// other listeners. Data is the event's subject: the trainer, round or convergence.
+type LifecycleEvent struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	OccurredAt string `json:"occurred_at"`
+	JobID      string `json:"job_id,omitempty"`
+	TxID       string `json:"tx_id,omitempty"`
+	Data       any    `json:"data"`
+}
+
+// LifecycleBus fans lifecycle events out to in-process listeners. Listeners run on the
+// publishing request's goroutine and must hand work off rather than block. A nil bus drops
+// every event, so services can publish unconditionally.
+type LifecycleBus struct {
+	mu        sync.RWMutex
+	listeners []func(*LifecycleEvent)
+}
+
+// NewLifecycleBus returns a bus without listeners.
+func NewLifecycleBus() *LifecycleBus {
+	return &LifecycleBus{}
+}
+
+// Listen registers fn for every event published afterwards.
+func (b *LifecycleBus) Listen(fn func(*LifecycleEvent)) {
+	b.mu.Lock()
+	b.listeners = append(b.listeners, fn)
+	b.mu.Unlock()
+}
+
+// Publish stamps the event with an ID and time and hands it to every listener.
+func (b *LifecycleBus) Publish(event *LifecycleEvent) {
+	if b == nil || event == nil {
+		return
+	}
+	if event.ID == "" {
+		event.ID = GeneratePrefixedID("evt")
+	}
+	if event.OccurredAt == "" {
+		event.OccurredAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	b.mu.RLock()
+	listeners := append([]func(*LifecycleEvent){}, b.listeners...)
+	b.mu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metrics is a small registry of labelled counters rendered in the Prometheus text format.
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]*CounterVec
+}
+
+// CounterVec is a monotonically increasing counter partitioned by label values.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewMetrics creates an empty registry.
+func NewMetrics() *Metrics {
+	return &Metrics{counters: map[string]*CounterVec{}}
+}
+
+// Counter returns the named counter, registering it on first use.
+func (m *Metrics) Counter(name, help string, labels ...string) *CounterVec {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.counters[name]; ok {
+		return existing
+	}
+	counter := &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+	m.counters[name] = counter
+	return counter
+}
+
+// Inc adds one to the series identified by the label values (in registration order).
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increases the series by delta.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if c == nil {
+		return
+	}
+	key := strings.Join(values, "\xff")
+	c.mu.Lock()
+	c.values[key] += delta
+	c.mu.Unlock()
+}
+
+// Handler serves the registry at `/metrics`.
+func (m *Metrics) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			WriteErrorWithCode(w, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.Render(w)
+	}
+}
+
+// Render writes every counter in name order.
+func (m *Metrics) Render(w io.Writer) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		m.mu.Lock()
+		counter := m.counters[name]
+		m.mu.Unlock()
+		counter.writeTo(w)
+	}
+}
+
+func (c *CounterVec) writeTo(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %g\n", c.name, c.formatLabels(key), c.values[key])
+	}
+}
+
+func (c *CounterVec) formatLabels(key string) string {
+	if len(c.labels) == 0 {
+		return ""
+	}
+	values := strings.Split(key, "\xff")
+	pairs := make([]string, 0, len(c.labels))
+	for i, label := range c.labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,1350 @@
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mockFunction is a chaincode function the mock ledger emulates, with its parameter count.
+type mockFunction struct {
+	params int
+	fn     func(tx *mockTx, args []string) (any, error)
+}
+
+// mockChaincode emulates the gateway chaincode functions the trainer, round, convergence and
+// revocation flows use, keeping their record shapes and validation messages. Job lifecycles,
+// suspensions, DIDs and the other modules are not emulated; calls to them fail with 501.
+var mockChaincode = map[string]mockFunction{
+	"RegisterTrainer":      {6, mockRegisterTrainer},
+	"IsTrainerAuthorized":  {0, mockIsTrainerAuthorized},
+	"RecordWhitelistEntry": {9, mockRecordWhitelistEntry},
+	"ListWhitelist":        {2, mockListWhitelist},
+
+	"CommitData": {2, mockCommitData},
+	"ReadData":   {1, mockReadData},
+
+	"CommitModel": {5, func(tx *mockTx, a []string) (any, error) {
+		return mockCommitModel(tx, a[0], a[1], a[2], a[3], a[4], "", "", nil, nil)
+	}},
+	"CommitModelInRound": {7, func(tx *mockTx, a []string) (any, error) {
+		if strings.TrimSpace(a[4]) == "" {
+			return nil, errors.New("round must be a positive integer")
+		}
+		return mockCommitModel(tx, a[0], a[2], a[3], a[5], a[6], a[1], a[4], nil, nil)
+	}},
+	"CommitAttestedModel": {9, func(tx *mockTx, a []string) (any, error) {
+		attestation := &mockAttestation{ModelHash: strings.TrimSpace(a[7]), Signature: strings.TrimSpace(a[8])}
+		return mockCommitModel(tx, a[0], a[1], a[2], a[3], a[4], a[5], a[6], attestation, nil)
+	}},
+	"CommitModelWithMetadata": {10, mockCommitModelWithMetadata},
+	"ReadModel":               {1, mockReadModel},
+	"ListModels":              {4, mockListModels},
+	"CountModels":             {0, mockCountModels},
+	"ListLatestModels":        {2, mockListLatestModels},
+	"ListRoundModels":         {4, mockListRoundModels},
+	"GetModelByHash":          {1, mockGetModelByHash},
+
+	"StartRound":        {3, mockStartRound},
+	"CloseRound":        {4, mockCloseRound},
+	"GetCurrentRound":   {3, mockGetCurrentRound},
+	"ListCurrentRounds": {1, mockListCurrentRounds},
+	"ListRounds":        {1, mockListRounds},
+
+	"CommitStateClusterConvergence": {3, func(tx *mockTx, a []string) (any, error) {
+		return mockCommitConvergence(tx, mockConvergenceScope{}, a[0], a[1], a[2])
+	}},
+	"CommitNationStateConvergence": {2, func(tx *mockTx, a []string) (any, error) {
+		return mockCommitConvergence(tx, mockConvergenceScope{}, a[0], "", a[1])
+	}},
+	"DeclareStateConvergence": {2, func(tx *mockTx, a []string) (any, error) {
+		return mockDeclareConvergence(tx, mockConvergenceScope{}, a[0], a[1])
+	}},
+	"DeclareNationConvergence": {1, func(tx *mockTx, a []string) (any, error) {
+		return mockDeclareConvergence(tx, mockConvergenceScope{}, "", a[0])
+	}},
+	"ReadStateConvergence": {1, func(tx *mockTx, a []string) (any, error) {
+		return mockReadStateConvergence(tx, mockConvergenceScope{}, a[0])
+	}},
+	"ListStateConvergence": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockListStateConvergence(tx, mockConvergenceScope{})
+	}},
+	"ReadNationConvergence": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockListNationConvergence(tx, mockConvergenceScope{})
+	}},
+	"ListNationConvergence": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockListNationConvergence(tx, mockConvergenceScope{})
+	}},
+	"CommitStateClusterConvergenceInRound": {5, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockCommitConvergence(tx, scope, a[2], a[3], a[4])
+	}},
+	"CommitNationStateConvergenceInRound": {4, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockCommitConvergence(tx, scope, a[2], "", a[3])
+	}},
+	"DeclareStateConvergenceInRound": {4, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockDeclareConvergence(tx, scope, a[2], a[3])
+	}},
+	"DeclareNationConvergenceInRound": {3, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockDeclareConvergence(tx, scope, "", a[2])
+	}},
+	"ReadStateConvergenceInRound": {3, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockReadStateConvergence(tx, scope, a[2])
+	}},
+	"ListStateConvergenceInRound": {2, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockListStateConvergence(tx, scope)
+	}},
+	"ListNationConvergenceInRound": {2, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockListNationConvergence(tx, scope)
+	}},
+
+	"RecordStateVerification": {5, func(tx *mockTx, a []string) (any, error) {
+		return mockRecordStateVerification(tx, mockConvergenceScope{}, a[0], a[1], a[2], a[3], a[4])
+	}},
+	"ListStateVerifications": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockStateVerifications(tx, mockConvergenceScope{})
+	}},
+	"ListPendingStateModels": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockPendingStateModels(tx, mockConvergenceScope{})
+	}},
+	"RecordStateVerificationInRound": {7, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockRecordStateVerification(tx, scope, a[2], a[3], a[4], a[5], a[6])
+	}},
+	"ListStateVerificationsInRound": {2, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockStateVerifications(tx, scope)
+	}},
+	"ListPendingStateModelsInRound": {2, func(tx *mockTx, a []string) (any, error) {
+		scope, err := mockParseConvergenceScope(a[0], a[1])
+		if err != nil {
+			return nil, err
+		}
+		return mockPendingStateModels(tx, scope)
+	}},
+
+	"AddRevokedVCHash": {2, mockAddRevokedVCHash},
+	"IsVCRevoked": {1, func(tx *mockTx, a []string) (any, error) {
+		vcHash := strings.ToLower(strings.TrimSpace(a[0]))
+		if vcHash == "" {
+			return nil, errors.New("vcHash is required")
+		}
+		return tx.get(mockRevokedPrefix+vcHash) != nil, nil
+	}},
+	"ListRevokedVCs": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockList[mockRevokedVC](tx, mockRevokedPrefix)
+	}},
+
+	"RecordAuditBatch": {4, mockRecordAuditBatch},
+	"ReadAuditBatch": {1, func(tx *mockTx, a []string) (any, error) {
+		var batch mockAuditBatch
+		if ok, err := tx.getJSON(mockAuditBatchPrefix+strings.TrimSpace(a[0]), &batch); err != nil || !ok {
+			if err == nil {
+				err = fmt.Errorf("audit batch %s not found", a[0])
+			}
+			return nil, err
+		}
+		return &batch, nil
+	}},
+	"ListAuditBatches": {0, func(tx *mockTx, a []string) (any, error) {
+		return mockList[mockAuditBatch](tx, mockAuditBatchPrefix)
+	}},
+}
+
+const (
+	mockTrainerPrefix    = "trainer:"
+	mockWhitelistPrefix  = "whitelist:"
+	mockDataPrefix       = "data:"
+	mockModelPrefix      = "model:"
+	mockRoundCurrent     = "round:current:"
+	mockRoundEntry       = "round:entry:"
+	mockRevokedPrefix    = "revoked:"
+	mockAuditBatchPrefix = "auditbatch:"
+
+	mockModelIndex     = "model~layer~scope~round~id"
+	mockModelHashIndex = "model~hash~id"
+	mockStateConv      = "conv~state"
+	mockNationConv     = "conv~nation"
+	mockJobStateConv   = "conv~job~state"
+	mockJobNationConv  = "conv~job~nation"
+	mockStateVerif     = "verification~state"
+	mockJobStateVerif  = "verification~job~state"
+
+	mockDefaultJob = "default"
+)
+
+var errMockTrainerUnauthorized = errors.New("trainer not authorized")
+
+// The record types below mirror the chaincode's JSON.
+
+type mockTrainer struct {
+	ClientID   string `json:"client_id"`
+	DID        string `json:"did"`
+	NodeID     string `json:"node_id"`
+	State      string `json:"state,omitempty"`
+	Cluster    string `json:"cluster,omitempty"`
+	VCHash     string `json:"vc_hash"`
+	PublicKey  string `json:"public_key"`
+	Status     string `json:"status"`
+	Registered string `json:"registered_at"`
+}
+
+type mockWhitelistEntry struct {
+	JWTSub          string          `json:"jwt_sub"`
+	DID             string          `json:"did"`
+	NodeID          string          `json:"node_id"`
+	State           string          `json:"state,omitempty"`
+	Cluster         string          `json:"cluster,omitempty"`
+	VCHash          string          `json:"vc_hash"`
+	PublicKey       string          `json:"public_key"`
+	Registered      string          `json:"registered_at"`
+	Capabilities    json.RawMessage `json:"capabilities,omitempty"`
+	Status          string          `json:"status,omitempty"`
+	StatusReason    string          `json:"status_reason,omitempty"`
+	StatusChangedBy string          `json:"status_changed_by,omitempty"`
+	StatusChangedAt string          `json:"status_changed_at,omitempty"`
+}
+
+type mockDataRecord struct {
+	ID          string `json:"id"`
+	Owner       string `json:"owner"`
+	Payload     string `json:"payload"`
+	SubmittedAt string `json:"submitted_at"`
+}
+
+type mockModelRecord struct {
+	ID              string             `json:"id"`
+	Layer           string             `json:"layer"`
+	ScopeID         string             `json:"scope_id"`
+	Owner           string             `json:"owner"`
+	Payload         string             `json:"payload"`
+	SubmittedAt     string             `json:"submitted_at"`
+	JobID           string             `json:"job_id,omitempty"`
+	RoundNumber     int                `json:"round,omitempty"`
+	ParentModelIDs  []string           `json:"parent_model_ids,omitempty"`
+	ModelHash       string             `json:"model_hash,omitempty"`
+	Signature       string             `json:"signature,omitempty"`
+	PayloadHash     string             `json:"payload_hash,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters string             `json:"hyperparameters,omitempty"`
+}
+
+type mockListPage[T any] struct {
+	Items   []*T `json:"items"`
+	Page    int  `json:"page"`
+	PerPage int  `json:"per_page"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"has_more"`
+}
+
+type mockTrainingRound struct {
+	JobID     string `json:"job_id"`
+	Layer     string `json:"layer"`
+	ScopeID   string `json:"scope_id"`
+	Round     int    `json:"round"`
+	Status    string `json:"status"`
+	StartedBy string `json:"started_by"`
+	StartedAt string `json:"started_at"`
+	ClosedBy  string `json:"closed_by,omitempty"`
+	ClosedAt  string `json:"closed_at,omitempty"`
+}
+
+type mockConvergenceRecord struct {
+	Scope       string `json:"scope"`
+	StateID     string `json:"state_id"`
+	ClusterID   string `json:"cluster_id,omitempty"`
+	SourceID    string `json:"source_id"`
+	Payload     string `json:"payload"`
+	SubmittedAt string `json:"submitted_at"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+}
+
+type mockConvergenceSummary struct {
+	Scope      string `json:"scope"`
+	TargetID   string `json:"target_id"`
+	DeclaredBy string `json:"declared_by"`
+	DeclaredAt string `json:"declared_at"`
+	Payload    string `json:"payload"`
+	JobID      string `json:"job_id,omitempty"`
+	Round      int    `json:"round,omitempty"`
+}
+
+type mockStateConvergence struct {
+	StateID  string                            `json:"state_id"`
+	Clusters map[string]*mockConvergenceRecord `json:"clusters"`
+	Summary  *mockConvergenceSummary           `json:"summary,omitempty"`
+}
+
+type mockNationConvergence struct {
+	States  map[string]*mockConvergenceRecord `json:"states"`
+	Summary *mockConvergenceSummary           `json:"summary,omitempty"`
+}
+
+type mockStateVerification struct {
+	StateID      string `json:"state_id"`
+	ModelID      string `json:"model_id"`
+	PayloadHash  string `json:"payload_hash,omitempty"`
+	Result       string `json:"result"`
+	EvidenceHash string `json:"evidence_hash"`
+	Note         string `json:"note,omitempty"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+	VerifiedBy   string `json:"verified_by"`
+	VerifiedAt   string `json:"verified_at"`
+	TxID         string `json:"tx_id"`
+}
+
+type mockRevokedVC struct {
+	VCHash    string `json:"vc_hash"`
+	Reason    string `json:"reason,omitempty"`
+	RevokedBy string `json:"revoked_by"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+type mockAuditBatch struct {
+	BatchID    string `json:"batch_id"`
+	FirstSeq   uint64 `json:"first_seq"`
+	LastSeq    uint64 `json:"last_seq"`
+	Digest     string `json:"digest"`
+	RecordedBy string `json:"recorded_by"`
+	RecordedAt string `json:"recorded_at"`
+}
+
+// mockList decodes every record under prefix in key order.
+func mockList[T any](tx *mockTx, prefix string) ([]*T, error) {
+	records := make([]*T, 0)
+	for _, key := range tx.scan(prefix) {
+		var record T
+		if err := json.Unmarshal(tx.get(key), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+func mockRequired(value, message string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New(message)
+	}
+	return nil
+}
+
+// mockPaging parses the page and perPage arguments of the paginated listings.
+func mockPaging(pageArg, perPageArg string, defaultPerPage int) (int, int, error) {
+	page, perPage := 1, defaultPerPage
+	if strings.TrimSpace(pageArg) != "" {
+		value, err := strconv.Atoi(pageArg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid page parameter: %w", err)
+		}
+		if value < 1 {
+			return 0, 0, errors.New("page must be >= 1")
+		}
+		page = value
+	}
+	if strings.TrimSpace(perPageArg) != "" {
+		value, err := strconv.Atoi(perPageArg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid perPage parameter: %w", err)
+		}
+		if value < 1 {
+			return 0, 0, errors.New("perPage must be >= 1")
+		}
+		perPage = value
+	}
+	return page, perPage, nil
+}
+
+func mockPage[T any](records []*T, page, perPage int) *mockListPage[T] {
+	start := (page - 1) * perPage
+	items := make([]*T, 0, perPage)
+	for i := start; i < len(records) && len(items) < perPage; i++ {
+		items = append(items, records[i])
+	}
+	return &mockListPage[T]{Items: items, Page: page, PerPage: perPage, Total: len(records), HasMore: len(records) > start+len(items)}
+}
+
+// Trainers and whitelist.
+
+func mockRegisterTrainer(tx *mockTx, a []string) (any, error) {
+	did, nodeID, vcHash, publicKey := a[0], a[1], a[2], a[3]
+	for _, check := range [][2]string{{did, "did is required"}, {nodeID, "nodeId is required"}, {vcHash, "vcHash is required"}, {publicKey, "publicKey is required"}} {
+		if err := mockRequired(check[0], check[1]); err != nil {
+			return nil, err
+		}
+	}
+	if tx.get(mockRevokedPrefix+strings.ToLower(strings.TrimSpace(vcHash))) != nil {
+		return nil, errors.New("trainer credential revoked")
+	}
+	return nil, tx.put(mockTrainerPrefix+tx.clientID, &mockTrainer{
+		ClientID:   tx.clientID,
+		DID:        did,
+		NodeID:     nodeID,
+		State:      strings.TrimSpace(a[4]),
+		Cluster:    strings.TrimSpace(a[5]),
+		VCHash:     vcHash,
+		PublicKey:  publicKey,
+		Status:     "AUTHORIZED",
+		Registered: tx.now,
+	})
+}
+
+// requireTrainer resolves the caller's trainer record like requireAuthorizedTrainer.
+func (tx *mockTx) requireTrainer() (*mockTrainer, error) {
+	var trainer mockTrainer
+	ok, err := tx.getJSON(mockTrainerPrefix+tx.clientID, &trainer)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !strings.EqualFold(trainer.Status, "AUTHORIZED") {
+		return nil, errMockTrainerUnauthorized
+	}
+	if tx.get(mockRevokedPrefix+strings.ToLower(strings.TrimSpace(trainer.VCHash))) != nil {
+		return nil, errors.New("trainer credential revoked")
+	}
+	return &trainer, nil
+}
+
+// invokerName prefers the registered trainer's node ID and falls back to the client identity.
+func (tx *mockTx) invokerName() string {
+	if trainer, err := tx.requireTrainer(); err == nil {
+		return trainer.NodeID
+	}
+	return tx.clientID
+}
+
+func mockIsTrainerAuthorized(tx *mockTx, a []string) (any, error) {
+	_, err := tx.requireTrainer()
+	return err == nil, nil
+}
+
+func mockRecordWhitelistEntry(tx *mockTx, a []string) (any, error) {
+	jwtSub := strings.ToLower(strings.TrimSpace(a[0]))
+	for _, check := range [][2]string{{jwtSub, "jwtSub is required"}, {a[1], "did is required"}, {a[2], "nodeId is required"}, {a[5], "vcHash is required"}, {a[6], "publicKey is required"}} {
+		if err := mockRequired(check[0], check[1]); err != nil {
+			return nil, err
+		}
+	}
+	entry := &mockWhitelistEntry{
+		JWTSub:     jwtSub,
+		DID:        a[1],
+		NodeID:     a[2],
+		State:      strings.TrimSpace(a[3]),
+		Cluster:    strings.TrimSpace(a[4]),
+		VCHash:     a[5],
+		PublicKey:  a[6],
+		Registered: strings.TrimSpace(a[7]),
+		Status:     "active",
+	}
+	if entry.Registered == "" {
+		entry.Registered = tx.now
+	}
+	if capabilities := strings.TrimSpace(a[8]); capabilities != "" {
+		if !json.Valid([]byte(capabilities)) {
+			return nil, errors.New("invalid capabilities: must be a JSON object")
+		}
+		entry.Capabilities = json.RawMessage(capabilities)
+	}
+	var previous mockWhitelistEntry
+	if ok, _ := tx.getJSON(mockWhitelistPrefix+jwtSub, &previous); ok && previous.Status != "" {
+		entry.Status = previous.Status
+		entry.StatusReason = previous.StatusReason
+		entry.StatusChangedBy = previous.StatusChangedBy
+		entry.StatusChangedAt = previous.StatusChangedAt
+	}
+	return nil, tx.put(mockWhitelistPrefix+jwtSub, entry)
+}
+
+func mockListWhitelist(tx *mockTx, a []string) (any, error) {
+	page, perPage, err := mockPaging(a[0], a[1], 50)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := mockList[mockWhitelistEntry](tx, mockWhitelistPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return mockPage(entries, page, perPage), nil
+}
+
+// Data.
+
+func mockCommitData(tx *mockTx, a []string) (any, error) {
+	trainer, err := tx.requireTrainer()
+	if err != nil {
+		return nil, err
+	}
+	if err := mockRequired(a[0], "data identifier is required"); err != nil {
+		return nil, err
+	}
+	if tx.get(mockDataPrefix+a[0]) != nil {
+		return nil, fmt.Errorf("data %s already exists", a[0])
+	}
+	record := &mockDataRecord{ID: a[0], Owner: trainer.NodeID, Payload: a[1], SubmittedAt: tx.now}
+	return record, tx.put(mockDataPrefix+a[0], record)
+}
+
+func mockReadData(tx *mockTx, a []string) (any, error) {
+	if _, err := tx.requireTrainer(); err != nil {
+		return nil, err
+	}
+	if err := mockRequired(a[0], "data identifier is required"); err != nil {
+		return nil, err
+	}
+	var record mockDataRecord
+	ok, err := tx.getJSON(mockDataPrefix+a[0], &record)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("record %s not found", a[0])
+	}
+	return &record, nil
+}
+
+// Models.
+
+type mockAttestation struct {
+	ModelHash string
+	Signature string
+}
+
+// verify checks the signature over {"layer","scope_id","model_hash"} against the trainer's
+// registered public key.
+func (a *mockAttestation) verify(publicKey, layer, scopeID string) error {
+	if a.ModelHash == "" {
+		return errors.New("model attestation failed: model hash is required")
+	}
+	if a.Signature == "" {
+		return errors.New("model attestation failed: signature is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("model attestation failed: trainer public key is not a base64 Ed25519 key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("model attestation failed: invalid signature encoding: %v", err)
+	}
+	message := MustJSON(map[string]string{"layer": layer, "scope_id": scopeID, "model_hash": a.ModelHash})
+	if !ed25519.Verify(ed25519.PublicKey(key), []byte(message), sig) {
+		return errors.New("model attestation failed: signature does not match the trainer's registered public key")
+	}
+	return nil
+}
+
+func mockCommitModelWithMetadata(tx *mockTx, a []string) (any, error) {
+	var metadata struct {
+		Metrics         map[string]float64 `json:"metrics"`
+		Hyperparameters json.RawMessage    `json:"hyperparameters"`
+	}
+	if err := json.Unmarshal([]byte(a[9]), &metadata); err != nil {
+		return nil, fmt.Errorf("invalid model metadata: %w", err)
+	}
+	for name, value := range metadata.Metrics {
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("metric names must not be empty")
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("metric %s must be a finite number", name)
+		}
+	}
+	var attestation *mockAttestation
+	if strings.TrimSpace(a[7]) != "" || strings.TrimSpace(a[8]) != "" {
+		attestation = &mockAttestation{ModelHash: strings.TrimSpace(a[7]), Signature: strings.TrimSpace(a[8])}
+	}
+	record, err := mockCommitModel(tx, a[0], a[1], a[2], a[3], a[4], a[5], a[6], attestation, metadata.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	if hyperparameters := strings.TrimSpace(string(metadata.Hyperparameters)); hyperparameters != "" && hyperparameters != "null" {
+		record.Hyperparameters = hyperparameters
+		if err := tx.put(mockModelPrefix+record.ID, record); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// mockCommitModel commits like commitModelInOptionalRound: roundArg empty commits outside any
+// round, otherwise the job's round must be open.
+func mockCommitModel(tx *mockTx, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg string, attestation *mockAttestation, metrics map[string]float64) (*mockModelRecord, error) {
+	trainer, err := tx.requireTrainer()
+	if err != nil {
+		return nil, err
+	}
+	round := 0
+	if strings.TrimSpace(roundArg) != "" {
+		if jobID, layer, scopeID, err = mockRoundScope(jobID, layer, scopeID); err != nil {
+			return nil, err
+		}
+		if round, err = mockRoundNumber(roundArg); err != nil {
+			return nil, err
+		}
+		current, err := mockCurrentRound(tx, jobID, layer, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case current == nil || round > current.Round:
+			return nil, fmt.Errorf("round %d has not started", round)
+		case round < current.Round || current.Status != "OPEN":
+			return nil, fmt.Errorf("round %d is closed", round)
+		}
+	} else {
+		jobID = ""
+	}
+	id := strings.TrimSpace(dataID)
+	parents, err := mockParentModelIDs(tx, id, parentModelIDs)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, errors.New("data identifier is required")
+	}
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	if layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	scope := strings.TrimSpace(scopeID)
+	if scope == "" {
+		return nil, errors.New("scope identifier is required")
+	}
+	if attestation != nil {
+		if err := attestation.verify(trainer.PublicKey, layer, scope); err != nil {
+			return nil, err
+		}
+	}
+	if tx.get(mockModelPrefix+id) != nil {
+		return nil, fmt.Errorf("model %s already exists", id)
+	}
+	record := &mockModelRecord{
+		ID:             id,
+		Layer:          layer,
+		ScopeID:        scope,
+		Owner:          trainer.NodeID,
+		Payload:        payload,
+		SubmittedAt:    tx.now,
+		JobID:          jobID,
+		RoundNumber:    round,
+		ParentModelIDs: parents,
+		PayloadHash:    mockPayloadDigest(payload),
+		Metrics:        metrics,
+	}
+	if attestation != nil {
+		record.ModelHash = attestation.ModelHash
+		record.Signature = attestation.Signature
+	}
+	if err := tx.put(mockModelPrefix+id, record); err != nil {
+		return nil, err
+	}
+	tx.writes[mockKey(mockModelIndex, layer, strings.ToLower(scope), fmt.Sprintf("%010d", round), id)] = []byte{0x00}
+	tx.writes[mockKey(mockModelHashIndex, record.PayloadHash, id)] = []byte{0x00}
+	return record, nil
+}
+
+func mockParentModelIDs(tx *mockTx, modelID, raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+		return nil, fmt.Errorf("invalid parentModelIds: %w", err)
+	}
+	parents := make([]string, 0, len(ids))
+	seen := map[string]bool{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		switch {
+		case id == "":
+			return nil, errors.New("parent model identifiers must not be empty")
+		case id == modelID:
+			return nil, errors.New("a model cannot be its own parent")
+		case seen[id]:
+			continue
+		case tx.get(mockModelPrefix+id) == nil:
+			return nil, fmt.Errorf("parent model %s not found", id)
+		}
+		seen[id] = true
+		parents = append(parents, id)
+	}
+	return parents, nil
+}
+
+// mockPayloadDigest hashes the payload, or takes the digest an off-chain pointer or an
+// encrypted envelope carries for the payload it stands for.
+func mockPayloadDigest(payload string) string {
+	type wrapper struct {
+		SHA256 string `json:"sha256"`
+		Digest string `json:"digest"`
+	}
+	var wrapped struct {
+		Offchain  *wrapper `json:"offchain"`
+		Encrypted *wrapper `json:"encrypted"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(payload), "{") && json.Unmarshal([]byte(payload), &wrapped) == nil {
+		for _, w := range []*wrapper{wrapped.Offchain, wrapped.Encrypted} {
+			switch {
+			case w == nil:
+			case w.Digest != "":
+				return strings.ToLower(w.Digest)
+			case w.SHA256 != "":
+				return strings.ToLower(w.SHA256)
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+func mockReadModelRecord(tx *mockTx, id string) (*mockModelRecord, error) {
+	var record mockModelRecord
+	ok, err := tx.getJSON(mockModelPrefix+id, &record)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// mockIndexedModels returns the models of the model index under attributes, in index order.
+func mockIndexedModels(tx *mockTx, attributes ...string) ([]*mockModelRecord, error) {
+	records := make([]*mockModelRecord, 0)
+	for _, key := range tx.scan(mockKey(mockModelIndex, attributes...)) {
+		parts := mockKeyParts(key, 0)
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", key)
+		}
+		record, err := mockReadModelRecord(tx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func mockReadModel(tx *mockTx, a []string) (any, error) {
+	if _, err := tx.requireTrainer(); err != nil {
+		return nil, err
+	}
+	if err := mockRequired(a[0], "data identifier is required"); err != nil {
+		return nil, err
+	}
+	record, err := mockReadModelRecord(tx, a[0])
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("model %s not found", a[0])
+	}
+	return record, nil
+}
+
+func mockListModels(tx *mockTx, a []string) (any, error) {
+	if _, err := tx.requireTrainer(); err != nil {
+		return nil, err
+	}
+	layer := strings.ToLower(strings.TrimSpace(a[0]))
+	if layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	page, perPage, err := mockPaging(a[2], a[3], 10)
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{layer}
+	if scope := strings.ToLower(strings.TrimSpace(a[1])); scope != "" {
+		attributes = append(attributes, scope)
+	}
+	records, err := mockIndexedModels(tx, attributes...)
+	if err != nil {
+		return nil, err
+	}
+	return mockPage(records, page, perPage), nil
+}
+
+func mockCountModels(tx *mockTx, a []string) (any, error) {
+	counts := map[string]int{}
+	for _, key := range tx.scan(mockKey(mockModelIndex)) {
+		if parts := mockKeyParts(key, 0); len(parts) == 4 {
+			counts[parts[0]]++
+		}
+	}
+	return counts, nil
+}
+
+func mockListLatestModels(tx *mockTx, a []string) (any, error) {
+	layer := strings.ToLower(strings.TrimSpace(a[0]))
+	if layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	var scopes []string
+	if err := json.Unmarshal([]byte(a[1]), &scopes); err != nil {
+		return nil, fmt.Errorf("invalid scopeIds: %w", err)
+	}
+	latest := map[string]*mockModelRecord{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		records, err := mockIndexedModels(tx, layer, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			current := latest[scope]
+			if current == nil || record.RoundNumber > current.RoundNumber ||
+				(record.RoundNumber == current.RoundNumber && record.SubmittedAt >= current.SubmittedAt) {
+				latest[scope] = record
+			}
+		}
+	}
+	return latest, nil
+}
+
+func mockListRoundModels(tx *mockTx, a []string) (any, error) {
+	jobID, layer, scopeID, err := mockRoundScope(a[0], a[1], a[2])
+	if err != nil {
+		return nil, err
+	}
+	round, err := mockRoundNumber(a[3])
+	if err != nil {
+		return nil, err
+	}
+	records, err := mockIndexedModels(tx, layer, strings.ToLower(scopeID), fmt.Sprintf("%010d", round))
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]*mockModelRecord, 0, len(records))
+	for _, record := range records {
+		if record.JobID == jobID {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+func mockGetModelByHash(tx *mockTx, a []string) (any, error) {
+	hash := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(a[0]), "sha256:"))
+	if hash == "" {
+		return nil, errors.New("hash is required")
+	}
+	records := make([]*mockModelRecord, 0)
+	for _, key := range tx.scan(mockKey(mockModelHashIndex, hash)) {
+		parts := mockKeyParts(key, 0)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed model hash key %q", key)
+		}
+		record, err := mockReadModelRecord(tx, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Rounds.
+
+func mockRoundScope(jobID, layer, scopeID string) (string, string, string, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = mockDefaultJob
+	}
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	if layer == "" {
+		return "", "", "", errors.New("layer is required")
+	}
+	scopeID = strings.TrimSpace(scopeID)
+	if scopeID == "" {
+		return "", "", "", errors.New("scope identifier is required")
+	}
+	return jobID, layer, scopeID, nil
+}
+
+func mockRoundNumber(roundArg string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || number < 1 {
+		return 0, errors.New("round must be a positive integer")
+	}
+	return number, nil
+}
+
+func mockCurrentRound(tx *mockTx, jobID, layer, scopeID string) (*mockTrainingRound, error) {
+	var round mockTrainingRound
+	ok, err := tx.getJSON(fmt.Sprintf("%s%s:%s:%s", mockRoundCurrent, jobID, layer, scopeID), &round)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &round, nil
+}
+
+func mockPutRound(tx *mockTx, round *mockTrainingRound) error {
+	if err := tx.put(fmt.Sprintf("%s%s:%s:%s", mockRoundCurrent, round.JobID, round.Layer, round.ScopeID), round); err != nil {
+		return err
+	}
+	return tx.put(fmt.Sprintf("%s%s:%s:%s:%010d", mockRoundEntry, round.JobID, round.Layer, round.ScopeID, round.Round), round)
+}
+
+func mockStartRound(tx *mockTx, a []string) (any, error) {
+	jobID, layer, scopeID, err := mockRoundScope(a[0], a[1], a[2])
+	if err != nil {
+		return nil, err
+	}
+	current, err := mockCurrentRound(tx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if current != nil {
+		if current.Status == "OPEN" {
+			return nil, fmt.Errorf("round %d is still open for %s/%s/%s", current.Round, jobID, layer, scopeID)
+		}
+		next = current.Round + 1
+	}
+	round := &mockTrainingRound{JobID: jobID, Layer: layer, ScopeID: scopeID, Round: next, Status: "OPEN", StartedBy: tx.invokerName(), StartedAt: tx.now}
+	return round, mockPutRound(tx, round)
+}
+
+func mockCloseRound(tx *mockTx, a []string) (any, error) {
+	jobID, layer, scopeID, err := mockRoundScope(a[0], a[1], a[2])
+	if err != nil {
+		return nil, err
+	}
+	number, err := mockRoundNumber(a[3])
+	if err != nil {
+		return nil, err
+	}
+	current, err := mockCurrentRound(tx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Round != number {
+		return nil, fmt.Errorf("round %d is not the current round", number)
+	}
+	if current.Status != "OPEN" {
+		return nil, fmt.Errorf("round %d is already closed", number)
+	}
+	current.Status = "CLOSED"
+	current.ClosedBy = tx.invokerName()
+	current.ClosedAt = tx.now
+	return current, mockPutRound(tx, current)
+}
+
+func mockGetCurrentRound(tx *mockTx, a []string) (any, error) {
+	jobID, layer, scopeID, err := mockRoundScope(a[0], a[1], a[2])
+	if err != nil {
+		return nil, err
+	}
+	current, err := mockCurrentRound(tx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("no round started for %s/%s/%s", jobID, layer, scopeID)
+	}
+	return current, nil
+}
+
+func mockListCurrentRounds(tx *mockTx, a []string) (any, error) {
+	prefix := mockRoundCurrent
+	if jobID := strings.TrimSpace(a[0]); jobID != "" {
+		prefix += jobID + ":"
+	}
+	return mockList[mockTrainingRound](tx, prefix)
+}
+
+func mockListRounds(tx *mockTx, a []string) (any, error) {
+	jobID := strings.TrimSpace(a[0])
+	if jobID == "" {
+		jobID = mockDefaultJob
+	}
+	rounds, err := mockList[mockTrainingRound](tx, mockRoundEntry+jobID+":")
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]*mockTrainingRound, 0, len(rounds))
+	for _, round := range rounds {
+		if round.JobID == jobID {
+			matching = append(matching, round)
+		}
+	}
+	return matching, nil
+}
+
+// Convergence.
+
+// mockConvergenceScope is a job round for the *InRound functions, zero for the legacy ones.
+type mockConvergenceScope struct {
+	jobID string
+	round int
+}
+
+func mockParseConvergenceScope(jobID, roundArg string) (mockConvergenceScope, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = mockDefaultJob
+	}
+	round, err := mockRoundNumber(roundArg)
+	if err != nil {
+		return mockConvergenceScope{}, err
+	}
+	return mockConvergenceScope{jobID: jobID, round: round}, nil
+}
+
+func (s mockConvergenceScope) prefix() []string {
+	if s.jobID == "" {
+		return nil
+	}
+	return []string{s.jobID, fmt.Sprintf("%010d", s.round)}
+}
+
+func (s mockConvergenceScope) stateKey(attributes ...string) string {
+	if s.jobID == "" {
+		return mockKey(mockStateConv, attributes...)
+	}
+	return mockKey(mockJobStateConv, append(s.prefix(), attributes...)...)
+}
+
+func (s mockConvergenceScope) verificationKey(attributes ...string) string {
+	if s.jobID == "" {
+		return mockKey(mockStateVerif, attributes...)
+	}
+	return mockKey(mockJobStateVerif, append(s.prefix(), attributes...)...)
+}
+
+func (s mockConvergenceScope) describe() string {
+	if s.jobID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for job %s round %d", s.jobID, s.round)
+}
+
+func (s mockConvergenceScope) nationKey(attributes ...string) string {
+	if s.jobID == "" {
+		return mockKey(mockNationConv, attributes...)
+	}
+	return mockKey(mockJobNationConv, append(s.prefix(), attributes...)...)
+}
+
+// mockCommitConvergence records a cluster's convergence toward its state, or a state's toward
+// the nation when clusterID is empty.
+func mockCommitConvergence(tx *mockTx, scope mockConvergenceScope, stateID, clusterID, payload string) (any, error) {
+	trainer, err := tx.requireTrainer()
+	if err != nil {
+		return nil, err
+	}
+	nation := clusterID == ""
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	if stateID == "" {
+		return nil, errors.New("stateId is required")
+	}
+	clusterID = strings.ToLower(strings.TrimSpace(clusterID))
+	if !nation && clusterID == "" {
+		return nil, errors.New("clusterId is required")
+	}
+	if err := mockRequired(payload, "payload is required"); err != nil {
+		return nil, err
+	}
+	record := &mockConvergenceRecord{Scope: "state", StateID: stateID, ClusterID: clusterID, SourceID: trainer.NodeID, Payload: payload, SubmittedAt: tx.now, JobID: scope.jobID, Round: scope.round}
+	key := scope.stateKey(stateID, "cluster", clusterID)
+	if nation {
+		record.Scope = "nation"
+		key = scope.nationKey("state", stateID)
+	}
+	return record, tx.put(key, record)
+}
+
+// mockDeclareConvergence declares a state converged, or the nation when stateID is empty.
+// The first declaration wins.
+func mockDeclareConvergence(tx *mockTx, scope mockConvergenceScope, stateID, payload string) (any, error) {
+	trainer, err := tx.requireTrainer()
+	if err != nil {
+		return nil, err
+	}
+	describe := scope.describe()
+	summary := &mockConvergenceSummary{Scope: "nation", TargetID: "nation", DeclaredBy: trainer.NodeID, DeclaredAt: tx.now, Payload: payload, JobID: scope.jobID, Round: scope.round}
+	key := scope.nationKey("summary")
+	if nation := stateID == ""; !nation {
+		if stateID = strings.ToLower(strings.TrimSpace(stateID)); stateID == "" {
+			return nil, errors.New("stateId is required")
+		}
+		summary.Scope, summary.TargetID = "state", stateID
+		key = scope.stateKey(stateID, "summary")
+		if tx.get(key) != nil {
+			return nil, fmt.Errorf("state %s already declared converged%s", stateID, describe)
+		}
+	} else if tx.get(key) != nil {
+		return nil, fmt.Errorf("nation convergence already declared%s", describe)
+	}
+	if err := mockRequired(payload, "payload is required"); err != nil {
+		return nil, err
+	}
+	if summary.Scope == "nation" {
+		if err := mockRequirePassingVerifications(tx, scope); err != nil {
+			return nil, err
+		}
+	}
+	return summary, tx.put(key, summary)
+}
+
+func mockReadStateConvergence(tx *mockTx, scope mockConvergenceScope, stateID string) (any, error) {
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	if stateID == "" {
+		return nil, errors.New("stateId is required")
+	}
+	states, err := mockStateConvergences(tx, scope, stateID)
+	if err != nil {
+		return nil, err
+	}
+	if state, ok := states[stateID]; ok {
+		return state, nil
+	}
+	return &mockStateConvergence{StateID: stateID, Clusters: map[string]*mockConvergenceRecord{}}, nil
+}
+
+func mockListStateConvergence(tx *mockTx, scope mockConvergenceScope) (any, error) {
+	return mockStateConvergences(tx, scope)
+}
+
+func mockStateConvergences(tx *mockTx, scope mockConvergenceScope, attributes ...string) (map[string]*mockStateConvergence, error) {
+	results := map[string]*mockStateConvergence{}
+	for _, key := range tx.scan(scope.stateKey(attributes...)) {
+		parts := mockKeyParts(key, len(scope.prefix()))
+		if len(parts) < 2 {
+			continue
+		}
+		state, ok := results[parts[0]]
+		if !ok {
+			state = &mockStateConvergence{StateID: parts[0], Clusters: map[string]*mockConvergenceRecord{}}
+			results[parts[0]] = state
+		}
+		switch parts[1] {
+		case "summary":
+			if err := json.Unmarshal(tx.get(key), &state.Summary); err != nil {
+				return nil, err
+			}
+		case "cluster":
+			var record mockConvergenceRecord
+			if err := json.Unmarshal(tx.get(key), &record); err != nil {
+				return nil, err
+			}
+			state.Clusters[record.ClusterID] = &record
+		}
+	}
+	return results, nil
+}
+
+func mockListNationConvergence(tx *mockTx, scope mockConvergenceScope) (any, error) {
+	result := &mockNationConvergence{States: map[string]*mockConvergenceRecord{}}
+	for _, key := range tx.scan(scope.nationKey()) {
+		parts := mockKeyParts(key, len(scope.prefix()))
+		if len(parts) == 0 {
+			continue
+		}
+		switch parts[0] {
+		case "summary":
+			if err := json.Unmarshal(tx.get(key), &result.Summary); err != nil {
+				return nil, err
+			}
+		case "state":
+			var record mockConvergenceRecord
+			if err := json.Unmarshal(tx.get(key), &record); err != nil {
+				return nil, err
+			}
+			result.States[record.StateID] = &record
+		}
+	}
+	return result, nil
+}
+
+// Verification.
+
+func mockRecordStateVerification(tx *mockTx, scope mockConvergenceScope, stateID, modelID, result, evidenceHash, note string) (any, error) {
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	if stateID == "" {
+		return nil, errors.New("stateId is required")
+	}
+	result = strings.ToUpper(strings.TrimSpace(result))
+	if result != "PASS" && result != "FAIL" {
+		return nil, errors.New("result must be pass or fail")
+	}
+	evidenceHash = strings.ToLower(strings.TrimSpace(evidenceHash))
+	if decoded, err := hex.DecodeString(evidenceHash); err != nil || len(decoded) != sha256.Size {
+		return nil, errors.New("evidenceHash must be a hex SHA-256 digest")
+	}
+	modelID = strings.TrimSpace(modelID)
+	model, err := mockReadModelRecord(tx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	if model.Layer != "state" || !strings.EqualFold(model.ScopeID, stateID) {
+		return nil, fmt.Errorf("model %s is not a model of state %s", model.ID, stateID)
+	}
+	if model.JobID != scope.jobID || model.RoundNumber != scope.round {
+		committed := scope.describe()
+		if committed == "" {
+			committed = " outside a job round"
+		}
+		return nil, fmt.Errorf("model %s was not committed%s", model.ID, committed)
+	}
+	verification := &mockStateVerification{
+		StateID:      stateID,
+		ModelID:      model.ID,
+		PayloadHash:  model.PayloadHash,
+		Result:       result,
+		EvidenceHash: evidenceHash,
+		Note:         strings.TrimSpace(note),
+		JobID:        scope.jobID,
+		Round:        scope.round,
+		VerifiedBy:   tx.invokerName(),
+		VerifiedAt:   tx.now,
+		TxID:         tx.txID,
+	}
+	return verification, tx.put(scope.verificationKey(stateID), verification)
+}
+
+func mockStateVerifications(tx *mockTx, scope mockConvergenceScope) ([]*mockStateVerification, error) {
+	return mockList[mockStateVerification](tx, scope.verificationKey())
+}
+
+func mockPendingStateModels(tx *mockTx, scope mockConvergenceScope) (any, error) {
+	verifications, err := mockStateVerifications(tx, scope)
+	if err != nil {
+		return nil, err
+	}
+	verified := map[string]bool{}
+	for _, verification := range verifications {
+		verified[verification.ModelID] = true
+	}
+	models, err := mockIndexedModels(tx, "state")
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*mockModelRecord, 0)
+	for _, model := range models {
+		if model.JobID == scope.jobID && model.RoundNumber == scope.round && !verified[model.ID] {
+			pending = append(pending, model)
+		}
+	}
+	return pending, nil
+}
+
+// mockRequirePassingVerifications refuses a nation declaration while a state that reported
+// toward the nation lacks a passing verification.
+func mockRequirePassingVerifications(tx *mockTx, scope mockConvergenceScope) error {
+	nation, err := mockListNationConvergence(tx, scope)
+	if err != nil {
+		return err
+	}
+	verifications, err := mockStateVerifications(tx, scope)
+	if err != nil {
+		return err
+	}
+	results := map[string]string{}
+	for _, verification := range verifications {
+		results[verification.StateID] = verification.Result
+	}
+	var missing []string
+	for stateID := range nation.(*mockNationConvergence).States {
+		if results[stateID] != "PASS" {
+			missing = append(missing, stateID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("state %s has no passing verification%s", strings.Join(missing, ", "), scope.describe())
+}
+
+// Revocation and audit.
+
+func mockAddRevokedVCHash(tx *mockTx, a []string) (any, error) {
+	vcHash := strings.ToLower(strings.TrimSpace(a[0]))
+	if vcHash == "" {
+		return nil, errors.New("vcHash is required")
+	}
+	if tx.get(mockRevokedPrefix+vcHash) != nil {
+		return nil, fmt.Errorf("vc %s already revoked", vcHash)
+	}
+	entry := &mockRevokedVC{VCHash: vcHash, Reason: strings.TrimSpace(a[1]), RevokedBy: tx.clientID, RevokedAt: tx.now}
+	return entry, tx.put(mockRevokedPrefix+vcHash, entry)
+}
+
+func mockRecordAuditBatch(tx *mockTx, a []string) (any, error) {
+	batchID, digest := strings.TrimSpace(a[0]), strings.TrimSpace(a[3])
+	if batchID == "" {
+		return nil, errors.New("batch identifier is required")
+	}
+	if digest == "" {
+		return nil, errors.New("digest is required")
+	}
+	first, err := strconv.ParseUint(strings.TrimSpace(a[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid firstSeq: %w", err)
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(a[2]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lastSeq: %w", err)
+	}
+	if first == 0 || last < first {
+		return nil, fmt.Errorf("invalid sequence range %d..%d", first, last)
+	}
+	if tx.get(mockAuditBatchPrefix+batchID) != nil {
+		return nil, fmt.Errorf("audit batch %s already recorded", batchID)
+	}
+	batch := &mockAuditBatch{BatchID: batchID, FirstSeq: first, LastSeq: last, Digest: digest, RecordedBy: tx.clientID, RecordedAt: tx.now}
+	return batch, tx.put(mockAuditBatchPrefix+batchID, batch)
+}
@@ -0,0 +1,302 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fabric transports.
+const (
+	FabricTransportCLI = "cli"
+	// FabricTransportMock answers peer commands from an in-process ledger that emulates the
+	// gateway chaincode, so the API can run without a Fabric network. State lives in memory
+	// and is lost on restart.
+	FabricTransportMock = "mock"
+)
+
+// mockLedger stands in for the peer CLI under FABRIC_TRANSPORT=mock. It parses the same
+// commands execPeerCommand would run and prints what the CLI would, so everything above
+// execPeerCommand (retries, breakers, receipts, spans) behaves as against a network. Each
+// identity label acts as its own client ID. Every committed invoke cuts one block.
+type mockLedger struct {
+	mu     sync.Mutex
+	state  map[string][]byte
+	blocks []string
+	// txBlocks maps committed transaction IDs to their block number.
+	txBlocks map[string]uint64
+}
+
+func newMockLedger() *mockLedger {
+	ledger := &mockLedger{state: map[string][]byte{}, txBlocks: map[string]uint64{}}
+	ledger.cutBlock("")
+	return ledger
+}
+
+// run executes one peer command. Like the CLI it returns the output and a non-nil error
+// when the command failed, and the output then carries the error text. Calls to functions
+// the mock does not emulate fail with a 501 StatusError instead.
+func (m *mockLedger) run(identity string, args []string) ([]byte, error) {
+	if len(args) < 2 {
+		return mockFailure("unknown command")
+	}
+	switch args[0] + " " + args[1] {
+	case "channel getinfo":
+		return m.channelInfo()
+	case "chaincode query", "chaincode invoke":
+	default:
+		return mockFailure(fmt.Sprintf("unknown command %q", args[0]+" "+args[1]))
+	}
+	chaincode := mockFlag(args, "-n")
+	call, err := mockCall(mockFlag(args, "-c"))
+	if err != nil {
+		return mockFailure(err.Error())
+	}
+	if chaincode == "qscc" {
+		return m.qsccBlock(call)
+	}
+	invoke := args[1] == "invoke"
+	payload, txID, err := m.execute(identity, call, invoke)
+	if se, ok := err.(*StatusError); ok {
+		return nil, se
+	}
+	if err != nil {
+		operation := "query"
+		if invoke {
+			operation = "invoke"
+		}
+		return mockFailure(fmt.Sprintf("endorsement failure during %s. response: status:500 message:%s", operation, strconv.Quote(err.Error())))
+	}
+	if !invoke {
+		return payload, nil
+	}
+	output := fmt.Sprintf("txid [%s] committed with status (VALID) at mock\nChaincode invoke successful. result: status:200", txID)
+	if len(payload) > 0 {
+		output += " payload:" + strconv.Quote(string(payload))
+	}
+	return []byte(output), nil
+}
+
+// execute runs call against a snapshot of the state. Invokes commit the writes and cut a
+// block; queries discard them, as on a peer.
+func (m *mockLedger) execute(identity string, call []string, invoke bool) ([]byte, string, error) {
+	function, params, err := mockParams(call)
+	if err != nil {
+		return nil, "", err
+	}
+	handler, ok := mockChaincode[function]
+	if !ok {
+		return nil, "", &StatusError{Code: http.StatusNotImplemented, Msg: fmt.Sprintf("the mock ledger does not emulate %s", function)}
+	}
+	if len(params) != handler.params {
+		return nil, "", fmt.Errorf("incorrect number of params for %s: expected %d, received %d", function, handler.params, len(params))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx := &mockTx{ledger: m, clientID: identity, txID: mockTxID(), now: time.Now().UTC().Format(time.RFC3339), writes: map[string][]byte{}}
+	result, err := handler.fn(tx, params)
+	if err != nil {
+		return nil, "", err
+	}
+	var payload []byte
+	if result != nil {
+		if payload, err = json.Marshal(result); err != nil {
+			return nil, "", err
+		}
+	}
+	if !invoke {
+		return payload, "", nil
+	}
+	for key, value := range tx.writes {
+		m.state[key] = value
+	}
+	m.txBlocks[tx.txID] = m.cutBlock(tx.txID)
+	return payload, tx.txID, nil
+}
+
+// cutBlock appends a block and returns its number. Block hashes chain over the transaction IDs.
+func (m *mockLedger) cutBlock(txID string) uint64 {
+	previous := ""
+	if len(m.blocks) > 0 {
+		previous = m.blocks[len(m.blocks)-1]
+	}
+	sum := sha256.Sum256([]byte(previous + txID))
+	m.blocks = append(m.blocks, base64.StdEncoding.EncodeToString(sum[:]))
+	return uint64(len(m.blocks) - 1)
+}
+
+func (m *mockLedger) channelInfo() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info := ChannelInfo{Height: uint64(len(m.blocks)), CurrentBlockHash: m.blocks[len(m.blocks)-1]}
+	if len(m.blocks) > 1 {
+		info.PreviousBlockHash = m.blocks[len(m.blocks)-2]
+	}
+	return []byte("Blockchain info: " + MustJSON(info)), nil
+}
+
+// qsccBlock answers qscc GetBlockByTxID and GetBlockByNumber with a block that carries only
+// its header number, hex encoded as with --hex. The mock emits no chaincode events.
+func (m *mockLedger) qsccBlock(call []string) ([]byte, error) {
+	if len(call) != 3 || (call[0] != "GetBlockByTxID" && call[0] != "GetBlockByNumber") {
+		return mockFailure("the mock ledger only answers qscc GetBlockByTxID and GetBlockByNumber")
+	}
+	m.mu.Lock()
+	var (
+		number uint64
+		ok     bool
+	)
+	if call[0] == "GetBlockByTxID" {
+		number, ok = m.txBlocks[call[2]]
+	} else if parsed, err := strconv.ParseUint(call[2], 10, 64); err == nil && parsed < uint64(len(m.blocks)) {
+		number, ok = parsed, true
+	}
+	m.mu.Unlock()
+	if !ok && call[0] == "GetBlockByTxID" {
+		return mockFailure(fmt.Sprintf("failed to get block for txID %s: entry not found in index", call[2]))
+	}
+	if !ok {
+		return mockFailure(fmt.Sprintf("failed to get block number %s: entry not found in index", call[2]))
+	}
+	header := binary.AppendUvarint([]byte{0x08}, number)
+	block := append([]byte{0x0a, byte(len(header))}, header...)
+	return []byte(hex.EncodeToString(block)), nil
+}
+
+func mockFailure(message string) ([]byte, error) {
+	return []byte("Error: " + message), errors.New(message)
+}
+
+// mockFlag returns the value following flag in args.
+func mockFlag(args []string, flag string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func mockCall(raw string) ([]string, error) {
+	var spec struct {
+		Args []string `json:"Args"`
+	}
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil || len(spec.Args) == 0 {
+		return nil, errors.New("invalid chaincode call: -c must be {\"Args\":[...]}")
+	}
+	return spec.Args, nil
+}
+
+// mockParams splits a call into function and positional parameters, decoding the payload
+// of <Function>JSON overloads back into the positional arguments chaincodeJSONArgs lists.
+func mockParams(call []string) (string, []string, error) {
+	function, params := call[0], call[1:]
+	base := strings.TrimSuffix(function, "JSON")
+	names, ok := chaincodeJSONArgs[base]
+	if base == function || !ok {
+		return function, params, nil
+	}
+	if len(params) != 1 {
+		return "", nil, fmt.Errorf("%s takes one JSON payload", function)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(params[0]), &fields); err != nil || fields == nil {
+		return "", nil, errors.New("invalid payload: must be a JSON object")
+	}
+	positional := make([]string, len(names))
+	for i, name := range names {
+		value, ok := fields[name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			positional[i] = text
+			continue
+		}
+		positional[i] = string(value)
+	}
+	return base, positional, nil
+}
+
+func mockTxID() string {
+	var raw [32]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
+
+// mockTx is one chaincode execution: reads see its own writes, which reach the ledger only
+// when an invoke succeeds. The ledger lock is held while it runs.
+type mockTx struct {
+	ledger   *mockLedger
+	clientID string
+	txID     string
+	now      string
+	writes   map[string][]byte
+}
+
+func (tx *mockTx) get(key string) []byte {
+	if value, ok := tx.writes[key]; ok {
+		return value
+	}
+	return tx.ledger.state[key]
+}
+
+// getJSON decodes key into out and reports whether it exists.
+func (tx *mockTx) getJSON(key string, out any) (bool, error) {
+	raw := tx.get(key)
+	if len(raw) == 0 {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, out)
+}
+
+func (tx *mockTx) put(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tx.writes[key] = raw
+	return nil
+}
+
+// scan returns the keys starting with prefix in key order.
+func (tx *mockTx) scan(prefix string) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, source := range []map[string][]byte{tx.ledger.state, tx.writes} {
+		for key := range source {
+			if strings.HasPrefix(key, prefix) && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mockKey builds a composite-style key whose attributes sort like Fabric composite keys.
+func mockKey(objectType string, attributes ...string) string {
+	return "\x00" + objectType + "\x00" + strings.Join(append(attributes, ""), "\x00")
+}
+
+// mockKeyParts returns the attributes of a mockKey after the first skip.
+func mockKeyParts(key string, skip int) []string {
+	parts := strings.Split(strings.TrimSuffix(key, "\x00"), "\x00")
+	if len(parts) < 2+skip {
+		return nil
+	}
+	return parts[2+skip:]
+}
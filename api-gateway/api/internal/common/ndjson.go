@@ -0,0 +1,66 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON streams.
+const NDJSONContentType = "application/x-ndjson"
+
+// WantsNDJSON reports whether the request's Accept header asks for an NDJSON stream.
+func WantsNDJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamNDJSON writes every record next returns as one JSON document per line. next is
+// called for one page at a time until it reports no more pages, and the response is flushed
+// after each page so clients start on the first records while later pages are still being
+// read from the ledger. An error before anything was written goes to onError as usual; once
+// the stream has started the status is already sent, so the stream ends with an
+// {"error": ...} line instead. The stream stops quietly when the client goes away.
+func StreamNDJSON[T any](w http.ResponseWriter, r *http.Request, next func(ctx context.Context) ([]T, bool, error), onError func(http.ResponseWriter, error)) {
+	ctx := r.Context()
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	started := false
+	for {
+		records, more, err := next(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !started {
+				onError(w, err)
+				return
+			}
+			_ = encoder.Encode(map[string]ErrorEnvelope{"error": NewErrorEnvelope(StatusOf(err), err, w.Header().Get(RequestIDHeader))})
+			return
+		}
+		if !started {
+			w.Header().Set("Content-Type", NDJSONContentType)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !more {
+			return
+		}
+	}
+}
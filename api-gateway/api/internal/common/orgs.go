@@ -0,0 +1,158 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultOrg names the organisation described by MSP_ID, ORG_CRYPTO_PATH, ORG_DOMAIN and
+// ADMIN_IDENTITY.
+const DefaultOrg = "default"
+
+// OrgProfile is an organisation the gateway can transact as: its MSP ID, the cryptogen-style
+// directory holding its users' MSPs and peers' TLS material, and its admin identity.
+type OrgProfile struct {
+	Name          string `json:"name"`
+	MSPID         string `json:"msp_id"`
+	CryptoPath    string `json:"crypto_path"`
+	Domain        string `json:"domain"`
+	AdminIdentity string `json:"admin_identity"`
+}
+
+// peerTLSPath is where cryptogen puts a peer's TLS root certificate.
+func (o OrgProfile) peerTLSPath(peer string) string {
+	return fmt.Sprintf("%s/peers/%s.%s/tls/ca.crt", o.CryptoPath, peer, o.Domain)
+}
+
+// parseOrgProfiles reads ORG_PROFILES name=MSPID;crypto_path[;domain[;admin]] entries. The
+// domain defaults to the crypto path's last element and the admin to Admin@<domain>.
+func parseOrgProfiles(raw map[string]string, primary OrgProfile) (map[string]OrgProfile, error) {
+	orgs := map[string]OrgProfile{DefaultOrg: primary}
+	for name, value := range raw {
+		if name == DefaultOrg {
+			return nil, fmt.Errorf("ORG_PROFILES: %q is reserved for MSP_ID/ORG_CRYPTO_PATH", DefaultOrg)
+		}
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("ORG_PROFILES: org name %q may not contain '/'", name)
+		}
+		fields := strings.Split(value, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("ORG_PROFILES: %s must be MSPID;crypto_path[;domain[;admin]]", name)
+		}
+		org := OrgProfile{Name: name, MSPID: fields[0], CryptoPath: strings.TrimRight(fields[1], "/")}
+		if len(fields) > 2 {
+			org.Domain = fields[2]
+		}
+		if org.Domain == "" {
+			org.Domain = filepath.Base(org.CryptoPath)
+		}
+		if len(fields) > 3 {
+			org.AdminIdentity = fields[3]
+		}
+		if org.AdminIdentity == "" {
+			org.AdminIdentity = "Admin@" + org.Domain
+		}
+		orgs[name] = org
+	}
+	return orgs, nil
+}
+
+// splitPeerName splits an `org/peer` PEER_ENDPOINTS name; names without an org belong to
+// the default org.
+func splitPeerName(name string) (org, peer string) {
+	if org, peer, ok := strings.Cut(name, "/"); ok {
+		return org, peer
+	}
+	return DefaultOrg, name
+}
+
+// newPeerConfig resolves a routed peer's org and TLS material.
+func newPeerConfig(orgs map[string]OrgProfile, name, address string) (PeerConfig, error) {
+	orgName, peer := splitPeerName(name)
+	org, ok := orgs[orgName]
+	if !ok {
+		return PeerConfig{}, fmt.Errorf("peer %s belongs to unknown org %s", name, orgName)
+	}
+	return PeerConfig{Name: name, Address: address, TLSPath: org.peerTLSPath(peer), Org: orgName}, nil
+}
+
+type orgKey struct{}
+
+// WithOrg makes Fabric calls with the returned context transact as the named org.
+func WithOrg(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, orgKey{}, name)
+}
+
+// OrgFor returns the org a call transacts as: one set with WithOrg, else the org
+// STATE_ORGS assigns the caller's state, else the default org.
+func (c *Config) OrgFor(ctx context.Context) string {
+	if name, ok := ctx.Value(orgKey{}).(string); ok {
+		if _, known := c.Orgs[name]; known {
+			return name
+		}
+	}
+	if authCtx, ok := AuthContextFrom(ctx); ok && authCtx.State != "" {
+		if name, ok := c.StateOrgs[authCtx.State]; ok {
+			return name
+		}
+	}
+	return DefaultOrg
+}
+
+// mspPath resolves the MSP folder of identity within org. The empty identity and the
+// default org's admin map to org's own admin, so admin-signed calls follow the org.
+func (c *Config) mspPath(orgName, identity string) (string, error) {
+	org, ok := c.Orgs[orgName]
+	if !ok {
+		org = c.Orgs[DefaultOrg]
+	}
+	if identity == "" || identity == c.AdminIdentity {
+		identity = org.AdminIdentity
+	}
+	key := org.Name + "/" + identity
+	c.mspMu.RLock()
+	if path, ok := c.mspCache[key]; ok {
+		c.mspMu.RUnlock()
+		return path, nil
+	}
+	c.mspMu.RUnlock()
+
+	path := fmt.Sprintf("%s/users/%s/msp", org.CryptoPath, identity)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("fabric identity %s of org %s not found at %s: %w", identity, org.Name, path, err)
+	}
+	c.mspMu.Lock()
+	c.mspCache[key] = path
+	c.mspMu.Unlock()
+	return path, nil
+}
+
+// peerFor narrows peerName to the org ctx transacts as and to op's pool. A peer of both is
+// kept; otherwise another is picked per the selection strategy, skipping open breakers. With
+// consistent hashing the caller's peer always replaces peerName.
+func (f *FabricClient) peerFor(ctx context.Context, op PeerOperation, peerName, identity string) (string, error) {
+	org := f.cfg.OrgFor(ctx)
+	routes := f.routes.Load()
+	key := selectionKey(ctx, identity)
+	sticky := f.cfg.PeerSelection == PeerSelectionConsistentHash && key != ""
+	if peer, ok := routes.peers[peerName]; ok && peer.Org == org && f.inPool(op, peerName) && !sticky {
+		return peerName, nil
+	}
+	var candidates []string
+	for _, name := range routes.names {
+		if routes.peers[name].Org == org && f.inPool(op, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", NewStatusError(http.StatusServiceUnavailable, fmt.Sprintf("no %s peers configured for org %s", op, org))
+	}
+	return f.pickPeer(routes, op, candidates, key), nil
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PayloadGuard caps request body sizes and validates JSON bodies against per-route schemas
+// before any handler runs.
+type PayloadGuard struct {
+	maxBytes int64
+	limits   []routeRule[int64]
+	schemas  []routeRule[*Schema]
+	exempt   []string
+}
+
+// routeRule binds a value to a route. Routes ending in "*" match by prefix, others exactly.
+type routeRule[T any] struct {
+	route string
+	value T
+}
+
+// PayloadError is served as 422 when a body fails its route's schema.
+type PayloadError struct {
+	Route    string   `json:"route"`
+	Problems []string `json:"problems"`
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("request body does not match the %s schema", e.Route)
+}
+
+// NewPayloadGuard loads the configured schemas. Routes under exempt prefixes enforce their
+// own limits (artifact uploads stream far larger bodies).
+func NewPayloadGuard(cfg *Config, exempt ...string) (*PayloadGuard, error) {
+	guard := &PayloadGuard{maxBytes: cfg.MaxBodyBytes, exempt: exempt}
+	for route, limit := range cfg.BodyLimits {
+		guard.limits = append(guard.limits, routeRule[int64]{route: route, value: limit})
+	}
+	for route, path := range cfg.PayloadSchemas {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema for %s: %w", route, err)
+		}
+		schema, err := ParseSchema(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema for %s: %w", route, err)
+		}
+		guard.schemas = append(guard.schemas, routeRule[*Schema]{route: route, value: schema})
+	}
+	sortRules(guard.limits)
+	sortRules(guard.schemas)
+	return guard, nil
+}
+
+// Middleware rejects oversized bodies with 413 and schema violations with 422, then hands the
+// buffered body to next.
+func (g *PayloadGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || g.isExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit := g.maxBytes
+		if rule, ok := matchRoute(g.limits, r.URL.Path); ok {
+			limit = rule.value
+		}
+		if limit > 0 && r.ContentLength > limit {
+			writeTooLarge(w, limit)
+			return
+		}
+		reader := io.Reader(r.Body)
+		if limit > 0 {
+			reader = io.LimitReader(r.Body, limit+1)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			WriteErrorWithCode(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+			return
+		}
+		if limit > 0 && int64(len(body)) > limit {
+			writeTooLarge(w, limit)
+			return
+		}
+		if rule, ok := matchRoute(g.schemas, r.URL.Path); ok && len(bytes.TrimSpace(body)) > 0 {
+			var value any
+			if err := json.Unmarshal(body, &value); err != nil {
+				WriteErrorWithCode(w, http.StatusBadRequest, fmt.Errorf("request body is not valid JSON: %w", err))
+				return
+			}
+			if problems := rule.value.Validate(value); len(problems) > 0 {
+				payloadErr := &PayloadError{Route: rule.route, Problems: problems}
+				WriteErrorWithCode(w, http.StatusUnprocessableEntity, NewCodedError(http.StatusUnprocessableEntity, CodeInvalidPayload, payloadErr.Error(), payloadErr))
+				return
+			}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *PayloadGuard) isExempt(path string) bool {
+	for _, prefix := range g.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeTooLarge(w http.ResponseWriter, limit int64) {
+	WriteErrorWithCode(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds the %d byte limit", limit))
+}
+
+// sortRules orders exact routes first, then prefixes from longest to shortest, so the most
+// specific rule wins.
+func sortRules[T any](rules []routeRule[T]) {
+	sort.Slice(rules, func(i, j int) bool {
+		pi, pj := strings.HasSuffix(rules[i].route, "*"), strings.HasSuffix(rules[j].route, "*")
+		if pi != pj {
+			return !pi
+		}
+		if len(rules[i].route) != len(rules[j].route) {
+			return len(rules[i].route) > len(rules[j].route)
+		}
+		return rules[i].route < rules[j].route
+	})
+}
+
+func matchRoute[T any](rules []routeRule[T], path string) (routeRule[T], bool) {
+	for _, rule := range rules {
+		if prefix, ok := strings.CutSuffix(rule.route, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return rule, true
+			}
+		} else if rule.route == path {
+			return rule, true
+		}
+	}
+	return routeRule[T]{}, false
+}
@@ -0,0 +1,178 @@
+package common
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states reported by /health/peers.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// PeerStatus is a snapshot of one peer's health.
+type PeerStatus struct {
+	Name                string `json:"name"`
+	Address             string `json:"address"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+	LastSuccess         string `json:"last_success,omitempty"`
+	LastFailure         string `json:"last_failure,omitempty"`
+	OpenedAt            string `json:"opened_at,omitempty"`
+	// LatencyMS is the moving average of the peer's successful queries.
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+}
+
+// peerBreaker trips after threshold consecutive connectivity failures and keeps the peer out
+// of rotation for the cooldown. After the cooldown one request is let through (half-open);
+// success closes the breaker, failure re-opens it.
+type peerBreaker struct {
+	mu          sync.Mutex
+	failures    int
+	state       string
+	openedAt    time.Time
+	lastError   string
+	lastSuccess time.Time
+	lastFailure time.Time
+	probing     bool
+}
+
+// peerUnavailableErrors are CLI error fragments that indicate the peer itself is unreachable,
+// as opposed to a chaincode or validation error returned by a healthy peer.
+var peerUnavailableErrors = []string{
+	"connection refused",
+	"transport is closing",
+	"code = Unavailable",
+	"context deadline exceeded",
+	"failed to create new connection",
+	"no such host",
+	"i/o timeout",
+}
+
+func isPeerUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, fragment := range peerUnavailableErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// available reports whether the peer may receive a request now. Moving from open to
+// half-open admits exactly one probe.
+func (b *peerBreaker) available(now time.Time, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+func (b *peerBreaker) record(err error, unavailable bool, threshold int, now time.Time) (tripped bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !unavailable {
+		b.failures = 0
+		b.state = BreakerClosed
+		b.lastSuccess = now
+		return false
+	}
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailure = now
+	if b.state == BreakerHalfOpen || (b.state != BreakerOpen && b.failures >= threshold) {
+		b.state = BreakerOpen
+		b.openedAt = now
+		return true
+	}
+	return false
+}
+
+func (b *peerBreaker) snapshot() PeerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := PeerStatus{State: b.state, ConsecutiveFailures: b.failures, LastError: b.lastError}
+	if !b.lastSuccess.IsZero() {
+		status.LastSuccess = b.lastSuccess.UTC().Format(time.RFC3339)
+	}
+	if !b.lastFailure.IsZero() {
+		status.LastFailure = b.lastFailure.UTC().Format(time.RFC3339)
+	}
+	if b.state != BreakerClosed {
+		status.OpenedAt = b.openedAt.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+// PeerStatuses reports the breaker state of every configured peer in selection order.
+func (f *FabricClient) PeerStatuses() []PeerStatus {
+	routes := f.routes.Load()
+	statuses := make([]PeerStatus, 0, len(routes.names))
+	for _, name := range routes.names {
+		status := routes.breakers[name].snapshot()
+		status.Name = name
+		status.Address = routes.peers[name].Address
+		status.LatencyMS = float64(routes.latency[name].value().Microseconds()) / 1000
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// RunHealthChecks probes every peer with `peer channel getinfo` on each PEER_HEALTH_INTERVAL
+// tick so open breakers recover without waiting for live traffic.
+func (f *FabricClient) RunHealthChecks(ctx context.Context) {
+	interval := f.cfg.PeerHealthInterval
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, name := range f.routes.Load().names {
+				// Any getinfo failure counts: a healthy peer always answers it.
+				probeCtx, cancel := withTimeout(ctx, f.cfg.FabricQueryTimeout)
+				_, err := f.execPeerCommand(probeCtx, name, "", []string{"channel", "getinfo", "-c", f.cfg.Channel})
+				cancel()
+				f.recordPeerResult(name, err, err != nil)
+			}
+		}
+	}
+}
+
+func (f *FabricClient) recordPeerResult(peerName string, err error, unavailable bool) {
+	breaker, ok := f.routes.Load().breakers[peerName]
+	if !ok {
+		return
+	}
+	if breaker.record(err, unavailable, f.cfg.PeerBreakerThreshold, time.Now()) {
+		log.Printf("peer %s removed from rotation: %v", peerName, err)
+	}
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// peerRoutes is the peer set FabricClient routes requests across. It is replaced as a
+// whole, so a request sees either the old set or the new one.
+type peerRoutes struct {
+	peers       map[string]PeerConfig
+	names       []string
+	defaultPeer string
+	breakers    map[string]*peerBreaker
+	latency     map[string]*peerLatency
+}
+
+// newPeerRoutes orders peers default-first and then by name. Peers whose address is
+// unchanged from previous keep their circuit breaker and measured latency.
+func newPeerRoutes(peers map[string]PeerConfig, defaultPeer string, previous *peerRoutes) *peerRoutes {
+	routes := &peerRoutes{peers: peers, defaultPeer: defaultPeer, breakers: map[string]*peerBreaker{}, latency: map[string]*peerLatency{}}
+	if _, ok := peers[defaultPeer]; ok {
+		routes.names = append(routes.names, defaultPeer)
+	}
+	var remaining []string
+	for name, peer := range peers {
+		if previous != nil && previous.peers[name] == peer {
+			routes.breakers[name] = previous.breakers[name]
+			routes.latency[name] = previous.latency[name]
+		} else {
+			routes.breakers[name] = &peerBreaker{state: BreakerClosed}
+			routes.latency[name] = &peerLatency{}
+		}
+		if name != defaultPeer {
+			remaining = append(remaining, name)
+		}
+	}
+	sort.Strings(remaining)
+	routes.names = append(routes.names, remaining...)
+	return routes
+}
+
+// PeerRouting describes the peers requests are routed across: the default peer and each
+// peer's address by name.
+type PeerRouting struct {
+	DefaultPeer string            `json:"default_peer"`
+	Peers       map[string]string `json:"peers"`
+}
+
+// Routing returns the peer routing currently in effect.
+func (f *FabricClient) Routing() PeerRouting {
+	routes := f.routes.Load()
+	routing := PeerRouting{DefaultPeer: routes.defaultPeer, Peers: map[string]string{}}
+	for name, peer := range routes.peers {
+		routing.Peers[name] = peer.Address
+	}
+	return routing
+}
+
+// peerNamePattern matches names usable as the first label of a peer's TLS host name,
+// optionally prefixed with their org as org/peer.
+var peerNamePattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_-]*/)?[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// UpdateRouting validates routing and swaps it in atomically. Every peer needs a host:port
+// address and TLS material under its org's crypto path; an empty default keeps the current one
+// when it is still routed. Requests that already picked a removed peer fail as unconfigured.
+func (f *FabricClient) UpdateRouting(routing PeerRouting) (PeerRouting, error) {
+	if len(routing.Peers) == 0 {
+		return PeerRouting{}, NewStatusError(http.StatusBadRequest, "at least one peer is required")
+	}
+	current := f.routes.Load()
+	peers := make(map[string]PeerConfig, len(routing.Peers))
+	for name, address := range routing.Peers {
+		if !peerNamePattern.MatchString(name) {
+			return PeerRouting{}, NewStatusError(http.StatusBadRequest, fmt.Sprintf("invalid peer name %q", name))
+		}
+		host, port, err := net.SplitHostPort(address)
+		if _, portErr := strconv.ParseUint(port, 10, 16); err != nil || host == "" || portErr != nil {
+			return PeerRouting{}, NewStatusError(http.StatusBadRequest, fmt.Sprintf("peer %s: address must be host:port, got %q", name, address))
+		}
+		peer, err := newPeerConfig(f.cfg.Orgs, name, address)
+		if err != nil {
+			return PeerRouting{}, NewStatusError(http.StatusBadRequest, err.Error())
+		}
+		if _, err := os.Stat(peer.TLSPath); err != nil {
+			return PeerRouting{}, NewStatusError(http.StatusBadRequest, fmt.Sprintf("peer %s: TLS root certificate not found at %s", name, peer.TLSPath))
+		}
+		peers[name] = peer
+	}
+	defaultPeer := routing.DefaultPeer
+	if defaultPeer == "" {
+		defaultPeer = current.defaultPeer
+		if _, ok := peers[defaultPeer]; !ok {
+			defaultPeer = sortedKeys(peers)[0]
+		}
+	} else if _, ok := peers[defaultPeer]; !ok {
+		return PeerRouting{}, NewStatusError(http.StatusBadRequest, fmt.Sprintf("default peer %s is not in peers", defaultPeer))
+	}
+	f.routes.Store(newPeerRoutes(peers, defaultPeer, current))
+	return f.Routing(), nil
+}
+
+// ReloadPeerRouting re-reads the configuration file and returns the peer routing it now
+// describes, with environment variables still taking precedence.
+func (c *Config) ReloadPeerRouting() (PeerRouting, error) {
+	if err := loadConfigFile(ConfigFilePath()); err != nil {
+		return PeerRouting{}, err
+	}
+	peers, err := parsePeerConfig(setting("PEER_ENDPOINTS"), c.Orgs)
+	if err != nil {
+		return PeerRouting{}, err
+	}
+	routing := PeerRouting{DefaultPeer: setting("DEFAULT_PEER"), Peers: map[string]string{}}
+	for name, peer := range peers {
+		routing.Peers[name] = peer.Address
+	}
+	return routing, nil
+}
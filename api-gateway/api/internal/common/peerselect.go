@@ -0,0 +1,145 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Peer selection strategies for PEER_SELECTION_STRATEGY.
+const (
+	// PeerSelectionRoundRobin spreads requests evenly across peers.
+	PeerSelectionRoundRobin = "round_robin"
+	// PeerSelectionLeastLatency prefers the peer with the lowest recent query latency.
+	PeerSelectionLeastLatency = "least_latency"
+	// PeerSelectionConsistentHash pins each caller to one peer, so a trainer reads its own
+	// writes from the peer that endorsed them.
+	PeerSelectionConsistentHash = "consistent_hash"
+)
+
+// PeerOperation is what a peer is selected for. READ_PEERS and WRITE_PEERS limit the peers
+// each operation may use.
+type PeerOperation int
+
+const (
+	// PeerRead evaluates queries, which any peer on the channel can answer.
+	PeerRead PeerOperation = iota
+	// PeerWrite submits invokes, which need endorsers with the chaincode installed.
+	PeerWrite
+)
+
+func (op PeerOperation) String() string {
+	if op == PeerWrite {
+		return "write"
+	}
+	return "read"
+}
+
+// inPool reports whether op may use peerName. An operation without a configured pool may use
+// every routed peer.
+func (f *FabricClient) inPool(op PeerOperation, peerName string) bool {
+	pool := f.cfg.ReadPeers
+	if op == PeerWrite {
+		pool = f.cfg.WritePeers
+	}
+	if len(pool) == 0 {
+		return true
+	}
+	for _, name := range pool {
+		if name == peerName {
+			return true
+		}
+	}
+	return false
+}
+
+// latencyWeight is the weight of the newest sample in a peer's moving average.
+const latencyWeight = 0.3
+
+// peerLatency is the exponentially weighted average duration of a peer's successful queries.
+type peerLatency struct {
+	mu      sync.Mutex
+	average time.Duration
+	samples int
+}
+
+func (l *peerLatency) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.samples == 0 {
+		l.average = d
+	} else {
+		l.average = time.Duration(latencyWeight*float64(d) + (1-latencyWeight)*float64(l.average))
+	}
+	l.samples++
+}
+
+// value is the average, or zero before the first sample so unmeasured peers are tried first.
+func (l *peerLatency) value() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.average
+}
+
+// orderPeers returns candidates in the order the selection strategy prefers them. key
+// identifies the caller for consistent hashing; without one it falls back to round-robin.
+func (f *FabricClient) orderPeers(routes *peerRoutes, op PeerOperation, candidates []string, key string) []string {
+	ordered := make([]string, len(candidates))
+	start := int(f.nextIndex(op) % uint32(len(candidates)))
+	copy(ordered, candidates[start:])
+	copy(ordered[len(candidates)-start:], candidates[:start])
+	switch f.cfg.PeerSelection {
+	case PeerSelectionLeastLatency:
+		// The rotation above breaks ties, so unmeasured peers share the load.
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return routes.latency[ordered[i]].value() < routes.latency[ordered[j]].value()
+		})
+	case PeerSelectionConsistentHash:
+		if key == "" {
+			break
+		}
+		// Rendezvous hashing: adding or removing a peer only moves the callers it wins or
+		// loses, and the runners-up give each caller a stable fallback order.
+		sort.Slice(ordered, func(i, j int) bool {
+			return rendezvousScore(key, ordered[i]) > rendezvousScore(key, ordered[j])
+		})
+	}
+	return ordered
+}
+
+func rendezvousScore(key, peer string) uint64 {
+	sum := sha256.Sum256([]byte(key + "\x00" + peer))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// pickPeer returns the first candidate in strategy order whose breaker is available, or the
+// first candidate when every breaker is open so requests still surface the underlying error.
+func (f *FabricClient) pickPeer(routes *peerRoutes, op PeerOperation, candidates []string, key string) string {
+	ordered := f.orderPeers(routes, op, candidates, key)
+	now := time.Now()
+	for _, name := range ordered {
+		if routes.breakers[name].available(now, f.cfg.PeerBreakerCooldown) {
+			return name
+		}
+	}
+	return ordered[0]
+}
+
+// selectionKey identifies the caller for consistent hashing: the authenticated subject when
+// there is one, so a trainer keeps its peer whichever identity signs, and the Fabric identity
+// otherwise.
+func selectionKey(ctx context.Context, identity string) string {
+	if authCtx, ok := AuthContextFrom(ctx); ok && authCtx.Subject != "" {
+		return authCtx.Subject
+	}
+	return identity
+}
+
+func (f *FabricClient) observeLatency(peerName string, d time.Duration) {
+	if latency, ok := f.routes.Load().latency[peerName]; ok {
+		latency.observe(d)
+	}
+}
@@ -0,0 +1,295 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Policy effects and defaults.
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+
+	// PolicyDefaultBuiltin leaves requests no rule matches to the roles each route and
+	// handler checks; PolicyDefaultDeny refuses them.
+	PolicyDefaultBuiltin = "builtin"
+	PolicyDefaultDeny    = "deny"
+)
+
+// Policy is an authorization policy evaluated on every authenticated request, after the
+// caller is identified and before the route's own role checks. Its rules are tried in order
+// and the first that matches the request decides it: an allow rule admits the request even
+// where the route or handler would refuse the caller's role, a deny rule refuses it. The
+// chaincode still enforces its own role policy on whatever the gateway lets through.
+type Policy struct {
+	Default string       `json:"default,omitempty"`
+	Rules   []PolicyRule `json:"rules"`
+}
+
+// PolicyRule matches requests by method, path, role, and caller attributes. Empty methods
+// or roles match any. Path segments are literal, "*" (any one segment), "{name}" (any one
+// segment, captured as name) or a trailing "**" (any remainder, including none).
+//
+// When maps caller attributes (subject, node_id, state, cluster, nation, issuer) to the
+// values accepted for them: a literal, "*" for any non-empty value, or "{name}" for the
+// path segment captured as name.
+type PolicyRule struct {
+	Name    string                  `json:"name,omitempty"`
+	Methods []string                `json:"methods,omitempty"`
+	Path    string                  `json:"path"`
+	Roles   []Role                  `json:"roles,omitempty"`
+	When    map[string]PolicyValues `json:"when,omitempty"`
+	Effect  string                  `json:"effect"`
+
+	segments []string
+}
+
+// PolicyValues is a list of accepted values, written as a single string or a list.
+type PolicyValues []string
+
+// UnmarshalJSON accepts both the string and the list form.
+func (v *PolicyValues) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*v = PolicyValues{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("values must be a string or a list of strings")
+	}
+	*v = many
+	return nil
+}
+
+// PolicyDecision is the outcome of evaluating a request against a Policy.
+type PolicyDecision struct {
+	// Matched is false when no rule applies and the policy default decides.
+	Matched bool
+	Allowed bool
+	Rule    string
+}
+
+// policyAttributes are the caller attributes rules can test.
+var policyAttributes = map[string]func(*AuthContext) string{
+	"subject": func(c *AuthContext) string { return c.Subject },
+	"node_id": func(c *AuthContext) string { return c.NodeID },
+	"state":   func(c *AuthContext) string { return c.State },
+	"cluster": func(c *AuthContext) string { return c.Cluster },
+	"nation":  func(c *AuthContext) string { return c.Nation },
+	"issuer": func(c *AuthContext) string {
+		if c.Claims == nil {
+			return ""
+		}
+		return c.Claims.Issuer
+	},
+}
+
+// LoadPolicy reads a YAML or JSON policy file and validates its rules.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := DecodeYAML(data, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+func (p *Policy) compile() error {
+	p.Default = strings.ToLower(strings.TrimSpace(p.Default))
+	switch p.Default {
+	case "":
+		p.Default = PolicyDefaultBuiltin
+	case PolicyDefaultBuiltin, PolicyDefaultDeny:
+	default:
+		return fmt.Errorf("default must be %q or %q", PolicyDefaultBuiltin, PolicyDefaultDeny)
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name = strings.TrimSpace(rule.Name); rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("%s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *PolicyRule) compile() error {
+	r.Effect = strings.ToLower(strings.TrimSpace(r.Effect))
+	if r.Effect != PolicyAllow && r.Effect != PolicyDeny {
+		return fmt.Errorf("effect must be %q or %q", PolicyAllow, PolicyDeny)
+	}
+	for i, method := range r.Methods {
+		r.Methods[i] = strings.ToUpper(strings.TrimSpace(method))
+	}
+	for i, role := range r.Roles {
+		parsed, err := ParseRole(string(role))
+		if err != nil {
+			return err
+		}
+		r.Roles[i] = parsed
+	}
+	path := strings.TrimSpace(r.Path)
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("path must start with /")
+	}
+	r.segments = pathSegments(path)
+	captures := map[string]bool{}
+	for i, segment := range r.segments {
+		switch {
+		case segment == "**" && i != len(r.segments)-1:
+			return errors.New("** may only end a path")
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			captures[segment[1:len(segment)-1]] = true
+		}
+	}
+	for attribute, values := range r.When {
+		if _, ok := policyAttributes[attribute]; !ok {
+			return fmt.Errorf("unknown attribute %q", attribute)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("attribute %q lists no values", attribute)
+		}
+		for _, value := range values {
+			if name, ok := policyReference(value); ok && !captures[name] {
+				return fmt.Errorf("attribute %q refers to %s, which the path does not capture", attribute, value)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate decides r for the caller authCtx.
+func (p *Policy) Evaluate(r *http.Request, authCtx *AuthContext) PolicyDecision {
+	segments := pathSegments(r.URL.Path)
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.matches(r.Method, segments, authCtx) {
+			return PolicyDecision{Matched: true, Allowed: rule.Effect == PolicyAllow, Rule: rule.Name}
+		}
+	}
+	return PolicyDecision{Allowed: p.Default == PolicyDefaultBuiltin}
+}
+
+func (r *PolicyRule) matches(method string, path []string, authCtx *AuthContext) bool {
+	if len(r.Methods) > 0 && !containsString(r.Methods, method) {
+		return false
+	}
+	if len(r.Roles) > 0 && !authCtx.Role.Allowed(r.Roles...) {
+		return false
+	}
+	captures, ok := matchSegments(r.segments, path)
+	if !ok {
+		return false
+	}
+	for attribute, values := range r.When {
+		if !attributeMatches(policyAttributes[attribute](authCtx), values, captures) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchSegments(pattern, path []string) (map[string]string, bool) {
+	captures := map[string]string{}
+	for i, segment := range pattern {
+		if segment == "**" {
+			return captures, true
+		}
+		if i >= len(path) {
+			return nil, false
+		}
+		switch {
+		case segment == "*":
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			captures[segment[1:len(segment)-1]] = path[i]
+		case segment != path[i]:
+			return nil, false
+		}
+	}
+	return captures, len(pattern) == len(path)
+}
+
+func attributeMatches(actual string, values PolicyValues, captures map[string]string) bool {
+	if actual == "" {
+		return false
+	}
+	for _, value := range values {
+		if name, ok := policyReference(value); ok {
+			value = captures[name]
+		}
+		if value == "*" || value == actual {
+			return true
+		}
+	}
+	return false
+}
+
+// policyReference reports whether value names a captured path segment.
+func policyReference(value string) (string, bool) {
+	if len(value) > 2 && strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+		return value[1 : len(value)-1], true
+	}
+	return "", false
+}
+
+func pathSegments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EnablePolicy evaluates policy on every authenticated route.
+func (a *Authenticator) EnablePolicy(policy *Policy) {
+	a.policy = policy
+}
+
+// authorize applies the policy, when one is enabled, and otherwise the route's roles. A
+// request a policy rule allowed is marked on authCtx so handlers' own role checks admit it.
+func (a *Authenticator) authorize(r *http.Request, authCtx *AuthContext, allowedRoles []Role) error {
+	if a.policy != nil {
+		decision := a.policy.Evaluate(r, authCtx)
+		switch {
+		case decision.Matched && decision.Allowed:
+			authCtx.AllowedBy = decision.Rule
+			return nil
+		case decision.Matched:
+			return fmt.Errorf("denied by authorization policy rule %q", decision.Rule)
+		case !decision.Allowed:
+			return errors.New("no authorization policy rule permits this request")
+		}
+	}
+	if len(allowedRoles) > 0 && !authCtx.Role.Allowed(allowedRoles...) {
+		return fmt.Errorf("role %s is not permitted", authCtx.Role)
+	}
+	return nil
+}
+
+// Permits reports whether a handler that admits roles should serve the caller: a policy rule
+// that allowed the request overrides the handler's roles.
+func (c *AuthContext) Permits(roles ...Role) bool {
+	return c.AllowedBy != "" || c.Role.Allowed(roles...)
+}
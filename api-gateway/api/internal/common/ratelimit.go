@@ -0,0 +1,174 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route classes used to pick a rate limit. Reads and writes of authenticated callers are keyed
+// by JWT subject; the unauthenticated token endpoints are keyed by client address.
+const (
+	RateClassRead  = "read"
+	RateClassWrite = "write"
+	RateClassAuth  = "auth"
+)
+
+// RateLimit is a token bucket refilled at Rate tokens per second up to Burst.
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// rateLimiterIdle is how long an untouched bucket is kept before it is forgotten.
+const rateLimiterIdle = 10 * time.Minute
+
+// RateLimiter applies a token bucket per (route class, caller).
+type RateLimiter struct {
+	limits map[string]RateLimit
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	requests *CounterVec
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter builds a limiter for the given classes. Classes without a positive rate are
+// not limited.
+func NewRateLimiter(limits map[string]RateLimit, metrics *Metrics) *RateLimiter {
+	limiter := &RateLimiter{limits: map[string]RateLimit{}, buckets: map[string]*tokenBucket{}, lastSweep: time.Now()}
+	for class, limit := range limits {
+		if limit.Rate > 0 {
+			if limit.Burst < 1 {
+				limit.Burst = int(math.Ceil(limit.Rate))
+			}
+			limiter.limits[class] = limit
+		}
+	}
+	if metrics != nil {
+		limiter.requests = metrics.Counter("gateway_rate_limit_requests_total", "Requests checked by the rate limiter by route class and outcome.", "class", "outcome")
+	}
+	return limiter
+}
+
+// Allow takes a token from the caller's bucket. When the bucket is empty it reports how long
+// until the next token is available.
+func (l *RateLimiter) Allow(class, key string) (bool, time.Duration) {
+	limit, ok := l.limits[class]
+	if !ok {
+		return true, 0
+	}
+	return l.allow(limit, class, key)
+}
+
+func (l *RateLimiter) allow(limit RateLimit, class, key string) (bool, time.Duration) {
+	if limit.Burst < 1 {
+		limit.Burst = int(math.Ceil(limit.Rate))
+	}
+	now := time.Now()
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > rateLimiterIdle {
+		for id, bucket := range l.buckets {
+			if now.Sub(bucket.last) > rateLimiterIdle {
+				delete(l.buckets, id)
+			}
+		}
+		l.lastSweep = now
+	}
+	id := class + "\x00" + key
+	bucket, ok := l.buckets[id]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[id] = bucket
+	}
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.last).Seconds()*limit.Rate)
+	bucket.last = now
+	allowed := bucket.tokens >= 1
+	var wait time.Duration
+	if allowed {
+		bucket.tokens--
+	} else {
+		wait = time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	outcome := "allowed"
+	if !allowed {
+		outcome = "limited"
+	}
+	l.requests.Inc(class, outcome)
+	return allowed, wait
+}
+
+// Middleware limits next under class, keyed by the authenticated subject when the request
+// carries one and by client address otherwise.
+func (l *RateLimiter) Middleware(class string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ClientAddress(r)
+		if authCtx, ok := AuthContextFrom(r.Context()); ok && authCtx.Subject != "" {
+			key = "sub:" + authCtx.Subject
+		}
+		if !l.admit(w, class, key) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// admit writes a 429 with Retry-After and returns false when the caller is over its limit.
+func (l *RateLimiter) admit(w http.ResponseWriter, class, key string) bool {
+	allowed, wait := l.Allow(class, key)
+	return l.answer(w, class, allowed, wait)
+}
+
+// admitWith is admit with a caller-specific limit in place of the class limit.
+func (l *RateLimiter) admitWith(w http.ResponseWriter, limit RateLimit, class, key string) bool {
+	if limit.Rate <= 0 {
+		return true
+	}
+	allowed, wait := l.allow(limit, class, key)
+	return l.answer(w, class, allowed, wait)
+}
+
+func (l *RateLimiter) answer(w http.ResponseWriter, class string, allowed bool, wait time.Duration) bool {
+	if allowed {
+		return true
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	WriteErrorWithCode(w, http.StatusTooManyRequests, NewCodedError(http.StatusTooManyRequests, CodeRateLimited,
+		fmt.Sprintf("rate limit exceeded for %s requests; retry in %ds", class, seconds),
+		map[string]any{"class": class, "retry_after_seconds": seconds}))
+	return false
+}
+
+// RateClassOf classifies a request as a read (GET/HEAD) or a write.
+func RateClassOf(r *http.Request) string {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return RateClassRead
+	}
+	return RateClassWrite
+}
+
+// ClientAddress returns the caller's IP without the port.
+func ClientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Dependency check states reported by /readyz.
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// ReadinessCheck reports whether one dependency is usable. It should honour ctx; checks that
+// cannot are abandoned once ctx expires and reported as timed out.
+type ReadinessCheck func(ctx context.Context) error
+
+// DependencyStatus is the outcome of one readiness check.
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Readiness runs the dependency checks behind /readyz. Every check gets its own timeout and
+// they run concurrently, so one hung dependency costs at most the timeout.
+type Readiness struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	names  []string
+	checks map[string]ReadinessCheck
+}
+
+// NewReadiness bounds each check with timeout (READINESS_TIMEOUT).
+func NewReadiness(timeout time.Duration) *Readiness {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Readiness{timeout: timeout, checks: map[string]ReadinessCheck{}}
+}
+
+// Add registers check under name; registering a name again replaces its check.
+func (r *Readiness) Add(name string, check ReadinessCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// Check runs every registered check and reports whether all of them passed, with the status
+// of each in registration order.
+func (r *Readiness) Check(ctx context.Context) (bool, []DependencyStatus) {
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	checks := make([]ReadinessCheck, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mu.Unlock()
+
+	statuses := make([]DependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = r.run(ctx, names[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+	ready := true
+	for _, status := range statuses {
+		if status.Status != CheckOK {
+			ready = false
+		}
+	}
+	return ready, statuses
+}
+
+func (r *Readiness) run(ctx context.Context, name string, check ReadinessCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	status := DependencyStatus{Name: name, Status: CheckOK, LatencyMS: time.Since(started).Milliseconds()}
+	if err != nil {
+		status.Status = CheckFailed
+		status.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			status.Error = "timed out after " + r.timeout.String()
+		}
+	}
+	return status
+}
+
+// Handler serves /readyz: 200 when every dependency is ready, 503 otherwise.
+func (r *Readiness) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ready, statuses := r.Check(req.Context())
+		code, state := http.StatusOK, "ready"
+		if !ready {
+			code, state = http.StatusServiceUnavailable, "not_ready"
+		}
+		WriteJSON(w, code, map[string]any{"status": state, "checks": statuses})
+	}
+}
+
+// CheckChannel reports whether a peer answers `peer channel getinfo` for the default channel.
+func (f *FabricClient) CheckChannel(ctx context.Context) error {
+	_, err := f.ChannelInfo(ctx, "")
+	return err
+}
+
+// CheckPeers reports whether at least one peer's circuit breaker is closed.
+func (f *FabricClient) CheckPeers(context.Context) error {
+	for _, peer := range f.PeerStatuses() {
+		if peer.State == BreakerClosed {
+			return nil
+		}
+	}
+	return errors.New("no healthy peer")
+}
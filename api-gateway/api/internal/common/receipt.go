@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TxReceipt identifies the ledger transaction a chaincode invoke produced. BlockNumber is
+// zero when the block lookup is disabled or failed; block 0 only ever holds the channel
+// configuration.
+type TxReceipt struct {
+	TxID           string `json:"tx_id"`
+	BlockNumber    uint64 `json:"block_number,omitempty"`
+	ValidationCode string `json:"validation_code,omitempty"`
+}
+
+// txCommitPattern matches the line the CLI logs once --waitForEvent sees the commit:
+// `txid [<id>] committed with status (VALID) at <peer>`.
+var txCommitPattern = regexp.MustCompile(`txid \[([0-9a-fA-F]+)\] committed with status \((\w+)\)`)
+
+// parseTxReceipt extracts the transaction ID and validation code from invoke output.
+func parseTxReceipt(output []byte) *TxReceipt {
+	match := txCommitPattern.FindSubmatch(output)
+	if match == nil {
+		return nil
+	}
+	return &TxReceipt{TxID: string(match[1]), ValidationCode: string(match[2])}
+}
+
+// BlockNumberForTx asks the peer's qscc system chaincode which block of the context's
+// channel holds txID.
+func (f *FabricClient) BlockNumberForTx(ctx context.Context, peerName, identity, txID string) (uint64, error) {
+	block, err := f.qsccBlock(ctx, peerName, identity, "GetBlockByTxID", txID)
+	if err != nil {
+		return 0, err
+	}
+	return blockNumber(block)
+}
+
+// qsccBlock runs a qscc block lookup on the context's channel and returns the
+// protobuf-encoded block.
+func (f *FabricClient) qsccBlock(ctx context.Context, peerName, identity, function string, args ...string) ([]byte, error) {
+	ctx, span := f.startSpan(ctx, "chaincode query", peerName, function)
+	defer span.End()
+	ctx, cancel := withTimeout(ctx, f.cfg.FabricQueryTimeout)
+	defer cancel()
+	channel := f.cfg.Target(ctx).Channel
+	payload := map[string]any{"Args": append([]string{function, channel}, args...)}
+	output, err := f.runPeerCommand(ctx, peerName, identity, []string{
+		"chaincode", "query",
+		"-C", channel,
+		"-n", "qscc",
+		"--hex",
+		"-c", MustJSON(payload),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	raw := strings.TrimSpace(string(output))
+	if idx := strings.LastIndex(raw, "\n"); idx != -1 {
+		raw = strings.TrimSpace(raw[idx+1:])
+	}
+	block, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s block %s: %w", function, strings.Join(args, " "), err)
+	}
+	return block, nil
+}
+
+// blockNumber reads Block.header.number (fields 1 and 1) from a protobuf-encoded block
+// without pulling in the Fabric protos.
+func blockNumber(block []byte) (uint64, error) {
+	header, ok := protoField(block, 1, 2)
+	if !ok {
+		return 0, fmt.Errorf("block has no header")
+	}
+	number, ok := protoField(header, 1, 0)
+	if !ok {
+		// proto3 omits zero values.
+		return 0, nil
+	}
+	value, _ := binary.Uvarint(number)
+	return value, nil
+}
+
+// protoField returns the first occurrence of field number with the given wire type: the
+// raw varint bytes for type 0, the contents for type 2.
+func protoField(message []byte, number uint64, wireType uint64) ([]byte, bool) {
+	value, _, ok := protoNext(message, number, wireType)
+	return value, ok
+}
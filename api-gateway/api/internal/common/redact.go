@@ -0,0 +1,86 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// DefaultResponseFieldRoles keeps trainer keys and credential hashes to the roles that audit
+// the federation.
+var DefaultResponseFieldRoles = map[string][]Role{
+	"public_key": {RoleAdmin, RoleCentralChecker},
+	"vc_hash":    {RoleAdmin, RoleCentralChecker},
+}
+
+// RedactFields drops the JSON fields of payload the caller may not see. fields maps JSON
+// field names, at any depth, to the roles allowed to see them; a request without an auth
+// context sees none of them. payload is returned untouched when nothing is hidden from the
+// caller, otherwise as its redacted JSON tree.
+func RedactFields(ctx context.Context, fields map[string][]Role, payload any) any {
+	hidden := hiddenFields(ctx, fields)
+	if len(hidden) == 0 {
+		return payload
+	}
+	return redactValue(hidden, payload)
+}
+
+// RedactItems applies RedactFields to every item, for responses streamed record by record.
+func RedactItems[T any](ctx context.Context, fields map[string][]Role, items []T) []any {
+	hidden := hiddenFields(ctx, fields)
+	redacted := make([]any, 0, len(items))
+	for _, item := range items {
+		if len(hidden) == 0 {
+			redacted = append(redacted, item)
+			continue
+		}
+		redacted = append(redacted, redactValue(hidden, item))
+	}
+	return redacted
+}
+
+func hiddenFields(ctx context.Context, fields map[string][]Role) map[string]bool {
+	var role Role
+	if authCtx, ok := AuthContextFrom(ctx); ok {
+		role = authCtx.Role
+	}
+	hidden := map[string]bool{}
+	for field, roles := range fields {
+		if !role.Allowed(roles...) {
+			hidden[field] = true
+		}
+	}
+	return hidden
+}
+
+func redactValue(hidden map[string]bool, payload any) any {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil
+	}
+	stripFields(hidden, tree)
+	return tree
+}
+
+func stripFields(hidden map[string]bool, node any) {
+	switch value := node.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if hidden[key] {
+				delete(value, key)
+				continue
+			}
+			stripFields(hidden, child)
+		}
+	case []any:
+		for _, child := range value {
+			stripFields(hidden, child)
+		}
+	}
+}
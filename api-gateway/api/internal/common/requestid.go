@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader carries the request ID. A caller may supply one; otherwise the gateway
+// generates it. Either way it is echoed on the response and reported in error bodies.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware assigns every request an ID before any other middleware can fail it.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = GeneratePrefixedID("req")
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFrom returns the ID RequestIDMiddleware assigned to the request behind ctx.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts caller-supplied IDs of printable ASCII without spaces, so they are
+// safe to log and to echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
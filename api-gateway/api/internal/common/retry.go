@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how FabricClient retries invokes that failed for transient reasons.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// retryableErrors maps peer CLI error fragments to the reason reported in metrics. MVCC and
+// phantom read conflicts are resolved by re-simulating against the newer state; the rest are
+// connectivity problems with the peer or orderer.
+var retryableErrors = []struct {
+	fragment string
+	reason   string
+}{
+	{"MVCC_READ_CONFLICT", "mvcc_read_conflict"},
+	{"PHANTOM_READ_CONFLICT", "phantom_read_conflict"},
+	{"ProposalResponsePayloads do not match", "endorsement_mismatch"},
+	{"timed out waiting for txid", "commit_timeout"},
+	{"context deadline exceeded", "deadline_exceeded"},
+	{"connection refused", "unavailable"},
+	{"transport is closing", "unavailable"},
+	{"code = Unavailable", "unavailable"},
+	{"failed to create new connection", "unavailable"},
+}
+
+// retryReason classifies an invoke error; an empty reason means the error is permanent.
+func retryReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	for _, candidate := range retryableErrors {
+		if strings.Contains(msg, candidate.fragment) {
+			return candidate.reason
+		}
+	}
+	return ""
+}
+
+// backoff returns the delay before the given retry (1-based), doubling from InitialBackoff up
+// to MaxBackoff with up to 50% jitter so concurrent conflicting writers spread out.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleepContext waits for d or until the context is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Schema is the subset of JSON Schema the gateway validates request bodies against: type,
+// enum, required, properties, additionalProperties, items, string and array lengths, numeric
+// bounds, and pattern.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseSchema decodes a schema document and compiles its patterns.
+func ParseSchema(raw []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, property := range s.Properties {
+		if property != nil {
+			if err := property.compile(); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON value and returns one message per violation, each prefixed
+// with the JSON path of the offending value.
+func (s *Schema) Validate(value any) []string {
+	var problems []string
+	s.validate(value, "$", &problems)
+	return problems
+}
+
+func (s *Schema) validate(value any, path string, problems *[]string) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	if s.Type != "" && !schemaTypeMatches(s.Type, value) {
+		report("must be of type %s", s.Type)
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, candidate := range s.Enum {
+			if fmt.Sprint(candidate) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("must be one of %v", s.Enum)
+		}
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := typed[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, known := s.Properties[name]
+			switch {
+			case known && property != nil:
+				property.validate(typed[name], path+"."+name, problems)
+			case !known && s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				report("unexpected property %q", name)
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(typed) < *s.MinItems {
+			report("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(typed) > *s.MaxItems {
+			report("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range typed {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(typed)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(typed) {
+			report("must match pattern %s", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && typed < *s.Minimum {
+			report("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && typed > *s.Maximum {
+			report("must be <= %v", *s.Maximum)
+		}
+	}
+}
+
+func schemaTypeMatches(kind string, value any) bool {
+	switch kind {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
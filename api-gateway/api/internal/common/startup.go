@@ -0,0 +1,150 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Startup states reported by /readyz. The sequence moves starting → running ⇄ waiting → ready:
+// each step runs, and a failed step waits out its backoff before running again. It only
+// becomes stopped when its context is cancelled first.
+const (
+	StartupStarting = "starting"
+	StartupRunning  = "running"
+	StartupWaiting  = "waiting"
+	StartupReady    = "ready"
+	StartupStopped  = "stopped"
+)
+
+// StartupStep is one dependency the gateway needs before it serves traffic. Run is retried
+// until it succeeds, so it must be safe to repeat.
+type StartupStep struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// StartupStatus is a snapshot of the startup sequence.
+type StartupStatus struct {
+	State string `json:"state"`
+	// Step is the step running or waiting to be retried; empty once ready.
+	Step        string `json:"step,omitempty"`
+	Attempts    int    `json:"attempts,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+	NextAttempt string `json:"next_attempt,omitempty"`
+	Since       string `json:"since"`
+}
+
+// Startup runs the steps the gateway depends on in the background, so the HTTP server can
+// listen (and answer probes) while the channel is still coming up.
+type Startup struct {
+	policy RetryPolicy
+
+	mu     sync.Mutex
+	status StartupStatus
+}
+
+// NewStartup retries failed steps after STARTUP_BACKOFF, doubling up to STARTUP_BACKOFF_MAX.
+func NewStartup(cfg *Config) *Startup {
+	return &Startup{
+		policy: RetryPolicy{InitialBackoff: cfg.StartupBackoff, MaxBackoff: cfg.StartupBackoffMax},
+		status: StartupStatus{State: StartupStarting, Since: time.Now().UTC().Format(time.RFC3339)},
+	}
+}
+
+// Run performs steps in order, retrying each until it succeeds, and returns nil once all of
+// them have. It only fails when ctx is cancelled.
+func (s *Startup) Run(ctx context.Context, steps ...StartupStep) error {
+	for _, step := range steps {
+		for attempt := 1; ; attempt++ {
+			s.transition(StartupRunning, step.Name, attempt, nil, time.Time{})
+			err := step.Run(ctx)
+			if err == nil {
+				break
+			}
+			delay := s.policy.backoff(attempt)
+			log.Printf("startup: %s failed (attempt %d), retrying in %s: %v", step.Name, attempt, delay.Round(time.Millisecond), err)
+			s.transition(StartupWaiting, step.Name, attempt, err, time.Now().Add(delay))
+			if err := sleepContext(ctx, delay); err != nil {
+				s.transition(StartupStopped, step.Name, attempt, err, time.Time{})
+				return err
+			}
+		}
+	}
+	s.transition(StartupReady, "", 0, nil, time.Time{})
+	log.Printf("startup: ready")
+	return nil
+}
+
+func (s *Startup) transition(state, step string, attempts int, err error, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := StartupStatus{State: state, Step: step, Attempts: attempts, Since: s.status.Since}
+	if state != s.status.State || step != s.status.Step {
+		status.Since = time.Now().UTC().Format(time.RFC3339)
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	} else if state == StartupRunning {
+		// Keep the previous failure visible while the step is retried.
+		status.LastError = s.status.LastError
+	}
+	if !next.IsZero() {
+		status.NextAttempt = next.UTC().Format(time.RFC3339)
+	}
+	s.status = status
+}
+
+// Status returns the current state of the sequence.
+func (s *Startup) Status() StartupStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Ready reports whether every step has succeeded.
+func (s *Startup) Ready() bool {
+	return s.Status().State == StartupReady
+}
+
+// Check is the /readyz check for the sequence: it fails until every step has succeeded.
+func (s *Startup) Check(context.Context) error {
+	status := s.Status()
+	if status.State == StartupReady {
+		return nil
+	}
+	if status.LastError != "" {
+		return fmt.Errorf("%s %s (attempt %d): %s", status.State, status.Step, status.Attempts, status.LastError)
+	}
+	return fmt.Errorf("%s %s", status.State, status.Step)
+}
+
+// Middleware answers 503 with Retry-After until the sequence is ready, except for paths under
+// one of the exempt prefixes (probes, metrics, docs).
+func (s *Startup) Middleware(next http.Handler, exempt ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Ready() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		retry := s.policy.InitialBackoff
+		if next := s.Status().NextAttempt; next != "" {
+			if at, err := time.Parse(time.RFC3339, next); err == nil && time.Until(at) > retry {
+				retry = time.Until(at)
+			}
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Round(time.Second)/time.Second)+1))
+		WriteJSON(w, http.StatusServiceUnavailable, NewErrorEnvelope(http.StatusServiceUnavailable, NewCodedError(http.StatusServiceUnavailable, CodeStarting, "gateway is starting", s.Status()), w.Header().Get(RequestIDHeader)))
+	})
+}
@@ -0,0 +1,250 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ordererRoute is the orderer invokes are submitted to.
+type ordererRoute struct {
+	Endpoint string
+	Host     string
+	TLSCA    string
+}
+
+// discoveredPeer is one entry of `discover peers` output.
+type discoveredPeer struct {
+	MSPID    string `json:"MSPID"`
+	Endpoint string `json:"Endpoint"`
+}
+
+// discoveredConfig is the part of `discover config` output the gateway reads. Certificates
+// are base64-encoded PEM.
+type discoveredConfig struct {
+	MSPs map[string]struct {
+		TLSRootCerts [][]byte `json:"tls_root_certs"`
+	} `json:"msps"`
+	Orderers map[string]struct {
+		Endpoint []struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"endpoint"`
+	} `json:"orderers"`
+}
+
+// RunTopologyDiscovery refreshes the peer and orderer topology from the channel's discovery
+// service now and on every PEER_DISCOVERY_INTERVAL tick. The static PEER_ENDPOINTS and
+// ORDERER_* settings stay the bootstrap: they are queried when the discovered peers are not,
+// and remain in effect while discovery fails.
+func (f *FabricClient) RunTopologyDiscovery(ctx context.Context) {
+	if err := f.RefreshTopology(ctx); err != nil {
+		log.Printf("peer discovery failed, keeping static topology: %v", err)
+	}
+	interval := f.cfg.PeerDiscoveryInterval
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.RefreshTopology(ctx); err != nil {
+				log.Printf("peer discovery failed, keeping current topology: %v", err)
+			}
+		}
+	}
+}
+
+// RefreshTopology asks the first answering peer, current routes before bootstrap peers, for
+// the default channel's peers, orderers and TLS roots and swaps them in. Peers of MSPs
+// without an org profile are skipped since the gateway cannot sign for them. Cached
+// endorsement layouts are dropped so they are rediscovered against the new topology. Each
+// discover call is bounded by FABRIC_QUERY_TIMEOUT and abandoned when ctx is done.
+func (f *FabricClient) RefreshTopology(ctx context.Context) error {
+	routes := f.routes.Load()
+	var sources []PeerConfig
+	for _, name := range routes.names {
+		sources = append(sources, routes.peers[name])
+	}
+	for _, name := range sortedKeys(f.cfg.Peers) {
+		sources = append(sources, f.cfg.Peers[name])
+	}
+	var errs []error
+	for _, source := range sources {
+		err := f.refreshTopologyFrom(ctx, source)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+	}
+	if len(errs) == 0 {
+		return errors.New("no peers to discover from")
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FabricClient) refreshTopologyFrom(ctx context.Context, source PeerConfig) error {
+	channel := f.cfg.Channel
+	configOutput, err := f.discoverWith(ctx, source, "config", "--channel", channel)
+	if err != nil {
+		return err
+	}
+	var config discoveredConfig
+	if err := json.Unmarshal(jsonObject(configOutput, "{"), &config); err != nil {
+		return fmt.Errorf("failed to parse discover config output: %w", err)
+	}
+	peersOutput, err := f.discoverWith(ctx, source, "peers", "--channel", channel)
+	if err != nil {
+		return err
+	}
+	var discovered []discoveredPeer
+	if err := json.Unmarshal(jsonObject(peersOutput, "["), &discovered); err != nil {
+		return fmt.Errorf("failed to parse discover peers output: %w", err)
+	}
+
+	orgsByMSP := map[string]string{}
+	for name, org := range f.cfg.Orgs {
+		orgsByMSP[org.MSPID] = name
+	}
+	peers := map[string]PeerConfig{}
+	skipped := 0
+	for _, peer := range discovered {
+		org, ok := orgsByMSP[peer.MSPID]
+		msp, hasTLS := config.MSPs[peer.MSPID]
+		if !ok || !hasTLS || len(msp.TLSRootCerts) == 0 {
+			skipped++
+			continue
+		}
+		tlsPath, err := f.writeTLSRoots(peer.MSPID, msp.TLSRootCerts)
+		if err != nil {
+			return err
+		}
+		name := discoveredPeerName(org, peer.Endpoint)
+		if name == "" {
+			skipped++
+			continue
+		}
+		for i := 2; ; i++ {
+			if _, taken := peers[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s-%d", discoveredPeerName(org, peer.Endpoint), i)
+		}
+		peers[name] = PeerConfig{Name: name, Address: peer.Endpoint, TLSPath: tlsPath, Org: org}
+	}
+	if len(peers) == 0 {
+		return fmt.Errorf("discovery reported no peers of a configured org (%d skipped)", skipped)
+	}
+
+	orderer, err := f.discoveredOrderer(config)
+	if err != nil {
+		return err
+	}
+	current := f.routes.Load()
+	defaultPeer := current.defaultPeer
+	if _, ok := peers[defaultPeer]; !ok {
+		defaultPeer = f.cfg.DefaultPeer
+		if _, ok := peers[defaultPeer]; !ok {
+			defaultPeer = sortedKeys(peers)[0]
+		}
+	}
+	f.routes.Store(newPeerRoutes(peers, defaultPeer, current))
+	if orderer != nil {
+		f.orderer.Store(orderer)
+	}
+	f.endorsements.mu.Lock()
+	f.endorsements.layouts = map[string]cachedLayout{}
+	f.endorsements.mu.Unlock()
+	if skipped > 0 {
+		log.Printf("peer discovery: %d peer(s) skipped: MSP has no org profile or TLS root", skipped)
+	}
+	return nil
+}
+
+// discoveredOrderer picks the first orderer endpoint, in MSP order, and writes its TLS roots.
+// It returns nil when the channel reports none, keeping the current orderer.
+func (f *FabricClient) discoveredOrderer(config discoveredConfig) (*ordererRoute, error) {
+	for _, mspID := range sortedKeys(config.Orderers) {
+		endpoints := config.Orderers[mspID].Endpoint
+		msp, ok := config.MSPs[mspID]
+		if len(endpoints) == 0 || !ok || len(msp.TLSRootCerts) == 0 {
+			continue
+		}
+		tlsPath, err := f.writeTLSRoots(mspID, msp.TLSRootCerts)
+		if err != nil {
+			return nil, err
+		}
+		endpoint := endpoints[0]
+		return &ordererRoute{
+			Endpoint: net.JoinHostPort(endpoint.Host, strconv.Itoa(endpoint.Port)),
+			Host:     endpoint.Host,
+			TLSCA:    tlsPath,
+		}, nil
+	}
+	return nil, nil
+}
+
+// writeTLSRoots stores an MSP's TLS root certificates as one PEM bundle for the peer CLI.
+func (f *FabricClient) writeTLSRoots(mspID string, certs [][]byte) (string, error) {
+	if f.tlsDir == "" {
+		dir, err := os.MkdirTemp("", "gateway-tls-")
+		if err != nil {
+			return "", err
+		}
+		f.tlsDir = dir
+	}
+	var bundle []byte
+	for _, cert := range certs {
+		bundle = append(bundle, cert...)
+		if len(cert) > 0 && cert[len(cert)-1] != '\n' {
+			bundle = append(bundle, '\n')
+		}
+	}
+	path := filepath.Join(f.tlsDir, mspIDFileName.ReplaceAllString(mspID, "_")+"-tlsca.pem")
+	if err := AtomicWriteFile(path, bundle, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write TLS roots of %s: %w", mspID, err)
+	}
+	return path, nil
+}
+
+var mspIDFileName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// discoveredPeerName names a discovered peer after its host's first label, prefixed with its
+// org unless that is the default org, matching PEER_ENDPOINTS names.
+func discoveredPeerName(org, endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+	}
+	label, _, _ := strings.Cut(host, ".")
+	name := label
+	if org != DefaultOrg {
+		name = org + "/" + label
+	}
+	if !peerNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// jsonObject skips any log lines the CLI prints before its JSON output.
+func jsonObject(output []byte, start string) []byte {
+	raw := string(output)
+	if idx := strings.Index(raw, start); idx != -1 {
+		raw = raw[idx:]
+	}
+	return []byte(raw)
+}
@@ -0,0 +1,365 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind follows the OpenTelemetry span kind numbering used by OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+const (
+	tracerScope        = "github.com/nebula/api-gateway"
+	traceBatchSize     = 256
+	traceQueueSize     = 2048
+	traceFlushInterval = 5 * time.Second
+)
+
+// Tracer records spans and exports them to an OTLP/HTTP collector using the JSON encoding.
+// A tracer without an endpoint is a no-op: Start returns nil spans and every Span method
+// accepts a nil receiver.
+type Tracer struct {
+	cfg    *Config
+	client *http.Client
+	queue  chan *Span
+	once   sync.Once
+}
+
+// Span is a single timed operation within a trace.
+type Span struct {
+	tracer  *Tracer
+	traceID [16]byte
+	spanID  [8]byte
+	parent  [8]byte
+	sampled bool
+	name    string
+	kind    SpanKind
+	start   time.Time
+	end     time.Time
+	attrs   map[string]any
+	errMsg  string
+	mu      sync.Mutex
+}
+
+type spanContextKey struct{}
+
+// NewTracer builds a tracer from the OTEL_* settings in the configuration.
+func NewTracer(cfg *Config) *Tracer {
+	t := &Tracer{cfg: cfg}
+	if cfg.TracingEndpoint != "" {
+		t.client = &http.Client{Timeout: 10 * time.Second}
+		t.queue = make(chan *Span, traceQueueSize)
+	}
+	return t
+}
+
+// Enabled reports whether spans are exported.
+func (t *Tracer) Enabled() bool {
+	return t != nil && t.queue != nil
+}
+
+// Run batches finished spans and posts them to the collector until the context is cancelled.
+func (t *Tracer) Run(ctx context.Context) {
+	if !t.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, traceBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil {
+			log.Printf("trace export failed: %v", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			for len(t.queue) > 0 {
+				batch = append(batch, <-t.queue)
+			}
+			flush()
+			return
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= traceBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Start opens a span as a child of the span in ctx, if any.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if !t.Enabled() {
+		return ctx, nil
+	}
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: map[string]any{}}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parent = parent.spanID
+		span.sampled = parent.sampled
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+		span.sampled = t.sample(span.traceID)
+	}
+	_, _ = rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// startRemote opens a server span continuing a W3C traceparent received from the caller.
+func (t *Tracer) startRemote(ctx context.Context, name, traceparent string) (context.Context, *Span) {
+	ctx, span := t.Start(ctx, name, SpanKindServer)
+	if span == nil {
+		return ctx, nil
+	}
+	if traceID, parentID, sampled, ok := parseTraceparent(traceparent); ok {
+		span.traceID = traceID
+		span.parent = parentID
+		span.sampled = sampled
+	}
+	return ctx, span
+}
+
+// Middleware wraps every request in a server span, continuing any incoming traceparent.
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	if !t.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := t.startRemote(r.Context(), r.Method+" "+r.URL.Path, r.Header.Get("traceparent"))
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("client.address", r.RemoteAddr)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		recorder.Header().Set("traceparent", span.Traceparent())
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		span.SetAttribute("http.response.status_code", recorder.status)
+		if recorder.status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("%d %s", recorder.status, http.StatusText(recorder.status)))
+		}
+		span.End()
+	})
+}
+
+// SpanFromContext returns the active span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// InjectTraceparent propagates the active span to an outgoing HTTP request.
+func InjectTraceparent(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set("traceparent", span.Traceparent())
+	}
+}
+
+// SetAttribute annotates the span. Values should be strings, bools or numbers.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// Traceparent renders the span as a W3C traceparent header value.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(s.traceID[:]), hex.EncodeToString(s.spanID[:]), flags)
+}
+
+// End finishes the span and queues it for export. Spans are dropped when the queue is full.
+func (s *Span) End() {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+	select {
+	case s.tracer.queue <- s:
+	default:
+		s.tracer.once.Do(func() { log.Printf("trace queue full; dropping spans") })
+	}
+}
+
+// sample keeps the trace when its low 8 bytes fall under the configured ratio, so the
+// decision is stable for a trace ID.
+func (t *Tracer) sample(traceID [16]byte) bool {
+	ratio := t.cfg.TracingSampleRatio
+	switch {
+	case ratio >= 1:
+		return true
+	case ratio <= 0:
+		return false
+	}
+	bound := uint64(ratio * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+func (t *Tracer) export(spans []*Span) error {
+	payload := otlpPayload(t.cfg.TracingServiceName, spans)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.cfg.TracingEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.cfg.TracingHeaders {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func otlpPayload(serviceName string, spans []*Span) map[string]any {
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		entry := map[string]any{
+			"traceId":           hex.EncodeToString(span.traceID[:]),
+			"spanId":            hex.EncodeToString(span.spanID[:]),
+			"name":              span.name,
+			"kind":              int(span.kind),
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(span.attrs),
+		}
+		if span.parent != [8]byte{} {
+			entry["parentSpanId"] = hex.EncodeToString(span.parent[:])
+		}
+		if span.errMsg != "" {
+			entry["status"] = map[string]any{"code": 2, "message": span.errMsg}
+		}
+		span.mu.Unlock()
+		encoded = append(encoded, entry)
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": tracerScope},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	result := make([]map[string]any, 0, len(attrs))
+	for key, value := range attrs {
+		var encoded map[string]any
+		switch v := value.(type) {
+		case bool:
+			encoded = map[string]any{"boolValue": v}
+		case int:
+			encoded = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			encoded = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case uint64:
+			encoded = map[string]any{"intValue": strconv.FormatUint(v, 10)}
+		case float64:
+			encoded = map[string]any{"doubleValue": v}
+		default:
+			encoded = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		result = append(result, map[string]any{"key": key, "value": encoded})
+	}
+	return result
+}
+
+func parseTraceparent(value string) (traceID [16]byte, spanID [8]byte, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return traceID, spanID, false, false
+	}
+	if n, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || n != len(traceID) || traceID == [16]byte{} {
+		return traceID, spanID, false, false
+	}
+	if n, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || n != len(spanID) || spanID == [8]byte{} {
+		return traceID, spanID, false, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return traceID, spanID, false, false
+	}
+	return traceID, spanID, flags[0]&0x01 == 1, true
+}
+
+// statusRecorder captures the response status while still exposing Flush for SSE streams.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
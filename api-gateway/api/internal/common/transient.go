@@ -0,0 +1,25 @@
+package common
+
+import "context"
+
+type transientKey struct{}
+
+// WithTransient attaches transient data to the chaincode calls made with the returned context.
+// The chaincode reads it with GetTransient; it reaches the endorsers but is never written to
+// the ledger.
+func WithTransient(ctx context.Context, data map[string][]byte) context.Context {
+	if len(data) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, transientKey{}, data)
+}
+
+// transientArgs returns the peer CLI flag carrying ctx's transient data, if any. The CLI
+// expects a JSON object of base64 values, which is how encoding/json writes []byte.
+func transientArgs(ctx context.Context) []string {
+	data, ok := ctx.Value(transientKey{}).(map[string][]byte)
+	if !ok || len(data) == 0 {
+		return nil
+	}
+	return []string{"--transient", MustJSON(data)}
+}
@@ -0,0 +1,261 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrIdentityNotFound is returned by wallets that hold no identity under a label.
+var ErrIdentityNotFound = errors.New("identity not found in wallet")
+
+// Identity is a Fabric X.509 identity: the material the peer CLI reads from an MSP folder.
+type Identity struct {
+	Label             string   `json:"label"`
+	MSPID             string   `json:"msp_id"`
+	Certificate       []byte   `json:"certificate"`
+	PrivateKey        []byte   `json:"private_key"`
+	CACerts           [][]byte `json:"ca_certs"`
+	IntermediateCerts [][]byte `json:"intermediate_certs,omitempty"`
+	// NodeOUConfig is the MSP config.yaml that classifies the identity's OU.
+	NodeOUConfig []byte `json:"node_ou_config,omitempty"`
+	// KeySealer names the KeySealer PrivateKey is encrypted with; empty means plaintext.
+	KeySealer string `json:"key_sealer,omitempty"`
+}
+
+// Wallet stores Fabric identities by label. Deployments can plug in their own backend by
+// implementing it; NewWallet builds the built-in ones.
+type Wallet interface {
+	// Get returns the identity stored under label or ErrIdentityNotFound.
+	Get(label string) (*Identity, error)
+	// Put inserts or replaces the identity under its label.
+	Put(identity *Identity) error
+	// List returns every stored label in order.
+	List() ([]string, error)
+}
+
+// NewWallet builds the backend named by kind: "file" (default) keeps one JSON document per
+// identity under dir, "memory" keeps identities only for the life of the process.
+func NewWallet(kind, dir string) (Wallet, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "file":
+		return NewFileWallet(dir)
+	case "memory":
+		return NewMemoryWallet(), nil
+	default:
+		return nil, fmt.Errorf("unknown wallet %q", kind)
+	}
+}
+
+// Wallet encryption modes.
+const (
+	WalletEncryptionNone   = "none"
+	WalletEncryptionSecret = "secret"
+	WalletEncryptionKMS    = "kms"
+)
+
+// OpenWallet builds the wallet the configuration describes, sealing private keys at rest
+// when WALLET_ENCRYPTION asks for it.
+func OpenWallet(cfg *Config) (Wallet, error) {
+	wallet, err := NewWallet(cfg.WalletType, cfg.WalletPath)
+	if err != nil {
+		return nil, err
+	}
+	var sealer KeySealer
+	switch cfg.WalletEncryption {
+	case WalletEncryptionSecret:
+		sealer, err = NewSecretSealer(cfg.WalletSecret, cfg.WalletKDF, cfg.WalletKDFIterations)
+	case WalletEncryptionKMS:
+		sealer, err = NewTransitSealer(cfg.WalletKMSURL, cfg.WalletKMSKey, cfg.WalletKMSToken)
+	default:
+		return wallet, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedWallet(wallet, sealer), nil
+}
+
+// walletLabelPattern keeps labels safe to use as file names.
+var walletLabelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._-]*$`)
+
+func validateIdentity(identity *Identity) error {
+	if identity == nil || !walletLabelPattern.MatchString(identity.Label) {
+		return errors.New("wallet identity needs a label of letters, digits, @, ., _ or -")
+	}
+	if len(identity.Certificate) == 0 || len(identity.PrivateKey) == 0 || identity.MSPID == "" {
+		return fmt.Errorf("wallet identity %s needs an MSP ID, certificate and private key", identity.Label)
+	}
+	return nil
+}
+
+// FileWallet persists each identity as <dir>/<label>.id, readable by the gateway user only.
+type FileWallet struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileWallet creates dir if needed.
+func NewFileWallet(dir string) (*FileWallet, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("wallet path is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	return &FileWallet{dir: dir}, nil
+}
+
+// Get reads the identity stored under label.
+func (w *FileWallet) Get(label string) (*Identity, error) {
+	if !walletLabelPattern.MatchString(label) {
+		return nil, ErrIdentityNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(w.dir, label+".id"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var identity Identity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("failed to read wallet identity %s: %w", label, err)
+	}
+	return &identity, nil
+}
+
+// Put writes the identity atomically.
+func (w *FileWallet) Put(identity *Identity) error {
+	if err := validateIdentity(identity); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return AtomicWriteFile(filepath.Join(w.dir, identity.Label+".id"), data, 0o600)
+}
+
+// List returns the stored labels.
+func (w *FileWallet) List() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if label, ok := strings.CutSuffix(entry.Name(), ".id"); ok && !entry.IsDir() {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// MemoryWallet keeps identities in process memory.
+type MemoryWallet struct {
+	mu         sync.RWMutex
+	identities map[string]*Identity
+}
+
+// NewMemoryWallet returns an empty in-memory wallet.
+func NewMemoryWallet() *MemoryWallet {
+	return &MemoryWallet{identities: map[string]*Identity{}}
+}
+
+// Get returns a copy of the identity stored under label.
+func (w *MemoryWallet) Get(label string) (*Identity, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	identity, ok := w.identities[label]
+	if !ok {
+		return nil, ErrIdentityNotFound
+	}
+	copied := *identity
+	return &copied, nil
+}
+
+// Put stores a copy of the identity.
+func (w *MemoryWallet) Put(identity *Identity) error {
+	if err := validateIdentity(identity); err != nil {
+		return err
+	}
+	copied := *identity
+	w.mu.Lock()
+	w.identities[identity.Label] = &copied
+	w.mu.Unlock()
+	return nil
+}
+
+// List returns the stored labels.
+func (w *MemoryWallet) List() ([]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return sortedKeys(w.identities), nil
+}
+
+// materializeMSP writes identity as an MSP folder the peer CLI can read and returns its
+// path. Folders live under dir, named by a digest of the material so a re-enrolled identity
+// gets a fresh folder and an unchanged one is reused.
+func materializeMSP(dir string, identity *Identity) (string, error) {
+	sum := sha256.New()
+	for _, part := range [][]byte{[]byte(identity.MSPID), identity.Certificate, identity.PrivateKey, identity.NodeOUConfig} {
+		sum.Write(part)
+		sum.Write([]byte{0})
+	}
+	for _, cert := range append(append([][]byte{}, identity.CACerts...), identity.IntermediateCerts...) {
+		sum.Write(cert)
+	}
+	mspDir := filepath.Join(dir, identity.Label+"-"+hex.EncodeToString(sum.Sum(nil))[:16])
+	if _, err := os.Stat(filepath.Join(mspDir, "signcerts", "cert.pem")); err == nil {
+		return mspDir, nil
+	}
+	files := map[string][]byte{
+		"signcerts/cert.pem": identity.Certificate,
+		"keystore/key_sk":    identity.PrivateKey,
+	}
+	for i, cert := range identity.CACerts {
+		files[fmt.Sprintf("cacerts/ca-%d.pem", i)] = cert
+	}
+	for i, cert := range identity.IntermediateCerts {
+		files[fmt.Sprintf("intermediatecerts/ica-%d.pem", i)] = cert
+	}
+	if len(identity.NodeOUConfig) > 0 {
+		files["config.yaml"] = identity.NodeOUConfig
+	}
+	staging, err := os.MkdirTemp(dir, ".msp-")
+	if err != nil {
+		return "", err
+	}
+	for name, content := range files {
+		path := filepath.Join(staging, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			os.RemoveAll(staging)
+			return "", err
+		}
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			os.RemoveAll(staging)
+			return "", err
+		}
+	}
+	if err := os.Rename(staging, mspDir); err != nil {
+		os.RemoveAll(staging)
+		// A concurrent call may have materialized the same folder first.
+		if _, statErr := os.Stat(filepath.Join(mspDir, "signcerts", "cert.pem")); statErr == nil {
+			return mspDir, nil
+		}
+		return "", err
+	}
+	return mspDir, nil
+}
@@ -0,0 +1,340 @@
+package common
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeySealer encrypts wallet private keys at rest. Name identifies the sealer in stored
+// identities so a wallet never hands a key sealed by one backend to another.
+type KeySealer interface {
+	Name() string
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// EncryptedWallet seals private keys with a KeySealer before they reach the wrapped wallet.
+// Identities stored in plaintext before encryption was enabled are sealed on first read.
+type EncryptedWallet struct {
+	inner  Wallet
+	sealer KeySealer
+
+	mu     sync.Mutex
+	opened map[string][]byte
+}
+
+// NewEncryptedWallet wraps inner so every private key it stores is sealed.
+func NewEncryptedWallet(inner Wallet, sealer KeySealer) *EncryptedWallet {
+	return &EncryptedWallet{inner: inner, sealer: sealer, opened: map[string][]byte{}}
+}
+
+// Get returns the identity with its private key opened.
+func (w *EncryptedWallet) Get(label string) (*Identity, error) {
+	identity, err := w.inner.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	switch identity.KeySealer {
+	case "":
+		if err := w.Put(identity); err != nil {
+			return nil, fmt.Errorf("failed to seal wallet identity %s: %w", label, err)
+		}
+		return identity, nil
+	case w.sealer.Name():
+	default:
+		return nil, fmt.Errorf("wallet identity %s is sealed with %s, not %s", label, identity.KeySealer, w.sealer.Name())
+	}
+	// Opening may be a KMS round trip, so opened keys are kept by ciphertext digest.
+	digest := sha256.Sum256(identity.PrivateKey)
+	key := hex.EncodeToString(digest[:])
+	w.mu.Lock()
+	plaintext, ok := w.opened[key]
+	w.mu.Unlock()
+	if !ok {
+		if plaintext, err = w.sealer.Open(identity.PrivateKey); err != nil {
+			return nil, fmt.Errorf("failed to open private key of wallet identity %s: %w", label, err)
+		}
+		w.mu.Lock()
+		w.opened[key] = plaintext
+		w.mu.Unlock()
+	}
+	identity.PrivateKey = plaintext
+	identity.KeySealer = ""
+	return identity, nil
+}
+
+// Put seals the identity's private key and stores it.
+func (w *EncryptedWallet) Put(identity *Identity) error {
+	if err := validateIdentity(identity); err != nil {
+		return err
+	}
+	sealed, err := w.sealer.Seal(identity.PrivateKey)
+	if err != nil {
+		return err
+	}
+	stored := *identity
+	stored.PrivateKey = sealed
+	stored.KeySealer = w.sealer.Name()
+	return w.inner.Put(&stored)
+}
+
+// List returns the stored labels.
+func (w *EncryptedWallet) List() ([]string, error) {
+	return w.inner.List()
+}
+
+// Key derivation functions for NewSecretSealer.
+const (
+	KDFPBKDF2 = "pbkdf2"
+	KDFHKDF   = "hkdf"
+)
+
+const (
+	kdfIDPBKDF2 byte = 1
+	kdfIDHKDF   byte = 2
+
+	sealSaltSize = 16
+	// sealHeaderSize covers the KDF id, the iteration count and the salt.
+	sealHeaderSize = 1 + 4 + sealSaltSize
+)
+
+// secretSealer encrypts with AES-256-GCM under a key derived from a secret and a per-key
+// salt. The KDF and its parameters are stored with each ciphertext, so changing them only
+// affects keys sealed afterwards.
+type secretSealer struct {
+	secret     []byte
+	kdf        byte
+	iterations uint32
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewSecretSealer derives sealing keys from secret with kdf: "pbkdf2" (PBKDF2-HMAC-SHA256
+// with iterations rounds) for passphrases, or "hkdf" (HKDF-SHA256) for high-entropy secrets.
+func NewSecretSealer(secret, kdf string, iterations int) (KeySealer, error) {
+	if secret == "" {
+		return nil, errors.New("wallet secret is required")
+	}
+	sealer := &secretSealer{secret: []byte(secret), keys: map[string]cipher.AEAD{}}
+	switch strings.ToLower(strings.TrimSpace(kdf)) {
+	case "", KDFPBKDF2:
+		if iterations < 1 {
+			return nil, errors.New("pbkdf2 needs at least one iteration")
+		}
+		sealer.kdf, sealer.iterations = kdfIDPBKDF2, uint32(iterations)
+	case KDFHKDF:
+		sealer.kdf = kdfIDHKDF
+	default:
+		return nil, fmt.Errorf("unknown key derivation function %q", kdf)
+	}
+	return sealer, nil
+}
+
+func (s *secretSealer) Name() string {
+	return "secret"
+}
+
+func (s *secretSealer) Seal(plaintext []byte) ([]byte, error) {
+	header := make([]byte, sealHeaderSize)
+	header[0] = s.kdf
+	binary.BigEndian.PutUint32(header[1:5], s.iterations)
+	if _, err := io.ReadFull(rand.Reader, header[5:]); err != nil {
+		return nil, err
+	}
+	aead, err := s.aead(header)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := append(append(header, nonce...), aead.Seal(nil, nonce, plaintext, header)...)
+	return sealed, nil
+}
+
+func (s *secretSealer) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < sealHeaderSize {
+		return nil, errors.New("sealed key is truncated")
+	}
+	header := sealed[:sealHeaderSize]
+	aead, err := s.aead(header)
+	if err != nil {
+		return nil, err
+	}
+	rest := sealed[sealHeaderSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("sealed key is truncated")
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("wrong wallet secret or corrupted key")
+	}
+	return plaintext, nil
+}
+
+// aead derives the cipher for a ciphertext header, caching it since PBKDF2 is slow by design.
+func (s *secretSealer) aead(header []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if aead, ok := s.keys[string(header)]; ok {
+		return aead, nil
+	}
+	salt := header[5:]
+	var key []byte
+	switch header[0] {
+	case kdfIDPBKDF2:
+		key = pbkdf2SHA256(s.secret, salt, int(binary.BigEndian.Uint32(header[1:5])), 32)
+	case kdfIDHKDF:
+		key = hkdfSHA256(s.secret, salt, []byte("nebula wallet key"), 32)
+	default:
+		return nil, fmt.Errorf("unknown key derivation function id %d", header[0])
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[string(header)] = aead
+	return aead, nil
+}
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < size; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:size]
+}
+
+// hkdfSHA256 implements HKDF (RFC 5869) with SHA-256.
+func hkdfSHA256(secret, salt, info []byte, size int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	var key, previous []byte
+	for counter := byte(1); len(key) < size; counter++ {
+		expand.Reset()
+		expand.Write(previous)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		previous = expand.Sum(nil)
+		key = append(key, previous...)
+	}
+	return key[:size]
+}
+
+// TransitSealer keeps private keys encrypted under a key that never leaves a KMS or HSM,
+// using the encrypt/decrypt API of a Vault-compatible transit engine.
+type TransitSealer struct {
+	endpoint string
+	keyName  string
+	token    string
+	client   *http.Client
+}
+
+// NewTransitSealer targets the transit engine mounted at endpoint (e.g.
+// https://vault:8200/v1/transit) and its named key.
+func NewTransitSealer(endpoint, keyName, token string) (*TransitSealer, error) {
+	if endpoint == "" || keyName == "" {
+		return nil, errors.New("KMS endpoint and key name are required")
+	}
+	return &TransitSealer{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		keyName:  keyName,
+		token:    token,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name reports the sealer as the KMS key, so switching keys is detected.
+func (t *TransitSealer) Name() string {
+	return "kms:" + t.keyName
+}
+
+// Seal encrypts plaintext in the KMS and returns its ciphertext token.
+func (t *TransitSealer) Seal(plaintext []byte) ([]byte, error) {
+	var result struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := t.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}, &result); err != nil {
+		return nil, err
+	}
+	if result.Data.Ciphertext == "" {
+		return nil, errors.New("KMS returned no ciphertext")
+	}
+	return []byte(result.Data.Ciphertext), nil
+}
+
+// Open decrypts a ciphertext token in the KMS.
+func (t *TransitSealer) Open(sealed []byte) ([]byte, error) {
+	var result struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := t.call("decrypt", map[string]string{"ciphertext": string(sealed)}, &result); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(result.Data.Plaintext)
+}
+
+func (t *TransitSealer) call(operation string, body map[string]string, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/%s", t.endpoint, operation, t.keyName), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.token != "" {
+		req.Header.Set("X-Vault-Token", t.token)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS %s failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("KMS %s failed with status %d", operation, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(result)
+}
@@ -0,0 +1,417 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads the subset of YAML used by gateway config files: block mappings and
+// sequences, flow collections ([a, b] and {k: v}), plain and quoted scalars, and comments.
+// Scalars are returned as strings (null and ~ as nil); the config schema decides their type.
+// Anchors, tags, multi-document streams and block scalars (| and >) are rejected.
+func parseYAML(data []byte) (any, error) {
+	value, err := parseYAMLTree(data)
+	if err != nil {
+		return nil, err
+	}
+	return resolveYAML(value, false), nil
+}
+
+// DecodeYAML decodes a YAML document of the subset parseYAML reads into v, through its JSON
+// form. Unlike in config files, plain scalars that read as booleans (true, false), integers
+// or floats become JSON booleans and numbers, as in the YAML 1.2 core schema; quoted scalars
+// always stay strings, so quote identifiers such as "007". Unknown fields are rejected.
+func DecodeYAML(data []byte, v any) error {
+	value, err := parseYAMLTree(data)
+	if err != nil {
+		return err
+	}
+	document, err := json.Marshal(resolveYAML(value, true))
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// yamlPlain is an unquoted scalar; resolveYAML decides whether it is a string.
+type yamlPlain string
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// resolveYAML turns the plain scalars of a parsed document into strings or, when typed is
+// set, into booleans and numbers where they read as such.
+func resolveYAML(value any, typed bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = resolveYAML(item, typed)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = resolveYAML(item, typed)
+		}
+	case yamlPlain:
+		text := string(v)
+		if !typed {
+			return text
+		}
+		switch {
+		case text == "true" || text == "True" || text == "TRUE":
+			return true
+		case text == "false" || text == "False" || text == "FALSE":
+			return false
+		case yamlInt.MatchString(text):
+			if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return json.Number(strconv.FormatInt(n, 10))
+			}
+			fallthrough
+		case yamlFloat.MatchString(text):
+			if f, err := strconv.ParseFloat(text, 64); err == nil {
+				return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+		}
+		return text
+	}
+	return value
+}
+
+func parseYAMLTree(data []byte) (any, error) {
+	lines, err := yamlLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].number)
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+func yamlLines(source string) ([]yamlLine, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		content := strings.TrimRight(stripYAMLComment(raw), " \t")
+		trimmed := strings.TrimLeft(content, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		if trimmed == "..." || strings.HasPrefix(trimmed, "%") {
+			return nil, fmt.Errorf("line %d: directives and document markers are not supported", i+1)
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: len(content) - len(trimmed), text: trimmed})
+	}
+	return lines, nil
+}
+
+// stripYAMLComment removes a # comment that starts a line or follows whitespace outside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if isYAMLSequenceItem(lines[i].text) {
+		return parseYAMLSequence(lines, i, indent)
+	}
+	return parseYAMLMapping(lines, i, indent)
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func parseYAMLSequence(lines []yamlLine, i, indent int) (any, int, error) {
+	items := []any{}
+	for i < len(lines) && lines[i].indent == indent && isYAMLSequenceItem(lines[i].text) {
+		line := lines[i]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		switch {
+		case rest == "":
+			if i+1 >= len(lines) || lines[i+1].indent <= indent {
+				items = append(items, nil)
+				i++
+				continue
+			}
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, value)
+			i = next
+		case isYAMLSequenceItem(rest) || yamlKeySplit(rest) >= 0:
+			// A collection that starts on the dash line: re-read it as a block indented to
+			// where its first entry begins.
+			nested := append([]yamlLine{{number: line.number, indent: indent + len(line.text) - len(rest), text: rest}}, lines[i+1:]...)
+			value, next, err := parseYAMLBlock(nested, 0, nested[0].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, value)
+			i += next
+		default:
+			value, err := parseYAMLValue(rest, line.number)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, value)
+			i++
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+	return items, i, nil
+}
+
+func parseYAMLMapping(lines []yamlLine, i, indent int) (any, int, error) {
+	mapping := map[string]any{}
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if isYAMLSequenceItem(line.text) {
+			return nil, 0, fmt.Errorf("line %d: sequence item where a mapping key was expected", line.number)
+		}
+		split := yamlKeySplit(line.text)
+		if split < 0 {
+			return nil, 0, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		key, err := yamlScalar(strings.TrimSpace(line.text[:split]), line.number)
+		if err != nil {
+			return nil, 0, err
+		}
+		name := yamlKey(key)
+		if name == "" {
+			return nil, 0, fmt.Errorf("line %d: empty mapping key", line.number)
+		}
+		if _, exists := mapping[name]; exists {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.number, name)
+		}
+		rest := strings.TrimSpace(line.text[split+1:])
+		i++
+		switch {
+		case rest != "":
+			value, err := parseYAMLValue(rest, line.number)
+			if err != nil {
+				return nil, 0, err
+			}
+			mapping[name] = value
+		case i < len(lines) && lines[i].indent > indent:
+			value, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			mapping[name] = value
+			i = next
+		case i < len(lines) && lines[i].indent == indent && isYAMLSequenceItem(lines[i].text):
+			value, next, err := parseYAMLSequence(lines, i, indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			mapping[name] = value
+			i = next
+		default:
+			mapping[name] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+	return mapping, i, nil
+}
+
+// yamlKeySplit returns the index of the colon ending a mapping key, or -1.
+func yamlKeySplit(text string) int {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return -1
+	}
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+func parseYAMLValue(text string, line int) (any, error) {
+	switch {
+	case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		return nil, fmt.Errorf("line %d: block scalars are not supported; quote the value instead", line)
+	case strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "!"):
+		return nil, fmt.Errorf("line %d: anchors, aliases and tags are not supported", line)
+	case strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{"):
+		value, rest, err := parseYAMLFlow(text, line)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after flow collection", line, strings.TrimSpace(rest))
+		}
+		return value, nil
+	}
+	return yamlScalar(text, line)
+}
+
+// parseYAMLFlow parses a flow collection at the start of text and returns what follows it.
+func parseYAMLFlow(text string, line int) (any, string, error) {
+	open := text[0]
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+	rest := strings.TrimSpace(text[1:])
+	var items []any
+	mapping := map[string]any{}
+	for {
+		if rest == "" {
+			return nil, "", fmt.Errorf("line %d: unterminated flow collection", line)
+		}
+		if rest[0] == closing {
+			rest = rest[1:]
+			break
+		}
+		var key string
+		if open == '{' {
+			end := yamlKeySplit(rest)
+			if end < 0 {
+				return nil, "", fmt.Errorf("line %d: expected \"key: value\" in flow mapping", line)
+			}
+			parsed, err := yamlScalar(strings.TrimSpace(rest[:end]), line)
+			if err != nil {
+				return nil, "", err
+			}
+			key = yamlKey(parsed)
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+		var (
+			value any
+			err   error
+		)
+		if rest != "" && (rest[0] == '[' || rest[0] == '{') {
+			value, rest, err = parseYAMLFlow(rest, line)
+		} else {
+			end := flowScalarEnd(rest, closing)
+			value, err = yamlScalar(strings.TrimSpace(rest[:end]), line)
+			rest = rest[end:]
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if open == '{' {
+			mapping[key] = value
+		} else {
+			items = append(items, value)
+		}
+		rest = strings.TrimSpace(rest)
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+		} else if rest == "" || rest[0] != closing {
+			return nil, "", fmt.Errorf("line %d: expected ',' or '%c' in flow collection", line, closing)
+		}
+	}
+	if open == '{' {
+		return mapping, rest, nil
+	}
+	if items == nil {
+		items = []any{}
+	}
+	return items, rest, nil
+}
+
+func flowScalarEnd(text string, closing byte) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',' || c == closing:
+			return i
+		}
+	}
+	return len(text)
+}
+
+func yamlScalar(text string, line int) (any, error) {
+	switch {
+	case text == "" || text == "~" || text == "null" || text == "Null" || text == "NULL":
+		return nil, nil
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid double-quoted string %s", line, text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: unterminated single-quoted string", line)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return yamlPlain(text), nil
+}
+
+// yamlKey returns a mapping key as a string; keys never take other types.
+func yamlKey(key any) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case yamlPlain:
+		return string(k)
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package consistency
+
+import (
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the consistency reports.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the consistency HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/consistency`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/consistency", auth.RequireAuth(http.HandlerFunc(h.handleConsistency), common.RoleAdmin))
+}
+
+// Describe documents the consistency endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("consistency")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodGet, "/admin/consistency", openapi.Operation{
+		Summary:     "Read the latest ledger consistency report",
+		Description: "Runs a check first when none has run since startup.",
+		Roles:       admin,
+		Response:    Report{},
+	})
+	api.Add(http.MethodPost, "/admin/consistency", openapi.Operation{
+		Summary:     "Compare every peer and the registry store now",
+		Description: "Peers are compared with the highest one: their block lag, the digests of CONSISTENCY_NAMESPACES, and the models of every open round. The reference peer's whitelist is compared with the registry store.",
+		Roles:       admin,
+		Response:    Report{},
+	})
+}
+
+func (h *HTTPHandler) handleConsistency(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if report := h.svc.Last(); report != nil {
+			common.WriteJSON(w, http.StatusOK, report)
+			return
+		}
+		fallthrough
+	case http.MethodPost:
+		report, err := h.svc.Check(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, report)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
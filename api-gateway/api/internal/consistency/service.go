@@ -0,0 +1,411 @@
+package consistency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// whitelistPageSize is how many entries a registry comparison reads per ledger page.
+const whitelistPageSize = 200
+
+// Divergence kinds.
+const (
+	KindPeerError   = "peer_error"
+	KindLag         = "lag"
+	KindNamespace   = "namespace"
+	KindRoundModels = "round_models"
+	KindRegistry    = "registry"
+)
+
+// Service compares what each peer holds for the watched key ranges, and the ledger's
+// whitelist against the registry store, so stale peers and lost enrollments are noticed
+// before they serve queries.
+type Service struct {
+	cfg         *common.Config
+	fabric      *common.FabricClient
+	store       registry.Store
+	divergences *common.CounterVec
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewService constructs the consistency checker.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store, metrics *common.Metrics) *Service {
+	return &Service{
+		cfg:         cfg,
+		fabric:      fabric,
+		store:       store,
+		divergences: metrics.Counter("ledger_consistency_divergences_total", "Divergences found by the ledger consistency checker.", "kind"),
+	}
+}
+
+// Report is the outcome of one consistency check.
+type Report struct {
+	CheckedAt string `json:"checked_at"`
+	// Reference is the peer with the highest block height, the one every other peer is
+	// compared with.
+	Reference   string         `json:"reference,omitempty"`
+	Peers       []*PeerSummary `json:"peers"`
+	Divergences []*Divergence  `json:"divergences"`
+	Consistent  bool           `json:"consistent"`
+}
+
+// PeerSummary is what one peer reported.
+type PeerSummary struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height,omitempty"`
+	// Lag is how many blocks the peer is behind the reference.
+	Lag        uint64                     `json:"lag"`
+	Namespaces map[string]*NamespaceState `json:"namespaces,omitempty"`
+	Error      string                     `json:"error,omitempty"`
+}
+
+// NamespaceState is the digest and key count of one watched key range.
+type NamespaceState struct {
+	Digest string `json:"digest"`
+	Keys   int    `json:"keys"`
+}
+
+// Divergence is one disagreement: a peer that differs from the reference, or a registry
+// enrollment that differs from the ledger.
+type Divergence struct {
+	Kind    string `json:"kind"`
+	Peer    string `json:"peer,omitempty"`
+	Subject string `json:"subject"`
+	Detail  string `json:"detail"`
+}
+
+type ledgerStateDigest struct {
+	Namespaces map[string]string `json:"namespaces"`
+	KeyCounts  map[string]int    `json:"key_counts"`
+}
+
+type ledgerRound struct {
+	JobID   string `json:"job_id"`
+	Layer   string `json:"layer"`
+	ScopeID string `json:"scope_id"`
+	Round   int    `json:"round"`
+	Status  string `json:"status"`
+}
+
+type ledgerWhitelistPage struct {
+	Items []*struct {
+		JWTSub  string `json:"jwt_sub"`
+		NodeID  string `json:"node_id"`
+		State   string `json:"state"`
+		Cluster string `json:"cluster"`
+		Status  string `json:"status"`
+	} `json:"items"`
+	Bookmark string `json:"bookmark"`
+	HasMore  bool   `json:"has_more"`
+}
+
+// Run checks on every CONSISTENCY_INTERVAL tick until the context is cancelled, logging each
+// divergence found.
+func (s *Service) Run(ctx context.Context) {
+	if s.cfg.ConsistencyInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.ConsistencyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Check(ctx)
+			if err != nil {
+				log.Printf("ledger consistency check failed: %v", err)
+				continue
+			}
+			for _, divergence := range report.Divergences {
+				log.Printf("ledger consistency: %s %s on %s: %s", divergence.Kind, divergence.Subject, divergence.Peer, divergence.Detail)
+			}
+		}
+	}
+}
+
+// Last returns the most recent report, or nil before the first check.
+func (s *Service) Last() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Check compares every configured peer now and stores the report as the latest one.
+func (s *Service) Check(ctx context.Context) (*Report, error) {
+	statuses := s.fabric.PeerStatuses()
+	if len(statuses) == 0 {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "no fabric peers configured")
+	}
+	report := &Report{CheckedAt: time.Now().UTC().Format(time.RFC3339), Peers: []*PeerSummary{}, Divergences: []*Divergence{}}
+	var reference *PeerSummary
+	for _, status := range statuses {
+		peer := s.inspectPeer(ctx, status.Name)
+		report.Peers = append(report.Peers, peer)
+		if peer.Error != "" {
+			report.add(&Divergence{Kind: KindPeerError, Peer: peer.Name, Subject: "peer", Detail: peer.Error})
+			continue
+		}
+		if reference == nil || peer.Height > reference.Height {
+			reference = peer
+		}
+	}
+	if reference == nil {
+		return s.finish(report), nil
+	}
+	report.Reference = reference.Name
+	for _, peer := range report.Peers {
+		if peer.Error != "" || peer == reference {
+			continue
+		}
+		peer.Lag = reference.Height - peer.Height
+		if peer.Lag > 0 {
+			// Digests of peers at different heights legitimately differ; only a lag beyond
+			// CONSISTENCY_MAX_LAG is worth reporting.
+			if peer.Lag > uint64(s.cfg.ConsistencyMaxLag) {
+				report.add(&Divergence{Kind: KindLag, Peer: peer.Name, Subject: "height", Detail: fmt.Sprintf("%d blocks behind %s (%d < %d)", peer.Lag, reference.Name, peer.Height, reference.Height)})
+			}
+			continue
+		}
+		for _, namespace := range sortedNamespaces(reference.Namespaces) {
+			want, got := reference.Namespaces[namespace], peer.Namespaces[namespace]
+			if got == nil || got.Digest != want.Digest {
+				report.add(&Divergence{Kind: KindNamespace, Peer: peer.Name, Subject: namespace, Detail: describeNamespace(want, got, reference.Name)})
+			}
+		}
+	}
+	if err := s.compareRoundModels(ctx, report, reference); err != nil {
+		return nil, err
+	}
+	if err := s.compareRegistry(ctx, report, reference.Name); err != nil {
+		return nil, err
+	}
+	return s.finish(report), nil
+}
+
+// inspectPeer reads the height and watched namespace digests of one peer.
+func (s *Service) inspectPeer(ctx context.Context, name string) *PeerSummary {
+	peer := &PeerSummary{Name: name}
+	info, err := s.fabric.PeerChannelInfo(ctx, name)
+	if err != nil {
+		peer.Error = err.Error()
+		return peer
+	}
+	peer.Height = info.Height
+	if len(s.cfg.ConsistencyNamespaces) == 0 {
+		return peer
+	}
+	raw, err := s.fabric.QueryPeer(ctx, name, s.cfg.AdminIdentity, []string{"ComputeStateDigest", common.MustJSON(s.cfg.ConsistencyNamespaces)})
+	if err != nil {
+		peer.Error = err.Error()
+		return peer
+	}
+	var digest ledgerStateDigest
+	if err := json.Unmarshal(raw, &digest); err != nil {
+		peer.Error = fmt.Sprintf("invalid state digest: %v", err)
+		return peer
+	}
+	peer.Namespaces = map[string]*NamespaceState{}
+	for namespace, value := range digest.Namespaces {
+		peer.Namespaces[namespace] = &NamespaceState{Digest: value, Keys: digest.KeyCounts[namespace]}
+	}
+	return peer
+}
+
+// compareRoundModels lists the models of every open round on each peer at the reference's
+// height and reports the model IDs a peer lacks or holds in addition.
+func (s *Service) compareRoundModels(ctx context.Context, report *Report, reference *PeerSummary) error {
+	raw, err := s.fabric.QueryPeer(ctx, reference.Name, s.cfg.AdminIdentity, []string{"ListCurrentRounds", ""})
+	if err != nil {
+		return err
+	}
+	var rounds []*ledgerRound
+	if err := json.Unmarshal(raw, &rounds); err != nil {
+		return err
+	}
+	for _, round := range rounds {
+		if !strings.EqualFold(round.Status, "open") {
+			continue
+		}
+		args := []string{"ListRoundModels", round.JobID, round.Layer, round.ScopeID, strconv.Itoa(round.Round)}
+		subject := fmt.Sprintf("%s/%s/%s round %d", round.JobID, round.Layer, round.ScopeID, round.Round)
+		want, err := s.roundModelIDs(ctx, reference.Name, args)
+		if err != nil {
+			return err
+		}
+		for _, peer := range report.Peers {
+			if peer.Error != "" || peer == reference || peer.Lag > 0 {
+				continue
+			}
+			got, err := s.roundModelIDs(ctx, peer.Name, args)
+			if err != nil {
+				report.add(&Divergence{Kind: KindPeerError, Peer: peer.Name, Subject: subject, Detail: err.Error()})
+				continue
+			}
+			missing, extra := difference(want, got), difference(got, want)
+			if len(missing) == 0 && len(extra) == 0 {
+				continue
+			}
+			var details []string
+			if len(missing) > 0 {
+				details = append(details, "missing "+strings.Join(missing, ", "))
+			}
+			if len(extra) > 0 {
+				details = append(details, "not on "+reference.Name+": "+strings.Join(extra, ", "))
+			}
+			report.add(&Divergence{Kind: KindRoundModels, Peer: peer.Name, Subject: subject, Detail: strings.Join(details, "; ")})
+		}
+	}
+	return nil
+}
+
+func (s *Service) roundModelIDs(ctx context.Context, peerName string, args []string) (map[string]bool, error) {
+	raw, err := s.fabric.QueryPeer(ctx, peerName, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, err
+	}
+	var models []*struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &models); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(models))
+	for _, model := range models {
+		if model != nil {
+			ids[model.ID] = true
+		}
+	}
+	return ids, nil
+}
+
+// compareRegistry reads the whole whitelist from the reference peer and reports enrollments
+// the registry store holds that the ledger lacks, ledger entries the store does not know, and
+// entries whose placement or status differ.
+func (s *Service) compareRegistry(ctx context.Context, report *Report, peerName string) error {
+	type entry struct{ nodeID, state, cluster, status string }
+	ledger := map[string]entry{}
+	bookmark := ""
+	for {
+		raw, err := s.fabric.QueryPeer(ctx, peerName, s.cfg.AdminIdentity, []string{"ListWhitelistPage", strconv.Itoa(whitelistPageSize), bookmark, ""})
+		if err != nil {
+			return err
+		}
+		var page ledgerWhitelistPage
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			if item != nil {
+				ledger[item.JWTSub] = entry{nodeID: item.NodeID, state: item.State, cluster: item.Cluster, status: item.Status}
+			}
+		}
+		if !page.HasMore || page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+	known := map[string]bool{}
+	for _, record := range s.store.All() {
+		known[record.JWTSub] = true
+		onLedger, ok := ledger[record.JWTSub]
+		if !ok {
+			report.add(&Divergence{Kind: KindRegistry, Peer: peerName, Subject: record.JWTSub, Detail: "enrolled in the registry store but missing from the ledger whitelist"})
+			continue
+		}
+		var mismatches []string
+		if !strings.EqualFold(record.State, onLedger.state) || !strings.EqualFold(record.Cluster, onLedger.cluster) {
+			mismatches = append(mismatches, fmt.Sprintf("placement %s/%s in the store, %s/%s on the ledger", record.State, record.Cluster, onLedger.state, onLedger.cluster))
+		}
+		if normalizeStatus(record.Status) != normalizeStatus(onLedger.status) {
+			mismatches = append(mismatches, fmt.Sprintf("status %s in the store, %s on the ledger", normalizeStatus(record.Status), normalizeStatus(onLedger.status)))
+		}
+		if len(mismatches) > 0 {
+			report.add(&Divergence{Kind: KindRegistry, Peer: peerName, Subject: record.JWTSub, Detail: strings.Join(mismatches, "; ")})
+		}
+	}
+	subjects := make([]string, 0, len(ledger))
+	for subject := range ledger {
+		if !known[subject] {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		report.add(&Divergence{Kind: KindRegistry, Peer: peerName, Subject: subject, Detail: "on the ledger whitelist but unknown to the registry store"})
+	}
+	return nil
+}
+
+// finish counts the report's divergences and keeps it as the latest one.
+func (s *Service) finish(report *Report) *Report {
+	report.Consistent = len(report.Divergences) == 0
+	for _, divergence := range report.Divergences {
+		s.divergences.Inc(divergence.Kind)
+	}
+	s.mu.Lock()
+	s.last = report
+	s.mu.Unlock()
+	return report
+}
+
+func (r *Report) add(divergence *Divergence) {
+	r.Divergences = append(r.Divergences, divergence)
+}
+
+func describeNamespace(want, got *NamespaceState, reference string) string {
+	if got == nil {
+		return "not reported"
+	}
+	return fmt.Sprintf("%d keys (digest %s), %s has %d keys (digest %s)", got.Keys, shortDigest(got.Digest), reference, want.Keys, shortDigest(want.Digest))
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// normalizeStatus treats an empty status as active, as the whitelist does.
+func normalizeStatus(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		return registry.StatusActive
+	}
+	return status
+}
+
+func sortedNamespaces(namespaces map[string]*NamespaceState) []string {
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// difference lists the keys of a that b lacks, sorted.
+func difference(a, b map[string]bool) []string {
+	var keys []string
+	for key := range a {
+		if !b[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package contributions
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the `/contributions` endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the contributions HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/contributions` (record and list summaries) and
+// `/contributions/{nodeId}` (one trainer's totals and rounds).
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	mux.Handle("/contributions", auth.RequireAuth(http.HandlerFunc(h.handleCollection), readers...))
+	mux.Handle("/contributions/", auth.RequireAuth(http.HandlerFunc(h.handleTrainer), readers...))
+}
+
+// Describe documents the contribution endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("contributions")
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	api.Add(http.MethodPost, "/contributions", openapi.Operation{Summary: "Record a trainer's contribution to a round", Roles: []common.Role{common.RoleAggregator}, Body: RecordRequest{}, Response: Contribution{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/contributions", openapi.Operation{Summary: "List every trainer's running totals", Roles: readers, Response: map[string]any{"items": []*Summary{}}})
+	api.Add(http.MethodGet, "/contributions/{node_id}", openapi.Operation{Summary: "Read one trainer's totals and rounds", Roles: readers, Query: []openapi.Param{{Name: "job_id", Description: "Only list rounds of this job."}}, Response: TrainerContributions{}, Errors: []int{http.StatusNotFound}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can record contributions"))
+			return
+		}
+		var req RecordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Record(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	case http.MethodGet:
+		summaries, err := h.svc.Summaries(r.Context(), authCtx)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": summaries})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleTrainer serves `/contributions/{nodeId}`; `job_id` limits the listed rounds.
+func (h *HTTPHandler) handleTrainer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	nodeID := strings.TrimPrefix(r.URL.Path, "/contributions/")
+	result, err := h.svc.Trainer(r.Context(), authCtx, nodeID, r.URL.Query().Get("job_id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, result)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
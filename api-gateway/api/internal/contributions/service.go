@@ -0,0 +1,178 @@
+package contributions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service records per-round trainer contributions and reads the accumulated totals used for
+// reward accounting.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a contributions service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Contribution mirrors the on-chain Contribution record.
+type Contribution struct {
+	NodeID      string  `json:"node_id"`
+	JobID       string  `json:"job_id"`
+	Round       int     `json:"round"`
+	SampleCount int     `json:"sample_count"`
+	LossDelta   float64 `json:"loss_delta"`
+	ModelHash   string  `json:"model_hash"`
+	RecordedBy  string  `json:"recorded_by"`
+	RecordedAt  string  `json:"recorded_at"`
+	TxID        string  `json:"tx_id"`
+}
+
+// Summary mirrors the on-chain ContributionSummary record.
+type Summary struct {
+	NodeID         string  `json:"node_id"`
+	Rounds         int     `json:"rounds"`
+	TotalSamples   int     `json:"total_samples"`
+	TotalLossDelta float64 `json:"total_loss_delta"`
+	LastJobID      string  `json:"last_job_id"`
+	LastRound      int     `json:"last_round"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// TrainerContributions is a trainer's summary together with its per-round records.
+type TrainerContributions struct {
+	*Summary
+	Contributions []*Contribution `json:"contributions"`
+}
+
+// RecordRequest is the payload for recording a contribution. An empty JobID selects
+// GATEWAY_JOB_ID; an empty NodeID records the caller's own contribution.
+type RecordRequest struct {
+	JobID       string  `json:"job_id,omitempty"`
+	Round       int     `json:"round"`
+	NodeID      string  `json:"node_id,omitempty"`
+	SampleCount int     `json:"sample_count"`
+	LossDelta   float64 `json:"loss_delta"`
+	ModelHash   string  `json:"model_hash"`
+}
+
+// Record stores a trainer's contribution to a round and adds it to the trainer's totals.
+func (s *Service) Record(ctx context.Context, authCtx *common.AuthContext, req *RecordRequest) (*Contribution, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	if req.Round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	if req.SampleCount < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "sample_count must not be negative")
+	}
+	modelHash := strings.TrimSpace(req.ModelHash)
+	if modelHash == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_hash is required")
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	jobID := strings.TrimSpace(req.JobID)
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	args := []string{
+		"RecordContribution",
+		jobID,
+		strconv.Itoa(req.Round),
+		strings.TrimSpace(req.NodeID),
+		strconv.Itoa(req.SampleCount),
+		strconv.FormatFloat(req.LossDelta, 'g', -1, 64),
+		modelHash,
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, rec.FabricClientID, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var record Contribution
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Summaries returns the accumulated contributions of every trainer.
+func (s *Service) Summaries(ctx context.Context, authCtx *common.AuthContext) ([]*Summary, error) {
+	var summaries []*Summary
+	if err := s.query(ctx, authCtx, []string{"ListContributionSummaries"}, &summaries); err != nil {
+		return nil, err
+	}
+	if summaries == nil {
+		summaries = []*Summary{}
+	}
+	return summaries, nil
+}
+
+// Trainer returns a trainer's totals and per-round records, optionally limited to one job.
+func (s *Service) Trainer(ctx context.Context, authCtx *common.AuthContext, nodeID, jobID string) (*TrainerContributions, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "node id is required")
+	}
+	var summary Summary
+	if err := s.query(ctx, authCtx, []string{"ReadContributionSummary", nodeID}, &summary); err != nil {
+		return nil, err
+	}
+	var records []*Contribution
+	if err := s.query(ctx, authCtx, []string{"ListContributions", nodeID, strings.TrimSpace(jobID)}, &records); err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*Contribution{}
+	}
+	return &TrainerContributions{Summary: &summary, Contributions: records}, nil
+}
+
+func (s *Service) query(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "already recorded"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "trainer not authorized"), strings.Contains(msg, "trainer credential revoked"), strings.Contains(msg, "trainer deactivated"),
+		strings.Contains(msg, "trainer suspended"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must be"), strings.Contains(msg, "is required"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package convergence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Dispute statuses, as recorded by the chaincode.
+const (
+	DisputeOpen     = "OPEN"
+	DisputeUpheld   = "UPHELD"
+	DisputeRejected = "REJECTED"
+)
+
+// ChallengeRequest contests a state's convergence declaration.
+type ChallengeRequest struct {
+	StateID string `json:"state_id"`
+	JobID   string `json:"job_id,omitempty"`
+	Round   int    `json:"round,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// ResolveRequest closes a dispute: "uphold" clears the state's convergence, "reject" keeps it.
+type ResolveRequest struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+}
+
+// WindowRequest changes how long after a declaration it can be challenged.
+type WindowRequest struct {
+	WindowSeconds *int `json:"window_seconds"`
+}
+
+// Dispute is a challenge of a state convergence declaration. While it is OPEN the state's
+// summary cannot be reset and the nation cannot be declared converged in its scope.
+type Dispute struct {
+	DisputeID    string `json:"dispute_id"`
+	StateID      string `json:"state_id"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	DeclaredBy   string `json:"declared_by"`
+	DeclaredAt   string `json:"declared_at"`
+	ChallengedBy string `json:"challenged_by"`
+	ChallengedAt string `json:"challenged_at"`
+	Resolution   string `json:"resolution_note,omitempty"`
+	ResolvedBy   string `json:"resolved_by,omitempty"`
+	ResolvedAt   string `json:"resolved_at,omitempty"`
+}
+
+// DisputePolicy is the challenge window in force.
+type DisputePolicy struct {
+	WindowSeconds int    `json:"window_seconds"`
+	UpdatedBy     string `json:"updated_by,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+}
+
+// Challenge records the caller's dispute of a state declaration. Only registered
+// aggregators can challenge, and not a declaration they made themselves.
+func (s *Service) Challenge(ctx context.Context, authCtx *common.AuthContext, req *ChallengeRequest) (*Dispute, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	stateID := strings.TrimSpace(req.StateID)
+	reason := strings.TrimSpace(req.Reason)
+	switch {
+	case stateID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
+	case reason == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "reason is required")
+	}
+	scope, err := s.resolveScope(req.JobID, req.Round)
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	var dispute Dispute
+	if err := s.submitDispute(ctx, rec.FabricClientID, scope.args("ChallengeConvergence", stateID, reason), &dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// Resolve closes an open dispute, signed by the admin identity.
+func (s *Service) Resolve(ctx context.Context, disputeID string, req *ResolveRequest) (*Dispute, error) {
+	disputeID = strings.TrimSpace(disputeID)
+	if disputeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "dispute id is required")
+	}
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	decision := strings.ToLower(strings.TrimSpace(req.Decision))
+	if decision != "uphold" && decision != "reject" {
+		return nil, common.NewStatusError(http.StatusBadRequest, `decision must be "uphold" or "reject"`)
+	}
+	var dispute Dispute
+	if err := s.submitDispute(ctx, s.cfg.AdminIdentity, []string{"ResolveConvergenceDispute", disputeID, decision, strings.TrimSpace(req.Note)}, &dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// Disputes lists the disputes of a scope in state order, optionally filtered by status.
+func (s *Service) Disputes(ctx context.Context, authCtx *common.AuthContext, scope Scope, status string) ([]*Dispute, error) {
+	scope, err := s.resolveScope(scope.JobID, scope.Round)
+	if err != nil {
+		return nil, err
+	}
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", DisputeOpen, DisputeUpheld, DisputeRejected:
+	default:
+		return nil, common.NewStatusError(http.StatusBadRequest, "status must be OPEN, UPHELD or REJECTED")
+	}
+	var disputes []*Dispute
+	if err := s.queryDispute(ctx, authCtx, scope.args("ListConvergenceDisputes"), &disputes); err != nil {
+		return nil, err
+	}
+	filtered := make([]*Dispute, 0, len(disputes))
+	for _, dispute := range disputes {
+		if dispute != nil && (status == "" || dispute.Status == status) {
+			filtered = append(filtered, dispute)
+		}
+	}
+	return filtered, nil
+}
+
+// Dispute reads one dispute.
+func (s *Service) Dispute(ctx context.Context, authCtx *common.AuthContext, disputeID string) (*Dispute, error) {
+	disputeID = strings.TrimSpace(disputeID)
+	if disputeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "dispute id is required")
+	}
+	var dispute Dispute
+	if err := s.queryDispute(ctx, authCtx, []string{"ReadConvergenceDispute", disputeID}, &dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// DisputePolicy returns the challenge window in force.
+func (s *Service) DisputePolicy(ctx context.Context, authCtx *common.AuthContext) (*DisputePolicy, error) {
+	var policy DisputePolicy
+	if err := s.queryDispute(ctx, authCtx, []string{"GetConvergenceDisputePolicy"}, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetChallengeWindow changes the challenge window, signed by the admin identity.
+func (s *Service) SetChallengeWindow(ctx context.Context, req *WindowRequest) (*DisputePolicy, error) {
+	if req == nil || req.WindowSeconds == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "window_seconds is required")
+	}
+	if *req.WindowSeconds < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "window_seconds must be a non-negative integer")
+	}
+	var policy DisputePolicy
+	if err := s.submitDispute(ctx, s.cfg.AdminIdentity, []string{"SetConvergenceChallengeWindow", strconv.Itoa(*req.WindowSeconds)}, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *Service) submitDispute(ctx context.Context, identity string, args []string, target any) error {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, identity, args)
+	if err != nil {
+		return mapDisputeError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) queryDispute(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return err
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, args)
+	if err != nil {
+		return mapDisputeError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func mapDisputeError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "no convergence declared"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "cannot challenge its own declaration"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "is disputed"), strings.Contains(msg, "already resolved"),
+		strings.Contains(msg, "challenge window"), strings.Contains(msg, "challenges are disabled"),
+		strings.Contains(msg, "convergence cannot change"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "is required"), strings.Contains(msg, "must be"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -3,30 +3,90 @@ package convergence
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/events"
+	"github.com/nebula/api-gateway/internal/openapi"
 )
 
 // HTTPHandler wires convergence routes.
 type HTTPHandler struct {
-	svc *Service
+	svc  *Service
+	hub  *events.Hub
+	idem *common.IdempotencyStore
 }
 
-// NewHTTPHandler creates a convergence HTTP handler.
-func NewHTTPHandler(svc *Service) *HTTPHandler {
-	return &HTTPHandler{svc: svc}
+// NewHTTPHandler creates a convergence HTTP handler. The hub backs the SSE streams and idem
+// replays commits retried with the same Idempotency-Key.
+func NewHTTPHandler(svc *Service, hub *events.Hub, idem *common.IdempotencyStore) *HTTPHandler {
+	return &HTTPHandler{svc: svc, hub: hub, idem: idem}
 }
 
+// disputeReaders may list and read convergence disputes; challenging is limited to
+// aggregators and resolving to admins.
+var disputeReaders = []common.Role{common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+
 // RegisterRoutes adds convergence endpoints to the mux.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
-	mux.Handle("/state/convergence", auth.RequireAuth(http.HandlerFunc(h.handleStateConvergence), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
-	mux.Handle("/state/convergence/all", auth.RequireAuth(http.HandlerFunc(h.handleStateAll), common.RoleCentralChecker))
+	mux.Handle("/state/convergence", auth.RequireAuth(h.idem.Middleware(http.HandlerFunc(h.handleStateConvergence)), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
+	mux.Handle("/state/convergence/all", auth.RequireAuth(h.idem.Middleware(http.HandlerFunc(h.handleStateAll)), common.RoleCentralChecker))
 	mux.Handle("/state/convergence/list", auth.RequireAuth(http.HandlerFunc(h.handleStateList), common.RoleAdmin))
+	mux.Handle("/state/convergence/reset", auth.RequireAuth(http.HandlerFunc(h.handleReset("state")), common.RoleAdmin))
+	mux.Handle("/state/convergence/history", auth.RequireAuth(http.HandlerFunc(h.handleHistory("state")), common.RoleAdmin))
+	mux.Handle("/state/convergence/disputes", auth.RequireAuth(http.HandlerFunc(h.handleDisputes), disputeReaders...))
+	mux.Handle("/state/convergence/disputes/", auth.RequireAuth(http.HandlerFunc(h.handleDispute), disputeReaders...))
+	mux.Handle("/state/convergence/dispute-window", auth.RequireAuth(http.HandlerFunc(h.handleDisputeWindow), disputeReaders...))
+	mux.Handle("/state/convergence/stream", auth.RequireAuth(http.HandlerFunc(h.handleStateStream), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
 
-	mux.Handle("/nation/convergence", auth.RequireAuth(http.HandlerFunc(h.handleNationConvergence), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
-	mux.Handle("/nation/convergence/all", auth.RequireAuth(http.HandlerFunc(h.handleNationAll), common.RoleCentralChecker))
+	mux.Handle("/nation/convergence", auth.RequireAuth(h.idem.Middleware(http.HandlerFunc(h.handleNationConvergence)), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
+	mux.Handle("/nation/convergence/all", auth.RequireAuth(h.idem.Middleware(http.HandlerFunc(h.handleNationAll)), common.RoleCentralChecker))
 	mux.Handle("/nation/convergence/list", auth.RequireAuth(http.HandlerFunc(h.handleNationList), common.RoleAdmin))
+	mux.Handle("/nation/convergence/reset", auth.RequireAuth(http.HandlerFunc(h.handleReset("nation")), common.RoleAdmin))
+	mux.Handle("/nation/convergence/history", auth.RequireAuth(http.HandlerFunc(h.handleHistory("nation")), common.RoleAdmin))
+	mux.Handle("/nation/convergence/stream", auth.RequireAuth(http.HandlerFunc(h.handleNationStream), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
+}
+
+// Describe documents the state and nation convergence endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("convergence")
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	admin := []common.Role{common.RoleAdmin}
+	scope := []openapi.Param{
+		{Name: "job_id", Description: "Read the records of this job; requires round."},
+		{Name: "round", Type: "integer", Description: "Round of job_id."},
+	}
+	ok := CommitResponse{}
+	api.Add(http.MethodPost, "/state/convergence", openapi.Operation{Summary: "Submit a cluster's convergence to its state", Description: "Honours Idempotency-Key.", Roles: []common.Role{common.RoleAggregator}, Body: CommitRequest{}, Response: ok, Status: http.StatusCreated})
+	api.Add(http.MethodGet, "/state/convergence", openapi.Operation{Summary: "Read a state's convergence", Roles: readers, Query: append([]openapi.Param{{Name: "stateId", Description: "Defaults to the token's state."}}, scope...), Response: StateStatus{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/state/convergence/all", openapi.Operation{Summary: "Declare that every cluster of a state converged", Roles: []common.Role{common.RoleCentralChecker}, Body: DeclareRequest{}, Response: ok, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/state/convergence/list", openapi.Operation{Summary: "Read the convergence of every state", Roles: admin, Query: scope, Response: map[string]*StateStatus{}})
+	api.Add(http.MethodPost, "/state/convergence/reset", openapi.Operation{Summary: "Archive and clear a state's convergence", Roles: admin, Body: ResetRequest{}, Response: Archive{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/state/convergence/history", openapi.Operation{Summary: "List a state's archived convergence", Roles: admin, Query: []openapi.Param{{Name: "stateId", Required: true}}, Response: map[string]any{"items": []*Archive{}}})
+	api.Add(http.MethodPost, "/state/convergence/disputes", openapi.Operation{
+		Summary:     "Challenge a state's convergence declaration",
+		Description: "Allowed within the challenge window after the declaration, once per state while a dispute is open, and never by the declarer. The declaration is frozen until an admin resolves the dispute: it cannot be reset and the nation cannot be declared converged.",
+		Roles:       []common.Role{common.RoleAggregator},
+		Body:        ChallengeRequest{},
+		Response:    Dispute{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/state/convergence/disputes", openapi.Operation{Summary: "List convergence disputes", Roles: disputeReaders, Query: append([]openapi.Param{{Name: "status", Description: "OPEN, UPHELD or REJECTED."}}, scope...), Response: map[string]any{"items": []*Dispute{}}})
+	api.Add(http.MethodGet, "/state/convergence/disputes/{dispute_id}", openapi.Operation{Summary: "Read a convergence dispute", Roles: disputeReaders, Response: Dispute{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/state/convergence/disputes/{dispute_id}/resolve", openapi.Operation{Summary: "Uphold or reject an open dispute", Description: "Upholding archives and clears the state's convergence so it can declare again; rejecting keeps the declaration.", Roles: admin, Body: ResolveRequest{}, Response: Dispute{}, Errors: []int{http.StatusNotFound, http.StatusConflict}})
+	api.Add(http.MethodGet, "/state/convergence/dispute-window", openapi.Operation{Summary: "Read the challenge window", Roles: disputeReaders, Response: DisputePolicy{}})
+	api.Add(http.MethodPut, "/state/convergence/dispute-window", openapi.Operation{Summary: "Change the challenge window", Description: "0 disables challenges.", Roles: admin, Body: WindowRequest{}, Response: DisputePolicy{}})
+	api.Add(http.MethodGet, "/state/convergence/stream", openapi.Operation{Summary: "Stream a state's convergence as server-sent events", Roles: readers, Query: append([]openapi.Param{{Name: "stateId"}}, scope...), Response: "", Produces: "text/event-stream"})
+
+	api.Add(http.MethodPost, "/nation/convergence", openapi.Operation{Summary: "Submit a state's convergence to the nation", Description: "Honours Idempotency-Key.", Roles: []common.Role{common.RoleAggregator}, Body: CommitRequest{}, Response: ok, Status: http.StatusCreated})
+	api.Add(http.MethodGet, "/nation/convergence", openapi.Operation{Summary: "Read the nation's convergence", Roles: readers, Query: scope, Response: NationStatus{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/nation/convergence/all", openapi.Operation{Summary: "Declare that every state converged", Roles: []common.Role{common.RoleCentralChecker}, Body: DeclareRequest{}, Response: ok, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/nation/convergence/list", openapi.Operation{Summary: "Read the nation's convergence", Roles: admin, Query: scope, Response: NationStatus{}})
+	api.Add(http.MethodPost, "/nation/convergence/reset", openapi.Operation{Summary: "Archive and clear the nation's convergence", Roles: admin, Body: ResetRequest{}, Response: Archive{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/nation/convergence/history", openapi.Operation{Summary: "List the nation's archived convergence", Roles: admin, Response: map[string]any{"items": []*Archive{}}})
+	api.Add(http.MethodGet, "/nation/convergence/stream", openapi.Operation{Summary: "Stream the nation's convergence as server-sent events", Roles: readers, Query: scope, Response: "", Produces: "text/event-stream"})
 }
 
 func (h *HTTPHandler) handleStateConvergence(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +97,7 @@ func (h *HTTPHandler) handleStateConvergence(w http.ResponseWriter, r *http.Requ
 	}
 	switch r.Method {
 	case http.MethodPost:
-		if authCtx.Role != common.RoleAggregator {
+		if !authCtx.Permits(common.RoleAggregator) {
 			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can submit convergence payloads"))
 			return
 		}
@@ -46,14 +106,20 @@ func (h *HTTPHandler) handleStateConvergence(w http.ResponseWriter, r *http.Requ
 			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
 			return
 		}
-		if err := h.svc.CommitStateCluster(r.Context(), authCtx, &req); err != nil {
+		receipt, err := h.svc.CommitStateCluster(r.Context(), authCtx, &req)
+		if err != nil {
 			writeServiceError(w, err)
 			return
 		}
-		common.WriteJSON(w, http.StatusCreated, map[string]any{"status": "ok"})
+		common.WriteJSON(w, http.StatusCreated, &CommitResponse{Status: "ok", TxReceipt: receipt})
 	case http.MethodGet:
+		scope, err := scopeFromQuery(r)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
 		stateID := strings.TrimSpace(r.URL.Query().Get("stateId"))
-		status, err := h.svc.StateStatus(r.Context(), authCtx, stateID)
+		status, err := h.svc.StateStatus(r.Context(), authCtx, stateID, scope)
 		if err != nil {
 			writeServiceError(w, err)
 			return
@@ -79,11 +145,12 @@ func (h *HTTPHandler) handleStateAll(w http.ResponseWriter, r *http.Request) {
 		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.svc.DeclareStateAll(r.Context(), authCtx, &req); err != nil {
+	receipt, err := h.svc.DeclareStateAll(r.Context(), authCtx, &req)
+	if err != nil {
 		writeServiceError(w, err)
 		return
 	}
-	common.WriteJSON(w, http.StatusCreated, map[string]any{"status": "ok"})
+	common.WriteJSON(w, http.StatusCreated, &CommitResponse{Status: "ok", TxReceipt: receipt})
 }
 
 func (h *HTTPHandler) handleStateList(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +163,12 @@ func (h *HTTPHandler) handleStateList(w http.ResponseWriter, r *http.Request) {
 		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
 		return
 	}
-	result, err := h.svc.ListStateStatuses(r.Context(), authCtx)
+	scope, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.svc.ListStateStatuses(r.Context(), authCtx, scope)
 	if err != nil {
 		writeServiceError(w, err)
 		return
@@ -112,7 +184,7 @@ func (h *HTTPHandler) handleNationConvergence(w http.ResponseWriter, r *http.Req
 	}
 	switch r.Method {
 	case http.MethodPost:
-		if authCtx.Role != common.RoleAggregator {
+		if !authCtx.Permits(common.RoleAggregator) {
 			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can submit convergence payloads"))
 			return
 		}
@@ -121,13 +193,19 @@ func (h *HTTPHandler) handleNationConvergence(w http.ResponseWriter, r *http.Req
 			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
 			return
 		}
-		if err := h.svc.CommitNationState(r.Context(), authCtx, &req); err != nil {
+		receipt, err := h.svc.CommitNationState(r.Context(), authCtx, &req)
+		if err != nil {
 			writeServiceError(w, err)
 			return
 		}
-		common.WriteJSON(w, http.StatusCreated, map[string]any{"status": "ok"})
+		common.WriteJSON(w, http.StatusCreated, &CommitResponse{Status: "ok", TxReceipt: receipt})
 	case http.MethodGet:
-		status, err := h.svc.NationStatus(r.Context(), authCtx)
+		scope, err := scopeFromQuery(r)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		status, err := h.svc.NationStatus(r.Context(), authCtx, scope)
 		if err != nil {
 			writeServiceError(w, err)
 			return
@@ -153,11 +231,12 @@ func (h *HTTPHandler) handleNationAll(w http.ResponseWriter, r *http.Request) {
 		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.svc.DeclareNationAll(r.Context(), authCtx, &req); err != nil {
+	receipt, err := h.svc.DeclareNationAll(r.Context(), authCtx, &req)
+	if err != nil {
 		writeServiceError(w, err)
 		return
 	}
-	common.WriteJSON(w, http.StatusCreated, map[string]any{"status": "ok"})
+	common.WriteJSON(w, http.StatusCreated, &CommitResponse{Status: "ok", TxReceipt: receipt})
 }
 
 func (h *HTTPHandler) handleNationList(w http.ResponseWriter, r *http.Request) {
@@ -170,7 +249,12 @@ func (h *HTTPHandler) handleNationList(w http.ResponseWriter, r *http.Request) {
 		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
 		return
 	}
-	result, err := h.svc.ListNationStatus(r.Context(), authCtx)
+	scope, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.svc.ListNationStatus(r.Context(), authCtx, scope)
 	if err != nil {
 		writeServiceError(w, err)
 		return
@@ -178,6 +262,59 @@ func (h *HTTPHandler) handleNationList(w http.ResponseWriter, r *http.Request) {
 	common.WriteJSON(w, http.StatusOK, result)
 }
 
+// handleReset archives and clears convergence so the next round can declare again.
+func (h *HTTPHandler) handleReset(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		var req ResetRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		archive, err := h.svc.Reset(r.Context(), scope, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, archive)
+	}
+}
+
+// handleHistory lists archived convergence; the state scope reads `stateId` from the query.
+func (h *HTTPHandler) handleHistory(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		archives, err := h.svc.History(r.Context(), scope, r.URL.Query().Get("stateId"))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": archives})
+	}
+}
+
+// scopeFromQuery reads the optional `job_id` and `round` query parameters.
+func scopeFromQuery(r *http.Request) (Scope, error) {
+	query := r.URL.Query()
+	scope := Scope{JobID: strings.TrimSpace(query.Get("job_id"))}
+	if raw := strings.TrimSpace(query.Get("round")); raw != "" {
+		round, err := strconv.Atoi(raw)
+		if err != nil || round < 1 {
+			return Scope{}, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+		}
+		scope.Round = round
+	}
+	return scope, nil
+}
+
 func writeServiceError(w http.ResponseWriter, err error) {
 	status := http.StatusInternalServerError
 	if se, ok := common.AsStatusError(err); ok {
@@ -185,3 +322,123 @@ func writeServiceError(w http.ResponseWriter, err error) {
 	}
 	common.WriteErrorWithCode(w, status, err)
 }
+
+// handleDisputes lists disputes and lets aggregators challenge a state declaration.
+func (h *HTTPHandler) handleDisputes(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can challenge convergence declarations"))
+			return
+		}
+		var req ChallengeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		dispute, err := h.svc.Challenge(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, dispute)
+	case http.MethodGet:
+		scope, err := scopeFromQuery(r)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		disputes, err := h.svc.Disputes(r.Context(), authCtx, scope, r.URL.Query().Get("status"))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": disputes})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleDispute serves `GET /state/convergence/disputes/{id}` and
+// `POST /state/convergence/disputes/{id}/resolve`.
+func (h *HTTPHandler) handleDispute(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	disputeID := strings.TrimPrefix(r.URL.Path, "/state/convergence/disputes/")
+	if id, found := strings.CutSuffix(disputeID, "/resolve"); found {
+		if r.Method != http.MethodPost {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		if !authCtx.Permits(common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only admins can resolve convergence disputes"))
+			return
+		}
+		var req ResolveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		dispute, err := h.svc.Resolve(r.Context(), id, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, dispute)
+		return
+	}
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	dispute, err := h.svc.Dispute(r.Context(), authCtx, disputeID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, dispute)
+}
+
+// handleDisputeWindow reads the challenge window; admins change it with PUT.
+func (h *HTTPHandler) handleDisputeWindow(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := h.svc.DisputePolicy(r.Context(), authCtx)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, policy)
+	case http.MethodPut:
+		if !authCtx.Permits(common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only admins can change the challenge window"))
+			return
+		}
+		var req WindowRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		policy, err := h.svc.SetChallengeWindow(r.Context(), &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, policy)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
@@ -0,0 +1,176 @@
+package convergence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// ResetRequest asks to archive and clear the convergence of a state or of the nation.
+type ResetRequest struct {
+	StateID string `json:"state_id,omitempty"`
+	JobID   string `json:"job_id,omitempty"`
+	Round   int    `json:"round,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Archive is a convergence snapshot moved to history by a reset.
+type Archive struct {
+	Scope    string           `json:"scope"`
+	TargetID string           `json:"target_id"`
+	JobID    string           `json:"job_id,omitempty"`
+	Round    int              `json:"round,omitempty"`
+	Summary  *ArchiveSummary  `json:"summary,omitempty"`
+	Records  []*ArchiveRecord `json:"records"`
+	Reason   string           `json:"reason,omitempty"`
+	ResetBy  string           `json:"reset_by"`
+	ResetAt  string           `json:"reset_at"`
+	TxID     string           `json:"tx_id"`
+}
+
+// ArchiveSummary is an archived "all converged" declaration.
+type ArchiveSummary struct {
+	DeclaredBy string         `json:"declared_by"`
+	DeclaredAt string         `json:"declared_at"`
+	Payload    map[string]any `json:"payload,omitempty"`
+}
+
+// ArchiveRecord is an archived cluster or state convergence submission.
+type ArchiveRecord struct {
+	StateID     string         `json:"state_id"`
+	ClusterID   string         `json:"cluster_id,omitempty"`
+	SourceID    string         `json:"source_id"`
+	SubmittedAt string         `json:"submitted_at"`
+	Payload     map[string]any `json:"payload,omitempty"`
+}
+
+type ledgerArchive struct {
+	Scope    string                     `json:"scope"`
+	TargetID string                     `json:"target_id"`
+	JobID    string                     `json:"job_id"`
+	Round    int                        `json:"round"`
+	Summary  *ledgerConvergenceSummary  `json:"summary"`
+	Records  []*ledgerConvergenceRecord `json:"records"`
+	Reason   string                     `json:"reason"`
+	ResetBy  string                     `json:"reset_by"`
+	ResetAt  string                     `json:"reset_at"`
+	TxID     string                     `json:"tx_id"`
+}
+
+// toArchive converts a ledger archive, opening its payloads for callers allowed to read them.
+func (s *Service) toArchive(ctx context.Context, l *ledgerArchive) (*Archive, error) {
+	archive := &Archive{
+		Scope:    l.Scope,
+		TargetID: l.TargetID,
+		JobID:    l.JobID,
+		Round:    l.Round,
+		Records:  make([]*ArchiveRecord, 0, len(l.Records)),
+		Reason:   l.Reason,
+		ResetBy:  l.ResetBy,
+		ResetAt:  l.ResetAt,
+		TxID:     l.TxID,
+	}
+	if l.Summary != nil {
+		payload, err := s.openPayload(ctx, l.Summary.Payload)
+		if err != nil {
+			return nil, err
+		}
+		archive.Summary = &ArchiveSummary{
+			DeclaredBy: l.Summary.DeclaredBy,
+			DeclaredAt: l.Summary.DeclaredAt,
+			Payload:    payload,
+		}
+	}
+	for _, record := range l.Records {
+		if record == nil {
+			continue
+		}
+		payload, err := s.openPayload(ctx, record.Payload)
+		if err != nil {
+			return nil, err
+		}
+		archive.Records = append(archive.Records, &ArchiveRecord{
+			StateID:     record.StateID,
+			ClusterID:   record.ClusterID,
+			SourceID:    record.SourceID,
+			SubmittedAt: record.SubmittedAt,
+			Payload:     payload,
+		})
+	}
+	return archive, nil
+}
+
+// Reset archives the convergence of a state (scope "state") or of the nation (scope
+// "nation") and clears it so the next round can converge and declare again. The
+// transaction is signed by the admin identity.
+func (s *Service) Reset(ctx context.Context, scope string, req *ResetRequest) (*Archive, error) {
+	if req == nil {
+		req = &ResetRequest{}
+	}
+	stateID := strings.TrimSpace(req.StateID)
+	if scope == "state" && stateID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
+	}
+	convScope, err := s.resolveScope(req.JobID, req.Round)
+	if err != nil {
+		return nil, err
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := convScope.args("ResetConvergence", scope, stateID, strings.TrimSpace(req.Reason))
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, mapResetError(err)
+	}
+	var ledger ledgerArchive
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	return s.toArchive(ctx, &ledger)
+}
+
+// History returns the archived convergence of a state or of the nation, oldest first.
+func (s *Service) History(ctx context.Context, scope, stateID string) ([]*Archive, error) {
+	stateID = strings.TrimSpace(stateID)
+	if scope == "state" && stateID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListConvergenceHistory", scope, stateID})
+	if err != nil {
+		return nil, err
+	}
+	var ledger []*ledgerArchive
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	archives := make([]*Archive, 0, len(ledger))
+	for _, entry := range ledger {
+		if entry == nil {
+			continue
+		}
+		archive, err := s.toArchive(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+func mapResetError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no convergence recorded"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "is disputed"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "is required"), strings.Contains(msg, "must be"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -7,36 +7,77 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/envelope"
+	"github.com/nebula/api-gateway/internal/evaluations"
 	"github.com/nebula/api-gateway/internal/registry"
 	"github.com/nebula/api-gateway/internal/whitelist"
 )
 
 // Service coordinates convergence operations.
 type Service struct {
-	cfg       *common.Config
-	fabric    *common.FabricClient
-	store     *registry.Store
-	whitelist *whitelist.Service
+	cfg         *common.Config
+	fabric      *common.FabricClient
+	store       registry.Store
+	whitelist   *whitelist.Service
+	evaluations *evaluations.Service
+	envelopes   *envelope.Cipher
+	events      *common.LifecycleBus
 }
 
 // NewService creates a convergence service.
-func NewService(cfg *common.Config, fabric *common.FabricClient, store *registry.Store, whitelist *whitelist.Service) *Service {
-	return &Service{cfg: cfg, fabric: fabric, store: store, whitelist: whitelist}
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store, whitelist *whitelist.Service, evaluations *evaluations.Service) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store, whitelist: whitelist, evaluations: evaluations}
+}
+
+// EnableEncryption seals convergence payloads with the job's data key before they are
+// committed and opens them on read for the roles envelopes allows.
+func (s *Service) EnableEncryption(envelopes *envelope.Cipher) {
+	s.envelopes = envelopes
+}
+
+// EnableEvents publishes state.converged and nation.converged events for declarations made
+// through this gateway.
+func (s *Service) EnableEvents(events *common.LifecycleBus) {
+	s.events = events
+}
+
+// ConvergenceEvent is the data of state.converged and nation.converged events. Payloads are
+// left out, since they may be encrypted or large.
+type ConvergenceEvent struct {
+	Scope      string `json:"scope"`
+	StateID    string `json:"state_id,omitempty"`
+	Round      int    `json:"round,omitempty"`
+	DeclaredBy string `json:"declared_by"`
+	ModelID    string `json:"model_id,omitempty"`
+}
+
+// Scope selects the job and round convergence records belong to. A zero Round selects the
+// legacy unscoped records; JobID defaults to GATEWAY_JOB_ID.
+type Scope struct {
+	JobID string `json:"job_id,omitempty"`
+	Round int    `json:"round,omitempty"`
 }
 
 // CommitRequest captures convergence payloads submitted by aggregators.
 type CommitRequest struct {
 	StateID   string         `json:"state_id"`
 	ClusterID string         `json:"cluster_id,omitempty"`
+	JobID     string         `json:"job_id,omitempty"`
+	Round     int            `json:"round,omitempty"`
 	Payload   map[string]any `json:"payload"`
 }
 
-// DeclareRequest captures "all converged" submissions.
+// DeclareRequest captures "all converged" submissions. ModelID optionally names the
+// converged model so the declaration can be gated on its evaluation consensus.
 type DeclareRequest struct {
 	StateID string         `json:"state_id,omitempty"`
+	ModelID string         `json:"model_id,omitempty"`
+	JobID   string         `json:"job_id,omitempty"`
+	Round   int            `json:"round,omitempty"`
 	Payload map[string]any `json:"payload"`
 }
 
@@ -47,11 +88,15 @@ type ClusterStatus struct {
 	SubmittedAt string         `json:"submitted_at,omitempty"`
 	SourceID    string         `json:"source_id,omitempty"`
 	Payload     map[string]any `json:"payload,omitempty"`
+	// Epoch is the membership epoch the convergence was recorded in.
+	Epoch int `json:"epoch,omitempty"`
 }
 
 // StateStatus summarizes convergence for a state.
 type StateStatus struct {
 	StateID        string           `json:"state_id"`
+	JobID          string           `json:"job_id,omitempty"`
+	Round          int              `json:"round,omitempty"`
 	IsConverged    bool             `json:"is_converged"`
 	ConvergedAt    string           `json:"converged_at,omitempty"`
 	DeclaredBy     string           `json:"declared_by,omitempty"`
@@ -61,6 +106,8 @@ type StateStatus struct {
 
 // NationStatus summarizes convergence for the nation.
 type NationStatus struct {
+	JobID          string            `json:"job_id,omitempty"`
+	Round          int               `json:"round,omitempty"`
 	IsConverged    bool              `json:"is_converged"`
 	ConvergedAt    string            `json:"converged_at,omitempty"`
 	DeclaredBy     string            `json:"declared_by,omitempty"`
@@ -75,115 +122,173 @@ type StateAggregate struct {
 	SubmittedAt string         `json:"submitted_at,omitempty"`
 	SourceID    string         `json:"source_id,omitempty"`
 	Payload     map[string]any `json:"payload,omitempty"`
+	Epoch       int            `json:"epoch,omitempty"`
+}
+
+// CommitResponse acknowledges a convergence write with the transaction that recorded it.
+type CommitResponse struct {
+	Status string `json:"status"`
+	*common.TxReceipt
 }
 
 // CommitStateCluster records a cluster -> state convergence payload.
-func (s *Service) CommitStateCluster(ctx context.Context, authCtx *common.AuthContext, req *CommitRequest) error {
+func (s *Service) CommitStateCluster(ctx context.Context, authCtx *common.AuthContext, req *CommitRequest) (*common.TxReceipt, error) {
 	if authCtx == nil {
-		return common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
 	}
 	if req == nil {
-		return common.NewStatusError(http.StatusBadRequest, "request body is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
 	}
 	stateID := selectValue(req.StateID, authCtx.State)
 	if strings.TrimSpace(stateID) == "" {
-		return common.NewStatusError(http.StatusBadRequest, "state_id is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
 	}
 	clusterID := selectValue(req.ClusterID, authCtx.Cluster)
 	if strings.TrimSpace(clusterID) == "" {
-		return common.NewStatusError(http.StatusBadRequest, "cluster_id is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "cluster_id is required")
 	}
-	payload, err := marshalPayload(req.Payload)
+	scope, err := s.resolveScope(req.JobID, req.Round)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	payload, err := s.sealPayload(scope, req.Payload)
+	if err != nil {
+		return nil, err
 	}
 	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
 	if !ok {
-		return common.NewStatusError(http.StatusForbidden, "trainer not registered")
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
-	args := []string{"CommitStateClusterConvergence", stateID, clusterID, payload}
-	return s.invoke(authCtx, rec.FabricClientID, args)
+	args := scope.args("CommitStateClusterConvergence", stateID, clusterID, payload)
+	return s.invoke(ctx, authCtx, rec.FabricClientID, args)
 }
 
 // CommitNationState records a state -> nation convergence payload.
-func (s *Service) CommitNationState(ctx context.Context, authCtx *common.AuthContext, req *CommitRequest) error {
+func (s *Service) CommitNationState(ctx context.Context, authCtx *common.AuthContext, req *CommitRequest) (*common.TxReceipt, error) {
 	if authCtx == nil {
-		return common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
 	}
 	if req == nil {
-		return common.NewStatusError(http.StatusBadRequest, "request body is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
 	}
 	stateID := selectValue(req.StateID, authCtx.State)
 	if strings.TrimSpace(stateID) == "" {
-		return common.NewStatusError(http.StatusBadRequest, "state_id is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
 	}
-	payload, err := marshalPayload(req.Payload)
+	scope, err := s.resolveScope(req.JobID, req.Round)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	payload, err := s.sealPayload(scope, req.Payload)
+	if err != nil {
+		return nil, err
 	}
 	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
 	if !ok {
-		return common.NewStatusError(http.StatusForbidden, "trainer not registered")
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
-	args := []string{"CommitNationStateConvergence", stateID, payload}
-	return s.invoke(authCtx, rec.FabricClientID, args)
+	args := scope.args("CommitNationStateConvergence", stateID, payload)
+	return s.invoke(ctx, authCtx, rec.FabricClientID, args)
 }
 
 // DeclareStateAll records that all clusters in a state are converged.
-func (s *Service) DeclareStateAll(ctx context.Context, authCtx *common.AuthContext, req *DeclareRequest) error {
+func (s *Service) DeclareStateAll(ctx context.Context, authCtx *common.AuthContext, req *DeclareRequest) (*common.TxReceipt, error) {
 	if authCtx == nil {
-		return common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
 	}
 	if req == nil {
-		return common.NewStatusError(http.StatusBadRequest, "request body is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
 	}
 	stateID := selectValue(req.StateID, authCtx.State)
 	if strings.TrimSpace(stateID) == "" {
-		return common.NewStatusError(http.StatusBadRequest, "state_id is required")
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
 	}
-	payload, err := marshalPayload(req.Payload)
+	scope, err := s.resolveScope(req.JobID, req.Round)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
 	if !ok {
-		return common.NewStatusError(http.StatusForbidden, "trainer not registered")
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	if err := s.checkEvaluation(ctx, rec.FabricClientID, req); err != nil {
+		return nil, err
+	}
+	payload, err := s.sealPayload(scope, req.Payload)
+	if err != nil {
+		return nil, err
 	}
-	args := []string{"DeclareStateConvergence", stateID, payload}
-	return s.invoke(authCtx, rec.FabricClientID, args)
+	args := scope.args("DeclareStateConvergence", stateID, payload)
+	receipt, err := s.invoke(ctx, authCtx, rec.FabricClientID, args)
+	if err != nil {
+		return nil, err
+	}
+	s.publishConverged(common.EventStateConverged, scope, receipt, &ConvergenceEvent{Scope: "state", StateID: stateID, Round: scope.Round, DeclaredBy: authCtx.Subject, ModelID: strings.TrimSpace(req.ModelID)})
+	return receipt, nil
 }
 
 // DeclareNationAll records that all states are converged at the nation scope.
-func (s *Service) DeclareNationAll(ctx context.Context, authCtx *common.AuthContext, req *DeclareRequest) error {
+func (s *Service) DeclareNationAll(ctx context.Context, authCtx *common.AuthContext, req *DeclareRequest) (*common.TxReceipt, error) {
 	if authCtx == nil {
-		return common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
 	}
-	payload, err := marshalPayload(req.Payload)
+	scope, err := s.resolveScope(req.JobID, req.Round)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
 	if !ok {
-		return common.NewStatusError(http.StatusForbidden, "trainer not registered")
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	if err := s.checkEvaluation(ctx, rec.FabricClientID, req); err != nil {
+		return nil, err
+	}
+	payload, err := s.sealPayload(scope, req.Payload)
+	if err != nil {
+		return nil, err
+	}
+	args := scope.args("DeclareNationConvergence", payload)
+	receipt, err := s.invoke(ctx, authCtx, rec.FabricClientID, args)
+	if err != nil {
+		return nil, err
 	}
-	args := []string{"DeclareNationConvergence", payload}
-	return s.invoke(authCtx, rec.FabricClientID, args)
+	s.publishConverged(common.EventNationConverged, scope, receipt, &ConvergenceEvent{Scope: "nation", Round: scope.Round, DeclaredBy: authCtx.Subject, ModelID: strings.TrimSpace(req.ModelID)})
+	return receipt, nil
+}
+
+func (s *Service) publishConverged(eventType string, scope Scope, receipt *common.TxReceipt, data *ConvergenceEvent) {
+	jobID := scope.JobID
+	if scope.Round == 0 {
+		jobID = s.cfg.JobID
+	}
+	event := &common.LifecycleEvent{Type: eventType, JobID: jobID, Data: data}
+	if receipt != nil {
+		event.TxID = receipt.TxID
+	}
+	s.events.Publish(event)
 }
 
 // StateStatus resolves convergence for a state.
-func (s *Service) StateStatus(ctx context.Context, authCtx *common.AuthContext, stateID string) (*StateStatus, error) {
+func (s *Service) StateStatus(ctx context.Context, authCtx *common.AuthContext, stateID string, scope Scope) (*StateStatus, error) {
 	if authCtx != nil {
 		stateID = selectValue(stateID, authCtx.State)
 	}
 	if strings.TrimSpace(stateID) == "" {
 		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
 	}
+	scope, err := s.resolveScope(scope.JobID, scope.Round)
+	if err != nil {
+		return nil, err
+	}
 	identity, err := s.identityFor(authCtx)
 	if err != nil {
 		return nil, err
 	}
-	args := []string{"ReadStateConvergence", stateID}
-	payload, err := s.fabric.QueryChaincode(s.fabric.SelectPeer(), identity, args)
+	args := scope.args("ReadStateConvergence", stateID)
+	payload, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, args)
 	if err != nil {
 		return nil, err
 	}
@@ -191,17 +296,30 @@ func (s *Service) StateStatus(ctx context.Context, authCtx *common.AuthContext,
 	if err := json.Unmarshal(payload, &ledgerState); err != nil {
 		return nil, err
 	}
-	return s.stateStatusFromLedger(ctx, &ledgerState)
+	status, err := s.stateStatusFromLedger(ctx, &ledgerState)
+	if err != nil {
+		return nil, err
+	}
+	status.JobID, status.Round = scope.JobID, scope.Round
+	return status, nil
 }
 
 // NationStatus resolves convergence for the nation.
-func (s *Service) NationStatus(ctx context.Context, authCtx *common.AuthContext) (*NationStatus, error) {
+func (s *Service) NationStatus(ctx context.Context, authCtx *common.AuthContext, scope Scope) (*NationStatus, error) {
+	scope, err := s.resolveScope(scope.JobID, scope.Round)
+	if err != nil {
+		return nil, err
+	}
 	identity, err := s.identityFor(authCtx)
 	if err != nil {
 		return nil, err
 	}
+	// The chaincode has no ReadNationConvergenceInRound; the list variant returns the same view.
 	args := []string{"ReadNationConvergence"}
-	payload, err := s.fabric.QueryChaincode(s.fabric.SelectPeer(), identity, args)
+	if scope.Round > 0 {
+		args = scope.args("ListNationConvergence")
+	}
+	payload, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, args)
 	if err != nil {
 		return nil, err
 	}
@@ -209,17 +327,26 @@ func (s *Service) NationStatus(ctx context.Context, authCtx *common.AuthContext)
 	if err := json.Unmarshal(payload, &ledgerNation); err != nil {
 		return nil, err
 	}
-	return s.nationStatusFromLedger(ctx, &ledgerNation)
+	status, err := s.nationStatusFromLedger(ctx, &ledgerNation)
+	if err != nil {
+		return nil, err
+	}
+	status.JobID, status.Round = scope.JobID, scope.Round
+	return status, nil
 }
 
 // ListStateStatuses returns convergence data for all states (admin only).
-func (s *Service) ListStateStatuses(ctx context.Context, authCtx *common.AuthContext) (map[string]*StateStatus, error) {
+func (s *Service) ListStateStatuses(ctx context.Context, authCtx *common.AuthContext, scope Scope) (map[string]*StateStatus, error) {
+	scope, err := s.resolveScope(scope.JobID, scope.Round)
+	if err != nil {
+		return nil, err
+	}
 	identity, err := s.identityFor(authCtx)
 	if err != nil {
 		return nil, err
 	}
-	args := []string{"ListStateConvergence"}
-	payload, err := s.fabric.QueryChaincode(s.fabric.SelectPeer(), identity, args)
+	args := scope.args("ListStateConvergence")
+	payload, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, args)
 	if err != nil {
 		return nil, err
 	}
@@ -234,22 +361,92 @@ func (s *Service) ListStateStatuses(ctx context.Context, authCtx *common.AuthCon
 		if err != nil {
 			return nil, err
 		}
+		status.JobID, status.Round = scope.JobID, scope.Round
 		results[stateID] = status
 	}
 	return results, nil
 }
 
 // ListNationStatus returns the detailed nation convergence map.
-func (s *Service) ListNationStatus(ctx context.Context, authCtx *common.AuthContext) (*NationStatus, error) {
-	return s.NationStatus(ctx, authCtx)
+func (s *Service) ListNationStatus(ctx context.Context, authCtx *common.AuthContext, scope Scope) (*NationStatus, error) {
+	return s.NationStatus(ctx, authCtx, scope)
+}
+
+// resolveScope validates a job/round pair. A job without a round is rejected so records are
+// never silently written to the legacy keyspace.
+func (s *Service) resolveScope(jobID string, round int) (Scope, error) {
+	jobID = strings.TrimSpace(jobID)
+	if round < 0 {
+		return Scope{}, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	if round == 0 {
+		if jobID != "" {
+			return Scope{}, common.NewStatusError(http.StatusBadRequest, "round is required when job_id is set")
+		}
+		return Scope{}, nil
+	}
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	return Scope{JobID: jobID, Round: round}, nil
+}
+
+// args builds chaincode arguments, switching to the InRound variant for scoped requests.
+func (sc Scope) args(function string, rest ...string) []string {
+	if sc.Round == 0 {
+		return append([]string{function}, rest...)
+	}
+	return append([]string{function + "InRound", sc.JobID, strconv.Itoa(sc.Round)}, rest...)
 }
 
-func (s *Service) invoke(authCtx *common.AuthContext, identity string, args []string) error {
-	peer := s.fabric.SelectPeer()
+// checkEvaluation enforces the evaluation consensus gate when a minimum score is configured
+// and records the consensus alongside the declaration payload.
+func (s *Service) checkEvaluation(ctx context.Context, identity string, req *DeclareRequest) error {
+	modelID := strings.TrimSpace(req.ModelID)
+	if modelID == "" {
+		if s.cfg.EvaluationMinScore > 0 {
+			return common.NewStatusError(http.StatusBadRequest, "model_id is required when an evaluation minimum score is configured")
+		}
+		return nil
+	}
+	if s.evaluations == nil {
+		return nil
+	}
+	consensus, err := s.evaluations.RequireAccepted(ctx, identity, modelID)
+	if err != nil {
+		return err
+	}
+	if req.Payload == nil {
+		req.Payload = map[string]any{}
+	}
+	req.Payload["model_id"] = consensus.ModelID
+	req.Payload["evaluation_consensus"] = map[string]any{
+		"metric": consensus.Metric,
+		"score":  consensus.Score,
+		"count":  consensus.Count,
+	}
+	return nil
+}
+
+func (s *Service) invoke(ctx context.Context, authCtx *common.AuthContext, identity string, args []string) (*common.TxReceipt, error) {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
 	if peer == "" {
-		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	receipt, err := s.fabric.InvokeChaincode(ctx, peer, identity, args)
+	if err != nil && strings.Contains(err.Error(), "in membership epoch") {
+		// The cluster was moved out of the state by a clustering plan.
+		return nil, common.NewStatusError(http.StatusConflict, err.Error())
+	}
+	if err != nil && strings.Contains(err.Error(), "has no passing verification") {
+		// The central checker has not passed every state reporting toward the nation.
+		return nil, common.NewStatusError(http.StatusConflict, err.Error())
 	}
-	return s.fabric.InvokeChaincode(peer, identity, args)
+	if err != nil && strings.Contains(err.Error(), "is disputed") {
+		// An aggregator challenged a state declaration and no admin has resolved it yet.
+		return nil, common.NewStatusError(http.StatusConflict, err.Error())
+	}
+	return receipt, err
 }
 
 func (s *Service) identityFor(authCtx *common.AuthContext) (string, error) {
@@ -264,6 +461,24 @@ func (s *Service) identityFor(authCtx *common.AuthContext) (string, error) {
 	return s.cfg.AdminIdentity, nil
 }
 
+// sealPayload marshals a payload and encrypts it with the data key of the scope's job;
+// unscoped records belong to GATEWAY_JOB_ID.
+func (s *Service) sealPayload(scope Scope, payload map[string]any) (string, error) {
+	raw, err := marshalPayload(payload)
+	if err != nil {
+		return "", err
+	}
+	jobID := scope.JobID
+	if scope.Round == 0 {
+		jobID = s.cfg.JobID
+	}
+	sealed, err := s.envelopes.Encrypt(jobID, []byte(raw))
+	if err != nil {
+		return "", err
+	}
+	return string(sealed), nil
+}
+
 func marshalPayload(payload map[string]any) (string, error) {
 	if len(payload) == 0 {
 		return "", common.NewStatusError(http.StatusBadRequest, "payload is required")
@@ -308,7 +523,12 @@ func (s *Service) stateStatusFromLedger(ctx context.Context, entry *ledgerStateC
 			clusterStatus.IsConverged = true
 			clusterStatus.SubmittedAt = record.SubmittedAt
 			clusterStatus.SourceID = record.SourceID
-			clusterStatus.Payload = decodePayload(record.Payload)
+			payload, err := s.openPayload(ctx, record.Payload)
+			if err != nil {
+				return nil, err
+			}
+			clusterStatus.Payload = payload
+			clusterStatus.Epoch = record.Epoch
 		}
 		status.Clusters = append(status.Clusters, clusterStatus)
 	}
@@ -316,7 +536,9 @@ func (s *Service) stateStatusFromLedger(ctx context.Context, entry *ledgerStateC
 		status.IsConverged = true
 		status.ConvergedAt = entry.Summary.DeclaredAt
 		status.DeclaredBy = entry.Summary.DeclaredBy
-		status.SummaryPayload = decodePayload(entry.Summary.Payload)
+		if status.SummaryPayload, err = s.openPayload(ctx, entry.Summary.Payload); err != nil {
+			return nil, err
+		}
 	} else {
 		allConverged := true
 		for _, cluster := range status.Clusters {
@@ -341,7 +563,7 @@ func (s *Service) nationStatusFromLedger(ctx context.Context, entry *ledgerNatio
 	if err != nil {
 		return nil, err
 	}
-	stateIDs := hierarchyStateIDs(hierarchy)
+	stateIDs := hierarchyStateIDs(hierarchy.ActiveOnly())
 	states := make([]*StateAggregate, 0, len(stateIDs))
 	allConverged := true
 	var latest string
@@ -352,7 +574,12 @@ func (s *Service) nationStatusFromLedger(ctx context.Context, entry *ledgerNatio
 			stateAggregate.IsConverged = true
 			stateAggregate.SubmittedAt = record.SubmittedAt
 			stateAggregate.SourceID = record.SourceID
-			stateAggregate.Payload = decodePayload(record.Payload)
+			payload, err := s.openPayload(ctx, record.Payload)
+			if err != nil {
+				return nil, err
+			}
+			stateAggregate.Payload = payload
+			stateAggregate.Epoch = record.Epoch
 			if record.SubmittedAt > latest {
 				latest = record.SubmittedAt
 			}
@@ -368,7 +595,9 @@ func (s *Service) nationStatusFromLedger(ctx context.Context, entry *ledgerNatio
 		status.IsConverged = true
 		status.ConvergedAt = entry.Summary.DeclaredAt
 		status.DeclaredBy = entry.Summary.DeclaredBy
-		status.SummaryPayload = decodePayload(entry.Summary.Payload)
+		if status.SummaryPayload, err = s.openPayload(ctx, entry.Summary.Payload); err != nil {
+			return nil, err
+		}
 	} else {
 		status.IsConverged = allConverged && len(states) > 0
 		if status.IsConverged {
@@ -379,11 +608,22 @@ func (s *Service) nationStatusFromLedger(ctx context.Context, entry *ledgerNatio
 }
 
 func (s *Service) clustersForState(ctx context.Context, stateID string) ([]string, error) {
-	hierarchy, err := s.whitelist.Hierarchy(ctx)
-	if err != nil {
-		return nil, err
+	entries := &whitelist.ListResult{}
+	for page := 1; ; page++ {
+		result, err := s.whitelist.ByState(ctx, stateID, page, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range result.Items {
+			if entry.Active() {
+				entries.Items = append(entries.Items, entry)
+			}
+		}
+		if !result.HasMore {
+			break
+		}
 	}
-	for _, state := range hierarchy.States {
+	for _, state := range entries.ToHierarchy().States {
 		if state == nil {
 			continue
 		}
@@ -402,6 +642,15 @@ func (s *Service) clustersForState(ctx context.Context, stateID string) ([]strin
 	return nil, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("state %s not found in whitelist", stateID))
 }
 
+// openPayload decodes a ledger payload, decrypting it first for callers allowed to read it.
+func (s *Service) openPayload(ctx context.Context, raw json.RawMessage) (map[string]any, error) {
+	opened, err := s.envelopes.Reveal(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return decodePayload(opened), nil
+}
+
 func decodePayload(raw json.RawMessage) map[string]any {
 	if len(raw) == 0 {
 		return nil
@@ -430,6 +679,7 @@ type ledgerConvergenceRecord struct {
 	SourceID    string          `json:"source_id"`
 	Payload     json.RawMessage `json:"payload"`
 	SubmittedAt string          `json:"submitted_at"`
+	Epoch       int             `json:"epoch,omitempty"`
 }
 
 type ledgerConvergenceSummary struct {
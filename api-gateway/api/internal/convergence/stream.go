@@ -0,0 +1,103 @@
+package convergence
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/events"
+)
+
+// statusLoader re-reads the convergence view a stream is following.
+type statusLoader func(r *http.Request, authCtx *common.AuthContext) (any, error)
+
+func (h *HTTPHandler) handleStateStream(w http.ResponseWriter, r *http.Request) {
+	h.stream(w, r, func(r *http.Request, authCtx *common.AuthContext) (any, error) {
+		scope, err := scopeFromQuery(r)
+		if err != nil {
+			return nil, err
+		}
+		return h.svc.StateStatus(r.Context(), authCtx, strings.TrimSpace(r.URL.Query().Get("stateId")), scope)
+	})
+}
+
+func (h *HTTPHandler) handleNationStream(w http.ResponseWriter, r *http.Request) {
+	h.stream(w, r, func(r *http.Request, authCtx *common.AuthContext) (any, error) {
+		scope, err := scopeFromQuery(r)
+		if err != nil {
+			return nil, err
+		}
+		return h.svc.NationStatus(r.Context(), authCtx, scope)
+	})
+}
+
+// stream sends the current status immediately and again whenever a new block changes it.
+func (h *HTTPHandler) stream(w http.ResponseWriter, r *http.Request, load statusLoader) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	if h.hub == nil {
+		common.WriteErrorWithCode(w, http.StatusServiceUnavailable, common.NewStatusError(http.StatusServiceUnavailable, "event streaming is disabled"))
+		return
+	}
+	status, err := load(r, authCtx)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	blocks, cancel := h.hub.Subscribe()
+	defer cancel()
+
+	sse, err := events.NewSSEWriter(w)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusInternalServerError, err)
+		return
+	}
+	var height uint64
+	if latest := h.hub.Latest(); latest != nil {
+		height = latest.Height
+	}
+	last, _ := json.Marshal(status)
+	if err := sse.Send("convergence", strconv.FormatUint(height, 10), status); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(events.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := sse.Heartbeat(); err != nil {
+				return
+			}
+		case block := <-blocks:
+			status, err := load(r, authCtx)
+			if err != nil {
+				if sendErr := sse.Send("error", "", common.NewErrorEnvelope(common.StatusOf(err), err, common.RequestIDFrom(r.Context()))); sendErr != nil {
+					return
+				}
+				continue
+			}
+			current, _ := json.Marshal(status)
+			if bytes.Equal(current, last) {
+				continue
+			}
+			last = current
+			if err := sse.Send("convergence", strconv.FormatUint(block.Height, 10), status); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -2,46 +2,40 @@ package data
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
 	"github.com/nebula/api-gateway/internal/registry"
 )
 
 // HTTPHandler exposes the commit/retrieve endpoints.
 type HTTPHandler struct {
 	svc   *Service
-	store *registry.Store
+	store registry.Store
 }
 
 // NewHTTPHandler builds a handler.
-func NewHTTPHandler(svc *Service, store *registry.Store) *HTTPHandler {
+func NewHTTPHandler(svc *Service, store registry.Store) *HTTPHandler {
 	return &HTTPHandler{svc: svc, store: store}
 }
 
 // RegisterRoutes mounts the handler on the mux.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
-	keyFunc := func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
-		subject := strings.TrimSpace(claims.Subject)
-		if subject == "" {
-			return nil, errors.New("token missing subject")
-		}
-		record, ok := h.store.FindByJWTSub(subject)
-		if !ok {
-			return nil, errors.New("trainer not registered")
-		}
-		pub, err := record.PublicKeyBytes()
-		if err != nil {
-			return nil, err
-		}
-		return &common.KeySpec{Algorithm: "EdDSA", PublicKey: pub}, nil
-	}
+	keyFunc := registry.TrainerKeyFunc(h.store)
 	mux.Handle("/data/commit", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleCommit)))
 	mux.Handle("/data/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleRetrieve)))
 }
 
+// Describe documents the data endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("data")
+	const runtimeToken = "Requires a trainer runtime token (EdDSA) or a token from /auth/token."
+	api.Add(http.MethodPost, "/data/commit", openapi.Operation{Summary: "Store a payload on the ledger", Description: runtimeToken, Body: commitRequest{}, Response: CommitResult{}, Status: http.StatusCreated})
+	api.Add(http.MethodGet, "/data/{data_id}", openapi.Operation{Summary: "Read a stored payload", Description: runtimeToken, Response: DataRecord{}, Errors: []int{http.StatusNotFound}})
+}
+
 type commitRequest struct {
 	Payload json.RawMessage `json:"payload"`
 }
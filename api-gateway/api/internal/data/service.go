@@ -9,20 +9,28 @@ import (
 
 	"github.com/nebula/api-gateway/internal/common"
 	"github.com/nebula/api-gateway/internal/registry"
+	"github.com/nebula/api-gateway/internal/storage"
 )
 
 // Service handles Fabric transactions for commit/retrieve operations.
 type Service struct {
-	cfg    *common.Config
-	fabric *common.FabricClient
-	store  *registry.Store
+	cfg      *common.Config
+	fabric   *common.FabricClient
+	store    registry.Store
+	payloads *storage.Offloader
 }
 
 // NewService instantiates a data service.
-func NewService(cfg *common.Config, fabric *common.FabricClient, store *registry.Store) *Service {
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
 	return &Service{cfg: cfg, fabric: fabric, store: store}
 }
 
+// EnableStorage commits large payloads off-chain through payloads and rehydrates them on
+// read.
+func (s *Service) EnableStorage(payloads *storage.Offloader) {
+	s.payloads = payloads
+}
+
 // Commit stores arbitrary payloads on-chain and returns their identifier.
 func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, payload json.RawMessage) (*CommitResult, error) {
 	if authCtx == nil {
@@ -36,12 +44,17 @@ func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, paylo
 		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
 	dataID := common.GeneratePrefixedID("data")
+	payload, err := s.payloads.Offload(ctx, "data", payload)
+	if err != nil {
+		return nil, err
+	}
 	args := []string{"CommitData", dataID, string(payload)}
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	if err := s.fabric.InvokeChaincode(peerName, enrolment.FabricClientID, args); err != nil {
+	receipt, err := s.fabric.InvokeChaincode(ctx, peerName, enrolment.FabricClientID, args)
+	if err != nil {
 		return nil, err
 	}
 	return &CommitResult{
@@ -49,6 +62,8 @@ func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, paylo
 		NodeID:      enrolment.NodeID,
 		VCHash:      enrolment.VCHash,
 		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		TxID:        receipt.TxID,
+		BlockNumber: receipt.BlockNumber,
 	}, nil
 }
 
@@ -65,11 +80,11 @@ func (s *Service) Retrieve(ctx context.Context, authCtx *common.AuthContext, dat
 		return nil, common.NewStatusError(http.StatusBadRequest, "data identifier is required")
 	}
 	args := []string{"ReadData", dataID}
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerRead)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	raw, err := s.fabric.QueryChaincode(peerName, enrolment.FabricClientID, args)
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, enrolment.FabricClientID, args)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +92,13 @@ func (s *Service) Retrieve(ctx context.Context, authCtx *common.AuthContext, dat
 	if err := json.Unmarshal(raw, &ledger); err != nil {
 		return nil, err
 	}
+	payload, err := s.payloads.Rehydrate(ctx, ledger.Payload)
+	if err != nil {
+		return nil, err
+	}
 	return &DataRecord{
 		DataID:      ledger.ID,
-		Payload:     ledger.Payload,
+		Payload:     payload,
 		Owner:       ledger.Owner,
 		SubmittedAt: ledger.SubmittedAt,
 	}, nil
@@ -91,6 +110,8 @@ type CommitResult struct {
 	NodeID      string `json:"node_id"`
 	VCHash      string `json:"vc_hash"`
 	SubmittedAt string `json:"submitted_at"`
+	TxID        string `json:"tx_id,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
 }
 
 // DataRecord describes chaincode records returned to clients.
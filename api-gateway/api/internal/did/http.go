@@ -0,0 +1,125 @@
+package did
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the DID registry endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the DID registry HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the `/did-contract/dids` endpoints.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/did-contract/dids", auth.RequireAuth(http.HandlerFunc(h.handleCollection)))
+	mux.Handle("/did-contract/dids/", auth.RequireAuth(http.HandlerFunc(h.handleRecord)))
+}
+
+// Describe documents the DID registry endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("did")
+	api.Add(http.MethodGet, "/did-contract/dids", openapi.Operation{Summary: "List DID records", Response: map[string]any{"items": []*Record{}}})
+	api.Add(http.MethodPost, "/did-contract/dids", openapi.Operation{Summary: "Register a DID document", Body: documentRequest{}, Response: Record{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/did-contract/dids/{did}", openapi.Operation{Summary: "Resolve a DID", Response: Record{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPut, "/did-contract/dids/{did}", openapi.Operation{Summary: "Replace a DID document", Body: documentRequest{}, Response: Record{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodDelete, "/did-contract/dids/{did}", openapi.Operation{Summary: "Deactivate a DID", Response: Record{}, Errors: []int{http.StatusNotFound}})
+}
+
+type documentRequest struct {
+	DID      string          `json:"did"`
+	Document json.RawMessage `json:"document"`
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		records, err := h.svc.List(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": records})
+	case http.MethodPost:
+		authCtx, ok := common.AuthContextFrom(r.Context())
+		if !ok {
+			common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+			return
+		}
+		var req documentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Create(r.Context(), authCtx, req.DID, req.Document)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleRecord(w http.ResponseWriter, r *http.Request) {
+	did := strings.TrimPrefix(r.URL.Path, "/did-contract/dids/")
+	if strings.TrimSpace(did) == "" {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "did missing"))
+		return
+	}
+	if r.Method == http.MethodGet {
+		record, err := h.svc.Resolve(r.Context(), did)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, record)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	var (
+		record *Record
+		err    error
+	)
+	switch r.Method {
+	case http.MethodPut:
+		var req documentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err = h.svc.Update(r.Context(), authCtx, did, req.Document)
+	case http.MethodDelete:
+		record, err = h.svc.Deactivate(r.Context(), authCtx, did)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, record)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
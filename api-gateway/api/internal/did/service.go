@@ -0,0 +1,163 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service manages DID Documents stored in the on-chain DID registry.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a DID registry service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Record mirrors the on-chain DIDRecord with the document decoded.
+type Record struct {
+	DID         string          `json:"did"`
+	Controller  string          `json:"controller"`
+	Document    json.RawMessage `json:"document"`
+	Status      string          `json:"status"`
+	Version     int             `json:"version"`
+	Created     string          `json:"created"`
+	Updated     string          `json:"updated"`
+	Deactivated string          `json:"deactivated,omitempty"`
+}
+
+type ledgerDIDRecord struct {
+	DID         string `json:"did"`
+	Controller  string `json:"controller"`
+	Document    string `json:"document"`
+	Status      string `json:"status"`
+	Version     int    `json:"version"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+	Deactivated string `json:"deactivated,omitempty"`
+}
+
+// Create registers a DID Document; the caller's Fabric identity becomes its controller.
+func (s *Service) Create(ctx context.Context, authCtx *common.AuthContext, did string, document json.RawMessage) (*Record, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "did is required")
+	}
+	if len(document) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "document is required")
+	}
+	return s.invoke(ctx, authCtx, []string{"CreateDID", did, string(document)})
+}
+
+// Resolve returns the DID Document and its registry metadata.
+func (s *Service) Resolve(ctx context.Context, did string) (*Record, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "did is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ResolveDID", did})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var record ledgerDIDRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return record.toRecord(), nil
+}
+
+// List returns every registered DID.
+func (s *Service) List(ctx context.Context) ([]*Record, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListDIDs"})
+	if err != nil {
+		return nil, err
+	}
+	var records []*ledgerDIDRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+	result := make([]*Record, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.toRecord())
+	}
+	return result, nil
+}
+
+// Update replaces the DID Document; only the controller identity may update it.
+func (s *Service) Update(ctx context.Context, authCtx *common.AuthContext, did string, document json.RawMessage) (*Record, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "did is required")
+	}
+	if len(document) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "document is required")
+	}
+	return s.invoke(ctx, authCtx, []string{"UpdateDIDDocument", did, string(document)})
+}
+
+// Deactivate marks the DID as deactivated; it still resolves afterwards.
+func (s *Service) Deactivate(ctx context.Context, authCtx *common.AuthContext, did string) (*Record, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "did is required")
+	}
+	return s.invoke(ctx, authCtx, []string{"DeactivateDID", did})
+}
+
+func (s *Service) invoke(ctx context.Context, authCtx *common.AuthContext, args []string) (*Record, error) {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	if _, err := s.fabric.InvokeChaincode(ctx, peer, s.identityFor(authCtx), args); err != nil {
+		return nil, mapLedgerError(err)
+	}
+	return s.Resolve(ctx, args[1])
+}
+
+// identityFor signs with the caller's enrolled trainer identity so the trainer controls its
+// DID; callers without an enrollment (e.g. before registration) use the admin identity.
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func (r *ledgerDIDRecord) toRecord() *Record {
+	return &Record{
+		DID:         r.DID,
+		Controller:  r.Controller,
+		Document:    json.RawMessage(r.Document),
+		Status:      r.Status,
+		Version:     r.Version,
+		Created:     r.Created,
+		Updated:     r.Updated,
+		Deactivated: r.Deactivated,
+	}
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "already exists"), strings.Contains(msg, "is deactivated"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "not the controller"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "invalid did document"), strings.Contains(msg, "did document"), strings.Contains(msg, "is not a valid did"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// WellKnownPath is where the discovery document is served.
+const WellKnownPath = "/.well-known/nebula-configuration"
+
+// HTTPHandler serves the discovery document.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the discovery HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the unauthenticated discovery endpoint.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(WellKnownPath, h.handleDocument)
+}
+
+// Describe documents the discovery endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	spec.Tag("discovery").Add(http.MethodGet, WellKnownPath, openapi.Operation{Summary: "Read the gateway discovery document", Public: true, Response: Document{}})
+}
+
+func (h *HTTPHandler) handleDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	common.WriteJSON(w, http.StatusOK, h.svc.Document())
+}
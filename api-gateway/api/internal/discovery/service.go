@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// APIVersion is the gateway API version advertised to clients.
+const APIVersion = "1.0"
+
+// Module describes a feature area and the endpoints it mounts.
+type Module struct {
+	Name      string   `json:"name"`
+	Enabled   bool     `json:"enabled"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Backend   string   `json:"backend,omitempty"`
+}
+
+// AuthMethod describes an accepted credential type.
+type AuthMethod struct {
+	Name        string   `json:"name"`
+	Algorithm   string   `json:"algorithm,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Endpoints   []string `json:"endpoints,omitempty"`
+	Description string   `json:"description"`
+}
+
+// Deployment identifies a channel/chaincode pair the gateway talks to. Name is the selector
+// clients pass in X-Fabric-Channel to use it.
+type Deployment struct {
+	Name      string `json:"name"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+	MSPID     string `json:"msp_id"`
+}
+
+// Stream describes a long-lived streaming endpoint.
+type Stream struct {
+	Path     string `json:"path"`
+	Protocol string `json:"protocol"`
+	Topics   string `json:"topics,omitempty"`
+}
+
+// Document is the body of `/.well-known/nebula-configuration`.
+type Document struct {
+	APIVersion  string        `json:"api_version"`
+	JobID       string        `json:"job_id,omitempty"`
+	Modules     []*Module     `json:"modules"`
+	AuthMethods []*AuthMethod `json:"auth_methods"`
+	Deployments []*Deployment `json:"deployments"`
+	Streams     []*Stream     `json:"streams"`
+}
+
+// Service collects what each module registers so trainer frameworks can auto-configure.
+type Service struct {
+	cfg     *common.Config
+	mu      sync.RWMutex
+	modules map[string]*Module
+	streams []*Stream
+}
+
+// NewService constructs a discovery service.
+func NewService(cfg *common.Config) *Service {
+	return &Service{cfg: cfg, modules: map[string]*Module{}}
+}
+
+// RegisterModule records a module and the endpoints it exposes.
+func (s *Service) RegisterModule(name string, enabled bool, endpoints ...string) *Module {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	module := &Module{Name: name, Enabled: enabled, Endpoints: endpoints}
+	s.modules[name] = module
+	return module
+}
+
+// RegisterStream records a streaming endpoint.
+func (s *Service) RegisterStream(path, protocol, topics string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams = append(s.streams, &Stream{Path: path, Protocol: protocol, Topics: topics})
+}
+
+// Document assembles the discovery document.
+func (s *Service) Document() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	modules := make([]*Module, 0, len(s.modules))
+	for _, module := range s.modules {
+		modules = append(modules, module)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	streams := make([]*Stream, len(s.streams))
+	copy(streams, s.streams)
+	return &Document{
+		APIVersion:  APIVersion,
+		JobID:       s.cfg.JobID,
+		Modules:     modules,
+		AuthMethods: s.authMethods(),
+		Deployments: s.deployments(),
+		Streams:     streams,
+	}
+}
+
+func (s *Service) deployments() []*Deployment {
+	targets := s.cfg.ChannelTargets()
+	deployments := make([]*Deployment, 0, len(targets))
+	for _, target := range targets {
+		deployments = append(deployments, &Deployment{Name: target.Name, Channel: target.Channel, Chaincode: target.Chaincode, MSPID: s.cfg.MSPID})
+	}
+	return deployments
+}
+
+// Endpoints returns the endpoints a registered module mounts.
+func (s *Service) Endpoints(module string) ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	registered, ok := s.modules[module]
+	if !ok {
+		return nil, false
+	}
+	return registered.Endpoints, true
+}
+
+func (s *Service) authMethods() []*AuthMethod {
+	methods := []*AuthMethod{
+		{
+			Name:        "shared_secret_jwt",
+			Algorithm:   "HS256",
+			Roles:       []string{string(common.RoleAdmin), string(common.RoleAggregator), string(common.RoleCentralChecker), string(common.RoleStateCoordinator), string(common.RoleTrainer)},
+			Endpoints:   []string{"/auth/register-trainer"},
+			Description: "JWT signed with the deployment's shared secret; used for registration and admin/aggregator APIs.",
+		},
+		{
+			Name:        "trainer_jwt",
+			Algorithm:   "EdDSA",
+			Roles:       []string{string(common.RoleTrainer), string(common.RoleValidator)},
+			Description: "JWT signed with the Ed25519 key registered for the trainer; required for runtime APIs.",
+		},
+		{
+			Name:        "gateway_token",
+			Algorithm:   "EdDSA",
+			Roles:       []string{string(common.RoleTrainer)},
+			Endpoints:   []string{"/auth/challenge", "/auth/token"},
+			Description: "Short-lived JWT issued by the gateway after the trainer signs a one-time challenge with its registered key; accepted wherever trainer tokens are.",
+		},
+		{
+			Name:        "verifiable_credential",
+			Algorithm:   "Ed25519",
+			Endpoints:   []string{"/auth/register-trainer"},
+			Description: "Admin-signed VC presented once during enrollment.",
+		},
+	}
+	if s.cfg.AuthJWKSURL != "" {
+		methods = append(methods, &AuthMethod{
+			Name:        "identity_provider_jwt",
+			Algorithm:   "RS256/ES256/EdDSA",
+			Roles:       []string{string(common.RoleAdmin), string(common.RoleAggregator), string(common.RoleCentralChecker)},
+			Description: "JWT issued by an allowlisted external identity provider and verified against its JWKS; accepted wherever shared-secret tokens are.",
+		})
+	}
+	return methods
+}
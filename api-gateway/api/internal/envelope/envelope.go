@@ -0,0 +1,349 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Algorithm is the cipher envelopes are sealed with.
+const Algorithm = "AES-256-GCM"
+
+// Envelope is the committed form of an encrypted payload. The data key it was sealed with
+// travels wrapped alongside, so any gateway holding the master key can open any record even
+// if its own keyring never saw the job.
+type Envelope struct {
+	Algorithm  string `json:"alg"`
+	KeyID      string `json:"key_id"`
+	JobID      string `json:"job_id"`
+	Sealer     string `json:"sealer"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+	// Digest is the hex HMAC-SHA256 of the plaintext under a key derived from the job's data
+	// key (see Cipher.Digest). The chaincode indexes it in place of the ciphertext's hash, so
+	// duplicate detection keeps working on encrypted payloads without letting channel members
+	// confirm a guessed payload.
+	Digest string `json:"digest,omitempty"`
+	// SHA256 is the unkeyed plaintext digest of envelopes sealed before Digest replaced it;
+	// it is only checked when they are opened.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// document is the on-chain form of an Envelope. The single wrapping key keeps it from being
+// mistaken for an ordinary payload.
+type document struct {
+	Encrypted *Envelope `json:"encrypted"`
+}
+
+// DataKey is one job's data key as kept in the keyring, wrapped by the master key.
+type DataKey struct {
+	ID         string `json:"id"`
+	JobID      string `json:"job_id"`
+	Sealer     string `json:"sealer"`
+	WrappedKey []byte `json:"wrapped_key"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Cipher encrypts payloads with per-job data keys before they are committed and decrypts
+// them on read for the roles allowed to see them. A nil Cipher leaves every payload as it is,
+// so callers can use the result of Open unconditionally.
+type Cipher struct {
+	sealer common.KeySealer
+	roles  []common.Role
+	path   string
+
+	mu     sync.Mutex
+	keys   map[string]*DataKey
+	opened map[string]*openedKey
+}
+
+// openedKey is an unwrapped data key: the AEAD payloads are sealed with and the key their
+// digests are keyed with.
+type openedKey struct {
+	aead      cipher.AEAD
+	digestKey []byte
+}
+
+// Open builds the cipher the configuration describes, loading the keyring at
+// PayloadKeyringPath. It returns nil when payload encryption is off.
+func Open(cfg *common.Config) (*Cipher, error) {
+	var (
+		sealer common.KeySealer
+		err    error
+	)
+	switch cfg.PayloadEncryption {
+	case common.WalletEncryptionSecret:
+		sealer, err = common.NewSecretSealer(cfg.PayloadSecret, cfg.WalletKDF, cfg.WalletKDFIterations)
+	case common.WalletEncryptionKMS:
+		sealer, err = common.NewTransitSealer(cfg.PayloadKMSURL, cfg.PayloadKMSKey, cfg.PayloadKMSToken)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return New(sealer, cfg.PayloadDecryptRoles, cfg.PayloadKeyringPath)
+}
+
+// New builds a cipher whose data keys are wrapped by sealer and kept in the keyring file at
+// path; roles may read decrypted payloads.
+func New(sealer common.KeySealer, roles []common.Role, path string) (*Cipher, error) {
+	c := &Cipher{sealer: sealer, roles: roles, path: path, keys: map[string]*DataKey{}, opened: map[string]*openedKey{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload keyring: %w", err)
+	}
+	var keys []*DataKey
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("invalid payload keyring %s: %w", path, err)
+	}
+	for _, key := range keys {
+		c.keys[key.JobID] = key
+	}
+	return c, nil
+}
+
+// Encrypt returns what should be committed for payload: an envelope sealed with the job's
+// data key, which is created and wrapped on the job's first payload.
+func (c *Cipher) Encrypt(jobID string, payload []byte) ([]byte, error) {
+	if c == nil {
+		return payload, nil
+	}
+	key, opened, err := c.jobKey(jobID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, opened.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document{Encrypted: &Envelope{
+		Algorithm:  Algorithm,
+		KeyID:      key.ID,
+		JobID:      key.JobID,
+		Sealer:     key.Sealer,
+		WrappedKey: key.WrappedKey,
+		Nonce:      nonce,
+		Ciphertext: opened.aead.Seal(nil, nonce, payload, []byte(key.ID)),
+		Digest:     opened.digest(payload),
+	}})
+}
+
+// Digest is the payload hash the chaincode records for payload once Encrypt has sealed it:
+// the hex SHA-256 of the payload when encryption is off, otherwise its HMAC-SHA256 under the
+// job's digest key. Equal payloads of a job share a digest, but only gateways holding the
+// master key can compute it.
+func (c *Cipher) Digest(jobID string, payload []byte) (string, error) {
+	if c == nil {
+		sum := sha256.Sum256(payload)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	_, opened, err := c.jobKey(jobID)
+	if err != nil {
+		return "", err
+	}
+	return opened.digest(payload), nil
+}
+
+// Reveal resolves a payload as it was read from the ledger, either a JSON string or the
+// JSON value itself, into the payload that was committed, keeping its form. Envelopes stay
+// sealed for callers whose role may not read payloads and when encryption is off; anything
+// else is returned unchanged.
+func (c *Cipher) Reveal(ctx context.Context, raw json.RawMessage) (json.RawMessage, error) {
+	content, quoted := []byte(raw), false
+	var value string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &value) == nil {
+		content, quoted = []byte(value), true
+	}
+	env := Parse(content)
+	if env == nil || c == nil || !c.allowed(ctx) {
+		return raw, nil
+	}
+	plaintext, err := c.reveal(env)
+	if err != nil {
+		return nil, err
+	}
+	if quoted {
+		return json.Marshal(string(plaintext))
+	}
+	return plaintext, nil
+}
+
+// Parse returns the envelope payload holds, or nil if it is an ordinary payload.
+func Parse(payload []byte) *Envelope {
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 || payload[0] != '{' {
+		return nil
+	}
+	var doc document
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil || doc.Encrypted == nil {
+		return nil
+	}
+	return doc.Encrypted
+}
+
+func (c *Cipher) allowed(ctx context.Context) bool {
+	authCtx, ok := common.AuthContextFrom(ctx)
+	return ok && authCtx.Role.Allowed(c.roles...)
+}
+
+func (c *Cipher) reveal(env *Envelope) ([]byte, error) {
+	if env.Algorithm != Algorithm {
+		return nil, fmt.Errorf("payload is sealed with unsupported algorithm %q", env.Algorithm)
+	}
+	opened, err := c.open(env.KeyID, env.Sealer, env.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != opened.aead.NonceSize() {
+		return nil, fmt.Errorf("payload sealed with data key %s has an invalid nonce", env.KeyID)
+	}
+	plaintext, err := opened.aead.Open(nil, env.Nonce, env.Ciphertext, []byte(env.KeyID))
+	if err != nil {
+		return nil, common.NewStatusError(http.StatusInternalServerError, fmt.Sprintf("payload sealed with data key %s failed to decrypt", env.KeyID))
+	}
+	expected, digest := env.Digest, opened.digest(plaintext)
+	if expected == "" {
+		sum := sha256.Sum256(plaintext)
+		expected, digest = env.SHA256, hex.EncodeToString(sum[:])
+	}
+	if !hmac.Equal([]byte(strings.ToLower(expected)), []byte(digest)) {
+		return nil, common.NewStatusError(http.StatusInternalServerError, fmt.Sprintf("payload sealed with data key %s failed its integrity check", env.KeyID))
+	}
+	return plaintext, nil
+}
+
+// jobKey returns the job's data key, creating, wrapping and persisting one the first time.
+func (c *Cipher) jobKey(jobID string) (*DataKey, *openedKey, error) {
+	c.mu.Lock()
+	key, ok := c.keys[jobID]
+	c.mu.Unlock()
+	if ok {
+		opened, err := c.open(key.ID, key.Sealer, key.WrappedKey)
+		return key, opened, err
+	}
+	plain := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, plain); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := c.sealer.Seal(plain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap data key of job %s: %w", jobID, err)
+	}
+	opened, err := newOpenedKey(plain)
+	if err != nil {
+		return nil, nil, err
+	}
+	key = &DataKey{
+		ID:         common.GeneratePrefixedID("dk"),
+		JobID:      jobID,
+		Sealer:     c.sealer.Name(),
+		WrappedKey: wrapped,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	c.mu.Lock()
+	if existing, ok := c.keys[jobID]; ok {
+		// Another commit of the job created its key first.
+		c.mu.Unlock()
+		opened, err := c.open(existing.ID, existing.Sealer, existing.WrappedKey)
+		return existing, opened, err
+	}
+	c.keys[jobID] = key
+	if err := c.persistLocked(); err != nil {
+		delete(c.keys, jobID)
+		c.mu.Unlock()
+		return nil, nil, err
+	}
+	c.opened[key.ID] = opened
+	c.mu.Unlock()
+	return key, opened, nil
+}
+
+// open unwraps a data key, caching it by key ID since unwrapping may be a KMS round trip.
+func (c *Cipher) open(keyID, sealer string, wrapped []byte) (*openedKey, error) {
+	c.mu.Lock()
+	opened, ok := c.opened[keyID]
+	c.mu.Unlock()
+	if ok {
+		return opened, nil
+	}
+	if sealer != c.sealer.Name() {
+		return nil, fmt.Errorf("data key %s is wrapped with %s, not %s", keyID, sealer, c.sealer.Name())
+	}
+	plain, err := c.sealer.Open(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key %s: %w", keyID, err)
+	}
+	if opened, err = newOpenedKey(plain); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.opened[keyID] = opened
+	c.mu.Unlock()
+	return opened, nil
+}
+
+func (c *Cipher) persistLocked() error {
+	keys := make([]*DataKey, 0, len(c.keys))
+	for _, key := range c.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].JobID < keys[j].JobID })
+	raw, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := common.AtomicWriteFile(c.path, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write payload keyring: %w", err)
+	}
+	return nil
+}
+
+// digestLabel separates the digest key derived from a data key from its encryption use.
+const digestLabel = "nebula payload digest v1"
+
+func newOpenedKey(key []byte) (*openedKey, error) {
+	if len(key) != 32 {
+		return nil, errors.New("data keys must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(digestLabel))
+	return &openedKey{aead: aead, digestKey: mac.Sum(nil)}, nil
+}
+
+func (k *openedKey) digest(payload []byte) string {
+	mac := hmac.New(sha256.New, k.digestKey)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
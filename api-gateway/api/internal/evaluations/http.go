@@ -0,0 +1,152 @@
+package evaluations
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// runRecorders are the roles allowed to record evaluation runs.
+var runRecorders = []common.Role{common.RoleValidator, common.RoleAggregator, common.RoleCentralChecker}
+
+// HTTPHandler exposes the evaluation endpoints.
+type HTTPHandler struct {
+	svc   *Service
+	store registry.Store
+}
+
+// NewHTTPHandler wires an evaluations HTTP handler.
+func NewHTTPHandler(svc *Service, store registry.Store) *HTTPHandler {
+	return &HTTPHandler{svc: svc, store: store}
+}
+
+// RegisterRoutes mounts the evaluation endpoints; callers authenticate with runtime EdDSA tokens.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	keyFunc := registry.TrainerKeyFunc(h.store)
+	mux.Handle("/evaluations", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleCollection)))
+	mux.Handle("/evaluations/consensus", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleConsensus)))
+	mux.Handle("/evaluations/runs", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleRuns)))
+}
+
+// Describe documents the evaluation endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("evaluations")
+	const runtimeToken = "Requires a trainer runtime token (EdDSA) or a token from /auth/token."
+	modelID := openapi.Param{Name: "model_id", Description: "Evaluated model", Required: true}
+	api.Add(http.MethodPost, "/evaluations", openapi.Operation{Summary: "Submit a model evaluation", Description: runtimeToken + " Only validators may submit.", Body: SubmitRequest{}, Response: Evaluation{}, Status: http.StatusCreated, Errors: []int{http.StatusForbidden, http.StatusConflict}})
+	api.Add(http.MethodGet, "/evaluations", openapi.Operation{Summary: "List the evaluations of a model", Description: runtimeToken, Query: []openapi.Param{modelID}, Response: map[string]any{"model_id": "", "items": []*Evaluation{}}})
+	api.Add(http.MethodPost, "/evaluations/runs", openapi.Operation{
+		Summary:     "Record an evaluation run",
+		Description: runtimeToken + " Validators, aggregators and central checkers may record runs; each call appends to the model's history.",
+		Roles:       runRecorders,
+		Body:        RunRequest{},
+		Response:    Run{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	api.Add(http.MethodGet, "/evaluations/runs", openapi.Operation{Summary: "List the evaluation history of a model, oldest first", Query: []openapi.Param{modelID}, Response: map[string]any{"model_id": "", "items": []*Run{}}})
+	api.Add(http.MethodGet, "/evaluations/consensus", openapi.Operation{Summary: "Summarise validator agreement on a model", Description: runtimeToken, Query: []openapi.Param{modelID, {Name: "metric", Description: "Metric the decision is based on"}}, Response: Consensus{}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleValidator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only validators can submit evaluations"))
+			return
+		}
+		var req SubmitRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Submit(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	case http.MethodGet:
+		modelID := strings.TrimSpace(r.URL.Query().Get("model_id"))
+		records, err := h.svc.List(r.Context(), authCtx, modelID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"model_id": modelID, "items": records})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleConsensus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	query := r.URL.Query()
+	result, err := h.svc.Consensus(r.Context(), authCtx, query.Get("model_id"), query.Get("metric"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, result)
+}
+
+func (h *HTTPHandler) handleRuns(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(runRecorders...) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only validators, aggregators and central checkers can record evaluation runs"))
+			return
+		}
+		var req RunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		run, err := h.svc.RecordRun(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, run)
+	case http.MethodGet:
+		modelID := strings.TrimSpace(r.URL.Query().Get("model_id"))
+		runs, err := h.svc.Runs(r.Context(), authCtx, modelID)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"model_id": modelID, "items": runs})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,293 @@
+package evaluations
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service records independent model evaluations and resolves their consensus score.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs an evaluations service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// SubmitRequest is an evaluation signed by the validator's registered Ed25519 key.
+// The signature covers the JSON document {"model_id","dataset_id","metrics"} with
+// metric names sorted, exactly as encoding/json marshals it.
+type SubmitRequest struct {
+	ModelID   string             `json:"model_id"`
+	DatasetID string             `json:"dataset_id"`
+	Metrics   map[string]float64 `json:"metrics"`
+	Signature string             `json:"signature"`
+}
+
+// Evaluation is a single validator result stored on-chain.
+type Evaluation struct {
+	ModelID     string             `json:"model_id"`
+	DatasetID   string             `json:"dataset_id"`
+	Evaluator   string             `json:"evaluator"`
+	Metrics     map[string]float64 `json:"metrics"`
+	Signature   string             `json:"signature"`
+	SubmittedAt string             `json:"submitted_at"`
+}
+
+// MetricSummary aggregates one metric across validators.
+type MetricSummary struct {
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Count  int     `json:"count"`
+}
+
+// Consensus is the aggregated view of every evaluation for a model.
+type Consensus struct {
+	ModelID    string                    `json:"model_id"`
+	Metric     string                    `json:"metric"`
+	Score      float64                   `json:"score"`
+	Count      int                       `json:"count"`
+	Quorum     int                       `json:"quorum"`
+	MinScore   float64                   `json:"min_score"`
+	Accepted   bool                      `json:"accepted"`
+	Evaluators []string                  `json:"evaluators"`
+	Datasets   []string                  `json:"datasets"`
+	Metrics    map[string]*MetricSummary `json:"metrics"`
+}
+
+// RunRequest records one evaluation run. DatasetHash is the hex SHA-256 of the evaluation
+// dataset, so the run can be reproduced and compared with other runs on the same data.
+type RunRequest struct {
+	ModelID     string             `json:"model_id"`
+	DatasetHash string             `json:"dataset_hash"`
+	Metrics     map[string]float64 `json:"metrics"`
+}
+
+// Run is one entry of a model's on-chain evaluation history. The layer, scope, job and round
+// are copied from the model so the history explains a selection on its own.
+type Run struct {
+	RunID       string             `json:"run_id"`
+	ModelID     string             `json:"model_id"`
+	Layer       string             `json:"layer"`
+	ScopeID     string             `json:"scope_id"`
+	JobID       string             `json:"job_id,omitempty"`
+	Round       int                `json:"round,omitempty"`
+	DatasetHash string             `json:"dataset_hash"`
+	Metrics     map[string]float64 `json:"metrics"`
+	Evaluator   string             `json:"evaluator"`
+	EvaluatedAt string             `json:"evaluated_at"`
+}
+
+// Submit records an evaluation signed by the calling validator.
+func (s *Service) Submit(ctx context.Context, authCtx *common.AuthContext, req *SubmitRequest) (*Evaluation, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID := strings.TrimSpace(req.ModelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	datasetID := strings.TrimSpace(req.DatasetID)
+	if datasetID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "dataset_id is required")
+	}
+	if len(req.Metrics) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "metrics are required")
+	}
+	if strings.TrimSpace(req.Signature) == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "signature is required")
+	}
+	enrolment, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
+	if peerName == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"SubmitEvaluation", modelID, datasetID, common.MustJSON(req.Metrics), req.Signature}
+	if _, err := s.fabric.InvokeChaincode(ctx, peerName, enrolment.FabricClientID, args); err != nil {
+		return nil, err
+	}
+	records, err := s.List(ctx, authCtx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Evaluator == enrolment.NodeID {
+			return record, nil
+		}
+	}
+	return nil, common.NewStatusError(http.StatusInternalServerError, "evaluation committed but not found on ledger")
+}
+
+// List returns every evaluation recorded for a model.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, modelID string) ([]*Evaluation, error) {
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"ListEvaluations", modelID})
+	if err != nil {
+		return nil, err
+	}
+	var records []*Evaluation
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RecordRun appends an evaluation run by the calling node to the model's history. Unlike
+// Submit it needs no signature and may be repeated; runs do not count toward the consensus.
+func (s *Service) RecordRun(ctx context.Context, authCtx *common.AuthContext, req *RunRequest) (*Run, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID := strings.TrimSpace(req.ModelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	datasetHash := strings.ToLower(strings.TrimSpace(req.DatasetHash))
+	if decoded, err := hex.DecodeString(datasetHash); err != nil || len(decoded) != 32 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "dataset_hash must be a hex SHA-256 digest")
+	}
+	if len(req.Metrics) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "metrics are required")
+	}
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return nil, err
+	}
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
+	if peerName == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"RecordEvaluationRun", modelID, datasetHash, common.MustJSON(req.Metrics)}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peerName, identity, args)
+	if err != nil {
+		return nil, mapRunError(err)
+	}
+	var run Run
+	if err := json.Unmarshal(raw, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Runs returns the evaluation history of a model, oldest first. The history is public
+// on-chain, so callers without an enrolled identity read it as the gateway admin.
+func (s *Service) Runs(ctx context.Context, authCtx *common.AuthContext, modelID string) ([]*Run, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	identity := s.cfg.AdminIdentity
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			identity = rec.FabricClientID
+		}
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"ListEvaluationRuns", modelID})
+	if err != nil {
+		return nil, mapRunError(err)
+	}
+	var runs []*Run
+	if err := json.Unmarshal(raw, &runs); err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []*Run{}
+	}
+	return runs, nil
+}
+
+// Consensus resolves the consensus score for a model and checks it against the configured quorum.
+func (s *Service) Consensus(ctx context.Context, authCtx *common.AuthContext, modelID, metric string) (*Consensus, error) {
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return nil, err
+	}
+	return s.consensusAs(ctx, identity, modelID, metric)
+}
+
+// RequireAccepted fails unless the model's consensus satisfies the configured quorum and minimum score.
+// It is used by promotion and convergence decisions that reference a model.
+func (s *Service) RequireAccepted(ctx context.Context, identity, modelID string) (*Consensus, error) {
+	consensus, err := s.consensusAs(ctx, identity, modelID, "")
+	if err != nil {
+		return nil, err
+	}
+	if !consensus.Accepted {
+		return consensus, common.NewStatusError(http.StatusConflict, fmt.Sprintf(
+			"model %s has %d evaluation(s) with %s consensus %.4f; requires %d evaluation(s) and score >= %.4f",
+			consensus.ModelID, consensus.Count, consensus.Metric, consensus.Score, consensus.Quorum, consensus.MinScore))
+	}
+	return consensus, nil
+}
+
+func (s *Service) consensusAs(ctx context.Context, identity, modelID, metric string) (*Consensus, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	metric = strings.TrimSpace(metric)
+	if metric == "" {
+		metric = s.cfg.EvaluationMetric
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"GetEvaluationConsensus", modelID, metric})
+	if err != nil {
+		return nil, err
+	}
+	var consensus Consensus
+	if err := json.Unmarshal(raw, &consensus); err != nil {
+		return nil, err
+	}
+	consensus.Quorum = s.cfg.EvaluationQuorum
+	consensus.MinScore = s.cfg.EvaluationMinScore
+	consensus.Accepted = consensus.Count >= consensus.Quorum && consensus.Score >= consensus.MinScore
+	return &consensus, nil
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) (string, error) {
+	if authCtx == nil {
+		return "", common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return "", common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	return rec.FabricClientID, nil
+}
+
+func mapRunError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "is not permitted to call"), strings.Contains(msg, "trainer not authorized"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"), strings.Contains(msg, "invalid metrics"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
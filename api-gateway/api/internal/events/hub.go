@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// BlockEvent announces that the channel grew to a new height.
+type BlockEvent struct {
+	Channel    string `json:"channel"`
+	Height     uint64 `json:"height"`
+	BlockHash  string `json:"block_hash"`
+	ObservedAt string `json:"observed_at"`
+}
+
+// Hub watches the channel height and fans block notifications out to subscribers.
+// A single watcher serves every stream, so the peers see one poll per interval no
+// matter how many clients are connected.
+type Hub struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+
+	mu          sync.Mutex
+	subscribers map[chan *BlockEvent]struct{}
+	last        *BlockEvent
+}
+
+// NewHub constructs an event hub.
+func NewHub(cfg *common.Config, fabric *common.FabricClient) *Hub {
+	return &Hub{cfg: cfg, fabric: fabric, subscribers: map[chan *BlockEvent]struct{}{}}
+}
+
+// Subscribe registers a listener. The returned cancel function must be called once the
+// listener is done.
+func (h *Hub) Subscribe() (<-chan *BlockEvent, func()) {
+	ch := make(chan *BlockEvent, 8)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Latest returns the most recently observed block, if any.
+func (h *Hub) Latest() *BlockEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.last
+}
+
+// Run polls the channel height until the context is cancelled. Polling pauses while nobody
+// is subscribed.
+func (h *Hub) Run(ctx context.Context) {
+	interval := h.cfg.EventsPollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if h.subscriberCount() == 0 {
+				continue
+			}
+			if err := h.poll(ctx); err != nil {
+				log.Printf("block watcher: %v", err)
+			}
+		}
+	}
+}
+
+func (h *Hub) poll(ctx context.Context) error {
+	info, err := h.fabric.ChannelInfo(ctx, h.fabric.SelectPeer(common.PeerRead))
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.last != nil && h.last.Height >= info.Height {
+		return nil
+	}
+	event := &BlockEvent{
+		Channel:    h.cfg.Target(ctx).Channel,
+		Height:     info.Height,
+		BlockHash:  info.CurrentBlockHash,
+		ObservedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	h.last = event
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber; it will pick up the state on the next block.
+		}
+	}
+	return nil
+}
+
+func (h *Hub) subscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the training-run export to admins.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the export HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// CSV tables selected with the `table` query parameter.
+const (
+	TableRounds       = "rounds"
+	TableSubmissions  = "submissions"
+	TableDeclarations = "declarations"
+)
+
+// RegisterRoutes mounts `/admin/export/rounds/{jobId}`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/export/rounds/", auth.RequireAuth(http.HandlerFunc(h.handleRounds), common.RoleAdmin))
+}
+
+// Describe documents the export endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("export")
+	api.Add(http.MethodGet, "/admin/export/rounds/{jobId}", openapi.Operation{
+		Summary:     "Export a job's training run",
+		Description: "Walks the ledger for every round of the job: its timings, the models committed to it, the aggregations recorded for it, and the state and nation convergence declarations of each round number. The output contains only ledger data in a fixed order, so the same ledger always exports the same bytes. `format=csv` returns one table, chosen with `table`.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Query: []openapi.Param{
+			{Name: "format", Description: "json (default) or csv."},
+			{Name: "table", Description: "CSV table: rounds (default; one row per round with its duration and submission timings), submissions (one row per committed model) or declarations."},
+		},
+		Response: Dataset{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+}
+
+func (h *HTTPHandler) handleRounds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	jobID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/export/rounds/"), "/")
+	if jobID == "" || strings.Contains(jobID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	format := strings.ToLower(strings.TrimSpace(query.Get("format")))
+	table := strings.ToLower(strings.TrimSpace(query.Get("table")))
+	switch format {
+	case "", "json":
+		if table != "" {
+			writeServiceError(w, common.NewStatusError(http.StatusBadRequest, "table requires format=csv"))
+			return
+		}
+	case "csv":
+		if table == "" {
+			table = TableRounds
+		}
+		if table != TableRounds && table != TableSubmissions && table != TableDeclarations {
+			writeServiceError(w, common.NewStatusError(http.StatusBadRequest, "table must be rounds, submissions or declarations"))
+			return
+		}
+	default:
+		writeServiceError(w, common.NewStatusError(http.StatusBadRequest, "format must be json or csv"))
+		return
+	}
+	dataset, err := h.svc.Rounds(r.Context(), jobID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if format != "csv" {
+		common.WriteJSON(w, http.StatusOK, dataset)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", dataset.JobID+"-"+table+".csv"))
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	_ = writer.WriteAll(csvRows(dataset, table))
+}
+
+func csvRows(dataset *Dataset, table string) [][]string {
+	switch table {
+	case TableSubmissions:
+		rows := [][]string{{"layer", "scope_id", "round", "model_id", "owner", "submitted_at", "offset_seconds", "model_hash", "parent_model_ids", "metrics"}}
+		for _, round := range dataset.Rounds {
+			for _, submission := range round.Submissions {
+				rows = append(rows, []string{
+					round.Layer, round.ScopeID, strconv.Itoa(round.Round),
+					submission.ModelID, submission.Owner, submission.SubmittedAt, formatSeconds(submission.OffsetSeconds),
+					submission.ModelHash, strings.Join(submission.ParentModelIDs, ";"), formatMetrics(submission.Metrics),
+				})
+			}
+		}
+		return rows
+	case TableDeclarations:
+		rows := [][]string{{"round", "scope", "target_id", "reports", "declared_by", "declared_at"}}
+		for _, declaration := range dataset.Declarations {
+			rows = append(rows, []string{
+				strconv.Itoa(declaration.Round), declaration.Scope, declaration.TargetID,
+				strconv.Itoa(declaration.Reports), declaration.DeclaredBy, declaration.DeclaredAt,
+			})
+		}
+		return rows
+	}
+	rows := [][]string{{"layer", "scope_id", "round", "status", "started_at", "closed_at", "duration_seconds", "submissions", "submitters", "first_submission_seconds", "last_submission_seconds", "aggregations"}}
+	for _, round := range dataset.Rounds {
+		submitters := map[string]bool{}
+		var first, last *float64
+		for _, submission := range round.Submissions {
+			submitters[submission.Owner] = true
+			if first == nil {
+				first = submission.OffsetSeconds
+			}
+			last = submission.OffsetSeconds
+		}
+		rows = append(rows, []string{
+			round.Layer, round.ScopeID, strconv.Itoa(round.Round), round.Status,
+			round.StartedAt, round.ClosedAt, formatSeconds(round.DurationSeconds),
+			strconv.Itoa(len(round.Submissions)), strconv.Itoa(len(submitters)),
+			formatSeconds(first), formatSeconds(last), strconv.Itoa(len(round.Aggregations)),
+		})
+	}
+	return rows
+}
+
+func formatSeconds(seconds *float64) string {
+	if seconds == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*seconds, 'f', -1, 64)
+}
+
+// formatMetrics renders metrics as name=value pairs in name order, separated by semicolons.
+func formatMetrics(metrics map[string]float64) string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.FormatFloat(metrics[name], 'f', -1, 64))
+	}
+	return strings.Join(pairs, ";")
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
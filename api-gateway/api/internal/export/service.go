@@ -0,0 +1,280 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/rounds"
+)
+
+// Service walks the ledger records of a training run and assembles them into a dataset for
+// offline analysis.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	rounds *rounds.Service
+}
+
+// NewService constructs an export service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, rounds *rounds.Service) *Service {
+	return &Service{cfg: cfg, fabric: fabric, rounds: rounds}
+}
+
+// Dataset is the full record of a job's training run. It holds only ledger data in a fixed
+// order, so exporting the same ledger twice yields identical output.
+type Dataset struct {
+	JobID        string         `json:"job_id"`
+	Rounds       []*Round       `json:"rounds"`
+	Declarations []*Declaration `json:"declarations"`
+}
+
+// Round is one round of a layer/scope with its timings, model submissions and aggregations.
+// DurationSeconds is set once the round is closed.
+type Round struct {
+	Layer           string         `json:"layer"`
+	ScopeID         string         `json:"scope_id"`
+	Round           int            `json:"round"`
+	Status          string         `json:"status"`
+	StartedBy       string         `json:"started_by"`
+	StartedAt       string         `json:"started_at"`
+	ClosedBy        string         `json:"closed_by,omitempty"`
+	ClosedAt        string         `json:"closed_at,omitempty"`
+	DurationSeconds *float64       `json:"duration_seconds,omitempty"`
+	Submissions     []*Submission  `json:"submissions"`
+	Aggregations    []*Aggregation `json:"aggregations"`
+}
+
+// Submission is a model committed to a round. OffsetSeconds is the time from the round's
+// start to the commit.
+type Submission struct {
+	ModelID        string             `json:"model_id"`
+	Owner          string             `json:"owner"`
+	SubmittedAt    string             `json:"submitted_at"`
+	OffsetSeconds  *float64           `json:"offset_seconds,omitempty"`
+	ParentModelIDs []string           `json:"parent_model_ids,omitempty"`
+	ModelHash      string             `json:"model_hash,omitempty"`
+	Metrics        map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Aggregation is an aggregation recorded for a round.
+type Aggregation struct {
+	OutputModelID string    `json:"output_model_id"`
+	InputModelIDs []string  `json:"input_model_ids"`
+	Algorithm     string    `json:"algorithm"`
+	Weights       []float64 `json:"weights,omitempty"`
+	Aggregator    string    `json:"aggregator"`
+	TxID          string    `json:"tx_id"`
+	RecordedAt    string    `json:"recorded_at"`
+}
+
+// Declaration is a convergence declaration of a job round: a state's, with the number of its
+// clusters that reported convergence, or the nation's, with the number of reporting states.
+type Declaration struct {
+	Round      int    `json:"round"`
+	Scope      string `json:"scope"`
+	TargetID   string `json:"target_id"`
+	Reports    int    `json:"reports"`
+	DeclaredBy string `json:"declared_by"`
+	DeclaredAt string `json:"declared_at"`
+}
+
+// Declaration scopes.
+const (
+	ScopeState  = "state"
+	ScopeNation = "nation"
+)
+
+type ledgerModel struct {
+	ID             string             `json:"id"`
+	Owner          string             `json:"owner"`
+	SubmittedAt    string             `json:"submitted_at"`
+	ParentModelIDs []string           `json:"parent_model_ids"`
+	ModelHash      string             `json:"model_hash"`
+	Metrics        map[string]float64 `json:"metrics"`
+}
+
+type ledgerSummary struct {
+	DeclaredBy string `json:"declared_by"`
+	DeclaredAt string `json:"declared_at"`
+}
+
+type ledgerStateConvergence struct {
+	StateID  string                     `json:"state_id"`
+	Clusters map[string]json.RawMessage `json:"clusters"`
+	Summary  *ledgerSummary             `json:"summary"`
+}
+
+type ledgerNationConvergence struct {
+	States  map[string]json.RawMessage `json:"states"`
+	Summary *ledgerSummary             `json:"summary"`
+}
+
+// Rounds exports every round of jobID. Rounds are ordered by
+// layer, scope and round; submissions by commit time and model ID; declarations by round, scope
+// and target.
+func (s *Service) Rounds(ctx context.Context, jobID string) (*Dataset, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "job_id is required")
+	}
+	ledgerRounds, err := s.rounds.List(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ledgerRounds) == 0 {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("no rounds recorded for job %s", jobID))
+	}
+	dataset := &Dataset{JobID: jobID, Rounds: make([]*Round, 0, len(ledgerRounds)), Declarations: []*Declaration{}}
+	numbers := map[int]bool{}
+	for _, ledgerRound := range ledgerRounds {
+		round, err := s.round(ctx, jobID, ledgerRound)
+		if err != nil {
+			return nil, err
+		}
+		dataset.Rounds = append(dataset.Rounds, round)
+		numbers[round.Round] = true
+	}
+	sort.Slice(dataset.Rounds, func(i, j int) bool {
+		a, b := dataset.Rounds[i], dataset.Rounds[j]
+		if a.Layer != b.Layer {
+			return a.Layer < b.Layer
+		}
+		if a.ScopeID != b.ScopeID {
+			return a.ScopeID < b.ScopeID
+		}
+		return a.Round < b.Round
+	})
+	for number := range numbers {
+		declarations, err := s.declarations(ctx, jobID, number)
+		if err != nil {
+			return nil, err
+		}
+		dataset.Declarations = append(dataset.Declarations, declarations...)
+	}
+	sort.Slice(dataset.Declarations, func(i, j int) bool {
+		a, b := dataset.Declarations[i], dataset.Declarations[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.Scope != b.Scope {
+			return a.Scope == ScopeState
+		}
+		return a.TargetID < b.TargetID
+	})
+	return dataset, nil
+}
+
+func (s *Service) round(ctx context.Context, jobID string, ledgerRound *rounds.Round) (*Round, error) {
+	round := &Round{
+		Layer:           ledgerRound.Layer,
+		ScopeID:         ledgerRound.ScopeID,
+		Round:           ledgerRound.Round,
+		Status:          ledgerRound.Status,
+		StartedBy:       ledgerRound.StartedBy,
+		StartedAt:       ledgerRound.StartedAt,
+		ClosedBy:        ledgerRound.ClosedBy,
+		ClosedAt:        ledgerRound.ClosedAt,
+		DurationSeconds: secondsBetween(ledgerRound.StartedAt, ledgerRound.ClosedAt),
+		Submissions:     []*Submission{},
+		Aggregations:    []*Aggregation{},
+	}
+	args := []string{jobID, round.Layer, round.ScopeID, strconv.Itoa(round.Round)}
+	var models []*ledgerModel
+	if err := s.query(ctx, append([]string{"ListRoundModels"}, args...), &models); err != nil {
+		return nil, err
+	}
+	for _, model := range models {
+		round.Submissions = append(round.Submissions, &Submission{
+			ModelID:        model.ID,
+			Owner:          model.Owner,
+			SubmittedAt:    model.SubmittedAt,
+			OffsetSeconds:  secondsBetween(round.StartedAt, model.SubmittedAt),
+			ParentModelIDs: model.ParentModelIDs,
+			ModelHash:      model.ModelHash,
+			Metrics:        model.Metrics,
+		})
+	}
+	sort.Slice(round.Submissions, func(i, j int) bool {
+		a, b := round.Submissions[i], round.Submissions[j]
+		if a.SubmittedAt != b.SubmittedAt {
+			return a.SubmittedAt < b.SubmittedAt
+		}
+		return a.ModelID < b.ModelID
+	})
+	if err := s.query(ctx, append([]string{"ListAggregations"}, args...), &round.Aggregations); err != nil {
+		return nil, err
+	}
+	if round.Aggregations == nil {
+		round.Aggregations = []*Aggregation{}
+	}
+	return round, nil
+}
+
+func (s *Service) declarations(ctx context.Context, jobID string, round int) ([]*Declaration, error) {
+	args := []string{jobID, strconv.Itoa(round)}
+	var states map[string]*ledgerStateConvergence
+	if err := s.query(ctx, append([]string{"ListStateConvergenceInRound"}, args...), &states); err != nil {
+		return nil, err
+	}
+	declarations := make([]*Declaration, 0, len(states)+1)
+	for stateID, state := range states {
+		if state == nil || state.Summary == nil {
+			continue
+		}
+		declarations = append(declarations, &Declaration{
+			Round:      round,
+			Scope:      ScopeState,
+			TargetID:   stateID,
+			Reports:    len(state.Clusters),
+			DeclaredBy: state.Summary.DeclaredBy,
+			DeclaredAt: state.Summary.DeclaredAt,
+		})
+	}
+	var nation ledgerNationConvergence
+	if err := s.query(ctx, append([]string{"ListNationConvergenceInRound"}, args...), &nation); err != nil {
+		return nil, err
+	}
+	if nation.Summary != nil {
+		declarations = append(declarations, &Declaration{
+			Round:      round,
+			Scope:      ScopeNation,
+			TargetID:   ScopeNation,
+			Reports:    len(nation.States),
+			DeclaredBy: nation.Summary.DeclaredBy,
+			DeclaredAt: nation.Summary.DeclaredAt,
+		})
+	}
+	return declarations, nil
+}
+
+func (s *Service) query(ctx context.Context, args []string, out any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, args)
+	if err != nil {
+		return err
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// secondsBetween returns the seconds from start to end, or nil when either is missing.
+func secondsBetween(start, end string) *float64 {
+	from, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return nil
+	}
+	to, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return nil
+	}
+	seconds := to.Sub(from).Seconds()
+	return &seconds
+}
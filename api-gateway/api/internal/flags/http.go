@@ -0,0 +1,220 @@
+package flags
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the `/flags` endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the flags HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+var (
+	flaggers = []common.Role{common.RoleAggregator, common.RoleValidator, common.RoleCentralChecker, common.RoleAdmin}
+	readers  = []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleValidator, common.RoleCentralChecker, common.RoleAdmin}
+)
+
+// RegisterRoutes mounts `/flags` (flag and list), `/flags/threshold` and `/flags/tallies`
+// with the per-node `/flags/tallies/{nodeId}` and its `/reinstate` action.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/flags", auth.RequireAuth(http.HandlerFunc(h.handleCollection), readers...))
+	mux.Handle("/flags/threshold", auth.RequireAuth(http.HandlerFunc(h.handleThreshold), readers...))
+	mux.Handle("/flags/tallies", auth.RequireAuth(http.HandlerFunc(h.handleTallies), readers...))
+	mux.Handle("/flags/tallies/", auth.RequireAuth(http.HandlerFunc(h.handleTally), readers...))
+}
+
+// Describe documents the flag endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("flags")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodPost, "/flags", openapi.Operation{
+		Summary:     "Flag a model submission as suspected malicious",
+		Description: "The flag counts against the model's owner. Each node can flag a model once and never its own. When the owner's count reaches the threshold it is suspended and every trainer-gated transaction it sends is rejected until an admin reinstates it.",
+		Roles:       flaggers,
+		Body:        FlagRequest{},
+		Response:    Flag{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/flags", openapi.Operation{
+		Summary: "List the flags raised against a node",
+		Roles:   readers,
+		Query: []openapi.Param{
+			{Name: "node_id", Description: "Required. The owner of the flagged models."},
+			{Name: "model_id", Description: "Narrows the listing to one model."},
+		},
+		Response: map[string]any{"items": []*Flag{}},
+	})
+	api.Add(http.MethodGet, "/flags/threshold", openapi.Operation{Summary: "Read the suspension threshold", Roles: readers, Response: Policy{}})
+	api.Add(http.MethodPut, "/flags/threshold", openapi.Operation{Summary: "Change the suspension threshold", Description: "0 disables suspension.", Roles: admin, Body: ThresholdRequest{}, Response: Policy{}})
+	api.Add(http.MethodGet, "/flags/tallies", openapi.Operation{Summary: "List the flag tallies of every flagged node", Roles: readers, Response: map[string]any{"items": []*Tally{}}})
+	api.Add(http.MethodGet, "/flags/tallies/{node_id}", openapi.Operation{Summary: "Read a node's flag tally", Roles: readers, Response: Tally{}})
+	api.Add(http.MethodPost, "/flags/tallies/{node_id}/reinstate", openapi.Operation{
+		Summary:     "Lift a node's suspension",
+		Description: "Restarts the node's count towards the threshold; its flags stay on the ledger.",
+		Roles:       admin,
+		Body:        ReinstateRequest{},
+		Response:    Tally{},
+		Errors:      []int{http.StatusConflict},
+	})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !hasRole(authCtx, flaggers) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "trainers cannot flag models"))
+			return
+		}
+		var req FlagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		flag, err := h.svc.Flag(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, flag)
+	case http.MethodGet:
+		query := r.URL.Query()
+		flags, err := h.svc.List(r.Context(), authCtx, query.Get("node_id"), query.Get("model_id"))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": flags})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleThreshold(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := h.svc.Policy(r.Context(), authCtx)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, policy)
+	case http.MethodPut:
+		if !authCtx.Permits(common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only admins can change the flag threshold"))
+			return
+		}
+		var req ThresholdRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		policy, err := h.svc.SetThreshold(r.Context(), &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, policy)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleTallies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	tallies, err := h.svc.Tallies(r.Context(), authCtx)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": tallies})
+}
+
+// handleTally serves `/flags/tallies/{nodeId}` and `/flags/tallies/{nodeId}/reinstate`.
+func (h *HTTPHandler) handleTally(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	nodeID := strings.TrimPrefix(r.URL.Path, "/flags/tallies/")
+	if node, found := strings.CutSuffix(nodeID, "/reinstate"); found {
+		if r.Method != http.MethodPost {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		if !authCtx.Permits(common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only admins can reinstate nodes"))
+			return
+		}
+		var req ReinstateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		tally, err := h.svc.Reinstate(r.Context(), node, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, tally)
+		return
+	}
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	tally, err := h.svc.Tally(r.Context(), authCtx, nodeID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, tally)
+}
+
+func hasRole(authCtx *common.AuthContext, roles []common.Role) bool {
+	for _, role := range roles {
+		if authCtx.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,230 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service flags suspected-malicious model submissions and manages the per-node tallies that
+// suspend repeat offenders.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a flags service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Flag mirrors the on-chain ModelFlag. NodeID is the owner of the flagged model.
+type Flag struct {
+	ModelID      string `json:"model_id"`
+	NodeID       string `json:"node_id"`
+	Reason       string `json:"reason"`
+	EvidenceHash string `json:"evidence_hash,omitempty"`
+	FlaggedBy    string `json:"flagged_by"`
+	TxID         string `json:"tx_id"`
+	FlaggedAt    string `json:"flagged_at"`
+}
+
+// Tally mirrors the on-chain FlagTally. Flags counts towards the suspension threshold and
+// restarts on reinstatement; TotalFlags never does.
+type Tally struct {
+	NodeID          string `json:"node_id"`
+	Flags           int    `json:"flags"`
+	TotalFlags      int    `json:"total_flags"`
+	Suspended       bool   `json:"suspended"`
+	SuspendedAt     string `json:"suspended_at,omitempty"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedBy string `json:"status_changed_by,omitempty"`
+	UpdatedAt       string `json:"updated_at,omitempty"`
+}
+
+// Policy mirrors the on-chain FlagPolicy. A threshold of 0 disables suspension.
+type Policy struct {
+	Threshold int    `json:"threshold"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// FlagRequest is the payload for flagging a model. EvidenceHash optionally identifies
+// off-chain evidence, such as the digest of an evaluation report.
+type FlagRequest struct {
+	ModelID      string `json:"model_id"`
+	Reason       string `json:"reason"`
+	EvidenceHash string `json:"evidence_hash,omitempty"`
+}
+
+// ReinstateRequest is the payload for lifting a suspension.
+type ReinstateRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// ThresholdRequest is the payload for changing the suspension threshold.
+type ThresholdRequest struct {
+	Threshold *int `json:"threshold"`
+}
+
+// Flag records the caller's suspicion of a model. Enrolled callers sign with their own
+// identity, so one node can flag a model once; others sign with the admin identity.
+func (s *Service) Flag(ctx context.Context, authCtx *common.AuthContext, req *FlagRequest) (*Flag, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID := strings.TrimSpace(req.ModelID)
+	reason := strings.TrimSpace(req.Reason)
+	switch {
+	case modelID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	case reason == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "reason is required")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"FlagModel", modelID, reason, strings.TrimSpace(req.EvidenceHash)}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.identityFor(authCtx), args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var flag Flag
+	if err := json.Unmarshal(raw, &flag); err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List returns the flags raised against a node's models, optionally narrowed to one model.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, nodeID, modelID string) ([]*Flag, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "node_id is required")
+	}
+	var flags []*Flag
+	if err := s.query(ctx, authCtx, []string{"ListModelFlags", nodeID, strings.TrimSpace(modelID)}, &flags); err != nil {
+		return nil, err
+	}
+	if flags == nil {
+		flags = []*Flag{}
+	}
+	return flags, nil
+}
+
+// Tally returns a node's flag tally.
+func (s *Service) Tally(ctx context.Context, authCtx *common.AuthContext, nodeID string) (*Tally, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "node_id is required")
+	}
+	var tally Tally
+	if err := s.query(ctx, authCtx, []string{"GetFlagTally", nodeID}, &tally); err != nil {
+		return nil, err
+	}
+	return &tally, nil
+}
+
+// Tallies returns the tallies of every flagged node.
+func (s *Service) Tallies(ctx context.Context, authCtx *common.AuthContext) ([]*Tally, error) {
+	var tallies []*Tally
+	if err := s.query(ctx, authCtx, []string{"ListFlagTallies"}, &tallies); err != nil {
+		return nil, err
+	}
+	if tallies == nil {
+		tallies = []*Tally{}
+	}
+	return tallies, nil
+}
+
+// Reinstate lifts a node's suspension, signed by the admin identity.
+func (s *Service) Reinstate(ctx context.Context, nodeID string, req *ReinstateRequest) (*Tally, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "node_id is required")
+	}
+	reason := ""
+	if req != nil {
+		reason = strings.TrimSpace(req.Reason)
+	}
+	var tally Tally
+	if err := s.submitAdmin(ctx, []string{"ReinstateNode", nodeID, reason}, &tally); err != nil {
+		return nil, err
+	}
+	return &tally, nil
+}
+
+// Policy returns the suspension threshold in force.
+func (s *Service) Policy(ctx context.Context, authCtx *common.AuthContext) (*Policy, error) {
+	var policy Policy
+	if err := s.query(ctx, authCtx, []string{"GetFlagPolicy"}, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetThreshold changes the suspension threshold, signed by the admin identity.
+func (s *Service) SetThreshold(ctx context.Context, req *ThresholdRequest) (*Policy, error) {
+	if req == nil || req.Threshold == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "threshold is required")
+	}
+	if *req.Threshold < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "threshold must be a non-negative integer")
+	}
+	var policy Policy
+	if err := s.submitAdmin(ctx, []string{"SetFlagThreshold", strconv.Itoa(*req.Threshold)}, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *Service) submitAdmin(ctx context.Context, args []string, target any) error {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) query(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "already flagged"), strings.Contains(msg, "is not suspended"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "cannot flag its own model"), strings.Contains(msg, "is not permitted to call"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the `/job-contract` endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the jobs HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+var readRoles = []common.Role{common.RoleAdmin, common.RoleCentralChecker, common.RoleAggregator, common.RoleTrainer, common.RoleValidator}
+
+// RegisterRoutes mounts job CRUD, lifecycle and training config endpoints. Every role may
+// read; only admins change jobs, and central checkers may also mark a job converged.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/job-contract/jobs", auth.RequireAuth(http.HandlerFunc(h.handleCollection), readRoles...))
+	mux.Handle("/job-contract/jobs/", auth.RequireAuth(http.HandlerFunc(h.handleJob), readRoles...))
+	mux.Handle("/job-contract/training-config", auth.RequireAuth(http.HandlerFunc(h.handleConfig), readRoles...))
+	mux.Handle("/job-contract/training-config/versions", auth.RequireAuth(http.HandlerFunc(h.handleConfigVersions), readRoles...))
+	mux.Handle("/job-contract/training-config/versions/", auth.RequireAuth(http.HandlerFunc(h.handleConfigVersion), readRoles...))
+	mux.Handle("/job-contract/training-config/history", auth.RequireAuth(http.HandlerFunc(h.handleConfigHistory), readRoles...))
+}
+
+// Describe documents the job endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("jobs")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodGet, "/job-contract/jobs", openapi.Operation{Summary: "List jobs", Roles: readRoles, Response: map[string]any{"items": []*Job{}}})
+	api.Add(http.MethodPost, "/job-contract/jobs", openapi.Operation{Summary: "Create a job", Roles: admin, Body: JobRequest{}, Response: Job{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/job-contract/jobs/{id}", openapi.Operation{Summary: "Read a job", Roles: readRoles, Response: Job{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPut, "/job-contract/jobs/{id}", openapi.Operation{Summary: "Update a job", Roles: admin, Body: JobRequest{}, Response: Job{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodDelete, "/job-contract/jobs/{id}", openapi.Operation{Summary: "Archive a job", Roles: admin, Response: Job{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/job-contract/jobs/{id}/start", openapi.Operation{Summary: "Start a job", Roles: admin, Response: Job{}, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodPost, "/job-contract/jobs/{id}/complete", openapi.Operation{Summary: "Mark a job converged", Roles: []common.Role{common.RoleAdmin, common.RoleCentralChecker}, Body: completeRequest{}, Response: Job{}, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodPost, "/job-contract/jobs/{id}/archive", openapi.Operation{Summary: "Archive a job", Roles: admin, Response: Job{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/job-contract/training-config", openapi.Operation{Summary: "Read a job's training config", Roles: readRoles, Query: []openapi.Param{{Name: "job_id", Description: "Defaults to GATEWAY_JOB_ID."}}, Response: TrainingConfig{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPut, "/job-contract/training-config", openapi.Operation{
+		Summary:     "Store a new version of a job's training config",
+		Description: "Allowed while the job is CREATED or CONFIGURED, and while it is RUNNING if the current version was stored with freeze=false. freeze defaults to true.",
+		Roles:       admin,
+		Body:        ConfigRequest{},
+		Response:    TrainingConfig{},
+		Errors:      []int{http.StatusConflict},
+	})
+	jobQuery := []openapi.Param{{Name: "job_id", Description: "Defaults to GATEWAY_JOB_ID."}}
+	api.Add(http.MethodGet, "/job-contract/training-config/versions", openapi.Operation{Summary: "List every version of a job's training config, oldest first", Roles: readRoles, Query: jobQuery, Response: map[string]any{"items": []*TrainingConfig{}}})
+	api.Add(http.MethodGet, "/job-contract/training-config/versions/{version}", openapi.Operation{Summary: "Read one version of a job's training config", Roles: readRoles, Query: jobQuery, Response: TrainingConfig{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/job-contract/training-config/history", openapi.Operation{Summary: "List every ledger write to a job's training config, oldest first", Roles: readRoles, Query: jobQuery, Response: map[string]any{"items": []*ConfigHistoryEntry{}}, Errors: []int{http.StatusNotFound}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jobs, err := h.svc.List(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": jobs})
+	case http.MethodPost:
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		var req JobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		job, err := h.svc.Create(r.Context(), &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, job)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleJob serves `/job-contract/jobs/{id}` and the lifecycle actions
+// `/job-contract/jobs/{id}/{start|complete|archive}`.
+func (h *HTTPHandler) handleJob(w http.ResponseWriter, r *http.Request) {
+	jobID, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/job-contract/jobs/"), "/")
+	if jobID == "" {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	if action != "" {
+		h.handleAction(w, r, jobID, action)
+		return
+	}
+	var (
+		job *Job
+		err error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		job, err = h.svc.Get(r.Context(), jobID)
+	case http.MethodPut:
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		var req JobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		job, err = h.svc.Update(r.Context(), jobID, &req)
+	case http.MethodDelete:
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		job, err = h.svc.Archive(r.Context(), jobID)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, job)
+}
+
+type completeRequest struct {
+	FinalModelID string `json:"final_model_id"`
+}
+
+func (h *HTTPHandler) handleAction(w http.ResponseWriter, r *http.Request, jobID, action string) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var (
+		job *Job
+		err error
+	)
+	switch action {
+	case "start":
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		job, err = h.svc.Start(r.Context(), jobID)
+	case "complete":
+		if !requireRole(w, r, common.RoleAdmin, common.RoleCentralChecker) {
+			return
+		}
+		var req completeRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		job, err = h.svc.Complete(r.Context(), jobID, req.FinalModelID)
+	case "archive":
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		job, err = h.svc.Archive(r.Context(), jobID)
+	default:
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, job)
+}
+
+func (h *HTTPHandler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		config, err := h.svc.Config(r.Context(), r.URL.Query().Get("job_id"))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, config)
+	case http.MethodPut:
+		if !requireRole(w, r, common.RoleAdmin) {
+			return
+		}
+		var req ConfigRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		config, err := h.svc.UpsertConfig(r.Context(), &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, config)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleConfigVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	versions, err := h.svc.ConfigVersions(r.Context(), r.URL.Query().Get("job_id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": versions})
+}
+
+func (h *HTTPHandler) handleConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	entries, err := h.svc.ConfigHistory(r.Context(), r.URL.Query().Get("job_id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": entries})
+}
+
+// handleConfigVersion serves `/job-contract/training-config/versions/{version}`.
+func (h *HTTPHandler) handleConfigVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/job-contract/training-config/versions/"))
+	if err != nil || version < 1 {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "version must be a positive integer"))
+		return
+	}
+	config, err := h.svc.ConfigVersion(r.Context(), r.URL.Query().Get("job_id"), version)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, config)
+}
+
+func requireRole(w http.ResponseWriter, r *http.Request, roles ...common.Role) bool {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return false
+	}
+	if !authCtx.Permits(roles...) {
+		common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "role "+string(authCtx.Role)+" is not permitted"))
+		return false
+	}
+	return true
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// CurrentConfigSchema is the newest training config schema_version the chaincode accepts.
+// Configs without schema_version are treated as version 1.
+const CurrentConfigSchema = 1
+
+// ConfigDocument is schema version 1 of a training config. The typed fields are shared by
+// every model family; anything else is family specific and belongs in Params. Top-level
+// fields the schema does not know are moved into Params by the chaincode.
+type ConfigDocument struct {
+	SchemaVersion int                        `json:"schema_version"`
+	Model         string                     `json:"model,omitempty"`
+	Rounds        int                        `json:"rounds,omitempty"`
+	LocalEpochs   int                        `json:"local_epochs,omitempty"`
+	BatchSize     int                        `json:"batch_size,omitempty"`
+	LearningRate  float64                    `json:"learning_rate,omitempty"`
+	Optimizer     string                     `json:"optimizer,omitempty"`
+	Aggregation   string                     `json:"aggregation,omitempty"`
+	MinClients    int                        `json:"min_clients,omitempty"`
+	Params        map[string]json.RawMessage `json:"params,omitempty"`
+}
+
+// validateConfig rejects configs the chaincode would refuse, so callers get a 400 without
+// a ledger round trip. The chaincode repeats every check.
+func validateConfig(raw json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return common.NewStatusError(http.StatusBadRequest, "config must be a JSON object")
+	}
+	var doc ConfigDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return common.NewStatusError(http.StatusBadRequest, "config does not match the training config schema: "+err.Error())
+	}
+	if _, ok := fields["schema_version"]; ok {
+		switch {
+		case doc.SchemaVersion < 1:
+			return common.NewStatusError(http.StatusBadRequest, "schema_version must be a positive integer")
+		case doc.SchemaVersion > CurrentConfigSchema:
+			return common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("training config schema_version %d is not supported; the newest is %d", doc.SchemaVersion, CurrentConfigSchema))
+		}
+	}
+	for name, value := range map[string]int{"rounds": doc.Rounds, "local_epochs": doc.LocalEpochs, "batch_size": doc.BatchSize, "min_clients": doc.MinClients} {
+		if _, ok := fields[name]; ok && value < 1 {
+			return common.NewStatusError(http.StatusBadRequest, name+" must be a positive integer")
+		}
+	}
+	for name, value := range map[string]string{"model": doc.Model, "optimizer": doc.Optimizer, "aggregation": doc.Aggregation} {
+		if _, ok := fields[name]; ok && strings.TrimSpace(value) == "" {
+			return common.NewStatusError(http.StatusBadRequest, name+" must be a non-empty string")
+		}
+	}
+	if _, ok := fields["learning_rate"]; ok && (doc.LearningRate <= 0 || math.IsInf(doc.LearningRate, 0)) {
+		return common.NewStatusError(http.StatusBadRequest, "learning_rate must be a positive number")
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Service manages training jobs and their configuration on the ledger. Lifecycle changes are
+// signed by the admin identity; the HTTP layer restricts who may request them.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	cache  *common.QueryCache
+}
+
+// NewService constructs a jobs service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric}
+}
+
+// Job mirrors the on-chain Job record.
+type Job struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Status       string `json:"status"`
+	CreatedBy    string `json:"created_by"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	ConfiguredAt string `json:"configured_at,omitempty"`
+	StartedAt    string `json:"started_at,omitempty"`
+	ConvergedAt  string `json:"converged_at,omitempty"`
+	ArchivedAt   string `json:"archived_at,omitempty"`
+	FinalModelID string `json:"final_model_id,omitempty"`
+}
+
+// TrainingConfig is one version of a job's configuration document. A frozen config cannot
+// change once the job is RUNNING.
+// SchemaVersion is the schema Config was validated against, 0 for configs stored before
+// schemas.
+type TrainingConfig struct {
+	JobID         string          `json:"job_id"`
+	Version       int             `json:"version"`
+	SchemaVersion int             `json:"schema_version,omitempty"`
+	Config        json.RawMessage `json:"config"`
+	Frozen        bool            `json:"frozen"`
+	UpdatedBy     string          `json:"updated_by"`
+	UpdatedAt     string          `json:"updated_at"`
+}
+
+// JobRequest is the payload for creating or updating a job.
+type JobRequest struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ConfigRequest is the payload for storing a training config. Config follows ConfigDocument.
+// An empty JobID selects the gateway's GATEWAY_JOB_ID. Freeze defaults to true; set it to false to allow new versions
+// while the job is RUNNING.
+type ConfigRequest struct {
+	JobID  string          `json:"job_id"`
+	Config json.RawMessage `json:"config"`
+	Freeze *bool           `json:"freeze,omitempty"`
+}
+
+// ConfigHistoryEntry is one committed write to a job's latest training config, from the
+// peer's history database. Config is empty for deletions.
+type ConfigHistoryEntry struct {
+	TxID      string          `json:"tx_id"`
+	Timestamp string          `json:"timestamp"`
+	IsDelete  bool            `json:"is_delete"`
+	Config    *TrainingConfig `json:"config,omitempty"`
+}
+
+type ledgerConfigHistoryEntry struct {
+	TxID      string                `json:"tx_id"`
+	Timestamp string                `json:"timestamp"`
+	IsDelete  bool                  `json:"is_delete"`
+	Record    *ledgerTrainingConfig `json:"record"`
+}
+
+type ledgerTrainingConfig struct {
+	JobID         string `json:"job_id"`
+	Version       int    `json:"version"`
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	Config        string `json:"config"`
+	Frozen        bool   `json:"frozen"`
+	UpdatedBy     string `json:"updated_by"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+func (l *ledgerTrainingConfig) toTrainingConfig() *TrainingConfig {
+	return &TrainingConfig{
+		JobID:         l.JobID,
+		Version:       l.Version,
+		SchemaVersion: l.SchemaVersion,
+		Config:        json.RawMessage(l.Config),
+		Frozen:        l.Frozen,
+		UpdatedBy:     l.UpdatedBy,
+		UpdatedAt:     l.UpdatedAt,
+	}
+}
+
+// EnableCache serves training configs from cache until they expire or are replaced.
+func (s *Service) EnableCache(cache *common.QueryCache) {
+	s.cache = cache
+}
+
+// Create registers a job in CREATED status.
+func (s *Service) Create(ctx context.Context, req *JobRequest) (*Job, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	jobID := strings.TrimSpace(req.ID)
+	if jobID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "id is required")
+	}
+	return s.submitJob(ctx, []string{"CreateJob", jobID, strings.TrimSpace(req.Name), strings.TrimSpace(req.Description)})
+}
+
+// Update changes a job's name and description.
+func (s *Service) Update(ctx context.Context, jobID string, req *JobRequest) (*Job, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	return s.submitJob(ctx, []string{"UpdateJob", strings.TrimSpace(jobID), strings.TrimSpace(req.Name), strings.TrimSpace(req.Description)})
+}
+
+// Start moves a CONFIGURED job to RUNNING.
+func (s *Service) Start(ctx context.Context, jobID string) (*Job, error) {
+	return s.submitJob(ctx, []string{"StartJob", strings.TrimSpace(jobID)})
+}
+
+// Complete moves a RUNNING job to CONVERGED, optionally naming the final model.
+func (s *Service) Complete(ctx context.Context, jobID, finalModelID string) (*Job, error) {
+	return s.submitJob(ctx, []string{"CompleteJob", strings.TrimSpace(jobID), strings.TrimSpace(finalModelID)})
+}
+
+// Archive retires a job that has converged or never started.
+func (s *Service) Archive(ctx context.Context, jobID string) (*Job, error) {
+	return s.submitJob(ctx, []string{"ArchiveJob", strings.TrimSpace(jobID)})
+}
+
+// Get returns a single job.
+func (s *Service) Get(ctx context.Context, jobID string) (*Job, error) {
+	var job Job
+	if err := s.query(ctx, []string{"ReadJob", strings.TrimSpace(jobID)}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns every job.
+func (s *Service) List(ctx context.Context) ([]*Job, error) {
+	var jobs []*Job
+	if err := s.query(ctx, []string{"ListJobs"}, &jobs); err != nil {
+		return nil, err
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	return jobs, nil
+}
+
+// UpsertConfig stores a new version of a job's training config. The job must be CREATED or
+// CONFIGURED, or RUNNING with an unfrozen config.
+func (s *Service) UpsertConfig(ctx context.Context, req *ConfigRequest) (*TrainingConfig, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	if len(req.Config) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "config is required")
+	}
+	if err := validateConfig(req.Config); err != nil {
+		return nil, err
+	}
+	args := []string{"UpsertTrainingConfig", s.jobIDOrDefault(req.JobID), string(req.Config)}
+	if req.Freeze != nil {
+		args = []string{"UpsertTrainingConfigWithFreeze", args[1], args[2], strconv.FormatBool(*req.Freeze)}
+	}
+	raw, err := s.submit(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Invalidate("config_update", common.CacheTrainingConfig)
+	var ledger ledgerTrainingConfig
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	return ledger.toTrainingConfig(), nil
+}
+
+// Config returns a job's training config.
+func (s *Service) Config(ctx context.Context, jobID string) (*TrainingConfig, error) {
+	jobID = s.jobIDOrDefault(jobID)
+	return common.CachedLoad(ctx, s.cache, common.CacheTrainingConfig, jobID, func() (*TrainingConfig, error) {
+		var ledger ledgerTrainingConfig
+		if err := s.query(ctx, []string{"GetTrainingConfig", jobID}, &ledger); err != nil {
+			return nil, err
+		}
+		return ledger.toTrainingConfig(), nil
+	})
+}
+
+// ConfigVersion returns one version of a job's training config.
+func (s *Service) ConfigVersion(ctx context.Context, jobID string, version int) (*TrainingConfig, error) {
+	if version < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "version must be a positive integer")
+	}
+	var ledger ledgerTrainingConfig
+	if err := s.query(ctx, []string{"GetTrainingConfigVersion", s.jobIDOrDefault(jobID), strconv.Itoa(version)}, &ledger); err != nil {
+		return nil, err
+	}
+	return ledger.toTrainingConfig(), nil
+}
+
+// ConfigVersions returns every version of a job's training config, oldest first.
+func (s *Service) ConfigVersions(ctx context.Context, jobID string) ([]*TrainingConfig, error) {
+	var ledger []*ledgerTrainingConfig
+	if err := s.query(ctx, []string{"ListTrainingConfigVersions", s.jobIDOrDefault(jobID)}, &ledger); err != nil {
+		return nil, err
+	}
+	versions := make([]*TrainingConfig, 0, len(ledger))
+	for _, record := range ledger {
+		versions = append(versions, record.toTrainingConfig())
+	}
+	return versions, nil
+}
+
+// ConfigHistory lists every ledger write to a job's training config, oldest first, with its
+// transaction ID and timestamp.
+func (s *Service) ConfigHistory(ctx context.Context, jobID string) ([]*ConfigHistoryEntry, error) {
+	var ledger []*ledgerConfigHistoryEntry
+	if err := s.query(ctx, []string{"GetTrainingConfigHistory", s.jobIDOrDefault(jobID)}, &ledger); err != nil {
+		return nil, err
+	}
+	entries := make([]*ConfigHistoryEntry, 0, len(ledger))
+	for _, entry := range ledger {
+		if entry == nil {
+			continue
+		}
+		item := &ConfigHistoryEntry{TxID: entry.TxID, Timestamp: entry.Timestamp, IsDelete: entry.IsDelete}
+		if entry.Record != nil {
+			item.Config = entry.Record.toTrainingConfig()
+		}
+		entries = append(entries, item)
+	}
+	return entries, nil
+}
+
+func (s *Service) jobIDOrDefault(jobID string) string {
+	if jobID = strings.TrimSpace(jobID); jobID != "" {
+		return jobID
+	}
+	return s.cfg.JobID
+}
+
+func (s *Service) submitJob(ctx context.Context, args []string) (*Job, error) {
+	if len(args) > 1 && args[1] == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "job id is required")
+	}
+	raw, err := s.submit(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *Service) submit(ctx context.Context, args []string) ([]byte, error) {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	return raw, nil
+}
+
+func (s *Service) query(ctx context.Context, args []string, target any) error {
+	if len(args) > 1 && args[1] == "" {
+		return common.NewStatusError(http.StatusBadRequest, "job id is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "already exists"), strings.Contains(msg, "cannot move"),
+		strings.Contains(msg, "cannot change"), strings.Contains(msg, "is archived"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "must be"), strings.Contains(msg, "is not supported"), strings.Contains(msg, "is given both"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
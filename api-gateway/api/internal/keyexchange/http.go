@@ -0,0 +1,99 @@
+package keyexchange
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the `/rounds/{round}/keys` endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the key exchange HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/rounds/{round}/keys`. The fixed `/rounds/current`, `/rounds/start`
+// and `/rounds/close` routes are more specific and keep their own handlers.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/rounds/", auth.RequireAuth(http.HandlerFunc(h.handleKeys), common.RoleTrainer, common.RoleAggregator))
+}
+
+// Describe documents the key exchange endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("keyexchange")
+	members := []common.Role{common.RoleTrainer, common.RoleAggregator}
+	api.Add(http.MethodPost, "/rounds/{round}/keys", openapi.Operation{
+		Summary:     "Publish the caller's public key share for a round of its cluster",
+		Description: "The cluster round must be open. Each trainer publishes once per round.",
+		Roles:       members,
+		Body:        PublishRequest{},
+		Response:    KeyShare{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/rounds/{round}/keys", openapi.Operation{
+		Summary:     "List the key shares published for a round of the caller's cluster",
+		Description: "Only trainers of the cluster can read its shares.",
+		Roles:       members,
+		Query:       []openapi.Param{{Name: "job_id", Description: "Defaults to GATEWAY_JOB_ID."}},
+		Response:    map[string]any{"items": []*KeyShare{}},
+	})
+}
+
+// handleKeys serves `/rounds/{round}/keys`.
+func (h *HTTPHandler) handleKeys(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	rawRound, found := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/rounds/"), "/keys")
+	if !found || strings.Contains(rawRound, "/") {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	round, err := strconv.Atoi(rawRound)
+	if err != nil || round < 1 {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		var req PublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		share, err := h.svc.Publish(r.Context(), authCtx, round, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, share)
+	case http.MethodGet:
+		shares, err := h.svc.List(r.Context(), authCtx, r.URL.Query().Get("job_id"), round)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": shares})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
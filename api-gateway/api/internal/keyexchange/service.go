@@ -0,0 +1,133 @@
+package keyexchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service publishes and reads the per-round public key shares trainers exchange within their
+// cluster for secure aggregation. The chaincode scopes both to the caller's cluster, so every
+// call is signed with the caller's enrolled identity.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a key exchange service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// KeyShare mirrors the on-chain KeyShare.
+type KeyShare struct {
+	JobID       string `json:"job_id"`
+	ClusterID   string `json:"cluster_id"`
+	Round       int    `json:"round"`
+	NodeID      string `json:"node_id"`
+	PublicKey   string `json:"public_key"`
+	TxID        string `json:"tx_id"`
+	PublishedAt string `json:"published_at"`
+}
+
+// PublishRequest is the payload for publishing a key share. PublicKey is base64 encoded; an
+// empty JobID selects GATEWAY_JOB_ID.
+type PublishRequest struct {
+	JobID     string `json:"job_id,omitempty"`
+	PublicKey string `json:"public_key"`
+}
+
+// Publish stores the caller's key share for a round of its cluster.
+func (s *Service) Publish(ctx context.Context, authCtx *common.AuthContext, round int, req *PublishRequest) (*KeyShare, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	if round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	publicKey := strings.TrimSpace(req.PublicKey)
+	if decoded, err := base64.StdEncoding.DecodeString(publicKey); err != nil || len(decoded) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "public_key must be non-empty base64")
+	}
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return nil, err
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"PublishKeyShare", s.jobID(req.JobID), strconv.Itoa(round), publicKey}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, identity, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var share KeyShare
+	if err := json.Unmarshal(raw, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// List returns the key shares published for a round of the caller's cluster.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, jobID string, round int) ([]*KeyShare, error) {
+	if round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	identity, err := s.identityFor(authCtx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"ListKeyShares", s.jobID(jobID), strconv.Itoa(round)})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var shares []*KeyShare
+	if err := json.Unmarshal(raw, &shares); err != nil {
+		return nil, err
+	}
+	if shares == nil {
+		shares = []*KeyShare{}
+	}
+	return shares, nil
+}
+
+func (s *Service) jobID(jobID string) string {
+	if jobID = strings.TrimSpace(jobID); jobID != "" {
+		return jobID
+	}
+	return s.cfg.JobID
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) (string, error) {
+	if authCtx == nil {
+		return "", common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return "", common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	return rec.FabricClientID, nil
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "already published"), strings.Contains(msg, "has not started"), strings.Contains(msg, "is closed"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "trainer not authorized"), strings.Contains(msg, "trainer credential revoked"), strings.Contains(msg, "trainer deactivated"),
+		strings.Contains(msg, "trainer suspended"),
+		strings.Contains(msg, "has no cluster"), strings.Contains(msg, "is not permitted to call"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
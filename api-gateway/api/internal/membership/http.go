@@ -0,0 +1,118 @@
+package membership
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the clustering plan and membership epoch endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the membership HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/clustering-plan`, `/membership/epoch` and
+// `/membership/epochs`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	mux.Handle("/admin/clustering-plan", auth.RequireAuth(http.HandlerFunc(h.handlePlan), common.RoleAdmin))
+	mux.Handle("/membership/epoch", auth.RequireAuth(http.HandlerFunc(h.handleEpoch), readers...))
+	mux.Handle("/membership/epochs", auth.RequireAuth(http.HandlerFunc(h.handleEpochs), readers...))
+}
+
+// Describe documents the membership endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("membership")
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	api.Add(http.MethodPost, "/admin/clustering-plan", openapi.Operation{
+		Summary:     "Apply a clustering plan",
+		Description: "Moves whitelisted nodes to new clusters, and optionally states, in one transaction and opens the next membership epoch. A missing state keeps the node's current one. Rejected with 409 while any job has an open round of an affected cluster or state, or when the plan changes nothing.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Body:        PlanRequest{},
+		Response:    Epoch{},
+		Errors:      []int{http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/membership/epoch", openapi.Operation{
+		Summary:  "Read a membership epoch",
+		Roles:    readers,
+		Query:    []openapi.Param{{Name: "epoch", Description: "Epoch number; the current epoch when omitted.", Type: "integer"}},
+		Response: Epoch{},
+		Errors:   []int{http.StatusNotFound},
+	})
+	api.Add(http.MethodGet, "/membership/epochs", openapi.Operation{
+		Summary:  "List the applied membership epochs",
+		Roles:    readers,
+		Response: map[string]any{"items": []*Epoch{}},
+	})
+}
+
+func (h *HTTPHandler) handlePlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	epoch, err := h.svc.Apply(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, epoch)
+}
+
+// handleEpoch serves `/membership/epoch`; `epoch` selects an earlier epoch.
+func (h *HTTPHandler) handleEpoch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	number := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("epoch")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "epoch must be a positive integer"))
+			return
+		}
+		number = parsed
+	}
+	epoch, err := h.svc.Get(r.Context(), number)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, epoch)
+}
+
+func (h *HTTPHandler) handleEpochs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	epochs, err := h.svc.List(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": epochs})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,169 @@
+package membership
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service applies clustering plans and reads the membership epochs they open.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+	// cache is told when a plan moves whitelist entries.
+	cache *common.QueryCache
+}
+
+// NewService constructs a membership service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// EnableCache makes applied plans invalidate the whitelist reads cached in cache.
+func (s *Service) EnableCache(cache *common.QueryCache) {
+	s.cache = cache
+}
+
+// Assignment moves one node to a cluster and, when State is set, to another state. The
+// previous placement is filled in by the chaincode.
+type Assignment struct {
+	NodeID          string `json:"node_id"`
+	State           string `json:"state,omitempty"`
+	Cluster         string `json:"cluster"`
+	PreviousState   string `json:"previous_state,omitempty"`
+	PreviousCluster string `json:"previous_cluster,omitempty"`
+}
+
+// Epoch mirrors the on-chain MembershipEpoch record.
+type Epoch struct {
+	Epoch       int           `json:"epoch"`
+	Assignments []*Assignment `json:"assignments"`
+	Reason      string        `json:"reason,omitempty"`
+	AppliedBy   string        `json:"applied_by,omitempty"`
+	AppliedAt   string        `json:"applied_at,omitempty"`
+	TxID        string        `json:"tx_id,omitempty"`
+}
+
+// PlanRequest is a clustering plan: the nodes to move and why.
+type PlanRequest struct {
+	Assignments []*Assignment `json:"assignments"`
+	Reason      string        `json:"reason,omitempty"`
+}
+
+// Apply moves the plan's nodes in one transaction and opens the next membership epoch. The
+// chaincode rejects the plan while a round of an affected cluster or state is open. The local
+// enrollments of the moved nodes follow, so new tokens carry the new placement.
+func (s *Service) Apply(ctx context.Context, req *PlanRequest) (*Epoch, error) {
+	if req == nil || len(req.Assignments) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "assignments are required")
+	}
+	plan := make([]*Assignment, 0, len(req.Assignments))
+	for _, assignment := range req.Assignments {
+		if assignment == nil || strings.TrimSpace(assignment.NodeID) == "" {
+			return nil, common.NewStatusError(http.StatusBadRequest, "node_id is required for every assignment")
+		}
+		if strings.TrimSpace(assignment.Cluster) == "" {
+			return nil, common.NewStatusError(http.StatusBadRequest, "cluster is required for node "+assignment.NodeID)
+		}
+		plan = append(plan, &Assignment{
+			NodeID:  strings.TrimSpace(assignment.NodeID),
+			State:   strings.TrimSpace(assignment.State),
+			Cluster: strings.TrimSpace(assignment.Cluster),
+		})
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"ApplyClusteringPlan", common.MustJSON(plan), strings.TrimSpace(req.Reason)}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	s.cache.Invalidate("clustering_plan", common.CacheWhitelist)
+	var epoch Epoch
+	if err := json.Unmarshal(raw, &epoch); err != nil {
+		return nil, err
+	}
+	if err := s.moveEnrollments(epoch.Assignments); err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
+
+// Get returns an applied membership epoch, or the current one when number is 0. The current
+// epoch is 0, with no assignments, until a plan is applied.
+func (s *Service) Get(ctx context.Context, number int) (*Epoch, error) {
+	arg := ""
+	if number > 0 {
+		arg = strconv.Itoa(number)
+	}
+	var epoch Epoch
+	if err := s.query(ctx, []string{"GetMembershipEpoch", arg}, &epoch); err != nil {
+		return nil, err
+	}
+	if epoch.Assignments == nil {
+		epoch.Assignments = []*Assignment{}
+	}
+	return &epoch, nil
+}
+
+// List returns every applied membership epoch, oldest first.
+func (s *Service) List(ctx context.Context) ([]*Epoch, error) {
+	var epochs []*Epoch
+	if err := s.query(ctx, []string{"ListMembershipEpochs"}, &epochs); err != nil {
+		return nil, err
+	}
+	if epochs == nil {
+		epochs = []*Epoch{}
+	}
+	return epochs, nil
+}
+
+// moveEnrollments copies the new placement to the local enrollments of the moved nodes.
+func (s *Service) moveEnrollments(assignments []*Assignment) error {
+	moved := make(map[string]*Assignment, len(assignments))
+	for _, assignment := range assignments {
+		moved[assignment.NodeID] = assignment
+	}
+	for _, record := range s.store.All() {
+		assignment, ok := moved[record.NodeID]
+		if !ok {
+			continue
+		}
+		record.State = assignment.State
+		record.Cluster = assignment.Cluster
+		if err := s.store.Save(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) query(ctx context.Context, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, args)
+	if err != nil {
+		return mapLedgerError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "before re-clustering"), strings.Contains(msg, "changes nothing"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "is required"), strings.Contains(msg, "must be"), strings.Contains(msg, "not whitelisted"),
+		strings.Contains(msg, "more than once"), strings.Contains(msg, "no assignments"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// attestationMarker prefixes the chaincode's attestation failures.
+const attestationMarker = "model attestation failed: "
+
+// AttestationError reports a model hash signature the chaincode refused. It is served as
+// 422 with the reason and the document the trainer was expected to sign.
+type AttestationError struct {
+	Reason        string             `json:"reason"`
+	SignedMessage AttestationMessage `json:"signed_message"`
+}
+
+// AttestationMessage is the JSON document a trainer signs with its registered Ed25519 key.
+type AttestationMessage struct {
+	Layer     string `json:"layer"`
+	ScopeID   string `json:"scope_id"`
+	ModelHash string `json:"model_hash"`
+}
+
+func (e *AttestationError) Error() string {
+	return strings.TrimSpace(attestationMarker) + " " + e.Reason
+}
+
+// asAttestationError converts a chaincode attestation failure into an AttestationError.
+func asAttestationError(err error, message AttestationMessage) error {
+	msg := err.Error()
+	idx := strings.Index(msg, attestationMarker)
+	if idx < 0 {
+		return err
+	}
+	reason := msg[idx+len(attestationMarker):]
+	// The peer CLI wraps chaincode errors, so cut the reason at the end of its line.
+	if end := strings.IndexAny(reason, "\n\"'"); end >= 0 {
+		reason = reason[:end]
+	}
+	return &AttestationError{Reason: strings.TrimSpace(reason), SignedMessage: message}
+}
+
+// writeCommitError serves attestation failures as 422 with details and every other error
+// with its status code.
+func writeCommitError(w http.ResponseWriter, err error) {
+	var attestationErr *AttestationError
+	if errors.As(err, &attestationErr) {
+		common.WriteErrorWithCode(w, http.StatusUnprocessableEntity, common.NewCodedError(http.StatusUnprocessableEntity, common.CodeAttestationFailed, attestationErr.Error(), attestationErr))
+		return
+	}
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// MaxBatchSize mirrors the chaincode limit on models per CommitModels transaction.
+const MaxBatchSize = 100
+
+// BatchItem is one model reference in a batch commit.
+type BatchItem struct {
+	ScopeID         string
+	Payload         json.RawMessage
+	Round           int
+	ParentModelIDs  []string
+	Metrics         map[string]float64
+	Hyperparameters json.RawMessage
+}
+
+// BatchItemResult reports the outcome of one batch item, in request order.
+type BatchItemResult struct {
+	Index       int          `json:"index"`
+	DataID      string       `json:"data_id,omitempty"`
+	Status      string       `json:"status"`
+	Error       string       `json:"error,omitempty"`
+	DuplicateOf []string     `json:"duplicate_of,omitempty"`
+	Model       *ModelRecord `json:"model,omitempty"`
+}
+
+// BatchResult summarises a batch commit. Every committed item shares the one transaction
+// named by TxID.
+type BatchResult struct {
+	Layer       string             `json:"layer"`
+	Committed   int                `json:"committed"`
+	Failed      int                `json:"failed"`
+	Items       []*BatchItemResult `json:"items"`
+	TxID        string             `json:"tx_id,omitempty"`
+	BlockNumber uint64             `json:"block_number,omitempty"`
+}
+
+const (
+	batchStatusCommitted = "committed"
+	batchStatusFailed    = "failed"
+)
+
+type ledgerBatchItem struct {
+	ID              string             `json:"id"`
+	Layer           string             `json:"layer"`
+	ScopeID         string             `json:"scope_id"`
+	Payload         string             `json:"payload"`
+	ParentModelIDs  []string           `json:"parent_model_ids,omitempty"`
+	JobID           string             `json:"job_id,omitempty"`
+	Round           int                `json:"round,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters json.RawMessage    `json:"hyperparameters,omitempty"`
+}
+
+type ledgerBatchResult struct {
+	Committed int `json:"committed"`
+	Failed    int `json:"failed"`
+	Items     []*struct {
+		Index  int                `json:"index"`
+		ID     string             `json:"id"`
+		Status string             `json:"status"`
+		Error  string             `json:"error"`
+		Record *ledgerModelRecord `json:"record"`
+	} `json:"items"`
+}
+
+// CommitBatch records several model references for one layer in a single CommitModels
+// transaction. Items rejected by the gateway (missing scope, closed round) or by the
+// chaincode are reported individually; the remaining items are still committed. With an
+// idempotency key the model identifiers are derived from the key and the item index.
+// Duplicate payloads are detected against the ledger and against earlier items of the batch.
+func (s *Service) CommitBatch(ctx context.Context, authCtx *common.AuthContext, layerSlug string, items []*BatchItem, idempotencyKey string) (*BatchResult, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	layer, err := s.layerBySlug(layerSlug)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "items must contain at least one model")
+	}
+	if len(items) > MaxBatchSize {
+		return nil, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("a batch holds at most %d models", MaxBatchSize))
+	}
+	enrolment, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	result := &BatchResult{Layer: layer.Slug, Items: make([]*BatchItemResult, len(items))}
+	ledgerItems := make([]*ledgerBatchItem, 0, len(items))
+	submitted := make([]int, 0, len(items))
+	openRounds := map[string]error{}
+	batchHashes := map[duplicateTarget]map[string]string{}
+	for index, item := range items {
+		entry := &BatchItemResult{Index: index, Status: batchStatusFailed}
+		result.Items[index] = entry
+		if item == nil {
+			entry.Error = "item is empty"
+			continue
+		}
+		scope := strings.TrimSpace(item.ScopeID)
+		switch {
+		case scope == "":
+			entry.Error = layer.ScopeLabel + " identifier is required"
+			continue
+		case len(item.Payload) == 0:
+			entry.Error = "payload is required"
+			continue
+		case item.Round < 0:
+			entry.Error = "round must be a positive integer"
+			continue
+		}
+		if err := validateMetadata(item.Metrics, item.Hyperparameters); err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		if item.Round > 0 {
+			key := scope + "/" + strconv.Itoa(item.Round)
+			roundErr, checked := openRounds[key]
+			if !checked {
+				roundErr = s.rounds.RequireOpen(ctx, enrolment.FabricClientID, layer.Slug, scope, item.Round)
+				openRounds[key] = roundErr
+			}
+			if roundErr != nil {
+				entry.Error = roundErr.Error()
+				continue
+			}
+		}
+		entry.DataID = common.GeneratePrefixedID("model")
+		if idempotencyKey != "" {
+			entry.DataID = common.DeterministicID("model", authCtx.Subject, layer.Slug, idempotencyKey, strconv.Itoa(index))
+		}
+		payloadHash, err := s.payloadHash(item.Payload)
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		target := duplicateTarget{Layer: layer.Slug, ScopeID: strings.ToLower(scope), JobID: s.cfg.JobID, Round: item.Round}
+		duplicates, err := s.findDuplicates(ctx, payloadHash, target, entry.DataID)
+		if earlier, ok := batchHashes[target][payloadHash]; ok {
+			duplicates = append(duplicates, earlier)
+			if err == nil && s.cfg.ModelDuplicates == DuplicatesReject {
+				err = duplicateError(duplicates)
+			}
+		}
+		entry.DuplicateOf = duplicates
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		payload, err := s.sealPayload(ctx, item.Payload, payloadHash)
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		if batchHashes[target] == nil {
+			batchHashes[target] = map[string]string{}
+		}
+		if _, ok := batchHashes[target][payloadHash]; !ok {
+			batchHashes[target][payloadHash] = entry.DataID
+		}
+		ledgerItem := &ledgerBatchItem{
+			ID:              entry.DataID,
+			Layer:           layer.Slug,
+			ScopeID:         scope,
+			Payload:         string(payload),
+			ParentModelIDs:  item.ParentModelIDs,
+			Round:           item.Round,
+			Metrics:         item.Metrics,
+			Hyperparameters: item.Hyperparameters,
+		}
+		if item.Round > 0 {
+			ledgerItem.JobID = s.cfg.JobID
+		}
+		ledgerItems = append(ledgerItems, ledgerItem)
+		submitted = append(submitted, index)
+	}
+	if len(ledgerItems) > 0 {
+		peerName := s.fabric.SelectPeer(common.PeerWrite)
+		if peerName == "" {
+			return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+		}
+		raw, receipt, err := s.fabric.SubmitChaincode(ctx, peerName, enrolment.FabricClientID, []string{"CommitModels", common.MustJSON(ledgerItems)})
+		if err != nil {
+			return nil, err
+		}
+		result.TxID = receipt.TxID
+		result.BlockNumber = receipt.BlockNumber
+		var ledger ledgerBatchResult
+		if err := json.Unmarshal(raw, &ledger); err != nil {
+			return nil, fmt.Errorf("failed to decode batch result: %w", err)
+		}
+		for _, item := range ledger.Items {
+			if item == nil || item.Index < 0 || item.Index >= len(submitted) {
+				continue
+			}
+			entry := result.Items[submitted[item.Index]]
+			entry.Status = item.Status
+			entry.Error = item.Error
+			entry.Model = item.Record.toModelRecord()
+			if entry.Model != nil {
+				// Echo the submitted payload rather than the off-chain pointer that was committed.
+				entry.Model.Payload = json.RawMessage(common.MustJSON(string(items[submitted[item.Index]].Payload)))
+			}
+		}
+	}
+	for _, entry := range result.Items {
+		if entry.Status == batchStatusCommitted {
+			result.Committed++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Duplicate policies selectable with MODEL_DUPLICATES.
+const (
+	DuplicatesAllow  = "allow"
+	DuplicatesReject = "reject"
+)
+
+// payloadHash is the digest the chaincode records for payload: its hex SHA-256, or, when
+// payloads are encrypted, its HMAC under the job's digest key (see envelope.Cipher.Digest).
+func (s *Service) payloadHash(payload []byte) (string, error) {
+	return s.envelopes.Digest(s.cfg.JobID, payload)
+}
+
+// sealPayload returns what is committed for payload: encrypted when encryption is on, then
+// offloaded when it is large enough. An offloaded pointer carries hash, so the chaincode
+// records the same payload hash whether or not the payload stayed on-chain.
+func (s *Service) sealPayload(ctx context.Context, payload []byte, hash string) ([]byte, error) {
+	sealed, err := s.envelopes.Encrypt(s.cfg.JobID, payload)
+	if err != nil {
+		return nil, err
+	}
+	return s.payloads.OffloadWithDigest(ctx, "models", sealed, hash)
+}
+
+// ByHash returns every model reference whose payload hashes to hash, which may carry a
+// "sha256:" prefix.
+func (s *Service) ByHash(ctx context.Context, hash string) ([]*ModelRecord, error) {
+	records, err := s.lookupHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rehydrate(ctx, records...); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *Service) lookupHash(ctx context.Context, hash string) ([]*ModelRecord, error) {
+	hash = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(hash), "sha256:"))
+	if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+		return nil, common.NewStatusError(http.StatusBadRequest, "hash must be a hex SHA-256 digest")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"GetModelByHash", hash})
+	if err != nil {
+		return nil, err
+	}
+	var ledger []*ledgerModelRecord
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	records := make([]*ModelRecord, 0, len(ledger))
+	for _, record := range ledger {
+		if record != nil {
+			records = append(records, record.toModelRecord())
+		}
+	}
+	return records, nil
+}
+
+// duplicateTarget is where a commit lands: duplicates are models with the same payload in
+// the same layer, scope and round. Commits outside a round only match each other.
+type duplicateTarget struct {
+	Layer   string
+	ScopeID string
+	JobID   string
+	Round   int
+}
+
+func (t duplicateTarget) matches(record *ModelRecord) bool {
+	if record.Layer != t.Layer || !strings.EqualFold(record.ScopeID, t.ScopeID) || record.Round != t.Round {
+		return false
+	}
+	return t.Round == 0 || record.JobID == t.JobID
+}
+
+// findDuplicates lists the models already committed to target with the payload hash, other
+// than self, which is the model being committed (an idempotent retry finds its own record).
+// Detection is best effort: two concurrent commits of the same payload can both pass.
+// Lookup failures only fail the commit when duplicates are rejected.
+func (s *Service) findDuplicates(ctx context.Context, hash string, target duplicateTarget, self string) ([]string, error) {
+	records, err := s.lookupHash(ctx, hash)
+	if err != nil {
+		if s.cfg.ModelDuplicates == DuplicatesReject {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var ids []string
+	for _, record := range records {
+		if record.DataID != self && target.matches(record) {
+			ids = append(ids, record.DataID)
+		}
+	}
+	if len(ids) > 0 && s.cfg.ModelDuplicates == DuplicatesReject {
+		return ids, duplicateError(ids)
+	}
+	return ids, nil
+}
+
+func duplicateError(ids []string) error {
+	return common.NewStatusError(http.StatusConflict, fmt.Sprintf("payload duplicates model %s committed to the same scope and round", strings.Join(ids, ", ")))
+}
@@ -1,58 +1,184 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
 	"github.com/nebula/api-gateway/internal/registry"
 )
 
 // HTTPHandler exposes the scoped /models endpoints.
 type HTTPHandler struct {
 	svc   *Service
-	store *registry.Store
+	store registry.Store
+	idem  *common.IdempotencyStore
 }
 
-// NewHTTPHandler prepares a HTTP handler.
-func NewHTTPHandler(svc *Service, store *registry.Store) *HTTPHandler {
-	return &HTTPHandler{svc: svc, store: store}
+// NewHTTPHandler prepares a HTTP handler. Commits honour the Idempotency-Key header via idem.
+func NewHTTPHandler(svc *Service, store registry.Store, idem *common.IdempotencyStore) *HTTPHandler {
+	return &HTTPHandler{svc: svc, store: store, idem: idem}
 }
 
 // RegisterRoutes wires the models endpoints for each configured layer.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
-	keyFunc := func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
-		subject := strings.TrimSpace(claims.Subject)
-		if subject == "" {
-			return nil, errors.New("token missing subject")
-		}
-		record, ok := h.store.FindByJWTSub(subject)
-		if !ok {
-			return nil, errors.New("trainer not registered")
-		}
-		pub, err := record.PublicKeyBytes()
-		if err != nil {
-			return nil, err
-		}
-		return &common.KeySpec{Algorithm: "EdDSA", PublicKey: pub}, nil
-	}
+	keyFunc := registry.TrainerKeyFunc(h.store)
 	for _, layer := range h.svc.Layers() {
 		if layer == nil {
 			continue
 		}
 		layer := layer
 		basePath := fmt.Sprintf("/%s/models", layer.Slug)
-		mux.Handle(basePath, auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle(basePath, auth.RequireAuthWithKeyFunc(keyFunc, h.idem.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			h.handleCollection(w, r, layer)
-		})))
+		}))))
+		mux.Handle(basePath+"/batch", auth.RequireAuthWithKeyFunc(keyFunc, h.idem.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.handleBatch(w, r, layer)
+		}))))
 		mux.Handle(basePath+"/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			h.handleRecord(w, r, layer)
 		})))
 	}
+	mux.Handle("/models/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleModel)))
+}
+
+// Describe documents the model endpoints of every layer.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("models")
+	const runtimeToken = "Requires a trainer runtime token (EdDSA) or a token from /auth/token."
+	for _, layer := range h.svc.Layers() {
+		if layer == nil {
+			continue
+		}
+		basePath := fmt.Sprintf("/%s/models", layer.Slug)
+		commit := map[string]any{
+			layer.ScopeField:   "",
+			"payload":          map[string]any{},
+			"round":            0,
+			"parent_model_ids": []string{},
+			"model_hash":       "",
+			"signature":        "",
+			"metrics":          map[string]float64{MetricAccuracy: 0, MetricLoss: 0, MetricNumSamples: 0},
+			"hyperparameters":  map[string]any{},
+		}
+		api.Add(http.MethodPost, basePath, openapi.Operation{
+			Summary:     fmt.Sprintf("Commit a %s model reference", layer.Name),
+			Description: runtimeToken + " Send model_hash and signature to have the chaincode verify the trainer's attestation; honours Idempotency-Key. `metrics` are numbers keyed by lower-case name: accuracy must be in [0, 1], loss non-negative and num_samples a non-negative integer. `hyperparameters` is any JSON object.",
+			Body:        commit,
+			Response:    CommitResult{},
+			Status:      http.StatusCreated,
+			Errors:      []int{http.StatusConflict, http.StatusUnprocessableEntity},
+		})
+		api.Add(http.MethodGet, basePath, openapi.Operation{
+			Summary:     fmt.Sprintf("List %s model references", layer.Name),
+			Description: runtimeToken + " With `Accept: application/x-ndjson` every matching model is streamed as one JSON line, reading the ledger `per_page` records at a time.",
+			Query: []openapi.Param{
+				{Name: "scope_id", Description: "Limit to one " + layer.ScopeLabel + "."},
+				{Name: "page", Type: "integer"},
+				{Name: "owner"},
+				{Name: "since", Description: "RFC3339 timestamp."},
+				{Name: "until", Description: "RFC3339 timestamp."},
+				{Name: "metric", Description: "Only models reporting this metric, e.g. accuracy."},
+				{Name: "min_metric", Type: "number", Description: "Lower bound of metric."},
+				{Name: "max_metric", Type: "number", Description: "Upper bound of metric."},
+				{Name: "order", Description: "Sort by metric: asc or desc. `order=desc&per_page=k` returns the top k."},
+				{Name: "per_page", Type: "integer", Description: fmt.Sprintf("Page size, at most %d.", maxPageSize)},
+				{Name: "bookmark", Description: "Page with ledger bookmarks instead of page numbers: pass an empty value for the first page, then the returned bookmark."},
+				{Name: "total", Description: "With bookmark: none (default), estimate (counts up to 1000) or exact."},
+			},
+			Response: ListResult{},
+		})
+		api.Add(http.MethodPost, basePath+"/batch", openapi.Operation{
+			Summary:     fmt.Sprintf("Commit up to %d %s model references in one transaction", MaxBatchSize, layer.Name),
+			Description: runtimeToken + " Returns 207 when some items failed.",
+			Body:        map[string]any{"items": []map[string]any{commit}},
+			Response:    BatchResult{},
+			Status:      http.StatusCreated,
+		})
+		api.Add(http.MethodGet, basePath+"/{data_id}", openapi.Operation{Summary: fmt.Sprintf("Read a %s model reference", layer.Name), Description: runtimeToken, Response: ModelRecord{}, Errors: []int{http.StatusNotFound}})
+	}
+	api.Add(http.MethodGet, "/models/{data_id}/lineage", openapi.Operation{Summary: "Walk a model's parents", Description: runtimeToken, Query: []openapi.Param{{Name: "max_depth", Type: "integer"}}, Response: Lineage{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/models/{data_id}/history", openapi.Operation{Summary: "List every ledger write of a model", Description: runtimeToken, Response: History{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/models/by-hash/{hash}", openapi.Operation{Summary: "List the models whose payload has a SHA-256 digest", Description: runtimeToken + " The digest is hex, optionally prefixed with `sha256:`.", Response: []*ModelRecord{}, Errors: []int{http.StatusBadRequest}})
+}
+
+// handleModel serves layer-independent model routes: `/models/{id}/lineage`,
+// `/models/{id}/history` and `/models/by-hash/{hash}`.
+func (h *HTTPHandler) handleModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/models/")
+	dataID, action, _ := strings.Cut(rest, "/")
+	if dataID == "by-hash" && action != "" {
+		h.handleByHash(w, r, action)
+		return
+	}
+	if dataID == "" || (action != "lineage" && action != "history") {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	if action == "history" {
+		history, err := h.svc.History(r.Context(), authCtx, dataID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if se, ok := common.AsStatusError(err); ok {
+				status = se.Code
+			}
+			common.WriteErrorWithCode(w, status, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, history))
+		return
+	}
+	maxDepth := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("max_depth")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "max_depth must be a positive integer"))
+			return
+		}
+		maxDepth = value
+	}
+	lineage, err := h.svc.Lineage(r.Context(), authCtx, dataID, maxDepth)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := common.AsStatusError(err); ok {
+			status = se.Code
+		}
+		common.WriteErrorWithCode(w, status, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, lineage))
+}
+
+func (h *HTTPHandler) handleByHash(w http.ResponseWriter, r *http.Request, hash string) {
+	if _, ok := common.AuthContextFrom(r.Context()); !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	records, err := h.svc.ByHash(r.Context(), hash)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := common.AsStatusError(err); ok {
+			status = se.Code
+		}
+		common.WriteErrorWithCode(w, status, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, records))
 }
 
 func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request, layer *Layer) {
@@ -91,7 +217,7 @@ func (h *HTTPHandler) handleRecord(w http.ResponseWriter, r *http.Request, layer
 		common.WriteErrorWithCode(w, status, err)
 		return
 	}
-	common.WriteJSON(w, http.StatusOK, record)
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, record))
 }
 
 func (h *HTTPHandler) handleCommit(w http.ResponseWriter, r *http.Request, layer *Layer) {
@@ -110,12 +236,90 @@ func (h *HTTPHandler) handleCommit(w http.ResponseWriter, r *http.Request, layer
 		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
 		return
 	}
+	opts := &CommitOptions{IdempotencyKey: common.IdempotencyKey(r)}
+	if raw, ok := body["round"]; ok {
+		if err := json.Unmarshal(raw, &opts.Round); err != nil || opts.Round < 0 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+			return
+		}
+	}
+	if raw, ok := body["parent_model_ids"]; ok {
+		if err := json.Unmarshal(raw, &opts.ParentModelIDs); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "parent_model_ids must be an array of strings"))
+			return
+		}
+	}
+	for field, target := range map[string]*string{"model_hash": &opts.ModelHash, "signature": &opts.Signature} {
+		if raw, ok := body[field]; ok {
+			if err := json.Unmarshal(raw, target); err != nil {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, field+" must be a string"))
+				return
+			}
+		}
+	}
+	if opts.Metrics, opts.Hyperparameters, err = extractMetadata(body); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
 	authCtx, ok := common.AuthContextFrom(r.Context())
 	if !ok {
 		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
 		return
 	}
-	result, err := h.svc.Commit(r.Context(), authCtx, layer.Slug, scopeID, payload)
+	result, err := h.svc.Commit(r.Context(), authCtx, layer.Slug, scopeID, payload, opts)
+	if err != nil {
+		writeCommitError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusCreated, result)
+}
+
+// handleBatch commits up to MaxBatchSize models in one transaction. The response lists the
+// outcome of every item; it is 201 when all items were committed and 207 otherwise.
+func (h *HTTPHandler) handleBatch(w http.ResponseWriter, r *http.Request, layer *Layer) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Items []map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	items := make([]*BatchItem, 0, len(body.Items))
+	for index, raw := range body.Items {
+		scopeID, err := extractScopeID(raw, layer)
+		if err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("items[%d]: %v", index, err)))
+			return
+		}
+		item := &BatchItem{ScopeID: scopeID, Payload: raw["payload"]}
+		if value, ok := raw["round"]; ok {
+			if err := json.Unmarshal(value, &item.Round); err != nil || item.Round < 0 {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("items[%d]: round must be a positive integer", index)))
+				return
+			}
+		}
+		if value, ok := raw["parent_model_ids"]; ok {
+			if err := json.Unmarshal(value, &item.ParentModelIDs); err != nil {
+				common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("items[%d]: parent_model_ids must be an array of strings", index)))
+				return
+			}
+		}
+		if item.Metrics, item.Hyperparameters, err = extractMetadata(raw); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("items[%d]: %v", index, err)))
+			return
+		}
+		items = append(items, item)
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	result, err := h.svc.CommitBatch(r.Context(), authCtx, layer.Slug, items, common.IdempotencyKey(r))
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := common.AsStatusError(err); ok {
@@ -124,7 +328,11 @@ func (h *HTTPHandler) handleCommit(w http.ResponseWriter, r *http.Request, layer
 		common.WriteErrorWithCode(w, status, err)
 		return
 	}
-	common.WriteJSON(w, http.StatusCreated, result)
+	status := http.StatusCreated
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	common.WriteJSON(w, status, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
 }
 
 func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request, layer *Layer) {
@@ -142,12 +350,73 @@ func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request, layer *
 		}
 		page = value
 	}
+	filter := &ListFilter{
+		Owner:    strings.TrimSpace(query.Get("owner")),
+		Metric:   strings.TrimSpace(query.Get("metric")),
+		Order:    strings.ToLower(strings.TrimSpace(query.Get("order"))),
+		Bookmark: strings.TrimSpace(query.Get("bookmark")),
+		// An empty bookmark parameter asks for the first bookmark page.
+		BookmarkPaging: query.Has("bookmark"),
+		Total:          strings.ToLower(strings.TrimSpace(query.Get("total"))),
+	}
+	if raw := strings.TrimSpace(query.Get("per_page")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "per_page must be a positive integer"))
+			return
+		}
+		filter.PerPage = value
+	}
+	for name, target := range map[string]**float64{"min_metric": &filter.MinMetric, "max_metric": &filter.MaxMetric} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, name+" must be a number"))
+			return
+		}
+		*target = &value
+	}
+	for name, target := range map[string]*string{"since": &filter.Since, "until": &filter.Until} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, name+" must be an RFC3339 timestamp"))
+			return
+		}
+		*target = at.UTC().Format(time.RFC3339)
+	}
 	authCtx, ok := common.AuthContextFrom(r.Context())
 	if !ok {
 		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
 		return
 	}
-	result, err := h.svc.List(r.Context(), authCtx, layer.Slug, scopeID, page)
+	if common.WantsNDJSON(r) {
+		// Unfiltered streams page with ledger bookmarks; filtered ones follow whichever
+		// paging the query uses, a rich-query bookmark or page numbers.
+		filter.BookmarkPaging = true
+		common.StreamNDJSON(w, r, func(ctx context.Context) ([]any, bool, error) {
+			result, err := h.svc.List(ctx, authCtx, layer.Slug, scopeID, page, filter)
+			if err != nil {
+				return nil, false, err
+			}
+			if result.Bookmark != "" {
+				filter.Bookmark = result.Bookmark
+			} else {
+				page++
+			}
+			return common.RedactItems(ctx, h.svc.cfg.ResponseFieldRoles, result.Items), result.HasMore && len(result.Items) > 0, nil
+		}, func(w http.ResponseWriter, err error) {
+			common.WriteErrorWithCode(w, common.StatusOf(err), err)
+		})
+		return
+	}
+	result, err := h.svc.List(r.Context(), authCtx, layer.Slug, scopeID, page, filter)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := common.AsStatusError(err); ok {
@@ -156,7 +425,22 @@ func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request, layer *
 		common.WriteErrorWithCode(w, status, err)
 		return
 	}
-	common.WriteJSON(w, http.StatusOK, result)
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+// extractMetadata reads the optional metrics and hyperparameters of a commit body.
+func extractMetadata(body map[string]json.RawMessage) (map[string]float64, json.RawMessage, error) {
+	var metrics map[string]float64
+	if raw, ok := body["metrics"]; ok {
+		if err := json.Unmarshal(raw, &metrics); err != nil {
+			return nil, nil, common.NewStatusError(http.StatusBadRequest, "metrics must be an object of numbers")
+		}
+	}
+	hyperparameters := body["hyperparameters"]
+	if string(hyperparameters) == "null" {
+		hyperparameters = nil
+	}
+	return metrics, hyperparameters, nil
 }
 
 func extractScopeID(body map[string]json.RawMessage, layer *Layer) (string, error) {
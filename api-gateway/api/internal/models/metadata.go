@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Well-known metrics, range-checked by the gateway and the chaincode. Other metrics are
+// accepted under names matching metricNamePattern.
+const (
+	MetricAccuracy   = "accuracy"
+	MetricLoss       = "loss"
+	MetricNumSamples = "num_samples"
+)
+
+var metricNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// validateMetadata checks the metrics and hyperparameters reported with a model.
+func validateMetadata(metrics map[string]float64, hyperparameters json.RawMessage) error {
+	for name, value := range metrics {
+		if err := validateMetric(name, value); err != nil {
+			return common.NewStatusError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if len(hyperparameters) > 0 {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(hyperparameters, &document); err != nil || document == nil {
+			return common.NewStatusError(http.StatusBadRequest, "hyperparameters must be a JSON object")
+		}
+	}
+	return nil
+}
+
+func validateMetric(name string, value float64) error {
+	if !metricNamePattern.MatchString(name) {
+		return fmt.Errorf("metric name %q must be lower-case letters, digits and underscores", name)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("metric %s must be a finite number", name)
+	}
+	switch name {
+	case MetricAccuracy:
+		if value < 0 || value > 1 {
+			return errors.New("accuracy must be between 0 and 1")
+		}
+	case MetricLoss:
+		if value < 0 {
+			return errors.New("loss must not be negative")
+		}
+	case MetricNumSamples:
+		if value < 0 || value != math.Trunc(value) {
+			return errors.New("num_samples must be a non-negative integer")
+		}
+	}
+	return nil
+}
+
+// metadataArg renders metrics and hyperparameters as the CommitModelWithMetadata argument.
+func metadataArg(metrics map[string]float64, hyperparameters json.RawMessage) string {
+	return common.MustJSON(map[string]any{"metrics": metrics, "hyperparameters": hyperparameters})
+}
+
+// decodeHyperparameters turns the compacted JSON string the chaincode stores back into an
+// object.
+func decodeHyperparameters(raw string) json.RawMessage {
+	if raw == "" || !json.Valid([]byte(raw)) {
+		return nil
+	}
+	return json.RawMessage(raw)
+}
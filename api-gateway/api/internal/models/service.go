@@ -3,25 +3,35 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/envelope"
 	"github.com/nebula/api-gateway/internal/registry"
+	"github.com/nebula/api-gateway/internal/rounds"
+	"github.com/nebula/api-gateway/internal/storage"
 )
 
-const defaultPageSize = 10
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
 
 // Service coordinates Fabric interactions for scoped model references.
 type Service struct {
 	cfg       *common.Config
 	fabric    *common.FabricClient
-	store     *registry.Store
+	store     registry.Store
+	rounds    *rounds.Service
 	layers    map[string]*Layer
 	layerList []*Layer
 	pageSize  int
+	payloads  *storage.Offloader
+	envelopes *envelope.Cipher
 }
 
 // Layer describes a logical scope that model references can belong to.
@@ -33,7 +43,7 @@ type Layer struct {
 }
 
 // NewService constructs a Service seeded with the initial layer definitions.
-func NewService(cfg *common.Config, fabric *common.FabricClient, store *registry.Store) *Service {
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store, rounds *rounds.Service) *Service {
 	layers := []*Layer{
 		{Name: "Cluster", Slug: "cluster", ScopeField: "cluster_id", ScopeLabel: "cluster"},
 		{Name: "State", Slug: "state", ScopeField: "state_id", ScopeLabel: "state"},
@@ -50,19 +60,51 @@ func NewService(cfg *common.Config, fabric *common.FabricClient, store *registry
 		cfg:       cfg,
 		fabric:    fabric,
 		store:     store,
+		rounds:    rounds,
 		layers:    index,
 		layerList: layers,
 		pageSize:  defaultPageSize,
 	}
 }
 
+// EnableStorage commits large payloads off-chain through payloads and rehydrates them on
+// read.
+func (s *Service) EnableStorage(payloads *storage.Offloader) {
+	s.payloads = payloads
+}
+
+// EnableEncryption seals payloads with the job's data key before they are committed and
+// opens them on read for the roles envelopes allows.
+func (s *Service) EnableEncryption(envelopes *envelope.Cipher) {
+	s.envelopes = envelopes
+}
+
 // Layers exposes the configured layer definitions in registration order.
 func (s *Service) Layers() []*Layer {
 	return s.layerList
 }
 
+// CommitOptions carries the optional provenance attached to a model commit.
+type CommitOptions struct {
+	// Round pins the commit to that training round, which must currently be open.
+	Round int
+	// ParentModelIDs names the models this one was aggregated from.
+	ParentModelIDs []string
+	// IdempotencyKey derives the model identifier, so a retried commit hits the same ledger
+	// key and is rejected by the chaincode instead of creating a duplicate.
+	IdempotencyKey string
+	// ModelHash and Signature attest the artifact: the chaincode verifies the signature with
+	// the trainer's registered public key before storing the record.
+	ModelHash string
+	Signature string
+	// Metrics and Hyperparameters describe how the model was trained; metrics can then be
+	// used to filter and sort listings.
+	Metrics         map[string]float64
+	Hyperparameters json.RawMessage
+}
+
 // Commit registers a model reference scoped to the provided layer.
-func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, layerSlug, scopeID string, payload json.RawMessage) (*CommitResult, error) {
+func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, layerSlug, scopeID string, payload json.RawMessage, opts *CommitOptions) (*CommitResult, error) {
 	if authCtx == nil {
 		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
 	}
@@ -81,25 +123,135 @@ func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, layer
 	if !ok {
 		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+	if err := validateMetadata(opts.Metrics, opts.Hyperparameters); err != nil {
+		return nil, err
+	}
+	parents := ""
+	if len(opts.ParentModelIDs) > 0 {
+		parents = common.MustJSON(opts.ParentModelIDs)
+	}
 	dataID := common.GeneratePrefixedID("model")
-	args := []string{"CommitModel", dataID, layer.Slug, scope, string(payload)}
-	peerName := s.fabric.SelectPeer()
+	if opts.IdempotencyKey != "" {
+		dataID = common.DeterministicID("model", authCtx.Subject, layer.Slug, opts.IdempotencyKey)
+	}
+	payloadHash, err := s.payloadHash(payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload, err = s.sealPayload(ctx, payload, payloadHash); err != nil {
+		return nil, err
+	}
+	args := []string{"CommitModel", dataID, layer.Slug, scope, string(payload), parents}
+	if opts.Round > 0 {
+		if err := s.rounds.RequireOpen(ctx, enrolment.FabricClientID, layer.Slug, scope, opts.Round); err != nil {
+			return nil, err
+		}
+		args = []string{"CommitModelInRound", dataID, s.cfg.JobID, layer.Slug, scope, strconv.Itoa(opts.Round), string(payload), parents}
+	}
+	duplicates, err := s.findDuplicates(ctx, payloadHash, duplicateTarget{Layer: layer.Slug, ScopeID: scope, JobID: s.cfg.JobID, Round: opts.Round}, dataID)
+	if err != nil {
+		return nil, err
+	}
+	modelHash := strings.TrimSpace(opts.ModelHash)
+	attested := modelHash != "" || strings.TrimSpace(opts.Signature) != ""
+	if attested {
+		round := ""
+		if opts.Round > 0 {
+			round = strconv.Itoa(opts.Round)
+		}
+		args = []string{"CommitAttestedModel", dataID, layer.Slug, scope, string(payload), parents, s.cfg.JobID, round, modelHash, strings.TrimSpace(opts.Signature)}
+	}
+	if len(opts.Metrics) > 0 || len(opts.Hyperparameters) > 0 {
+		round := ""
+		if opts.Round > 0 {
+			round = strconv.Itoa(opts.Round)
+		}
+		args = []string{"CommitModelWithMetadata", dataID, layer.Slug, scope, string(payload), parents, s.cfg.JobID, round, modelHash, strings.TrimSpace(opts.Signature), metadataArg(opts.Metrics, opts.Hyperparameters)}
+	}
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	if err := s.fabric.InvokeChaincode(peerName, enrolment.FabricClientID, args); err != nil {
+	receipt, err := s.fabric.InvokeChaincode(ctx, peerName, enrolment.FabricClientID, args)
+	if err != nil {
+		if opts.IdempotencyKey != "" && strings.Contains(err.Error(), "already exists") {
+			return s.replayCommit(ctx, authCtx, enrolment, dataID)
+		}
+		if attested {
+			return nil, asAttestationError(err, AttestationMessage{Layer: layer.Slug, ScopeID: scope, ModelHash: modelHash})
+		}
+		if strings.Contains(err.Error(), "in membership epoch") {
+			// A clustering plan moved the trainer to another cluster.
+			return nil, common.NewStatusError(http.StatusConflict, err.Error())
+		}
 		return nil, err
 	}
 	return &CommitResult{
-		DataID:      dataID,
-		Layer:       layer.Slug,
-		ScopeID:     scope,
-		NodeID:      enrolment.NodeID,
-		VCHash:      enrolment.VCHash,
-		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		DataID:         dataID,
+		Layer:          layer.Slug,
+		ScopeID:        scope,
+		NodeID:         enrolment.NodeID,
+		VCHash:         enrolment.VCHash,
+		Round:          opts.Round,
+		ParentModelIDs: opts.ParentModelIDs,
+		ModelHash:      modelHash,
+		PayloadHash:    payloadHash,
+		DuplicateOf:    duplicates,
+		Metrics:        opts.Metrics,
+		SubmittedAt:    time.Now().UTC().Format(time.RFC3339),
+		TxID:           receipt.TxID,
+		BlockNumber:    receipt.BlockNumber,
+	}, nil
+}
+
+// replayCommit rebuilds the result of an earlier commit made with the same Idempotency-Key,
+// e.g. when the original response was lost or served by another gateway instance.
+func (s *Service) replayCommit(ctx context.Context, authCtx *common.AuthContext, enrolment *registry.TrainerRecord, dataID string) (*CommitResult, error) {
+	record, err := s.Retrieve(ctx, authCtx, dataID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Owner != enrolment.NodeID {
+		return nil, common.NewStatusError(http.StatusConflict, fmt.Sprintf("model %s already exists", dataID))
+	}
+	receipt := s.originalReceipt(ctx, authCtx, enrolment, dataID)
+	return &CommitResult{
+		DataID:         record.DataID,
+		Layer:          record.Layer,
+		ScopeID:        record.ScopeID,
+		NodeID:         record.Owner,
+		VCHash:         enrolment.VCHash,
+		Round:          record.Round,
+		ParentModelIDs: record.ParentModelIDs,
+		ModelHash:      record.ModelHash,
+		PayloadHash:    record.PayloadHash,
+		Metrics:        record.Metrics,
+		SubmittedAt:    record.SubmittedAt,
+		TxID:           receipt.TxID,
+		BlockNumber:    receipt.BlockNumber,
 	}, nil
 }
 
+// originalReceipt recovers the transaction that created dataID from its history. Lookup
+// failures leave the receipt empty rather than failing the replay.
+func (s *Service) originalReceipt(ctx context.Context, authCtx *common.AuthContext, enrolment *registry.TrainerRecord, dataID string) *common.TxReceipt {
+	receipt := &common.TxReceipt{}
+	history, err := s.History(ctx, authCtx, dataID)
+	if err != nil || len(history.Entries) == 0 {
+		return receipt
+	}
+	receipt.TxID = history.Entries[0].TxID
+	if s.cfg.FabricReceiptBlocks && receipt.TxID != "" {
+		if number, err := s.fabric.BlockNumberForTx(ctx, s.fabric.SelectPeer(common.PeerRead), enrolment.FabricClientID, receipt.TxID); err == nil {
+			receipt.BlockNumber = number
+		}
+	}
+	return receipt
+}
+
 // Retrieve fetches a specific model reference by identifier.
 func (s *Service) Retrieve(ctx context.Context, authCtx *common.AuthContext, dataID string) (*ModelRecord, error) {
 	if authCtx == nil {
@@ -114,11 +266,11 @@ func (s *Service) Retrieve(ctx context.Context, authCtx *common.AuthContext, dat
 		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
 	args := []string{"ReadModel", dataID}
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerRead)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	raw, err := s.fabric.QueryChaincode(peerName, enrolment.FabricClientID, args)
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, enrolment.FabricClientID, args)
 	if err != nil {
 		return nil, err
 	}
@@ -126,11 +278,61 @@ func (s *Service) Retrieve(ctx context.Context, authCtx *common.AuthContext, dat
 	if err := json.Unmarshal(raw, &ledger); err != nil {
 		return nil, err
 	}
-	return ledger.toModelRecord(), nil
+	record := ledger.toModelRecord()
+	if err := s.rehydrate(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListFilter narrows listings by owner, submission time and a reported metric. Bookmark
+// continues a CouchDB rich query and is ignored on LevelDB networks. Order ("asc" or
+// "desc") sorts by Metric; sorted listings always use a range scan, so the first page of a
+// descending listing holds the top PerPage models.
+type ListFilter struct {
+	Owner     string
+	Since     string
+	Until     string
+	Metric    string
+	MinMetric *float64
+	MaxMetric *float64
+	Order     string
+	PerPage   int
+	Bookmark  string
+	// BookmarkPaging pages unfiltered listings with ledger bookmarks instead of page numbers;
+	// Total is then "none", "estimate" or "exact".
+	BookmarkPaging bool
+	Total          string
+}
+
+func (f *ListFilter) empty() bool {
+	return f == nil || (f.Owner == "" && f.Since == "" && f.Until == "" && f.Metric == "")
+}
+
+func (f *ListFilter) validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.PerPage < 0 || f.PerPage > maxPageSize {
+		return common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("per_page must be between 1 and %d", maxPageSize))
+	}
+	if f.Order != "" && f.Order != "asc" && f.Order != "desc" {
+		return common.NewStatusError(http.StatusBadRequest, "order must be asc or desc")
+	}
+	if f.Metric == "" {
+		if f.MinMetric != nil || f.MaxMetric != nil || f.Order != "" {
+			return common.NewStatusError(http.StatusBadRequest, "metric is required to filter or sort by metric")
+		}
+		return nil
+	}
+	if !metricNamePattern.MatchString(f.Metric) {
+		return common.NewStatusError(http.StatusBadRequest, "metric must be lower-case letters, digits and underscores")
+	}
+	return nil
 }
 
 // List returns a paginated collection of model references filtered by scope.
-func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, layerSlug, scopeID string, page int) (*ListResult, error) {
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, layerSlug, scopeID string, page int, filter *ListFilter) (*ListResult, error) {
 	if authCtx == nil {
 		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
 	}
@@ -141,12 +343,19 @@ func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, layerSl
 	if err != nil {
 		return nil, err
 	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	perPage := s.pageSize
+	if filter != nil && filter.PerPage > 0 {
+		perPage = filter.PerPage
+	}
 	enrolment, ok := s.store.FindByJWTSub(authCtx.Subject)
 	if !ok {
 		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
 	}
 	scope := strings.TrimSpace(scopeID)
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerRead)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
@@ -155,9 +364,28 @@ func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, layerSl
 		layer.Slug,
 		scope,
 		strconv.Itoa(page),
-		strconv.Itoa(s.pageSize),
+		strconv.Itoa(perPage),
 	}
-	raw, err := s.fabric.QueryChaincode(peerName, enrolment.FabricClientID, args)
+	if !filter.empty() {
+		selector := common.MustJSON(map[string]any{
+			"layer":      layer.Slug,
+			"scope_id":   scope,
+			"owner":      filter.Owner,
+			"since":      filter.Since,
+			"until":      filter.Until,
+			"metric":     filter.Metric,
+			"min_metric": filter.MinMetric,
+			"max_metric": filter.MaxMetric,
+			"order":      filter.Order,
+		})
+		if s.cfg.RichQueriesEnabled() && filter.Order == "" {
+			return s.queryRich(ctx, peerName, enrolment.FabricClientID, selector, filter.Bookmark, perPage)
+		}
+		args = []string{"ListModelsFiltered", selector, strconv.Itoa(page), strconv.Itoa(perPage)}
+	} else if filter != nil && filter.BookmarkPaging {
+		return s.listBookmarked(ctx, peerName, enrolment.FabricClientID, layer.Slug, scope, filter.Bookmark, perPage, filter.Total)
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, enrolment.FabricClientID, args)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +393,219 @@ func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, layerSl
 	if err := json.Unmarshal(raw, &ledgerPage); err != nil {
 		return nil, err
 	}
-	return ledgerPage.toListResult(), nil
+	result := ledgerPage.toListResult()
+	if err := s.rehydrate(ctx, result.Items...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listBookmarked reads one page of a layer listing with the ledger's bookmark pagination, so
+// the chaincode reads at most one page of index entries plus the requested total.
+func (s *Service) listBookmarked(ctx context.Context, peerName, identity, layer, scope, bookmark string, pageSize int, total string) (*ListResult, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, identity, []string{"ListModelsPage", layer, scope, strconv.Itoa(pageSize), bookmark, total})
+	if err != nil {
+		if strings.Contains(err.Error(), "total must be") {
+			return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+		}
+		return nil, err
+	}
+	var ledgerPage ledgerModelBookmarkPage
+	if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+		return nil, err
+	}
+	result := &ListResult{
+		Items:    make([]*ModelRecord, 0, len(ledgerPage.Items)),
+		PerPage:  ledgerPage.PageSize,
+		Total:    len(ledgerPage.Items),
+		HasMore:  ledgerPage.HasMore,
+		Bookmark: ledgerPage.Bookmark,
+	}
+	if ledgerPage.Total != nil {
+		result.Total = ledgerPage.Total.Count
+		result.TotalEstimated = ledgerPage.Total.Estimated
+	}
+	for _, item := range ledgerPage.Items {
+		if item != nil {
+			result.Items = append(result.Items, item.toModelRecord())
+		}
+	}
+	if err := s.rehydrate(ctx, result.Items...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) queryRich(ctx context.Context, peerName, identity, selector, bookmark string, pageSize int) (*ListResult, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, identity, []string{"QueryModels", selector, strconv.Itoa(pageSize), bookmark})
+	if err != nil {
+		return nil, err
+	}
+	var ledgerPage ledgerModelQueryPage
+	if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+		return nil, err
+	}
+	result := &ListResult{
+		Items:    make([]*ModelRecord, 0, len(ledgerPage.Items)),
+		PerPage:  ledgerPage.PageSize,
+		Total:    ledgerPage.Count,
+		HasMore:  ledgerPage.Count == ledgerPage.PageSize && ledgerPage.Bookmark != "",
+		Bookmark: ledgerPage.Bookmark,
+	}
+	for _, item := range ledgerPage.Items {
+		if item != nil {
+			result.Items = append(result.Items, item.toModelRecord())
+		}
+	}
+	if err := s.rehydrate(ctx, result.Items...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Lineage returns the ancestors of a model, following parent_model_ids breadth first.
+func (s *Service) Lineage(ctx context.Context, authCtx *common.AuthContext, dataID string, maxDepth int) (*Lineage, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	dataID = strings.TrimSpace(dataID)
+	if dataID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "data identifier is required")
+	}
+	enrolment, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	depth := ""
+	if maxDepth > 0 {
+		depth = strconv.Itoa(maxDepth)
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), enrolment.FabricClientID, []string{"GetModelLineage", dataID, depth})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, common.NewStatusError(http.StatusNotFound, err.Error())
+		}
+		return nil, err
+	}
+	var ledger ledgerModelLineage
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	lineage := ledger.toLineage()
+	records := []*ModelRecord{lineage.Model}
+	for _, node := range lineage.Ancestors {
+		records = append(records, node.Model)
+	}
+	if err := s.rehydrate(ctx, records...); err != nil {
+		return nil, err
+	}
+	return lineage, nil
+}
+
+// History lists every ledger write to a model record, oldest first, with its transaction
+// ID and timestamp.
+func (s *Service) History(ctx context.Context, authCtx *common.AuthContext, dataID string) (*History, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	dataID = strings.TrimSpace(dataID)
+	if dataID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "data identifier is required")
+	}
+	enrolment, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer not registered")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), enrolment.FabricClientID, []string{"GetModelHistory", dataID})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, common.NewStatusError(http.StatusNotFound, err.Error())
+		}
+		return nil, err
+	}
+	var ledger []*ledgerHistoryEntry
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	history := &History{ModelID: dataID, Entries: make([]*HistoryEntry, 0, len(ledger))}
+	for _, entry := range ledger {
+		if entry == nil {
+			continue
+		}
+		model := entry.Record.toModelRecord()
+		if err := s.rehydrate(ctx, model); err != nil {
+			return nil, err
+		}
+		history.Entries = append(history.Entries, &HistoryEntry{
+			TxID:      entry.TxID,
+			Timestamp: entry.Timestamp,
+			IsDelete:  entry.IsDelete,
+			Model:     model,
+		})
+	}
+	return history, nil
+}
+
+// Counts returns the number of model references per configured layer, zero included.
+func (s *Service) Counts(ctx context.Context) (map[string]int, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"CountModels"})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(s.layerList))
+	if err := json.Unmarshal(raw, &counts); err != nil {
+		return nil, err
+	}
+	for _, layer := range s.layerList {
+		if _, ok := counts[layer.Slug]; !ok {
+			counts[layer.Slug] = 0
+		}
+	}
+	return counts, nil
+}
+
+// Latest returns the latest model reference of each scope of a layer, keyed by lower-cased
+// scope ID. Scopes without models are left out.
+func (s *Service) Latest(ctx context.Context, layerSlug string, scopeIDs []string) (map[string]*ModelRecord, error) {
+	layer, err := s.layerBySlug(layerSlug)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListLatestModels", layer.Slug, common.MustJSON(scopeIDs)})
+	if err != nil {
+		return nil, err
+	}
+	var ledger map[string]*ledgerModelRecord
+	if err := json.Unmarshal(raw, &ledger); err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*ModelRecord, len(ledger))
+	for scope, record := range ledger {
+		latest[scope] = record.toModelRecord()
+		if err := s.rehydrate(ctx, latest[scope]); err != nil {
+			return nil, err
+		}
+	}
+	return latest, nil
+}
+
+// rehydrate replaces off-chain pointers in the records' payloads with the payloads they point
+// to, and opens encrypted payloads for callers allowed to read them.
+func (s *Service) rehydrate(ctx context.Context, records ...*ModelRecord) error {
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+		payload, err := s.payloads.Rehydrate(ctx, record.Payload)
+		if err != nil {
+			return err
+		}
+		if payload, err = s.envelopes.Reveal(ctx, payload); err != nil {
+			return err
+		}
+		record.Payload = payload
+	}
+	return nil
 }
 
 func (s *Service) layerBySlug(slug string) (*Layer, error) {
@@ -180,42 +620,74 @@ func (s *Service) layerBySlug(slug string) (*Layer, error) {
 	return layer, nil
 }
 
-// CommitResult is returned after successfully recording a model reference.
+// CommitResult is returned after successfully recording a model reference. DuplicateOf
+// lists earlier models with the same payload hash in the same scope and round.
 type CommitResult struct {
-	DataID      string `json:"data_id"`
-	Layer       string `json:"layer"`
-	ScopeID     string `json:"scope_id"`
-	NodeID      string `json:"node_id"`
-	VCHash      string `json:"vc_hash"`
-	SubmittedAt string `json:"submitted_at"`
+	DataID         string             `json:"data_id"`
+	Layer          string             `json:"layer"`
+	ScopeID        string             `json:"scope_id"`
+	NodeID         string             `json:"node_id"`
+	VCHash         string             `json:"vc_hash"`
+	Round          int                `json:"round,omitempty"`
+	ParentModelIDs []string           `json:"parent_model_ids,omitempty"`
+	ModelHash      string             `json:"model_hash,omitempty"`
+	PayloadHash    string             `json:"payload_hash"`
+	DuplicateOf    []string           `json:"duplicate_of,omitempty"`
+	Metrics        map[string]float64 `json:"metrics,omitempty"`
+	SubmittedAt    string             `json:"submitted_at"`
+	TxID           string             `json:"tx_id,omitempty"`
+	BlockNumber    uint64             `json:"block_number,omitempty"`
 }
 
 // ModelRecord represents a model reference on-chain.
 type ModelRecord struct {
-	DataID      string          `json:"data_id"`
-	Layer       string          `json:"layer"`
-	ScopeID     string          `json:"scope_id"`
-	Owner       string          `json:"owner"`
-	Payload     json.RawMessage `json:"payload"`
-	SubmittedAt string          `json:"submitted_at"`
+	DataID          string             `json:"data_id"`
+	Layer           string             `json:"layer"`
+	ScopeID         string             `json:"scope_id"`
+	Owner           string             `json:"owner"`
+	Payload         json.RawMessage    `json:"payload"`
+	SubmittedAt     string             `json:"submitted_at"`
+	JobID           string             `json:"job_id,omitempty"`
+	Round           int                `json:"round,omitempty"`
+	ParentModelIDs  []string           `json:"parent_model_ids,omitempty"`
+	ModelHash       string             `json:"model_hash,omitempty"`
+	Signature       string             `json:"signature,omitempty"`
+	PayloadHash     string             `json:"payload_hash,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters json.RawMessage    `json:"hyperparameters,omitempty"`
+	// Epoch is the membership epoch the model was committed in.
+	Epoch int `json:"epoch,omitempty"`
 }
 
-// ListResult represents one page of model references.
+// ListResult represents one page of model references. Bookmark and rich-query pages carry a
+// bookmark instead of a page number, and Total counts only the records on the page unless a
+// total was requested; TotalEstimated marks a requested total that is a lower bound.
 type ListResult struct {
-	Items   []*ModelRecord `json:"items"`
-	Page    int            `json:"page"`
-	PerPage int            `json:"per_page"`
-	Total   int            `json:"total"`
-	HasMore bool           `json:"has_more"`
+	Items          []*ModelRecord `json:"items"`
+	Page           int            `json:"page,omitempty"`
+	PerPage        int            `json:"per_page"`
+	Total          int            `json:"total"`
+	TotalEstimated bool           `json:"total_estimated,omitempty"`
+	HasMore        bool           `json:"has_more"`
+	Bookmark       string         `json:"bookmark,omitempty"`
 }
 
 type ledgerModelRecord struct {
-	ID          string          `json:"id"`
-	Layer       string          `json:"layer"`
-	ScopeID     string          `json:"scope_id"`
-	Owner       string          `json:"owner"`
-	Payload     json.RawMessage `json:"payload"`
-	SubmittedAt string          `json:"submitted_at"`
+	ID              string             `json:"id"`
+	Layer           string             `json:"layer"`
+	ScopeID         string             `json:"scope_id"`
+	Owner           string             `json:"owner"`
+	Payload         json.RawMessage    `json:"payload"`
+	SubmittedAt     string             `json:"submitted_at"`
+	JobID           string             `json:"job_id,omitempty"`
+	Round           int                `json:"round,omitempty"`
+	ParentModelIDs  []string           `json:"parent_model_ids,omitempty"`
+	ModelHash       string             `json:"model_hash,omitempty"`
+	Signature       string             `json:"signature,omitempty"`
+	PayloadHash     string             `json:"payload_hash,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters string             `json:"hyperparameters,omitempty"`
+	Epoch           int                `json:"epoch,omitempty"`
 }
 
 func (l *ledgerModelRecord) toModelRecord() *ModelRecord {
@@ -223,15 +695,42 @@ func (l *ledgerModelRecord) toModelRecord() *ModelRecord {
 		return nil
 	}
 	return &ModelRecord{
-		DataID:      l.ID,
-		Layer:       l.Layer,
-		ScopeID:     l.ScopeID,
-		Owner:       l.Owner,
-		Payload:     l.Payload,
-		SubmittedAt: l.SubmittedAt,
+		DataID:          l.ID,
+		Layer:           l.Layer,
+		ScopeID:         l.ScopeID,
+		Owner:           l.Owner,
+		Payload:         l.Payload,
+		SubmittedAt:     l.SubmittedAt,
+		JobID:           l.JobID,
+		Round:           l.Round,
+		ParentModelIDs:  l.ParentModelIDs,
+		ModelHash:       l.ModelHash,
+		Signature:       l.Signature,
+		PayloadHash:     l.PayloadHash,
+		Metrics:         l.Metrics,
+		Hyperparameters: decodeHyperparameters(l.Hyperparameters),
+		Epoch:           l.Epoch,
 	}
 }
 
+type ledgerModelQueryPage struct {
+	Items    []*ledgerModelRecord `json:"items"`
+	PageSize int                  `json:"page_size"`
+	Count    int                  `json:"count"`
+	Bookmark string               `json:"bookmark"`
+}
+
+type ledgerModelBookmarkPage struct {
+	Items    []*ledgerModelRecord `json:"items"`
+	PageSize int                  `json:"page_size"`
+	Bookmark string               `json:"bookmark"`
+	HasMore  bool                 `json:"has_more"`
+	Total    *struct {
+		Count     int  `json:"count"`
+		Estimated bool `json:"estimated"`
+	} `json:"total"`
+}
+
 type ledgerModelList struct {
 	Items   []*ledgerModelRecord `json:"items"`
 	Page    int                  `json:"page"`
@@ -260,3 +759,69 @@ func (l *ledgerModelList) toListResult() *ListResult {
 	result.Items = items
 	return result
 }
+
+// LineageNode is one ancestor and its distance from the requested model.
+type LineageNode struct {
+	Depth int          `json:"depth"`
+	Model *ModelRecord `json:"model"`
+}
+
+// Lineage describes the provenance of a model back to its contributing models.
+type Lineage struct {
+	ModelID   string         `json:"model_id"`
+	Model     *ModelRecord   `json:"model"`
+	Ancestors []*LineageNode `json:"ancestors"`
+	Missing   []string       `json:"missing,omitempty"`
+	Truncated bool           `json:"truncated"`
+}
+
+// HistoryEntry is one mutation of a model record.
+type HistoryEntry struct {
+	TxID      string       `json:"tx_id"`
+	Timestamp string       `json:"timestamp"`
+	IsDelete  bool         `json:"is_delete"`
+	Model     *ModelRecord `json:"model,omitempty"`
+}
+
+// History is the audit trail of a model record.
+type History struct {
+	ModelID string          `json:"model_id"`
+	Entries []*HistoryEntry `json:"entries"`
+}
+
+type ledgerHistoryEntry struct {
+	TxID      string             `json:"tx_id"`
+	Timestamp string             `json:"timestamp"`
+	IsDelete  bool               `json:"is_delete"`
+	Record    *ledgerModelRecord `json:"record"`
+}
+
+type ledgerLineageNode struct {
+	Depth int                `json:"depth"`
+	Model *ledgerModelRecord `json:"model"`
+}
+
+type ledgerModelLineage struct {
+	ModelID   string               `json:"model_id"`
+	Model     *ledgerModelRecord   `json:"model"`
+	Ancestors []*ledgerLineageNode `json:"ancestors"`
+	Missing   []string             `json:"missing"`
+	Truncated bool                 `json:"truncated"`
+}
+
+func (l *ledgerModelLineage) toLineage() *Lineage {
+	lineage := &Lineage{
+		ModelID:   l.ModelID,
+		Model:     l.Model.toModelRecord(),
+		Ancestors: make([]*LineageNode, 0, len(l.Ancestors)),
+		Missing:   l.Missing,
+		Truncated: l.Truncated,
+	}
+	for _, node := range l.Ancestors {
+		if node == nil {
+			continue
+		}
+		lineage.Ancestors = append(lineage.Ancestors, &LineageNode{Depth: node.Depth, Model: node.Model.toModelRecord()})
+	}
+	return lineage
+}
@@ -0,0 +1,206 @@
+package nation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Candidate mirrors the on-chain GlobalCandidate: a state model nominated as the next global
+// model. Status moves from PENDING to APPROVED or REJECTED, and from APPROVED to PUBLISHED.
+type Candidate struct {
+	ModelID     string `json:"model_id"`
+	StateID     string `json:"state_id"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	PayloadHash string `json:"payload_hash,omitempty"`
+	Status      string `json:"status"`
+	Note        string `json:"note,omitempty"`
+	NominatedBy string `json:"nominated_by"`
+	NominatedAt string `json:"nominated_at"`
+	ReviewedBy  string `json:"reviewed_by,omitempty"`
+	ReviewedAt  string `json:"reviewed_at,omitempty"`
+	ReviewNote  string `json:"review_note,omitempty"`
+	Version     int    `json:"version,omitempty"`
+}
+
+// GlobalModel mirrors the on-chain GlobalModel: one immutable published version.
+type GlobalModel struct {
+	Version     int    `json:"version"`
+	ModelID     string `json:"model_id"`
+	StateID     string `json:"state_id"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	ModelCID    string `json:"model_cid"`
+	ModelHash   string `json:"model_hash"`
+	ApprovedBy  string `json:"approved_by"`
+	PublishedBy string `json:"published_by"`
+	TxID        string `json:"tx_id"`
+	PublishedAt string `json:"published_at"`
+}
+
+// NominateRequest is the payload for nominating a state model.
+type NominateRequest struct {
+	ModelID string `json:"model_id"`
+	Note    string `json:"note,omitempty"`
+}
+
+// ReviewRequest is the central checker's decision on a candidate: "approve" or "reject".
+type ReviewRequest struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+}
+
+// PublishRequest is the payload for publishing an approved candidate. ModelHash defaults to
+// the payload hash recorded with the model.
+type PublishRequest struct {
+	ModelID   string `json:"model_id"`
+	ModelCID  string `json:"model_cid"`
+	ModelHash string `json:"model_hash,omitempty"`
+}
+
+// Nominate proposes a state model as the next global model.
+func (s *Service) Nominate(ctx context.Context, authCtx *common.AuthContext, req *NominateRequest) (*Candidate, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID := strings.TrimSpace(req.ModelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	}
+	var candidate Candidate
+	if err := s.submit(ctx, authCtx, []string{"NominateGlobalModel", modelID, strings.TrimSpace(req.Note)}, &candidate); err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// Review records the central checker's decision on a pending candidate.
+func (s *Service) Review(ctx context.Context, authCtx *common.AuthContext, modelID string, req *ReviewRequest) (*Candidate, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model id is required")
+	}
+	decision := strings.ToLower(strings.TrimSpace(req.Decision))
+	if decision != "approve" && decision != "reject" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "decision must be approve or reject")
+	}
+	var candidate Candidate
+	if err := s.submit(ctx, authCtx, []string{"ReviewGlobalCandidate", modelID, decision, strings.TrimSpace(req.Note)}, &candidate); err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// Publish turns an approved candidate into the next global model version.
+func (s *Service) Publish(ctx context.Context, authCtx *common.AuthContext, req *PublishRequest) (*GlobalModel, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	modelID := strings.TrimSpace(req.ModelID)
+	modelCID := strings.TrimSpace(req.ModelCID)
+	switch {
+	case modelID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	case modelCID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_cid is required")
+	}
+	var record GlobalModel
+	if err := s.submit(ctx, authCtx, []string{"PublishGlobalModel", modelID, modelCID, strings.TrimSpace(req.ModelHash)}, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Candidate returns the nomination of a model.
+func (s *Service) Candidate(ctx context.Context, authCtx *common.AuthContext, modelID string) (*Candidate, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model id is required")
+	}
+	var candidate Candidate
+	if err := s.query(ctx, authCtx, []string{"ReadGlobalCandidate", modelID}, &candidate); err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// Candidates lists nominations, optionally only those with status.
+func (s *Service) Candidates(ctx context.Context, authCtx *common.AuthContext, status string) ([]*Candidate, error) {
+	var candidates []*Candidate
+	if err := s.query(ctx, authCtx, []string{"ListGlobalCandidates", strings.TrimSpace(status)}, &candidates); err != nil {
+		return nil, err
+	}
+	if candidates == nil {
+		candidates = []*Candidate{}
+	}
+	return candidates, nil
+}
+
+// GlobalModel returns a published version; version 0 selects the latest.
+func (s *Service) GlobalModel(ctx context.Context, authCtx *common.AuthContext, version int) (*GlobalModel, error) {
+	arg := ""
+	if version > 0 {
+		arg = strconv.Itoa(version)
+	}
+	var record GlobalModel
+	if err := s.query(ctx, authCtx, []string{"ReadGlobalModel", arg}, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GlobalModels returns the version history, oldest first.
+func (s *Service) GlobalModels(ctx context.Context, authCtx *common.AuthContext) ([]*GlobalModel, error) {
+	var records []*GlobalModel
+	if err := s.query(ctx, authCtx, []string{"ListGlobalModels"}, &records); err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*GlobalModel{}
+	}
+	return records, nil
+}
+
+func (s *Service) submit(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.identityFor(authCtx), args)
+	if err != nil {
+		return mapGlobalError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) query(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), args)
+	if err != nil {
+		return mapGlobalError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func mapGlobalError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "already nominated"), strings.Contains(msg, "is already"), strings.Contains(msg, "not approved"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "no global model published"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "is not permitted to call"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"), strings.Contains(msg, "does not match"),
+		strings.Contains(msg, "only state models"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,288 @@
+package nation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes nation aggregation endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the nation HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/nation/aggregations`, `/nation/states` and the global model
+// registry under `/nation/candidates` and `/nation/global-models`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	mux.Handle("/nation/aggregations", auth.RequireAuth(http.HandlerFunc(h.handleCollection), readers...))
+	mux.Handle("/nation/aggregations/", auth.RequireAuth(http.HandlerFunc(h.handleRound), readers...))
+	mux.Handle("/nation/states", auth.RequireAuth(http.HandlerFunc(h.handleStates), readers...))
+	mux.Handle("/nation/candidates", auth.RequireAuth(http.HandlerFunc(h.handleCandidates), readers...))
+	mux.Handle("/nation/candidates/", auth.RequireAuth(http.HandlerFunc(h.handleCandidate), readers...))
+	mux.Handle("/nation/global-models", auth.RequireAuth(http.HandlerFunc(h.handleGlobalModels), readers...))
+	mux.Handle("/nation/global-models/", auth.RequireAuth(http.HandlerFunc(h.handleGlobalModel), readers...))
+}
+
+// Describe documents the nation aggregation endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("nation")
+	readers := []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}
+	api.Add(http.MethodPost, "/nation/aggregations", openapi.Operation{Summary: "Record a nation aggregation", Roles: []common.Role{common.RoleAggregator}, Body: CommitRequest{}, Response: Aggregation{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/nation/aggregations", openapi.Operation{Summary: "List nation aggregations", Roles: readers, Response: map[string]any{"items": []*Aggregation{}}})
+	api.Add(http.MethodGet, "/nation/aggregations/{round}", openapi.Operation{Summary: "Read the aggregation of a round", Roles: readers, Response: Aggregation{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/nation/states", openapi.Operation{Summary: "List the states known to the nation", Roles: readers, Response: map[string]any{"states": []string{}}})
+	api.Add(http.MethodPost, "/nation/candidates", openapi.Operation{Summary: "Nominate a state model as the next global model", Roles: []common.Role{common.RoleAggregator}, Body: NominateRequest{}, Response: Candidate{}, Status: http.StatusCreated, Errors: []int{http.StatusNotFound, http.StatusConflict}})
+	api.Add(http.MethodGet, "/nation/candidates", openapi.Operation{
+		Summary:  "List global model candidates",
+		Roles:    readers,
+		Query:    []openapi.Param{{Name: "status", Description: "pending, approved, rejected or published."}},
+		Response: map[string]any{"items": []*Candidate{}},
+	})
+	api.Add(http.MethodGet, "/nation/candidates/{model_id}", openapi.Operation{Summary: "Read a candidate", Roles: readers, Response: Candidate{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/nation/candidates/{model_id}/review", openapi.Operation{Summary: "Approve or reject a pending candidate", Roles: []common.Role{common.RoleCentralChecker}, Body: ReviewRequest{}, Response: Candidate{}, Errors: []int{http.StatusNotFound, http.StatusConflict}})
+	api.Add(http.MethodPost, "/nation/global-models", openapi.Operation{
+		Summary:     "Publish an approved candidate as the next global model version",
+		Description: "Versions are numbered from 1 and never rewritten. model_hash defaults to, and must match, the payload hash recorded with the model.",
+		Roles:       []common.Role{common.RoleAggregator, common.RoleAdmin},
+		Body:        PublishRequest{},
+		Response:    GlobalModel{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+	api.Add(http.MethodGet, "/nation/global-models", openapi.Operation{Summary: "List published global model versions, oldest first", Roles: readers, Response: map[string]any{"items": []*GlobalModel{}}})
+	api.Add(http.MethodGet, "/nation/global-models/{version}", openapi.Operation{Summary: "Read a global model version; `latest` selects the newest", Roles: readers, Response: GlobalModel{}, Errors: []int{http.StatusNotFound}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can record nation aggregations"))
+			return
+		}
+		var req CommitRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Commit(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	case http.MethodGet:
+		records, err := h.svc.List(r.Context(), authCtx)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": records})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleRound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	round, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/nation/aggregations/"))
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer"))
+		return
+	}
+	record, err := h.svc.Get(r.Context(), authCtx, round)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, record)
+}
+
+func (h *HTTPHandler) handleStates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	states, err := h.svc.States(r.Context(), authCtx)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"states": states})
+}
+
+func (h *HTTPHandler) handleCandidates(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators can nominate global models"))
+			return
+		}
+		var req NominateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		candidate, err := h.svc.Nominate(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, candidate)
+	case http.MethodGet:
+		candidates, err := h.svc.Candidates(r.Context(), authCtx, r.URL.Query().Get("status"))
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": candidates})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleCandidate serves `/nation/candidates/{modelId}` and its `/review` action.
+func (h *HTTPHandler) handleCandidate(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	modelID := strings.TrimPrefix(r.URL.Path, "/nation/candidates/")
+	if id, found := strings.CutSuffix(modelID, "/review"); found {
+		if r.Method != http.MethodPost {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		if !authCtx.Permits(common.RoleCentralChecker) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only central checkers can review global model candidates"))
+			return
+		}
+		var req ReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		candidate, err := h.svc.Review(r.Context(), authCtx, id, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, candidate)
+		return
+	}
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	candidate, err := h.svc.Candidate(r.Context(), authCtx, modelID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, candidate)
+}
+
+func (h *HTTPHandler) handleGlobalModels(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !authCtx.Permits(common.RoleAggregator, common.RoleAdmin) {
+			common.WriteErrorWithCode(w, http.StatusForbidden, common.NewStatusError(http.StatusForbidden, "only aggregators and admins can publish global models"))
+			return
+		}
+		var req PublishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		record, err := h.svc.Publish(r.Context(), authCtx, &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, record)
+	case http.MethodGet:
+		records, err := h.svc.GlobalModels(r.Context(), authCtx)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": records})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleGlobalModel serves `/nation/global-models/{version}` and `/nation/global-models/latest`.
+func (h *HTTPHandler) handleGlobalModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	version := 0
+	if raw := strings.TrimPrefix(r.URL.Path, "/nation/global-models/"); raw != "latest" {
+		parsed, err := strconv.Atoi(strings.TrimPrefix(raw, "v"))
+		if err != nil || parsed < 1 {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "version must be a positive integer or latest"))
+			return
+		}
+		version = parsed
+	}
+	record, err := h.svc.GlobalModel(r.Context(), authCtx, version)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, record)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
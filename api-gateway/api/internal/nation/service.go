@@ -0,0 +1,195 @@
+package nation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service records nation-level aggregation results: the global model CID per round and the
+// states that contributed to it.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a nation service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Aggregation mirrors the on-chain NationAggregation record.
+type Aggregation struct {
+	Round       int             `json:"round"`
+	ModelCID    string          `json:"model_cid"`
+	States      []string        `json:"states"`
+	Aggregator  string          `json:"aggregator"`
+	Strategy    string          `json:"strategy,omitempty"`
+	SampleCount int             `json:"sample_count,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	TxID        string          `json:"tx_id"`
+	RecordedAt  string          `json:"recorded_at"`
+}
+
+// CommitRequest is the payload for recording a nation aggregation. States may be omitted,
+// in which case the chaincode uses the states that submitted nation convergence.
+type CommitRequest struct {
+	Round       int             `json:"round"`
+	ModelCID    string          `json:"model_cid"`
+	States      []string        `json:"states,omitempty"`
+	Strategy    string          `json:"strategy,omitempty"`
+	SampleCount int             `json:"sample_count,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+}
+
+type ledgerAggregation struct {
+	Round       int      `json:"round"`
+	ModelCID    string   `json:"model_cid"`
+	States      []string `json:"states"`
+	Aggregator  string   `json:"aggregator"`
+	Strategy    string   `json:"strategy,omitempty"`
+	SampleCount int      `json:"sample_count,omitempty"`
+	Metadata    string   `json:"metadata,omitempty"`
+	TxID        string   `json:"tx_id"`
+	RecordedAt  string   `json:"recorded_at"`
+}
+
+// Commit records the nation global model for a round.
+func (s *Service) Commit(ctx context.Context, authCtx *common.AuthContext, req *CommitRequest) (*Aggregation, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	if req.Round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	modelCID := strings.TrimSpace(req.ModelCID)
+	if modelCID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_cid is required")
+	}
+	if req.SampleCount < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "sample_count must not be negative")
+	}
+	states := ""
+	if len(req.States) > 0 {
+		states = common.MustJSON(req.States)
+	}
+	metadata := common.MustJSON(map[string]any{
+		"strategy":     req.Strategy,
+		"sample_count": req.SampleCount,
+		"extra":        req.Metadata,
+	})
+	identity := s.identityFor(authCtx)
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"CommitNationAggregation", strconv.Itoa(req.Round), modelCID, states, metadata}
+	if _, err := s.fabric.InvokeChaincode(ctx, peer, identity, args); err != nil {
+		return nil, mapLedgerError(err)
+	}
+	return s.read(ctx, identity, req.Round)
+}
+
+// Get returns the nation aggregation recorded for a round.
+func (s *Service) Get(ctx context.Context, authCtx *common.AuthContext, round int) (*Aggregation, error) {
+	if round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	return s.read(ctx, s.identityFor(authCtx), round)
+}
+
+// List returns every nation aggregation in round order.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext) ([]*Aggregation, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), []string{"ListNationAggregations"})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var records []*ledgerAggregation
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, err
+	}
+	result := make([]*Aggregation, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.toAggregation())
+	}
+	return result, nil
+}
+
+// States lists the states that have submitted nation convergence.
+func (s *Service) States(ctx context.Context, authCtx *common.AuthContext) ([]string, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), []string{"ListNationStates"})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var states []string
+	if err := json.Unmarshal(raw, &states); err != nil {
+		return nil, err
+	}
+	if states == nil {
+		states = []string{}
+	}
+	return states, nil
+}
+
+func (s *Service) read(ctx context.Context, identity string, round int) (*Aggregation, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"ReadNationAggregation", strconv.Itoa(round)})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var record ledgerAggregation
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return record.toAggregation(), nil
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func (r *ledgerAggregation) toAggregation() *Aggregation {
+	agg := &Aggregation{
+		Round:       r.Round,
+		ModelCID:    r.ModelCID,
+		States:      r.States,
+		Aggregator:  r.Aggregator,
+		Strategy:    r.Strategy,
+		SampleCount: r.SampleCount,
+		TxID:        r.TxID,
+		RecordedAt:  r.RecordedAt,
+	}
+	if r.Metadata != "" {
+		agg.Metadata = json.RawMessage(r.Metadata)
+	}
+	if agg.States == nil {
+		agg.States = []string{}
+	}
+	return agg
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "already recorded"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "trainer not authorized"), strings.Contains(msg, "trainer credential revoked"), strings.Contains(msg, "trainer deactivated"),
+		strings.Contains(msg, "trainer suspended"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "invalid states"), strings.Contains(msg, "invalid metadata"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
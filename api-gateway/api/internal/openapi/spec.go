@@ -0,0 +1,384 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Spec collects the operations every module registers and renders them as an OpenAPI 3
+// document. Request and response schemas are derived from the Go types by reflection, so
+// they follow the structs' json tags.
+type Spec struct {
+	title   string
+	version string
+
+	mu      sync.Mutex
+	paths   map[string]map[string]any
+	schemas map[string]any
+	tags    []string
+}
+
+// Operation describes one method on one route.
+type Operation struct {
+	Summary     string
+	Description string
+	// Public marks routes that need no bearer token. Otherwise Roles lists the roles allowed
+	// to call the route; empty means any authenticated role.
+	Public bool
+	Roles  []common.Role
+	Query  []Param
+	// Body and Response are sample values whose types define the schemas. A map[string]any
+	// describes an object whose properties take the types of the map values.
+	Body     any
+	Response any
+	// Status is the success status code (200 when zero). Errors lists other documented codes.
+	Status int
+	Errors []int
+	// Consumes and Produces override the request and response content types
+	// (application/json by default). A []byte sample under another type is binary.
+	Consumes string
+	Produces string
+}
+
+// Param is a query string parameter.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Type        string
+}
+
+// Group adds operations under a tag.
+type Group struct {
+	spec *Spec
+	tag  string
+}
+
+// NewSpec creates an empty document.
+func NewSpec(title, version string) *Spec {
+	return &Spec{
+		title:   title,
+		version: version,
+		paths:   map[string]map[string]any{},
+		schemas: map[string]any{
+			"Error": map[string]any{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": map[string]any{
+					"code":       map[string]any{"type": "string", "description": "Stable error code, e.g. TRAINER_NOT_REGISTERED."},
+					"message":    map[string]any{"type": "string"},
+					"details":    map[string]any{"description": "Structured context; its shape depends on code."},
+					"request_id": map[string]any{"type": "string", "description": "Echoes the X-Request-ID response header."},
+				},
+			},
+		},
+	}
+}
+
+// Tag returns a group whose operations are listed under tag.
+func (s *Spec) Tag(tag string) *Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.tags {
+		if existing == tag {
+			return &Group{spec: s, tag: tag}
+		}
+	}
+	s.tags = append(s.tags, tag)
+	return &Group{spec: s, tag: tag}
+}
+
+// Add registers an operation. Path parameters are written as {name}.
+func (g *Group) Add(method, route string, op Operation) {
+	g.spec.add(g.tag, strings.ToLower(method), route, op)
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (s *Spec) add(tag, method, route string, op Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	operation := map[string]any{
+		"tags":        []string{tag},
+		"summary":     op.Summary,
+		"operationId": operationID(method, route),
+	}
+	description := op.Description
+	if !op.Public && len(op.Roles) > 0 {
+		roles := make([]string, len(op.Roles))
+		for i, role := range op.Roles {
+			roles[i] = string(role)
+		}
+		description = strings.TrimSpace(description + "\n\nRoles: " + strings.Join(roles, ", ") + ".")
+	}
+	if description != "" {
+		operation["description"] = description
+	}
+	if op.Public {
+		operation["security"] = []any{}
+	}
+	var params []any
+	for _, match := range pathParam.FindAllStringSubmatch(route, -1) {
+		params = append(params, map[string]any{"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+	}
+	for _, param := range op.Query {
+		kind := param.Type
+		if kind == "" {
+			kind = "string"
+		}
+		entry := map[string]any{"name": param.Name, "in": "query", "required": param.Required, "schema": map[string]any{"type": kind}}
+		if param.Description != "" {
+			entry["description"] = param.Description
+		}
+		params = append(params, entry)
+	}
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+	if op.Body != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  s.content(op.Consumes, op.Body),
+		}
+	}
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = s.content(op.Produces, op.Response)
+	}
+	responses := map[string]any{fmt.Sprint(status): success}
+	errorBody := map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}}}
+	for _, code := range op.Errors {
+		responses[fmt.Sprint(code)] = map[string]any{"description": http.StatusText(code), "content": errorBody}
+	}
+	responses["default"] = map[string]any{"description": "Error", "content": errorBody}
+	operation["responses"] = responses
+
+	if s.paths[route] == nil {
+		s.paths[route] = map[string]any{}
+	}
+	s.paths[route][method] = operation
+}
+
+func (s *Spec) content(contentType string, sample any) map[string]any {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	schema := map[string]any{"type": "string", "format": "binary"}
+	if _, raw := sample.([]byte); !raw || contentType == "application/json" {
+		schema = s.valueSchema(sample)
+	}
+	return map[string]any{contentType: map[string]any{"schema": schema}}
+}
+
+func operationID(method, route string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	upper := true
+	for _, r := range route {
+		switch {
+		case r == '/' || r == '-' || r == '_' || r == '{' || r == '}' || r == '.':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Document returns the OpenAPI document.
+func (s *Spec) Document() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags := make([]any, len(s.tags))
+	for i, tag := range s.tags {
+		tags[i] = map[string]any{"name": tag}
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": s.title, "version": s.version},
+		"tags":    tags,
+		"paths":   s.paths,
+		"security": []any{
+			map[string]any{"bearerAuth": []string{}},
+		},
+		"components": map[string]any{
+			"schemas": s.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+	numberType  = reflect.TypeOf(json.Number(""))
+	durationTyp = reflect.TypeOf(time.Duration(0))
+)
+
+// valueSchema describes a sample value; see Operation.Body.
+func (s *Spec) valueSchema(value any) map[string]any {
+	if object, ok := value.(map[string]any); ok {
+		properties := map[string]any{}
+		for name, field := range object {
+			if field == nil {
+				properties[name] = map[string]any{}
+				continue
+			}
+			properties[name] = s.typeSchema(reflect.TypeOf(field))
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	}
+	return s.typeSchema(reflect.TypeOf(value))
+}
+
+func (s *Spec) typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]any{}
+	case numberType:
+		return map[string]any{"type": "number"}
+	case durationTyp:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := s.schemas[name]; !ok {
+			s.schemas[name] = map[string]any{}
+			s.schemas[name] = s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s *Spec) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.collectFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *Spec) collectFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.collectFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := s.typeSchema(field.Type)
+		if strings.Contains(options, "string") {
+			schema = map[string]any{"type": "string"}
+		}
+		properties[name] = schema
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Handler serves the document as JSON.
+func (s *Spec) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, s.Document())
+	}
+}
+
+// DocsHandler serves Swagger UI pointed at specURL. The UI assets load from the public
+// swagger-ui-dist package, so the browser needs internet access.
+func (s *Spec) DocsHandler(specURL string) http.HandlerFunc {
+	page := fmt.Sprintf(docsPage, s.title, specURL)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
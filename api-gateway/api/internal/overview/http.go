@@ -0,0 +1,138 @@
+package overview
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/convergence"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the admin dashboard overview.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the overview HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/overview` and `/state/{stateId}/overview`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/overview", auth.RequireAuth(http.HandlerFunc(h.handleOverview), common.RoleAdmin))
+	state := auth.RequireAuth(http.HandlerFunc(h.handleStateOverview), common.RoleStateCoordinator, common.RoleAdmin)
+	// `/state/` also catches paths other handlers do not serve; only the overview is authenticated.
+	mux.Handle("/state/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := stateFromPath(r.URL.Path); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		state.ServeHTTP(w, r)
+	}))
+}
+
+// Describe documents the overview endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("overview")
+	api.Add(http.MethodGet, "/admin/overview", openapi.Operation{
+		Summary:     "Read the aggregate status for the admin dashboard",
+		Description: "Whitelist counts per state and cluster, the current round per job, convergence progress, model counts per layer and peer health in one call. Sections are read concurrently; a section that fails is omitted and its error is listed under `errors`, so the call still answers with 200.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Query: []openapi.Param{
+			{Name: "job_id", Description: "Report convergence for this job; requires round."},
+			{Name: "round", Type: "integer", Description: "Round of job_id."},
+		},
+		Response: Overview{},
+	})
+	api.Add(http.MethodGet, "/state/{stateId}/overview", openapi.Operation{
+		Summary:     "Read one state's dashboard",
+		Description: "The state's clusters with their registered trainers and latest cluster model, and the state's convergence. State coordinators may only read the state in their token's `state` claim; admins may read any state. Trainers' fields are redacted per RESPONSE_FIELD_ROLES as on `/whitelist`. A section that fails is listed under `errors`.",
+		Roles:       []common.Role{common.RoleStateCoordinator, common.RoleAdmin},
+		Query: []openapi.Param{
+			{Name: "job_id", Description: "Report convergence for this job; requires round."},
+			{Name: "round", Type: "integer", Description: "Round of job_id."},
+		},
+		Response: StateOverview{},
+		Errors:   []int{http.StatusForbidden},
+	})
+}
+
+func (h *HTTPHandler) handleOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	scope, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, h.svc.Get(r.Context(), authCtx, scope))
+}
+
+// handleStateOverview serves `/state/{stateId}/overview`.
+func (h *HTTPHandler) handleStateOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	stateID, _ := stateFromPath(r.URL.Path)
+	scope, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	result, err := h.svc.State(r.Context(), authCtx, stateID, scope)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	// The clusters embed whitelist entries and models, redacted as those endpoints do.
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+// stateFromPath extracts stateId from `/state/{stateId}/overview`.
+func stateFromPath(path string) (string, bool) {
+	stateID, rest, ok := strings.Cut(strings.TrimPrefix(path, "/state/"), "/")
+	if !ok || rest != "overview" || stateID == "" {
+		return "", false
+	}
+	return stateID, true
+}
+
+func scopeFromQuery(r *http.Request) (convergence.Scope, error) {
+	query := r.URL.Query()
+	scope := convergence.Scope{JobID: strings.TrimSpace(query.Get("job_id"))}
+	if raw := strings.TrimSpace(query.Get("round")); raw != "" {
+		round, err := strconv.Atoi(raw)
+		if err != nil || round < 1 {
+			return convergence.Scope{}, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+		}
+		scope.Round = round
+	}
+	if scope.JobID != "" && scope.Round == 0 {
+		return convergence.Scope{}, common.NewStatusError(http.StatusBadRequest, "round is required when job_id is set")
+	}
+	return scope, nil
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
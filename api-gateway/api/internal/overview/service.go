@@ -0,0 +1,262 @@
+package overview
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/convergence"
+	"github.com/nebula/api-gateway/internal/jobs"
+	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/rounds"
+	"github.com/nebula/api-gateway/internal/whitelist"
+)
+
+// Service assembles the admin dashboard view from the whitelist, jobs, rounds, convergence and
+// model services and the gateway's peer health.
+type Service struct {
+	cfg         *common.Config
+	fabric      *common.FabricClient
+	whitelist   *whitelist.Service
+	jobs        *jobs.Service
+	rounds      *rounds.Service
+	convergence *convergence.Service
+	models      *models.Service
+}
+
+// NewService constructs an overview service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, whitelist *whitelist.Service, jobs *jobs.Service, rounds *rounds.Service, convergence *convergence.Service, models *models.Service) *Service {
+	return &Service{cfg: cfg, fabric: fabric, whitelist: whitelist, jobs: jobs, rounds: rounds, convergence: convergence, models: models}
+}
+
+// Overview is the aggregate dashboard status. A section that could not be read is left empty
+// and its error is reported under Errors, keyed by section name.
+type Overview struct {
+	GeneratedAt string               `json:"generated_at"`
+	Whitelist   *WhitelistSummary    `json:"whitelist,omitempty"`
+	Jobs        []*JobRounds         `json:"jobs,omitempty"`
+	Convergence *ConvergenceProgress `json:"convergence,omitempty"`
+	Models      []*LayerCount        `json:"models,omitempty"`
+	Peers       []common.PeerStatus  `json:"peers"`
+	Errors      map[string]string    `json:"errors,omitempty"`
+}
+
+// WhitelistSummary counts whitelisted trainers overall and per state and cluster.
+type WhitelistSummary struct {
+	Total       int           `json:"total"`
+	Active      int           `json:"active"`
+	Deactivated int           `json:"deactivated"`
+	States      []*StateCount `json:"states"`
+}
+
+// StateCount counts the trainers of one state.
+type StateCount struct {
+	StateID  string          `json:"state_id"`
+	Total    int             `json:"total"`
+	Active   int             `json:"active"`
+	Clusters []*ClusterCount `json:"clusters"`
+}
+
+// ClusterCount counts the trainers of one cluster.
+type ClusterCount struct {
+	ClusterID string `json:"cluster_id"`
+	Total     int    `json:"total"`
+	Active    int    `json:"active"`
+}
+
+// JobRounds lists the current round of every layer/scope of a job. Status is empty for round
+// keyspaces without a job record, such as the default job.
+type JobRounds struct {
+	JobID  string          `json:"job_id"`
+	Status string          `json:"status,omitempty"`
+	Rounds []*rounds.Round `json:"rounds"`
+}
+
+// ConvergenceProgress summarizes how many states have converged and whether the nation has.
+type ConvergenceProgress struct {
+	JobID           string           `json:"job_id,omitempty"`
+	Round           int              `json:"round,omitempty"`
+	StatesTotal     int              `json:"states_total"`
+	StatesConverged int              `json:"states_converged"`
+	NationConverged bool             `json:"nation_converged"`
+	NationAt        string           `json:"nation_converged_at,omitempty"`
+	States          []*StateProgress `json:"states"`
+}
+
+// StateProgress summarizes convergence of one state's clusters.
+type StateProgress struct {
+	StateID           string `json:"state_id"`
+	IsConverged       bool   `json:"is_converged"`
+	ClustersTotal     int    `json:"clusters_total"`
+	ClustersConverged int    `json:"clusters_converged"`
+}
+
+// LayerCount is the number of model references committed to a layer.
+type LayerCount struct {
+	Layer string `json:"layer"`
+	Count int    `json:"count"`
+}
+
+// Get reads every section concurrently. Convergence is reported for scope, which defaults to
+// the legacy unscoped records.
+func (s *Service) Get(ctx context.Context, authCtx *common.AuthContext, scope convergence.Scope) *Overview {
+	overview := &Overview{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Peers:       s.fabric.PeerStatuses(),
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		hierarchy *whitelist.HierarchyResult
+	)
+	section := func(name string, read func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := read(); err != nil {
+				mu.Lock()
+				if overview.Errors == nil {
+					overview.Errors = make(map[string]string)
+				}
+				overview.Errors[name] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+	section("whitelist", func() (err error) {
+		hierarchy, err = s.whitelist.Hierarchy(ctx)
+		return err
+	})
+	section("jobs", func() (err error) {
+		overview.Jobs, err = s.jobRounds(ctx)
+		return err
+	})
+	section("convergence", func() (err error) {
+		overview.Convergence, err = s.convergenceProgress(ctx, authCtx, scope)
+		return err
+	})
+	section("models", func() (err error) {
+		overview.Models, err = s.modelCounts(ctx)
+		return err
+	})
+	wg.Wait()
+
+	if hierarchy != nil {
+		overview.Whitelist = summarizeWhitelist(hierarchy)
+		if overview.Convergence != nil && len(hierarchy.States) > overview.Convergence.StatesTotal {
+			overview.Convergence.StatesTotal = len(hierarchy.States)
+		}
+	}
+	return overview
+}
+
+func summarizeWhitelist(hierarchy *whitelist.HierarchyResult) *WhitelistSummary {
+	summary := &WhitelistSummary{States: make([]*StateCount, 0, len(hierarchy.States))}
+	for _, state := range hierarchy.States {
+		stateCount := &StateCount{StateID: state.StateID, Clusters: make([]*ClusterCount, 0, len(state.Clusters))}
+		for _, cluster := range state.Clusters {
+			clusterCount := &ClusterCount{ClusterID: cluster.ClusterID, Total: len(cluster.Nodes)}
+			for _, node := range cluster.Nodes {
+				if node.Active() {
+					clusterCount.Active++
+				}
+			}
+			stateCount.Total += clusterCount.Total
+			stateCount.Active += clusterCount.Active
+			stateCount.Clusters = append(stateCount.Clusters, clusterCount)
+		}
+		summary.Total += stateCount.Total
+		summary.Active += stateCount.Active
+		summary.States = append(summary.States, stateCount)
+	}
+	summary.Deactivated = summary.Total - summary.Active
+	return summary
+}
+
+// jobRounds groups the ledger's current rounds by job, listing every job record even when it
+// has no round yet.
+func (s *Service) jobRounds(ctx context.Context) ([]*JobRounds, error) {
+	jobList, err := s.jobs.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.rounds.ListCurrent(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*JobRounds, len(jobList))
+	for _, job := range jobList {
+		byID[job.ID] = &JobRounds{JobID: job.ID, Status: job.Status, Rounds: []*rounds.Round{}}
+	}
+	for _, round := range current {
+		entry, ok := byID[round.JobID]
+		if !ok {
+			entry = &JobRounds{JobID: round.JobID, Rounds: []*rounds.Round{}}
+			byID[round.JobID] = entry
+		}
+		entry.Rounds = append(entry.Rounds, round)
+	}
+	result := make([]*JobRounds, 0, len(byID))
+	for _, entry := range byID {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].JobID < result[j].JobID })
+	return result, nil
+}
+
+func (s *Service) convergenceProgress(ctx context.Context, authCtx *common.AuthContext, scope convergence.Scope) (*ConvergenceProgress, error) {
+	states, err := s.convergence.ListStateStatuses(ctx, authCtx, scope)
+	if err != nil {
+		return nil, err
+	}
+	nation, err := s.convergence.NationStatus(ctx, authCtx, scope)
+	if err != nil {
+		return nil, err
+	}
+	progress := &ConvergenceProgress{
+		JobID:           nation.JobID,
+		Round:           nation.Round,
+		StatesTotal:     len(states),
+		NationConverged: nation.IsConverged,
+		NationAt:        nation.ConvergedAt,
+		States:          make([]*StateProgress, 0, len(states)),
+	}
+	for stateID, status := range states {
+		state := &StateProgress{StateID: stateID, IsConverged: status.IsConverged, ClustersTotal: len(status.Clusters)}
+		for _, cluster := range status.Clusters {
+			if cluster.IsConverged {
+				state.ClustersConverged++
+			}
+		}
+		if status.IsConverged {
+			progress.StatesConverged++
+		}
+		progress.States = append(progress.States, state)
+	}
+	sort.Slice(progress.States, func(i, j int) bool { return progress.States[i].StateID < progress.States[j].StateID })
+	return progress, nil
+}
+
+func (s *Service) modelCounts(ctx context.Context) ([]*LayerCount, error) {
+	counts, err := s.models.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*LayerCount, 0, len(counts))
+	for _, layer := range s.models.Layers() {
+		result = append(result, &LayerCount{Layer: layer.Slug, Count: counts[layer.Slug]})
+		delete(counts, layer.Slug)
+	}
+	// Layers the gateway no longer serves still hold committed models.
+	extra := make([]string, 0, len(counts))
+	for layer := range counts {
+		extra = append(extra, layer)
+	}
+	sort.Strings(extra)
+	for _, layer := range extra {
+		result = append(result, &LayerCount{Layer: layer, Count: counts[layer]})
+	}
+	return result, nil
+}
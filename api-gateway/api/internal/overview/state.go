@@ -0,0 +1,133 @@
+package overview
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/convergence"
+	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/whitelist"
+)
+
+// StateOverview is one state's dashboard. As with Overview, a section that could not be read
+// is left empty and its error is reported under Errors.
+type StateOverview struct {
+	GeneratedAt string                   `json:"generated_at"`
+	StateID     string                   `json:"state_id"`
+	Trainers    int                      `json:"trainers"`
+	Active      int                      `json:"active"`
+	Clusters    []*StateCluster          `json:"clusters"`
+	Convergence *convergence.StateStatus `json:"convergence,omitempty"`
+	Errors      map[string]string        `json:"errors,omitempty"`
+}
+
+// StateCluster lists a cluster's registered trainers and its latest cluster-layer model.
+type StateCluster struct {
+	ClusterID   string              `json:"cluster_id"`
+	Trainers    []*whitelist.Entry  `json:"trainers"`
+	Active      int                 `json:"active"`
+	LatestModel *models.ModelRecord `json:"latest_model,omitempty"`
+}
+
+// State builds the dashboard of one state. Admins may read any state; state coordinators only
+// the state in their token.
+func (s *Service) State(ctx context.Context, authCtx *common.AuthContext, stateID string, scope convergence.Scope) (*StateOverview, error) {
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	if stateID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
+	}
+	if err := authorizeState(authCtx, stateID); err != nil {
+		return nil, err
+	}
+	trainers := &whitelist.ListResult{}
+	for page := 1; ; page++ {
+		result, err := s.whitelist.ByState(ctx, stateID, page, 0)
+		if err != nil {
+			return nil, err
+		}
+		trainers.Items = append(trainers.Items, result.Items...)
+		if !result.HasMore {
+			break
+		}
+	}
+	overview := &StateOverview{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		StateID:     stateID,
+		Clusters:    []*StateCluster{},
+	}
+	byCluster := make(map[string]*StateCluster)
+	clusterIDs := make([]string, 0)
+	for _, state := range trainers.ToHierarchy().States {
+		for _, cluster := range state.Clusters {
+			entry := &StateCluster{ClusterID: cluster.ClusterID, Trainers: cluster.Nodes}
+			for _, node := range cluster.Nodes {
+				if node.Active() {
+					entry.Active++
+				}
+			}
+			overview.Trainers += len(entry.Trainers)
+			overview.Active += entry.Active
+			overview.Clusters = append(overview.Clusters, entry)
+			byCluster[strings.ToLower(cluster.ClusterID)] = entry
+			clusterIDs = append(clusterIDs, cluster.ClusterID)
+		}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		latest map[string]*models.ModelRecord
+	)
+	fail := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if overview.Errors == nil {
+			overview.Errors = make(map[string]string)
+		}
+		overview.Errors[name] = err.Error()
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		if latest, err = s.models.Latest(ctx, "cluster", clusterIDs); err != nil {
+			fail("models", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		if overview.Convergence, err = s.convergence.StateStatus(ctx, authCtx, stateID, scope); err != nil {
+			fail("convergence", err)
+		}
+	}()
+	wg.Wait()
+
+	for scopeID, record := range latest {
+		if cluster, ok := byCluster[scopeID]; ok {
+			cluster.LatestModel = record
+		}
+	}
+	return overview, nil
+}
+
+func authorizeState(authCtx *common.AuthContext, stateID string) error {
+	if authCtx == nil {
+		return common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	if authCtx.Role == common.RoleAdmin {
+		return nil
+	}
+	own := strings.ToLower(strings.TrimSpace(authCtx.State))
+	if own == "" {
+		return common.NewStatusError(http.StatusForbidden, "token carries no state claim")
+	}
+	if own != stateID {
+		return common.NewStatusError(http.StatusForbidden, "state coordinators may only read their own state")
+	}
+	return nil
+}
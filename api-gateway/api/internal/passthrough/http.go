@@ -0,0 +1,87 @@
+package passthrough
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the admin chaincode passthrough.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the passthrough HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/chaincode/invoke` and `/admin/chaincode/query`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/chaincode/invoke", auth.RequireAuth(http.HandlerFunc(h.handleInvoke), common.RoleAdmin))
+	mux.Handle("/admin/chaincode/query", auth.RequireAuth(http.HandlerFunc(h.handleQuery), common.RoleAdmin))
+}
+
+// Describe documents the passthrough endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("passthrough")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodPost, "/admin/chaincode/invoke", openapi.Operation{
+		Summary:     "Invoke any allowed chaincode function",
+		Description: "Submits the call as GATEWAY_ADMIN_IDENTITY and waits for it to commit. The function must be listed in ADMIN_CHAINCODE_FUNCTIONS. The call is recorded in the audit trail.",
+		Roles:       admin,
+		Body:        Request{},
+		Response:    Result{},
+		Errors:      []int{http.StatusForbidden},
+	})
+	api.Add(http.MethodPost, "/admin/chaincode/query", openapi.Operation{
+		Summary:     "Query any allowed chaincode function",
+		Description: "Evaluates the call as GATEWAY_ADMIN_IDENTITY without submitting it. The function must be listed in ADMIN_CHAINCODE_FUNCTIONS. The call is recorded in the audit trail.",
+		Roles:       admin,
+		Body:        Request{},
+		Response:    Result{},
+		Errors:      []int{http.StatusForbidden},
+	})
+}
+
+func (h *HTTPHandler) handleInvoke(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.svc.Invoke)
+}
+
+func (h *HTTPHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.svc.Query)
+}
+
+func (h *HTTPHandler) handle(w http.ResponseWriter, r *http.Request, call func(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Result, error)) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := call(r.Context(), authCtx, &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, result)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
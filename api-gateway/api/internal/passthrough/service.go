@@ -0,0 +1,121 @@
+package passthrough
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Service calls arbitrary chaincode functions as the admin identity, for emergency
+// operations and debugging without the peer CLI. Only the functions listed in
+// ADMIN_CHAINCODE_FUNCTIONS can be called.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+}
+
+// NewService constructs a passthrough service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric}
+}
+
+// Request is one chaincode call. Transient values are sent as the UTF-8 bytes of the strings.
+type Request struct {
+	Function  string            `json:"function"`
+	Args      []string          `json:"args,omitempty"`
+	Transient map[string]string `json:"transient,omitempty"`
+}
+
+// Result is the chaincode's response. Payload is the response itself when it is JSON and a
+// JSON string otherwise; TxID and BlockNumber are only set for invokes.
+type Result struct {
+	Function    string          `json:"function"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	TxID        string          `json:"tx_id,omitempty"`
+	BlockNumber uint64          `json:"block_number,omitempty"`
+}
+
+// Invoke submits the call and waits for it to commit.
+func (s *Service) Invoke(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Result, error) {
+	ctx, args, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	log.Printf("admin passthrough: %s invokes %s with %d args and %d transient keys", authCtx.Subject, args[0], len(args)-1, len(req.Transient))
+	raw, receipt, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, args)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Function: args[0], Payload: payloadJSON(raw)}
+	if receipt != nil {
+		result.TxID = receipt.TxID
+		result.BlockNumber = receipt.BlockNumber
+	}
+	return result, nil
+}
+
+// Query evaluates the call on one peer without submitting it. The call is noted in the
+// request's audit entry like an invoke.
+func (s *Service) Query(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Result, error) {
+	ctx, args, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("admin passthrough: %s queries %s with %d args and %d transient keys", authCtx.Subject, args[0], len(args)-1, len(req.Transient))
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, args)
+	common.NoteAuditQuery(ctx, args, err)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Function: args[0], Payload: payloadJSON(raw)}, nil
+}
+
+// prepare checks the call against the allowlist and attaches its transient data to ctx.
+func (s *Service) prepare(ctx context.Context, req *Request) (context.Context, []string, error) {
+	if req == nil {
+		return ctx, nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	function := strings.TrimSpace(req.Function)
+	if function == "" {
+		return ctx, nil, common.NewStatusError(http.StatusBadRequest, "function is required")
+	}
+	if !s.allowed(function) {
+		return ctx, nil, common.NewStatusError(http.StatusForbidden, fmt.Sprintf("chaincode function %s is not allowed by ADMIN_CHAINCODE_FUNCTIONS", function))
+	}
+	if len(req.Transient) > 0 {
+		transient := make(map[string][]byte, len(req.Transient))
+		for key, value := range req.Transient {
+			transient[key] = []byte(value)
+		}
+		ctx = common.WithTransient(ctx, transient)
+	}
+	return ctx, append([]string{function}, req.Args...), nil
+}
+
+func (s *Service) allowed(function string) bool {
+	for _, name := range s.cfg.AdminChaincodeFunctions {
+		if name == function {
+			return true
+		}
+	}
+	return false
+}
+
+func payloadJSON(raw []byte) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	if json.Valid(raw) {
+		return raw
+	}
+	return json.RawMessage(common.MustJSON(string(raw)))
+}
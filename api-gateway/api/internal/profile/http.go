@@ -0,0 +1,69 @@
+package profile
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// HTTPHandler exposes `/auth/me`.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the profile HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/auth/me`. Trainers call it with their runtime EdDSA tokens; every
+// other role uses its usual token.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	trainerKeys := registry.TrainerKeyFunc(h.svc.store)
+	keyFunc := func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
+		if strings.EqualFold(header.Alg, "EdDSA") && claims.Issuer == "" {
+			return trainerKeys(header, claims)
+		}
+		return nil, nil
+	}
+	mux.Handle("/auth/me", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleMe)))
+}
+
+// Describe documents the profile endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("registry")
+	api.Add(http.MethodGet, "/auth/me", openapi.Operation{
+		Summary:     "Read the caller's enrollment, placement and current rounds",
+		Description: "Accepts any token, including trainer runtime tokens (EdDSA). Callers without an enrollment get `registered: false` and the placement from their token.",
+		Response:    Profile{},
+	})
+}
+
+func (h *HTTPHandler) handleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	profile, err := h.svc.Me(r.Context(), authCtx)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, profile)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+	"github.com/nebula/api-gateway/internal/rounds"
+)
+
+// Service resolves what the gateway knows about the caller.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+	rounds *rounds.Service
+}
+
+// NewService constructs a profile service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store, rounds *rounds.Service) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store, rounds: rounds}
+}
+
+// Profile is the caller's identity as the gateway resolves it. Placement comes from the
+// enrollment when there is one and from the token otherwise.
+type Profile struct {
+	Subject        string                  `json:"subject"`
+	Role           common.Role             `json:"role"`
+	NodeID         string                  `json:"node_id,omitempty"`
+	State          string                  `json:"state,omitempty"`
+	Cluster        string                  `json:"cluster,omitempty"`
+	Nation         string                  `json:"nation,omitempty"`
+	JobID          string                  `json:"job_id,omitempty"`
+	Registered     bool                    `json:"registered"`
+	FabricClientID string                  `json:"fabric_client_id,omitempty"`
+	Registration   *registry.TrainerRecord `json:"registration,omitempty"`
+	Whitelist      *WhitelistStatus        `json:"whitelist,omitempty"`
+	Rounds         []*rounds.Round         `json:"rounds"`
+}
+
+// WhitelistStatus is the caller's whitelist standing. Authorized is the chaincode's own
+// answer for the caller's Fabric identity, so it also reflects suspensions.
+type WhitelistStatus struct {
+	Status     string `json:"status"`
+	Authorized bool   `json:"authorized"`
+}
+
+// Me resolves the caller's profile. Registered trainers also get their whitelist status and
+// the current round of their cluster and state in GATEWAY_JOB_ID; scopes without a round
+// started yet are left out.
+func (s *Service) Me(ctx context.Context, authCtx *common.AuthContext) (*Profile, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	profile := &Profile{
+		Subject: authCtx.Subject,
+		Role:    authCtx.Role,
+		NodeID:  authCtx.NodeID,
+		State:   authCtx.State,
+		Cluster: authCtx.Cluster,
+		Nation:  authCtx.Nation,
+		JobID:   s.cfg.JobID,
+		Rounds:  []*rounds.Round{},
+	}
+	rec, ok := s.store.FindByJWTSub(authCtx.Subject)
+	if !ok {
+		return profile, nil
+	}
+	profile.Registered = true
+	profile.FabricClientID = rec.FabricClientID
+	profile.Registration = rec
+	profile.NodeID = rec.NodeID
+	if rec.State != "" {
+		profile.State = rec.State
+	}
+	if rec.Cluster != "" {
+		profile.Cluster = rec.Cluster
+	}
+	authorized, err := s.authorized(ctx, rec.FabricClientID)
+	if err != nil {
+		return nil, err
+	}
+	status := rec.Status
+	if status == "" {
+		status = registry.StatusActive
+	}
+	profile.Whitelist = &WhitelistStatus{Status: status, Authorized: authorized}
+	for _, scope := range []struct{ layer, id string }{{"cluster", profile.Cluster}, {"state", profile.State}} {
+		if scope.id == "" {
+			continue
+		}
+		round, err := s.rounds.Current(ctx, authCtx, scope.layer, scope.id)
+		if se, ok := common.AsStatusError(err); ok && se.Code == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		profile.Rounds = append(profile.Rounds, round)
+	}
+	return profile, nil
+}
+
+func (s *Service) authorized(ctx context.Context, identity string) (bool, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"IsTrainerAuthorized"})
+	if err != nil && strings.Contains(err.Error(), "trainer suspended") {
+		// The chaincode answers false for unknown and revoked trainers but fails for
+		// suspended ones.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var authorized bool
+	if err := json.Unmarshal(raw, &authorized); err != nil {
+		return false, err
+	}
+	return authorized, nil
+}
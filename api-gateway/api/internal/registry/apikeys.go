@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// APIKeyStore is implemented by stores that also keep service-account API keys. It is
+// optional: the admin API key routes answer 501 when the trainer store lacks it.
+type APIKeyStore interface {
+	common.APIKeyStore
+	// SaveAPIKey inserts or replaces the key with the same ID.
+	SaveAPIKey(key *common.APIKey) error
+	// DeleteAPIKey drops the key, reporting whether it existed.
+	DeleteAPIKey(id string) (bool, error)
+	// APIKeys returns every key ordered by ID.
+	APIKeys() []*common.APIKey
+}
+
+// apiKeysPath is where a file store keeps its API keys, next to the enrollments so the trainer
+// file keeps its format.
+func apiKeysPath(path string) string {
+	return path + ".apikeys.json"
+}
+
+func (s *LocalStore) loadAPIKeys() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(apiKeysPath(s.path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var keys []*common.APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key != nil && key.ID != "" {
+			s.apiKeys[key.ID] = key
+		}
+	}
+	return nil
+}
+
+// LookupAPIKey implements common.APIKeyStore.
+func (s *LocalStore) LookupAPIKey(id string) (*common.APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.apiKeys[id]
+	return key, ok
+}
+
+// SaveAPIKey stores or replaces an API key.
+func (s *LocalStore) SaveAPIKey(key *common.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.apiKeys[key.ID]
+	s.apiKeys[key.ID] = key
+	if err := s.persistAPIKeysLocked(); err != nil {
+		if existed {
+			s.apiKeys[key.ID] = previous
+		} else {
+			delete(s.apiKeys, key.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteAPIKey removes an API key.
+func (s *LocalStore) DeleteAPIKey(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return false, nil
+	}
+	delete(s.apiKeys, id)
+	if err := s.persistAPIKeysLocked(); err != nil {
+		s.apiKeys[id] = key
+		return false, err
+	}
+	return true, nil
+}
+
+// APIKeys returns a snapshot of the stored keys ordered by ID.
+func (s *LocalStore) APIKeys() []*common.APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]*common.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+func (s *LocalStore) persistAPIKeysLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	keys := make([]*common.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	payload, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	return common.AtomicWriteFile(apiKeysPath(s.path), payload, 0o600)
+}
+
+// APIKeyInput describes a service-account key to create.
+type APIKeyInput struct {
+	ID          string            `json:"id"`
+	Roles       []string          `json:"roles"`
+	State       string            `json:"state,omitempty"`
+	RateLimit   *common.RateLimit `json:"rate_limit,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// CreatedAPIKey is returned once when a key is created; Key is never shown again.
+type CreatedAPIKey struct {
+	*common.APIKey
+	Key string `json:"key"`
+}
+
+func (s *Service) apiKeyStore() (APIKeyStore, error) {
+	keys, ok := s.store.(APIKeyStore)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusNotImplemented, "the trainer store does not keep API keys")
+	}
+	return keys, nil
+}
+
+// CreateAPIKey generates a key for a service account and stores its hash.
+func (s *Service) CreateAPIKey(ctx context.Context, input APIKeyInput) (*CreatedAPIKey, error) {
+	keys, err := s.apiKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(input.ID)
+	if err := common.ValidateAPIKeyID(id); err != nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+	}
+	if len(input.Roles) == 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "roles are required")
+	}
+	apiKey := &common.APIKey{
+		ID:          id,
+		State:       strings.TrimSpace(input.State),
+		Description: strings.TrimSpace(input.Description),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, name := range input.Roles {
+		role, err := common.ParseRole(name)
+		if err != nil {
+			return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+		}
+		apiKey.Roles = append(apiKey.Roles, role)
+	}
+	if limit := input.RateLimit; limit != nil {
+		if limit.Rate <= 0 || limit.Burst < 0 {
+			return nil, common.NewStatusError(http.StatusBadRequest, "rate_limit needs a positive rate and a non-negative burst")
+		}
+		apiKey.RateLimit = limit
+	}
+	if authCtx, ok := common.AuthContextFrom(ctx); ok {
+		apiKey.CreatedBy = authCtx.Subject
+	}
+	if _, exists := s.cfg.APIKeys[id]; exists {
+		return nil, common.NewStatusError(http.StatusConflict, "api key "+id+" is configured in API_KEYS")
+	}
+	if _, exists := keys.LookupAPIKey(id); exists {
+		return nil, common.NewStatusError(http.StatusConflict, "api key "+id+" already exists")
+	}
+	presented, hash, err := common.GenerateAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	apiKey.Hash = hash
+	if err := keys.SaveAPIKey(apiKey); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKey: withoutHash(apiKey), Key: presented}, nil
+}
+
+// ListAPIKeys returns the stored keys without their hashes.
+func (s *Service) ListAPIKeys(context.Context) ([]*common.APIKey, error) {
+	keys, err := s.apiKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	stored := keys.APIKeys()
+	listed := make([]*common.APIKey, 0, len(stored))
+	for _, key := range stored {
+		listed = append(listed, withoutHash(key))
+	}
+	return listed, nil
+}
+
+// RevokeAPIKey deletes a stored key; requests presenting it fail from then on.
+func (s *Service) RevokeAPIKey(_ context.Context, id string) error {
+	keys, err := s.apiKeyStore()
+	if err != nil {
+		return err
+	}
+	if _, configured := s.cfg.APIKeys[id]; configured {
+		return common.NewStatusError(http.StatusConflict, "api key "+id+" is configured in API_KEYS; remove it there")
+	}
+	deleted, err := keys.DeleteAPIKey(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return common.NewStatusError(http.StatusNotFound, "api key "+id+" not found")
+	}
+	return nil
+}
+
+func withoutHash(key *common.APIKey) *common.APIKey {
+	copied := *key
+	copied.Hash = ""
+	return &copied
+}
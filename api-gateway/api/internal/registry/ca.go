@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// CAClient registers and enrolls trainer identities through the fabric-ca-client CLI, acting
+// as the CA admin whose client home is configured.
+type CAClient struct {
+	url       *url.URL
+	caName    string
+	tlsCert   string
+	adminHome string
+	mspID     string
+	mspConfig string
+}
+
+// NewCAClient builds a client from the FABRIC_CA_* settings. Enrolled identities belong to the
+// default org.
+func NewCAClient(cfg *common.Config) (*CAClient, error) {
+	parsed, err := url.Parse(cfg.FabricCAURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("FABRIC_CA_URL must be an absolute URL, got %q", cfg.FabricCAURL)
+	}
+	if _, err := os.Stat(cfg.FabricCAAdminHome); err != nil {
+		return nil, fmt.Errorf("fabric CA admin home not found at %s: %w", cfg.FabricCAAdminHome, err)
+	}
+	return &CAClient{
+		url:       &url.URL{Scheme: parsed.Scheme, Host: parsed.Host},
+		caName:    cfg.FabricCAName,
+		tlsCert:   cfg.FabricCATLSCert,
+		adminHome: cfg.FabricCAAdminHome,
+		mspID:     cfg.MSPID,
+		mspConfig: cfg.FabricCAMSPConfig,
+	}, nil
+}
+
+// Enroll registers name as a client identity with a fresh secret (resetting the secret of an
+// identity registered before) and enrolls it, returning the issued material.
+func (c *CAClient) Enroll(name string) (*common.Identity, error) {
+	secretBytes := make([]byte, 16)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+	secret := hex.EncodeToString(secretBytes)
+	output, err := c.run("register", "--id.name", name, "--id.secret", secret, "--id.type", "client", "--url", c.url.String())
+	if err != nil && strings.Contains(strings.ToLower(output), "already registered") {
+		_, err = c.run("identity", "modify", name, "--secret", secret, "--url", c.url.String())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to register fabric identity %s: %w", name, err)
+	}
+
+	mspDir, err := os.MkdirTemp("", "enroll-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(mspDir)
+	enrollURL := *c.url
+	enrollURL.User = url.UserPassword(name, secret)
+	if _, err := c.run("enroll", "-u", enrollURL.String(), "-M", mspDir); err != nil {
+		return nil, fmt.Errorf("failed to enroll fabric identity %s: %w", name, err)
+	}
+	return c.readIdentity(name, mspDir)
+}
+
+// readIdentity collects the MSP folder fabric-ca-client wrote into a wallet identity.
+func (c *CAClient) readIdentity(name, mspDir string) (*common.Identity, error) {
+	identity := &common.Identity{Label: name, MSPID: c.mspID}
+	certs, err := readDir(filepath.Join(mspDir, "signcerts"))
+	if err != nil || len(certs) == 0 {
+		return nil, fmt.Errorf("enrollment of %s returned no certificate", name)
+	}
+	keys, err := readDir(filepath.Join(mspDir, "keystore"))
+	if err != nil || len(keys) == 0 {
+		return nil, fmt.Errorf("enrollment of %s returned no private key", name)
+	}
+	identity.Certificate, identity.PrivateKey = certs[0], keys[0]
+	if identity.CACerts, err = readDir(filepath.Join(mspDir, "cacerts")); err != nil {
+		return nil, err
+	}
+	if identity.IntermediateCerts, err = readDir(filepath.Join(mspDir, "intermediatecerts")); err != nil {
+		return nil, err
+	}
+	if c.mspConfig != "" {
+		if identity.NodeOUConfig, err = os.ReadFile(c.mspConfig); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read MSP config template: %w", err)
+		}
+	}
+	return identity, nil
+}
+
+func (c *CAClient) run(args ...string) (string, error) {
+	if c.caName != "" {
+		args = append(args, "--caname", c.caName)
+	}
+	if c.tlsCert != "" {
+		args = append(args, "--tls.certfiles", c.tlsCert)
+	}
+	cmd := exec.Command("fabric-ca-client", args...)
+	cmd.Env = append(os.Environ(), "FABRIC_CA_CLIENT_HOME="+c.adminHome)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		cleaned := common.SanitizeCLIError(output.String())
+		return output.String(), fmt.Errorf("fabric-ca-client %s failed: %s", args[0], cleaned)
+	}
+	return output.String(), nil
+}
+
+// readDir returns the contents of each file in dir by name; a missing dir is empty.
+func readDir(dir string) ([][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var contents [][]byte
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, data)
+	}
+	return contents, nil
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Capabilities describes the hardware profile a trainer advertises at registration.
+type Capabilities struct {
+	GPUClass            string                `json:"gpu_class,omitempty"`
+	RAMGB               int                   `json:"ram_gb,omitempty"`
+	BandwidthMbps       int                   `json:"bandwidth_mbps,omitempty"`
+	AvailabilityWindows []*AvailabilityWindow `json:"availability_windows,omitempty"`
+}
+
+// AvailabilityWindow is a recurring UTC range (HH:MM) on the listed weekdays.
+type AvailabilityWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+var knownDays = map[string]struct{}{
+	"mon": {}, "tue": {}, "wed": {}, "thu": {}, "fri": {}, "sat": {}, "sun": {},
+}
+
+// Normalize validates the capability metadata and canonicalizes casing.
+func (c *Capabilities) Normalize() error {
+	if c == nil {
+		return nil
+	}
+	c.GPUClass = strings.ToLower(strings.TrimSpace(c.GPUClass))
+	if c.RAMGB < 0 {
+		return fmt.Errorf("capabilities.ram_gb must be >= 0")
+	}
+	if c.BandwidthMbps < 0 {
+		return fmt.Errorf("capabilities.bandwidth_mbps must be >= 0")
+	}
+	for i, window := range c.AvailabilityWindows {
+		if window == nil {
+			return fmt.Errorf("capabilities.availability_windows[%d] is empty", i)
+		}
+		window.Start = strings.TrimSpace(window.Start)
+		window.End = strings.TrimSpace(window.End)
+		if _, err := time.Parse("15:04", window.Start); err != nil {
+			return fmt.Errorf("capabilities.availability_windows[%d].start must be HH:MM", i)
+		}
+		if _, err := time.Parse("15:04", window.End); err != nil {
+			return fmt.Errorf("capabilities.availability_windows[%d].end must be HH:MM", i)
+		}
+		for j, day := range window.Days {
+			normalized := strings.ToLower(strings.TrimSpace(day))
+			if len(normalized) > 3 {
+				normalized = normalized[:3]
+			}
+			if _, ok := knownDays[normalized]; !ok {
+				return fmt.Errorf("capabilities.availability_windows[%d].days: unknown day %s", i, day)
+			}
+			window.Days[j] = normalized
+		}
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package registry
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
 )
 
 // HTTPHandler exposes registry endpoints.
@@ -18,10 +21,244 @@ func NewHTTPHandler(svc *Service) *HTTPHandler {
 	return &HTTPHandler{svc: svc}
 }
 
-// RegisterRoutes mounts the enrollment endpoint.
+// RegisterRoutes mounts the enrollment endpoints, the admin whitelist lifecycle endpoints and
+// the service-account API key endpoints.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
 	mux.Handle("/auth/register-trainer", auth.RequireAuth(http.HandlerFunc(h.handleRegister)))
 	mux.Handle("/auth/register-trainers", auth.RequireAuth(http.HandlerFunc(h.handleBulkRegister), common.RoleAdmin))
+	trainerKeys := TrainerKeyFunc(h.svc.store)
+	keyFunc := func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
+		if strings.EqualFold(header.Alg, "EdDSA") && claims.Issuer == "" {
+			return trainerKeys(header, claims)
+		}
+		return nil, nil
+	}
+	mux.Handle("/auth/trainers/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleTrainer)))
+	mux.Handle("/admin/whitelist/removed", auth.RequireAuth(http.HandlerFunc(h.handleRemoved), common.RoleAdmin))
+	mux.Handle("/admin/whitelist/", auth.RequireAuth(http.HandlerFunc(h.handleWhitelistEntry), common.RoleAdmin))
+	mux.Handle("/admin/api-keys", auth.RequireAuth(http.HandlerFunc(h.handleAPIKeys), common.RoleAdmin))
+	mux.Handle("/admin/api-keys/", auth.RequireAuth(http.HandlerFunc(h.handleAPIKey), common.RoleAdmin))
+}
+
+// Describe documents the enrollment endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("registry")
+	registered := map[string]any{
+		"status":           "",
+		"jwt_sub":          "",
+		"fabric_client_id": "",
+		"vc_hash":          "",
+		"did":              "",
+		"node_id":          "",
+		"state":            "",
+		"cluster":          "",
+		"capabilities":     &Capabilities{},
+		"registered_at":    "",
+	}
+	api.Add(http.MethodPost, "/auth/register-trainer", openapi.Operation{
+		Summary:     "Enroll a trainer with an admin-signed verifiable credential",
+		Description: "Requires the HS256 registration token.",
+		Body:        registerRequest{},
+		Response:    registered,
+		Errors:      []int{http.StatusBadRequest, http.StatusConflict},
+	})
+	api.Add(http.MethodPost, "/auth/register-trainers", openapi.Operation{
+		Summary:     "Enroll several trainers",
+		Description: "Returns 207 when some entries failed.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Body:        []registerRequest{},
+		Response:    map[string]any{"results": []bulkRegisterResult{}},
+	})
+	const trainerOrAdmin = "Requires the trainer's runtime token (EdDSA) or an admin token."
+	api.Add(http.MethodPatch, "/auth/trainers/{did}", openapi.Operation{
+		Summary:     "Rotate a trainer's public key or move it to another state or cluster",
+		Description: trainerOrAdmin + " The previous values are kept on-chain; the whitelist entry and enrollment follow the change.",
+		Body:        UpdateInput{},
+		Response:    TrainerUpdate{},
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	})
+	api.Add(http.MethodGet, "/auth/trainers/{did}/updates", openapi.Operation{
+		Summary:     "List a trainer's updates with the values they replaced",
+		Description: trainerOrAdmin,
+		Response:    map[string]any{"items": []*TrainerUpdate{}},
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodPost, "/admin/whitelist/{jwt_sub}/deactivate", openapi.Operation{
+		Summary:     "Deactivate a trainer's whitelist entry",
+		Description: "The entry stays listed with status `deactivated`; convergence and client selection skip it and the trainer's runtime tokens are refused.",
+		Roles:       admin,
+		Body:        whitelistChangeRequest{},
+		Response:    WhitelistStatus{},
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+	api.Add(http.MethodPost, "/admin/whitelist/{jwt_sub}/reactivate", openapi.Operation{Summary: "Reactivate a deactivated whitelist entry", Roles: admin, Response: WhitelistStatus{}, Errors: []int{http.StatusNotFound, http.StatusConflict}})
+	api.Add(http.MethodDelete, "/admin/whitelist/{jwt_sub}", openapi.Operation{
+		Summary:     "Remove a decommissioned trainer from the whitelist",
+		Description: "Deletes the entry and the gateway enrollment and leaves a tombstone, so the subject cannot register again.",
+		Roles:       admin,
+		Body:        whitelistChangeRequest{},
+		Response:    WhitelistTombstone{},
+		Errors:      []int{http.StatusNotFound},
+	})
+	api.Add(http.MethodGet, "/admin/whitelist/removed", openapi.Operation{Summary: "List removed whitelist entries", Roles: admin, Response: map[string]any{"items": []*WhitelistTombstone{}}})
+	api.Add(http.MethodPost, "/admin/api-keys", openapi.Operation{
+		Summary:     "Create a service-account API key",
+		Description: "Returns the key once as `<id>.<secret>`; only its SHA-256 is stored. Keys are accepted on the routes listed in API_KEY_ROUTES.",
+		Roles:       admin,
+		Body:        APIKeyInput{},
+		Response:    CreatedAPIKey{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusNotImplemented},
+	})
+	api.Add(http.MethodGet, "/admin/api-keys", openapi.Operation{Summary: "List stored API keys without their hashes", Roles: admin, Response: map[string]any{"items": []*common.APIKey{}}, Errors: []int{http.StatusNotImplemented}})
+	api.Add(http.MethodDelete, "/admin/api-keys/{id}", openapi.Operation{Summary: "Revoke a stored API key", Roles: admin, Response: map[string]any{"id": "", "revoked": true}, Errors: []int{http.StatusNotFound, http.StatusConflict}})
+}
+
+// handleTrainer serves `PATCH /auth/trainers/{did}` and `GET /auth/trainers/{did}/updates`.
+func (h *HTTPHandler) handleTrainer(w http.ResponseWriter, r *http.Request) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	did := strings.TrimPrefix(r.URL.Path, "/auth/trainers/")
+	if trimmed, found := strings.CutSuffix(did, "/updates"); found {
+		if r.Method != http.MethodGet {
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+			return
+		}
+		updates, err := h.svc.TrainerUpdates(r.Context(), authCtx, trimmed)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": updates})
+		return
+	}
+	if r.Method != http.MethodPatch {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var input UpdateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	update, err := h.svc.UpdateTrainer(r.Context(), authCtx, did, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, update)
+}
+
+type whitelistChangeRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// handleWhitelistEntry serves `DELETE /admin/whitelist/{jwt_sub}` and
+// `POST /admin/whitelist/{jwt_sub}/{deactivate|reactivate}`.
+func (h *HTTPHandler) handleWhitelistEntry(w http.ResponseWriter, r *http.Request) {
+	jwtSub, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/whitelist/"), "/")
+	if jwtSub == "" {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	var req whitelistChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	var (
+		result any
+		err    error
+	)
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		result, err = h.svc.Remove(r.Context(), jwtSub, req.Reason)
+	case action == "deactivate" && r.Method == http.MethodPost:
+		result, err = h.svc.Deactivate(r.Context(), jwtSub, req.Reason)
+	case action == "reactivate" && r.Method == http.MethodPost:
+		result, err = h.svc.Reactivate(r.Context(), jwtSub)
+	case action == "" || action == "deactivate" || action == "reactivate":
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	default:
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, result)
+}
+
+func (h *HTTPHandler) handleRemoved(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	tombstones, err := h.svc.Removed(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": tombstones})
+}
+
+// handleAPIKeys serves `GET /admin/api-keys` and `POST /admin/api-keys`.
+func (h *HTTPHandler) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := h.svc.ListAPIKeys(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": keys})
+	case http.MethodPost:
+		var input APIKeyInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		created, err := h.svc.CreateAPIKey(r.Context(), input)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, created)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleAPIKey serves `DELETE /admin/api-keys/{id}`.
+func (h *HTTPHandler) handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/api-keys/")
+	if id == "" || strings.Contains(id, "/") {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	if r.Method != http.MethodDelete {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	if err := h.svc.RevokeAPIKey(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"id": id, "revoked": true})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
 }
 
 type registerRequest struct {
@@ -36,6 +273,7 @@ type registerRequest struct {
 	StateID         string          `json:"state_id"`
 	Cluster         string          `json:"cluster"`
 	ClusterID       string          `json:"cluster_id"`
+	Capabilities    *Capabilities   `json:"capabilities,omitempty"`
 }
 
 func (r *registerRequest) toInput() RegisterInput {
@@ -44,13 +282,14 @@ func (r *registerRequest) toInput() RegisterInput {
 		key = r.PublicKey2
 	}
 	return RegisterInput{
-		DID:        r.DID,
-		NodeID:     r.NodeID,
-		State:      r.stateValue(),
-		Cluster:    r.clusterValue(),
-		VC:         r.VC,
-		PublicKey:  key,
-		JWTSubject: r.requestedSubject(),
+		DID:          r.DID,
+		NodeID:       r.NodeID,
+		State:        r.stateValue(),
+		Cluster:      r.clusterValue(),
+		VC:           r.VC,
+		PublicKey:    key,
+		JWTSubject:   r.requestedSubject(),
+		Capabilities: r.Capabilities,
 	}
 }
 
@@ -130,6 +369,7 @@ func (h *HTTPHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		"node_id":          record.NodeID,
 		"state":            record.State,
 		"cluster":          record.Cluster,
+		"capabilities":     record.Capabilities,
 		"registered_at":    record.RegisteredAt,
 	})
 }
@@ -142,6 +382,7 @@ type bulkRegisterResult struct {
 	Cluster        string `json:"cluster,omitempty"`
 	Status         string `json:"status"`
 	Error          string `json:"error,omitempty"`
+	Code           string `json:"code,omitempty"`
 	HTTPStatus     int    `json:"status_code,omitempty"`
 	FabricClientID string `json:"fabric_client_id,omitempty"`
 	VCHash         string `json:"vc_hash,omitempty"`
@@ -182,6 +423,7 @@ func (h *HTTPHandler) handleBulkRegister(w http.ResponseWriter, r *http.Request)
 				NodeID:     payload.NodeID,
 				Status:     "error",
 				Error:      "subject could not be determined for this entry",
+				Code:       string(common.CodeInvalidRequest),
 				HTTPStatus: http.StatusBadRequest,
 			})
 			continue
@@ -202,6 +444,7 @@ func (h *HTTPHandler) handleBulkRegister(w http.ResponseWriter, r *http.Request)
 				JWTSub:     subject,
 				Status:     "error",
 				Error:      msg,
+				Code:       string(common.ErrorCodeOf(status, err)),
 				HTTPStatus: status,
 			})
 			continue
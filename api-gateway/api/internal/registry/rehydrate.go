@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+const rehydratePageSize = 100
+
+type ledgerWhitelistEntry struct {
+	JWTSub       string        `json:"jwt_sub"`
+	DID          string        `json:"did"`
+	NodeID       string        `json:"node_id"`
+	State        string        `json:"state"`
+	Cluster      string        `json:"cluster"`
+	VCHash       string        `json:"vc_hash"`
+	PublicKey    string        `json:"public_key"`
+	Registered   string        `json:"registered_at"`
+	Capabilities *Capabilities `json:"capabilities"`
+	Status       string        `json:"status"`
+}
+
+type ledgerWhitelistPage struct {
+	Items   []*ledgerWhitelistEntry `json:"items"`
+	HasMore bool                    `json:"has_more"`
+}
+
+// RehydrateFromLedger restores enrollments missing from the local store using the on-chain
+// whitelist, so a gateway that lost its store can keep serving registered trainers. The Fabric
+// identity is derived from the node ID exactly as during registration. It returns the number
+// of records restored.
+func (s *Service) RehydrateFromLedger(ctx context.Context) (int, error) {
+	restored := 0
+	for page := 1; ; page++ {
+		args := []string{"ListWhitelist", strconv.Itoa(page), strconv.Itoa(rehydratePageSize)}
+		raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, args)
+		if err != nil {
+			return restored, err
+		}
+		var result ledgerWhitelistPage
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return restored, err
+		}
+		for _, entry := range result.Items {
+			if entry == nil || strings.TrimSpace(entry.JWTSub) == "" || strings.TrimSpace(entry.NodeID) == "" {
+				continue
+			}
+			if _, ok := s.store.FindByJWTSub(entry.JWTSub); ok {
+				continue
+			}
+			record := &TrainerRecord{
+				JWTSub:         entry.JWTSub,
+				FabricClientID: buildFabricClientID(entry.NodeID),
+				DID:            entry.DID,
+				NodeID:         entry.NodeID,
+				State:          entry.State,
+				Cluster:        entry.Cluster,
+				VCHash:         entry.VCHash,
+				PublicKey:      entry.PublicKey,
+				RegisteredAt:   entry.Registered,
+				Capabilities:   entry.Capabilities,
+				Status:         entry.Status,
+			}
+			if err := s.store.Save(record); err != nil {
+				return restored, err
+			}
+			restored++
+		}
+		if !result.HasMore {
+			return restored, nil
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -18,26 +20,72 @@ import (
 type Service struct {
 	cfg      *common.Config
 	fabric   *common.FabricClient
-	store    *Store
+	store    Store
 	verifier *VCVerifier
+
+	// ca and wallet give each trainer its own enrolled Fabric identity when set.
+	ca     *CAClient
+	wallet common.Wallet
+	// cache is told when the whitelist changes.
+	cache *common.QueryCache
+	// events announces registered trainers.
+	events *common.LifecycleBus
+	// revokeSessions ends a trainer's gateway sessions when its whitelist entry is
+	// deactivated or removed.
+	revokeSessions func(jwtSub, reason string)
 }
 
 // RegisterInput captures the sanitized HTTP payload.
 type RegisterInput struct {
-	DID        string
-	NodeID     string
-	State      string
-	Cluster    string
-	VC         json.RawMessage
-	PublicKey  string
-	JWTSubject string
+	DID          string
+	NodeID       string
+	State        string
+	Cluster      string
+	VC           json.RawMessage
+	PublicKey    string
+	JWTSubject   string
+	Capabilities *Capabilities
 }
 
 // NewService wires a registry service instance.
-func NewService(cfg *common.Config, fabric *common.FabricClient, store *Store, verifier *VCVerifier) *Service {
+func NewService(cfg *common.Config, fabric *common.FabricClient, store Store, verifier *VCVerifier) *Service {
 	return &Service{cfg: cfg, fabric: fabric, store: store, verifier: verifier}
 }
 
+// EnableEnrollment makes Register enroll a dedicated Fabric identity for each new trainer
+// through ca and keep it in wallet, which the Fabric client signs that trainer's calls with.
+func (s *Service) EnableEnrollment(ca *CAClient, wallet common.Wallet) {
+	s.ca = ca
+	s.wallet = wallet
+}
+
+// EnableCache makes whitelist writes invalidate the whitelist reads cached in cache.
+func (s *Service) EnableCache(cache *common.QueryCache) {
+	s.cache = cache
+}
+
+// EnableEvents publishes a trainer.registered event for every registration.
+func (s *Service) EnableEvents(events *common.LifecycleBus) {
+	s.events = events
+}
+
+// EnableSessionRevocation makes deactivating or removing a whitelist entry call revoke, so
+// tokens the gateway already issued to the trainer stop working.
+func (s *Service) EnableSessionRevocation(revoke func(jwtSub, reason string)) {
+	s.revokeSessions = revoke
+}
+
+// TrainerEvent is the data of a trainer.registered event; keys and credential hashes are left
+// out.
+type TrainerEvent struct {
+	JWTSub       string `json:"jwt_sub"`
+	DID          string `json:"did"`
+	NodeID       string `json:"node_id"`
+	State        string `json:"state,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+	RegisteredAt string `json:"registered_at"`
+}
+
 // Register validates the VC, calls Fabric, and persists the trainer enrollment.
 func (s *Service) Register(ctx context.Context, authCtx *common.AuthContext, input RegisterInput) (*TrainerRecord, error) {
 	if authCtx == nil {
@@ -70,23 +118,34 @@ func (s *Service) Register(ctx context.Context, authCtx *common.AuthContext, inp
 	if len(input.VC) == 0 {
 		return nil, common.NewStatusError(http.StatusBadRequest, "vc is required")
 	}
+	if err := input.Capabilities.Normalize(); err != nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+	}
 
 	verified, err := s.verifier.Verify(input.VC, did)
 	if err != nil {
 		return nil, common.NewStatusError(http.StatusForbidden, err.Error())
 	}
+	if s.cfg.DIDRegistryRequired {
+		if err := s.requireRegisteredDID(ctx, did); err != nil {
+			return nil, err
+		}
+	}
 	pubKeyBytes, err := normalizePublicKey(publicKey)
 	if err != nil {
 		return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
 	}
 	canonicalPublicKey := base64.StdEncoding.EncodeToString(pubKeyBytes)
 	fabricID := buildFabricClientID(nodeID)
+	if err := s.ensureIdentity(fabricID); err != nil {
+		return nil, err
+	}
 	args := []string{"RegisterTrainer", did, nodeID, verified.Hash, canonicalPublicKey, state, cluster}
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	if err := s.fabric.InvokeChaincode(peerName, fabricID, args); err != nil {
+	if _, err := s.fabric.InvokeChaincode(ctx, peerName, fabricID, args); err != nil {
 		return nil, err
 	}
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -100,6 +159,10 @@ func (s *Service) Register(ctx context.Context, authCtx *common.AuthContext, inp
 		VCHash:         verified.Hash,
 		PublicKey:      canonicalPublicKey,
 		RegisteredAt:   now,
+		Capabilities:   input.Capabilities,
+	}
+	if existing, ok := s.store.FindByJWTSub(jwtSub); ok {
+		record.Status = existing.Status
 	}
 	if err := s.store.Save(record); err != nil {
 		return nil, err
@@ -107,14 +170,28 @@ func (s *Service) Register(ctx context.Context, authCtx *common.AuthContext, inp
 	if err := s.recordWhitelistEntry(ctx, record); err != nil {
 		return nil, err
 	}
+	s.events.Publish(&common.LifecycleEvent{
+		Type:  common.EventTrainerRegistered,
+		JobID: s.cfg.JobID,
+		Data:  &TrainerEvent{JWTSub: record.JWTSub, DID: record.DID, NodeID: record.NodeID, State: record.State, Cluster: record.Cluster, RegisteredAt: record.RegisteredAt},
+	})
 	return record, nil
 }
 
-// SyncWhitelist ensures every stored trainer record is mirrored on-chain.
+// SyncWhitelist ensures every stored trainer record is mirrored on-chain. Records whose
+// whitelist entry was removed are dropped from the store instead.
 func (s *Service) SyncWhitelist(ctx context.Context) error {
 	records := s.store.All()
 	for _, record := range records {
-		if err := s.recordWhitelistEntry(ctx, record); err != nil {
+		err := s.recordWhitelistEntry(ctx, record)
+		if se, ok := common.AsStatusError(err); ok && se.Code == http.StatusConflict {
+			log.Printf("dropping enrollment of %s: %s", record.JWTSub, se.Msg)
+			if _, err := s.store.Delete(record.JWTSub); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -125,6 +202,10 @@ func (s *Service) recordWhitelistEntry(ctx context.Context, record *TrainerRecor
 	if record == nil {
 		return common.NewStatusError(http.StatusBadRequest, "trainer record is required")
 	}
+	capabilities := ""
+	if record.Capabilities != nil {
+		capabilities = common.MustJSON(record.Capabilities)
+	}
 	args := []string{
 		"RecordWhitelistEntry",
 		record.JWTSub,
@@ -135,17 +216,59 @@ func (s *Service) recordWhitelistEntry(ctx context.Context, record *TrainerRecor
 		record.VCHash,
 		record.PublicKey,
 		record.RegisteredAt,
+		capabilities,
 	}
-	peerName := s.fabric.SelectPeer()
+	peerName := s.fabric.SelectPeer(common.PeerWrite)
 	if peerName == "" {
 		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
-	if err := s.fabric.InvokeChaincode(peerName, s.cfg.AdminIdentity, args); err != nil {
+	if _, err := s.fabric.InvokeChaincode(ctx, peerName, s.cfg.AdminIdentity, args); err != nil {
+		return mapWhitelistError(err)
+	}
+	s.cache.Invalidate("registration", common.CacheWhitelist)
+	return nil
+}
+
+// requireRegisteredDID rejects registrations whose DID is missing from the on-chain DID
+// registry or has been deactivated.
+func (s *Service) requireRegisteredDID(ctx context.Context, did string) error {
+	raw, err := s.fabric.QueryChaincode(s.cfg.ModuleContext(ctx, "did"), s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ResolveDID", did})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return common.NewStatusError(http.StatusForbidden, fmt.Sprintf("did %s is not registered on-chain", did))
+		}
+		return err
+	}
+	var record struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
 		return err
 	}
+	if !strings.EqualFold(record.Status, "ACTIVE") {
+		return common.NewStatusError(http.StatusForbidden, fmt.Sprintf("did %s is %s", did, strings.ToLower(record.Status)))
+	}
 	return nil
 }
 
+// ensureIdentity enrolls fabricID unless the wallet already holds it. It is a no-op when
+// enrollment is disabled.
+func (s *Service) ensureIdentity(fabricID string) error {
+	if s.ca == nil {
+		return nil
+	}
+	if _, err := s.wallet.Get(fabricID); err == nil {
+		return nil
+	} else if !errors.Is(err, common.ErrIdentityNotFound) {
+		return err
+	}
+	identity, err := s.ca.Enroll(fabricID)
+	if err != nil {
+		return common.NewStatusError(http.StatusBadGateway, err.Error())
+	}
+	return s.wallet.Put(identity)
+}
+
 func buildFabricClientID(nodeID string) string {
 	normalized := strings.ToLower(strings.TrimSpace(nodeID))
 	var b strings.Builder
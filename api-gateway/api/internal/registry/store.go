@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"sort"
 	"strings"
@@ -14,41 +15,87 @@ import (
 
 // TrainerRecord represents a verified trainer enrollment persisted by the gateway.
 type TrainerRecord struct {
-	JWTSub         string `json:"jwt_sub"`
-	FabricClientID string `json:"fabric_client_id"`
-	DID            string `json:"did"`
-	NodeID         string `json:"node_id"`
-	State          string `json:"state,omitempty"`
-	Cluster        string `json:"cluster,omitempty"`
-	VCHash         string `json:"vc_hash"`
-	PublicKey      string `json:"public_key"`
-	RegisteredAt   string `json:"registered_at"`
-}
-
-// Store keeps trainer enrollments on disk so they can be reused across restarts.
-type Store struct {
+	JWTSub         string        `json:"jwt_sub"`
+	FabricClientID string        `json:"fabric_client_id"`
+	DID            string        `json:"did"`
+	NodeID         string        `json:"node_id"`
+	State          string        `json:"state,omitempty"`
+	Cluster        string        `json:"cluster,omitempty"`
+	VCHash         string        `json:"vc_hash"`
+	PublicKey      string        `json:"public_key"`
+	RegisteredAt   string        `json:"registered_at"`
+	Capabilities   *Capabilities `json:"capabilities,omitempty"`
+	// Status mirrors the whitelist entry's status; deactivated trainers cannot authenticate.
+	Status string `json:"status,omitempty"`
+}
+
+// Store maps JWT subjects (or DIDs) to trainer enrollments. Deployments can plug in their
+// own backend by implementing it; NewStore builds the built-in ones.
+type Store interface {
+	// Save inserts or replaces the enrollment keyed by its JWT subject.
+	Save(record *TrainerRecord) error
+	// FindByJWTSub resolves an enrollment by JWT subject or DID.
+	FindByJWTSub(jwtSub string) (*TrainerRecord, bool)
+	// All returns a snapshot of every enrollment ordered by JWT subject.
+	All() []*TrainerRecord
+	// Delete drops the enrollment keyed by jwtSub, reporting whether it existed.
+	Delete(jwtSub string) (bool, error)
+}
+
+// NewStore builds the backend named by kind: "file" (default) persists to path, "memory"
+// keeps enrollments only for the life of the process.
+func NewStore(kind, path string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "file":
+		return NewFileStore(path)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown trainer store %q", kind)
+	}
+}
+
+// LocalStore indexes enrollments in memory and, when a path is set, mirrors them to a JSON file.
+type LocalStore struct {
 	path       string
 	mu         sync.RWMutex
 	byJWT      map[string]*TrainerRecord
 	byFabricID map[string]*TrainerRecord
 	byDID      map[string]*TrainerRecord
+	apiKeys    map[string]*common.APIKey
 }
 
-// NewStore loads existing records from disk, creating an empty store if the file doesn't exist.
-func NewStore(path string) (*Store, error) {
-	s := &Store{
-		path:       path,
-		byJWT:      map[string]*TrainerRecord{},
-		byFabricID: map[string]*TrainerRecord{},
-		byDID:      map[string]*TrainerRecord{},
+// NewFileStore loads existing records from disk, creating an empty store if the file doesn't exist.
+func NewFileStore(path string) (*LocalStore, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("trainer store path is required")
 	}
+	s := newLocalStore(path)
 	if err := s.load(); err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-func (s *Store) load() error {
+// NewMemoryStore returns a store that is never written to disk.
+func NewMemoryStore() *LocalStore {
+	return newLocalStore("")
+}
+
+func newLocalStore(path string) *LocalStore {
+	return &LocalStore{
+		path:       path,
+		byJWT:      map[string]*TrainerRecord{},
+		byFabricID: map[string]*TrainerRecord{},
+		byDID:      map[string]*TrainerRecord{},
+		apiKeys:    map[string]*common.APIKey{},
+	}
+}
+
+func (s *LocalStore) load() error {
+	if err := s.loadAPIKeys(); err != nil {
+		return err
+	}
 	data, err := os.ReadFile(s.path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -70,7 +117,7 @@ func (s *Store) load() error {
 }
 
 // Save stores/updates a trainer enrollment.
-func (s *Store) Save(record *TrainerRecord) error {
+func (s *LocalStore) Save(record *TrainerRecord) error {
 	if record == nil || record.JWTSub == "" {
 		return errors.New("invalid trainer record")
 	}
@@ -83,7 +130,7 @@ func (s *Store) Save(record *TrainerRecord) error {
 	return s.persistLocked()
 }
 
-func (s *Store) indexRecord(record *TrainerRecord) {
+func (s *LocalStore) indexRecord(record *TrainerRecord) {
 	jwtKey := strings.TrimSpace(record.JWTSub)
 	if jwtKey != "" {
 		s.byJWT[jwtKey] = record
@@ -97,8 +144,27 @@ func (s *Store) indexRecord(record *TrainerRecord) {
 	}
 }
 
+// Delete removes a trainer enrollment and its fabric identity and DID lookups.
+func (s *LocalStore) Delete(jwtSub string) (bool, error) {
+	key := strings.TrimSpace(jwtSub)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.byJWT[key]
+	if !ok {
+		return false, nil
+	}
+	delete(s.byJWT, key)
+	if s.byFabricID[record.FabricClientID] == record {
+		delete(s.byFabricID, record.FabricClientID)
+	}
+	if did := strings.TrimSpace(record.DID); s.byDID[did] == record {
+		delete(s.byDID, did)
+	}
+	return true, s.persistLocked()
+}
+
 // FindByJWTSub returns the enrollment for the provided JWT subject.
-func (s *Store) FindByJWTSub(jwtSub string) (*TrainerRecord, bool) {
+func (s *LocalStore) FindByJWTSub(jwtSub string) (*TrainerRecord, bool) {
 	key := strings.TrimSpace(jwtSub)
 	if key == "" {
 		return nil, false
@@ -114,7 +180,7 @@ func (s *Store) FindByJWTSub(jwtSub string) (*TrainerRecord, bool) {
 }
 
 // All returns a snapshot of every trainer record.
-func (s *Store) All() []*TrainerRecord {
+func (s *LocalStore) All() []*TrainerRecord {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	list := make([]*TrainerRecord, 0, len(s.byJWT))
@@ -128,7 +194,7 @@ func (s *Store) All() []*TrainerRecord {
 	return list
 }
 
-func (s *Store) lookupLocked(key string) *TrainerRecord {
+func (s *LocalStore) lookupLocked(key string) *TrainerRecord {
 	if rec, ok := s.byJWT[key]; ok {
 		return rec
 	}
@@ -138,7 +204,10 @@ func (s *Store) lookupLocked(key string) *TrainerRecord {
 	return nil
 }
 
-func (s *Store) persistLocked() error {
+func (s *LocalStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
 	list := make([]*TrainerRecord, 0, len(s.byJWT))
 	for _, rec := range s.byJWT {
 		list = append(list, rec)
@@ -153,6 +222,28 @@ func (s *Store) persistLocked() error {
 	return common.AtomicWriteFile(s.path, payload, 0o600)
 }
 
+// TrainerKeyFunc verifies runtime tokens against the Ed25519 key registered for the token subject.
+func TrainerKeyFunc(s Store) common.KeyFunc {
+	return func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
+		subject := strings.TrimSpace(claims.Subject)
+		if subject == "" {
+			return nil, errors.New("token missing subject")
+		}
+		record, ok := s.FindByJWTSub(subject)
+		if !ok {
+			return nil, errors.New("trainer not registered")
+		}
+		if record.Status == StatusDeactivated {
+			return nil, errors.New("trainer deactivated")
+		}
+		pub, err := record.PublicKeyBytes()
+		if err != nil {
+			return nil, err
+		}
+		return &common.KeySpec{Algorithm: "EdDSA", PublicKey: pub}, nil
+	}
+}
+
 // PublicKeyBytes returns the trainer public key decoded from base64.
 func (r *TrainerRecord) PublicKeyBytes() ([]byte, error) {
 	if strings.TrimSpace(r.PublicKey) == "" {
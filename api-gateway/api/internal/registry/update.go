@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// UpdateInput carries the trainer attributes to change; empty fields are left as they are.
+type UpdateInput struct {
+	PublicKey string `json:"public_key,omitempty"`
+	State     string `json:"state,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// TrainerFields are the trainer attributes an update can change.
+type TrainerFields struct {
+	PublicKey string `json:"public_key"`
+	State     string `json:"state,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+// TrainerUpdate is one entry of a trainer's on-chain update trail.
+type TrainerUpdate struct {
+	DID         string         `json:"did"`
+	ClientID    string         `json:"client_id"`
+	JWTSub      string         `json:"jwt_sub,omitempty"`
+	Previous    *TrainerFields `json:"previous"`
+	Current     *TrainerFields `json:"current"`
+	Reason      string         `json:"reason,omitempty"`
+	RequestedBy string         `json:"requested_by,omitempty"`
+	UpdatedAt   string         `json:"updated_at"`
+	TxID        string         `json:"tx_id"`
+}
+
+// UpdateTrainer rotates a trainer's public key or moves it to another state or cluster without
+// re-registering. Trainers may update themselves; admins may update anyone. The change is
+// signed with the trainer's own Fabric identity, mirrored to its whitelist entry and the local
+// enrollment, and the replaced values are kept on-chain.
+func (s *Service) UpdateTrainer(ctx context.Context, authCtx *common.AuthContext, did string, input UpdateInput) (*TrainerUpdate, error) {
+	record, err := s.authorizeTrainer(authCtx, did)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := strings.TrimSpace(input.PublicKey)
+	if publicKey != "" {
+		raw, err := normalizePublicKey(publicKey)
+		if err != nil {
+			return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+		}
+		publicKey = base64.StdEncoding.EncodeToString(raw)
+	}
+	state := strings.TrimSpace(input.State)
+	cluster := strings.TrimSpace(input.Cluster)
+	if publicKey == "" && state == "" && cluster == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "one of public_key, state or cluster is required")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"UpdateTrainer", record.DID, record.JWTSub, publicKey, state, cluster, strings.TrimSpace(input.Reason), authCtx.Subject}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, record.FabricClientID, args)
+	if err != nil {
+		return nil, mapUpdateError(err)
+	}
+	s.cache.Invalidate("trainer_update", common.CacheWhitelist)
+	var update TrainerUpdate
+	if err := json.Unmarshal(raw, &update); err != nil {
+		return nil, err
+	}
+	if update.Current != nil {
+		record.PublicKey = update.Current.PublicKey
+		record.State = update.Current.State
+		record.Cluster = update.Current.Cluster
+		if err := s.store.Save(record); err != nil {
+			return nil, err
+		}
+	}
+	return &update, nil
+}
+
+// TrainerUpdates returns a trainer's on-chain update trail, oldest first.
+func (s *Service) TrainerUpdates(ctx context.Context, authCtx *common.AuthContext, did string) ([]*TrainerUpdate, error) {
+	record, err := s.authorizeTrainer(authCtx, did)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListTrainerUpdates", record.DID})
+	if err != nil {
+		return nil, err
+	}
+	var updates []*TrainerUpdate
+	if err := json.Unmarshal(raw, &updates); err != nil {
+		return nil, err
+	}
+	if updates == nil {
+		updates = []*TrainerUpdate{}
+	}
+	return updates, nil
+}
+
+// authorizeTrainer resolves the enrollment of did and checks the caller may act on it: the
+// trainer itself, with its runtime token, or an admin.
+func (s *Service) authorizeTrainer(authCtx *common.AuthContext, did string) (*TrainerRecord, error) {
+	if authCtx == nil {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "authentication context missing")
+	}
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "did is required")
+	}
+	record, ok := s.store.FindByJWTSub(did)
+	if !ok || record.DID != did {
+		return nil, common.NewStatusError(http.StatusNotFound, "trainer not found")
+	}
+	if record.JWTSub == authCtx.Subject && isTrainerToken(authCtx) {
+		return record, nil
+	}
+	if authCtx.Role == common.RoleAdmin && !isTrainerToken(authCtx) {
+		return record, nil
+	}
+	return nil, common.NewStatusError(http.StatusForbidden, "only the trainer or an admin may access this trainer")
+}
+
+// isTrainerToken reports whether the request was authenticated with a trainer's own EdDSA
+// runtime token rather than a gateway, shared-secret or provider token.
+func isTrainerToken(authCtx *common.AuthContext) bool {
+	return authCtx.Header != nil && authCtx.Claims != nil &&
+		strings.EqualFold(authCtx.Header.Alg, "EdDSA") && authCtx.Claims.Issuer == ""
+}
+
+func mapUpdateError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "changes nothing"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	case strings.Contains(msg, "not the trainer"), strings.Contains(msg, "belongs to another trainer"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Whitelist entry statuses, as recorded by the chaincode.
+const (
+	StatusActive      = "active"
+	StatusDeactivated = "deactivated"
+)
+
+// WhitelistStatus is the chaincode's whitelist entry after a status change.
+type WhitelistStatus struct {
+	JWTSub          string `json:"jwt_sub"`
+	NodeID          string `json:"node_id"`
+	State           string `json:"state,omitempty"`
+	Cluster         string `json:"cluster,omitempty"`
+	Status          string `json:"status"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedBy string `json:"status_changed_by,omitempty"`
+	StatusChangedAt string `json:"status_changed_at,omitempty"`
+}
+
+// WhitelistTombstone is what the chaincode keeps of a removed whitelist entry.
+type WhitelistTombstone struct {
+	JWTSub    string `json:"jwt_sub"`
+	DID       string `json:"did"`
+	NodeID    string `json:"node_id"`
+	State     string `json:"state,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	RemovedBy string `json:"removed_by"`
+	RemovedAt string `json:"removed_at"`
+}
+
+// Deactivate marks a trainer's whitelist entry deactivated: it stays listed but is skipped by
+// convergence and client selection, and the trainer's runtime tokens are refused.
+func (s *Service) Deactivate(ctx context.Context, jwtSub, reason string) (*WhitelistStatus, error) {
+	var status WhitelistStatus
+	if err := s.submitWhitelist(ctx, &status, "DeactivateWhitelistEntry", jwtSub, strings.TrimSpace(reason)); err != nil {
+		return nil, err
+	}
+	if err := s.setStatus(jwtSub, status.Status); err != nil {
+		return nil, err
+	}
+	s.endSessions(jwtSub, "trainer deactivated")
+	return &status, nil
+}
+
+// Reactivate returns a deactivated trainer to the active whitelist.
+func (s *Service) Reactivate(ctx context.Context, jwtSub string) (*WhitelistStatus, error) {
+	var status WhitelistStatus
+	if err := s.submitWhitelist(ctx, &status, "ReactivateWhitelistEntry", jwtSub); err != nil {
+		return nil, err
+	}
+	if err := s.setStatus(jwtSub, status.Status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Remove deletes a decommissioned trainer from the whitelist and the local enrollment store.
+// The chaincode keeps a tombstone, so the subject cannot be registered again.
+func (s *Service) Remove(ctx context.Context, jwtSub, reason string) (*WhitelistTombstone, error) {
+	var tombstone WhitelistTombstone
+	if err := s.submitWhitelist(ctx, &tombstone, "RemoveWhitelistEntry", jwtSub, strings.TrimSpace(reason)); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.Delete(strings.TrimSpace(jwtSub)); err != nil {
+		return nil, err
+	}
+	s.endSessions(jwtSub, "trainer removed")
+	return &tombstone, nil
+}
+
+// Removed lists the tombstones of removed whitelist entries.
+func (s *Service) Removed(ctx context.Context) ([]*WhitelistTombstone, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListRemovedWhitelistEntries"})
+	if err != nil {
+		return nil, err
+	}
+	var tombstones []*WhitelistTombstone
+	if err := json.Unmarshal(raw, &tombstones); err != nil {
+		return nil, err
+	}
+	if tombstones == nil {
+		tombstones = []*WhitelistTombstone{}
+	}
+	return tombstones, nil
+}
+
+func (s *Service) submitWhitelist(ctx context.Context, target any, function, jwtSub string, args ...string) error {
+	jwtSub = strings.TrimSpace(jwtSub)
+	if jwtSub == "" {
+		return common.NewStatusError(http.StatusBadRequest, "jwt_sub is required")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.cfg.AdminIdentity, append([]string{function, jwtSub}, args...))
+	if err != nil {
+		return mapWhitelistError(err)
+	}
+	s.cache.Invalidate("whitelist_status", common.CacheWhitelist)
+	return json.Unmarshal(raw, target)
+}
+
+// setStatus copies a whitelist status to the local enrollment, if the gateway has one.
+func (s *Service) setStatus(jwtSub, status string) error {
+	record, ok := s.store.FindByJWTSub(strings.TrimSpace(jwtSub))
+	if !ok {
+		return nil
+	}
+	record.Status = status
+	return s.store.Save(record)
+}
+
+func (s *Service) endSessions(jwtSub, reason string) {
+	if s.revokeSessions != nil {
+		s.revokeSessions(strings.TrimSpace(jwtSub), reason)
+	}
+}
+
+func mapWhitelistError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "already deactivated"), strings.Contains(msg, "not deactivated"),
+		strings.Contains(msg, "was removed"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	}
+	return err
+}
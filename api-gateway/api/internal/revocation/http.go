@@ -0,0 +1,82 @@
+package revocation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the revocation list endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the revocation HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the `/revocations` endpoints.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/revocations", auth.RequireAuth(http.HandlerFunc(h.handleCollection), common.RoleAdmin))
+	mux.Handle("/revocations/", auth.RequireAuth(http.HandlerFunc(h.handleCheck), common.RoleAdmin, common.RoleCentralChecker))
+}
+
+// Describe documents the revocation endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("revocation")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodGet, "/revocations", openapi.Operation{Summary: "List revoked VC hashes", Roles: admin, Response: map[string]any{"items": []*Entry{}}})
+	api.Add(http.MethodPost, "/revocations", openapi.Operation{Summary: "Revoke a VC hash", Roles: admin, Body: RevokeRequest{}, Response: Entry{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/revocations/{vc_hash}", openapi.Operation{Summary: "Check whether a VC hash is revoked", Roles: []common.Role{common.RoleAdmin, common.RoleCentralChecker}, Response: Status{}})
+}
+
+func (h *HTTPHandler) handleCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := h.svc.List(r.Context())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": entries})
+	case http.MethodPost:
+		var req RevokeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		entry, err := h.svc.Revoke(r.Context(), &req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, entry)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func (h *HTTPHandler) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	status, err := h.svc.Check(r.Context(), strings.TrimPrefix(r.URL.Path, "/revocations/"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, status)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
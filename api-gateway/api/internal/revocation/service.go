@@ -0,0 +1,106 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Service manages the on-chain VC revocation list.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+}
+
+// NewService constructs a revocation service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric}
+}
+
+// Entry mirrors the on-chain RevokedVC record.
+type Entry struct {
+	VCHash    string `json:"vc_hash"`
+	Reason    string `json:"reason,omitempty"`
+	RevokedBy string `json:"revoked_by"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+// Status reports whether a VC hash is revoked.
+type Status struct {
+	VCHash  string `json:"vc_hash"`
+	Revoked bool   `json:"revoked"`
+}
+
+// RevokeRequest is the payload for adding a VC hash to the list.
+type RevokeRequest struct {
+	VCHash string `json:"vc_hash"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Revoke adds a VC hash to the revocation list, signed by the admin identity.
+func (s *Service) Revoke(ctx context.Context, req *RevokeRequest) (*Entry, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	vcHash := strings.ToLower(strings.TrimSpace(req.VCHash))
+	if vcHash == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "vc_hash is required")
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := []string{"AddRevokedVCHash", vcHash, strings.TrimSpace(req.Reason)}
+	if _, err := s.fabric.InvokeChaincode(ctx, peer, s.cfg.AdminIdentity, args); err != nil {
+		if strings.Contains(err.Error(), "already revoked") {
+			return nil, common.NewStatusError(http.StatusConflict, err.Error())
+		}
+		return nil, err
+	}
+	entries, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.VCHash == vcHash {
+			return entry, nil
+		}
+	}
+	return nil, common.NewStatusError(http.StatusInternalServerError, "revocation was not recorded")
+}
+
+// Check reports whether the VC hash is revoked.
+func (s *Service) Check(ctx context.Context, vcHash string) (*Status, error) {
+	vcHash = strings.ToLower(strings.TrimSpace(vcHash))
+	if vcHash == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "vc_hash is required")
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"IsVCRevoked", vcHash})
+	if err != nil {
+		return nil, err
+	}
+	var revoked bool
+	if err := json.Unmarshal(raw, &revoked); err != nil {
+		return nil, err
+	}
+	return &Status{VCHash: vcHash, Revoked: revoked}, nil
+}
+
+// List returns the full revocation list.
+func (s *Service) List(ctx context.Context) ([]*Entry, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListRevokedVCs"})
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,106 @@
+package rounds
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Run closes open rounds whose deadline has passed, every ROUND_DEADLINE_INTERVAL until the
+// context is cancelled. Each expired round is published to the Subscribe listeners.
+func (s *Service) Run(ctx context.Context) {
+	if s.cfg.RoundDeadlineInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.RoundDeadlineInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.expireDue(ctx, time.Now()); err != nil {
+				log.Printf("round scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// Subscribe registers a listener for rounds closed at their deadline. The returned cancel
+// function must be called once the listener is done.
+func (s *Service) Subscribe() (<-chan *Round, func()) {
+	ch := make(chan *Round, 8)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, ch)
+			s.mu.Unlock()
+		})
+	}
+}
+
+// expireDue closes every open round whose deadline is before now, for the gateway's job or,
+// without GATEWAY_JOB_ID, for every job. A failure on one round is logged and does not hold
+// back the others; a round another gateway or an aggregator closed first is skipped.
+func (s *Service) expireDue(ctx context.Context, now time.Time) error {
+	current, err := s.ListCurrent(ctx, s.cfg.JobID)
+	if err != nil {
+		return err
+	}
+	for _, round := range current {
+		if round.Status != StatusOpen || round.Deadline == "" {
+			continue
+		}
+		deadline, err := time.Parse(time.RFC3339, round.Deadline)
+		if err != nil || now.Before(deadline) {
+			continue
+		}
+		expired, err := s.expire(ctx, round)
+		if err != nil {
+			if strings.Contains(err.Error(), "already closed") || strings.Contains(err.Error(), "not the current round") {
+				continue
+			}
+			log.Printf("round scheduler: expire round %d of %s/%s/%s: %v", round.Round, round.JobID, round.Layer, round.ScopeID, err)
+			continue
+		}
+		log.Printf("round %d of %s/%s/%s closed at its deadline: %d participants, %d stragglers, quorum met: %t",
+			expired.Round, expired.JobID, expired.Layer, expired.ScopeID, len(expired.Participants), len(expired.Stragglers), expired.QuorumMet)
+		s.publish(expired)
+		s.events.Publish(&common.LifecycleEvent{Type: common.EventRoundClosed, JobID: expired.JobID, Data: expired})
+	}
+	return nil
+}
+
+// expire invokes ExpireRound with the admin identity and reads the closed round back.
+func (s *Service) expire(ctx context.Context, round *Round) (*Round, error) {
+	quorum := ""
+	if s.cfg.RoundMinQuorum > 0 {
+		quorum = strconv.Itoa(s.cfg.RoundMinQuorum)
+	}
+	args := []string{"ExpireRound", round.JobID, round.Layer, round.ScopeID, strconv.Itoa(round.Round), quorum}
+	if err := s.invoke(ctx, nil, args); err != nil {
+		return nil, err
+	}
+	return s.read(ctx, s.cfg.AdminIdentity, round.JobID, round.Layer, round.ScopeID)
+}
+
+func (s *Service) publish(round *Round) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- round:
+		default:
+			// Slow subscriber; the round stays readable through /rounds/current.
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/events"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes round management endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the rounds HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the `/rounds` endpoints.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/rounds/current", auth.RequireAuth(http.HandlerFunc(h.handleCurrent), common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
+	mux.Handle("/rounds/start", auth.RequireAuth(http.HandlerFunc(h.handleStart), common.RoleAggregator, common.RoleAdmin))
+	mux.Handle("/rounds/close", auth.RequireAuth(http.HandlerFunc(h.handleClose), common.RoleAggregator, common.RoleAdmin))
+	mux.Handle("/rounds/stream", auth.RequireAuth(http.HandlerFunc(h.handleStream), common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin))
+}
+
+// Describe documents the round endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("rounds")
+	managers := []common.Role{common.RoleAggregator, common.RoleAdmin}
+	scope := []openapi.Param{
+		{Name: "layer", Description: "Aggregation layer", Required: true},
+		{Name: "scope_id", Description: "Cluster, region or nation the round belongs to", Required: true},
+	}
+	api.Add(http.MethodGet, "/rounds/current", openapi.Operation{Summary: "Read the latest round for a layer and scope", Roles: []common.Role{common.RoleTrainer, common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}, Query: scope, Response: Round{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPost, "/rounds/start", openapi.Operation{Summary: "Open the next round", Roles: managers, Body: Request{}, Response: Round{}, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodPost, "/rounds/close", openapi.Operation{Summary: "Close an open round", Roles: managers, Body: Request{}, Response: Round{}, Errors: []int{http.StatusConflict}})
+	api.Add(http.MethodGet, "/rounds/stream", openapi.Operation{Summary: "Stream rounds closed at their deadline as server-sent events", Roles: []common.Role{common.RoleAggregator, common.RoleCentralChecker, common.RoleAdmin}, Response: "", Produces: "text/event-stream"})
+}
+
+func (h *HTTPHandler) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	query := r.URL.Query()
+	round, err := h.svc.Current(r.Context(), authCtx, query.Get("layer"), query.Get("scope_id"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, round)
+}
+
+func (h *HTTPHandler) handleStart(w http.ResponseWriter, r *http.Request) {
+	h.handleTransition(w, r, h.svc.Start)
+}
+
+func (h *HTTPHandler) handleClose(w http.ResponseWriter, r *http.Request) {
+	h.handleTransition(w, r, h.svc.Close)
+}
+
+func (h *HTTPHandler) handleTransition(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Round, error)) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	round, err := apply(r.Context(), authCtx, &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, round)
+}
+
+// handleStream sends a round_expired event for every round the scheduler closes at its
+// deadline, so aggregators can aggregate the participants without polling.
+func (h *HTTPHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	expired, cancel := h.svc.Subscribe()
+	defer cancel()
+	sse, err := events.NewSSEWriter(w)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusInternalServerError, err)
+		return
+	}
+	heartbeat := time.NewTicker(events.HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := sse.Heartbeat(); err != nil {
+				return
+			}
+		case round := <-expired:
+			id := fmt.Sprintf("%s:%s:%s:%d", round.JobID, round.Layer, round.ScopeID, round.Round)
+			if err := sse.Send("round_expired", id, round); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,229 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Service manages training rounds keyed by job, layer and scope.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+
+	mu          sync.Mutex
+	subscribers map[chan *Round]struct{}
+	events      *common.LifecycleBus
+}
+
+// NewService constructs a rounds service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store, subscribers: map[chan *Round]struct{}{}}
+}
+
+// EnableEvents publishes round.opened and round.closed events for the rounds this gateway
+// opens and closes, including those closed at their deadline.
+func (s *Service) EnableEvents(events *common.LifecycleBus) {
+	s.events = events
+}
+
+// Round mirrors the on-chain TrainingRound record.
+type Round struct {
+	JobID     string `json:"job_id"`
+	Layer     string `json:"layer"`
+	ScopeID   string `json:"scope_id"`
+	Round     int    `json:"round"`
+	Status    string `json:"status"`
+	StartedBy string `json:"started_by"`
+	StartedAt string `json:"started_at"`
+	ClosedBy  string `json:"closed_by,omitempty"`
+	ClosedAt  string `json:"closed_at,omitempty"`
+	// Deadline is set when the job's training config has round_duration_sec. The remaining
+	// fields are filled in when the round scheduler closed the round at its deadline.
+	Deadline     string   `json:"deadline,omitempty"`
+	CloseReason  string   `json:"close_reason,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+	Stragglers   []string `json:"stragglers,omitempty"`
+	MinQuorum    int      `json:"min_quorum,omitempty"`
+	QuorumMet    bool     `json:"quorum_met,omitempty"`
+	// Epoch is the membership epoch the round was started in.
+	Epoch int `json:"epoch,omitempty"`
+}
+
+// Request identifies a round; Round is only used when closing.
+type Request struct {
+	Layer   string `json:"layer"`
+	ScopeID string `json:"scope_id"`
+	Round   int    `json:"round,omitempty"`
+}
+
+// StatusOpen is the status of a round that accepts model commits.
+const StatusOpen = "OPEN"
+
+// Start opens the next round for the layer/scope under the gateway's job.
+func (s *Service) Start(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Round, error) {
+	layer, scope, err := validateScope(req)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"StartRound", s.cfg.JobID, layer, scope}
+	if err := s.invoke(ctx, authCtx, args); err != nil {
+		return nil, mapLedgerError(err)
+	}
+	round, err := s.Current(ctx, authCtx, layer, scope)
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(&common.LifecycleEvent{Type: common.EventRoundOpened, JobID: round.JobID, Data: round})
+	return round, nil
+}
+
+// Close closes the currently open round.
+func (s *Service) Close(ctx context.Context, authCtx *common.AuthContext, req *Request) (*Round, error) {
+	layer, scope, err := validateScope(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Round < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	args := []string{"CloseRound", s.cfg.JobID, layer, scope, strconv.Itoa(req.Round)}
+	if err := s.invoke(ctx, authCtx, args); err != nil {
+		return nil, mapLedgerError(err)
+	}
+	round, err := s.Current(ctx, authCtx, layer, scope)
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(&common.LifecycleEvent{Type: common.EventRoundClosed, JobID: round.JobID, Data: round})
+	return round, nil
+}
+
+// Current returns the latest round for the layer/scope.
+func (s *Service) Current(ctx context.Context, authCtx *common.AuthContext, layer, scopeID string) (*Round, error) {
+	layer, scope, err := validateScope(&Request{Layer: layer, ScopeID: scopeID})
+	if err != nil {
+		return nil, err
+	}
+	return s.current(ctx, s.identityFor(authCtx), layer, scope)
+}
+
+// ListCurrent returns the latest round of every job/layer/scope on the ledger, optionally
+// restricted to one job.
+func (s *Service) ListCurrent(ctx context.Context, jobID string) ([]*Round, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListCurrentRounds", strings.TrimSpace(jobID)})
+	if err != nil {
+		return nil, err
+	}
+	var rounds []*Round
+	if err := json.Unmarshal(raw, &rounds); err != nil {
+		return nil, err
+	}
+	if rounds == nil {
+		rounds = []*Round{}
+	}
+	return rounds, nil
+}
+
+// List returns every round of a job, open and closed, ordered by layer, scope and round.
+func (s *Service) List(ctx context.Context, jobID string) ([]*Round, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.cfg.AdminIdentity, []string{"ListRounds", strings.TrimSpace(jobID)})
+	if err != nil {
+		return nil, err
+	}
+	var rounds []*Round
+	if err := json.Unmarshal(raw, &rounds); err != nil {
+		return nil, err
+	}
+	if rounds == nil {
+		rounds = []*Round{}
+	}
+	return rounds, nil
+}
+
+// RequireOpen rejects commits for rounds that are closed or have not started yet.
+func (s *Service) RequireOpen(ctx context.Context, identity, layer, scopeID string, round int) error {
+	current, err := s.current(ctx, identity, layer, scopeID)
+	if err != nil {
+		if se, ok := common.AsStatusError(err); ok && se.Code == http.StatusNotFound {
+			return common.NewStatusError(http.StatusConflict, fmt.Sprintf("round %d has not started", round))
+		}
+		return err
+	}
+	switch {
+	case round > current.Round:
+		return common.NewStatusError(http.StatusConflict, fmt.Sprintf("round %d has not started; current round is %d", round, current.Round))
+	case round < current.Round || current.Status != StatusOpen:
+		return common.NewStatusError(http.StatusConflict, fmt.Sprintf("round %d is closed", round))
+	}
+	return nil
+}
+
+func (s *Service) current(ctx context.Context, identity, layer, scopeID string) (*Round, error) {
+	return s.read(ctx, identity, s.cfg.JobID, layer, scopeID)
+}
+
+func (s *Service) read(ctx context.Context, identity, jobID, layer, scopeID string) (*Round, error) {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), identity, []string{"GetCurrentRound", jobID, layer, scopeID})
+	if err != nil {
+		return nil, mapLedgerError(err)
+	}
+	var round Round
+	if err := json.Unmarshal(raw, &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+func (s *Service) invoke(ctx context.Context, authCtx *common.AuthContext, args []string) error {
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	_, err := s.fabric.InvokeChaincode(ctx, peer, s.identityFor(authCtx), args)
+	return err
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func validateScope(req *Request) (string, string, error) {
+	if req == nil {
+		return "", "", common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	layer := strings.ToLower(strings.TrimSpace(req.Layer))
+	if layer == "" {
+		return "", "", common.NewStatusError(http.StatusBadRequest, "layer is required")
+	}
+	scope := strings.TrimSpace(req.ScopeID)
+	if scope == "" {
+		return "", "", common.NewStatusError(http.StatusBadRequest, "scope_id is required")
+	}
+	return layer, scope, nil
+}
+
+func mapLedgerError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no round started"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "still open"), strings.Contains(msg, "not the current round"), strings.Contains(msg, "already closed"),
+		strings.Contains(msg, "has no deadline"), strings.Contains(msg, "has not passed"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	}
+	return err
+}
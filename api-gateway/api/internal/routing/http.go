@@ -0,0 +1,76 @@
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the admin peer routing endpoint.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the routing HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/routes`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/routes", auth.RequireAuth(http.HandlerFunc(h.handleRoutes), common.RoleAdmin))
+}
+
+// Describe documents the routing endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("routing")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodGet, "/admin/routes", openapi.Operation{
+		Summary:  "Read the peer routing and its change history",
+		Roles:    admin,
+		Response: map[string]any{"routing": common.PeerRouting{}, "changes": []*Change{}},
+	})
+	api.Add(http.MethodPost, "/admin/routes", openapi.Operation{
+		Summary:     "Replace the peer routing",
+		Description: "Swaps the peer set atomically without a restart. Every peer needs a host:port address and TLS material under ORG_CRYPTO_PATH. The change is recorded in the audit trail.",
+		Roles:       admin,
+		Body:        common.PeerRouting{},
+		Response:    map[string]any{"routing": common.PeerRouting{}, "change": Change{}},
+	})
+}
+
+func (h *HTTPHandler) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		common.WriteJSON(w, http.StatusOK, map[string]any{"routing": h.svc.Current(), "changes": h.svc.Changes()})
+	case http.MethodPost:
+		authCtx, ok := common.AuthContextFrom(r.Context())
+		if !ok {
+			common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+			return
+		}
+		var req common.PeerRouting
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		change, err := h.svc.Apply(authCtx.Subject, SourceAPI, req)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"routing": h.svc.Current(), "change": change})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
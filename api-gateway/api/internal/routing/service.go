@@ -0,0 +1,130 @@
+package routing
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Change sources.
+const (
+	SourceAPI  = "api"
+	SourceFile = "file"
+)
+
+// maxChanges bounds the in-memory audit trail of routing changes.
+const maxChanges = 100
+
+// Change is the audit record of one routing update.
+type Change struct {
+	ID        string             `json:"change_id"`
+	Actor     string             `json:"actor"`
+	Source    string             `json:"source"`
+	Before    common.PeerRouting `json:"before"`
+	After     common.PeerRouting `json:"after"`
+	ChangedAt string             `json:"changed_at"`
+}
+
+// Service swaps the FabricClient's peer routing at runtime, from the admin API or from
+// changes to the configuration file, and keeps an audit trail of every change.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+
+	mu      sync.Mutex
+	changes []*Change
+}
+
+// NewService constructs a routing service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric}
+}
+
+// Current returns the routing in effect.
+func (s *Service) Current() common.PeerRouting {
+	return s.fabric.Routing()
+}
+
+// Changes returns the retained audit trail, newest first.
+func (s *Service) Changes() []*Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := make([]*Change, len(s.changes))
+	for i, change := range s.changes {
+		changes[len(s.changes)-1-i] = change
+	}
+	return changes
+}
+
+// Apply validates routing and swaps it in. It returns a nil change when routing matches
+// what is already in effect.
+func (s *Service) Apply(actor, source string, routing common.PeerRouting) (*Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.fabric.Routing()
+	after, err := s.fabric.UpdateRouting(routing)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(before, after) {
+		return nil, nil
+	}
+	change := &Change{
+		ID:        common.GeneratePrefixedID("route"),
+		Actor:     actor,
+		Source:    source,
+		Before:    before,
+		After:     after,
+		ChangedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	s.changes = append(s.changes, change)
+	if len(s.changes) > maxChanges {
+		s.changes = s.changes[len(s.changes)-maxChanges:]
+	}
+	log.Printf("audit: peer routing %s changed by %s via %s: %s -> %s", change.ID, actor, source, common.MustJSON(before), common.MustJSON(after))
+	return change, nil
+}
+
+// Watch polls the configuration file every ConfigReloadInterval and applies its peer
+// routing when the file changes. Invalid files are logged and the current routing is kept.
+func (s *Service) Watch(ctx context.Context) {
+	path := common.ConfigFilePath()
+	if path == "" || s.cfg.ConfigReloadInterval <= 0 {
+		return
+	}
+	lastModified := modTime(path)
+	ticker := time.NewTicker(s.cfg.ConfigReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modified := modTime(path)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+			routing, err := s.cfg.ReloadPeerRouting()
+			if err == nil {
+				_, err = s.Apply("config-file", SourceFile, routing)
+			}
+			if err != nil {
+				log.Printf("ignoring peer routing from %s: %v", path, err)
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,403 @@
+package seed
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/jobs"
+	"github.com/nebula/api-gateway/internal/models"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Scenario describes a demo environment: the job and its training config, the states and
+// clusters trainers are placed in, the trainers themselves and an optional genesis model.
+type Scenario struct {
+	// KeySeed derives the Ed25519 key of every trainer without a public_key, the way
+	// `gateway -bench -seed` does, so the benchmark can drive seeded trainers.
+	KeySeed        string          `json:"key_seed"`
+	Job            *Job            `json:"job"`
+	TrainingConfig *TrainingConfig `json:"training_config"`
+	States         []*State        `json:"states"`
+	Trainers       []*Trainer      `json:"trainers"`
+	GenesisModel   *GenesisModel   `json:"genesis_model"`
+}
+
+// Job is the job to create. ID defaults to GATEWAY_JOB_ID; Start moves it to RUNNING once
+// everything else is seeded.
+type Job struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Start       bool   `json:"start"`
+}
+
+// TrainingConfig is the job's training config document, as for PUT
+// /job-contract/training-config.
+type TrainingConfig struct {
+	Config json.RawMessage `json:"config"`
+	Freeze *bool           `json:"freeze"`
+}
+
+// State lists the clusters of a state.
+type State struct {
+	ID       string   `json:"id"`
+	Clusters []string `json:"clusters"`
+}
+
+// Trainer is one trainer to enroll. NodeID defaults to Subject and DID to
+// did:nebula:<subject>; a trainer without a Cluster is placed round-robin over its state's
+// clusters.
+type Trainer struct {
+	Subject      string                 `json:"subject"`
+	NodeID       string                 `json:"node_id"`
+	DID          string                 `json:"did"`
+	State        string                 `json:"state"`
+	Cluster      string                 `json:"cluster"`
+	PublicKey    string                 `json:"public_key"`
+	Capabilities *registry.Capabilities `json:"capabilities"`
+
+	key ed25519.PrivateKey
+}
+
+// GenesisModel is the model every run starts from, committed by Trainer.
+type GenesisModel struct {
+	Trainer         string             `json:"trainer"`
+	Layer           string             `json:"layer"`
+	Scope           string             `json:"scope"`
+	Payload         json.RawMessage    `json:"payload"`
+	Metrics         map[string]float64 `json:"metrics"`
+	Hyperparameters json.RawMessage    `json:"hyperparameters"`
+}
+
+// genesisKey is the idempotency key of the genesis model commit.
+const genesisKey = "seed-genesis"
+
+// jobConfigured is the status a job must have to be started.
+const jobConfigured = "CONFIGURED"
+
+// Step outcomes.
+const (
+	OutcomeCreated = "created"
+	OutcomeExists  = "exists"
+)
+
+// Step is one thing the seeder did.
+type Step struct {
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Load reads a YAML (or JSON) scenario and fills in its defaults.
+func Load(path, defaultJobID string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenario Scenario
+	if err := common.DecodeYAML(data, &scenario); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := scenario.normalize(defaultJobID); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &scenario, nil
+}
+
+func (s *Scenario) normalize(defaultJobID string) error {
+	if s.Job != nil {
+		if s.Job.ID = strings.TrimSpace(s.Job.ID); s.Job.ID == "" {
+			s.Job.ID = defaultJobID
+		}
+		if s.Job.ID == "" {
+			return errors.New("job.id is required when GATEWAY_JOB_ID is not set")
+		}
+	}
+	if s.TrainingConfig != nil && s.Job == nil {
+		return errors.New("training_config needs a job")
+	}
+	clusters := map[string][]string{}
+	for i, state := range s.States {
+		if state == nil || strings.TrimSpace(state.ID) == "" {
+			return fmt.Errorf("states[%d]: id is required", i)
+		}
+		state.ID = strings.TrimSpace(state.ID)
+		if _, ok := clusters[state.ID]; ok {
+			return fmt.Errorf("state %s is listed twice", state.ID)
+		}
+		if len(state.Clusters) == 0 {
+			return fmt.Errorf("state %s has no clusters", state.ID)
+		}
+		clusters[state.ID] = state.Clusters
+	}
+	placed := map[string]int{}
+	subjects := map[string]bool{}
+	for i, trainer := range s.Trainers {
+		if trainer == nil || strings.TrimSpace(trainer.Subject) == "" {
+			return fmt.Errorf("trainers[%d]: subject is required", i)
+		}
+		trainer.Subject = strings.TrimSpace(trainer.Subject)
+		if subjects[trainer.Subject] {
+			return fmt.Errorf("trainer %s is listed twice", trainer.Subject)
+		}
+		subjects[trainer.Subject] = true
+		if trainer.NodeID == "" {
+			trainer.NodeID = trainer.Subject
+		}
+		if trainer.DID == "" {
+			trainer.DID = "did:nebula:" + trainer.Subject
+		}
+		stateClusters, ok := clusters[trainer.State]
+		if !ok {
+			return fmt.Errorf("trainer %s: state %q is not listed under states", trainer.Subject, trainer.State)
+		}
+		if trainer.Cluster == "" {
+			trainer.Cluster = stateClusters[placed[trainer.State]%len(stateClusters)]
+			placed[trainer.State]++
+		} else if !contains(stateClusters, trainer.Cluster) {
+			return fmt.Errorf("trainer %s: cluster %q is not a cluster of state %s", trainer.Subject, trainer.Cluster, trainer.State)
+		}
+		if trainer.PublicKey == "" {
+			if s.KeySeed == "" {
+				return fmt.Errorf("trainer %s: public_key is required without a key_seed", trainer.Subject)
+			}
+			seed := sha256.Sum256([]byte(s.KeySeed + ":" + trainer.Subject))
+			trainer.key = ed25519.NewKeyFromSeed(seed[:])
+			trainer.PublicKey = base64.StdEncoding.EncodeToString(trainer.key.Public().(ed25519.PublicKey))
+		}
+	}
+	if genesis := s.GenesisModel; genesis != nil {
+		if !subjects[genesis.Trainer] {
+			return fmt.Errorf("genesis_model: trainer %q is not listed under trainers", genesis.Trainer)
+		}
+		if genesis.Layer = strings.ToLower(strings.TrimSpace(genesis.Layer)); genesis.Layer == "" {
+			genesis.Layer = "nation"
+		}
+		if genesis.Scope == "" {
+			genesis.Scope = "nation"
+		}
+		if len(genesis.Payload) == 0 {
+			return errors.New("genesis_model: payload is required")
+		}
+	}
+	return nil
+}
+
+// PrivateKeys returns the keys derived from key_seed, by trainer subject.
+func (s *Scenario) PrivateKeys() map[string]ed25519.PrivateKey {
+	keys := map[string]ed25519.PrivateKey{}
+	for _, trainer := range s.Trainers {
+		if trainer.key != nil {
+			keys[trainer.Subject] = trainer.key
+		}
+	}
+	return keys
+}
+
+// Seeder applies scenarios through the gateway's own services, so seeded state passes the
+// same validation, credential checks and ledger calls as state created over HTTP.
+type Seeder struct {
+	cfg      *common.Config
+	store    registry.Store
+	registry *registry.Service
+	jobs     *jobs.Service
+	models   *models.Service
+	// adminKey signs the trainers' credentials; validFor bounds them.
+	adminKey ed25519.PrivateKey
+	validFor time.Duration
+}
+
+// NewSeeder constructs a seeder. adminKey must match ADMIN_PUBLIC_KEY.
+func NewSeeder(cfg *common.Config, store registry.Store, registrySvc *registry.Service, jobSvc *jobs.Service, modelSvc *models.Service, adminKey ed25519.PrivateKey, validFor time.Duration) *Seeder {
+	return &Seeder{cfg: cfg, store: store, registry: registrySvc, jobs: jobSvc, models: modelSvc, adminKey: adminKey, validFor: validFor}
+}
+
+// Run seeds the scenario: the job, its training config, the trainers, the genesis model, and
+// finally the job's start. Whatever already exists is left alone, so a scenario can be run
+// again after a failure; a training config that differs from the stored one becomes a new
+// version. report is called after each step.
+func (s *Seeder) Run(ctx context.Context, scenario *Scenario, report func(*Step)) error {
+	if job := scenario.Job; job != nil {
+		step, err := s.seedJob(ctx, job)
+		if err != nil {
+			return err
+		}
+		report(step)
+	}
+	if config := scenario.TrainingConfig; config != nil {
+		step, err := s.seedConfig(ctx, scenario.Job.ID, config)
+		if err != nil {
+			return err
+		}
+		report(step)
+	}
+	for _, trainer := range scenario.Trainers {
+		step, err := s.seedTrainer(ctx, trainer)
+		if err != nil {
+			return fmt.Errorf("trainer %s: %w", trainer.Subject, err)
+		}
+		report(step)
+	}
+	if genesis := scenario.GenesisModel; genesis != nil {
+		step, err := s.seedGenesis(ctx, genesis)
+		if err != nil {
+			return fmt.Errorf("genesis model: %w", err)
+		}
+		report(step)
+	}
+	if job := scenario.Job; job != nil && job.Start {
+		step, err := s.startJob(ctx, job.ID)
+		if err != nil {
+			return err
+		}
+		report(step)
+	}
+	return nil
+}
+
+func (s *Seeder) seedJob(ctx context.Context, job *Job) (*Step, error) {
+	step := &Step{Kind: "job", Subject: job.ID, Outcome: OutcomeExists}
+	if _, err := s.jobs.Get(ctx, job.ID); err == nil {
+		return step, nil
+	} else if common.StatusOf(err) != http.StatusNotFound {
+		return nil, fmt.Errorf("job %s: %w", job.ID, err)
+	}
+	if _, err := s.jobs.Create(ctx, &jobs.JobRequest{ID: job.ID, Name: job.Name, Description: job.Description}); err != nil {
+		return nil, fmt.Errorf("job %s: %w", job.ID, err)
+	}
+	step.Outcome = OutcomeCreated
+	return step, nil
+}
+
+func (s *Seeder) seedConfig(ctx context.Context, jobID string, config *TrainingConfig) (*Step, error) {
+	current, err := s.jobs.Config(ctx, jobID)
+	if err != nil && common.StatusOf(err) != http.StatusNotFound {
+		return nil, fmt.Errorf("training config: %w", err)
+	}
+	if err == nil && sameJSON(current.Config, config.Config) {
+		return &Step{Kind: "training_config", Subject: jobID, Outcome: OutcomeExists, Detail: fmt.Sprintf("version %d", current.Version)}, nil
+	}
+	stored, err := s.jobs.UpsertConfig(ctx, &jobs.ConfigRequest{JobID: jobID, Config: config.Config, Freeze: config.Freeze})
+	if err != nil {
+		return nil, fmt.Errorf("training config: %w", err)
+	}
+	return &Step{Kind: "training_config", Subject: jobID, Outcome: OutcomeCreated, Detail: fmt.Sprintf("version %d", stored.Version)}, nil
+}
+
+func (s *Seeder) seedTrainer(ctx context.Context, trainer *Trainer) (*Step, error) {
+	step := &Step{Kind: "trainer", Subject: trainer.Subject, Detail: trainer.State + "/" + trainer.Cluster}
+	if existing, ok := s.store.FindByJWTSub(trainer.Subject); ok {
+		if existing.DID != trainer.DID {
+			return nil, fmt.Errorf("already enrolled as %s", existing.DID)
+		}
+		step.Outcome = OutcomeExists
+		return step, nil
+	}
+	vc, err := s.credential(trainer.DID)
+	if err != nil {
+		return nil, err
+	}
+	authCtx := &common.AuthContext{Subject: trainer.Subject, NodeID: trainer.NodeID, State: trainer.State, Cluster: trainer.Cluster, Role: common.RoleTrainer}
+	_, err = s.registry.Register(ctx, authCtx, registry.RegisterInput{
+		DID:          trainer.DID,
+		NodeID:       trainer.NodeID,
+		State:        trainer.State,
+		Cluster:      trainer.Cluster,
+		VC:           vc,
+		PublicKey:    trainer.PublicKey,
+		JWTSubject:   trainer.Subject,
+		Capabilities: trainer.Capabilities,
+	})
+	if err != nil {
+		return nil, err
+	}
+	step.Outcome = OutcomeCreated
+	return step, nil
+}
+
+// credential signs a VC for did with the admin key, as vctool does.
+func (s *Seeder) credential(did string) (json.RawMessage, error) {
+	now := time.Now().UTC()
+	document := map[string]any{
+		"subject":     did,
+		"job_id":      s.cfg.JobID,
+		"valid_from":  now.Add(-time.Minute).Format(time.RFC3339),
+		"valid_until": now.Add(s.validFor).Format(time.RFC3339),
+	}
+	unsigned, err := registry.Canonicalize(document)
+	if err != nil {
+		return nil, err
+	}
+	document["signature"] = base64.StdEncoding.EncodeToString(ed25519.Sign(s.adminKey, unsigned))
+	return registry.Canonicalize(document)
+}
+
+func (s *Seeder) seedGenesis(ctx context.Context, genesis *GenesisModel) (*Step, error) {
+	authCtx := &common.AuthContext{Subject: genesis.Trainer, Role: common.RoleTrainer}
+	step := &Step{Kind: "genesis_model", Detail: genesis.Layer + "/" + genesis.Scope}
+	// The fixed idempotency key gives the genesis model the same identifier on every run.
+	step.Subject = common.DeterministicID("model", genesis.Trainer, genesis.Layer, genesisKey)
+	if _, err := s.models.Retrieve(ctx, authCtx, step.Subject); err == nil {
+		step.Outcome = OutcomeExists
+		return step, nil
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+	result, err := s.models.Commit(ctx, authCtx, genesis.Layer, genesis.Scope, genesis.Payload, &models.CommitOptions{
+		IdempotencyKey:  genesisKey,
+		Metrics:         genesis.Metrics,
+		Hyperparameters: genesis.Hyperparameters,
+	})
+	if err != nil {
+		return nil, err
+	}
+	step.Subject = result.DataID
+	step.Outcome = OutcomeCreated
+	return step, nil
+}
+
+func (s *Seeder) startJob(ctx context.Context, jobID string) (*Step, error) {
+	step := &Step{Kind: "job_start", Subject: jobID, Outcome: OutcomeExists}
+	job, err := s.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", jobID, err)
+	}
+	if job.Status != jobConfigured {
+		step.Detail = job.Status
+		return step, nil
+	}
+	if _, err := s.jobs.Start(ctx, jobID); err != nil {
+		return nil, fmt.Errorf("job %s: %w", jobID, err)
+	}
+	step.Outcome = OutcomeCreated
+	return step, nil
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	var left, right any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
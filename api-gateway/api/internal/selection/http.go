@@ -0,0 +1,51 @@
+package selection
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the client-selection API.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires a selection HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the selection endpoint.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/selection/rounds", auth.RequireAuth(http.HandlerFunc(h.handleSelect), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+}
+
+// Describe documents the selection endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	spec.Tag("selection").Add(http.MethodPost, "/selection/rounds", openapi.Operation{Summary: "Select trainers for a round", Roles: []common.Role{common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker}, Body: SelectRequest{}, Response: Selection{}})
+}
+
+func (h *HTTPHandler) handleSelect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req SelectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.svc.Select(r.Context(), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := common.AsStatusError(err); ok {
+			status = se.Code
+		}
+		common.WriteErrorWithCode(w, status, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, result)
+}
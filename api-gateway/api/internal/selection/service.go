@@ -0,0 +1,195 @@
+package selection
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/whitelist"
+)
+
+// Service builds balanced training rounds from the capability-aware whitelist.
+type Service struct {
+	cfg       *common.Config
+	whitelist *whitelist.Service
+}
+
+// NewService constructs a client-selection service.
+func NewService(cfg *common.Config, whitelist *whitelist.Service) *Service {
+	return &Service{cfg: cfg, whitelist: whitelist}
+}
+
+// SelectRequest describes the participants an aggregator wants for a round.
+type SelectRequest struct {
+	Round            int      `json:"round"`
+	Count            int      `json:"count"`
+	StateID          string   `json:"state_id,omitempty"`
+	ClusterID        string   `json:"cluster_id,omitempty"`
+	GPUClasses       []string `json:"gpu_classes,omitempty"`
+	MinRAMGB         int      `json:"min_ram_gb,omitempty"`
+	MinBandwidthMbps int      `json:"min_bandwidth_mbps,omitempty"`
+	AvailableAt      string   `json:"available_at,omitempty"`
+}
+
+// Candidate is a trainer picked for the round.
+type Candidate struct {
+	NodeID        string `json:"node_id"`
+	JWTSub        string `json:"jwt_sub"`
+	StateID       string `json:"state_id"`
+	ClusterID     string `json:"cluster_id"`
+	GPUClass      string `json:"gpu_class,omitempty"`
+	RAMGB         int    `json:"ram_gb,omitempty"`
+	BandwidthMbps int    `json:"bandwidth_mbps,omitempty"`
+}
+
+// Selection is the result of a balanced selection pass.
+type Selection struct {
+	Round       int            `json:"round"`
+	Requested   int            `json:"requested"`
+	Eligible    int            `json:"eligible"`
+	AvailableAt string         `json:"available_at"`
+	Selected    []*Candidate   `json:"selected"`
+	PerCluster  map[string]int `json:"per_cluster"`
+	PerGPUClass map[string]int `json:"per_gpu_class"`
+}
+
+// Select filters the whitelist by capability and spreads the picks evenly across
+// clusters, rotating the starting trainer per round so participation is shared.
+func (s *Service) Select(ctx context.Context, req *SelectRequest) (*Selection, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	if req.Count < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "count must be >= 0")
+	}
+	if req.Round < 0 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "round must be >= 0")
+	}
+	availableAt := time.Now().UTC()
+	if raw := strings.TrimSpace(req.AvailableAt); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, common.NewStatusError(http.StatusBadRequest, "available_at must be an RFC3339 timestamp")
+		}
+		availableAt = parsed.UTC()
+	}
+	filter := &whitelist.CapabilityFilter{
+		StateID:          strings.TrimSpace(req.StateID),
+		ClusterID:        strings.TrimSpace(req.ClusterID),
+		GPUClasses:       req.GPUClasses,
+		MinRAMGB:         req.MinRAMGB,
+		MinBandwidthMbps: req.MinBandwidthMbps,
+		AvailableAt:      availableAt.Format(time.RFC3339),
+	}
+	entries, err := s.whitelist.ListAllByCapability(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	picked := balance(entries, req.Count, req.Round)
+	result := &Selection{
+		Round:       req.Round,
+		Requested:   req.Count,
+		Eligible:    len(entries),
+		AvailableAt: filter.AvailableAt,
+		Selected:    picked,
+		PerCluster:  map[string]int{},
+		PerGPUClass: map[string]int{},
+	}
+	for _, candidate := range picked {
+		result.PerCluster[candidate.StateID+"/"+candidate.ClusterID]++
+		class := candidate.GPUClass
+		if class == "" {
+			class = "unknown"
+		}
+		result.PerGPUClass[class]++
+	}
+	return result, nil
+}
+
+// balance interleaves clusters round-robin; within a cluster trainers are ordered by
+// GPU class so mixed hardware is drawn evenly, then rotated by the round number.
+func balance(entries []*whitelist.Entry, count, round int) []*Candidate {
+	groups := map[string][]*Candidate{}
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		candidate := &Candidate{
+			NodeID:    entry.NodeID,
+			JWTSub:    entry.JWTSub,
+			StateID:   entry.State,
+			ClusterID: entry.Cluster,
+		}
+		if caps := entry.Capabilities; caps != nil {
+			candidate.GPUClass = caps.GPUClass
+			candidate.RAMGB = caps.RAMGB
+			candidate.BandwidthMbps = caps.BandwidthMbps
+		}
+		key := candidate.StateID + "/" + candidate.ClusterID
+		groups[key] = append(groups[key], candidate)
+	}
+	keys := make([]string, 0, len(groups))
+	for key, members := range groups {
+		keys = append(keys, key)
+		sort.Slice(members, func(i, j int) bool {
+			if members[i].GPUClass != members[j].GPUClass {
+				return members[i].GPUClass < members[j].GPUClass
+			}
+			return members[i].NodeID < members[j].NodeID
+		})
+		groups[key] = interleaveByGPU(members, round)
+	}
+	sort.Strings(keys)
+	if count == 0 || count > len(entries) {
+		count = len(entries)
+	}
+	selected := make([]*Candidate, 0, count)
+	for depth := 0; len(selected) < count; depth++ {
+		progressed := false
+		for _, key := range keys {
+			members := groups[key]
+			if depth >= len(members) {
+				continue
+			}
+			progressed = true
+			selected = append(selected, members[depth])
+			if len(selected) == count {
+				break
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return selected
+}
+
+func interleaveByGPU(members []*Candidate, round int) []*Candidate {
+	byClass := map[string][]*Candidate{}
+	classes := make([]string, 0)
+	for _, member := range members {
+		if _, ok := byClass[member.GPUClass]; !ok {
+			classes = append(classes, member.GPUClass)
+		}
+		byClass[member.GPUClass] = append(byClass[member.GPUClass], member)
+	}
+	for _, class := range classes {
+		bucket := byClass[class]
+		offset := round % len(bucket)
+		rotated := make([]*Candidate, 0, len(bucket))
+		rotated = append(rotated, bucket[offset:]...)
+		byClass[class] = append(rotated, bucket[:offset]...)
+	}
+	out := make([]*Candidate, 0, len(members))
+	for depth := 0; len(out) < len(members); depth++ {
+		for _, class := range classes {
+			if depth < len(byClass[class]) {
+				out = append(out, byClass[class][depth])
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// ArchiveContentType is the media type of snapshot archives.
+const ArchiveContentType = "application/gzip"
+
+// HTTPHandler exposes world-state snapshots to admins.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the snapshot HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/admin/snapshot`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/snapshot", auth.RequireAuth(http.HandlerFunc(h.handleSnapshot), common.RoleAdmin))
+}
+
+// Describe documents the snapshot endpoint.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("snapshot")
+	api.Add(http.MethodGet, "/admin/snapshot", openapi.Operation{
+		Summary:     "Export the gateway contract's world state",
+		Description: "Reads every key of the trainer, whitelist, model, convergence, round, job and related namespaces from one peer into a gzipped JSON archive (`" + Format + "` version " + strconv.Itoa(Version) + ") with its block height, block hash and a digest comparable with ComputeStateDigest. Aggregation leases, anchor receipts and audit batches are left out. Restore it on another network with `gateway -import-snapshot`.",
+		Roles:       []common.Role{common.RoleAdmin},
+		Query: []openapi.Param{
+			{Name: "height", Description: "Block height the archive must reflect; peers only serve their current state, so any other height is refused with 409."},
+		},
+		Response: Archive{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusServiceUnavailable},
+	})
+}
+
+func (h *HTTPHandler) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var height uint64
+	if raw := strings.TrimSpace(r.URL.Query().Get("height")); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 {
+			writeServiceError(w, common.NewStatusError(http.StatusBadRequest, "height must be a positive integer"))
+			return
+		}
+		height = parsed
+	}
+	archive, err := h.svc.Export(r.Context(), height)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", ArchiveContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("snapshot-%s-%d.json.gz", archive.Channel, archive.BlockHeight)))
+	w.WriteHeader(http.StatusOK)
+	if err := Write(w, archive); err != nil {
+		log.Printf("failed to write snapshot: %v", err)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
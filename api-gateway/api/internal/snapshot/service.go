@@ -0,0 +1,320 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Archive format identifiers. Version is bumped whenever the layout changes incompatibly.
+const (
+	Format  = "nebula-state-snapshot"
+	Version = 1
+)
+
+const (
+	// exportPageSize is how many keys one ExportStatePage call returns.
+	exportPageSize = 200
+	// exportAttempts bounds how often an export is restarted because a block was committed
+	// while it was reading.
+	exportAttempts = 3
+	// importBatchKeys and importBatchBytes bound one ImportStateEntries transaction; the byte
+	// bound stays below the chaincode's default 256 KiB argument limit.
+	importBatchKeys  = 100
+	importBatchBytes = 192 << 10
+	// configNamespace holds role enforcement and input limits, which could refuse the rest of
+	// an import once written, so it is imported last.
+	configNamespace = "config:"
+)
+
+// Service exports the gateway contract's world state into versioned archives and imports
+// them into another network, so thesis experiments can be repeated from identical state.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+}
+
+// NewService constructs the snapshot service.
+func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
+	return &Service{cfg: cfg, fabric: fabric}
+}
+
+// Archive is a snapshot of every key of the snapshot namespaces at one block height. Digest
+// and the namespace digests are computed like the chaincode's ComputeStateDigest, so a
+// restored network can be checked against the archive with the same function.
+type Archive struct {
+	Format      string       `json:"format"`
+	Version     int          `json:"version"`
+	Channel     string       `json:"channel"`
+	Chaincode   string       `json:"chaincode"`
+	Peer        string       `json:"peer"`
+	BlockHeight uint64       `json:"block_height"`
+	BlockHash   string       `json:"block_hash"`
+	ExportedAt  string       `json:"exported_at"`
+	Digest      string       `json:"digest"`
+	Namespaces  []*Namespace `json:"namespaces"`
+}
+
+// Namespace is the content of one key prefix or composite key object type.
+type Namespace struct {
+	Name    string   `json:"name"`
+	Digest  string   `json:"digest"`
+	Keys    int      `json:"keys"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Entry is one key and its raw value.
+type Entry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// ImportResult reports what an import wrote.
+type ImportResult struct {
+	Imported  int `json:"imported"`
+	Unchanged int `json:"unchanged"`
+	// Digest is the target network's state digest over the archive's namespaces after the
+	// import; it equals the archive's digest when the network holds exactly its state.
+	Digest   string `json:"digest"`
+	Verified bool   `json:"verified"`
+}
+
+type snapshotPage struct {
+	Entries  []*Entry `json:"entries"`
+	Bookmark string   `json:"bookmark"`
+	HasMore  bool     `json:"has_more"`
+}
+
+type stateDigest struct {
+	Digest string `json:"digest"`
+}
+
+// Export reads every snapshot namespace from one peer. Peers only serve their current state,
+// so height, when non-zero, must be the peer's current height. The height is read before and
+// after the namespaces; if a block was committed in between the export starts over, so the
+// archive never mixes two heights.
+func (s *Service) Export(ctx context.Context, height uint64) (*Archive, error) {
+	peer := s.fabric.SelectPeer(common.PeerRead)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "no fabric peers configured")
+	}
+	for attempt := 0; attempt < exportAttempts; attempt++ {
+		before, err := s.fabric.PeerChannelInfo(ctx, peer)
+		if err != nil {
+			return nil, err
+		}
+		if height != 0 && before.Height != height {
+			return nil, common.NewStatusError(http.StatusConflict, fmt.Sprintf("peer %s is at block height %d; only the current height can be exported", peer, before.Height))
+		}
+		namespaces, err := s.readNamespaces(ctx, peer)
+		if err != nil {
+			return nil, err
+		}
+		after, err := s.fabric.PeerChannelInfo(ctx, peer)
+		if err != nil {
+			return nil, err
+		}
+		if after.Height != before.Height {
+			if height != 0 {
+				return nil, common.NewStatusError(http.StatusConflict, fmt.Sprintf("peer %s moved past block height %d during the export", peer, height))
+			}
+			continue
+		}
+		target := s.cfg.Target(ctx)
+		archive := &Archive{
+			Format:      Format,
+			Version:     Version,
+			Channel:     target.Channel,
+			Chaincode:   target.Chaincode,
+			Peer:        peer,
+			BlockHeight: before.Height,
+			BlockHash:   before.CurrentBlockHash,
+			ExportedAt:  time.Now().UTC().Format(time.RFC3339),
+			Namespaces:  namespaces,
+		}
+		archive.Digest = digestNamespaces(namespaces)
+		return archive, nil
+	}
+	return nil, common.NewStatusError(http.StatusServiceUnavailable, fmt.Sprintf("peer %s kept committing blocks during %d export attempts", peer, exportAttempts))
+}
+
+func (s *Service) readNamespaces(ctx context.Context, peer string) ([]*Namespace, error) {
+	raw, err := s.fabric.QueryPeer(ctx, peer, s.cfg.AdminIdentity, []string{"ListSnapshotNamespaces"})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return nil, fmt.Errorf("invalid snapshot namespaces: %w", err)
+	}
+	sort.Strings(names)
+	namespaces := make([]*Namespace, 0, len(names))
+	for _, name := range names {
+		namespace := &Namespace{Name: name, Entries: []*Entry{}}
+		bookmark := ""
+		for {
+			raw, err := s.fabric.QueryPeer(ctx, peer, s.cfg.AdminIdentity, []string{"ExportStatePage", name, strconv.Itoa(exportPageSize), bookmark})
+			if err != nil {
+				return nil, err
+			}
+			var page snapshotPage
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return nil, fmt.Errorf("invalid export page of %s: %w", name, err)
+			}
+			namespace.Entries = append(namespace.Entries, page.Entries...)
+			if !page.HasMore {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+		namespace.Keys = len(namespace.Entries)
+		namespace.Digest = digestEntries(namespace.Entries)
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces, nil
+}
+
+// Import writes an archive's entries to the ledger in batches and then compares the
+// network's state digest with the archive's. Keys already holding the archived value are
+// skipped, so an interrupted import can be re-run; a key holding anything else fails it.
+func (s *Service) Import(ctx context.Context, archive *Archive) (*ImportResult, error) {
+	if err := Verify(archive); err != nil {
+		return nil, err
+	}
+	ordered := make([]*Namespace, 0, len(archive.Namespaces))
+	var config *Namespace
+	for _, namespace := range archive.Namespaces {
+		if namespace.Name == configNamespace {
+			config = namespace
+			continue
+		}
+		ordered = append(ordered, namespace)
+	}
+	if config != nil {
+		ordered = append(ordered, config)
+	}
+	result := &ImportResult{}
+	for _, namespace := range ordered {
+		var batch []*Entry
+		size := 0
+		for _, entry := range namespace.Entries {
+			entrySize := len(entry.Key) + len(entry.Value)*4/3 + 32
+			if len(batch) > 0 && (len(batch) == importBatchKeys || size+entrySize > importBatchBytes) {
+				if err := s.importBatch(ctx, batch, result); err != nil {
+					return nil, err
+				}
+				batch, size = nil, 0
+			}
+			batch = append(batch, entry)
+			size += entrySize
+		}
+		if len(batch) > 0 {
+			if err := s.importBatch(ctx, batch, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	names := make([]string, 0, len(archive.Namespaces))
+	for _, namespace := range archive.Namespaces {
+		names = append(names, namespace.Name)
+	}
+	raw, err := s.fabric.QueryChaincode(ctx, "", s.cfg.AdminIdentity, []string{"ComputeStateDigest", common.MustJSON(names)})
+	if err != nil {
+		return nil, err
+	}
+	var digest stateDigest
+	if err := json.Unmarshal(raw, &digest); err != nil {
+		return nil, fmt.Errorf("invalid state digest: %w", err)
+	}
+	result.Digest = digest.Digest
+	result.Verified = digest.Digest == archive.Digest
+	return result, nil
+}
+
+func (s *Service) importBatch(ctx context.Context, batch []*Entry, result *ImportResult) error {
+	payload, _, err := s.fabric.SubmitChaincode(ctx, "", s.cfg.AdminIdentity, []string{"ImportStateEntries", common.MustJSON(batch)})
+	if err != nil {
+		return err
+	}
+	var written ImportResult
+	if err := json.Unmarshal(payload, &written); err != nil {
+		return fmt.Errorf("invalid import result: %w", err)
+	}
+	result.Imported += written.Imported
+	result.Unchanged += written.Unchanged
+	return nil
+}
+
+// Verify checks an archive's format, version and digests.
+func Verify(archive *Archive) error {
+	if archive.Format != Format {
+		return common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("not a %s archive", Format))
+	}
+	if archive.Version != Version {
+		return common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("unsupported snapshot version %d (want %d)", archive.Version, Version))
+	}
+	for _, namespace := range archive.Namespaces {
+		if digestEntries(namespace.Entries) != namespace.Digest {
+			return common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("namespace %s does not match its digest", namespace.Name))
+		}
+	}
+	if digestNamespaces(archive.Namespaces) != archive.Digest {
+		return common.NewStatusError(http.StatusBadRequest, "archive does not match its digest")
+	}
+	return nil
+}
+
+// Write encodes the archive as gzipped JSON.
+func Write(w io.Writer, archive *Archive) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(archive); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read decodes a gzipped JSON archive.
+func Read(r io.Reader) (*Archive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("archive is not gzip-compressed: %w", err)
+	}
+	defer zr.Close()
+	var archive Archive
+	if err := json.NewDecoder(zr).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// digestEntries hashes length-prefixed key/value pairs in key order, as ComputeStateDigest
+// does for one namespace.
+func digestEntries(entries []*Entry) string {
+	hasher := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(hasher, "%d:%s%d:", len(entry.Key), entry.Key, len(entry.Value))
+		hasher.Write(entry.Value)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// digestNamespaces hashes the namespace digests in name order, as ComputeStateDigest does.
+func digestNamespaces(namespaces []*Namespace) string {
+	sorted := append([]*Namespace(nil), namespaces...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	hasher := sha256.New()
+	for _, namespace := range sorted {
+		fmt.Fprintf(hasher, "%s=%s;", namespace.Name, namespace.Digest)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
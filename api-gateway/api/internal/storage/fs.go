@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileDriver keeps payloads as files under a directory, e.g. a shared volume.
+type FileDriver struct {
+	root string
+}
+
+// NewFileDriver creates root if needed.
+func NewFileDriver(root string) (*FileDriver, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &FileDriver{root: root}, nil
+}
+
+// Name identifies the driver in pointers.
+func (d *FileDriver) Name() string {
+	return DriverFilesystem
+}
+
+// Put writes content atomically and returns a file:// URI relative to the root.
+func (d *FileDriver) Put(_ context.Context, key string, content []byte) (string, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".payload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return "file://" + key, nil
+}
+
+// Get reads the file a URI from Put names.
+func (d *FileDriver) Get(_ context.Context, uri string) ([]byte, error) {
+	key, ok := strings.CutPrefix(uri, "file://")
+	if !ok {
+		return nil, fmt.Errorf("not a file URI: %s", uri)
+	}
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// path resolves key under the root, refusing keys that would escape it.
+func (d *FileDriver) path(key string) (string, error) {
+	path := filepath.Join(d.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, d.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return path, nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// IPFSDriver pins payloads on an IPFS node through its Kubo RPC API.
+type IPFSDriver struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewIPFSDriver talks to the Kubo RPC API at apiURL (IPFS_API_URL).
+func NewIPFSDriver(apiURL string) *IPFSDriver {
+	return &IPFSDriver{apiURL: strings.TrimRight(apiURL, "/"), client: &http.Client{}}
+}
+
+// Name identifies the driver in pointers.
+func (d *IPFSDriver) Name() string {
+	return DriverIPFS
+}
+
+// Put adds and pins content and returns its ipfs:// URI. IPFS addresses content itself, so
+// key only names the file.
+func (d *IPFSDriver) Put(ctx context.Context, key string, content []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", key[strings.LastIndex(key, "/")+1:])
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", err
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+	query := url.Values{"pin": {"true"}, "cid-version": {"1"}, "quieter": {"true"}}
+	resp, err := d.call(ctx, "add", query, &body, form.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var added struct {
+		Hash string `json:"Hash"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return "", fmt.Errorf("failed to decode IPFS add response: %w", err)
+	}
+	return "ipfs://" + added.Hash, nil
+}
+
+// Get reads the content an ipfs:// URI names.
+func (d *IPFSDriver) Get(ctx context.Context, uri string) ([]byte, error) {
+	cid, ok := strings.CutPrefix(uri, "ipfs://")
+	if !ok || cid == "" {
+		return nil, fmt.Errorf("not an IPFS URI: %s", uri)
+	}
+	resp, err := d.call(ctx, "cat", url.Values{"arg": {cid}}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (d *IPFSDriver) call(ctx context.Context, command string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.apiURL+"/api/v0/"+command+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	common.InjectTraceparent(ctx, req.Header)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("IPFS %s failed: %w", command, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	var failure struct {
+		Message string `json:"Message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	return nil, fmt.Errorf("IPFS %s returned %d: %s", command, resp.StatusCode, failure.Message)
+}
+
+// PingIPFS reports whether the Kubo RPC API at apiURL answers its version command.
+func PingIPFS(ctx context.Context, apiURL string) error {
+	resp, err := NewIPFSDriver(apiURL).call(ctx, "version", url.Values{}, nil, "")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// S3Driver stores payloads as objects in an S3-compatible bucket such as MinIO. Requests use
+// path-style addressing and are signed with AWS Signature Version 4.
+type S3Driver struct {
+	cfg    common.S3Config
+	client *http.Client
+}
+
+// NewS3Driver builds a driver for the configured endpoint and bucket.
+func NewS3Driver(cfg common.S3Config) *S3Driver {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3Driver{cfg: cfg, client: &http.Client{}}
+}
+
+// Name identifies the driver in pointers.
+func (d *S3Driver) Name() string {
+	return DriverS3
+}
+
+// Put uploads content under key and returns its s3:// URI.
+func (d *S3Driver) Put(ctx context.Context, key string, content []byte) (string, error) {
+	resp, err := d.do(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return "s3://" + d.cfg.Bucket + "/" + key, nil
+}
+
+// Get downloads the object an s3:// URI of this bucket names.
+func (d *S3Driver) Get(ctx context.Context, uri string) ([]byte, error) {
+	key, ok := strings.CutPrefix(uri, "s3://"+d.cfg.Bucket+"/")
+	if !ok || key == "" {
+		return nil, fmt.Errorf("not an object of bucket %s: %s", d.cfg.Bucket, uri)
+	}
+	resp, err := d.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (d *S3Driver) do(ctx context.Context, method, key string, content []byte) (*http.Response, error) {
+	path := "/" + d.cfg.Bucket + "/" + escapeKey(key)
+	req, err := http.NewRequestWithContext(ctx, method, d.cfg.Endpoint+path, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = path
+	req.ContentLength = int64(len(content))
+	d.sign(req, content, time.Now().UTC())
+	common.InjectTraceparent(ctx, req.Header)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s failed: %w", method, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+	return nil, fmt.Errorf("S3 %s returned %d: %s", method, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// sign adds the SigV4 headers for a request without query parameters.
+func (d *S3Driver) sign(req *http.Request, content []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(content)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + d.cfg.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+d.cfg.SecretKey), day)
+	key = hmacSHA256(key, d.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", d.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapeKey URI-encodes each segment of an object key as SigV4 expects.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
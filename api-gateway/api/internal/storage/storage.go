@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Storage drivers selectable with STORAGE_DRIVER.
+const (
+	DriverNone       = "none"
+	DriverFilesystem = "filesystem"
+	DriverIPFS       = "ipfs"
+	DriverS3         = "s3"
+)
+
+// Driver stores payloads outside the world state. Put returns the URI Get reads the content
+// back from; keys are content addressed, so storing the same content twice is harmless.
+type Driver interface {
+	Name() string
+	Put(ctx context.Context, key string, content []byte) (string, error)
+	Get(ctx context.Context, uri string) ([]byte, error)
+}
+
+// NewDriver builds the driver the configuration names, or nil for "none".
+func NewDriver(cfg *common.Config) (Driver, error) {
+	switch cfg.StorageDriver {
+	case "", DriverNone:
+		return nil, nil
+	case DriverFilesystem:
+		return NewFileDriver(cfg.StoragePath)
+	case DriverIPFS:
+		return NewIPFSDriver(cfg.IPFSAPIURL), nil
+	case DriverS3:
+		return NewS3Driver(cfg.StorageS3), nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+}
+
+// Pointer replaces an offloaded payload on-chain. SHA256 is the hex digest of the content;
+// Digest, when set, is the payload hash the chaincode records instead, for content whose own
+// hash changes on every commit, such as an encrypted envelope.
+type Pointer struct {
+	Driver string `json:"driver"`
+	URI    string `json:"uri"`
+	SHA256 string `json:"sha256"`
+	Digest string `json:"digest,omitempty"`
+	Size   int    `json:"size"`
+}
+
+// pointerDocument is the on-chain form of a Pointer. The single wrapping key keeps it from
+// being mistaken for an ordinary payload.
+type pointerDocument struct {
+	Offchain *Pointer `json:"offchain"`
+}
+
+// Offloader moves payloads of at least threshold bytes to a driver and writes a Pointer
+// on-chain in their place. A nil Offloader keeps every payload on-chain.
+type Offloader struct {
+	driver    Driver
+	threshold int
+}
+
+// NewOffloader returns nil when driver is nil, so callers can use the result unconditionally.
+func NewOffloader(driver Driver, threshold int) *Offloader {
+	if driver == nil {
+		return nil
+	}
+	return &Offloader{driver: driver, threshold: threshold}
+}
+
+// Open builds the offloader the configuration describes.
+func Open(cfg *common.Config) (*Offloader, error) {
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewOffloader(driver, cfg.StorageOffloadBytes), nil
+}
+
+// Offload returns what should be committed for payload: a pointer document when the payload
+// was stored off-chain under kind, the payload itself otherwise.
+func (o *Offloader) Offload(ctx context.Context, kind string, payload []byte) ([]byte, error) {
+	return o.OffloadWithDigest(ctx, kind, payload, "")
+}
+
+// OffloadWithDigest is Offload for payloads whose payload hash is digest rather than the
+// SHA-256 of the bytes stored; the pointer carries it for the chaincode to index.
+func (o *Offloader) OffloadWithDigest(ctx context.Context, kind string, payload []byte, digest string) ([]byte, error) {
+	if o == nil || len(payload) < o.threshold {
+		return payload, nil
+	}
+	sum := sha256.Sum256(payload)
+	contentHash := hex.EncodeToString(sum[:])
+	uri, err := o.driver.Put(ctx, kind+"/"+contentHash, payload)
+	if err != nil {
+		return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("failed to store payload off-chain: %v", err))
+	}
+	pointer := &Pointer{Driver: o.driver.Name(), URI: uri, SHA256: contentHash, Size: len(payload)}
+	if digest = strings.ToLower(digest); digest != contentHash {
+		pointer.Digest = digest
+	}
+	return json.Marshal(pointerDocument{Offchain: pointer})
+}
+
+// Rehydrate resolves a payload as the chaincode returns it, a JSON string, into the payload
+// that was committed. Pointers are fetched from their driver and checked against their
+// digest; anything else is returned unchanged.
+func (o *Offloader) Rehydrate(ctx context.Context, raw json.RawMessage) (json.RawMessage, error) {
+	var value string
+	if len(raw) == 0 || raw[0] != '"' || json.Unmarshal(raw, &value) != nil {
+		return raw, nil
+	}
+	pointer := ParsePointer([]byte(value))
+	if pointer == nil {
+		return raw, nil
+	}
+	if o == nil {
+		return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("payload is stored off-chain at %s but no storage driver is configured", pointer.URI))
+	}
+	if pointer.Driver != o.driver.Name() {
+		return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("payload is stored with the %s driver, not %s", pointer.Driver, o.driver.Name()))
+	}
+	content, err := o.driver.Get(ctx, pointer.URI)
+	if err != nil {
+		return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("failed to read off-chain payload %s: %v", pointer.URI, err))
+	}
+	sum := sha256.Sum256(content)
+	if len(content) != pointer.Size || !strings.EqualFold(hex.EncodeToString(sum[:]), pointer.SHA256) {
+		return nil, common.NewStatusError(http.StatusBadGateway, fmt.Sprintf("off-chain payload %s failed its integrity check", pointer.URI))
+	}
+	return json.Marshal(string(content))
+}
+
+// ParsePointer returns the pointer payload refers to, or nil if it is an ordinary payload.
+func ParsePointer(payload []byte) *Pointer {
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 || payload[0] != '{' {
+		return nil
+	}
+	var document pointerDocument
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&document); err != nil || document.Offchain == nil {
+		return nil
+	}
+	if document.Offchain.URI == "" || document.Offchain.SHA256 == "" {
+		return nil
+	}
+	return document.Offchain
+}
@@ -0,0 +1,97 @@
+package submissions
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// HTTPHandler exposes the status of asynchronous writes.
+type HTTPHandler struct {
+	tracker *Tracker
+	store   registry.Store
+}
+
+// NewHTTPHandler wires the submissions HTTP handler.
+func NewHTTPHandler(tracker *Tracker, store registry.Store) *HTTPHandler {
+	return &HTTPHandler{tracker: tracker, store: store}
+}
+
+// RegisterRoutes mounts `/submissions`. Trainers poll with the same runtime EdDSA tokens they
+// submitted with; every other role uses its usual token.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	trainerKeys := registry.TrainerKeyFunc(h.store)
+	keyFunc := func(header *common.TokenHeader, claims *common.JWTClaims) (*common.KeySpec, error) {
+		if strings.EqualFold(header.Alg, "EdDSA") && claims.Issuer == "" {
+			return trainerKeys(header, claims)
+		}
+		return nil, nil
+	}
+	mux.Handle("/submissions", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleList)))
+	mux.Handle("/submissions/", auth.RequireAuthWithKeyFunc(keyFunc, http.HandlerFunc(h.handleSubmission)))
+}
+
+// Describe documents the submission endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("submissions")
+	api.Add(http.MethodGet, "/submissions", openapi.Operation{
+		Summary:     "List your asynchronous writes",
+		Description: "Admins see every caller's, optionally filtered by sub.",
+		Query:       []openapi.Param{{Name: "sub", Description: "Subject to list (admins only)"}},
+		Response:    map[string]any{"items": []*Submission{}},
+	})
+	api.Add(http.MethodGet, "/submissions/{id}", openapi.Operation{
+		Summary:     "Poll an asynchronous write",
+		Description: "Any authenticated write sent with `Prefer: respond-async` is answered 202 with an Accepted body; poll here until status is committed or failed.",
+		Response:    Submission{},
+		Errors:      []int{http.StatusNotFound},
+	})
+}
+
+func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	subject := strings.TrimSpace(r.URL.Query().Get("sub"))
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": h.tracker.List(authCtx, subject)})
+}
+
+func (h *HTTPHandler) handleSubmission(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/submissions/"), "/")
+	if id == "" {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "submission id is required"))
+		return
+	}
+	submission, err := h.tracker.Get(authCtx, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, submission)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
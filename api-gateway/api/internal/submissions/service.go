@@ -0,0 +1,274 @@
+package submissions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Submission states.
+const (
+	StatusPending   = "pending"
+	StatusCommitted = "committed"
+	StatusFailed    = "failed"
+)
+
+// maxPendingSubmissions bounds how many writes one subject may have in flight.
+const maxPendingSubmissions = 20
+
+// Submission is the tracked outcome of an asynchronous write.
+type Submission struct {
+	ID          string          `json:"submission_id"`
+	Subject     string          `json:"sub"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Status      string          `json:"status"`
+	HTTPStatus  int             `json:"http_status,omitempty"`
+	TxID        string          `json:"tx_id,omitempty"`
+	BlockNumber uint64          `json:"block_number,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	// ErrorCode is the code of the failed write's error envelope.
+	ErrorCode   string `json:"error_code,omitempty"`
+	SubmittedAt string `json:"submitted_at"`
+	CompletedAt string `json:"completed_at,omitempty"`
+
+	expires time.Time
+}
+
+// Accepted is the 202 body returned in place of the write's response.
+type Accepted struct {
+	ID        string `json:"submission_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+// Tracker runs writes whose caller sent `Prefer: respond-async` in the background and keeps
+// their outcome for SubmissionTTL after they finish. A write finishes when the chaincode
+// invoke behind it sees its commit event (or fails), so polling reports the ledger outcome.
+type Tracker struct {
+	cfg    *common.Config
+	exempt []string
+
+	mu      sync.Mutex
+	items   map[string]*Submission
+	pending map[string]int
+}
+
+// NewTracker constructs a tracker. Routes under exempt prefixes are always served
+// synchronously (uploads stream bodies too large to hold for a background run).
+func NewTracker(cfg *common.Config, exempt ...string) *Tracker {
+	return &Tracker{cfg: cfg, exempt: exempt, items: map[string]*Submission{}, pending: map[string]int{}}
+}
+
+// Accepts reports whether r is a write that asked for asynchronous handling.
+func (t *Tracker) Accepts(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return false
+	}
+	for _, prefix := range t.exempt {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	for _, value := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Submit answers 202 with the submission handle and serves r through next in the background.
+func (t *Tracker) Submit(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+	now := time.Now()
+	submission := &Submission{
+		ID:          common.GeneratePrefixedID("sub"),
+		Subject:     authCtx.Subject,
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Status:      StatusPending,
+		SubmittedAt: now.UTC().Format(time.RFC3339),
+	}
+	t.mu.Lock()
+	t.sweepLocked(now)
+	if t.pending[authCtx.Subject] >= maxPendingSubmissions {
+		t.mu.Unlock()
+		common.WriteErrorWithCode(w, http.StatusTooManyRequests, common.NewStatusError(http.StatusTooManyRequests, fmt.Sprintf("at most %d submissions may be pending", maxPendingSubmissions)))
+		return
+	}
+	t.items[submission.ID] = submission
+	t.pending[authCtx.Subject]++
+	t.mu.Unlock()
+
+	background := r.Clone(detached{r.Context()})
+	background.Body = io.NopCloser(bytes.NewReader(body))
+	background.ContentLength = int64(len(body))
+	background.Header.Del("Prefer")
+	go t.run(submission.ID, background, next)
+
+	statusURL := "/submissions/" + submission.ID
+	w.Header().Set("Location", statusURL)
+	w.Header().Set("Preference-Applied", "respond-async")
+	common.WriteJSON(w, http.StatusAccepted, &Accepted{ID: submission.ID, Status: StatusPending, StatusURL: statusURL})
+}
+
+func (t *Tracker) run(id string, r *http.Request, next http.Handler) {
+	capture := &responseCapture{header: http.Header{}, status: http.StatusOK}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("submission %s panicked: %v", id, recovered)
+			capture = &responseCapture{header: http.Header{}, status: http.StatusInternalServerError}
+			capture.body.WriteString(`{"code":"INTERNAL","message":"internal error"}`)
+		}
+		t.complete(id, capture)
+	}()
+	next.ServeHTTP(capture, r)
+}
+
+// complete records the handler's response. Success bodies are kept as the result and their
+// tx_id/block_number copied onto the submission; error bodies become Error.
+func (t *Tracker) complete(id string, capture *responseCapture) {
+	var envelope struct {
+		TxID        string `json:"tx_id"`
+		BlockNumber uint64 `json:"block_number"`
+		Code        string `json:"code"`
+		Message     string `json:"message"`
+	}
+	body := bytes.TrimSpace(capture.body.Bytes())
+	_ = json.Unmarshal(body, &envelope)
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	submission, ok := t.items[id]
+	if !ok {
+		return
+	}
+	submission.HTTPStatus = capture.status
+	submission.CompletedAt = now.UTC().Format(time.RFC3339)
+	submission.expires = now.Add(t.cfg.SubmissionTTL)
+	if capture.status < http.StatusBadRequest {
+		submission.Status = StatusCommitted
+		submission.TxID = envelope.TxID
+		submission.BlockNumber = envelope.BlockNumber
+		if json.Valid(body) {
+			submission.Result = json.RawMessage(body)
+		}
+	} else {
+		submission.Status = StatusFailed
+		submission.Error = envelope.Message
+		submission.ErrorCode = envelope.Code
+		if submission.Error == "" {
+			submission.Error = http.StatusText(capture.status)
+		}
+	}
+	t.pending[submission.Subject]--
+	if t.pending[submission.Subject] <= 0 {
+		delete(t.pending, submission.Subject)
+	}
+}
+
+// Get returns a submission visible to authCtx: its own, or any for admins.
+func (t *Tracker) Get(authCtx *common.AuthContext, id string) (*Submission, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweepLocked(time.Now())
+	submission, ok := t.items[id]
+	if !ok || (authCtx.Role != common.RoleAdmin && submission.Subject != authCtx.Subject) {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("submission %s not found", id))
+	}
+	copied := *submission
+	return &copied, nil
+}
+
+// List returns the caller's submissions, newest first. Admins may name another subject or
+// pass "" to list everyone's.
+func (t *Tracker) List(authCtx *common.AuthContext, subject string) []*Submission {
+	if authCtx.Role != common.RoleAdmin {
+		subject = authCtx.Subject
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweepLocked(time.Now())
+	items := make([]*Submission, 0)
+	for _, submission := range t.items {
+		if subject == "" || submission.Subject == subject {
+			copied := *submission
+			items = append(items, &copied)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].SubmittedAt != items[j].SubmittedAt {
+			return items[i].SubmittedAt > items[j].SubmittedAt
+		}
+		return items[i].ID > items[j].ID
+	})
+	return items
+}
+
+// sweepLocked forgets finished submissions past their TTL.
+func (t *Tracker) sweepLocked(now time.Time) {
+	for id, submission := range t.items {
+		if submission.Status != StatusPending && now.After(submission.expires) {
+			delete(t.items, id)
+		}
+	}
+}
+
+// responseCapture records the response a handler writes for a background request.
+type responseCapture struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (c *responseCapture) Header() http.Header {
+	return c.header
+}
+
+func (c *responseCapture) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.status = code
+		c.wroteHeader = true
+	}
+}
+
+func (c *responseCapture) Write(p []byte) (int, error) {
+	c.wroteHeader = true
+	return c.body.Write(p)
+}
+
+// detached keeps the request's values (auth context, trace span) but not its cancellation,
+// which fires as soon as the 202 is written.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (d detached) Done() <-chan struct{}       { return nil }
+func (d detached) Err() error                  { return nil }
+func (d detached) Value(key any) any           { return d.parent.Value(key) }
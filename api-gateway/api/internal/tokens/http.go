@@ -0,0 +1,168 @@
+package tokens
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the token issuance endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the tokens HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/auth/challenge`, `/auth/token`, and `/auth/refresh`, which are
+// unauthenticated (the signed challenge or refresh token is the credential) and throttled
+// per client address, and the admin-only `/auth/sessions` revocation API.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/auth/challenge", auth.Throttle(common.RateClassAuth, http.HandlerFunc(h.handleChallenge)))
+	mux.Handle("/auth/token", auth.Throttle(common.RateClassAuth, http.HandlerFunc(h.handleToken)))
+	mux.Handle("/auth/refresh", auth.Throttle(common.RateClassAuth, http.HandlerFunc(h.handleRefresh)))
+	mux.Handle("/auth/sessions", auth.RequireAuth(http.HandlerFunc(h.handleSessions), common.RoleAdmin))
+	mux.Handle("/auth/sessions/", auth.RequireAuth(http.HandlerFunc(h.handleSession), common.RoleAdmin))
+}
+
+// Describe documents the token issuance endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("tokens")
+	admin := []common.Role{common.RoleAdmin}
+	subject := []openapi.Param{{Name: "sub", Description: "Trainer JWT subject"}}
+	reason := openapi.Param{Name: "reason", Description: "Recorded on the revoked sessions"}
+	api.Add(http.MethodPost, "/auth/challenge", openapi.Operation{Summary: "Request a challenge for a registered trainer", Public: true, Body: challengeRequest{}, Response: Challenge{}, Errors: []int{http.StatusNotFound, http.StatusTooManyRequests}})
+	api.Add(http.MethodPost, "/auth/token", openapi.Operation{Summary: "Exchange a signed challenge for a token", Public: true, Body: tokenRequest{}, Response: Token{}, Errors: []int{http.StatusUnauthorized, http.StatusTooManyRequests}})
+	api.Add(http.MethodPost, "/auth/refresh", openapi.Operation{Summary: "Rotate a refresh token for a new access token", Public: true, Body: refreshRequest{}, Response: Token{}, Errors: []int{http.StatusUnauthorized, http.StatusTooManyRequests}})
+	api.Add(http.MethodGet, "/auth/sessions", openapi.Operation{Summary: "List trainer sessions", Roles: admin, Query: subject, Response: map[string]any{"sessions": []*Session{}}})
+	api.Add(http.MethodDelete, "/auth/sessions", openapi.Operation{Summary: "Revoke every session of a trainer", Roles: admin, Query: []openapi.Param{{Name: "sub", Description: "Trainer JWT subject", Required: true}, reason}, Response: map[string]any{"revoked": 0}})
+	api.Add(http.MethodDelete, "/auth/sessions/{id}", openapi.Operation{Summary: "Revoke one session", Roles: admin, Query: []openapi.Param{reason}, Response: Session{}, Errors: []int{http.StatusNotFound}})
+}
+
+type challengeRequest struct {
+	Subject string `json:"sub"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenRequest struct {
+	Subject   string `json:"sub"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+func (h *HTTPHandler) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req challengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	challenge, err := h.svc.Challenge(req.Subject)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, challenge)
+}
+
+func (h *HTTPHandler) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	token, err := h.svc.Exchange(req.Subject, req.Nonce, req.Signature)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	common.WriteJSON(w, http.StatusOK, token)
+}
+
+func (h *HTTPHandler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	token, err := h.svc.Refresh(req.RefreshToken)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	common.WriteJSON(w, http.StatusOK, token)
+}
+
+// handleSessions lists sessions (GET, optionally ?sub=) or revokes every session of a
+// trainer (DELETE ?sub=, optional &reason=).
+func (h *HTTPHandler) handleSessions(w http.ResponseWriter, r *http.Request) {
+	subject := strings.TrimSpace(r.URL.Query().Get("sub"))
+	switch r.Method {
+	case http.MethodGet:
+		common.WriteJSON(w, http.StatusOK, map[string]any{"sessions": h.svc.Sessions().List(subject)})
+	case http.MethodDelete:
+		if subject == "" {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "sub is required"))
+			return
+		}
+		revoked := h.svc.Sessions().RevokeSubject(subject, revokerOf(r), r.URL.Query().Get("reason"))
+		common.WriteJSON(w, http.StatusOK, map[string]any{"revoked": revoked})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleSession revokes one session: DELETE /auth/sessions/{id}, optional ?reason=.
+func (h *HTTPHandler) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/auth/sessions/"), "/")
+	if id == "" {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "session id is required"))
+		return
+	}
+	session, err := h.svc.Sessions().Revoke(id, revokerOf(r), r.URL.Query().Get("reason"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, session)
+}
+
+func revokerOf(r *http.Request) string {
+	if authCtx, ok := common.AuthContextFrom(r.Context()); ok {
+		return authCtx.Subject
+	}
+	return ""
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,211 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// maxPendingChallenges caps the unanswered challenges one trainer can hold at a time.
+const maxPendingChallenges = 5
+
+// Challenge is a one-time nonce a trainer signs with its registered key to obtain a token.
+type Challenge struct {
+	Subject   string `json:"sub"`
+	Nonce     string `json:"nonce"`
+	Message   string `json:"message"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// Token is a gateway-issued bearer token with the refresh token of its session.
+type Token struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	ExpiresAt        string `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+	SessionID        string `json:"session_id"`
+}
+
+type pendingChallenge struct {
+	subject string
+	message string
+	expires time.Time
+}
+
+// Service issues gateway tokens to registered trainers that prove control of their key.
+type Service struct {
+	cfg      *common.Config
+	store    registry.Store
+	issuer   *common.TokenIssuer
+	sessions *SessionStore
+
+	mu         sync.Mutex
+	challenges map[string]*pendingChallenge
+}
+
+// NewService wires the token service.
+func NewService(cfg *common.Config, store registry.Store, issuer *common.TokenIssuer, sessions *SessionStore) *Service {
+	return &Service{cfg: cfg, store: store, issuer: issuer, sessions: sessions, challenges: map[string]*pendingChallenge{}}
+}
+
+// Sessions returns the store backing issued tokens.
+func (s *Service) Sessions() *SessionStore {
+	return s.sessions
+}
+
+// Challenge creates a nonce for a registered trainer. The trainer signs Message with the
+// Ed25519 key it registered and exchanges the signature for a token before ExpiresAt.
+func (s *Service) Challenge(subject string) (*Challenge, error) {
+	record, err := s.trainer(subject)
+	if err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	nonce := hex.EncodeToString(raw)
+	expires := time.Now().UTC().Add(s.cfg.AuthChallengeTTL)
+	message := strings.Join([]string{common.GatewayIssuer, "auth", record.JWTSub, nonce}, ":")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	pending := 0
+	for _, challenge := range s.challenges {
+		if challenge.subject == record.JWTSub {
+			pending++
+		}
+	}
+	if pending >= maxPendingChallenges {
+		return nil, common.NewStatusError(http.StatusTooManyRequests, "too many pending challenges; answer or let one expire first")
+	}
+	s.challenges[nonce] = &pendingChallenge{subject: record.JWTSub, message: message, expires: expires}
+	return &Challenge{
+		Subject:   record.JWTSub,
+		Nonce:     nonce,
+		Message:   message,
+		ExpiresAt: expires.Format(time.RFC3339),
+	}, nil
+}
+
+// Exchange consumes a challenge and, when signature is the trainer's base64 Ed25519
+// signature over its message, opens a session and issues a token carrying the trainer's
+// role, state, and cluster. A nonce can be used once, whether or not the signature verifies.
+func (s *Service) Exchange(subject, nonce, signature string) (*Token, error) {
+	nonce = strings.TrimSpace(nonce)
+	if nonce == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "nonce is required")
+	}
+	s.mu.Lock()
+	challenge, ok := s.challenges[nonce]
+	delete(s.challenges, nonce)
+	s.mu.Unlock()
+	if !ok || time.Now().After(challenge.expires) {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "challenge is unknown, expired, or already used")
+	}
+	record, err := s.trainer(subject)
+	if err != nil {
+		return nil, err
+	}
+	if record.JWTSub != challenge.subject {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "challenge was issued to a different trainer")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "signature must be base64 encoded")
+	}
+	pub, err := record.PublicKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(ed25519.PublicKey(pub), []byte(challenge.message), sig) {
+		return nil, common.NewStatusError(http.StatusUnauthorized, "signature does not match the trainer's registered public key")
+	}
+	if strings.TrimSpace(record.State) == "" {
+		return nil, common.NewStatusError(http.StatusConflict, "trainer enrollment has no state; re-register with state_id")
+	}
+	session, refresh, err := s.sessions.create(record.JWTSub)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(record, session, refresh)
+}
+
+// Refresh rotates a refresh token and issues a new access token for the same session. The
+// enrollment is looked up again so state and cluster changes are picked up.
+func (s *Service) Refresh(refreshToken string) (*Token, error) {
+	if strings.TrimSpace(refreshToken) == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "refresh_token is required")
+	}
+	session, refresh, err := s.sessions.rotate(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := s.store.FindByJWTSub(session.Subject)
+	if !ok {
+		s.sessions.Revoke(session.ID, "gateway", "trainer no longer registered")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "trainer not registered")
+	}
+	if record.Status == registry.StatusDeactivated {
+		s.sessions.Revoke(session.ID, "gateway", "trainer deactivated")
+		return nil, common.NewStatusError(http.StatusUnauthorized, "trainer deactivated")
+	}
+	return s.issue(record, session, refresh)
+}
+
+func (s *Service) issue(record *registry.TrainerRecord, session *Session, refresh string) (*Token, error) {
+	token, expires, err := s.issuer.Issue(common.JWTClaims{
+		Subject:   record.JWTSub,
+		State:     record.State,
+		Cluster:   record.Cluster,
+		Role:      string(common.RoleTrainer),
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:      token,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.issuer.TTL().Seconds()),
+		ExpiresAt:        expires.Format(time.RFC3339),
+		RefreshToken:     refresh,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+	}, nil
+}
+
+func (s *Service) trainer(subject string) (*registry.TrainerRecord, error) {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "sub is required")
+	}
+	record, ok := s.store.FindByJWTSub(subject)
+	if !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, "trainer not registered")
+	}
+	if record.Status == registry.StatusDeactivated {
+		return nil, common.NewStatusError(http.StatusForbidden, "trainer deactivated")
+	}
+	return record, nil
+}
+
+func (s *Service) sweepLocked() {
+	now := time.Now()
+	for nonce, challenge := range s.challenges {
+		if now.After(challenge.expires) {
+			delete(s.challenges, nonce)
+		}
+	}
+}
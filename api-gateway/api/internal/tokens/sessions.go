@@ -0,0 +1,205 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Session tracks one sign-in of a trainer. Every access token carries the session id in its
+// sid claim, so revoking the session invalidates the access token and its refresh token.
+type Session struct {
+	ID          string `json:"id"`
+	Subject     string `json:"sub"`
+	CreatedAt   string `json:"created_at"`
+	RefreshedAt string `json:"refreshed_at,omitempty"`
+	ExpiresAt   string `json:"expires_at"`
+	Refreshes   int    `json:"refreshes"`
+	RevokedAt   string `json:"revoked_at,omitempty"`
+	RevokedBy   string `json:"revoked_by,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+
+	expires time.Time
+	// refreshHash is the digest of the current refresh token; previousHash the one it replaced,
+	// kept to detect a rotated token being replayed.
+	refreshHash  string
+	previousHash string
+}
+
+// SessionStore keeps sessions in memory; a restart signs every trainer out.
+type SessionStore struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// NewSessionStore creates a store whose sessions (and refresh tokens) last ttl.
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{ttl: ttl, sessions: map[string]*Session{}}
+}
+
+// SessionActive reports whether id names a session that is neither expired nor revoked. It
+// satisfies common.SessionChecker.
+func (s *SessionStore) SessionActive(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	return ok && session.RevokedAt == "" && time.Now().Before(session.expires)
+}
+
+// create opens a session for subject and returns it with its first refresh token.
+func (s *SessionStore) create(subject string) (*Session, string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+	refresh, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	session := &Session{
+		ID:          id,
+		Subject:     subject,
+		CreatedAt:   now.Format(time.RFC3339),
+		ExpiresAt:   now.Add(s.ttl).Format(time.RFC3339),
+		expires:     now.Add(s.ttl),
+		refreshHash: hashToken(refresh),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.sessions[id] = session
+	return session.snapshot(), id + "." + refresh, nil
+}
+
+// rotate exchanges a refresh token for a new one. Presenting a refresh token that was already
+// rotated revokes the session, since either the trainer or a thief holds a stale copy.
+func (s *SessionStore) rotate(token string) (*Session, string, error) {
+	id, secret, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || id == "" || secret == "" {
+		return nil, "", common.NewStatusError(http.StatusBadRequest, "refresh_token is malformed")
+	}
+	next, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, found := s.sessions[id]
+	if !found || time.Now().After(session.expires) {
+		return nil, "", common.NewStatusError(http.StatusUnauthorized, "session is unknown or expired")
+	}
+	if session.RevokedAt != "" {
+		return nil, "", common.NewStatusError(http.StatusUnauthorized, "session has been revoked")
+	}
+	digest := hashToken(secret)
+	if session.previousHash != "" && constantEqual(digest, session.previousHash) {
+		session.revoke("gateway", "refresh token reused")
+		return nil, "", common.NewStatusError(http.StatusUnauthorized, "refresh token was already used; session revoked")
+	}
+	if !constantEqual(digest, session.refreshHash) {
+		return nil, "", common.NewStatusError(http.StatusUnauthorized, "refresh token is invalid")
+	}
+	session.previousHash = session.refreshHash
+	session.refreshHash = hashToken(next)
+	session.Refreshes++
+	session.RefreshedAt = time.Now().UTC().Format(time.RFC3339)
+	return session.snapshot(), id + "." + next, nil
+}
+
+// List returns the sessions of subject (all subjects when empty), newest first. Expired
+// sessions are dropped.
+func (s *SessionStore) List(subject string) []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	sessions := []*Session{}
+	for _, session := range s.sessions {
+		if subject == "" || session.Subject == subject {
+			sessions = append(sessions, session.snapshot())
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt != sessions[j].CreatedAt {
+			return sessions[i].CreatedAt > sessions[j].CreatedAt
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+// Revoke revokes one session.
+func (s *SessionStore) Revoke(id, revokedBy, reason string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, "session not found")
+	}
+	session.revoke(revokedBy, reason)
+	return session.snapshot(), nil
+}
+
+// RevokeSubject revokes every active session of subject and returns them.
+func (s *SessionStore) RevokeSubject(subject, revokedBy, reason string) []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revoked := []*Session{}
+	for _, session := range s.sessions {
+		if session.Subject == subject && session.RevokedAt == "" {
+			session.revoke(revokedBy, reason)
+			revoked = append(revoked, session.snapshot())
+		}
+	}
+	return revoked
+}
+
+func (s *SessionStore) sweepLocked() {
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func (s *Session) revoke(revokedBy, reason string) {
+	if s.RevokedAt != "" {
+		return
+	}
+	s.RevokedAt = time.Now().UTC().Format(time.RFC3339)
+	s.RevokedBy = revokedBy
+	s.Reason = reason
+}
+
+func (s *Session) snapshot() *Session {
+	clone := *s
+	return &clone
+}
+
+func randomToken(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func constantEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
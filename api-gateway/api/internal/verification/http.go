@@ -0,0 +1,151 @@
+package verification
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the central checker verification workflow.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires the verification HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts `/verification/pending` and `/verification/states`.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/verification/pending", auth.RequireAuth(http.HandlerFunc(h.handlePending), common.RoleCentralChecker, common.RoleAdmin))
+	mux.Handle("/verification/states", auth.RequireAuth(http.HandlerFunc(h.handleList), common.RoleCentralChecker, common.RoleAggregator, common.RoleAdmin))
+	mux.Handle("/verification/states/", auth.RequireAuth(http.HandlerFunc(h.handleRecord), common.RoleCentralChecker))
+}
+
+// Describe documents the verification endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("verification")
+	scope := []openapi.Param{
+		{Name: "job_id", Description: "Job of the round under review; defaults to GATEWAY_JOB_ID."},
+		{Name: "round", Type: "integer", Description: "Round under review. Without it the legacy unscoped convergence is reviewed."},
+	}
+	api.Add(http.MethodGet, "/verification/pending", openapi.Operation{
+		Summary:     "List state models awaiting verification",
+		Description: "State models of the round without a verdict. A state verified on an earlier model is listed again once it commits a newer one.",
+		Roles:       []common.Role{common.RoleCentralChecker, common.RoleAdmin},
+		Query:       scope,
+		Response:    map[string]any{"items": []*PendingModel{}},
+	})
+	api.Add(http.MethodGet, "/verification/states", openapi.Operation{
+		Summary:  "List the verdict recorded for each state",
+		Roles:    []common.Role{common.RoleCentralChecker, common.RoleAggregator, common.RoleAdmin},
+		Query:    scope,
+		Response: map[string]any{"items": []*Verification{}},
+	})
+	api.Add(http.MethodPost, "/verification/states/{state_id}", openapi.Operation{
+		Summary:     "Record a pass or fail verdict on a state model",
+		Description: "The verdict is recorded on-chain and replaces the state's earlier one. The nation cannot be declared converged until every state that reported toward it has passed.",
+		Roles:       []common.Role{common.RoleCentralChecker},
+		Body:        RecordRequest{},
+		Response:    Verification{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusNotFound, http.StatusConflict},
+	})
+}
+
+func (h *HTTPHandler) handlePending(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	jobID, round, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	models, err := h.svc.Pending(r.Context(), authCtx, jobID, round)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": models})
+}
+
+func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	jobID, round, err := scopeFromQuery(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	verifications, err := h.svc.List(r.Context(), authCtx, jobID, round)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, map[string]any{"items": verifications})
+}
+
+// handleRecord serves `POST /verification/states/{stateId}`.
+func (h *HTTPHandler) handleRecord(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	authCtx, ok := common.AuthContextFrom(r.Context())
+	if !ok {
+		common.WriteErrorWithCode(w, http.StatusUnauthorized, common.ErrMissingAuthContext)
+		return
+	}
+	var req RecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	verification, err := h.svc.Record(r.Context(), authCtx, strings.TrimPrefix(r.URL.Path, "/verification/states/"), &req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusCreated, verification)
+}
+
+// scopeFromQuery reads the optional `job_id` and `round` query parameters.
+func scopeFromQuery(r *http.Request) (string, int, error) {
+	query := r.URL.Query()
+	round := 0
+	if raw := strings.TrimSpace(query.Get("round")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 {
+			return "", 0, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+		}
+		round = value
+	}
+	return query.Get("job_id"), round, nil
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
@@ -0,0 +1,233 @@
+package verification
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
+)
+
+// Verification results.
+const (
+	ResultPass = "PASS"
+	ResultFail = "FAIL"
+)
+
+// Service drives the central checker's review of state models. Verdicts are recorded on-chain,
+// where the nation cannot be declared converged until every state that reported toward it has
+// a passing verification.
+type Service struct {
+	cfg    *common.Config
+	fabric *common.FabricClient
+	store  registry.Store
+}
+
+// NewService constructs a verification service.
+func NewService(cfg *common.Config, fabric *common.FabricClient, store registry.Store) *Service {
+	return &Service{cfg: cfg, fabric: fabric, store: store}
+}
+
+// Scope selects the job round under review. A zero Round selects the legacy unscoped
+// convergence; JobID defaults to GATEWAY_JOB_ID.
+type Scope struct {
+	JobID string `json:"job_id,omitempty"`
+	Round int    `json:"round,omitempty"`
+}
+
+// Verification mirrors the on-chain StateVerification: the verdict on the model a state
+// submitted, with the SHA-256 of the report that backs it.
+type Verification struct {
+	StateID      string `json:"state_id"`
+	ModelID      string `json:"model_id"`
+	PayloadHash  string `json:"payload_hash,omitempty"`
+	Result       string `json:"result"`
+	EvidenceHash string `json:"evidence_hash"`
+	Note         string `json:"note,omitempty"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+	VerifiedBy   string `json:"verified_by"`
+	VerifiedAt   string `json:"verified_at"`
+	TxID         string `json:"tx_id"`
+}
+
+// PendingModel is a state model that has not been verified yet.
+type PendingModel struct {
+	ModelID     string             `json:"model_id"`
+	StateID     string             `json:"state_id"`
+	Owner       string             `json:"owner"`
+	JobID       string             `json:"job_id,omitempty"`
+	Round       int                `json:"round,omitempty"`
+	PayloadHash string             `json:"payload_hash,omitempty"`
+	ModelHash   string             `json:"model_hash,omitempty"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+	SubmittedAt string             `json:"submitted_at"`
+}
+
+// RecordRequest is the central checker's verdict on a state model: result "pass" or "fail"
+// and the hex SHA-256 of the verification report.
+type RecordRequest struct {
+	ModelID      string `json:"model_id"`
+	Result       string `json:"result"`
+	EvidenceHash string `json:"evidence_hash"`
+	Note         string `json:"note,omitempty"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+}
+
+type ledgerModel struct {
+	ID          string             `json:"id"`
+	ScopeID     string             `json:"scope_id"`
+	Owner       string             `json:"owner"`
+	SubmittedAt string             `json:"submitted_at"`
+	JobID       string             `json:"job_id,omitempty"`
+	RoundNumber int                `json:"round,omitempty"`
+	ModelHash   string             `json:"model_hash,omitempty"`
+	PayloadHash string             `json:"payload_hash,omitempty"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Pending lists the state models of the scope that still await a verdict. A state verified
+// on an earlier model is listed again once it commits a newer one.
+func (s *Service) Pending(ctx context.Context, authCtx *common.AuthContext, jobID string, round int) ([]*PendingModel, error) {
+	scope, err := s.resolveScope(jobID, round)
+	if err != nil {
+		return nil, err
+	}
+	var records []*ledgerModel
+	if err := s.query(ctx, authCtx, scope.args("ListPendingStateModels"), &records); err != nil {
+		return nil, err
+	}
+	models := make([]*PendingModel, 0, len(records))
+	for _, record := range records {
+		models = append(models, &PendingModel{
+			ModelID:     record.ID,
+			StateID:     record.ScopeID,
+			Owner:       record.Owner,
+			JobID:       record.JobID,
+			Round:       record.RoundNumber,
+			PayloadHash: record.PayloadHash,
+			ModelHash:   record.ModelHash,
+			Metrics:     record.Metrics,
+			SubmittedAt: record.SubmittedAt,
+		})
+	}
+	return models, nil
+}
+
+// List returns the verification of every state in the scope.
+func (s *Service) List(ctx context.Context, authCtx *common.AuthContext, jobID string, round int) ([]*Verification, error) {
+	scope, err := s.resolveScope(jobID, round)
+	if err != nil {
+		return nil, err
+	}
+	var verifications []*Verification
+	if err := s.query(ctx, authCtx, scope.args("ListStateVerifications"), &verifications); err != nil {
+		return nil, err
+	}
+	if verifications == nil {
+		verifications = []*Verification{}
+	}
+	return verifications, nil
+}
+
+// Record stores the verdict on a state's model, replacing any earlier verdict for the state.
+func (s *Service) Record(ctx context.Context, authCtx *common.AuthContext, stateID string, req *RecordRequest) (*Verification, error) {
+	if req == nil {
+		return nil, common.NewStatusError(http.StatusBadRequest, "request body is required")
+	}
+	stateID = strings.TrimSpace(stateID)
+	modelID := strings.TrimSpace(req.ModelID)
+	result := strings.ToUpper(strings.TrimSpace(req.Result))
+	evidence := strings.ToLower(strings.TrimSpace(req.EvidenceHash))
+	switch {
+	case stateID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "state id is required")
+	case modelID == "":
+		return nil, common.NewStatusError(http.StatusBadRequest, "model_id is required")
+	case result != ResultPass && result != ResultFail:
+		return nil, common.NewStatusError(http.StatusBadRequest, "result must be pass or fail")
+	}
+	if decoded, err := hex.DecodeString(evidence); err != nil || len(decoded) != 32 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "evidence_hash must be a hex SHA-256 digest")
+	}
+	scope, err := s.resolveScope(req.JobID, req.Round)
+	if err != nil {
+		return nil, err
+	}
+	peer := s.fabric.SelectPeer(common.PeerWrite)
+	if peer == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	args := scope.args("RecordStateVerification", stateID, modelID, result, evidence, strings.TrimSpace(req.Note))
+	raw, _, err := s.fabric.SubmitChaincode(ctx, peer, s.identityFor(authCtx), args)
+	if err != nil {
+		return nil, mapVerificationError(err)
+	}
+	var verification Verification
+	if err := json.Unmarshal(raw, &verification); err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (s *Service) query(ctx context.Context, authCtx *common.AuthContext, args []string, target any) error {
+	raw, err := s.fabric.QueryChaincode(ctx, s.fabric.SelectPeer(common.PeerRead), s.identityFor(authCtx), args)
+	if err != nil {
+		return mapVerificationError(err)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func (s *Service) resolveScope(jobID string, round int) (Scope, error) {
+	jobID = strings.TrimSpace(jobID)
+	if round < 0 {
+		return Scope{}, common.NewStatusError(http.StatusBadRequest, "round must be a positive integer")
+	}
+	if round == 0 {
+		if jobID != "" {
+			return Scope{}, common.NewStatusError(http.StatusBadRequest, "round is required when job_id is set")
+		}
+		return Scope{}, nil
+	}
+	if jobID == "" {
+		jobID = s.cfg.JobID
+	}
+	return Scope{JobID: jobID, Round: round}, nil
+}
+
+// args builds chaincode arguments, switching to the InRound variant for scoped requests.
+func (sc Scope) args(function string, rest ...string) []string {
+	if sc.Round == 0 {
+		return append([]string{function}, rest...)
+	}
+	return append([]string{function + "InRound", sc.JobID, strconv.Itoa(sc.Round)}, rest...)
+}
+
+func (s *Service) identityFor(authCtx *common.AuthContext) string {
+	if authCtx != nil {
+		if rec, ok := s.store.FindByJWTSub(authCtx.Subject); ok {
+			return rec.FabricClientID
+		}
+	}
+	return s.cfg.AdminIdentity
+}
+
+func mapVerificationError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return common.NewStatusError(http.StatusNotFound, msg)
+	case strings.Contains(msg, "is not permitted to call"):
+		return common.NewStatusError(http.StatusForbidden, msg)
+	case strings.Contains(msg, "convergence cannot change"), strings.Contains(msg, "was not committed"):
+		return common.NewStatusError(http.StatusConflict, msg)
+	case strings.Contains(msg, "must"), strings.Contains(msg, "is required"), strings.Contains(msg, "is not a model of state"):
+		return common.NewStatusError(http.StatusBadRequest, msg)
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
+)
+
+// HTTPHandler exposes the webhook admin endpoints.
+type HTTPHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler wires a webhooks HTTP handler.
+func NewHTTPHandler(svc *Service) *HTTPHandler {
+	return &HTTPHandler{svc: svc}
+}
+
+// RegisterRoutes mounts the webhook endpoints; all of them are admin-only.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
+	mux.Handle("/admin/webhooks", auth.RequireAuth(http.HandlerFunc(h.handleWebhooks), common.RoleAdmin))
+	mux.Handle("/admin/webhooks/", auth.RequireAuth(http.HandlerFunc(h.handleWebhook), common.RoleAdmin))
+}
+
+// Describe documents the webhook endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("webhooks")
+	admin := []common.Role{common.RoleAdmin}
+	api.Add(http.MethodPost, "/admin/webhooks", openapi.Operation{
+		Summary:     "Register a webhook",
+		Description: "Events lists the lifecycle events to deliver (trainer.registered, round.opened, round.closed, state.converged, nation.converged); empty or `*` subscribes to all. The signing secret is generated unless given and is returned only here.",
+		Roles:       admin,
+		Body:        Input{},
+		Response:    WithSecret{},
+		Status:      http.StatusCreated,
+		Errors:      []int{http.StatusBadRequest},
+	})
+	api.Add(http.MethodGet, "/admin/webhooks", openapi.Operation{Summary: "List webhooks without their secrets", Roles: admin, Response: map[string]any{"items": []*Webhook{}}})
+	api.Add(http.MethodGet, "/admin/webhooks/{id}", openapi.Operation{Summary: "Get a webhook", Roles: admin, Response: Webhook{}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodPatch, "/admin/webhooks/{id}", openapi.Operation{
+		Summary:     "Update a webhook",
+		Description: "Omitted fields keep their value. A `secret` rotates the signing secret (`generate` picks one) and is echoed back once.",
+		Roles:       admin,
+		Body:        Input{},
+		Response:    WithSecret{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	api.Add(http.MethodDelete, "/admin/webhooks/{id}", openapi.Operation{Summary: "Delete a webhook", Roles: admin, Response: map[string]any{"id": "", "deleted": true}, Errors: []int{http.StatusNotFound}})
+	api.Add(http.MethodGet, "/admin/webhooks/{id}/deliveries", openapi.Operation{
+		Summary:     "List a webhook's recent deliveries",
+		Description: "Newest first, with status, attempts, the last response code or error and the next retry. Only the most recent 100 per webhook are kept, in memory.",
+		Roles:       admin,
+		Response:    map[string]any{"items": []*Delivery{}},
+		Errors:      []int{http.StatusNotFound},
+	})
+	api.Add(http.MethodPost, "/admin/webhooks/{id}/test", openapi.Operation{
+		Summary:  "Send a webhook.ping delivery",
+		Roles:    admin,
+		Response: Delivery{},
+		Status:   http.StatusAccepted,
+		Errors:   []int{http.StatusNotFound, http.StatusServiceUnavailable},
+	})
+}
+
+// handleWebhooks serves `GET /admin/webhooks` and `POST /admin/webhooks`.
+func (h *HTTPHandler) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		common.WriteJSON(w, http.StatusOK, map[string]any{"items": h.svc.List()})
+	case http.MethodPost:
+		var input Input
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		created, err := h.svc.Create(input)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, created)
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+// handleWebhook serves `/admin/webhooks/{id}`, `/admin/webhooks/{id}/deliveries` and
+// `/admin/webhooks/{id}/test`.
+func (h *HTTPHandler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/webhooks/"), "/")
+	if parts[0] == "" || len(parts) > 2 {
+		common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		return
+	}
+	id := parts[0]
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "deliveries" && r.Method == http.MethodGet:
+			deliveries, err := h.svc.Deliveries(id)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			common.WriteJSON(w, http.StatusOK, map[string]any{"items": deliveries})
+		case parts[1] == "test" && r.Method == http.MethodPost:
+			delivery, err := h.svc.Ping(id)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			common.WriteJSON(w, http.StatusAccepted, delivery)
+		case parts[1] == "deliveries" || parts[1] == "test":
+			common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		default:
+			common.WriteErrorWithCode(w, http.StatusNotFound, common.NewStatusError(http.StatusNotFound, "not found"))
+		}
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		hook, err := h.svc.Get(id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, hook)
+	case http.MethodPatch, http.MethodPut:
+		var input Input
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+			return
+		}
+		updated, err := h.svc.Update(id, input)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		if err := h.svc.Delete(id); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+	}
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
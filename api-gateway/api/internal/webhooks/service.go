@@ -0,0 +1,583 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebula/api-gateway/internal/common"
+)
+
+// Headers sent with every notification. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" under the webhook's secret.
+const (
+	HeaderEvent     = "X-Nebula-Event"
+	HeaderDelivery  = "X-Nebula-Delivery"
+	HeaderTimestamp = "X-Nebula-Timestamp"
+	HeaderSignature = "X-Nebula-Signature"
+)
+
+// EventPing is the event type of test deliveries sent through Ping.
+const EventPing = "webhook.ping"
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusRetrying  = "retrying"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+const (
+	// deliveryHistory is how many deliveries are kept per webhook for inspection.
+	deliveryHistory = 100
+	// queueSize bounds the deliveries waiting for a worker; events beyond it are recorded as
+	// failed rather than blocking the request that published them.
+	queueSize = 256
+	workers   = 4
+	// responseExcerpt is how much of a failed response body is kept as the delivery error.
+	responseExcerpt = 256
+)
+
+// Webhook is a registered notification target. Secret is only returned when the webhook is
+// created or its secret rotated.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description,omitempty"`
+	Active      bool     `json:"active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	Secret      string   `json:"-"`
+}
+
+// Subscribed reports whether the webhook receives events of eventType; an empty event list
+// (or "*" when registering) subscribes to every type.
+func (w *Webhook) Subscribed(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, name := range w.Events {
+		if name == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSecret is a webhook together with its signing secret.
+type WithSecret struct {
+	*Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// Input creates or updates a webhook. On update, empty fields keep their current value and
+// Secret rotates the signing secret ("generate" picks a random one).
+type Input struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+	Secret      string   `json:"secret"`
+}
+
+// Delivery tracks one event sent to one webhook.
+type Delivery struct {
+	ID             string `json:"id"`
+	WebhookID      string `json:"webhook_id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+
+	body []byte
+}
+
+// storedWebhook is the on-disk form of a webhook, which keeps the secret.
+type storedWebhook struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+// Service keeps the registered webhooks and delivers lifecycle events to them with retries.
+type Service struct {
+	cfg    *common.Config
+	client *http.Client
+	queue  chan *Delivery
+
+	mu         sync.Mutex
+	hooks      map[string]*Webhook
+	deliveries map[string][]*Delivery
+}
+
+// NewService loads the webhooks stored at WebhooksPath.
+func NewService(cfg *common.Config) (*Service, error) {
+	s := &Service{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.WebhookTimeout},
+		queue:      make(chan *Delivery, queueSize),
+		hooks:      map[string]*Webhook{},
+		deliveries: map[string][]*Delivery{},
+	}
+	raw, err := os.ReadFile(cfg.WebhooksPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	var stored []*storedWebhook
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("invalid webhooks file %s: %w", cfg.WebhooksPath, err)
+	}
+	for _, entry := range stored {
+		if entry == nil || entry.Webhook == nil {
+			continue
+		}
+		entry.Webhook.Secret = entry.Secret
+		s.hooks[entry.ID] = entry.Webhook
+	}
+	return s, nil
+}
+
+// List returns every webhook ordered by creation.
+func (s *Service) List() []*Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := make([]*Webhook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		copied := *hook
+		hooks = append(hooks, &copied)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].CreatedAt != hooks[j].CreatedAt {
+			return hooks[i].CreatedAt < hooks[j].CreatedAt
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks
+}
+
+// Get returns one webhook.
+func (s *Service) Get(id string) (*Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hook, ok := s.hooks[id]
+	if !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("webhook %s not found", id))
+	}
+	copied := *hook
+	return &copied, nil
+}
+
+// Create registers a webhook. Without a secret one is generated; either way it is returned
+// once, here.
+func (s *Service) Create(input Input) (*WithSecret, error) {
+	target, err := validateURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := validateEvents(input.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := resolveSecret(input.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+	hook := &Webhook{
+		ID:          common.GeneratePrefixedID("wh"),
+		URL:         target,
+		Events:      events,
+		Description: strings.TrimSpace(input.Description),
+		Active:      input.Active == nil || *input.Active,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		Secret:      secret,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[hook.ID] = hook
+	if err := s.persistLocked(); err != nil {
+		delete(s.hooks, hook.ID)
+		return nil, err
+	}
+	copied := *hook
+	return &WithSecret{Webhook: &copied, Secret: secret}, nil
+}
+
+// Update changes a webhook. The secret is only returned when it was rotated.
+func (s *Service) Update(id string, input Input) (*WithSecret, error) {
+	var (
+		target string
+		events []string
+		err    error
+	)
+	if strings.TrimSpace(input.URL) != "" {
+		if target, err = validateURL(input.URL); err != nil {
+			return nil, err
+		}
+	}
+	if input.Events != nil {
+		if events, err = validateEvents(input.Events); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := resolveSecret(input.Secret)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hook, ok := s.hooks[id]
+	if !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("webhook %s not found", id))
+	}
+	previous := *hook
+	if target != "" {
+		hook.URL = target
+	}
+	if input.Events != nil {
+		hook.Events = events
+	}
+	if description := strings.TrimSpace(input.Description); description != "" {
+		hook.Description = description
+	}
+	if input.Active != nil {
+		hook.Active = *input.Active
+	}
+	if secret != "" {
+		hook.Secret = secret
+	}
+	hook.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := s.persistLocked(); err != nil {
+		*hook = previous
+		return nil, err
+	}
+	copied := *hook
+	result := &WithSecret{Webhook: &copied}
+	if secret != "" {
+		result.Secret = secret
+	}
+	return result, nil
+}
+
+// Delete removes a webhook and its delivery history. Deliveries already queued are dropped.
+func (s *Service) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hook, ok := s.hooks[id]
+	if !ok {
+		return common.NewStatusError(http.StatusNotFound, fmt.Sprintf("webhook %s not found", id))
+	}
+	delete(s.hooks, id)
+	if err := s.persistLocked(); err != nil {
+		s.hooks[id] = hook
+		return err
+	}
+	delete(s.deliveries, id)
+	return nil
+}
+
+// Deliveries returns a webhook's most recent deliveries, newest first.
+func (s *Service) Deliveries(id string) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hooks[id]; !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("webhook %s not found", id))
+	}
+	history := s.deliveries[id]
+	deliveries := make([]*Delivery, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		copied := *history[i]
+		copied.body = nil
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, nil
+}
+
+// Notify queues the event for every active webhook subscribed to its type. It never blocks:
+// with the queue full the delivery is recorded as failed.
+func (s *Service) Notify(event *common.LifecycleEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hook := range s.hooks {
+		if !hook.Active || !hook.Subscribed(event.Type) {
+			continue
+		}
+		delivery := &Delivery{
+			ID:        common.GeneratePrefixedID("dlv"),
+			WebhookID: hook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Status:    StatusPending,
+			CreatedAt: now,
+			body:      body,
+		}
+		s.recordLocked(delivery)
+		select {
+		case s.queue <- delivery:
+		default:
+			delivery.Status = StatusFailed
+			delivery.LastError = "delivery queue is full"
+		}
+	}
+}
+
+// Ping queues a "webhook.ping" event for one webhook, active or not, so receivers can be
+// checked without waiting for a real lifecycle event.
+func (s *Service) Ping(id string) (*Delivery, error) {
+	event := &common.LifecycleEvent{
+		ID:         common.GeneratePrefixedID("evt"),
+		Type:       EventPing,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339),
+		Data:       map[string]any{"webhook_id": id},
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hooks[id]; !ok {
+		return nil, common.NewStatusError(http.StatusNotFound, fmt.Sprintf("webhook %s not found", id))
+	}
+	delivery := &Delivery{
+		ID:        common.GeneratePrefixedID("dlv"),
+		WebhookID: id,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Status:    StatusPending,
+		CreatedAt: event.OccurredAt,
+		body:      body,
+	}
+	s.recordLocked(delivery)
+	select {
+	case s.queue <- delivery:
+	default:
+		delivery.Status = StatusFailed
+		delivery.LastError = "delivery queue is full"
+		return nil, common.NewStatusError(http.StatusServiceUnavailable, "delivery queue is full")
+	}
+	copied := *delivery
+	copied.body = nil
+	return &copied, nil
+}
+
+// Run delivers queued notifications until the context is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case delivery := <-s.queue:
+					s.deliver(ctx, delivery)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver posts the notification, retrying with exponential backoff from WebhookRetryBackoff
+// until it is accepted with a 2xx or WebhookMaxAttempts is reached.
+func (s *Service) deliver(ctx context.Context, delivery *Delivery) {
+	backoff := s.cfg.WebhookRetryBackoff
+	for {
+		s.mu.Lock()
+		hook, ok := s.hooks[delivery.WebhookID]
+		var target, secret string
+		if ok {
+			target, secret = hook.URL, hook.Secret
+		}
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+		code, err := s.post(ctx, target, secret, delivery)
+		s.mu.Lock()
+		delivery.Attempts++
+		delivery.LastStatusCode = code
+		delivery.NextAttemptAt = ""
+		if err == nil {
+			delivery.Status = StatusDelivered
+			delivery.LastError = ""
+			delivery.DeliveredAt = time.Now().UTC().Format(time.RFC3339)
+			s.mu.Unlock()
+			return
+		}
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= s.cfg.WebhookMaxAttempts || ctx.Err() != nil {
+			delivery.Status = StatusFailed
+			s.mu.Unlock()
+			log.Printf("webhooks: giving up on %s of %s to %s after %d attempts: %v", delivery.EventType, delivery.EventID, target, delivery.Attempts, err)
+			return
+		}
+		delivery.Status = StatusRetrying
+		delivery.NextAttemptAt = time.Now().Add(backoff).UTC().Format(time.RFC3339)
+		s.mu.Unlock()
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.mu.Lock()
+			delivery.Status = StatusFailed
+			delivery.NextAttemptAt = ""
+			s.mu.Unlock()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Service) post(ctx context.Context, target, secret string, delivery *Delivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(delivery.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nebula-gateway-webhooks")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message := fmt.Sprintf("receiver answered %d", resp.StatusCode)
+		if text := strings.TrimSpace(string(excerpt)); text != "" {
+			message += ": " + text
+		}
+		return resp.StatusCode, errors.New(message)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the X-Nebula-Signature value receivers compare against.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) recordLocked(delivery *Delivery) {
+	history := append(s.deliveries[delivery.WebhookID], delivery)
+	if len(history) > deliveryHistory {
+		history = history[len(history)-deliveryHistory:]
+	}
+	s.deliveries[delivery.WebhookID] = history
+}
+
+func (s *Service) persistLocked() error {
+	stored := make([]*storedWebhook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		stored = append(stored, &storedWebhook{Webhook: hook, Secret: hook.Secret})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	raw, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := common.AtomicWriteFile(s.cfg.WebhooksPath, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	return nil
+}
+
+func validateURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if raw == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", common.NewStatusError(http.StatusBadRequest, "url must be an absolute http or https URL")
+	}
+	return raw, nil
+}
+
+func validateEvents(events []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, name := range events {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "*" {
+			return []string{}, nil
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		if !common.IsLifecycleEventType(name) {
+			return nil, common.NewStatusError(http.StatusBadRequest, fmt.Sprintf("unknown event %q; expected one of %s", name, strings.Join(common.LifecycleEventTypes, ", ")))
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// resolveSecret returns the requested secret, a generated one for "generate", or "" to keep
+// (or, on create, generate) the secret.
+func resolveSecret(secret string) (string, error) {
+	secret = strings.TrimSpace(secret)
+	switch {
+	case secret == "":
+		return "", nil
+	case secret == "generate":
+		return generateSecret()
+	case len(secret) < 16:
+		return "", common.NewStatusError(http.StatusBadRequest, "secret must be at least 16 characters")
+	}
+	return secret, nil
+}
+
+func generateSecret() (string, error) {
+	var buf [32]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
@@ -1,11 +1,15 @@
 package whitelist
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/openapi"
 )
 
 // HTTPHandler exposes whitelist routes.
@@ -18,9 +22,62 @@ func NewHTTPHandler(svc *Service) *HTTPHandler {
 	return &HTTPHandler{svc: svc}
 }
 
-// RegisterRoutes mounts the `/whitelist` endpoint.
+// RegisterRoutes mounts the `/whitelist` endpoints.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux, auth *common.Authenticator) {
 	mux.Handle("/whitelist", auth.RequireAuth(http.HandlerFunc(h.handleList), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+	mux.Handle("/whitelist/capabilities", auth.RequireAuth(http.HandlerFunc(h.handleCapabilities), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+	mux.Handle("/whitelist/hierarchy", auth.RequireAuth(http.HandlerFunc(h.handleHierarchy), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+	mux.Handle("/whitelist/states/", auth.RequireAuth(http.HandlerFunc(h.handleState), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+	mux.Handle("/whitelist/clusters/", auth.RequireAuth(http.HandlerFunc(h.handleCluster), common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker))
+}
+
+// Describe documents the whitelist endpoints.
+func (h *HTTPHandler) Describe(spec *openapi.Spec) {
+	api := spec.Tag("whitelist")
+	roles := []common.Role{common.RoleAggregator, common.RoleAdmin, common.RoleCentralChecker}
+	paging := []openapi.Param{
+		{Name: "page", Type: "integer", Description: "1-based page number."},
+		{Name: "per_page", Type: "integer", Description: "Entries per page."},
+	}
+	api.Add(http.MethodGet, "/whitelist", openapi.Operation{
+		Summary:     "List whitelisted trainers grouped by state and cluster",
+		Description: "`page` counts every entry on the ledger per request; pass `bookmark` to page with ledger bookmarks instead, which reads one page per request. With `Accept: application/x-ndjson` every entry is streamed as one JSON line, reading the ledger `per_page` entries at a time.",
+		Roles:       roles,
+		Query: []openapi.Param{
+			paging[0],
+			paging[1],
+			{Name: "bookmark", Description: "Page with ledger bookmarks: pass an empty value for the first page, then the returned bookmark."},
+			{Name: "total", Description: "With bookmark: none (default), estimate (counts up to 1000) or exact."},
+		},
+		Response: HierarchyResult{},
+	})
+	api.Add(http.MethodGet, "/whitelist/capabilities", openapi.Operation{
+		Summary: "List whitelisted trainers matching capability filters",
+		Roles:   roles,
+		Query: append(paging,
+			openapi.Param{Name: "gpu_class", Description: "Comma-separated GPU classes."},
+			openapi.Param{Name: "min_ram_gb", Type: "integer"},
+			openapi.Param{Name: "min_bandwidth_mbps", Type: "integer"},
+			openapi.Param{Name: "available_at", Description: "RFC3339 timestamp."},
+			openapi.Param{Name: "state_id"},
+			openapi.Param{Name: "cluster_id"},
+		),
+		Response: ListResult{},
+	})
+	api.Add(http.MethodGet, "/whitelist/hierarchy", openapi.Operation{
+		Summary:     "List whitelisted trainers grouped by state and cluster, paged by state",
+		Description: "Served from the chaincode's state index; `page` and `per_page` count states, and state and cluster IDs are lower-cased.",
+		Roles:       roles,
+		Query:       paging,
+		Response:    HierarchyResult{},
+	})
+	api.Add(http.MethodGet, "/whitelist/states/{id}", openapi.Operation{Summary: "List whitelisted trainers of a state", Roles: roles, Query: paging, Response: ListResult{}})
+	api.Add(http.MethodGet, "/whitelist/clusters/{id}", openapi.Operation{
+		Summary:  "List whitelisted trainers of a cluster",
+		Roles:    roles,
+		Query:    append(paging, openapi.Param{Name: "state_id", Description: "Restrict to one state when cluster IDs repeat across states."}),
+		Response: ListResult{},
+	})
 }
 
 func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
@@ -28,32 +85,184 @@ func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
 		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
 		return
 	}
+	query := r.URL.Query()
+	page, perPage, err := parsePaging(query)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	if common.WantsNDJSON(r) {
+		// Streams always page with ledger bookmarks, starting from the given bookmark.
+		bookmark := strings.TrimSpace(query.Get("bookmark"))
+		common.StreamNDJSON(w, r, func(ctx context.Context) ([]any, bool, error) {
+			result, err := h.svc.ListPage(ctx, bookmark, perPage, "")
+			if err != nil {
+				return nil, false, err
+			}
+			bookmark = result.Bookmark
+			return common.RedactItems(ctx, h.svc.cfg.ResponseFieldRoles, result.Items), result.HasMore && bookmark != "", nil
+		}, writeServiceError)
+		return
+	}
+	var result *ListResult
+	if query.Has("bookmark") {
+		// An empty bookmark parameter asks for the first bookmark page.
+		result, err = h.svc.ListPage(r.Context(), strings.TrimSpace(query.Get("bookmark")), perPage, strings.ToLower(strings.TrimSpace(query.Get("total"))))
+	} else {
+		result, err = h.svc.List(r.Context(), page, perPage)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result.ToHierarchy()))
+}
+
+func (h *HTTPHandler) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	page, perPage, err := parsePaging(query)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	filter, err := ParseCapabilityFilter(query)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.svc.ListByCapability(r.Context(), filter, page, perPage)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+func (h *HTTPHandler) handleHierarchy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	page, perPage, err := parsePaging(r.URL.Query())
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.svc.HierarchyPage(r.Context(), page, perPage)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+// handleState serves `/whitelist/states/{id}`.
+func (h *HTTPHandler) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	stateID := strings.TrimPrefix(r.URL.Path, "/whitelist/states/")
+	page, perPage, err := parsePaging(r.URL.Query())
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.svc.ByState(r.Context(), stateID, page, perPage)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+// handleCluster serves `/whitelist/clusters/{id}`.
+func (h *HTTPHandler) handleCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		common.WriteErrorWithCode(w, http.StatusMethodNotAllowed, common.ErrMethodNotAllowed)
+		return
+	}
+	clusterID := strings.TrimPrefix(r.URL.Path, "/whitelist/clusters/")
+	query := r.URL.Query()
+	page, perPage, err := parsePaging(query)
+	if err != nil {
+		common.WriteErrorWithCode(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.svc.ByCluster(r.Context(), clusterID, query.Get("state_id"), page, perPage)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, common.RedactFields(r.Context(), h.svc.cfg.ResponseFieldRoles, result))
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := common.AsStatusError(err); ok {
+		status = se.Code
+	}
+	common.WriteErrorWithCode(w, status, err)
+}
+
+// ParseCapabilityFilter builds a filter from query parameters such as
+// gpu_class, min_ram_gb, min_bandwidth_mbps, available_at, state_id and cluster_id.
+func ParseCapabilityFilter(query url.Values) (*CapabilityFilter, error) {
+	filter := &CapabilityFilter{
+		StateID:   strings.TrimSpace(query.Get("state_id")),
+		ClusterID: strings.TrimSpace(query.Get("cluster_id")),
+	}
+	for _, raw := range query["gpu_class"] {
+		for _, class := range strings.Split(raw, ",") {
+			if class = strings.ToLower(strings.TrimSpace(class)); class != "" {
+				filter.GPUClasses = append(filter.GPUClasses, class)
+			}
+		}
+	}
+	if raw := strings.TrimSpace(query.Get("min_ram_gb")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return nil, common.NewStatusError(http.StatusBadRequest, "min_ram_gb must be a non-negative integer")
+		}
+		filter.MinRAMGB = value
+	}
+	if raw := strings.TrimSpace(query.Get("min_bandwidth_mbps")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return nil, common.NewStatusError(http.StatusBadRequest, "min_bandwidth_mbps must be a non-negative integer")
+		}
+		filter.MinBandwidthMbps = value
+	}
+	if raw := strings.TrimSpace(query.Get("available_at")); raw != "" {
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, common.NewStatusError(http.StatusBadRequest, "available_at must be an RFC3339 timestamp")
+		}
+		filter.AvailableAt = at.UTC().Format(time.RFC3339)
+	}
+	return filter, nil
+}
+
+func parsePaging(query url.Values) (int, int, error) {
 	page := 1
 	perPage := defaultPageSize
-	if raw := strings.TrimSpace(r.URL.Query().Get("page")); raw != "" {
+	if raw := strings.TrimSpace(query.Get("page")); raw != "" {
 		value, err := strconv.Atoi(raw)
 		if err != nil || value < 1 {
-			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "page must be a positive integer"))
-			return
+			return 0, 0, common.NewStatusError(http.StatusBadRequest, "page must be a positive integer")
 		}
 		page = value
 	}
-	if raw := strings.TrimSpace(r.URL.Query().Get("per_page")); raw != "" {
+	if raw := strings.TrimSpace(query.Get("per_page")); raw != "" {
 		value, err := strconv.Atoi(raw)
 		if err != nil || value < 1 {
-			common.WriteErrorWithCode(w, http.StatusBadRequest, common.NewStatusError(http.StatusBadRequest, "per_page must be a positive integer"))
-			return
+			return 0, 0, common.NewStatusError(http.StatusBadRequest, "per_page must be a positive integer")
 		}
 		perPage = value
 	}
-	result, err := h.svc.List(r.Context(), page, perPage)
-	if err != nil {
-		status := http.StatusInternalServerError
-		if se, ok := common.AsStatusError(err); ok {
-			status = se.Code
-		}
-		common.WriteErrorWithCode(w, status, err)
-		return
-	}
-	common.WriteJSON(w, http.StatusOK, result.ToHierarchy())
+	return page, perPage, nil
 }
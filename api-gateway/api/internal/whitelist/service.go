@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/nebula/api-gateway/internal/common"
+	"github.com/nebula/api-gateway/internal/registry"
 )
 
 const defaultPageSize = 50
@@ -17,36 +18,54 @@ const defaultPageSize = 50
 type Service struct {
 	cfg    *common.Config
 	fabric *common.FabricClient
+	cache  *common.QueryCache
 }
 
 // Entry describes a trainer record.
 type Entry struct {
-	JWTSub       string `json:"jwt_sub"`
-	DID          string `json:"did"`
-	NodeID       string `json:"node_id"`
-	State        string `json:"state,omitempty"`
-	Cluster      string `json:"cluster,omitempty"`
-	VCHash       string `json:"vc_hash"`
-	PublicKey    string `json:"public_key"`
-	RegisteredAt string `json:"registered_at"`
+	JWTSub       string                 `json:"jwt_sub"`
+	DID          string                 `json:"did"`
+	NodeID       string                 `json:"node_id"`
+	State        string                 `json:"state,omitempty"`
+	Cluster      string                 `json:"cluster,omitempty"`
+	VCHash       string                 `json:"vc_hash"`
+	PublicKey    string                 `json:"public_key"`
+	RegisteredAt string                 `json:"registered_at"`
+	Capabilities *registry.Capabilities `json:"capabilities,omitempty"`
+
+	// Status is "active" or "deactivated"; entries recorded before statuses existed have none.
+	Status          string `json:"status,omitempty"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedAt string `json:"status_changed_at,omitempty"`
+}
+
+// Active reports whether the entry takes part in training; only deactivated entries do not.
+func (e *Entry) Active() bool {
+	return e.Status != registry.StatusDeactivated
 }
 
-// ListResult represents a page of whitelist entries.
+// ListResult represents a page of whitelist entries. Bookmark pages carry a bookmark instead
+// of a page number, and Total counts only the entries on the page unless a total was
+// requested; TotalEstimated marks a requested total that is a lower bound.
 type ListResult struct {
-	Items   []*Entry `json:"items"`
-	Page    int      `json:"page"`
-	PerPage int      `json:"per_page"`
-	Total   int      `json:"total"`
-	HasMore bool     `json:"has_more"`
+	Items          []*Entry `json:"items"`
+	Page           int      `json:"page,omitempty"`
+	PerPage        int      `json:"per_page"`
+	Total          int      `json:"total"`
+	TotalEstimated bool     `json:"total_estimated,omitempty"`
+	HasMore        bool     `json:"has_more"`
+	Bookmark       string   `json:"bookmark,omitempty"`
 }
 
 // HierarchyResult represents the whitelist grouped by state/cluster.
 type HierarchyResult struct {
-	States  []*StateGroup `json:"states"`
-	Page    int           `json:"page"`
-	PerPage int           `json:"per_page"`
-	Total   int           `json:"total"`
-	HasMore bool          `json:"has_more"`
+	States         []*StateGroup `json:"states"`
+	Page           int           `json:"page,omitempty"`
+	PerPage        int           `json:"per_page"`
+	Total          int           `json:"total"`
+	TotalEstimated bool          `json:"total_estimated,omitempty"`
+	HasMore        bool          `json:"has_more"`
+	Bookmark       string        `json:"bookmark,omitempty"`
 }
 
 // StateGroup captures clusters per state.
@@ -66,29 +85,107 @@ func NewService(cfg *common.Config, fabric *common.FabricClient) *Service {
 	return &Service{cfg: cfg, fabric: fabric}
 }
 
-// Hierarchy fetches the entire whitelist hierarchy.
+// EnableCache serves whitelist pages from cache until they expire or a registration
+// invalidates them.
+func (s *Service) EnableCache(cache *common.QueryCache) {
+	s.cache = cache
+}
+
+// Hierarchy fetches the entire whitelist hierarchy, a page of states at a time.
 func (s *Service) Hierarchy(ctx context.Context) (*HierarchyResult, error) {
 	page := 1
-	all := make([]*Entry, 0)
+	states := make([]*StateGroup, 0)
 	for {
-		result, err := s.List(ctx, page, defaultPageSize)
+		result, err := s.HierarchyPage(ctx, page, defaultPageSize)
 		if err != nil {
 			return nil, err
 		}
-		all = append(all, result.Items...)
+		states = append(states, result.States...)
 		if !result.HasMore {
 			break
 		}
 		page++
 	}
-	combined := &ListResult{
-		Items:   all,
+	return &HierarchyResult{
+		States:  states,
 		Page:    1,
-		PerPage: len(all),
-		Total:   len(all),
+		PerPage: len(states),
+		Total:   len(states),
 		HasMore: false,
+	}, nil
+}
+
+// HierarchyPage returns a page of states with their nodes grouped by cluster. The chaincode
+// groups entries through its state index, so paging counts states rather than entries and
+// state and cluster IDs come back lower-cased.
+func (s *Service) HierarchyPage(ctx context.Context, page, perPage int) (*HierarchyResult, error) {
+	if page < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "page must be >= 1")
+	}
+	if perPage < 1 {
+		perPage = defaultPageSize
+	}
+	key := "hierarchy/" + strconv.Itoa(page) + "/" + strconv.Itoa(perPage)
+	return common.CachedLoad(ctx, s.cache, common.CacheWhitelist, key, func() (*HierarchyResult, error) {
+		raw, err := s.query(ctx, "ListWhitelistHierarchy", strconv.Itoa(page), strconv.Itoa(perPage))
+		if err != nil {
+			return nil, err
+		}
+		var ledgerPage ledgerHierarchy
+		if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+			return nil, err
+		}
+		return ledgerPage.toResult(), nil
+	})
+}
+
+// ByState returns a page of the entries registered in stateID, ordered by cluster.
+func (s *Service) ByState(ctx context.Context, stateID string, page, perPage int) (*ListResult, error) {
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	if stateID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "state_id is required")
+	}
+	return s.listIndexed(ctx, "state/"+stateID, page, perPage, "ListWhitelistByState", stateID)
+}
+
+// ByCluster returns a page of the entries registered in clusterID. A non-empty stateID
+// narrows the result when cluster IDs repeat across states.
+func (s *Service) ByCluster(ctx context.Context, clusterID, stateID string, page, perPage int) (*ListResult, error) {
+	clusterID = strings.ToLower(strings.TrimSpace(clusterID))
+	if clusterID == "" {
+		return nil, common.NewStatusError(http.StatusBadRequest, "cluster_id is required")
+	}
+	stateID = strings.ToLower(strings.TrimSpace(stateID))
+	return s.listIndexed(ctx, "cluster/"+clusterID+"/"+stateID, page, perPage, "ListWhitelistByCluster", clusterID, stateID)
+}
+
+func (s *Service) listIndexed(ctx context.Context, key string, page, perPage int, function string, args ...string) (*ListResult, error) {
+	if page < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "page must be >= 1")
 	}
-	return combined.ToHierarchy(), nil
+	if perPage < 1 {
+		perPage = defaultPageSize
+	}
+	key += "/" + strconv.Itoa(page) + "/" + strconv.Itoa(perPage)
+	return common.CachedLoad(ctx, s.cache, common.CacheWhitelist, key, func() (*ListResult, error) {
+		raw, err := s.query(ctx, function, append(args, strconv.Itoa(page), strconv.Itoa(perPage))...)
+		if err != nil {
+			return nil, err
+		}
+		var ledgerPage ledgerList
+		if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+			return nil, err
+		}
+		return ledgerPage.toResult(), nil
+	})
+}
+
+func (s *Service) query(ctx context.Context, function string, args ...string) ([]byte, error) {
+	peerName := s.fabric.SelectPeer(common.PeerRead)
+	if peerName == "" {
+		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
+	}
+	return s.fabric.QueryChaincode(ctx, peerName, s.cfg.AdminIdentity, append([]string{function}, args...))
 }
 
 // List returns whitelist entries from the Fabric ledger.
@@ -99,16 +196,85 @@ func (s *Service) List(ctx context.Context, page, perPage int) (*ListResult, err
 	if perPage < 1 {
 		perPage = defaultPageSize
 	}
-	peerName := s.fabric.SelectPeer()
+	key := strconv.Itoa(page) + "/" + strconv.Itoa(perPage)
+	return common.CachedLoad(ctx, s.cache, common.CacheWhitelist, key, func() (*ListResult, error) {
+		raw, err := s.query(ctx, "ListWhitelist", strconv.Itoa(page), strconv.Itoa(perPage))
+		if err != nil {
+			return nil, err
+		}
+		var ledgerPage ledgerList
+		if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+			return nil, err
+		}
+		return ledgerPage.toResult(), nil
+	})
+}
+
+// ListPage returns one page of whitelist entries using the ledger's bookmark pagination, so
+// the chaincode reads at most perPage entries plus the requested total. total is "none",
+// "estimate" or "exact"; an empty bookmark starts at the first entry.
+func (s *Service) ListPage(ctx context.Context, bookmark string, perPage int, total string) (*ListResult, error) {
+	if perPage < 1 {
+		perPage = defaultPageSize
+	}
+	key := "bookmark/" + strconv.Itoa(perPage) + "/" + total + "/" + bookmark
+	return common.CachedLoad(ctx, s.cache, common.CacheWhitelist, key, func() (*ListResult, error) {
+		raw, err := s.query(ctx, "ListWhitelistPage", strconv.Itoa(perPage), bookmark, total)
+		if err != nil {
+			if strings.Contains(err.Error(), "must be") {
+				return nil, common.NewStatusError(http.StatusBadRequest, err.Error())
+			}
+			return nil, err
+		}
+		var ledgerPage ledgerBookmarkPage
+		if err := json.Unmarshal(raw, &ledgerPage); err != nil {
+			return nil, err
+		}
+		result := (&ledgerList{Items: ledgerPage.Items}).toResult()
+		result.PerPage = ledgerPage.PageSize
+		result.Total = len(result.Items)
+		result.HasMore = ledgerPage.HasMore
+		result.Bookmark = ledgerPage.Bookmark
+		if ledgerPage.Total != nil {
+			result.Total = ledgerPage.Total.Count
+			result.TotalEstimated = ledgerPage.Total.Estimated
+		}
+		return result, nil
+	})
+}
+
+// CapabilityFilter narrows whitelist queries by placement and hardware profile.
+type CapabilityFilter struct {
+	StateID          string   `json:"state_id,omitempty"`
+	ClusterID        string   `json:"cluster_id,omitempty"`
+	GPUClasses       []string `json:"gpu_classes,omitempty"`
+	MinRAMGB         int      `json:"min_ram_gb,omitempty"`
+	MinBandwidthMbps int      `json:"min_bandwidth_mbps,omitempty"`
+	AvailableAt      string   `json:"available_at,omitempty"`
+}
+
+// ListByCapability returns whitelist entries whose capabilities satisfy the filter.
+func (s *Service) ListByCapability(ctx context.Context, filter *CapabilityFilter, page, perPage int) (*ListResult, error) {
+	if page < 1 {
+		return nil, common.NewStatusError(http.StatusBadRequest, "page must be >= 1")
+	}
+	if perPage < 1 {
+		perPage = defaultPageSize
+	}
+	if filter == nil {
+		filter = &CapabilityFilter{}
+	}
+	peerName := s.fabric.SelectPeer(common.PeerRead)
 	if peerName == "" {
 		return nil, common.NewStatusError(http.StatusInternalServerError, "no fabric peers configured")
 	}
 	args := []string{
-		"ListWhitelist",
+		"ListWhitelistByCapability",
+		common.MustJSON(filter),
 		strconv.Itoa(page),
 		strconv.Itoa(perPage),
 	}
-	raw, err := s.fabric.QueryChaincode(peerName, s.cfg.AdminIdentity, args)
+	raw, err := s.fabric.QueryChaincode(ctx, peerName, s.cfg.AdminIdentity, args)
 	if err != nil {
 		return nil, err
 	}
@@ -119,15 +285,38 @@ func (s *Service) List(ctx context.Context, page, perPage int) (*ListResult, err
 	return ledgerPage.toResult(), nil
 }
 
+// ListAllByCapability pages through every entry that satisfies the filter.
+func (s *Service) ListAllByCapability(ctx context.Context, filter *CapabilityFilter) ([]*Entry, error) {
+	page := 1
+	all := make([]*Entry, 0)
+	for {
+		result, err := s.ListByCapability(ctx, filter, page, defaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, result.Items...)
+		if !result.HasMore {
+			break
+		}
+		page++
+	}
+	return all, nil
+}
+
 type ledgerEntry struct {
-	JWTSub     string `json:"jwt_sub"`
-	DID        string `json:"did"`
-	NodeID     string `json:"node_id"`
-	State      string `json:"state,omitempty"`
-	Cluster    string `json:"cluster,omitempty"`
-	VCHash     string `json:"vc_hash"`
-	PublicKey  string `json:"public_key"`
-	Registered string `json:"registered_at"`
+	JWTSub       string                 `json:"jwt_sub"`
+	DID          string                 `json:"did"`
+	NodeID       string                 `json:"node_id"`
+	State        string                 `json:"state,omitempty"`
+	Cluster      string                 `json:"cluster,omitempty"`
+	VCHash       string                 `json:"vc_hash"`
+	PublicKey    string                 `json:"public_key"`
+	Registered   string                 `json:"registered_at"`
+	Capabilities *registry.Capabilities `json:"capabilities,omitempty"`
+
+	Status          string `json:"status,omitempty"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedAt string `json:"status_changed_at,omitempty"`
 }
 
 type ledgerList struct {
@@ -138,6 +327,56 @@ type ledgerList struct {
 	HasMore bool           `json:"has_more"`
 }
 
+type ledgerBookmarkPage struct {
+	Items    []*ledgerEntry `json:"items"`
+	PageSize int            `json:"page_size"`
+	Bookmark string         `json:"bookmark"`
+	HasMore  bool           `json:"has_more"`
+	Total    *struct {
+		Count     int  `json:"count"`
+		Estimated bool `json:"estimated"`
+	} `json:"total"`
+}
+
+type ledgerHierarchy struct {
+	States []*struct {
+		StateID  string `json:"state_id"`
+		Clusters []*struct {
+			ClusterID string         `json:"cluster_id"`
+			Nodes     []*ledgerEntry `json:"nodes"`
+		} `json:"clusters"`
+	} `json:"states"`
+	Page    int  `json:"page"`
+	PerPage int  `json:"per_page"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"has_more"`
+}
+
+func (l *ledgerHierarchy) toResult() *HierarchyResult {
+	result := &HierarchyResult{
+		States:  make([]*StateGroup, 0, len(l.States)),
+		Page:    l.Page,
+		PerPage: l.PerPage,
+		Total:   l.Total,
+		HasMore: l.HasMore,
+	}
+	for _, state := range l.States {
+		if state == nil {
+			continue
+		}
+		group := &StateGroup{StateID: state.StateID, Clusters: make([]*ClusterGroup, 0, len(state.Clusters))}
+		for _, cluster := range state.Clusters {
+			if cluster == nil {
+				continue
+			}
+			nodes := (&ledgerList{Items: cluster.Nodes}).toResult().Items
+			group.Clusters = append(group.Clusters, &ClusterGroup{ClusterID: cluster.ClusterID, Nodes: nodes})
+		}
+		result.States = append(result.States, group)
+	}
+	return result
+}
+
 func (l *ledgerList) toResult() *ListResult {
 	result := &ListResult{
 		Page:    l.Page,
@@ -154,27 +393,64 @@ func (l *ledgerList) toResult() *ListResult {
 			continue
 		}
 		items = append(items, &Entry{
-			JWTSub:       entry.JWTSub,
-			DID:          entry.DID,
-			NodeID:       entry.NodeID,
-			State:        entry.State,
-			Cluster:      entry.Cluster,
-			VCHash:       entry.VCHash,
-			PublicKey:    entry.PublicKey,
-			RegisteredAt: entry.Registered,
+			JWTSub:          entry.JWTSub,
+			DID:             entry.DID,
+			NodeID:          entry.NodeID,
+			State:           entry.State,
+			Cluster:         entry.Cluster,
+			VCHash:          entry.VCHash,
+			PublicKey:       entry.PublicKey,
+			RegisteredAt:    entry.Registered,
+			Capabilities:    entry.Capabilities,
+			Status:          entry.Status,
+			StatusReason:    entry.StatusReason,
+			StatusChangedAt: entry.StatusChangedAt,
 		})
 	}
 	result.Items = items
 	return result
 }
 
+// ActiveOnly returns a copy of the hierarchy without deactivated nodes, dropping clusters and
+// states left empty.
+func (h *HierarchyResult) ActiveOnly() *HierarchyResult {
+	result := *h
+	result.States = make([]*StateGroup, 0, len(h.States))
+	for _, state := range h.States {
+		if state == nil {
+			continue
+		}
+		group := &StateGroup{StateID: state.StateID}
+		for _, cluster := range state.Clusters {
+			if cluster == nil {
+				continue
+			}
+			var nodes []*Entry
+			for _, node := range cluster.Nodes {
+				if node != nil && node.Active() {
+					nodes = append(nodes, node)
+				}
+			}
+			if len(nodes) > 0 {
+				group.Clusters = append(group.Clusters, &ClusterGroup{ClusterID: cluster.ClusterID, Nodes: nodes})
+			}
+		}
+		if len(group.Clusters) > 0 {
+			result.States = append(result.States, group)
+		}
+	}
+	return &result
+}
+
 // ToHierarchy groups entries by state and cluster.
 func (r *ListResult) ToHierarchy() *HierarchyResult {
 	hierarchy := &HierarchyResult{
-		Page:    r.Page,
-		PerPage: r.PerPage,
-		Total:   r.Total,
-		HasMore: r.HasMore,
+		Page:           r.Page,
+		PerPage:        r.PerPage,
+		Total:          r.Total,
+		TotalEstimated: r.TotalEstimated,
+		HasMore:        r.HasMore,
+		Bookmark:       r.Bookmark,
 	}
 	if len(r.Items) == 0 {
 		return hierarchy
@@ -0,0 +1,301 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AggregationRecord documents how an aggregated model was produced: the models that went in,
+// their weights and the algorithm that combined them.
+type AggregationRecord struct {
+	JobID         string    `json:"job_id"`
+	Layer         string    `json:"layer"`
+	ScopeID       string    `json:"scope_id"`
+	Round         int       `json:"round"`
+	InputModelIDs []string  `json:"input_model_ids"`
+	OutputModelID string    `json:"output_model_id"`
+	Algorithm     string    `json:"algorithm"`
+	Weights       []float64 `json:"weights,omitempty"`
+	Aggregator    string    `json:"aggregator"`
+	TxID          string    `json:"tx_id"`
+	RecordedAt    string    `json:"recorded_at"`
+}
+
+const (
+	aggregationPrefix = "aggregation:"
+	// aggregationIndexType lists aggregations by job, layer, scope and round.
+	aggregationIndexType = "aggregation~job~layer~scope~round~output"
+)
+
+// RecordAggregation stores the composition of an aggregated model. inputModelIDs is a JSON
+// array of the models that were combined and weightsArg an optional JSON array with one
+// non-negative weight per input. The output model must already be committed by the caller to
+// layer/scopeID, and when it names parents they must be exactly the inputs. Each output model
+// can be recorded once.
+func (c *GatewayContract) RecordAggregation(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID, roundArg, inputModelIDs, outputModelID, algorithm, weightsArg string) (*AggregationRecord, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobID, layer, scopeID, err = normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	if algorithm == "" {
+		return nil, errors.New("algorithm is required")
+	}
+	outputModelID = strings.TrimSpace(outputModelID)
+	if outputModelID == "" {
+		return nil, errors.New("output model identifier is required")
+	}
+	if err := requireAggregationLeaseHolder(ctx, jobID, scopeID, round, trainer.NodeID); err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(aggregationKey(outputModelID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aggregation: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("aggregation of model %s already recorded", outputModelID)
+	}
+	output, err := readModelRecord(ctx, outputModelID)
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		return nil, fmt.Errorf("output model %s not found", outputModelID)
+	}
+	if output.Layer != layer || !strings.EqualFold(output.ScopeID, scopeID) {
+		return nil, fmt.Errorf("output model %s belongs to %s %s, not %s %s", outputModelID, output.Layer, output.ScopeID, layer, scopeID)
+	}
+	if output.Owner != trainer.NodeID {
+		return nil, fmt.Errorf("output model %s was committed by %s, not the caller", outputModelID, output.Owner)
+	}
+	inputs, err := parseAggregationInputs(ctx, inputModelIDs, outputModelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(output.ParentModelIDs) > 0 && !sameModelSet(output.ParentModelIDs, inputs) {
+		return nil, fmt.Errorf("input models do not match the parents of output model %s", outputModelID)
+	}
+	weights, err := parseAggregationWeights(weightsArg, len(inputs))
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &AggregationRecord{
+		JobID:         jobID,
+		Layer:         layer,
+		ScopeID:       scopeID,
+		Round:         round,
+		InputModelIDs: inputs,
+		OutputModelID: outputModelID,
+		Algorithm:     algorithm,
+		Weights:       weights,
+		Aggregator:    trainer.NodeID,
+		TxID:          ctx.GetStub().GetTxID(),
+		RecordedAt:    now,
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, aggregationKey(outputModelID), bytes); err != nil {
+		return nil, err
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(aggregationIndexType, []string{jobID, layer, strings.ToLower(scopeID), fmt.Sprintf("%010d", round), outputModelID})
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, indexKey, []byte{0x00}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadAggregation returns the aggregation that produced a model.
+func (c *GatewayContract) ReadAggregation(ctx contractapi.TransactionContextInterface, outputModelID string) (*AggregationRecord, error) {
+	outputModelID = strings.TrimSpace(outputModelID)
+	if outputModelID == "" {
+		return nil, errors.New("output model identifier is required")
+	}
+	record, err := readAggregation(ctx, outputModelID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("aggregation of model %s not found", outputModelID)
+	}
+	return record, nil
+}
+
+// ListAggregations returns the aggregations of a job, optionally narrowed to a layer, then a
+// scope, then a round. Later filters require the earlier ones.
+func (c *GatewayContract) ListAggregations(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID, roundArg string) ([]*AggregationRecord, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	scopeID = strings.ToLower(strings.TrimSpace(scopeID))
+	roundArg = strings.TrimSpace(roundArg)
+	if scopeID != "" && layer == "" {
+		return nil, errors.New("scopeId requires layer")
+	}
+	if roundArg != "" && scopeID == "" {
+		return nil, errors.New("round requires layer and scopeId")
+	}
+	attributes := []string{jobID}
+	if layer != "" {
+		attributes = append(attributes, layer)
+	}
+	if scopeID != "" {
+		attributes = append(attributes, scopeID)
+	}
+	if roundArg != "" {
+		round, err := strconv.Atoi(roundArg)
+		if err != nil || round < 1 {
+			return nil, errors.New("round must be a positive integer")
+		}
+		attributes = append(attributes, fmt.Sprintf("%010d", round))
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(aggregationIndexType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aggregations: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*AggregationRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 5 {
+			return nil, fmt.Errorf("malformed aggregation index key %q", kv.Key)
+		}
+		record, err := readAggregation(ctx, parts[4])
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func readAggregation(ctx contractapi.TransactionContextInterface, outputModelID string) (*AggregationRecord, error) {
+	payload, err := ctx.GetStub().GetState(aggregationKey(outputModelID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aggregation: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var record AggregationRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// parseAggregationInputs decodes the input model IDs and checks each exists, is not the
+// output and appears once.
+func parseAggregationInputs(ctx contractapi.TransactionContextInterface, raw, outputModelID string) ([]string, error) {
+	var ids []string
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+		return nil, fmt.Errorf("invalid inputModelIds: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("at least one input model is required")
+	}
+	seen := make(map[string]struct{}, len(ids))
+	inputs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, errors.New("input model identifiers must not be empty")
+		}
+		if id == outputModelID {
+			return nil, errors.New("a model cannot be an input of its own aggregation")
+		}
+		if _, dup := seen[id]; dup {
+			return nil, fmt.Errorf("input model %s is listed more than once", id)
+		}
+		seen[id] = struct{}{}
+		record, err := readModelRecord(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, fmt.Errorf("input model %s not found", id)
+		}
+		inputs = append(inputs, id)
+	}
+	return inputs, nil
+}
+
+// parseAggregationWeights decodes the optional weights: one finite, non-negative weight per
+// input, not all zero.
+func parseAggregationWeights(raw string, inputs int) ([]float64, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var weights []float64
+	if err := json.Unmarshal([]byte(raw), &weights); err != nil {
+		return nil, fmt.Errorf("invalid weights: %w", err)
+	}
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	if len(weights) != inputs {
+		return nil, fmt.Errorf("expected %d weights, one per input model, got %d", inputs, len(weights))
+	}
+	total := 0.0
+	for _, weight := range weights {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return nil, errors.New("weights must be finite, non-negative numbers")
+		}
+		total += weight
+	}
+	if total == 0 {
+		return nil, errors.New("weights must not all be zero")
+	}
+	return weights, nil
+}
+
+func sameModelSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	left := append([]string(nil), a...)
+	right := append([]string(nil), b...)
+	sort.Strings(left)
+	sort.Strings(right)
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func aggregationKey(outputModelID string) string {
+	return aggregationPrefix + outputModelID
+}
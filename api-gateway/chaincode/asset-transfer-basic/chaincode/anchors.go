@@ -0,0 +1,194 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AnchorReceipt records that a ledger digest was published to an external chain or timestamping service.
+type AnchorReceipt struct {
+	AnchorID    string            `json:"anchor_id"`
+	Digest      string            `json:"digest"`
+	BlockHeight uint64            `json:"block_height"`
+	BlockHash   string            `json:"block_hash"`
+	Namespaces  map[string]string `json:"namespaces"`
+	Endpoint    string            `json:"endpoint"`
+	Receipt     string            `json:"receipt"`
+	AnchoredAt  string            `json:"anchored_at"`
+	RecordedAt  string            `json:"recorded_at"`
+}
+
+// StateDigest is a deterministic hash over the key/value pairs of selected key prefixes.
+type StateDigest struct {
+	Digest     string            `json:"digest"`
+	Namespaces map[string]string `json:"namespaces"`
+	KeyCounts  map[string]int    `json:"key_counts"`
+}
+
+const anchorPrefix = "anchor:"
+
+// ComputeStateDigest hashes every key/value under the given prefixes (JSON array). Entries
+// containing "~" name a composite key object type (e.g. conv~state) instead of a key prefix.
+// Each namespace digest is sha256 over length-prefixed key/value pairs in key order; the
+// overall digest hashes the namespace digests in prefix order.
+func (c *GatewayContract) ComputeStateDigest(ctx contractapi.TransactionContextInterface, prefixesArg string) (*StateDigest, error) {
+	var prefixes []string
+	if err := json.Unmarshal([]byte(prefixesArg), &prefixes); err != nil {
+		return nil, fmt.Errorf("invalid prefixes: %w", err)
+	}
+	if len(prefixes) == 0 {
+		return nil, errors.New("at least one prefix is required")
+	}
+	sort.Strings(prefixes)
+	result := &StateDigest{
+		Namespaces: map[string]string{},
+		KeyCounts:  map[string]int{},
+	}
+	overall := sha256.New()
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			return nil, errors.New("prefixes must not be empty")
+		}
+		iter, err := scanNamespace(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", prefix, err)
+		}
+		hasher := sha256.New()
+		count := 0
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				return nil, fmt.Errorf("failed to advance iterator: %w", err)
+			}
+			fmt.Fprintf(hasher, "%d:%s%d:", len(kv.Key), kv.Key, len(kv.Value))
+			hasher.Write(kv.Value)
+			count++
+		}
+		iter.Close()
+		digest := hex.EncodeToString(hasher.Sum(nil))
+		result.Namespaces[prefix] = digest
+		result.KeyCounts[prefix] = count
+		fmt.Fprintf(overall, "%s=%s;", prefix, digest)
+	}
+	result.Digest = hex.EncodeToString(overall.Sum(nil))
+	return result, nil
+}
+
+// RecordAnchorReceipt stores the receipt returned by the external anchoring endpoint.
+func (c *GatewayContract) RecordAnchorReceipt(ctx contractapi.TransactionContextInterface, anchorID, digest, blockHeight, blockHash, namespaces, endpoint, receipt, anchoredAt string) (*AnchorReceipt, error) {
+	anchorID = strings.TrimSpace(anchorID)
+	if anchorID == "" {
+		return nil, errors.New("anchor identifier is required")
+	}
+	if strings.TrimSpace(digest) == "" {
+		return nil, errors.New("digest is required")
+	}
+	if strings.TrimSpace(receipt) == "" {
+		return nil, errors.New("receipt is required")
+	}
+	var height uint64
+	if strings.TrimSpace(blockHeight) != "" {
+		parsed, err := strconv.ParseUint(strings.TrimSpace(blockHeight), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blockHeight: %w", err)
+		}
+		height = parsed
+	}
+	nsDigests := map[string]string{}
+	if strings.TrimSpace(namespaces) != "" {
+		if err := json.Unmarshal([]byte(namespaces), &nsDigests); err != nil {
+			return nil, fmt.Errorf("invalid namespaces: %w", err)
+		}
+	}
+	key := anchorKey(anchorID)
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anchor receipt: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("anchor %s already recorded", anchorID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &AnchorReceipt{
+		AnchorID:    anchorID,
+		Digest:      digest,
+		BlockHeight: height,
+		BlockHash:   blockHash,
+		Namespaces:  nsDigests,
+		Endpoint:    endpoint,
+		Receipt:     receipt,
+		AnchoredAt:  anchoredAt,
+		RecordedAt:  now,
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadAnchorReceipt returns a previously recorded anchor receipt.
+func (c *GatewayContract) ReadAnchorReceipt(ctx contractapi.TransactionContextInterface, anchorID string) (*AnchorReceipt, error) {
+	payload, err := ctx.GetStub().GetState(anchorKey(strings.TrimSpace(anchorID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anchor receipt: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("anchor %s not found", anchorID)
+	}
+	var record AnchorReceipt
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListAnchorReceipts returns every anchor receipt in identifier order.
+func (c *GatewayContract) ListAnchorReceipts(ctx contractapi.TransactionContextInterface) ([]*AnchorReceipt, error) {
+	iter, err := ctx.GetStub().GetStateByRange(anchorPrefix, anchorPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list anchor receipts: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*AnchorReceipt, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record AnchorReceipt
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+func scanNamespace(ctx contractapi.TransactionContextInterface, namespace string) (shim.StateQueryIteratorInterface, error) {
+	if strings.Contains(namespace, "~") {
+		return ctx.GetStub().GetStateByPartialCompositeKey(namespace, []string{})
+	}
+	return ctx.GetStub().GetStateByRange(namespace, namespace+"~")
+}
+
+func anchorKey(anchorID string) string {
+	return anchorPrefix + anchorID
+}
@@ -0,0 +1,63 @@
+package chaincode
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// modelAttestation carries the hash of a model artifact and the trainer's signature over it.
+type modelAttestation struct {
+	ModelHash string
+	Signature string
+}
+
+// attestationMessage is the document trainers sign, binding the hash to the layer and scope.
+type attestationMessage struct {
+	Layer     string `json:"layer"`
+	ScopeID   string `json:"scope_id"`
+	ModelHash string `json:"model_hash"`
+}
+
+// errAttestation prefixes every attestation failure so clients can tell them apart.
+var errAttestation = errors.New("model attestation failed")
+
+// CommitAttestedModel stores a model reference like CommitModel after verifying that
+// signature is the caller's Ed25519 signature (base64, made with the public key the trainer
+// registered) over {"layer","scope_id","model_hash"}. When roundArg is set the commit also
+// follows CommitModelInRound rules; jobID then defaults to the default job.
+func (c *GatewayContract) CommitAttestedModel(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg, modelHash, signature string) (*ModelRecord, error) {
+	attestation := &modelAttestation{ModelHash: strings.TrimSpace(modelHash), Signature: strings.TrimSpace(signature)}
+	return c.commitModelInOptionalRound(ctx, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg, attestation, nil)
+}
+
+// verify checks the signature against the trainer's registered public key.
+func (a *modelAttestation) verify(publicKey, layer, scopeID string) error {
+	if a.ModelHash == "" {
+		return fmt.Errorf("%w: model hash is required", errAttestation)
+	}
+	if a.Signature == "" {
+		return fmt.Errorf("%w: signature is required", errAttestation)
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: trainer public key is not a base64 Ed25519 key", errAttestation)
+	}
+	sig, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding: %v", errAttestation, err)
+	}
+	message, err := json.Marshal(&attestationMessage{Layer: layer, ScopeID: scopeID, ModelHash: a.ModelHash})
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), message, sig) {
+		return fmt.Errorf("%w: signature does not match the trainer's registered public key", errAttestation)
+	}
+	return nil
+}
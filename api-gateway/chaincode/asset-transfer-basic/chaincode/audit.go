@@ -0,0 +1,121 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AuditBatch anchors a run of the gateway's local audit log. The gateway chains its entries by
+// hash, so Digest (the hash of entry LastSeq) commits to every entry up to it; auditors compare
+// it with the log to prove the log was not rewritten.
+type AuditBatch struct {
+	BatchID    string `json:"batch_id"`
+	FirstSeq   uint64 `json:"first_seq"`
+	LastSeq    uint64 `json:"last_seq"`
+	Digest     string `json:"digest"`
+	RecordedBy string `json:"recorded_by"`
+	RecordedAt string `json:"recorded_at"`
+}
+
+const auditBatchPrefix = "auditbatch:"
+
+// RecordAuditBatch stores the digest of audit entries firstSeq..lastSeq.
+func (c *GatewayContract) RecordAuditBatch(ctx contractapi.TransactionContextInterface, batchID, firstSeq, lastSeq, digest string) (*AuditBatch, error) {
+	batchID = strings.TrimSpace(batchID)
+	if batchID == "" {
+		return nil, errors.New("batch identifier is required")
+	}
+	if strings.TrimSpace(digest) == "" {
+		return nil, errors.New("digest is required")
+	}
+	first, err := strconv.ParseUint(strings.TrimSpace(firstSeq), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid firstSeq: %w", err)
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(lastSeq), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lastSeq: %w", err)
+	}
+	if first == 0 || last < first {
+		return nil, fmt.Errorf("invalid sequence range %d..%d", first, last)
+	}
+	key := auditBatchKey(batchID)
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit batch: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("audit batch %s already recorded", batchID)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &AuditBatch{
+		BatchID:    batchID,
+		FirstSeq:   first,
+		LastSeq:    last,
+		Digest:     strings.TrimSpace(digest),
+		RecordedBy: clientID,
+		RecordedAt: now,
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadAuditBatch returns a previously recorded audit batch.
+func (c *GatewayContract) ReadAuditBatch(ctx contractapi.TransactionContextInterface, batchID string) (*AuditBatch, error) {
+	payload, err := ctx.GetStub().GetState(auditBatchKey(strings.TrimSpace(batchID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit batch: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("audit batch %s not found", batchID)
+	}
+	var record AuditBatch
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListAuditBatches returns every audit batch in identifier order.
+func (c *GatewayContract) ListAuditBatches(ctx contractapi.TransactionContextInterface) ([]*AuditBatch, error) {
+	iter, err := ctx.GetStub().GetStateByRange(auditBatchPrefix, auditBatchPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit batches: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*AuditBatch, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record AuditBatch
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+func auditBatchKey(batchID string) string {
+	return auditBatchPrefix + batchID
+}
@@ -0,0 +1,125 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ModelBatchItem is a single model reference submitted through CommitModels. Round and JobID
+// are optional; when Round is set the commit follows CommitModelInRound rules.
+type ModelBatchItem struct {
+	ID             string   `json:"id"`
+	Layer          string   `json:"layer"`
+	ScopeID        string   `json:"scope_id"`
+	Payload        string   `json:"payload"`
+	ParentModelIDs []string `json:"parent_model_ids,omitempty"`
+	JobID          string   `json:"job_id,omitempty"`
+	Round          int      `json:"round,omitempty"`
+	// Metrics and Hyperparameters follow CommitModelWithMetadata.
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters json.RawMessage    `json:"hyperparameters,omitempty"`
+}
+
+// ModelBatchItemResult reports the outcome of one batch item.
+type ModelBatchItemResult struct {
+	Index  int          `json:"index"`
+	ID     string       `json:"id"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Record *ModelRecord `json:"record,omitempty"`
+}
+
+// ModelBatchResult summarises a CommitModels transaction.
+type ModelBatchResult struct {
+	Committed int                     `json:"committed"`
+	Failed    int                     `json:"failed"`
+	Items     []*ModelBatchItemResult `json:"items"`
+}
+
+const (
+	maxModelBatchSize = 100
+
+	batchItemCommitted = "committed"
+	batchItemFailed    = "failed"
+)
+
+// CommitModels stores several model references in a single transaction. itemsArg is a JSON
+// array of ModelBatchItem. Items are validated independently: a failing item is reported in
+// the result and does not prevent the others from being written. Parents must already be on
+// the ledger; models committed earlier in the same batch are not visible as parents.
+func (c *GatewayContract) CommitModels(ctx contractapi.TransactionContextInterface, itemsArg string) (*ModelBatchResult, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	var items []*ModelBatchItem
+	if err := json.Unmarshal([]byte(itemsArg), &items); err != nil {
+		return nil, fmt.Errorf("invalid batch: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("batch must contain at least one model")
+	}
+	if len(items) > maxModelBatchSize {
+		return nil, fmt.Errorf("batch exceeds %d models", maxModelBatchSize)
+	}
+	result := &ModelBatchResult{Items: make([]*ModelBatchItemResult, 0, len(items))}
+	// Writes made earlier in this transaction are invisible to GetState, so duplicate
+	// identifiers within the batch are tracked here.
+	seen := map[string]struct{}{}
+	for index, item := range items {
+		entry := &ModelBatchItemResult{Index: index, Status: batchItemFailed}
+		result.Items = append(result.Items, entry)
+		if item == nil {
+			entry.Error = "batch item is empty"
+			result.Failed++
+			continue
+		}
+		entry.ID = strings.TrimSpace(item.ID)
+		if _, dup := seen[entry.ID]; dup && entry.ID != "" {
+			entry.Error = fmt.Sprintf("model %s appears more than once in the batch", entry.ID)
+			result.Failed++
+			continue
+		}
+		record, err := c.commitBatchItem(ctx, item)
+		if err != nil {
+			entry.Error = err.Error()
+			result.Failed++
+			continue
+		}
+		seen[entry.ID] = struct{}{}
+		entry.Status = batchItemCommitted
+		entry.Record = record
+		result.Committed++
+	}
+	return result, nil
+}
+
+func (c *GatewayContract) commitBatchItem(ctx contractapi.TransactionContextInterface, item *ModelBatchItem) (*ModelRecord, error) {
+	parents := ""
+	if len(item.ParentModelIDs) > 0 {
+		encoded, err := json.Marshal(item.ParentModelIDs)
+		if err != nil {
+			return nil, err
+		}
+		parents = string(encoded)
+	}
+	if item.Round < 0 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	round := ""
+	if item.Round > 0 {
+		round = strconv.Itoa(item.Round)
+	}
+	var metadata *modelMetadata
+	if len(item.Metrics) > 0 || len(item.Hyperparameters) > 0 {
+		metadata = &modelMetadata{Metrics: item.Metrics, Hyperparameters: item.Hyperparameters}
+		if err := metadata.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return c.commitModelInOptionalRound(ctx, item.ID, item.Layer, item.ScopeID, item.Payload, parents, item.JobID, round, nil, metadata)
+}
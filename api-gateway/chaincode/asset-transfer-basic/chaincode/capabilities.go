@@ -0,0 +1,256 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// TrainerCapabilities captures the hardware profile a trainer advertises during registration.
+type TrainerCapabilities struct {
+	GPUClass            string                `json:"gpu_class,omitempty"`
+	RAMGB               int                   `json:"ram_gb,omitempty"`
+	BandwidthMbps       int                   `json:"bandwidth_mbps,omitempty"`
+	AvailabilityWindows []*AvailabilityWindow `json:"availability_windows,omitempty"`
+}
+
+// AvailabilityWindow describes a recurring UTC time range in which a trainer can participate.
+type AvailabilityWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// CapabilityFilter narrows whitelist queries to trainers matching the requested profile.
+type CapabilityFilter struct {
+	StateID          string   `json:"state_id,omitempty"`
+	ClusterID        string   `json:"cluster_id,omitempty"`
+	GPUClasses       []string `json:"gpu_classes,omitempty"`
+	MinRAMGB         int      `json:"min_ram_gb,omitempty"`
+	MinBandwidthMbps int      `json:"min_bandwidth_mbps,omitempty"`
+	AvailableAt      string   `json:"available_at,omitempty"`
+}
+
+// ListWhitelistByCapability returns the active whitelist entries matching the supplied
+// capability filter.
+func (c *GatewayContract) ListWhitelistByCapability(ctx contractapi.TransactionContextInterface, filterArg, pageArg, perPageArg string) (*WhitelistListPage, error) {
+	filter, err := parseCapabilityFilter(filterArg)
+	if err != nil {
+		return nil, err
+	}
+	page := 1
+	if strings.TrimSpace(pageArg) != "" {
+		value, err := strconv.Atoi(pageArg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page parameter: %w", err)
+		}
+		if value < 1 {
+			return nil, errors.New("page must be >= 1")
+		}
+		page = value
+	}
+	perPage := 50
+	if strings.TrimSpace(perPageArg) != "" {
+		value, err := strconv.Atoi(perPageArg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid perPage parameter: %w", err)
+		}
+		if value < 1 {
+			return nil, errors.New("perPage must be >= 1")
+		}
+		perPage = value
+	}
+	iter, err := ctx.GetStub().GetStateByRange(whitelistPrefix, whitelistPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+
+	start := (page - 1) * perPage
+	total := 0
+	items := make([]*WhitelistEntry, 0, perPage)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var entry WhitelistEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.JWTSub == "" || entry.Status == whitelistStatusDeactivated || !filter.matches(&entry) {
+			continue
+		}
+		total++
+		if total <= start {
+			continue
+		}
+		if len(items) >= perPage {
+			continue
+		}
+		copy := entry
+		items = append(items, &copy)
+	}
+	hasMore := total > start+len(items)
+	return &WhitelistListPage{
+		Items:   items,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		HasMore: hasMore,
+	}, nil
+}
+
+func parseCapabilities(raw string) (*TrainerCapabilities, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var caps TrainerCapabilities
+	if err := json.Unmarshal([]byte(raw), &caps); err != nil {
+		return nil, fmt.Errorf("invalid capabilities: %w", err)
+	}
+	caps.GPUClass = strings.ToLower(strings.TrimSpace(caps.GPUClass))
+	if caps.RAMGB < 0 {
+		return nil, errors.New("capabilities.ram_gb must be >= 0")
+	}
+	if caps.BandwidthMbps < 0 {
+		return nil, errors.New("capabilities.bandwidth_mbps must be >= 0")
+	}
+	for i, window := range caps.AvailabilityWindows {
+		if window == nil {
+			return nil, fmt.Errorf("capabilities.availability_windows[%d] is empty", i)
+		}
+		if _, err := parseClock(window.Start); err != nil {
+			return nil, fmt.Errorf("capabilities.availability_windows[%d].start: %w", i, err)
+		}
+		if _, err := parseClock(window.End); err != nil {
+			return nil, fmt.Errorf("capabilities.availability_windows[%d].end: %w", i, err)
+		}
+		for j, day := range window.Days {
+			normalized := strings.ToLower(strings.TrimSpace(day))
+			if _, ok := weekdays[normalized]; !ok {
+				return nil, fmt.Errorf("capabilities.availability_windows[%d].days: unknown day %s", i, day)
+			}
+			window.Days[j] = normalized
+		}
+	}
+	return &caps, nil
+}
+
+func parseCapabilityFilter(raw string) (*CapabilityFilter, error) {
+	filter := &CapabilityFilter{}
+	if strings.TrimSpace(raw) == "" {
+		return filter, nil
+	}
+	if err := json.Unmarshal([]byte(raw), filter); err != nil {
+		return nil, fmt.Errorf("invalid capability filter: %w", err)
+	}
+	if filter.AvailableAt != "" {
+		if _, err := time.Parse(time.RFC3339, filter.AvailableAt); err != nil {
+			return nil, fmt.Errorf("invalid available_at: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+func (f *CapabilityFilter) matches(entry *WhitelistEntry) bool {
+	if f.StateID != "" && !strings.EqualFold(entry.State, f.StateID) {
+		return false
+	}
+	if f.ClusterID != "" && !strings.EqualFold(entry.Cluster, f.ClusterID) {
+		return false
+	}
+	requiresCapabilities := len(f.GPUClasses) > 0 || f.MinRAMGB > 0 || f.MinBandwidthMbps > 0 || f.AvailableAt != ""
+	if !requiresCapabilities {
+		return true
+	}
+	caps := entry.Capabilities
+	if caps == nil {
+		return false
+	}
+	if len(f.GPUClasses) > 0 {
+		matched := false
+		for _, class := range f.GPUClasses {
+			if strings.EqualFold(strings.TrimSpace(class), caps.GPUClass) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if caps.RAMGB < f.MinRAMGB || caps.BandwidthMbps < f.MinBandwidthMbps {
+		return false
+	}
+	if f.AvailableAt != "" {
+		at, err := time.Parse(time.RFC3339, f.AvailableAt)
+		if err != nil {
+			return false
+		}
+		return caps.availableAt(at.UTC())
+	}
+	return true
+}
+
+// availableAt reports whether any window covers the instant; trainers without windows are always available.
+func (caps *TrainerCapabilities) availableAt(at time.Time) bool {
+	if len(caps.AvailabilityWindows) == 0 {
+		return true
+	}
+	minute := at.Hour()*60 + at.Minute()
+	day := strings.ToLower(at.Weekday().String()[:3])
+	for _, window := range caps.AvailabilityWindows {
+		if window == nil {
+			continue
+		}
+		if len(window.Days) > 0 && !containsFold(window.Days, day) {
+			continue
+		}
+		start, err := parseClock(window.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			continue
+		}
+		if start <= end {
+			if minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+		// Windows that wrap past midnight (e.g. 22:00-06:00).
+		if minute >= start || minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdays = map[string]struct{}{
+	"mon": {}, "tue": {}, "wed": {}, "thu": {}, "fri": {}, "sat": {}, "sun": {},
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
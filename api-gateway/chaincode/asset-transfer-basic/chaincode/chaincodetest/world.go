@@ -0,0 +1,191 @@
+// Package chaincodetest simulates an endorsing peer for contract tests. A World is an
+// in-memory world state served through the counterfeiter fakes in chaincode/mocks, so tests
+// can run transactions as any identity and inspect what they wrote.
+package chaincodetest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Timestamp is the proposal time of every transaction unless a World sets its own. It is far
+// from the wall clock, so records stamped with time.Now cannot match it by accident.
+var Timestamp = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+// compositeKeyNamespace starts every composite key; range queries skip those keys like a peer.
+const compositeKeyNamespace = "\x00"
+
+// World is the world state shared by the transactions of a test. Writes apply immediately.
+type World struct {
+	State map[string][]byte
+	// Events holds the payload of the last event set under each name.
+	Events map[string][]byte
+	// Timestamp and TxID are what the stubs report for every transaction.
+	Timestamp time.Time
+	TxID      string
+}
+
+// NewWorld returns an empty world state.
+func NewWorld() *World {
+	return &World{State: map[string][]byte{}, Events: map[string][]byte{}, Timestamp: Timestamp, TxID: "tx-1"}
+}
+
+// Context returns a transaction context that invokes as clientID, with MSP ID Org1MSP and
+// no attributes.
+func (w *World) Context(clientID string) *mocks.TransactionContext {
+	return w.ContextFor(Identity(clientID, "Org1MSP", nil))
+}
+
+// ContextFor returns a transaction context that invokes as identity.
+func (w *World) ContextFor(identity *mocks.ClientIdentity) *mocks.TransactionContext {
+	ctx := &mocks.TransactionContext{}
+	ctx.GetStubReturns(w.Stub())
+	ctx.GetClientIdentityReturns(identity)
+	return ctx
+}
+
+// Identity returns a client identity fake with the given ID, MSP ID and attributes.
+func Identity(clientID, mspID string, attributes map[string]string) *mocks.ClientIdentity {
+	identity := &mocks.ClientIdentity{}
+	identity.GetIDReturns(clientID, nil)
+	identity.GetMSPIDReturns(mspID, nil)
+	identity.GetAttributeValueCalls(func(name string) (string, bool, error) {
+		value, ok := attributes[name]
+		return value, ok, nil
+	})
+	identity.AssertAttributeValueCalls(func(name, value string) error {
+		if actual, ok := attributes[name]; !ok || actual != value {
+			return errors.New("attribute " + name + " does not match")
+		}
+		return nil
+	})
+	return identity
+}
+
+// Stub returns a chaincode stub backed by the world state. Calls without a stub (private
+// data, history, rich queries) return the fake's zero values. Paginated range and composite
+// key queries use the next unread key as their bookmark, which is empty on the last page.
+func (w *World) Stub() *mocks.ChaincodeStub {
+	stub := &mocks.ChaincodeStub{}
+	stub.GetStateStub = func(key string) ([]byte, error) {
+		return w.State[key], nil
+	}
+	stub.PutStateStub = func(key string, value []byte) error {
+		if key == "" {
+			return errors.New("key must not be an empty string")
+		}
+		w.State[key] = value
+		return nil
+	}
+	stub.DelStateStub = func(key string) error {
+		delete(w.State, key)
+		return nil
+	}
+	stub.GetStateByRangeStub = func(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+		return w.iterator(func(key string) bool {
+			return !strings.HasPrefix(key, compositeKeyNamespace) && key >= startKey && (endKey == "" || key < endKey)
+		}), nil
+	}
+	stub.GetStateByPartialCompositeKeyStub = func(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+		prefix, err := shim.CreateCompositeKey(objectType, attributes)
+		if err != nil {
+			return nil, err
+		}
+		return w.iterator(func(key string) bool {
+			return strings.HasPrefix(key, prefix)
+		}), nil
+	}
+	stub.GetStateByRangeWithPaginationStub = func(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+		iterator, metadata := w.page(func(key string) bool {
+			return !strings.HasPrefix(key, compositeKeyNamespace) && key >= startKey && (endKey == "" || key < endKey)
+		}, pageSize, bookmark)
+		return iterator, metadata, nil
+	}
+	stub.GetStateByPartialCompositeKeyWithPaginationStub = func(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+		prefix, err := shim.CreateCompositeKey(objectType, attributes)
+		if err != nil {
+			return nil, nil, err
+		}
+		iterator, metadata := w.page(func(key string) bool {
+			return strings.HasPrefix(key, prefix)
+		}, pageSize, bookmark)
+		return iterator, metadata, nil
+	}
+	stub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	stub.SplitCompositeKeyStub = SplitCompositeKey
+	stub.SetEventStub = func(name string, payload []byte) error {
+		w.Events[name] = payload
+		return nil
+	}
+	stub.GetTxIDStub = func() string {
+		return w.TxID
+	}
+	stub.GetTxTimestampStub = func() (*timestamppb.Timestamp, error) {
+		return timestamppb.New(w.Timestamp), nil
+	}
+	return stub
+}
+
+// iterator returns the entries whose key matches, in key order.
+func (w *World) iterator(match func(key string) bool) *mocks.StateQueryIterator {
+	return w.keyIterator(w.matching(match))
+}
+
+// page returns up to pageSize matching entries from bookmark on, and the bookmark of the
+// next page.
+func (w *World) page(match func(key string) bool, pageSize int32, bookmark string) (*mocks.StateQueryIterator, *peer.QueryResponseMetadata) {
+	keys := w.matching(func(key string) bool {
+		return key >= bookmark && match(key)
+	})
+	next := ""
+	if pageSize > 0 && len(keys) > int(pageSize) {
+		next = keys[pageSize]
+		keys = keys[:pageSize]
+	}
+	return w.keyIterator(keys), &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(keys)), Bookmark: next}
+}
+
+// matching lists the keys that match, in key order.
+func (w *World) matching(match func(key string) bool) []string {
+	keys := make([]string, 0)
+	for key := range w.State {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (w *World) keyIterator(keys []string) *mocks.StateQueryIterator {
+	iterator := &mocks.StateQueryIterator{}
+	iterator.HasNextCalls(func() bool {
+		return len(keys) > 0
+	})
+	iterator.NextCalls(func() (*queryresult.KV, error) {
+		if len(keys) == 0 {
+			return nil, errors.New("iterator exhausted")
+		}
+		key := keys[0]
+		keys = keys[1:]
+		return &queryresult.KV{Key: key, Value: w.State[key]}, nil
+	})
+	return iterator
+}
+
+// SplitCompositeKey reverses shim.CreateCompositeKey.
+func SplitCompositeKey(key string) (string, []string, error) {
+	if !strings.HasPrefix(key, compositeKeyNamespace) || !strings.HasSuffix(key, "\x00") {
+		return "", nil, errors.New("not a composite key: " + key)
+	}
+	parts := strings.Split(key[1:len(key)-1], "\x00")
+	return parts[0], parts[1:], nil
+}
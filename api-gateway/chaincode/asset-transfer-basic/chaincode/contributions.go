@@ -0,0 +1,217 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Contribution records what one trainer contributed to a round.
+type Contribution struct {
+	NodeID      string  `json:"node_id"`
+	JobID       string  `json:"job_id"`
+	Round       int     `json:"round"`
+	SampleCount int     `json:"sample_count"`
+	LossDelta   float64 `json:"loss_delta"`
+	ModelHash   string  `json:"model_hash"`
+	RecordedBy  string  `json:"recorded_by"`
+	RecordedAt  string  `json:"recorded_at"`
+	TxID        string  `json:"tx_id"`
+}
+
+// ContributionSummary accumulates a trainer's contributions across rounds and jobs.
+type ContributionSummary struct {
+	NodeID         string  `json:"node_id"`
+	Rounds         int     `json:"rounds"`
+	TotalSamples   int     `json:"total_samples"`
+	TotalLossDelta float64 `json:"total_loss_delta"`
+	LastJobID      string  `json:"last_job_id"`
+	LastRound      int     `json:"last_round"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+const (
+	contributionType          = "contrib~node~job~round"
+	contributionSummaryPrefix = "contrib:total:"
+)
+
+// RecordContribution stores a trainer's contribution to a job round and adds it to the
+// trainer's running totals. An empty nodeID records the caller's own contribution. Each
+// trainer can be recorded once per job round.
+func (c *GatewayContract) RecordContribution(ctx contractapi.TransactionContextInterface, jobID, roundArg, nodeID, samplesArg, lossDeltaArg, modelHash string) (*Contribution, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		nodeID = trainer.NodeID
+	}
+	samples, err := strconv.Atoi(strings.TrimSpace(samplesArg))
+	if err != nil || samples < 0 {
+		return nil, errors.New("sample_count must be a non-negative integer")
+	}
+	lossDelta, err := strconv.ParseFloat(strings.TrimSpace(lossDeltaArg), 64)
+	if err != nil || math.IsNaN(lossDelta) || math.IsInf(lossDelta, 0) {
+		return nil, errors.New("loss_delta must be a finite number")
+	}
+	modelHash = strings.TrimSpace(modelHash)
+	if modelHash == "" {
+		return nil, errors.New("model_hash is required")
+	}
+	key, err := contributionKey(ctx, nodeID, jobID, round)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contribution: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("contribution of %s for job %s round %d already recorded", nodeID, jobID, round)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &Contribution{
+		NodeID:      nodeID,
+		JobID:       jobID,
+		Round:       round,
+		SampleCount: samples,
+		LossDelta:   lossDelta,
+		ModelHash:   modelHash,
+		RecordedBy:  trainer.NodeID,
+		RecordedAt:  now,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	summary, err := readContributionSummary(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		summary = &ContributionSummary{NodeID: nodeID}
+	}
+	summary.Rounds++
+	summary.TotalSamples += samples
+	summary.TotalLossDelta += lossDelta
+	summary.LastJobID = jobID
+	summary.LastRound = round
+	summary.UpdatedAt = record.RecordedAt
+	bytes, err = json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, contributionSummaryPrefix+nodeID, bytes); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListContributions returns a trainer's contributions in job and round order, optionally
+// restricted to one job.
+func (c *GatewayContract) ListContributions(ctx contractapi.TransactionContextInterface, nodeID, jobID string) ([]*Contribution, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, errors.New("node identifier is required")
+	}
+	attrs := []string{nodeID}
+	if jobID = strings.TrimSpace(jobID); jobID != "" {
+		attrs = append(attrs, jobID)
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(contributionType, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contributions: %w", err)
+	}
+	defer iter.Close()
+	records := []*Contribution{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var record Contribution
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// ReadContributionSummary returns a trainer's accumulated contributions.
+func (c *GatewayContract) ReadContributionSummary(ctx contractapi.TransactionContextInterface, nodeID string) (*ContributionSummary, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, errors.New("node identifier is required")
+	}
+	summary, err := readContributionSummary(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("contributions of %s not found", nodeID)
+	}
+	return summary, nil
+}
+
+// ListContributionSummaries returns the accumulated contributions of every trainer.
+func (c *GatewayContract) ListContributionSummaries(ctx contractapi.TransactionContextInterface) ([]*ContributionSummary, error) {
+	iter, err := ctx.GetStub().GetStateByRange(contributionSummaryPrefix, contributionSummaryPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contribution summaries: %w", err)
+	}
+	defer iter.Close()
+	summaries := []*ContributionSummary{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var summary ContributionSummary
+		if err := json.Unmarshal(kv.Value, &summary); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &summary)
+	}
+	return summaries, nil
+}
+
+func readContributionSummary(ctx contractapi.TransactionContextInterface, nodeID string) (*ContributionSummary, error) {
+	payload, err := ctx.GetStub().GetState(contributionSummaryPrefix + nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contribution summary: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var summary ContributionSummary
+	if err := json.Unmarshal(payload, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func contributionKey(ctx contractapi.TransactionContextInterface, nodeID, jobID string, round int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(contributionType, []string{nodeID, jobID, fmt.Sprintf("%010d", round)})
+}
@@ -0,0 +1,247 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ConvergenceArchive is a convergence snapshot moved to history by ResetConvergence.
+type ConvergenceArchive struct {
+	Scope    string               `json:"scope"`
+	TargetID string               `json:"target_id"`
+	JobID    string               `json:"job_id,omitempty"`
+	Round    int                  `json:"round,omitempty"`
+	Summary  *ConvergenceSummary  `json:"summary,omitempty"`
+	Records  []*ConvergenceRecord `json:"records"`
+	// Verification is the state's verification, cleared with the convergence it covered.
+	Verification *StateVerification `json:"verification,omitempty"`
+	Reason       string             `json:"reason,omitempty"`
+	ResetBy      string             `json:"reset_by"`
+	ResetAt      string             `json:"reset_at"`
+	TxID         string             `json:"tx_id"`
+}
+
+// EventConvergenceReset is emitted when a scope's convergence is reset.
+const EventConvergenceReset = "ConvergenceReset"
+
+const convHistoryType = "conv~history"
+
+// ResetConvergence archives the convergence of a state (scope "state") or of the nation
+// (scope "nation") to history and clears it, so the next round can converge and declare
+// again. Resetting a state clears its summary and cluster records; resetting the nation
+// clears the nation summary and state records. The gateway restricts callers to admins.
+func (c *GatewayContract) ResetConvergence(ctx contractapi.TransactionContextInterface, scope, stateID, reason string) (*ConvergenceArchive, error) {
+	return c.resetConvergence(ctx, convergenceScope{}, scope, stateID, reason)
+}
+
+// ResetConvergenceInRound resets the convergence recorded for a job round.
+func (c *GatewayContract) ResetConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, scope, stateID, reason string) (*ConvergenceArchive, error) {
+	convScope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.resetConvergence(ctx, convScope, scope, stateID, reason)
+}
+
+// ListConvergenceHistory returns the archived convergence of a state (scope "state") or of
+// the nation (scope "nation"), oldest first.
+func (c *GatewayContract) ListConvergenceHistory(ctx contractapi.TransactionContextInterface, scope, stateID string) ([]*ConvergenceArchive, error) {
+	scope, targetID, err := normalizeResetTarget(scope, stateID)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(convHistoryType, []string{scope, targetID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read convergence history: %w", err)
+	}
+	defer iter.Close()
+	archives := []*ConvergenceArchive{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var archive ConvergenceArchive
+		if err := json.Unmarshal(kv.Value, &archive); err != nil {
+			return nil, err
+		}
+		archives = append(archives, &archive)
+	}
+	return archives, nil
+}
+
+func (c *GatewayContract) resetConvergence(ctx contractapi.TransactionContextInterface, convScope convergenceScope, scope, stateID, reason string) (*ConvergenceArchive, error) {
+	scope, targetID, err := normalizeResetTarget(scope, stateID)
+	if err != nil {
+		return nil, err
+	}
+	if scope == "state" {
+		if err := requireNoOpenDispute(ctx, convScope, targetID); err != nil {
+			return nil, err
+		}
+	}
+	archive, err := c.archiveConvergence(ctx, convScope, scope, targetID, reason)
+	if err != nil {
+		return nil, err
+	}
+	event := &ConvergenceEvent{
+		Event:       EventConvergenceReset,
+		Scope:       scope,
+		TargetID:    targetID,
+		JobID:       convScope.jobID,
+		Round:       convScope.round,
+		SubmittedBy: archive.ResetBy,
+		Timestamp:   archive.ResetAt,
+	}
+	if scope == "state" {
+		event.StateID = targetID
+	}
+	if err := emitConvergenceEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// archiveConvergence moves the convergence of a normalized target to history and clears it.
+// Callers emit the event, as Fabric keeps one per transaction.
+func (c *GatewayContract) archiveConvergence(ctx contractapi.TransactionContextInterface, convScope convergenceScope, scope, targetID, reason string) (*ConvergenceArchive, error) {
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archive := &ConvergenceArchive{
+		Scope:    scope,
+		TargetID: targetID,
+		JobID:    convScope.jobID,
+		Round:    convScope.round,
+		Records:  []*ConvergenceRecord{},
+		Reason:   strings.TrimSpace(reason),
+		ResetBy:  actor,
+		ResetAt:  now,
+		TxID:     ctx.GetStub().GetTxID(),
+	}
+	keys, err := collectConvergence(ctx, convScope, archive)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no convergence recorded for %s %s%s", scope, targetID, convScope.describe())
+	}
+	if scope == "state" {
+		// A verification vouches for the declaration it checked, so it goes with it; the
+		// state must be verified again once it re-declares.
+		key, err := collectVerification(ctx, convScope, archive)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return nil, fmt.Errorf("failed to clear convergence: %w", err)
+		}
+	}
+	historyKey, err := ctx.GetStub().CreateCompositeKey(convHistoryType, []string{scope, targetID, archive.ResetAt, archive.TxID})
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := json.Marshal(archive)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, historyKey, bytes); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// collectConvergence copies the records and summary of the archive's target into it and
+// returns their keys.
+func collectConvergence(ctx contractapi.TransactionContextInterface, convScope convergenceScope, archive *ConvergenceArchive) ([]string, error) {
+	var (
+		iter shim.StateQueryIteratorInterface
+		err  error
+	)
+	if archive.Scope == "state" {
+		iter, err = convScope.partialStateKeys(ctx, archive.TargetID)
+	} else {
+		iter, err = convScope.partialNationKeys(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read convergence: %w", err)
+	}
+	defer iter.Close()
+	var keys []string
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		parts, err := convScope.splitKey(ctx, kv.Key)
+		if err != nil || len(parts) == 0 {
+			continue
+		}
+		if parts[len(parts)-1] == "summary" {
+			var summary ConvergenceSummary
+			if err := json.Unmarshal(kv.Value, &summary); err != nil {
+				return nil, err
+			}
+			archive.Summary = &summary
+		} else {
+			var record ConvergenceRecord
+			if err := json.Unmarshal(kv.Value, &record); err != nil {
+				return nil, err
+			}
+			archive.Records = append(archive.Records, &record)
+		}
+		keys = append(keys, kv.Key)
+	}
+	return keys, nil
+}
+
+// collectVerification copies the verification of the archive's state into it and returns its
+// key, or "" when the state has none.
+func collectVerification(ctx contractapi.TransactionContextInterface, convScope convergenceScope, archive *ConvergenceArchive) (string, error) {
+	key, err := convScope.verificationKey(ctx, archive.TargetID)
+	if err != nil {
+		return "", err
+	}
+	payload, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read state verification: %w", err)
+	}
+	if len(payload) == 0 {
+		return "", nil
+	}
+	var verification StateVerification
+	if err := json.Unmarshal(payload, &verification); err != nil {
+		return "", err
+	}
+	archive.Verification = &verification
+	return key, nil
+}
+
+func normalizeResetTarget(scope, stateID string) (string, string, error) {
+	switch strings.ToLower(strings.TrimSpace(scope)) {
+	case "state":
+		stateID, err := normalizeIdentifier(stateID, "stateId")
+		if err != nil {
+			return "", "", err
+		}
+		return "state", stateID, nil
+	case "nation":
+		return "nation", "nation", nil
+	}
+	return "", "", errors.New(`scope must be "state" or "nation"`)
+}
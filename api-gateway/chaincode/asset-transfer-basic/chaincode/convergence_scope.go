@@ -0,0 +1,175 @@
+package chaincode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// convergenceScope selects the keyspace of convergence records. The zero value is the
+// legacy unscoped keyspace used by the original convergence functions; a scoped value keeps
+// the records of each job and round apart.
+type convergenceScope struct {
+	jobID string
+	round int
+}
+
+// CommitStateClusterConvergenceInRound records cluster convergence for a job round.
+func (c *GatewayContract) CommitStateClusterConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID, clusterID, payload string) (*ConvergenceRecord, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.commitStateClusterConvergence(ctx, scope, stateID, clusterID, payload)
+}
+
+// CommitNationStateConvergenceInRound records a state's nation convergence for a job round.
+func (c *GatewayContract) CommitNationStateConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID, payload string) (*ConvergenceRecord, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.commitNationStateConvergence(ctx, scope, stateID, payload)
+}
+
+// DeclareStateConvergenceInRound marks a state as converged for a job round (first
+// declaration wins).
+func (c *GatewayContract) DeclareStateConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID, payload string) (*ConvergenceSummary, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.declareStateConvergence(ctx, scope, stateID, payload)
+}
+
+// DeclareNationConvergenceInRound marks the nation as converged for a job round (first
+// declaration wins).
+func (c *GatewayContract) DeclareNationConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, payload string) (*ConvergenceSummary, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.declareNationConvergence(ctx, scope, payload)
+}
+
+// ReadStateConvergenceInRound returns the convergence state of one state for a job round.
+func (c *GatewayContract) ReadStateConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID string) (*StateConvergence, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.readStateConvergence(ctx, scope, stateID)
+}
+
+// ListStateConvergenceInRound returns the convergence state of every state for a job round.
+func (c *GatewayContract) ListStateConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg string) (map[string]*StateConvergence, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.listStateConvergence(ctx, scope)
+}
+
+// ListNationConvergenceInRound returns the nation convergence state for a job round.
+func (c *GatewayContract) ListNationConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg string) (*NationConvergence, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.listNationConvergence(ctx, scope)
+}
+
+// parseConvergenceScope validates a job/round pair. An empty job ID selects the default job,
+// matching the round functions.
+func parseConvergenceScope(jobID, roundArg string) (convergenceScope, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return convergenceScope{}, errors.New("round must be a positive integer")
+	}
+	return convergenceScope{jobID: jobID, round: round}, nil
+}
+
+func (s convergenceScope) scoped() bool {
+	return s.jobID != ""
+}
+
+// prefix returns the composite key attributes that precede the record attributes.
+func (s convergenceScope) prefix() []string {
+	if !s.scoped() {
+		return nil
+	}
+	return []string{s.jobID, fmt.Sprintf("%010d", s.round)}
+}
+
+func (s convergenceScope) stateKey(ctx contractapi.TransactionContextInterface, attrs ...string) (string, error) {
+	if !s.scoped() {
+		return ctx.GetStub().CreateCompositeKey(stateConvType, attrs)
+	}
+	return ctx.GetStub().CreateCompositeKey(jobStateConvType, append(s.prefix(), attrs...))
+}
+
+func (s convergenceScope) nationKey(ctx contractapi.TransactionContextInterface, attrs ...string) (string, error) {
+	if !s.scoped() {
+		return ctx.GetStub().CreateCompositeKey(nationConvType, attrs)
+	}
+	return ctx.GetStub().CreateCompositeKey(jobNationConvType, append(s.prefix(), attrs...))
+}
+
+func (s convergenceScope) partialStateKeys(ctx contractapi.TransactionContextInterface, attrs ...string) (shim.StateQueryIteratorInterface, error) {
+	if !s.scoped() {
+		return ctx.GetStub().GetStateByPartialCompositeKey(stateConvType, attrs)
+	}
+	return ctx.GetStub().GetStateByPartialCompositeKey(jobStateConvType, append(s.prefix(), attrs...))
+}
+
+func (s convergenceScope) partialNationKeys(ctx contractapi.TransactionContextInterface, attrs ...string) (shim.StateQueryIteratorInterface, error) {
+	if !s.scoped() {
+		return ctx.GetStub().GetStateByPartialCompositeKey(nationConvType, attrs)
+	}
+	return ctx.GetStub().GetStateByPartialCompositeKey(jobNationConvType, append(s.prefix(), attrs...))
+}
+
+// splitKey returns the record attributes of a composite key, without the job/round prefix.
+func (s convergenceScope) splitKey(ctx contractapi.TransactionContextInterface, key string) ([]string, error) {
+	_, parts, err := ctx.GetStub().SplitCompositeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	skip := len(s.prefix())
+	if len(parts) < skip {
+		return nil, fmt.Errorf("convergence key %q is missing its job scope", key)
+	}
+	return parts[skip:], nil
+}
+
+// requireRunningJob rejects writes to a job that is registered but not RUNNING. Jobs that
+// were never registered (including the default job) are accepted.
+func (s convergenceScope) requireRunningJob(ctx contractapi.TransactionContextInterface) error {
+	if !s.scoped() {
+		return nil
+	}
+	job, err := readJob(ctx, s.jobID)
+	if err != nil {
+		return err
+	}
+	if job != nil && job.Status != jobStatusRunning {
+		return fmt.Errorf("job %s is %s; convergence cannot change", job.ID, job.Status)
+	}
+	return nil
+}
+
+// describe renders the scope for error messages.
+func (s convergenceScope) describe() string {
+	if !s.scoped() {
+		return ""
+	}
+	return fmt.Sprintf(" for job %s round %d", s.jobID, s.round)
+}
@@ -0,0 +1,230 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// DIDRecord stores a W3C DID Document together with its registry metadata.
+type DIDRecord struct {
+	DID         string `json:"did"`
+	Controller  string `json:"controller"`
+	Document    string `json:"document"`
+	Status      string `json:"status"`
+	Version     int    `json:"version"`
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+	Deactivated string `json:"deactivated,omitempty"`
+}
+
+const (
+	didPrefix            = "did:"
+	didStatusActive      = "ACTIVE"
+	didStatusDeactivated = "DEACTIVATED"
+)
+
+// CreateDID registers a DID Document. The invoking identity becomes the DID controller.
+func (c *GatewayContract) CreateDID(ctx contractapi.TransactionContextInterface, did, document string) (*DIDRecord, error) {
+	did, err := normalizeDID(did)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := canonicalDIDDocument(did, document)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := readDIDRecord(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("did %s already exists", did)
+	}
+	controller, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &DIDRecord{
+		DID:        did,
+		Controller: controller,
+		Document:   canonical,
+		Status:     didStatusActive,
+		Version:    1,
+		Created:    now,
+		Updated:    now,
+	}
+	if err := putDIDRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ResolveDID returns the stored DID Document, including deactivated ones.
+func (c *GatewayContract) ResolveDID(ctx contractapi.TransactionContextInterface, did string) (*DIDRecord, error) {
+	did, err := normalizeDID(did)
+	if err != nil {
+		return nil, err
+	}
+	record, err := readDIDRecord(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("did %s not found", did)
+	}
+	return record, nil
+}
+
+// UpdateDIDDocument replaces the DID Document; only the controller may update an active DID.
+func (c *GatewayContract) UpdateDIDDocument(ctx contractapi.TransactionContextInterface, did, document string) (*DIDRecord, error) {
+	record, err := c.controlledDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := canonicalDIDDocument(record.DID, document)
+	if err != nil {
+		return nil, err
+	}
+	record.Document = canonical
+	record.Version++
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record.Updated = now
+	if err := putDIDRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// DeactivateDID permanently deactivates a DID; the document is kept for resolution.
+func (c *GatewayContract) DeactivateDID(ctx contractapi.TransactionContextInterface, did string) (*DIDRecord, error) {
+	record, err := c.controlledDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record.Status = didStatusDeactivated
+	record.Version++
+	record.Updated = now
+	record.Deactivated = now
+	if err := putDIDRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListDIDs returns every registered DID in identifier order.
+func (c *GatewayContract) ListDIDs(ctx contractapi.TransactionContextInterface) ([]*DIDRecord, error) {
+	iter, err := ctx.GetStub().GetStateByRange(didPrefix, didPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dids: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*DIDRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record DIDRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+func (c *GatewayContract) controlledDID(ctx contractapi.TransactionContextInterface, did string) (*DIDRecord, error) {
+	record, err := c.ResolveDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == didStatusDeactivated {
+		return nil, fmt.Errorf("did %s is deactivated", record.DID)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	if clientID != record.Controller {
+		return nil, fmt.Errorf("caller is not the controller of %s", record.DID)
+	}
+	return record, nil
+}
+
+// canonicalDIDDocument checks the document is a JSON object whose id matches the DID and
+// re-encodes it with sorted keys so endorsing peers store identical bytes.
+func canonicalDIDDocument(did, document string) (string, error) {
+	if strings.TrimSpace(document) == "" {
+		return "", errors.New("did document is required")
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return "", fmt.Errorf("invalid did document: %w", err)
+	}
+	if _, ok := doc["@context"]; !ok {
+		return "", errors.New("did document must include @context")
+	}
+	id, _ := doc["id"].(string)
+	if strings.TrimSpace(id) != did {
+		return "", fmt.Errorf("did document id must be %s", did)
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(canonical), nil
+}
+
+func normalizeDID(did string) (string, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return "", errors.New("did is required")
+	}
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("%s is not a valid did", did)
+	}
+	return did, nil
+}
+
+func readDIDRecord(ctx contractapi.TransactionContextInterface, did string) (*DIDRecord, error) {
+	payload, err := ctx.GetStub().GetState(didKey(did))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read did record: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var record DIDRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func putDIDRecord(ctx contractapi.TransactionContextInterface, record *DIDRecord) error {
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, didKey(record.DID), bytes)
+}
+
+func didKey(did string) string {
+	return didPrefix + did
+}
@@ -0,0 +1,382 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ConvergenceDispute is an aggregator's challenge of a state convergence declaration. While
+// a dispute is OPEN the state's summary is frozen: it cannot be reset and the nation cannot
+// be declared converged in its scope. An admin resolves it by upholding the challenge, which
+// archives and clears the state's convergence, or by rejecting it, which keeps the summary.
+type ConvergenceDispute struct {
+	DisputeID    string `json:"dispute_id"`
+	StateID      string `json:"state_id"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	DeclaredBy   string `json:"declared_by"`
+	DeclaredAt   string `json:"declared_at"`
+	ChallengedBy string `json:"challenged_by"`
+	ChallengedAt string `json:"challenged_at"`
+	Resolution   string `json:"resolution_note,omitempty"`
+	ResolvedBy   string `json:"resolved_by,omitempty"`
+	ResolvedAt   string `json:"resolved_at,omitempty"`
+}
+
+// DisputePolicy holds how long after a state declaration it can be challenged.
+type DisputePolicy struct {
+	WindowSeconds int    `json:"window_seconds"`
+	UpdatedBy     string `json:"updated_by,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+}
+
+// Chaincode events emitted when a state declaration is challenged and when the dispute is
+// resolved.
+const (
+	EventConvergenceChallenged      = "ConvergenceChallenged"
+	EventConvergenceDisputeResolved = "ConvergenceDisputeResolved"
+)
+
+const (
+	disputeOpen     = "OPEN"
+	disputeUpheld   = "UPHELD"
+	disputeRejected = "REJECTED"
+
+	disputePrefix    = "convdispute:"
+	disputePolicyKey = "config:dispute-policy"
+	// Dispute indexes share the convergence scoping and key a state's disputes by ID.
+	stateDisputeType    = "dispute~state"
+	jobStateDisputeType = "dispute~job~state"
+	// defaultChallengeWindow applies until SetConvergenceChallengeWindow is called.
+	defaultChallengeWindow = 24 * 60 * 60
+)
+
+// ChallengeConvergence contests the legacy unscoped declaration of a state within the
+// challenge window. A state has at most one open dispute and a declarer cannot challenge its
+// own declaration.
+func (c *GatewayContract) ChallengeConvergence(ctx contractapi.TransactionContextInterface, stateID, reason string) (*ConvergenceDispute, error) {
+	return c.challengeConvergence(ctx, convergenceScope{}, stateID, reason)
+}
+
+// ChallengeConvergenceInRound contests a state's declaration for a job round.
+func (c *GatewayContract) ChallengeConvergenceInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID, reason string) (*ConvergenceDispute, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.challengeConvergence(ctx, scope, stateID, reason)
+}
+
+func (c *GatewayContract) challengeConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, reason string) (*ConvergenceDispute, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
+	stateID, err = normalizeIdentifier(stateID, "stateId")
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	key, err := stateSummaryKey(ctx, scope, stateID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state convergence: %w", err)
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("no convergence declared for state %s%s", stateID, scope.describe())
+	}
+	var summary ConvergenceSummary
+	if err := json.Unmarshal(existing, &summary); err != nil {
+		return nil, err
+	}
+	if summary.DeclaredBy == trainer.NodeID {
+		return nil, fmt.Errorf("node %s cannot challenge its own declaration", trainer.NodeID)
+	}
+	declaredAt, err := time.Parse(time.RFC3339, summary.DeclaredAt)
+	if err != nil {
+		return nil, fmt.Errorf("state %s declaration has an invalid timestamp: %w", stateID, err)
+	}
+	policy, err := readDisputePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy.WindowSeconds == 0 {
+		return nil, errors.New("convergence challenges are disabled")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	closes := declaredAt.Add(time.Duration(policy.WindowSeconds) * time.Second)
+	if now.After(closes) {
+		return nil, fmt.Errorf("challenge window for state %s closed at %s", stateID, closes.Format(time.RFC3339))
+	}
+	if err := requireNoOpenDispute(ctx, scope, stateID); err != nil {
+		return nil, err
+	}
+	dispute := &ConvergenceDispute{
+		DisputeID:    ctx.GetStub().GetTxID(),
+		StateID:      stateID,
+		JobID:        scope.jobID,
+		Round:        scope.round,
+		Reason:       reason,
+		Status:       disputeOpen,
+		DeclaredBy:   summary.DeclaredBy,
+		DeclaredAt:   summary.DeclaredAt,
+		ChallengedBy: trainer.NodeID,
+		ChallengedAt: now.Format(time.RFC3339),
+	}
+	if err := putDispute(ctx, dispute); err != nil {
+		return nil, err
+	}
+	indexKey, err := scope.disputeKey(ctx, stateID, dispute.DisputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, indexKey, []byte{0x00}); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventConvergenceChallenged,
+		Scope:       "state",
+		StateID:     stateID,
+		TargetID:    stateID,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		SubmittedBy: trainer.NodeID,
+		Timestamp:   dispute.ChallengedAt,
+	}); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// ResolveConvergenceDispute closes an open dispute. decision "uphold" archives and clears
+// the state's convergence so it can converge and declare again; "reject" keeps the
+// declaration. The gateway restricts callers to admins.
+func (c *GatewayContract) ResolveConvergenceDispute(ctx contractapi.TransactionContextInterface, disputeID, decision, note string) (*ConvergenceDispute, error) {
+	dispute, err := readDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != disputeOpen {
+		return nil, fmt.Errorf("dispute %s is already resolved as %s", dispute.DisputeID, dispute.Status)
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope := convergenceScope{jobID: dispute.JobID, round: dispute.Round}
+	switch strings.ToLower(strings.TrimSpace(decision)) {
+	case "uphold":
+		reason := fmt.Sprintf("dispute %s upheld", dispute.DisputeID)
+		if note = strings.TrimSpace(note); note != "" {
+			reason += ": " + note
+		}
+		if _, err := c.archiveConvergence(ctx, scope, "state", dispute.StateID, reason); err != nil {
+			return nil, err
+		}
+		dispute.Status = disputeUpheld
+	case "reject":
+		dispute.Status = disputeRejected
+	default:
+		return nil, errors.New(`decision must be "uphold" or "reject"`)
+	}
+	dispute.Resolution = strings.TrimSpace(note)
+	dispute.ResolvedBy = actor
+	dispute.ResolvedAt = now
+	if err := putDispute(ctx, dispute); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventConvergenceDisputeResolved,
+		Scope:       "state",
+		StateID:     dispute.StateID,
+		TargetID:    dispute.StateID,
+		JobID:       dispute.JobID,
+		Round:       dispute.Round,
+		SubmittedBy: actor,
+		Timestamp:   now,
+	}); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// ReadConvergenceDispute returns a dispute by ID.
+func (c *GatewayContract) ReadConvergenceDispute(ctx contractapi.TransactionContextInterface, disputeID string) (*ConvergenceDispute, error) {
+	return readDispute(ctx, disputeID)
+}
+
+// ListConvergenceDisputes returns every dispute of the legacy unscoped convergence, in state
+// order, open and resolved alike.
+func (c *GatewayContract) ListConvergenceDisputes(ctx contractapi.TransactionContextInterface) ([]*ConvergenceDispute, error) {
+	return listDisputes(ctx, convergenceScope{})
+}
+
+// ListConvergenceDisputesInRound returns every dispute of a job round.
+func (c *GatewayContract) ListConvergenceDisputesInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg string) ([]*ConvergenceDispute, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return listDisputes(ctx, scope)
+}
+
+// SetConvergenceChallengeWindow sets how many seconds after a state declaration it can be
+// challenged; "0" disables challenges.
+func (c *GatewayContract) SetConvergenceChallengeWindow(ctx contractapi.TransactionContextInterface, secondsArg string) (*DisputePolicy, error) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(secondsArg))
+	if err != nil || seconds < 0 {
+		return nil, errors.New("window must be a non-negative number of seconds")
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy := &DisputePolicy{
+		WindowSeconds: seconds,
+		UpdatedBy:     actor,
+		UpdatedAt:     now,
+	}
+	payload, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, disputePolicyKey, payload); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetConvergenceDisputePolicy returns the challenge window in force.
+func (c *GatewayContract) GetConvergenceDisputePolicy(ctx contractapi.TransactionContextInterface) (*DisputePolicy, error) {
+	return readDisputePolicy(ctx)
+}
+
+func readDisputePolicy(ctx contractapi.TransactionContextInterface) (*DisputePolicy, error) {
+	payload, err := ctx.GetStub().GetState(disputePolicyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dispute policy: %w", err)
+	}
+	policy := &DisputePolicy{WindowSeconds: defaultChallengeWindow}
+	if len(payload) == 0 {
+		return policy, nil
+	}
+	if err := json.Unmarshal(payload, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func readDispute(ctx contractapi.TransactionContextInterface, disputeID string) (*ConvergenceDispute, error) {
+	disputeID = strings.TrimSpace(disputeID)
+	if disputeID == "" {
+		return nil, errors.New("dispute identifier is required")
+	}
+	payload, err := ctx.GetStub().GetState(disputePrefix + disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dispute: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("dispute %s not found", disputeID)
+	}
+	var dispute ConvergenceDispute
+	if err := json.Unmarshal(payload, &dispute); err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func putDispute(ctx contractapi.TransactionContextInterface, dispute *ConvergenceDispute) error {
+	payload, err := json.Marshal(dispute)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, disputePrefix+dispute.DisputeID, payload)
+}
+
+// listDisputes reads the disputes of a scope, optionally of one state only.
+func listDisputes(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID ...string) ([]*ConvergenceDispute, error) {
+	iter, err := scope.partialDisputeKeys(ctx, stateID...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list convergence disputes: %w", err)
+	}
+	defer iter.Close()
+	disputes := make([]*ConvergenceDispute, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		parts, err := scope.splitKey(ctx, kv.Key)
+		if err != nil || len(parts) != 2 {
+			continue
+		}
+		dispute, err := readDispute(ctx, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, dispute)
+	}
+	return disputes, nil
+}
+
+// requireNoOpenDispute refuses to change a frozen state convergence. An empty stateID checks
+// every state of the scope.
+func requireNoOpenDispute(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID string) error {
+	var filter []string
+	if stateID != "" {
+		filter = []string{stateID}
+	}
+	disputes, err := listDisputes(ctx, scope, filter...)
+	if err != nil {
+		return err
+	}
+	for _, dispute := range disputes {
+		if dispute.Status == disputeOpen {
+			return fmt.Errorf("state %s convergence is disputed by %s%s", dispute.StateID, dispute.DisputeID, scope.describe())
+		}
+	}
+	return nil
+}
+
+func (s convergenceScope) disputeKey(ctx contractapi.TransactionContextInterface, stateID, disputeID string) (string, error) {
+	if !s.scoped() {
+		return ctx.GetStub().CreateCompositeKey(stateDisputeType, []string{stateID, disputeID})
+	}
+	return ctx.GetStub().CreateCompositeKey(jobStateDisputeType, append(s.prefix(), stateID, disputeID))
+}
+
+func (s convergenceScope) partialDisputeKeys(ctx contractapi.TransactionContextInterface, attrs ...string) (shim.StateQueryIteratorInterface, error) {
+	if !s.scoped() {
+		return ctx.GetStub().GetStateByPartialCompositeKey(stateDisputeType, attrs)
+	}
+	return ctx.GetStub().GetStateByPartialCompositeKey(jobStateDisputeType, append(s.prefix(), attrs...))
+}
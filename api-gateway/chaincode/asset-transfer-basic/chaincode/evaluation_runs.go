@@ -0,0 +1,114 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// EvaluationRun is one evaluation of a committed model on a dataset identified by its SHA-256.
+// Unlike validator evaluations, which count once per evaluator toward the consensus, runs are
+// an append-only history: an evaluator may rerun a model as often as it likes, and every run
+// stays on the ledger to justify why a state or nation model was selected.
+type EvaluationRun struct {
+	RunID       string             `json:"run_id"`
+	ModelID     string             `json:"model_id"`
+	Layer       string             `json:"layer"`
+	ScopeID     string             `json:"scope_id"`
+	JobID       string             `json:"job_id,omitempty"`
+	Round       int                `json:"round,omitempty"`
+	DatasetHash string             `json:"dataset_hash"`
+	Metrics     map[string]float64 `json:"metrics"`
+	Evaluator   string             `json:"evaluator"`
+	EvaluatedAt string             `json:"evaluated_at"`
+}
+
+// evaluationRunType keys runs by model, then timestamp and transaction ID, so a model's
+// history reads back oldest first.
+const evaluationRunType = "evalrun~model~at~tx"
+
+// RecordEvaluationRun appends the caller's evaluation of a model to its history. datasetHash
+// is the hex SHA-256 of the evaluation dataset; metricsArg is a JSON object of finite values.
+func (c *GatewayContract) RecordEvaluationRun(ctx contractapi.TransactionContextInterface, modelID, datasetHash, metricsArg string) (*EvaluationRun, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	datasetHash = strings.ToLower(strings.TrimSpace(datasetHash))
+	if decoded, err := hex.DecodeString(datasetHash); err != nil || len(decoded) != 32 {
+		return nil, errors.New("datasetHash must be a hex SHA-256 digest")
+	}
+	metrics, err := parseEvaluationMetrics(metricsArg)
+	if err != nil {
+		return nil, err
+	}
+	model, err := readModelRecord(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run := &EvaluationRun{
+		RunID:       ctx.GetStub().GetTxID(),
+		ModelID:     model.ID,
+		Layer:       model.Layer,
+		ScopeID:     model.ScopeID,
+		JobID:       model.JobID,
+		Round:       model.RoundNumber,
+		DatasetHash: datasetHash,
+		Metrics:     metrics,
+		Evaluator:   trainer.NodeID,
+		EvaluatedAt: now,
+	}
+	bytes, err := json.Marshal(run)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(evaluationRunType, []string{model.ID, now, run.RunID})
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ListEvaluationRuns returns the evaluation history of a model, oldest first.
+func (c *GatewayContract) ListEvaluationRuns(ctx contractapi.TransactionContextInterface, modelID string) ([]*EvaluationRun, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(evaluationRunType, []string{modelID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evaluation runs: %w", err)
+	}
+	defer iter.Close()
+	runs := make([]*EvaluationRun, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var run EvaluationRun
+		if err := json.Unmarshal(kv.Value, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,269 @@
+package chaincode
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// EvaluationRecord is an independent evaluation of a committed model by a validator node.
+type EvaluationRecord struct {
+	ModelID     string             `json:"model_id"`
+	DatasetID   string             `json:"dataset_id"`
+	Evaluator   string             `json:"evaluator"`
+	Metrics     map[string]float64 `json:"metrics"`
+	Signature   string             `json:"signature"`
+	SubmittedAt string             `json:"submitted_at"`
+}
+
+// MetricSummary aggregates a single metric across evaluations.
+type MetricSummary struct {
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Count  int     `json:"count"`
+}
+
+// EvaluationConsensus summarizes every evaluation recorded for a model.
+type EvaluationConsensus struct {
+	ModelID    string                    `json:"model_id"`
+	Metric     string                    `json:"metric"`
+	Score      float64                   `json:"score"`
+	Count      int                       `json:"count"`
+	Evaluators []string                  `json:"evaluators"`
+	Datasets   []string                  `json:"datasets"`
+	Metrics    map[string]*MetricSummary `json:"metrics"`
+}
+
+// evaluationMessage is the document validators sign; encoding/json sorts map keys so it is canonical.
+type evaluationMessage struct {
+	ModelID   string             `json:"model_id"`
+	DatasetID string             `json:"dataset_id"`
+	Metrics   map[string]float64 `json:"metrics"`
+}
+
+const (
+	evaluationPrefix      = "eval:"
+	defaultConsensusScore = "accuracy"
+)
+
+// SubmitEvaluation records a validator's signed evaluation of a committed model.
+// Each evaluator may evaluate a given model once.
+func (c *GatewayContract) SubmitEvaluation(ctx contractapi.TransactionContextInterface, modelID, datasetID, metricsArg, signature string) (*EvaluationRecord, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	datasetID = strings.TrimSpace(datasetID)
+	if datasetID == "" {
+		return nil, errors.New("dataset identifier is required")
+	}
+	model, err := ctx.GetStub().GetState(modelKey(modelID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model record: %w", err)
+	}
+	if len(model) == 0 {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	metrics, err := parseEvaluationMetrics(metricsArg)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyEvaluationSignature(trainer.PublicKey, &evaluationMessage{ModelID: modelID, DatasetID: datasetID, Metrics: metrics}, signature); err != nil {
+		return nil, err
+	}
+	key := evaluationKey(modelID, trainer.NodeID)
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read evaluation: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("evaluator %s already evaluated model %s", trainer.NodeID, modelID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &EvaluationRecord{
+		ModelID:     modelID,
+		DatasetID:   datasetID,
+		Evaluator:   trainer.NodeID,
+		Metrics:     metrics,
+		Signature:   signature,
+		SubmittedAt: now,
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListEvaluations returns every evaluation recorded for a model.
+func (c *GatewayContract) ListEvaluations(ctx contractapi.TransactionContextInterface, modelID string) ([]*EvaluationRecord, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	return c.listEvaluations(ctx, modelID)
+}
+
+// GetEvaluationConsensus aggregates all evaluations of a model; the score is the median of the
+// requested metric (accuracy by default) so a single outlying validator cannot move it.
+func (c *GatewayContract) GetEvaluationConsensus(ctx contractapi.TransactionContextInterface, modelID, metric string) (*EvaluationConsensus, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	return c.evaluationConsensus(ctx, modelID, metric)
+}
+
+func (c *GatewayContract) evaluationConsensus(ctx contractapi.TransactionContextInterface, modelID, metric string) (*EvaluationConsensus, error) {
+	metric = strings.TrimSpace(metric)
+	if metric == "" {
+		metric = defaultConsensusScore
+	}
+	records, err := c.listEvaluations(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	consensus := &EvaluationConsensus{
+		ModelID:    modelID,
+		Metric:     metric,
+		Count:      len(records),
+		Evaluators: make([]string, 0, len(records)),
+		Datasets:   []string{},
+		Metrics:    map[string]*MetricSummary{},
+	}
+	values := map[string][]float64{}
+	datasets := map[string]struct{}{}
+	for _, record := range records {
+		consensus.Evaluators = append(consensus.Evaluators, record.Evaluator)
+		if _, seen := datasets[record.DatasetID]; !seen {
+			datasets[record.DatasetID] = struct{}{}
+			consensus.Datasets = append(consensus.Datasets, record.DatasetID)
+		}
+		for name, value := range record.Metrics {
+			values[name] = append(values[name], value)
+		}
+	}
+	sort.Strings(consensus.Datasets)
+	for name, samples := range values {
+		consensus.Metrics[name] = summarizeMetric(samples)
+	}
+	if summary, ok := consensus.Metrics[metric]; ok {
+		consensus.Score = summary.Median
+	}
+	return consensus, nil
+}
+
+func (c *GatewayContract) listEvaluations(ctx contractapi.TransactionContextInterface, modelID string) ([]*EvaluationRecord, error) {
+	prefix := evaluationPrefix + modelID + ":"
+	iter, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evaluations: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*EvaluationRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record EvaluationRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// parseEvaluationMetrics decodes a JSON object of metric names to finite values.
+func parseEvaluationMetrics(metricsArg string) (map[string]float64, error) {
+	var metrics map[string]float64
+	if err := json.Unmarshal([]byte(metricsArg), &metrics); err != nil {
+		return nil, fmt.Errorf("invalid metrics: %w", err)
+	}
+	if len(metrics) == 0 {
+		return nil, errors.New("at least one metric is required")
+	}
+	for name, value := range metrics {
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("metric names must not be empty")
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("metric %s must be a finite number", name)
+		}
+	}
+	return metrics, nil
+}
+
+func summarizeMetric(samples []float64) *MetricSummary {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	summary := &MetricSummary{
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Count: len(sorted),
+	}
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	summary.Mean = sum / float64(len(sorted))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		summary.Median = (sorted[mid-1] + sorted[mid]) / 2
+	} else {
+		summary.Median = sorted[mid]
+	}
+	return summary
+}
+
+func verifyEvaluationSignature(publicKey string, message *evaluationMessage, signature string) error {
+	if strings.TrimSpace(signature) == "" {
+		return errors.New("signature is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("evaluator public key is invalid")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), payload, sig) {
+		return errors.New("evaluation signature does not match evaluator public key")
+	}
+	return nil
+}
+
+func evaluationKey(modelID, evaluator string) string {
+	return fmt.Sprintf("%s%s:%s", evaluationPrefix, modelID, evaluator)
+}
@@ -0,0 +1,54 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Chaincode event names emitted for convergence milestones.
+const (
+	EventStateClusterConverged     = "StateClusterConverged"
+	EventNationStateConverged      = "NationStateConverged"
+	EventStateConvergenceDeclared  = "StateConvergenceDeclared"
+	EventNationConvergenceDeclared = "NationConvergenceDeclared"
+)
+
+// EventRoundDeadlineExpired is emitted when ExpireRound closes a round at its deadline.
+const EventRoundDeadlineExpired = "RoundDeadlineExpired"
+
+// EventMembershipEpochApplied is emitted when ApplyClusteringPlan opens a membership epoch.
+const EventMembershipEpochApplied = "MembershipEpochApplied"
+
+// ConvergenceEvent is the payload attached to convergence chaincode events.
+type ConvergenceEvent struct {
+	Event       string `json:"event"`
+	Scope       string `json:"scope"`
+	StateID     string `json:"state_id,omitempty"`
+	ClusterID   string `json:"cluster_id,omitempty"`
+	TargetID    string `json:"target_id,omitempty"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	SubmittedBy string `json:"submitted_by"`
+	TxID        string `json:"tx_id"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// emitEvent attaches a JSON payload to the transaction. Fabric keeps only the last
+// event set per transaction, so each function emits at most once.
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, payload any) error {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetEvent(name, bytes); err != nil {
+		return fmt.Errorf("failed to emit %s event: %w", name, err)
+	}
+	return nil
+}
+
+func emitConvergenceEvent(ctx contractapi.TransactionContextInterface, event *ConvergenceEvent) error {
+	event.TxID = ctx.GetStub().GetTxID()
+	return emitEvent(ctx, event.Event, event)
+}
@@ -0,0 +1,7 @@
+package chaincode
+
+// Exported for the chaincode_test package.
+var (
+	ParseStateConvergenceKey  = parseStateConvergenceKey
+	ParseNationConvergenceKey = parseNationConvergenceKey
+)
@@ -0,0 +1,318 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ModelFlag marks a model submission as suspected malicious.
+type ModelFlag struct {
+	ModelID      string `json:"model_id"`
+	NodeID       string `json:"node_id"`
+	Reason       string `json:"reason"`
+	EvidenceHash string `json:"evidence_hash,omitempty"`
+	FlaggedBy    string `json:"flagged_by"`
+	TxID         string `json:"tx_id"`
+	FlaggedAt    string `json:"flagged_at"`
+}
+
+// FlagTally counts the flags raised against a node's submissions. Flags counts towards the
+// suspension threshold and restarts when a suspension is lifted; TotalFlags never does.
+type FlagTally struct {
+	NodeID          string `json:"node_id"`
+	Flags           int    `json:"flags"`
+	TotalFlags      int    `json:"total_flags"`
+	Suspended       bool   `json:"suspended"`
+	SuspendedAt     string `json:"suspended_at,omitempty"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedBy string `json:"status_changed_by,omitempty"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// FlagPolicy holds the flag count at which a node is suspended; 0 disables suspension.
+type FlagPolicy struct {
+	Threshold int    `json:"threshold"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+const (
+	flagTallyPrefix = "flagtally:"
+	flagPolicyKey   = "config:flag-policy"
+	// flagIndexType keys flags by flagged node, model and flagger, so a flagger can flag a
+	// model once and a node's flags list with one range scan.
+	flagIndexType = "flag~node~model~flagger"
+	// defaultFlagThreshold applies until SetFlagThreshold is called.
+	defaultFlagThreshold = 3
+)
+
+var errTrainerSuspended = errors.New("trainer suspended")
+
+// FlagModel records that the caller suspects a model submission is malicious. evidenceHash
+// optionally points at the off-chain evidence. The flag counts against the model's owner and
+// suspends it once its tally reaches the flag threshold; suspended nodes are rejected by every
+// trainer-gated transaction until ReinstateNode.
+func (c *GatewayContract) FlagModel(ctx contractapi.TransactionContextInterface, modelID, reason, evidenceHash string) (*ModelFlag, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	model, err := readModelRecord(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	flagger, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if flagger == model.Owner {
+		return nil, errors.New("a node cannot flag its own model")
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(flagIndexType, []string{model.Owner, modelID, flagger})
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flag: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("model %s already flagged by %s", modelID, flagger)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flag := &ModelFlag{
+		ModelID:      modelID,
+		NodeID:       model.Owner,
+		Reason:       reason,
+		EvidenceHash: strings.ToLower(strings.TrimSpace(evidenceHash)),
+		FlaggedBy:    flagger,
+		TxID:         ctx.GetStub().GetTxID(),
+		FlaggedAt:    now,
+	}
+	payload, err := json.Marshal(flag)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, indexKey, payload); err != nil {
+		return nil, err
+	}
+
+	tally, err := readFlagTally(ctx, model.Owner)
+	if err != nil {
+		return nil, err
+	}
+	tally.Flags++
+	tally.TotalFlags++
+	tally.UpdatedAt = now
+	policy, err := readFlagPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !tally.Suspended && policy.Threshold > 0 && tally.Flags >= policy.Threshold {
+		tally.Suspended = true
+		tally.SuspendedAt = now
+		tally.StatusReason = fmt.Sprintf("%d flags reached the suspension threshold", tally.Flags)
+		tally.StatusChangedBy = flagger
+	}
+	if err := putFlagTally(ctx, tally); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// ListModelFlags returns the flags raised against a node's models, optionally narrowed to
+// one model.
+func (c *GatewayContract) ListModelFlags(ctx contractapi.TransactionContextInterface, nodeID, modelID string) ([]*ModelFlag, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	modelID = strings.TrimSpace(modelID)
+	if nodeID == "" {
+		return nil, errors.New("node identifier is required")
+	}
+	attributes := []string{nodeID}
+	if modelID != "" {
+		attributes = append(attributes, modelID)
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(flagIndexType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flags: %w", err)
+	}
+	defer iter.Close()
+	flags := make([]*ModelFlag, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var flag ModelFlag
+		if err := json.Unmarshal(kv.Value, &flag); err != nil {
+			return nil, err
+		}
+		flags = append(flags, &flag)
+	}
+	return flags, nil
+}
+
+// GetFlagTally returns a node's flag tally; nodes that were never flagged have an empty one.
+func (c *GatewayContract) GetFlagTally(ctx contractapi.TransactionContextInterface, nodeID string) (*FlagTally, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, errors.New("node identifier is required")
+	}
+	return readFlagTally(ctx, nodeID)
+}
+
+// ListFlagTallies returns the tallies of every flagged node in node order.
+func (c *GatewayContract) ListFlagTallies(ctx contractapi.TransactionContextInterface) ([]*FlagTally, error) {
+	iter, err := ctx.GetStub().GetStateByRange(flagTallyPrefix, flagTallyPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flag tallies: %w", err)
+	}
+	defer iter.Close()
+	tallies := make([]*FlagTally, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var tally FlagTally
+		if err := json.Unmarshal(kv.Value, &tally); err != nil {
+			return nil, err
+		}
+		tallies = append(tallies, &tally)
+	}
+	return tallies, nil
+}
+
+// ReinstateNode lifts a node's suspension and restarts its count towards the threshold. The
+// flags themselves stay on the ledger.
+func (c *GatewayContract) ReinstateNode(ctx contractapi.TransactionContextInterface, nodeID, reason string) (*FlagTally, error) {
+	nodeID = strings.TrimSpace(nodeID)
+	if nodeID == "" {
+		return nil, errors.New("node identifier is required")
+	}
+	tally, err := readFlagTally(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if !tally.Suspended {
+		return nil, fmt.Errorf("node %s is not suspended", nodeID)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	tally.Flags = 0
+	tally.Suspended = false
+	tally.SuspendedAt = ""
+	tally.StatusReason = strings.TrimSpace(reason)
+	tally.StatusChangedBy = clientID
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tally.UpdatedAt = now
+	if err := putFlagTally(ctx, tally); err != nil {
+		return nil, err
+	}
+	return tally, nil
+}
+
+// SetFlagThreshold sets the flag count at which nodes are suspended; "0" disables
+// suspension. Nodes already past a lowered threshold are suspended on their next flag.
+func (c *GatewayContract) SetFlagThreshold(ctx contractapi.TransactionContextInterface, thresholdArg string) (*FlagPolicy, error) {
+	threshold, err := strconv.Atoi(strings.TrimSpace(thresholdArg))
+	if err != nil || threshold < 0 {
+		return nil, errors.New("threshold must be a non-negative integer")
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy := &FlagPolicy{
+		Threshold: threshold,
+		UpdatedBy: clientID,
+		UpdatedAt: now,
+	}
+	payload, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, flagPolicyKey, payload); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetFlagPolicy returns the flag threshold in force.
+func (c *GatewayContract) GetFlagPolicy(ctx contractapi.TransactionContextInterface) (*FlagPolicy, error) {
+	return readFlagPolicy(ctx)
+}
+
+func readFlagPolicy(ctx contractapi.TransactionContextInterface) (*FlagPolicy, error) {
+	payload, err := ctx.GetStub().GetState(flagPolicyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flag policy: %w", err)
+	}
+	policy := &FlagPolicy{Threshold: defaultFlagThreshold}
+	if len(payload) == 0 {
+		return policy, nil
+	}
+	if err := json.Unmarshal(payload, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func readFlagTally(ctx contractapi.TransactionContextInterface, nodeID string) (*FlagTally, error) {
+	payload, err := ctx.GetStub().GetState(flagTallyPrefix + nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flag tally: %w", err)
+	}
+	tally := &FlagTally{NodeID: nodeID}
+	if len(payload) == 0 {
+		return tally, nil
+	}
+	if err := json.Unmarshal(payload, tally); err != nil {
+		return nil, err
+	}
+	return tally, nil
+}
+
+func putFlagTally(ctx contractapi.TransactionContextInterface, tally *FlagTally) error {
+	payload, err := json.Marshal(tally)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, flagTallyPrefix+tally.NodeID, payload)
+}
+
+// isNodeSuspended reports whether a node's flag tally has it suspended.
+func isNodeSuspended(ctx contractapi.TransactionContextInterface, nodeID string) (bool, error) {
+	if nodeID == "" {
+		return false, nil
+	}
+	tally, err := readFlagTally(ctx, nodeID)
+	if err != nil {
+		return false, err
+	}
+	return tally.Suspended, nil
+}
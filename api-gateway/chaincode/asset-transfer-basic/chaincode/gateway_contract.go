@@ -27,18 +27,28 @@ type Trainer struct {
 	PublicKey  string `json:"public_key"`
 	Status     string `json:"status"`
 	Registered string `json:"registered_at"`
+	// WhitelistStatus mirrors the status of the trainer's whitelist entry once an admin
+	// deactivated or removed it; trainer calls are refused while it is set.
+	WhitelistStatus string `json:"whitelist_status,omitempty"`
 }
 
 // WhitelistEntry captures the trainer whitelist state.
 type WhitelistEntry struct {
-	JWTSub     string `json:"jwt_sub"`
-	DID        string `json:"did"`
-	NodeID     string `json:"node_id"`
-	State      string `json:"state,omitempty"`
-	Cluster    string `json:"cluster,omitempty"`
-	VCHash     string `json:"vc_hash"`
-	PublicKey  string `json:"public_key"`
-	Registered string `json:"registered_at"`
+	JWTSub       string               `json:"jwt_sub"`
+	DID          string               `json:"did"`
+	NodeID       string               `json:"node_id"`
+	State        string               `json:"state,omitempty"`
+	Cluster      string               `json:"cluster,omitempty"`
+	VCHash       string               `json:"vc_hash"`
+	PublicKey    string               `json:"public_key"`
+	Registered   string               `json:"registered_at"`
+	Capabilities *TrainerCapabilities `json:"capabilities,omitempty"`
+	// Status is active or deactivated; StatusReason, StatusChangedBy and StatusChangedAt
+	// describe the last change made through DeactivateWhitelistEntry or ReactivateWhitelistEntry.
+	Status          string `json:"status,omitempty"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedBy string `json:"status_changed_by,omitempty"`
+	StatusChangedAt string `json:"status_changed_at,omitempty"`
 }
 
 // DataRecord describes committed payloads.
@@ -47,16 +57,30 @@ type DataRecord struct {
 	Owner       string `json:"owner"`
 	Payload     string `json:"payload"`
 	SubmittedAt string `json:"submitted_at"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
 }
 
 // ModelRecord describes a scoped model reference.
 type ModelRecord struct {
-	ID          string `json:"id"`
-	Layer       string `json:"layer"`
-	ScopeID     string `json:"scope_id"`
-	Owner       string `json:"owner"`
-	Payload     string `json:"payload"`
-	SubmittedAt string `json:"submitted_at"`
+	ID             string   `json:"id"`
+	Layer          string   `json:"layer"`
+	ScopeID        string   `json:"scope_id"`
+	Owner          string   `json:"owner"`
+	Payload        string   `json:"payload"`
+	SubmittedAt    string   `json:"submitted_at"`
+	JobID          string   `json:"job_id,omitempty"`
+	RoundNumber    int      `json:"round,omitempty"`
+	ParentModelIDs []string `json:"parent_model_ids,omitempty"`
+	ModelHash      string   `json:"model_hash,omitempty"`
+	Signature      string   `json:"signature,omitempty"`
+	PayloadHash    string   `json:"payload_hash,omitempty"`
+	// Metrics holds the training results reported with the model (accuracy, loss,
+	// num_samples, ...); Hyperparameters is the JSON object the model was trained with.
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Hyperparameters string             `json:"hyperparameters,omitempty"`
+	// Epoch is the membership epoch the model was committed in.
+	Epoch int `json:"epoch,omitempty"`
 }
 
 // ModelListPage represents a single page of model references.
@@ -85,6 +109,9 @@ type ConvergenceRecord struct {
 	SourceID    string `json:"source_id"`
 	Payload     string `json:"payload"`
 	SubmittedAt string `json:"submitted_at"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	Epoch       int    `json:"epoch,omitempty"`
 }
 
 // ConvergenceSummary declares that a scope is fully converged.
@@ -94,6 +121,8 @@ type ConvergenceSummary struct {
 	DeclaredBy string `json:"declared_by"`
 	DeclaredAt string `json:"declared_at"`
 	Payload    string `json:"payload"`
+	JobID      string `json:"job_id,omitempty"`
+	Round      int    `json:"round,omitempty"`
 }
 
 // StateConvergence aggregates cluster convergence states for a state.
@@ -118,6 +147,15 @@ const (
 	nationConvPrefix   = "conv:nation:"
 	clusterSuffix      = ":cluster:"
 	stateSummarySuffix = ":summary"
+
+	// Composite key object types. Model records stay under model:<id>; the index
+	// lets ListModels read only the matching layer/scope.
+	modelIndexType = "model~layer~scope~round~id"
+	stateConvType  = "conv~state"
+	nationConvType = "conv~nation"
+	// Job/round scoped convergence records are prefixed with the job ID and padded round.
+	jobStateConvType  = "conv~job~state"
+	jobNationConvType = "conv~job~nation"
 )
 
 // InitLedger is present for compatibility with the bootstrap script.
@@ -139,12 +177,23 @@ func (c *GatewayContract) RegisterTrainer(ctx contractapi.TransactionContextInte
 	if strings.TrimSpace(publicKey) == "" {
 		return errors.New("publicKey is required")
 	}
+	revoked, err := isVCRevoked(ctx, vcHash)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errTrainerRevoked
+	}
 	state = strings.TrimSpace(state)
 	cluster = strings.TrimSpace(cluster)
 	clientID, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
 		return fmt.Errorf("failed to resolve client identity: %w", err)
 	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
 	trainer := &Trainer{
 		ClientID:   clientID,
 		DID:        did,
@@ -154,20 +203,20 @@ func (c *GatewayContract) RegisterTrainer(ctx contractapi.TransactionContextInte
 		VCHash:     vcHash,
 		PublicKey:  publicKey,
 		Status:     "AUTHORIZED",
-		Registered: time.Now().UTC().Format(time.RFC3339),
+		Registered: now,
 	}
 	payload, err := json.Marshal(trainer)
 	if err != nil {
 		return err
 	}
-	return ctx.GetStub().PutState(trainerKey(clientID), payload)
+	return putState(ctx, trainerKey(clientID), payload)
 }
 
 // IsTrainerAuthorized reports whether the invoker identity is registered and active.
 func (c *GatewayContract) IsTrainerAuthorized(ctx contractapi.TransactionContextInterface) (bool, error) {
 	_, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
-		if errors.Is(err, errTrainerUnauthorized) {
+		if errors.Is(err, errTrainerUnauthorized) || errors.Is(err, errTrainerRevoked) || errors.Is(err, errTrainerDeactivated) {
 			return false, nil
 		}
 		return false, err
@@ -184,17 +233,28 @@ func (c *GatewayContract) CommitData(ctx contractapi.TransactionContextInterface
 	if strings.TrimSpace(dataID) == "" {
 		return nil, errors.New("data identifier is required")
 	}
+	existing, err := ctx.GetStub().GetState(dataKey(dataID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data record: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("data %s already exists", dataID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	record := &DataRecord{
 		ID:          dataID,
 		Owner:       trainer.NodeID,
 		Payload:     payload,
-		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		SubmittedAt: now,
 	}
 	bytes, err := json.Marshal(record)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(dataKey(dataID), bytes); err != nil {
+	if err := putState(ctx, dataKey(dataID), bytes); err != nil {
 		return nil, err
 	}
 	return record, nil
@@ -223,12 +283,23 @@ func (c *GatewayContract) ReadData(ctx contractapi.TransactionContextInterface,
 	return &record, nil
 }
 
-// CommitModel stores a model reference scoped to a layer/scope identifier.
-func (c *GatewayContract) CommitModel(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload string) (*ModelRecord, error) {
+// CommitModel stores a model reference scoped to a layer/scope identifier. parentModelIDs is an
+// optional JSON array naming the models this one was aggregated from; each must already exist.
+func (c *GatewayContract) CommitModel(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload, parentModelIDs string) (*ModelRecord, error) {
+	return c.commitModel(ctx, dataID, layer, scopeID, payload, parentModelIDs, "", 0, nil, nil)
+}
+
+// commitModel writes a model reference. A non-nil attestation is verified against the
+// caller's registered public key and stored on the record, as is a non-nil metadata.
+func (c *GatewayContract) commitModel(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload, parentModelIDs, jobID string, round int, attestation *modelAttestation, metadata *modelMetadata) (*ModelRecord, error) {
 	trainer, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
 		return nil, err
 	}
+	parents, err := parseParentModelIDs(ctx, dataID, parentModelIDs)
+	if err != nil {
+		return nil, err
+	}
 	id := strings.TrimSpace(dataID)
 	if id == "" {
 		return nil, errors.New("data identifier is required")
@@ -241,19 +312,61 @@ func (c *GatewayContract) CommitModel(ctx contractapi.TransactionContextInterfac
 	if scope == "" {
 		return nil, errors.New("scope identifier is required")
 	}
+	if attestation != nil {
+		if err := attestation.verify(trainer.PublicKey, normalizedLayer, scope); err != nil {
+			return nil, err
+		}
+	}
+	membership, err := currentMembershipEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireEpochClusterMember(membership.Epoch, trainer, normalizedLayer, scope); err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(modelKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model record: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("model %s already exists", id)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	record := &ModelRecord{
-		ID:          id,
-		Layer:       normalizedLayer,
-		ScopeID:     scope,
-		Owner:       trainer.NodeID,
-		Payload:     payload,
-		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		ID:             id,
+		Layer:          normalizedLayer,
+		ScopeID:        scope,
+		Owner:          trainer.NodeID,
+		Payload:        payload,
+		SubmittedAt:    now,
+		JobID:          jobID,
+		RoundNumber:    round,
+		ParentModelIDs: parents,
+		PayloadHash:    payloadDigest(payload),
+		Epoch:          membership.Epoch,
+	}
+	if attestation != nil {
+		record.ModelHash = attestation.ModelHash
+		record.Signature = attestation.Signature
+	}
+	if metadata != nil {
+		record.Metrics = metadata.Metrics
+		record.Hyperparameters = metadata.hyperparameters()
 	}
 	bytes, err := json.Marshal(record)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(modelKey(id), bytes); err != nil {
+	if err := putState(ctx, modelKey(id), bytes); err != nil {
+		return nil, err
+	}
+	if err := putModelIndex(ctx, record); err != nil {
+		return nil, err
+	}
+	if err := putModelHashIndex(ctx, record); err != nil {
 		return nil, err
 	}
 	return record, nil
@@ -312,11 +425,14 @@ func (c *GatewayContract) ListModels(ctx contractapi.TransactionContextInterface
 		}
 		perPage = parsed
 	}
-	scopeFilter := strings.TrimSpace(scopeID)
+	attributes := []string{layerFilter}
+	if scopeFilter := strings.ToLower(strings.TrimSpace(scopeID)); scopeFilter != "" {
+		attributes = append(attributes, scopeFilter)
+	}
 	startIndex := (page - 1) * perPage
 	items := make([]*ModelRecord, 0, perPage)
 
-	iter, err := ctx.GetStub().GetStateByRange(modelPrefix, modelPrefix+"~")
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, attributes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list models: %w", err)
 	}
@@ -328,28 +444,22 @@ func (c *GatewayContract) ListModels(ctx contractapi.TransactionContextInterface
 		if err != nil {
 			return nil, fmt.Errorf("failed to advance iterator: %w", err)
 		}
-		var record ModelRecord
-		if err := json.Unmarshal(kv.Value, &record); err != nil {
-			return nil, err
-		}
-		if record.ID == "" {
-			continue
-		}
-		if !strings.EqualFold(record.Layer, layerFilter) {
+		matched++
+		if matched <= startIndex || len(items) >= perPage {
 			continue
 		}
-		if scopeFilter != "" && !strings.EqualFold(record.ScopeID, scopeFilter) {
-			continue
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
 		}
-		matched++
-		if matched <= startIndex {
-			continue
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
 		}
-		if len(items) >= perPage {
-			continue
+		if record == nil {
+			return nil, fmt.Errorf("model index references missing model %s", parts[3])
 		}
-		copy := record
-		items = append(items, &copy)
+		items = append(items, record)
 	}
 
 	hasMore := matched > startIndex+len(items)
@@ -362,8 +472,9 @@ func (c *GatewayContract) ListModels(ctx contractapi.TransactionContextInterface
 	}, nil
 }
 
-// RecordWhitelistEntry upserts whitelist metadata keyed by JWT subject.
-func (c *GatewayContract) RecordWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub, did, nodeID, state, cluster, vcHash, publicKey, registered string) error {
+// RecordWhitelistEntry upserts whitelist metadata keyed by JWT subject. Updates keep the
+// entry's status; subjects removed with RemoveWhitelistEntry are refused.
+func (c *GatewayContract) RecordWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub, did, nodeID, state, cluster, vcHash, publicKey, registered, capabilities string) error {
 	jwtSub = strings.TrimSpace(jwtSub)
 	if jwtSub == "" {
 		return errors.New("jwtSub is required")
@@ -382,25 +493,58 @@ func (c *GatewayContract) RecordWhitelistEntry(ctx contractapi.TransactionContex
 	if strings.TrimSpace(publicKey) == "" {
 		return errors.New("publicKey is required")
 	}
+	caps, err := parseCapabilities(capabilities)
+	if err != nil {
+		return err
+	}
 	registeredAt := strings.TrimSpace(registered)
 	if registeredAt == "" {
-		registeredAt = time.Now().UTC().Format(time.RFC3339)
+		now, err := txTimestamp(ctx)
+		if err != nil {
+			return err
+		}
+		registeredAt = now
 	}
 	entry := &WhitelistEntry{
-		JWTSub:     strings.ToLower(jwtSub),
-		DID:        did,
-		NodeID:     nodeID,
-		State:      state,
-		Cluster:    cluster,
-		VCHash:     vcHash,
-		PublicKey:  publicKey,
-		Registered: registeredAt,
+		JWTSub:       strings.ToLower(jwtSub),
+		DID:          did,
+		NodeID:       nodeID,
+		State:        state,
+		Cluster:      cluster,
+		VCHash:       vcHash,
+		PublicKey:    publicKey,
+		Registered:   registeredAt,
+		Capabilities: caps,
+		Status:       whitelistStatusActive,
+	}
+	if tombstone, err := ctx.GetStub().GetState(whitelistTombstoneKey(entry.JWTSub)); err != nil {
+		return fmt.Errorf("failed to read whitelist tombstone: %w", err)
+	} else if tombstone != nil {
+		return fmt.Errorf("whitelist entry %s was removed", entry.JWTSub)
+	}
+	var previous *WhitelistEntry
+	if raw, err := ctx.GetStub().GetState(whitelistKey(entry.JWTSub)); err != nil {
+		return fmt.Errorf("failed to read whitelist entry: %w", err)
+	} else if raw != nil {
+		previous = &WhitelistEntry{}
+		if err := json.Unmarshal(raw, previous); err != nil {
+			previous = nil
+		}
+	}
+	if previous != nil && previous.Status != "" {
+		entry.Status = previous.Status
+		entry.StatusReason = previous.StatusReason
+		entry.StatusChangedBy = previous.StatusChangedBy
+		entry.StatusChangedAt = previous.StatusChangedAt
 	}
 	payload, err := json.Marshal(entry)
 	if err != nil {
 		return err
 	}
-	return ctx.GetStub().PutState(whitelistKey(entry.JWTSub), payload)
+	if err := putState(ctx, whitelistKey(entry.JWTSub), payload); err != nil {
+		return err
+	}
+	return putWhitelistIndex(ctx, entry, previous)
 }
 
 // ListWhitelist returns trainers recorded on-chain.
@@ -470,10 +614,17 @@ func (c *GatewayContract) ListWhitelist(ctx contractapi.TransactionContextInterf
 
 // CommitStateClusterConvergence records convergence data for a specific cluster within a state.
 func (c *GatewayContract) CommitStateClusterConvergence(ctx contractapi.TransactionContextInterface, stateID, clusterID, payload string) (*ConvergenceRecord, error) {
+	return c.commitStateClusterConvergence(ctx, convergenceScope{}, stateID, clusterID, payload)
+}
+
+func (c *GatewayContract) commitStateClusterConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, clusterID, payload string) (*ConvergenceRecord, error) {
 	trainer, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
 	stateID, err = normalizeIdentifier(stateID, "stateId")
 	if err != nil {
 		return nil, err
@@ -485,19 +636,49 @@ func (c *GatewayContract) CommitStateClusterConvergence(ctx contractapi.Transact
 	if strings.TrimSpace(payload) == "" {
 		return nil, errors.New("payload is required")
 	}
+	membership, err := currentMembershipEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireEpochStateCluster(ctx, membership.Epoch, stateID, clusterID); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	record := &ConvergenceRecord{
 		Scope:       "state",
 		StateID:     stateID,
 		ClusterID:   clusterID,
 		SourceID:    trainer.NodeID,
 		Payload:     payload,
-		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		SubmittedAt: now,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		Epoch:       membership.Epoch,
 	}
 	bytes, err := json.Marshal(record)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(stateClusterKey(stateID, clusterID), bytes); err != nil {
+	key, err := stateClusterKey(ctx, scope, stateID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventStateClusterConverged,
+		Scope:       record.Scope,
+		StateID:     stateID,
+		ClusterID:   clusterID,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		SubmittedBy: trainer.NodeID,
+		Timestamp:   record.SubmittedAt,
+	}); err != nil {
 		return nil, err
 	}
 	return record, nil
@@ -505,10 +686,17 @@ func (c *GatewayContract) CommitStateClusterConvergence(ctx contractapi.Transact
 
 // CommitNationStateConvergence records convergence data for a state toward the nation scope.
 func (c *GatewayContract) CommitNationStateConvergence(ctx contractapi.TransactionContextInterface, stateID, payload string) (*ConvergenceRecord, error) {
+	return c.commitNationStateConvergence(ctx, convergenceScope{}, stateID, payload)
+}
+
+func (c *GatewayContract) commitNationStateConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, payload string) (*ConvergenceRecord, error) {
 	trainer, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
 	stateID, err = normalizeIdentifier(stateID, "stateId")
 	if err != nil {
 		return nil, err
@@ -516,18 +704,44 @@ func (c *GatewayContract) CommitNationStateConvergence(ctx contractapi.Transacti
 	if strings.TrimSpace(payload) == "" {
 		return nil, errors.New("payload is required")
 	}
+	membership, err := currentMembershipEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	record := &ConvergenceRecord{
 		Scope:       "nation",
 		StateID:     stateID,
 		SourceID:    trainer.NodeID,
 		Payload:     payload,
-		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+		SubmittedAt: now,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		Epoch:       membership.Epoch,
 	}
 	bytes, err := json.Marshal(record)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(nationStateKey(stateID), bytes); err != nil {
+	key, err := nationStateKey(ctx, scope, stateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventNationStateConverged,
+		Scope:       record.Scope,
+		StateID:     stateID,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		SubmittedBy: trainer.NodeID,
+		Timestamp:   record.SubmittedAt,
+	}); err != nil {
 		return nil, err
 	}
 	return record, nil
@@ -535,37 +749,65 @@ func (c *GatewayContract) CommitNationStateConvergence(ctx contractapi.Transacti
 
 // DeclareStateConvergence marks an entire state as converged (first declaration wins).
 func (c *GatewayContract) DeclareStateConvergence(ctx contractapi.TransactionContextInterface, stateID, payload string) (*ConvergenceSummary, error) {
+	return c.declareStateConvergence(ctx, convergenceScope{}, stateID, payload)
+}
+
+func (c *GatewayContract) declareStateConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, payload string) (*ConvergenceSummary, error) {
 	trainer, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
 	stateID, err = normalizeIdentifier(stateID, "stateId")
 	if err != nil {
 		return nil, err
 	}
-	key := stateSummaryKey(stateID)
+	key, err := stateSummaryKey(ctx, scope, stateID)
+	if err != nil {
+		return nil, err
+	}
 	existing, err := ctx.GetStub().GetState(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read existing state convergence: %w", err)
 	}
 	if len(existing) > 0 {
-		return nil, fmt.Errorf("state %s already declared converged", stateID)
+		return nil, fmt.Errorf("state %s already declared converged%s", stateID, scope.describe())
 	}
 	if strings.TrimSpace(payload) == "" {
 		return nil, errors.New("payload is required")
 	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	summary := &ConvergenceSummary{
 		Scope:      "state",
 		TargetID:   stateID,
 		DeclaredBy: trainer.NodeID,
-		DeclaredAt: time.Now().UTC().Format(time.RFC3339),
+		DeclaredAt: now,
 		Payload:    payload,
+		JobID:      scope.jobID,
+		Round:      scope.round,
 	}
 	bytes, err := json.Marshal(summary)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(key, bytes); err != nil {
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventStateConvergenceDeclared,
+		Scope:       summary.Scope,
+		StateID:     stateID,
+		TargetID:    stateID,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		SubmittedBy: trainer.NodeID,
+		Timestamp:   summary.DeclaredAt,
+	}); err != nil {
 		return nil, err
 	}
 	return summary, nil
@@ -573,33 +815,70 @@ func (c *GatewayContract) DeclareStateConvergence(ctx contractapi.TransactionCon
 
 // DeclareNationConvergence marks the nation as converged (first declaration wins).
 func (c *GatewayContract) DeclareNationConvergence(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceSummary, error) {
+	return c.declareNationConvergence(ctx, convergenceScope{}, payload)
+}
+
+func (c *GatewayContract) declareNationConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, payload string) (*ConvergenceSummary, error) {
 	trainer, err := c.requireAuthorizedTrainer(ctx)
 	if err != nil {
 		return nil, err
 	}
-	key := nationSummaryKey()
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
+	key, err := nationSummaryKey(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
 	existing, err := ctx.GetStub().GetState(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read nation convergence: %w", err)
 	}
 	if len(existing) > 0 {
-		return nil, errors.New("nation convergence already declared")
+		return nil, fmt.Errorf("nation convergence already declared%s", scope.describe())
 	}
 	if strings.TrimSpace(payload) == "" {
 		return nil, errors.New("payload is required")
 	}
+	nation, err := c.listNationConvergence(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePassingVerifications(ctx, scope, nation.States); err != nil {
+		return nil, err
+	}
+	if err := requireNoOpenDispute(ctx, scope, ""); err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
 	summary := &ConvergenceSummary{
 		Scope:      "nation",
 		TargetID:   "nation",
 		DeclaredBy: trainer.NodeID,
-		DeclaredAt: time.Now().UTC().Format(time.RFC3339),
+		DeclaredAt: now,
 		Payload:    payload,
+		JobID:      scope.jobID,
+		Round:      scope.round,
 	}
 	bytes, err := json.Marshal(summary)
 	if err != nil {
 		return nil, err
 	}
-	if err := ctx.GetStub().PutState(key, bytes); err != nil {
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	if err := emitConvergenceEvent(ctx, &ConvergenceEvent{
+		Event:       EventNationConvergenceDeclared,
+		Scope:       summary.Scope,
+		TargetID:    summary.TargetID,
+		JobID:       scope.jobID,
+		Round:       scope.round,
+		SubmittedBy: trainer.NodeID,
+		Timestamp:   summary.DeclaredAt,
+	}); err != nil {
 		return nil, err
 	}
 	return summary, nil
@@ -607,6 +886,10 @@ func (c *GatewayContract) DeclareNationConvergence(ctx contractapi.TransactionCo
 
 // ReadStateConvergence loads convergence information for a specific state.
 func (c *GatewayContract) ReadStateConvergence(ctx contractapi.TransactionContextInterface, stateID string) (*StateConvergence, error) {
+	return c.readStateConvergence(ctx, convergenceScope{}, stateID)
+}
+
+func (c *GatewayContract) readStateConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID string) (*StateConvergence, error) {
 	stateID, err := normalizeIdentifier(stateID, "stateId")
 	if err != nil {
 		return nil, err
@@ -615,8 +898,7 @@ func (c *GatewayContract) ReadStateConvergence(ctx contractapi.TransactionContex
 		StateID:  stateID,
 		Clusters: map[string]*ConvergenceRecord{},
 	}
-	prefix := fmt.Sprintf("%s%s:", stateConvPrefix, stateID)
-	iter, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	iter, err := scope.partialStateKeys(ctx, stateID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state convergence: %w", err)
 	}
@@ -626,33 +908,39 @@ func (c *GatewayContract) ReadStateConvergence(ctx contractapi.TransactionContex
 		if err != nil {
 			return nil, err
 		}
-		if strings.HasSuffix(kv.Key, ":summary") {
+		parts, err := scope.splitKey(ctx, kv.Key)
+		if err != nil || len(parts) < 2 {
+			continue
+		}
+		switch parts[1] {
+		case "summary":
 			var summary ConvergenceSummary
 			if err := json.Unmarshal(kv.Value, &summary); err != nil {
 				return nil, err
 			}
 			result.Summary = &summary
-			continue
-		}
-		if !strings.Contains(kv.Key, ":cluster:") {
-			continue
-		}
-		var record ConvergenceRecord
-		if err := json.Unmarshal(kv.Value, &record); err != nil {
-			return nil, err
-		}
-		if record.ClusterID == "" {
-			continue
+		case "cluster":
+			var record ConvergenceRecord
+			if err := json.Unmarshal(kv.Value, &record); err != nil {
+				return nil, err
+			}
+			if record.ClusterID == "" {
+				continue
+			}
+			result.Clusters[record.ClusterID] = &record
 		}
-		result.Clusters[record.ClusterID] = &record
 	}
 	return result, nil
 }
 
 // ListStateConvergence returns convergence info for all states.
 func (c *GatewayContract) ListStateConvergence(ctx contractapi.TransactionContextInterface) (map[string]*StateConvergence, error) {
+	return c.listStateConvergence(ctx, convergenceScope{})
+}
+
+func (c *GatewayContract) listStateConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope) (map[string]*StateConvergence, error) {
 	results := map[string]*StateConvergence{}
-	iter, err := ctx.GetStub().GetStateByRange(stateConvPrefix, stateConvPrefix+"~")
+	iter, err := scope.partialStateKeys(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list state convergence: %w", err)
 	}
@@ -662,10 +950,14 @@ func (c *GatewayContract) ListStateConvergence(ctx contractapi.TransactionContex
 		if err != nil {
 			return nil, err
 		}
-		stateID, kind, clusterID := parseStateConvergenceKey(kv.Key)
-		if stateID == "" {
+		parts, err := scope.splitKey(ctx, kv.Key)
+		if err != nil || len(parts) < 2 {
 			continue
 		}
+		stateID, kind, clusterID := parts[0], parts[1], ""
+		if len(parts) > 2 {
+			clusterID = parts[2]
+		}
 		state, ok := results[stateID]
 		if !ok {
 			state = &StateConvergence{
@@ -697,19 +989,19 @@ func (c *GatewayContract) ListStateConvergence(ctx contractapi.TransactionContex
 
 // ReadNationConvergence returns the convergence status for the nation.
 func (c *GatewayContract) ReadNationConvergence(ctx contractapi.TransactionContextInterface) (*NationConvergence, error) {
-	return c.listNationConvergence(ctx)
+	return c.listNationConvergence(ctx, convergenceScope{})
 }
 
 // ListNationConvergence exposes the detailed nation convergence map.
 func (c *GatewayContract) ListNationConvergence(ctx contractapi.TransactionContextInterface) (*NationConvergence, error) {
-	return c.listNationConvergence(ctx)
+	return c.listNationConvergence(ctx, convergenceScope{})
 }
 
-func (c *GatewayContract) listNationConvergence(ctx contractapi.TransactionContextInterface) (*NationConvergence, error) {
+func (c *GatewayContract) listNationConvergence(ctx contractapi.TransactionContextInterface, scope convergenceScope) (*NationConvergence, error) {
 	result := &NationConvergence{
 		States: map[string]*ConvergenceRecord{},
 	}
-	iter, err := ctx.GetStub().GetStateByRange(nationConvPrefix, nationConvPrefix+"~")
+	iter, err := scope.partialNationKeys(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nation convergence: %w", err)
 	}
@@ -719,7 +1011,15 @@ func (c *GatewayContract) listNationConvergence(ctx contractapi.TransactionConte
 		if err != nil {
 			return nil, err
 		}
-		switch kind, stateID := parseNationConvergenceKey(kv.Key); kind {
+		parts, err := scope.splitKey(ctx, kv.Key)
+		if err != nil || len(parts) == 0 {
+			continue
+		}
+		kind, stateID := parts[0], ""
+		if len(parts) > 1 {
+			stateID = parts[1]
+		}
+		switch kind {
 		case "summary":
 			var summary ConvergenceSummary
 			if err := json.Unmarshal(kv.Value, &summary); err != nil {
@@ -761,9 +1061,46 @@ func (c *GatewayContract) requireAuthorizedTrainer(ctx contractapi.TransactionCo
 	if !strings.EqualFold(trainer.Status, "AUTHORIZED") {
 		return nil, errTrainerUnauthorized
 	}
+	if trainer.WhitelistStatus == whitelistStatusDeactivated || trainer.WhitelistStatus == whitelistStatusRemoved {
+		return nil, errTrainerDeactivated
+	}
+	revoked, err := isVCRevoked(ctx, trainer.VCHash)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errTrainerRevoked
+	}
+	suspended, err := isNodeSuspended(ctx, trainer.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if suspended {
+		return nil, errTrainerSuspended
+	}
 	return &trainer, nil
 }
 
+// txTimestamp returns the transaction's timestamp from the proposal header in RFC 3339. Every
+// endorser sees the same value, unlike time.Now, so records stamped with it endorse
+// identically on every peer.
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	return now.Format(time.RFC3339), nil
+}
+
+// txTime is the transaction timestamp at the second precision of the stored timestamps.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %w", err)
+	}
+	return timestamp.AsTime().UTC().Truncate(time.Second), nil
+}
+
 func trainerKey(clientID string) string {
 	return trainerPrefix + clientID
 }
@@ -780,20 +1117,37 @@ func whitelistKey(jwtSub string) string {
 	return whitelistPrefix + strings.ToLower(strings.TrimSpace(jwtSub))
 }
 
-func stateClusterKey(stateID, clusterID string) string {
-	return fmt.Sprintf("%s%s:cluster:%s", stateConvPrefix, stateID, clusterID)
+func stateClusterKey(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, clusterID string) (string, error) {
+	return scope.stateKey(ctx, stateID, "cluster", clusterID)
+}
+
+func stateSummaryKey(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID string) (string, error) {
+	return scope.stateKey(ctx, stateID, "summary")
+}
+
+func nationStateKey(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID string) (string, error) {
+	return scope.nationKey(ctx, "state", stateID)
 }
 
-func stateSummaryKey(stateID string) string {
-	return fmt.Sprintf("%s%s:summary", stateConvPrefix, stateID)
+func nationSummaryKey(ctx contractapi.TransactionContextInterface, scope convergenceScope) (string, error) {
+	return scope.nationKey(ctx, "summary")
 }
 
-func nationStateKey(stateID string) string {
-	return fmt.Sprintf("%sstate:%s", nationConvPrefix, stateID)
+func modelIndexKey(ctx contractapi.TransactionContextInterface, record *ModelRecord) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(modelIndexType, []string{
+		record.Layer,
+		strings.ToLower(record.ScopeID),
+		fmt.Sprintf("%010d", record.RoundNumber),
+		record.ID,
+	})
 }
 
-func nationSummaryKey() string {
-	return nationConvPrefix + "summary"
+func putModelIndex(ctx contractapi.TransactionContextInterface, record *ModelRecord) error {
+	key, err := modelIndexKey(ctx, record)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, key, []byte{0x00})
 }
 
 func normalizeIdentifier(value, field string) (string, error) {
@@ -0,0 +1,888 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/chaincodetest"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+const trainerID = "x509::CN=trainer-1"
+
+// registerTrainer enrolls clientID in world as node nodeID with the VC hash vc-<nodeID>.
+func registerTrainer(t *testing.T, world *chaincodetest.World, clientID, nodeID string) {
+	t.Helper()
+	err := (&chaincode.GatewayContract{}).RegisterTrainer(world.Context(clientID), "did:nebula:"+nodeID, nodeID, "vc-"+nodeID, "public-key", "state-a", "cluster-a")
+	require.NoError(t, err)
+}
+
+func TestTrainerAuthorization(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the world; trainer-1 is registered first unless unregistered is set.
+		setup        func(t *testing.T, world *chaincodetest.World)
+		unregistered bool
+		caller       string
+		authorized   bool
+		err          string
+	}{
+		{
+			name:       "registered trainer",
+			caller:     trainerID,
+			authorized: true,
+		},
+		{
+			name:         "unregistered identity",
+			unregistered: true,
+			caller:       trainerID,
+			err:          "trainer not authorized",
+		},
+		{
+			name:   "another identity",
+			caller: "x509::CN=trainer-2",
+			err:    "trainer not authorized",
+		},
+		{
+			name:   "trainer not AUTHORIZED",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				world.State["trainer:"+trainerID] = []byte(`{"client_id":"` + trainerID + `","node_id":"trainer-1","status":"PENDING"}`)
+			},
+			err: "trainer not authorized",
+		},
+		{
+			name:   "revoked credential",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				_, err := (&chaincode.GatewayContract{}).AddRevokedVCHash(world.Context("x509::CN=admin"), "VC-TRAINER-1", "leaked")
+				require.NoError(t, err)
+			},
+			err: "trainer credential revoked",
+		},
+		{
+			name:   "suspended node",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				world.State["flagtally:trainer-1"] = []byte(`{"node_id":"trainer-1","suspended":true}`)
+			},
+			err: "trainer suspended",
+		},
+		{
+			name:   "deactivated whitelist entry",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				gateway := world.Context("x509::CN=gateway")
+				contract := &chaincode.GatewayContract{}
+				require.NoError(t, contract.RecordWhitelistEntry(gateway, "sub-1", "did:nebula:trainer-1", "trainer-1", "state-a", "cluster-a", "vc", "key", "", ""))
+				_, err := contract.DeactivateWhitelistEntry(gateway, "sub-1", "decommissioned")
+				require.NoError(t, err)
+			},
+			err: "trainer deactivated",
+		},
+		{
+			name:   "reactivated whitelist entry",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				gateway := world.Context("x509::CN=gateway")
+				contract := &chaincode.GatewayContract{}
+				require.NoError(t, contract.RecordWhitelistEntry(gateway, "sub-1", "did:nebula:trainer-1", "trainer-1", "state-a", "cluster-a", "vc", "key", "", ""))
+				_, err := contract.DeactivateWhitelistEntry(gateway, "sub-1", "maintenance")
+				require.NoError(t, err)
+				_, err = contract.ReactivateWhitelistEntry(gateway, "sub-1")
+				require.NoError(t, err)
+			},
+			authorized: true,
+		},
+		{
+			name:   "removed whitelist entry",
+			caller: trainerID,
+			setup: func(t *testing.T, world *chaincodetest.World) {
+				gateway := world.Context("x509::CN=gateway")
+				contract := &chaincode.GatewayContract{}
+				require.NoError(t, contract.RecordWhitelistEntry(gateway, "sub-1", "did:nebula:trainer-1", "trainer-1", "state-a", "cluster-a", "vc", "key", "", ""))
+				_, err := contract.RemoveWhitelistEntry(gateway, "sub-1", "decommissioned")
+				require.NoError(t, err)
+			},
+			err: "trainer deactivated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := chaincodetest.NewWorld()
+			if !tt.unregistered {
+				registerTrainer(t, world, trainerID, "trainer-1")
+			}
+			if tt.setup != nil {
+				tt.setup(t, world)
+			}
+			contract := &chaincode.GatewayContract{}
+			ctx := world.Context(tt.caller)
+
+			_, err := contract.CommitData(ctx, "data-1", "payload")
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+
+			authorized, err := contract.IsTrainerAuthorized(ctx)
+			if tt.err == "trainer suspended" {
+				// Suspension is not a credential problem, so it surfaces as an error.
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.authorized, authorized)
+		})
+	}
+}
+
+func TestRegisterTrainerRejectsRevokedCredential(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	_, err := contract.AddRevokedVCHash(world.Context("x509::CN=admin"), "vc-trainer-1", "leaked")
+	require.NoError(t, err)
+
+	err = contract.RegisterTrainer(world.Context(trainerID), "did:nebula:trainer-1", "trainer-1", "VC-Trainer-1", "public-key", "", "")
+	require.EqualError(t, err, "trainer credential revoked")
+	require.NotContains(t, world.State, "trainer:"+trainerID)
+}
+
+func TestRegisterTrainerRequiresFields(t *testing.T) {
+	tests := map[string]struct {
+		did, nodeID, vcHash, publicKey string
+		err                            string
+	}{
+		"did":       {"", "trainer-1", "vc", "key", "did is required"},
+		"nodeId":    {"did", " ", "vc", "key", "nodeId is required"},
+		"vcHash":    {"did", "trainer-1", "", "key", "vcHash is required"},
+		"publicKey": {"did", "trainer-1", "vc", "", "publicKey is required"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			world := chaincodetest.NewWorld()
+			err := (&chaincode.GatewayContract{}).RegisterTrainer(world.Context(trainerID), tt.did, tt.nodeID, tt.vcHash, tt.publicKey, "", "")
+			require.EqualError(t, err, tt.err)
+			require.Empty(t, world.State)
+		})
+	}
+}
+
+func TestRoleEnforcementUsesIdentityAttribute(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	admin := world.ContextFor(chaincodetest.Identity("x509::CN=admin", "Org1MSP", map[string]string{"role": "Admin"}))
+	_, err := contract.EnableRoleEnforcement(admin)
+	require.NoError(t, err)
+
+	before := contract.GetBeforeTransaction().(func(contractapi.TransactionContextInterface) error)
+	call := func(ctx *mocks.TransactionContext, function string) error {
+		ctx.GetStub().(*mocks.ChaincodeStub).GetFunctionAndParametersReturns(function, nil)
+		return before(ctx)
+	}
+	trainer := world.ContextFor(chaincodetest.Identity(trainerID, "Org1MSP", map[string]string{"role": "trainer"}))
+	anonymous := world.Context("x509::CN=anonymous")
+
+	require.NoError(t, call(trainer, "CommitModel"))
+	require.NoError(t, call(trainer, "GatewayContract:CommitModelJSON"))
+	require.NoError(t, call(anonymous, "ListModels"))
+	require.EqualError(t, call(trainer, "ResetConvergence"), `role "trainer" is not permitted to call ResetConvergence`)
+	require.EqualError(t, call(anonymous, "CommitModel"), `identity has no "role" attribute; CommitModel requires one of trainer, aggregator`)
+	require.NoError(t, call(admin, "ResetConvergence"))
+}
+
+func TestParseStateConvergenceKey(t *testing.T) {
+	tests := []struct {
+		key                      string
+		stateID, kind, clusterID string
+	}{
+		{"conv:state:state-a", "state-a", "", ""},
+		{"conv:state:state-a:summary", "state-a", "summary", ""},
+		{"conv:state:state-a:cluster:cluster-a", "state-a", "cluster", "cluster-a"},
+		{"conv:state:state-a:cluster:zone:1", "state-a", "cluster", "zone:1"},
+		{"conv:state:state-a:cluster", "state-a", "", ""},
+		{"conv:state:state-a:other", "state-a", "", ""},
+		{"conv:state:", "", "", ""},
+		{"conv:nation:summary", "", "", ""},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			stateID, kind, clusterID := chaincode.ParseStateConvergenceKey(tt.key)
+			require.Equal(t, []string{tt.stateID, tt.kind, tt.clusterID}, []string{stateID, kind, clusterID})
+		})
+	}
+}
+
+func TestParseNationConvergenceKey(t *testing.T) {
+	tests := []struct {
+		key           string
+		kind, stateID string
+	}{
+		{"conv:nation:summary", "summary", ""},
+		{"conv:nation:state:state-a", "state", "state-a"},
+		{"conv:nation:state:region:1", "state", "region:1"},
+		{"conv:nation:state", "", ""},
+		{"conv:nation:", "", ""},
+		{"conv:nation:other", "", ""},
+		{"conv:state:state-a:summary", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			kind, stateID := chaincode.ParseNationConvergenceKey(tt.key)
+			require.Equal(t, []string{tt.kind, tt.stateID}, []string{kind, stateID})
+		})
+	}
+}
+
+func TestConvergenceKeysRoundTrip(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	registerTrainer(t, world, trainerID, "trainer-1")
+	contract := &chaincode.GatewayContract{}
+	ctx := world.Context(trainerID)
+
+	_, err := contract.CommitStateClusterConvergence(ctx, " State-A ", "Cluster-A", `{"loss":0.1}`)
+	require.NoError(t, err)
+	_, err = contract.CommitStateClusterConvergence(ctx, "state-b", "cluster-b", `{"loss":0.2}`)
+	require.NoError(t, err)
+	_, err = contract.DeclareStateConvergence(ctx, "state-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+	_, err = contract.CommitNationStateConvergence(ctx, "state-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+	_, err = contract.CommitStateClusterConvergenceInRound(ctx, "job-1", "2", "state-a", "cluster-z", `{"loss":0.3}`)
+	require.NoError(t, err)
+
+	state, err := contract.ReadStateConvergence(ctx, "STATE-A")
+	require.NoError(t, err)
+	require.Equal(t, "state-a", state.StateID)
+	require.Len(t, state.Clusters, 1)
+	require.Equal(t, `{"loss":0.1}`, state.Clusters["cluster-a"].Payload)
+	require.NotNil(t, state.Summary)
+
+	states, err := contract.ListStateConvergence(ctx)
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	require.Nil(t, states["state-b"].Summary)
+	require.Contains(t, states["state-b"].Clusters, "cluster-b")
+
+	nation, err := contract.ListNationConvergence(ctx)
+	require.NoError(t, err)
+	require.Len(t, nation.States, 1)
+	require.Contains(t, nation.States, "state-a")
+	require.Nil(t, nation.Summary)
+
+	// The job-scoped keyspace is separate from the legacy one in both directions.
+	scoped, err := contract.ReadStateConvergenceInRound(ctx, "job-1", "2", "state-a")
+	require.NoError(t, err)
+	require.Len(t, scoped.Clusters, 1)
+	require.Contains(t, scoped.Clusters, "cluster-z")
+	otherRound, err := contract.ListStateConvergenceInRound(ctx, "job-1", "1")
+	require.NoError(t, err)
+	require.Empty(t, otherRound)
+}
+
+// commitModels commits count cluster models for scope, named <scope>-<n>.
+func commitModels(t *testing.T, world *chaincodetest.World, scope string, count int) {
+	t.Helper()
+	contract := &chaincode.GatewayContract{}
+	for i := 1; i <= count; i++ {
+		id := fmt.Sprintf("%s-%02d", scope, i)
+		_, err := contract.CommitModel(world.Context(trainerID), id, "cluster", scope, "ipfs://"+id, "")
+		require.NoError(t, err)
+	}
+}
+
+func TestListModelsPagination(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	registerTrainer(t, world, trainerID, "trainer-1")
+	commitModels(t, world, "cluster-a", 12)
+	commitModels(t, world, "cluster-b", 3)
+	contract := &chaincode.GatewayContract{}
+	ctx := world.Context(trainerID)
+
+	tests := []struct {
+		name                  string
+		layer, scope          string
+		page, perPage         string
+		wantPage, wantPerPage int
+		first                 string
+		items, total          int
+		hasMore               bool
+	}{
+		{name: "defaults", layer: "cluster", wantPage: 1, wantPerPage: 10, first: "cluster-a-01", items: 10, total: 15, hasMore: true},
+		{name: "last partial page", layer: "cluster", page: "2", wantPage: 2, wantPerPage: 10, first: "cluster-a-11", items: 5, total: 15},
+		{name: "exact last page", layer: "cluster", page: "3", perPage: "5", wantPage: 3, wantPerPage: 5, first: "cluster-a-11", items: 5, total: 15},
+		{name: "beyond the end", layer: "cluster", page: "9", wantPage: 9, wantPerPage: 10, total: 15},
+		{name: "scope filter", layer: "Cluster", scope: "CLUSTER-A", page: "3", perPage: "5", wantPage: 3, wantPerPage: 5, first: "cluster-a-11", items: 2, total: 12},
+		{name: "whitespace uses defaults", layer: "cluster", scope: "cluster-b", page: " ", perPage: "", wantPage: 1, wantPerPage: 10, first: "cluster-b-01", items: 3, total: 3},
+		{name: "unknown layer", layer: "nation", wantPage: 1, wantPerPage: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := contract.ListModels(ctx, tt.layer, tt.scope, tt.page, tt.perPage)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPage, page.Page)
+			require.Equal(t, tt.wantPerPage, page.PerPage)
+			require.Len(t, page.Items, tt.items)
+			require.Equal(t, tt.total, page.Total)
+			require.Equal(t, tt.hasMore, page.HasMore)
+			if tt.first != "" {
+				require.Equal(t, tt.first, page.Items[0].ID)
+			}
+		})
+	}
+}
+
+func TestListModelsRejectsInvalidArguments(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	registerTrainer(t, world, trainerID, "trainer-1")
+	contract := &chaincode.GatewayContract{}
+	ctx := world.Context(trainerID)
+
+	tests := map[string]struct {
+		layer, page, perPage string
+		err                  string
+	}{
+		"missing layer":   {" ", "", "", "layer is required"},
+		"page not number": {"cluster", "two", "", `invalid page parameter: strconv.Atoi: parsing "two": invalid syntax`},
+		"page zero":       {"cluster", "0", "", "page must be >= 1"},
+		"page negative":   {"cluster", "-1", "", "page must be >= 1"},
+		"perPage float":   {"cluster", "", "2.5", `invalid perPage parameter: strconv.Atoi: parsing "2.5": invalid syntax`},
+		"perPage zero":    {"cluster", "", "0", "perPage must be >= 1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := contract.ListModels(ctx, tt.layer, "", tt.page, tt.perPage)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	_, err := contract.ListModels(world.Context("x509::CN=stranger"), "cluster", "", "", "")
+	require.EqualError(t, err, "trainer not authorized")
+}
+
+func TestListWhitelistPagination(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	ctx := world.Context("x509::CN=gateway")
+	for i := 1; i <= 7; i++ {
+		sub := fmt.Sprintf("Trainer-%02d", i)
+		require.NoError(t, contract.RecordWhitelistEntry(ctx, sub, "did:nebula:"+sub, sub, "state-a", "cluster-a", "vc", "key", "", ""))
+	}
+
+	tests := []struct {
+		name                  string
+		page, perPage         string
+		wantPage, wantPerPage int
+		first                 string
+		items                 int
+		hasMore               bool
+	}{
+		{name: "defaults", wantPage: 1, wantPerPage: 50, first: "trainer-01", items: 7},
+		{name: "first page", perPage: "3", wantPage: 1, wantPerPage: 3, first: "trainer-01", items: 3, hasMore: true},
+		{name: "last partial page", page: "3", perPage: "3", wantPage: 3, wantPerPage: 3, first: "trainer-07", items: 1},
+		{name: "beyond the end", page: "4", perPage: "3", wantPage: 4, wantPerPage: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := contract.ListWhitelist(ctx, tt.page, tt.perPage)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPage, page.Page)
+			require.Equal(t, tt.wantPerPage, page.PerPage)
+			require.Len(t, page.Items, tt.items)
+			require.Equal(t, 7, page.Total)
+			require.Equal(t, tt.hasMore, page.HasMore)
+			if tt.first != "" {
+				require.Equal(t, tt.first, page.Items[0].JWTSub)
+			}
+		})
+	}
+
+	for _, args := range [][2]string{{"0", ""}, {"x", ""}, {"", "0"}, {"", "-3"}} {
+		_, err := contract.ListWhitelist(ctx, args[0], args[1])
+		require.Error(t, err, args)
+	}
+}
+
+func TestExpireRoundClosesAtDeadline(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	gateway := world.Context("x509::CN=gateway")
+	_, err := contract.CreateJob(gateway, "job-1", "deadlines", "")
+	require.NoError(t, err)
+	_, err = contract.UpsertTrainingConfig(gateway, "job-1", `{"round_duration_sec": 60, "min_clients": 2}`)
+	require.NoError(t, err)
+	registerTrainer(t, world, trainerID, "trainer-1")
+	for _, node := range []string{"trainer-1", "trainer-2", "trainer-3"} {
+		require.NoError(t, contract.RecordWhitelistEntry(gateway, node, "did:nebula:"+node, node, "state-a", "cluster-a", "vc", "key", "", ""))
+	}
+	_, err = contract.DeactivateWhitelistEntry(gateway, "trainer-3", "left the cluster")
+	require.NoError(t, err)
+
+	round, err := contract.StartRound(gateway, "job-1", "cluster", "cluster-a")
+	require.NoError(t, err)
+	require.Equal(t, "2024-05-06T07:09:09Z", round.Deadline)
+	_, err = contract.CommitModelInRound(world.Context(trainerID), "model-1", "job-1", "cluster", "cluster-a", "1", "ipfs://model-1", "")
+	require.NoError(t, err)
+
+	_, err = contract.ExpireRound(gateway, "job-1", "cluster", "cluster-a", "1", "")
+	require.ErrorContains(t, err, "has not passed")
+
+	world.Timestamp = world.Timestamp.Add(time.Minute)
+	expired, err := contract.ExpireRound(gateway, "job-1", "cluster", "cluster-a", "1", "")
+	require.NoError(t, err)
+	require.Equal(t, "CLOSED", expired.Status)
+	require.Equal(t, "deadline", expired.CloseReason)
+	require.Equal(t, []string{"trainer-1"}, expired.Participants)
+	require.Equal(t, []string{"trainer-2"}, expired.Stragglers)
+	require.Equal(t, 2, expired.MinQuorum)
+	require.False(t, expired.QuorumMet)
+
+	var event chaincode.RoundDeadlineEvent
+	require.NoError(t, json.Unmarshal(world.Events[chaincode.EventRoundDeadlineExpired], &event))
+	require.Equal(t, expired.Participants, event.Participants)
+	require.Equal(t, expired.Stragglers, event.Stragglers)
+	require.False(t, event.QuorumMet)
+
+	_, err = contract.CommitModelInRound(world.Context(trainerID), "model-2", "job-1", "cluster", "cluster-a", "1", "ipfs://model-2", "")
+	require.ErrorContains(t, err, "round 1 is closed")
+	_, err = contract.ExpireRound(gateway, "job-1", "cluster", "cluster-a", "1", "")
+	require.ErrorContains(t, err, "already closed")
+
+	// A round of a job without round_duration_sec never expires.
+	_, err = contract.StartRound(gateway, "job-2", "cluster", "cluster-a")
+	require.NoError(t, err)
+	_, err = contract.ExpireRound(gateway, "job-2", "cluster", "cluster-a", "1", "1")
+	require.ErrorContains(t, err, "has no deadline")
+}
+
+func TestAggregationLease(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	registerTrainer(t, world, "x509::CN=agg-1", "agg-1")
+	registerTrainer(t, world, "x509::CN=agg-2", "agg-2")
+	first, second := world.Context("x509::CN=agg-1"), world.Context("x509::CN=agg-2")
+
+	lease, err := contract.AcquireAggregationLease(first, "job-1", "cluster-a", "1", "60")
+	require.NoError(t, err)
+	require.Equal(t, "agg-1", lease.Holder)
+	require.Equal(t, "2024-05-06T07:09:09Z", lease.ExpiresAt)
+	require.True(t, lease.Active)
+
+	_, err = contract.AcquireAggregationLease(second, "job-1", "cluster-a", "1", "60")
+	require.ErrorContains(t, err, "is held by agg-1")
+	_, err = contract.RenewAggregationLease(second, "job-1", "cluster-a", "1", "60")
+	require.ErrorContains(t, err, "not the caller")
+	_, err = contract.ReleaseAggregationLease(second, "job-1", "cluster-a", "1")
+	require.ErrorContains(t, err, "not the caller")
+
+	// Only the holder can record the round's aggregation; other rounds are unaffected.
+	_, err = contract.CommitModel(first, "model-in", "cluster", "cluster-a", "ipfs://model-in", "")
+	require.NoError(t, err)
+	_, err = contract.CommitModel(second, "model-out", "cluster", "cluster-a", "ipfs://model-out", "")
+	require.NoError(t, err)
+	_, err = contract.RecordAggregation(second, "job-1", "cluster", "cluster-a", "1", `["model-in"]`, "model-out", "fedavg", "")
+	require.ErrorContains(t, err, "is held by agg-1")
+
+	world.Timestamp = world.Timestamp.Add(30 * time.Second)
+	renewed, err := contract.RenewAggregationLease(first, "job-1", "cluster-a", "1", "60")
+	require.NoError(t, err)
+	require.Equal(t, "2024-05-06T07:09:39Z", renewed.ExpiresAt)
+
+	// Once the lease lapses another aggregator can take over.
+	world.Timestamp = world.Timestamp.Add(time.Minute)
+	lease, err = contract.GetAggregationLease(second, "job-1", "cluster-a", "1")
+	require.NoError(t, err)
+	require.False(t, lease.Active)
+	lease, err = contract.AcquireAggregationLease(second, "job-1", "cluster-a", "1", "60")
+	require.NoError(t, err)
+	require.Equal(t, "agg-2", lease.Holder)
+	_, err = contract.RecordAggregation(second, "job-1", "cluster", "cluster-a", "1", `["model-in"]`, "model-out", "fedavg", "")
+	require.NoError(t, err)
+
+	released, err := contract.ReleaseAggregationLease(second, "job-1", "cluster-a", "1")
+	require.NoError(t, err)
+	require.False(t, released.Active)
+	require.NotEmpty(t, released.ReleasedAt)
+	_, err = contract.AcquireAggregationLease(first, "job-1", "cluster-a", "1", "60")
+	require.NoError(t, err)
+
+	for _, ttl := range []string{"", "0", "-5", "3601"} {
+		_, err := contract.AcquireAggregationLease(first, "job-1", "cluster-a", "2", ttl)
+		require.ErrorContains(t, err, "lease ttl", ttl)
+	}
+}
+
+func TestApplyClusteringPlan(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	gateway := world.Context("x509::CN=gateway")
+	registerTrainer(t, world, trainerID, "trainer-1")
+	for _, node := range []string{"trainer-1", "trainer-2"} {
+		require.NoError(t, contract.RecordWhitelistEntry(gateway, node, "did:nebula:"+node, node, "state-a", "cluster-a", "vc", "key", "", ""))
+	}
+	plan := `[{"node_id": "trainer-1", "cluster": "cluster-b"}]`
+
+	current, err := contract.GetMembershipEpoch(gateway, "")
+	require.NoError(t, err)
+	require.Equal(t, 0, current.Epoch)
+
+	// An open round of a cluster the node leaves blocks the plan.
+	round, err := contract.StartRound(gateway, "job-1", "cluster", "cluster-a")
+	require.NoError(t, err)
+	require.Equal(t, 0, round.Epoch)
+	_, err = contract.ApplyClusteringPlan(gateway, plan, "rebalance")
+	require.ErrorContains(t, err, "round 1 of job-1/cluster/cluster-a is open")
+	_, err = contract.CloseRound(gateway, "job-1", "cluster", "cluster-a", "1")
+	require.NoError(t, err)
+
+	for _, invalid := range []string{
+		`[]`,
+		`[{"cluster": "cluster-b"}]`,
+		`[{"node_id": "trainer-1"}]`,
+		`[{"node_id": "trainer-1", "cluster": "cluster-b"}, {"node_id": "trainer-1", "cluster": "cluster-c"}]`,
+		`[{"node_id": "trainer-9", "cluster": "cluster-b"}]`,
+		`[{"node_id": "trainer-1", "cluster": "cluster-a"}]`,
+	} {
+		_, err := contract.ApplyClusteringPlan(gateway, invalid, "")
+		require.Error(t, err, invalid)
+	}
+
+	epoch, err := contract.ApplyClusteringPlan(gateway, plan, "rebalance")
+	require.NoError(t, err)
+	require.Equal(t, 1, epoch.Epoch)
+	require.Equal(t, "cluster-a", epoch.Assignments[0].PreviousCluster)
+	require.Equal(t, "state-a", epoch.Assignments[0].State)
+	require.Contains(t, world.Events, chaincode.EventMembershipEpochApplied)
+
+	moved, err := contract.ListWhitelistByCluster(gateway, "cluster-b", "", "", "")
+	require.NoError(t, err)
+	require.Len(t, moved.Items, 1)
+	require.Equal(t, "trainer-1", moved.Items[0].NodeID)
+	updates, err := contract.ListTrainerUpdates(gateway, "did:nebula:trainer-1")
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.Equal(t, "cluster-b", updates[0].Current.Cluster)
+
+	// The moved node may only commit cluster models for its new cluster.
+	trainer := world.Context(trainerID)
+	_, err = contract.CommitModel(trainer, "model-old", "cluster", "cluster-a", "ipfs://model-old", "")
+	require.ErrorContains(t, err, "belongs to cluster cluster-b in membership epoch 1")
+	model, err := contract.CommitModel(trainer, "model-new", "cluster", "cluster-b", "ipfs://model-new", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, model.Epoch)
+
+	// Convergence is accepted only for clusters that are part of the state in this epoch.
+	record, err := contract.CommitStateClusterConvergence(trainer, "state-a", "cluster-b", "{}")
+	require.NoError(t, err)
+	require.Equal(t, 1, record.Epoch)
+	_, err = contract.CommitStateClusterConvergence(trainer, "state-a", "cluster-z", "{}")
+	require.ErrorContains(t, err, "not part of state state-a")
+
+	epochs, err := contract.ListMembershipEpochs(gateway)
+	require.NoError(t, err)
+	require.Len(t, epochs, 1)
+	_, err = contract.GetMembershipEpoch(gateway, "2")
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	source := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	ctx := source.Context("x509::CN=gateway")
+	for i := 1; i <= 5; i++ {
+		sub := fmt.Sprintf("trainer-%02d", i)
+		require.NoError(t, contract.RecordWhitelistEntry(ctx, sub, "did:nebula:"+sub, sub, "state-a", "cluster-a", "vc", "key", "", ""))
+	}
+	source.State["lease:aggregation:job-1"] = []byte(`{"holder":"agg-1"}`)
+
+	namespaces, err := contract.ListSnapshotNamespaces(ctx)
+	require.NoError(t, err)
+	var entries []*chaincode.SnapshotEntry
+	for _, namespace := range namespaces {
+		bookmark := ""
+		for {
+			page, err := contract.ExportStatePage(ctx, namespace, "2", bookmark)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Entries), 2)
+			entries = append(entries, page.Entries...)
+			if !page.HasMore {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+	}
+	require.Len(t, entries, len(source.State)-1, "every key but the lease is exported")
+
+	payload, err := json.Marshal(entries)
+	require.NoError(t, err)
+	target := chaincodetest.NewWorld()
+	imported, err := contract.ImportStateEntries(target.Context("x509::CN=gateway"), string(payload))
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.SnapshotImport{Imported: len(entries)}, imported)
+	delete(source.State, "lease:aggregation:job-1")
+	require.Equal(t, source.State, target.State)
+
+	again, err := contract.ImportStateEntries(target.Context("x509::CN=gateway"), string(payload))
+	require.NoError(t, err)
+	require.Equal(t, &chaincode.SnapshotImport{Unchanged: len(entries)}, again)
+
+	target.State["whitelist:trainer-01"] = []byte(`{}`)
+	_, err = contract.ImportStateEntries(target.Context("x509::CN=gateway"), string(payload))
+	require.EqualError(t, err, `key "whitelist:trainer-01" already holds different state`)
+
+	_, err = contract.ImportStateEntries(target.Context("x509::CN=gateway"), `[{"key":"lease:aggregation:job-1","value":"e30="}]`)
+	require.EqualError(t, err, `key "lease:aggregation:job-1" is outside the snapshot namespaces`)
+	_, err = contract.ExportStatePage(ctx, "lease:", "", "")
+	require.EqualError(t, err, `unknown snapshot namespace "lease:"`)
+}
+
+func TestEncryptedPayloadHash(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	registerTrainer(t, world, trainerID, "trainer-1")
+	contract := &chaincode.GatewayContract{}
+	ctx := world.Context(trainerID)
+	digest := strings.Repeat("ab", 32)
+	payloads := []string{
+		fmt.Sprintf(`{"encrypted":{"alg":"AES-256-GCM","key_id":"dk-1","nonce":"bm9uY2Ux","ciphertext":"c2VhbGVk","digest":%q}}`, strings.ToUpper(digest)),
+		fmt.Sprintf(`{"encrypted":{"alg":"AES-256-GCM","key_id":"dk-1","nonce":"bm9uY2Uy","ciphertext":"c2VhbGVk","digest":%q}}`, digest),
+		// Envelopes sealed before digests were keyed carry the plaintext's sha256.
+		fmt.Sprintf(`{"encrypted":{"alg":"AES-256-GCM","key_id":"dk-1","nonce":"bm9uY2Uz","ciphertext":"c2VhbGVk","sha256":%q}}`, digest),
+		// An offloaded envelope: the pointer's sha256 covers the ciphertext, its digest the plaintext.
+		fmt.Sprintf(`{"offchain":{"driver":"filesystem","uri":"file:///models/1","sha256":%q,"digest":%q,"size":512}}`, strings.Repeat("cd", 32), digest),
+	}
+	for i, payload := range payloads {
+		record, err := contract.CommitModel(ctx, fmt.Sprintf("model-%d", i), "cluster", "cluster-a", payload, "")
+		require.NoError(t, err)
+		require.Equal(t, digest, record.PayloadHash, "the plaintext digest is indexed, not the ciphertext's")
+	}
+	records, err := contract.GetModelByHash(ctx, "sha256:"+digest)
+	require.NoError(t, err)
+	require.Len(t, records, len(payloads))
+
+	pointer := fmt.Sprintf(`{"offchain":{"driver":"filesystem","uri":"file:///data/1","sha256":%q,"size":64}}`, strings.Repeat("ef", 32))
+	record, err := contract.CommitModel(ctx, "model-plain", "cluster", "cluster-a", pointer, "")
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("ef", 32), record.PayloadHash, "a plaintext pointer's content hash is the payload hash")
+}
+
+func TestNationConvergenceRequiresPassingVerifications(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	checker := world.Context("x509::CN=checker")
+	registerTrainer(t, world, trainerID, "trainer-1")
+	trainer := world.Context(trainerID)
+	evidence := strings.Repeat("ab", 32)
+
+	_, err := contract.StartRound(world.Context("x509::CN=gateway"), "job-1", "state", "state-a")
+	require.NoError(t, err)
+	_, err = contract.CommitModelInRound(trainer, "state-model-1", "job-1", "state", "state-a", "1", "ipfs://state-a", "")
+	require.NoError(t, err)
+	_, err = contract.CommitNationStateConvergenceInRound(trainer, "job-1", "1", "state-a", `{"loss":0.2}`)
+	require.NoError(t, err)
+
+	pending, err := contract.ListPendingStateModelsInRound(checker, "job-1", "1")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "state-model-1", pending[0].ID)
+
+	_, err = contract.DeclareNationConvergenceInRound(trainer, "job-1", "1", `{"loss":0.1}`)
+	require.ErrorContains(t, err, "state state-a has no passing verification for job job-1 round 1")
+
+	_, err = contract.RecordStateVerificationInRound(checker, "job-1", "1", "state-b", "state-model-1", "pass", evidence, "")
+	require.ErrorContains(t, err, "is not a model of state state-b")
+	_, err = contract.RecordStateVerificationInRound(checker, "job-1", "2", "state-a", "state-model-1", "pass", evidence, "")
+	require.ErrorContains(t, err, "was not committed for job job-1 round 2")
+	_, err = contract.RecordStateVerificationInRound(checker, "job-1", "1", "state-a", "state-model-1", "pass", "not-a-hash", "")
+	require.ErrorContains(t, err, "evidenceHash must be a hex SHA-256 digest")
+
+	failed, err := contract.RecordStateVerificationInRound(checker, "job-1", "1", "state-a", "state-model-1", "fail", evidence, "accuracy regressed")
+	require.NoError(t, err)
+	require.Equal(t, "FAIL", failed.Result)
+	pending, err = contract.ListPendingStateModelsInRound(checker, "job-1", "1")
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	_, err = contract.DeclareNationConvergenceInRound(trainer, "job-1", "1", `{"loss":0.1}`)
+	require.ErrorContains(t, err, "has no passing verification")
+
+	passed, err := contract.RecordStateVerificationInRound(checker, "job-1", "1", "state-a", "state-model-1", "PASS", strings.ToUpper(evidence), "")
+	require.NoError(t, err)
+	require.Equal(t, evidence, passed.EvidenceHash)
+	require.Equal(t, "x509::CN=checker", passed.VerifiedBy)
+	verifications, err := contract.ListStateVerificationsInRound(checker, "job-1", "1")
+	require.NoError(t, err)
+	require.Len(t, verifications, 1)
+	require.Equal(t, "PASS", verifications[0].Result)
+
+	summary, err := contract.DeclareNationConvergenceInRound(trainer, "job-1", "1", `{"loss":0.1}`)
+	require.NoError(t, err)
+	require.Equal(t, "nation", summary.Scope)
+
+	// The legacy keyspace keeps its own verifications.
+	legacy, err := contract.ListStateVerifications(checker)
+	require.NoError(t, err)
+	require.Empty(t, legacy)
+}
+
+func TestArchivedConvergenceClearsVerification(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	admin := world.Context("x509::CN=admin")
+	checker := world.Context("x509::CN=checker")
+	registerTrainer(t, world, trainerID, "trainer-1")
+	trainer := world.Context(trainerID)
+	evidence := strings.Repeat("ab", 32)
+
+	_, err := contract.StartRound(admin, "job-1", "state", "state-a")
+	require.NoError(t, err)
+	_, err = contract.CommitModelInRound(trainer, "state-model-1", "job-1", "state", "state-a", "1", "ipfs://state-a", "")
+	require.NoError(t, err)
+	_, err = contract.DeclareStateConvergenceInRound(trainer, "job-1", "1", "state-a", `{"loss":0.2}`)
+	require.NoError(t, err)
+	_, err = contract.CommitNationStateConvergenceInRound(trainer, "job-1", "1", "state-a", `{"loss":0.2}`)
+	require.NoError(t, err)
+	_, err = contract.RecordStateVerificationInRound(checker, "job-1", "1", "state-a", "state-model-1", "pass", evidence, "")
+	require.NoError(t, err)
+
+	_, err = contract.ResetConvergenceInRound(admin, "job-1", "1", "state", "state-a", "wrong model")
+	require.NoError(t, err)
+	verifications, err := contract.ListStateVerificationsInRound(checker, "job-1", "1")
+	require.NoError(t, err)
+	require.Empty(t, verifications)
+	history, err := contract.ListConvergenceHistory(admin, "state", "state-a")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.NotNil(t, history[0].Verification)
+	require.Equal(t, "state-model-1", history[0].Verification.ModelID)
+
+	// The state cannot carry the nation on the archived PASS.
+	_, err = contract.DeclareStateConvergenceInRound(trainer, "job-1", "1", "state-a", `{"loss":0.2}`)
+	require.NoError(t, err)
+	_, err = contract.DeclareNationConvergenceInRound(trainer, "job-1", "1", `{"loss":0.1}`)
+	require.ErrorContains(t, err, "state state-a has no passing verification for job job-1 round 1")
+}
+
+func TestEvaluationRunHistory(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	registerTrainer(t, world, trainerID, "trainer-1")
+	evaluator := world.Context(trainerID)
+	dataset := strings.Repeat("cd", 32)
+
+	_, err := contract.CommitModel(evaluator, "model-1", "state", "state-a", "ipfs://model-1", "")
+	require.NoError(t, err)
+
+	_, err = contract.RecordEvaluationRun(evaluator, "model-9", dataset, `{"accuracy":0.9}`)
+	require.ErrorContains(t, err, "model model-9 not found")
+	_, err = contract.RecordEvaluationRun(evaluator, "model-1", "dataset-a", `{"accuracy":0.9}`)
+	require.ErrorContains(t, err, "datasetHash must be a hex SHA-256 digest")
+	_, err = contract.RecordEvaluationRun(evaluator, "model-1", dataset, `{}`)
+	require.ErrorContains(t, err, "at least one metric is required")
+	_, err = contract.RecordEvaluationRun(world.Context("x509::CN=stranger"), "model-1", dataset, `{"accuracy":0.9}`)
+	require.ErrorContains(t, err, "trainer not authorized")
+
+	first, err := contract.RecordEvaluationRun(evaluator, "model-1", strings.ToUpper(dataset), `{"accuracy":0.9,"loss":0.3}`)
+	require.NoError(t, err)
+	require.Equal(t, dataset, first.DatasetHash)
+	require.Equal(t, "trainer-1", first.Evaluator)
+	require.Equal(t, "state", first.Layer)
+	require.Equal(t, "state-a", first.ScopeID)
+	require.Equal(t, "tx-1", first.RunID)
+
+	// The same evaluator may rerun the model; both runs are kept, oldest first.
+	world.Timestamp = world.Timestamp.Add(time.Hour)
+	world.TxID = "tx-2"
+	_, err = contract.RecordEvaluationRun(evaluator, "model-1", dataset, `{"accuracy":0.92}`)
+	require.NoError(t, err)
+
+	runs, err := contract.ListEvaluationRuns(world.Context("x509::CN=auditor"), "model-1")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, []string{"tx-1", "tx-2"}, []string{runs[0].RunID, runs[1].RunID})
+	require.Equal(t, 0.92, runs[1].Metrics["accuracy"])
+	require.Equal(t, "2024-05-06T08:08:09Z", runs[1].EvaluatedAt)
+
+	runs, err = contract.ListEvaluationRuns(evaluator, "model-2")
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+func TestConvergenceDisputeFreezesDeclaration(t *testing.T) {
+	world := chaincodetest.NewWorld()
+	contract := &chaincode.GatewayContract{}
+	admin := world.Context("x509::CN=admin")
+	registerTrainer(t, world, trainerID, "trainer-1")
+	registerTrainer(t, world, "x509::CN=trainer-2", "trainer-2")
+	declarer := world.Context(trainerID)
+	challenger := world.Context("x509::CN=trainer-2")
+
+	_, err := contract.StartRound(admin, "job-1", "state", "state-a")
+	require.NoError(t, err)
+	_, err = contract.ChallengeConvergenceInRound(challenger, "job-1", "1", "state-a", "premature")
+	require.ErrorContains(t, err, "no convergence declared for state state-a for job job-1 round 1")
+	_, err = contract.DeclareStateConvergenceInRound(declarer, "job-1", "1", "state-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+
+	_, err = contract.ChallengeConvergenceInRound(declarer, "job-1", "1", "state-a", "premature")
+	require.ErrorContains(t, err, "cannot challenge its own declaration")
+	_, err = contract.ChallengeConvergenceInRound(challenger, "job-1", "1", "state-a", " ")
+	require.ErrorContains(t, err, "reason is required")
+
+	world.TxID = "tx-challenge"
+	dispute, err := contract.ChallengeConvergenceInRound(challenger, "job-1", "1", "STATE-A", "loss measured on training data")
+	require.NoError(t, err)
+	require.Equal(t, "tx-challenge", dispute.DisputeID)
+	require.Equal(t, "OPEN", dispute.Status)
+	require.Equal(t, "trainer-1", dispute.DeclaredBy)
+	require.Equal(t, "trainer-2", dispute.ChallengedBy)
+	require.Contains(t, world.Events, chaincode.EventConvergenceChallenged)
+
+	_, err = contract.ChallengeConvergenceInRound(challenger, "job-1", "1", "state-a", "again")
+	require.ErrorContains(t, err, "state state-a convergence is disputed by tx-challenge")
+	_, err = contract.ResetConvergenceInRound(admin, "job-1", "1", "state", "state-a", "")
+	require.ErrorContains(t, err, "is disputed")
+	_, err = contract.DeclareNationConvergenceInRound(declarer, "job-1", "1", `{"loss":0.1}`)
+	require.ErrorContains(t, err, "is disputed")
+
+	_, err = contract.ResolveConvergenceDispute(admin, "tx-challenge", "ignore", "")
+	require.ErrorContains(t, err, `decision must be "uphold" or "reject"`)
+	world.TxID = "tx-resolve"
+	resolved, err := contract.ResolveConvergenceDispute(admin, "tx-challenge", "uphold", "evaluated on the wrong split")
+	require.NoError(t, err)
+	require.Equal(t, "UPHELD", resolved.Status)
+	require.Equal(t, "x509::CN=admin", resolved.ResolvedBy)
+	_, err = contract.ResolveConvergenceDispute(admin, "tx-challenge", "reject", "")
+	require.ErrorContains(t, err, "already resolved as UPHELD")
+
+	state, err := contract.ReadStateConvergenceInRound(admin, "job-1", "1", "state-a")
+	require.NoError(t, err)
+	require.Nil(t, state.Summary)
+	history, err := contract.ListConvergenceHistory(admin, "state", "state-a")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "dispute tx-challenge upheld: evaluated on the wrong split", history[0].Reason)
+	require.Nil(t, history[0].Verification)
+
+	// A fresh declaration can be challenged again, but only inside the window.
+	_, err = contract.DeclareStateConvergenceInRound(declarer, "job-1", "1", "state-a", `{"loss":0.2}`)
+	require.NoError(t, err)
+	_, err = contract.SetConvergenceChallengeWindow(admin, "60")
+	require.NoError(t, err)
+	world.Timestamp = chaincodetest.Timestamp.Add(61 * time.Second)
+	_, err = contract.ChallengeConvergenceInRound(challenger, "job-1", "1", "state-a", "late")
+	require.ErrorContains(t, err, "challenge window for state state-a closed at 2024-05-06T07:09:09Z")
+
+	disputes, err := contract.ListConvergenceDisputesInRound(admin, "job-1", "1")
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	legacy, err := contract.ListConvergenceDisputes(admin)
+	require.NoError(t, err)
+	require.Empty(t, legacy)
+}
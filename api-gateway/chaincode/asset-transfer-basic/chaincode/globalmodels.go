@@ -0,0 +1,359 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// GlobalCandidate is a state model nominated to become the next nation global model.
+type GlobalCandidate struct {
+	ModelID     string `json:"model_id"`
+	StateID     string `json:"state_id"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	PayloadHash string `json:"payload_hash,omitempty"`
+	Status      string `json:"status"`
+	Note        string `json:"note,omitempty"`
+	NominatedBy string `json:"nominated_by"`
+	NominatedAt string `json:"nominated_at"`
+	ReviewedBy  string `json:"reviewed_by,omitempty"`
+	ReviewedAt  string `json:"reviewed_at,omitempty"`
+	ReviewNote  string `json:"review_note,omitempty"`
+	Version     int    `json:"version,omitempty"`
+}
+
+// GlobalModel is a published nation global model version. Versions are never rewritten.
+type GlobalModel struct {
+	Version     int    `json:"version"`
+	ModelID     string `json:"model_id"`
+	StateID     string `json:"state_id"`
+	JobID       string `json:"job_id,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	ModelCID    string `json:"model_cid"`
+	ModelHash   string `json:"model_hash"`
+	ApprovedBy  string `json:"approved_by"`
+	PublishedBy string `json:"published_by"`
+	TxID        string `json:"tx_id"`
+	PublishedAt string `json:"published_at"`
+}
+
+const (
+	globalCandidatePrefix = "globalcandidate:"
+	globalLatestKey       = "globalmodel:latest"
+	// globalModelType keys published versions, zero-padded so they list in order.
+	globalModelType = "global~version"
+
+	candidateStatusPending   = "PENDING"
+	candidateStatusApproved  = "APPROVED"
+	candidateStatusRejected  = "REJECTED"
+	candidateStatusPublished = "PUBLISHED"
+)
+
+// NominateGlobalModel proposes a committed state model as the next nation global model. A
+// model can be nominated once.
+func (c *GatewayContract) NominateGlobalModel(ctx contractapi.TransactionContextInterface, modelID, note string) (*GlobalCandidate, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	existing, err := readGlobalCandidate(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("model %s already nominated", modelID)
+	}
+	model, err := readModelRecord(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	if model.Layer != "state" {
+		return nil, fmt.Errorf("model %s is a %s model; only state models can be nominated", modelID, model.Layer)
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidate := &GlobalCandidate{
+		ModelID:     modelID,
+		StateID:     model.ScopeID,
+		JobID:       model.JobID,
+		Round:       model.RoundNumber,
+		PayloadHash: model.PayloadHash,
+		Status:      candidateStatusPending,
+		Note:        strings.TrimSpace(note),
+		NominatedBy: actor,
+		NominatedAt: now,
+	}
+	if err := putGlobalCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// ReviewGlobalCandidate records the central checker's decision, "approve" or "reject", on a
+// pending candidate.
+func (c *GatewayContract) ReviewGlobalCandidate(ctx contractapi.TransactionContextInterface, modelID, decision, note string) (*GlobalCandidate, error) {
+	candidate, err := requireGlobalCandidate(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Status != candidateStatusPending {
+		return nil, fmt.Errorf("candidate %s is already %s", candidate.ModelID, strings.ToLower(candidate.Status))
+	}
+	switch strings.ToLower(strings.TrimSpace(decision)) {
+	case "approve":
+		candidate.Status = candidateStatusApproved
+	case "reject":
+		candidate.Status = candidateStatusRejected
+	default:
+		return nil, errors.New("decision must be approve or reject")
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidate.ReviewedBy = actor
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidate.ReviewedAt = now
+	candidate.ReviewNote = strings.TrimSpace(note)
+	if err := putGlobalCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// PublishGlobalModel publishes an approved candidate as the next global model version.
+// modelHash defaults to the payload hash recorded with the model and, when both are known,
+// must match it.
+func (c *GatewayContract) PublishGlobalModel(ctx contractapi.TransactionContextInterface, modelID, modelCID, modelHash string) (*GlobalModel, error) {
+	candidate, err := requireGlobalCandidate(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Status != candidateStatusApproved {
+		return nil, fmt.Errorf("candidate %s is %s, not approved", candidate.ModelID, strings.ToLower(candidate.Status))
+	}
+	modelCID = strings.TrimSpace(modelCID)
+	if modelCID == "" {
+		return nil, errors.New("model CID is required")
+	}
+	modelHash = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(modelHash), "sha256:"))
+	switch {
+	case modelHash == "":
+		modelHash = candidate.PayloadHash
+	case candidate.PayloadHash != "" && modelHash != candidate.PayloadHash:
+		return nil, fmt.Errorf("model hash does not match the payload hash of model %s", candidate.ModelID)
+	}
+	if modelHash == "" {
+		return nil, errors.New("model hash is required")
+	}
+	latest, err := readLatestGlobalVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &GlobalModel{
+		Version:     latest + 1,
+		ModelID:     candidate.ModelID,
+		StateID:     candidate.StateID,
+		JobID:       candidate.JobID,
+		Round:       candidate.Round,
+		ModelCID:    modelCID,
+		ModelHash:   modelHash,
+		ApprovedBy:  candidate.ReviewedBy,
+		PublishedBy: actor,
+		TxID:        ctx.GetStub().GetTxID(),
+		PublishedAt: now,
+	}
+	key, err := globalModelKey(ctx, record.Version)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, payload); err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, globalLatestKey, []byte(strconv.Itoa(record.Version))); err != nil {
+		return nil, err
+	}
+	candidate.Status = candidateStatusPublished
+	candidate.Version = record.Version
+	if err := putGlobalCandidate(ctx, candidate); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadGlobalModel returns a published version; an empty versionArg returns the latest.
+func (c *GatewayContract) ReadGlobalModel(ctx contractapi.TransactionContextInterface, versionArg string) (*GlobalModel, error) {
+	var version int
+	if versionArg = strings.TrimSpace(versionArg); versionArg == "" {
+		latest, err := readLatestGlobalVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if latest == 0 {
+			return nil, errors.New("no global model published")
+		}
+		version = latest
+	} else {
+		parsed, err := strconv.Atoi(versionArg)
+		if err != nil || parsed < 1 {
+			return nil, errors.New("version must be a positive integer")
+		}
+		version = parsed
+	}
+	key, err := globalModelKey(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global model: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("global model version %d not found", version)
+	}
+	var record GlobalModel
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListGlobalModels returns every published version in version order.
+func (c *GatewayContract) ListGlobalModels(ctx contractapi.TransactionContextInterface) ([]*GlobalModel, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(globalModelType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list global models: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*GlobalModel, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record GlobalModel
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// ReadGlobalCandidate returns the nomination of a model.
+func (c *GatewayContract) ReadGlobalCandidate(ctx contractapi.TransactionContextInterface, modelID string) (*GlobalCandidate, error) {
+	return requireGlobalCandidate(ctx, modelID)
+}
+
+// ListGlobalCandidates returns every nomination in model order, optionally only those with
+// the given status.
+func (c *GatewayContract) ListGlobalCandidates(ctx contractapi.TransactionContextInterface, status string) ([]*GlobalCandidate, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", candidateStatusPending, candidateStatusApproved, candidateStatusRejected, candidateStatusPublished:
+	default:
+		return nil, errors.New("status must be pending, approved, rejected or published")
+	}
+	iter, err := ctx.GetStub().GetStateByRange(globalCandidatePrefix, globalCandidatePrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list global candidates: %w", err)
+	}
+	defer iter.Close()
+	candidates := make([]*GlobalCandidate, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var candidate GlobalCandidate
+		if err := json.Unmarshal(kv.Value, &candidate); err != nil {
+			return nil, err
+		}
+		if status == "" || candidate.Status == status {
+			candidates = append(candidates, &candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func requireGlobalCandidate(ctx contractapi.TransactionContextInterface, modelID string) (*GlobalCandidate, error) {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	candidate, err := readGlobalCandidate(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("candidate %s not found", modelID)
+	}
+	return candidate, nil
+}
+
+func readGlobalCandidate(ctx contractapi.TransactionContextInterface, modelID string) (*GlobalCandidate, error) {
+	payload, err := ctx.GetStub().GetState(globalCandidatePrefix + modelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global candidate: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var candidate GlobalCandidate
+	if err := json.Unmarshal(payload, &candidate); err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func putGlobalCandidate(ctx contractapi.TransactionContextInterface, candidate *GlobalCandidate) error {
+	payload, err := json.Marshal(candidate)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, globalCandidatePrefix+candidate.ModelID, payload)
+}
+
+func readLatestGlobalVersion(ctx contractapi.TransactionContextInterface) (int, error) {
+	payload, err := ctx.GetStub().GetState(globalLatestKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read latest global model: %w", err)
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(string(payload))
+}
+
+func globalModelKey(ctx contractapi.TransactionContextInterface, version int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(globalModelType, []string{fmt.Sprintf("%010d", version)})
+}
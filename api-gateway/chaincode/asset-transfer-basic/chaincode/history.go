@@ -0,0 +1,125 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+)
+
+// ModelHistoryEntry is one mutation of a model record as kept by the peer's history database.
+// Record is empty for deletions.
+type ModelHistoryEntry struct {
+	TxID      string       `json:"tx_id"`
+	Timestamp string       `json:"timestamp"`
+	IsDelete  bool         `json:"is_delete"`
+	Record    *ModelRecord `json:"record,omitempty"`
+}
+
+// TrainingConfigHistoryEntry is one write to a job's latest training config as kept by the
+// peer's history database. Record is empty for deletions.
+type TrainingConfigHistoryEntry struct {
+	TxID      string          `json:"tx_id"`
+	Timestamp string          `json:"timestamp"`
+	IsDelete  bool            `json:"is_delete"`
+	Record    *TrainingConfig `json:"record,omitempty"`
+}
+
+// GetModelHistory lists every committed write to a model record, oldest first.
+func (c *GatewayContract) GetModelHistory(ctx contractapi.TransactionContextInterface, modelID string) ([]*ModelHistoryEntry, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	modifications, err := keyHistory(ctx, modelKey(modelID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model history: %w", err)
+	}
+	if len(modifications) == 0 {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	entries := make([]*ModelHistoryEntry, 0, len(modifications))
+	for _, modification := range modifications {
+		entry := &ModelHistoryEntry{TxID: modification.GetTxId(), Timestamp: modificationTime(modification), IsDelete: modification.GetIsDelete()}
+		if !entry.IsDelete && len(modification.GetValue()) > 0 {
+			var record ModelRecord
+			if err := json.Unmarshal(modification.GetValue(), &record); err != nil {
+				return nil, err
+			}
+			entry.Record = &record
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// GetTrainingConfigHistory lists every committed write to a job's latest training config,
+// oldest first. Unlike ListTrainingConfigVersions it includes writes made before versioning
+// and the transaction that made each one.
+func (c *GatewayContract) GetTrainingConfigHistory(ctx contractapi.TransactionContextInterface, jobID string) ([]*TrainingConfigHistoryEntry, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	modifications, err := keyHistory(ctx, trainingConfigKey(jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read training config history: %w", err)
+	}
+	if len(modifications) == 0 {
+		return nil, fmt.Errorf("training config for job %s not found", jobID)
+	}
+	entries := make([]*TrainingConfigHistoryEntry, 0, len(modifications))
+	for _, modification := range modifications {
+		entry := &TrainingConfigHistoryEntry{TxID: modification.GetTxId(), Timestamp: modificationTime(modification), IsDelete: modification.GetIsDelete()}
+		if !entry.IsDelete && len(modification.GetValue()) > 0 {
+			var record TrainingConfig
+			if err := json.Unmarshal(modification.GetValue(), &record); err != nil {
+				return nil, err
+			}
+			if record.Version == 0 {
+				// As readTrainingConfig: configs stored before versioning read as version 1.
+				record.Version = 1
+				record.Frozen = true
+			}
+			entry.Record = &record
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// keyHistory returns every committed modification of key, oldest first.
+func keyHistory(ctx contractapi.TransactionContextInterface, key string) ([]*queryresult.KeyModification, error) {
+	iter, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var modifications []*queryresult.KeyModification
+	for iter.HasNext() {
+		modification, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		modifications = append(modifications, modification)
+	}
+	// The history database returns the newest modification first.
+	for i, j := 0, len(modifications)-1; i < j; i, j = i+1, j-1 {
+		modifications[i], modifications[j] = modifications[j], modifications[i]
+	}
+	return modifications, nil
+}
+
+func modificationTime(modification *queryresult.KeyModification) string {
+	if ts := modification.GetTimestamp(); ts != nil {
+		return ts.AsTime().UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
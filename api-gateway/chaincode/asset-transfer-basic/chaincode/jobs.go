@@ -0,0 +1,500 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Job is a federated training job. Its status moves CREATED → CONFIGURED → RUNNING →
+// CONVERGED → ARCHIVED; jobs that never started can be archived directly.
+type Job struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Status       string `json:"status"`
+	CreatedBy    string `json:"created_by"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+	ConfiguredAt string `json:"configured_at,omitempty"`
+	StartedAt    string `json:"started_at,omitempty"`
+	ConvergedAt  string `json:"converged_at,omitempty"`
+	ArchivedAt   string `json:"archived_at,omitempty"`
+	FinalModelID string `json:"final_model_id,omitempty"`
+}
+
+// TrainingConfig is one version of a job's configuration document. Versions count from 1;
+// a frozen config cannot change once the job is RUNNING. SchemaVersion is the schema Config
+// was validated against; it is 0 for configs stored before schemas.
+type TrainingConfig struct {
+	JobID         string `json:"job_id"`
+	Version       int    `json:"version"`
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	Config        string `json:"config"`
+	Frozen        bool   `json:"frozen"`
+	UpdatedBy     string `json:"updated_by"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+const (
+	jobPrefix            = "job:"
+	trainingConfigPrefix = "trainingcfg:"
+	// trainingConfigVersionType keeps every version of a job's config, zero-padded so they
+	// list in order. trainingcfg:<jobId> holds the latest.
+	trainingConfigVersionType = "trainingcfg~job~version"
+
+	jobStatusCreated    = "CREATED"
+	jobStatusConfigured = "CONFIGURED"
+	jobStatusRunning    = "RUNNING"
+	jobStatusConverged  = "CONVERGED"
+	jobStatusArchived   = "ARCHIVED"
+)
+
+// jobTransitions lists the statuses each status may move to.
+var jobTransitions = map[string][]string{
+	jobStatusCreated:    {jobStatusConfigured, jobStatusArchived},
+	jobStatusConfigured: {jobStatusConfigured, jobStatusRunning, jobStatusArchived},
+	jobStatusRunning:    {jobStatusConverged},
+	jobStatusConverged:  {jobStatusArchived},
+}
+
+// CreateJob registers a job in CREATED status.
+func (c *GatewayContract) CreateJob(ctx contractapi.TransactionContextInterface, jobID, name, description string) (*Job, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	existing, err := readJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("job %s already exists", jobID)
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = jobID
+	}
+	job := &Job{
+		ID:          jobID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Status:      jobStatusCreated,
+		CreatedBy:   actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := putJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// UpdateJob changes the name and description of a job that is not archived. Empty values
+// keep the current ones.
+func (c *GatewayContract) UpdateJob(ctx contractapi.TransactionContextInterface, jobID, name, description string) (*Job, error) {
+	job, err := requireJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == jobStatusArchived {
+		return nil, fmt.Errorf("job %s is archived", job.ID)
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		job.Name = name
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		job.Description = description
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job.UpdatedAt = now
+	if err := putJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// UpsertTrainingConfig stores a new version of the job's configuration document, frozen, and
+// moves the job to CONFIGURED. The document is a JSON object following a training config
+// schema (see normalizeTrainingConfig). It is allowed while the job is CREATED or
+// CONFIGURED, and while it is RUNNING if the current version is not frozen.
+func (c *GatewayContract) UpsertTrainingConfig(ctx contractapi.TransactionContextInterface, jobID, config string) (*TrainingConfig, error) {
+	return c.upsertTrainingConfig(ctx, jobID, config, true)
+}
+
+// UpsertTrainingConfigWithFreeze is UpsertTrainingConfig with an explicit freeze flag
+// ("true" or "false"; empty means "true"). An unfrozen config keeps accepting new versions
+// after the job starts.
+func (c *GatewayContract) UpsertTrainingConfigWithFreeze(ctx contractapi.TransactionContextInterface, jobID, config, freezeArg string) (*TrainingConfig, error) {
+	freeze := true
+	if freezeArg = strings.TrimSpace(freezeArg); freezeArg != "" {
+		parsed, err := strconv.ParseBool(freezeArg)
+		if err != nil {
+			return nil, errors.New("freeze must be true or false")
+		}
+		freeze = parsed
+	}
+	return c.upsertTrainingConfig(ctx, jobID, config, freeze)
+}
+
+func (c *GatewayContract) upsertTrainingConfig(ctx contractapi.TransactionContextInterface, jobID, config string, freeze bool) (*TrainingConfig, error) {
+	job, err := requireJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := readTrainingConfig(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch job.Status {
+	case jobStatusCreated, jobStatusConfigured:
+	case jobStatusRunning:
+		if current != nil && current.Frozen {
+			return nil, fmt.Errorf("training config of job %s is frozen and cannot change while %s", job.ID, job.Status)
+		}
+	default:
+		return nil, fmt.Errorf("training config of job %s cannot change while %s", job.ID, job.Status)
+	}
+	config, schemaVersion, err := normalizeTrainingConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version := 1
+	if current != nil {
+		version = current.Version + 1
+	}
+	record := &TrainingConfig{JobID: job.ID, Version: version, SchemaVersion: schemaVersion, Config: config, Frozen: freeze, UpdatedBy: actor, UpdatedAt: now}
+	if current != nil {
+		// Configs stored before versioning have no history entry yet.
+		key, err := trainingConfigVersionKey(ctx, job.ID, current.Version)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read training config: %w", err)
+		}
+		if len(existing) == 0 {
+			if err := putTrainingConfigVersion(ctx, current); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := putTrainingConfigVersion(ctx, record); err != nil {
+		return nil, err
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, trainingConfigKey(job.ID), bytes); err != nil {
+		return nil, err
+	}
+	if job.Status == jobStatusRunning {
+		return record, nil
+	}
+	if job.Status == jobStatusCreated {
+		job.ConfiguredAt = now
+	}
+	if err := transitionJob(ctx, job, jobStatusConfigured, now); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetTrainingConfig returns the latest version of the job's configuration document.
+func (c *GatewayContract) GetTrainingConfig(ctx contractapi.TransactionContextInterface, jobID string) (*TrainingConfig, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	record, err := readTrainingConfig(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("training config for job %s not found", jobID)
+	}
+	return record, nil
+}
+
+// GetTrainingConfigVersion returns one version of the job's configuration document.
+func (c *GatewayContract) GetTrainingConfigVersion(ctx contractapi.TransactionContextInterface, jobID, versionArg string) (*TrainingConfig, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(versionArg))
+	if err != nil || version < 1 {
+		return nil, errors.New("version must be a positive integer")
+	}
+	key, err := trainingConfigVersionKey(ctx, jobID, version)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read training config: %w", err)
+	}
+	if len(payload) > 0 {
+		var record TrainingConfig
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return nil, err
+		}
+		return &record, nil
+	}
+	current, err := readTrainingConfig(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Version == version {
+		return current, nil
+	}
+	return nil, fmt.Errorf("training config version %d for job %s not found", version, jobID)
+}
+
+// ListTrainingConfigVersions returns every version of the job's configuration document,
+// oldest first.
+func (c *GatewayContract) ListTrainingConfigVersions(ctx contractapi.TransactionContextInterface, jobID string) ([]*TrainingConfig, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(trainingConfigVersionType, []string{jobID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list training config versions: %w", err)
+	}
+	defer iter.Close()
+	versions := make([]*TrainingConfig, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record TrainingConfig
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &record)
+	}
+	if len(versions) == 0 {
+		current, err := readTrainingConfig(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			versions = append(versions, current)
+		}
+	}
+	return versions, nil
+}
+
+// StartJob moves a CONFIGURED job to RUNNING.
+func (c *GatewayContract) StartJob(ctx contractapi.TransactionContextInterface, jobID string) (*Job, error) {
+	job, err := requireJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job.StartedAt = now
+	if err := transitionJob(ctx, job, jobStatusRunning, now); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CompleteJob moves a RUNNING job to CONVERGED, optionally recording the final model.
+func (c *GatewayContract) CompleteJob(ctx contractapi.TransactionContextInterface, jobID, finalModelID string) (*Job, error) {
+	job, err := requireJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if finalModelID = strings.TrimSpace(finalModelID); finalModelID != "" {
+		model, err := readModelRecord(ctx, finalModelID)
+		if err != nil {
+			return nil, err
+		}
+		if model == nil {
+			return nil, fmt.Errorf("model %s not found", finalModelID)
+		}
+		job.FinalModelID = finalModelID
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job.ConvergedAt = now
+	if err := transitionJob(ctx, job, jobStatusConverged, now); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ArchiveJob retires a job that has converged or never started.
+func (c *GatewayContract) ArchiveJob(ctx contractapi.TransactionContextInterface, jobID string) (*Job, error) {
+	job, err := requireJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job.ArchivedAt = now
+	if err := transitionJob(ctx, job, jobStatusArchived, now); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ReadJob returns a job.
+func (c *GatewayContract) ReadJob(ctx contractapi.TransactionContextInterface, jobID string) (*Job, error) {
+	return requireJob(ctx, jobID)
+}
+
+// ListJobs returns every job in key order.
+func (c *GatewayContract) ListJobs(ctx contractapi.TransactionContextInterface) ([]*Job, error) {
+	iter, err := ctx.GetStub().GetStateByRange(jobPrefix, jobPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer iter.Close()
+	jobs := []*Job{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var job Job
+		if err := json.Unmarshal(kv.Value, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func transitionJob(ctx contractapi.TransactionContextInterface, job *Job, next, at string) error {
+	allowed := false
+	for _, status := range jobTransitions[job.Status] {
+		if status == next {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("job %s cannot move from %s to %s", job.ID, job.Status, next)
+	}
+	job.Status = next
+	job.UpdatedAt = at
+	return putJob(ctx, job)
+}
+
+func requireJob(ctx contractapi.TransactionContextInterface, jobID string) (*Job, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		return nil, errors.New("job identifier is required")
+	}
+	job, err := readJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job %s not found", jobID)
+	}
+	return job, nil
+}
+
+func readJob(ctx contractapi.TransactionContextInterface, jobID string) (*Job, error) {
+	payload, err := ctx.GetStub().GetState(jobKey(jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var job Job
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func putJob(ctx contractapi.TransactionContextInterface, job *Job) error {
+	bytes, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, jobKey(job.ID), bytes)
+}
+
+func jobKey(jobID string) string {
+	return jobPrefix + jobID
+}
+
+func trainingConfigKey(jobID string) string {
+	return trainingConfigPrefix + jobID
+}
+
+// readTrainingConfig returns the latest config of a job, or nil. Configs stored before
+// versioning read as version 1 and frozen, which is how they behaved.
+func readTrainingConfig(ctx contractapi.TransactionContextInterface, jobID string) (*TrainingConfig, error) {
+	payload, err := ctx.GetStub().GetState(trainingConfigKey(jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read training config: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var record TrainingConfig
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	if record.Version == 0 {
+		record.Version = 1
+		record.Frozen = true
+	}
+	return &record, nil
+}
+
+func putTrainingConfigVersion(ctx contractapi.TransactionContextInterface, record *TrainingConfig) error {
+	key, err := trainingConfigVersionKey(ctx, record.JobID, record.Version)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, key, bytes)
+}
+
+func trainingConfigVersionKey(ctx contractapi.TransactionContextInterface, jobID string, version int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(trainingConfigVersionType, []string{jobID, fmt.Sprintf("%010d", version)})
+}
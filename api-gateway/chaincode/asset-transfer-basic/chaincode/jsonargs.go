@@ -0,0 +1,788 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// jsonOverloadSuffix names the JSON payload overload of a multi-argument function. An overload
+// takes one JSON object whose snake_case fields are the positional arguments, decodes it with
+// jsonArgs and calls the positional function, so both forms share validation, events and the
+// role policy.
+const jsonOverloadSuffix = "JSON"
+
+// jsonArgs decodes a JSON object payload into positional arguments ordered as names. Strings
+// pass through unchanged; numbers and booleans become their JSON text; arrays and objects
+// become compact JSON, which is what the positional functions parse. Missing and null fields
+// become "". Unknown fields are rejected so misspelt names do not silently fall back to
+// defaults.
+func jsonArgs(payload string, names ...string) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil || fields == nil {
+		return nil, errors.New("invalid payload: must be a JSON object")
+	}
+	args := make([]string, len(names))
+	for i, name := range names {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		delete(fields, name)
+		if isJSONNull(value) {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			args[i] = text
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, fmt.Errorf("invalid payload: %s: %w", name, err)
+		}
+		args[i] = compact.String()
+	}
+	if len(fields) > 0 {
+		unknown := make([]string, 0, len(fields))
+		for name := range fields {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("invalid payload: unknown field %q", unknown[0])
+	}
+	return args, nil
+}
+
+// RecordAggregationJSON is RecordAggregation taking a JSON object payload.
+func (c *GatewayContract) RecordAggregationJSON(ctx contractapi.TransactionContextInterface, payload string) (*AggregationRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id", "round", "input_model_ids", "output_model_id", "algorithm", "weights")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordAggregation(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7])
+}
+
+// ListAggregationsJSON is ListAggregations taking a JSON object payload.
+func (c *GatewayContract) ListAggregationsJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*AggregationRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListAggregations(ctx, args[0], args[1], args[2], args[3])
+}
+
+// RecordAnchorReceiptJSON is RecordAnchorReceipt taking a JSON object payload.
+func (c *GatewayContract) RecordAnchorReceiptJSON(ctx contractapi.TransactionContextInterface, payload string) (*AnchorReceipt, error) {
+	args, err := jsonArgs(payload, "anchor_id", "digest", "block_height", "block_hash", "namespaces", "endpoint", "receipt", "anchored_at")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordAnchorReceipt(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7])
+}
+
+// RecordAuditBatchJSON is RecordAuditBatch taking a JSON object payload.
+func (c *GatewayContract) RecordAuditBatchJSON(ctx contractapi.TransactionContextInterface, payload string) (*AuditBatch, error) {
+	args, err := jsonArgs(payload, "batch_id", "first_seq", "last_seq", "digest")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordAuditBatch(ctx, args[0], args[1], args[2], args[3])
+}
+
+// CommitAttestedModelJSON is CommitAttestedModel taking a JSON object payload.
+func (c *GatewayContract) CommitAttestedModelJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelRecord, error) {
+	args, err := jsonArgs(payload, "data_id", "layer", "scope_id", "payload", "parent_model_ids", "job_id", "round", "model_hash", "signature")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitAttestedModel(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8])
+}
+
+// ListWhitelistByCapabilityJSON is ListWhitelistByCapability taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistByCapabilityJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistListPage, error) {
+	args, err := jsonArgs(payload, "filter", "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelistByCapability(ctx, args[0], args[1], args[2])
+}
+
+// RecordContributionJSON is RecordContribution taking a JSON object payload.
+func (c *GatewayContract) RecordContributionJSON(ctx contractapi.TransactionContextInterface, payload string) (*Contribution, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "node_id", "samples", "loss_delta", "model_hash")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordContribution(ctx, args[0], args[1], args[2], args[3], args[4], args[5])
+}
+
+// ListContributionsJSON is ListContributions taking a JSON object payload.
+func (c *GatewayContract) ListContributionsJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*Contribution, error) {
+	args, err := jsonArgs(payload, "node_id", "job_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListContributions(ctx, args[0], args[1])
+}
+
+// ResetConvergenceJSON is ResetConvergence taking a JSON object payload.
+func (c *GatewayContract) ResetConvergenceJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceArchive, error) {
+	args, err := jsonArgs(payload, "scope", "state_id", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ResetConvergence(ctx, args[0], args[1], args[2])
+}
+
+// ResetConvergenceInRoundJSON is ResetConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) ResetConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceArchive, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "scope", "state_id", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ResetConvergenceInRound(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// ListConvergenceHistoryJSON is ListConvergenceHistory taking a JSON object payload.
+func (c *GatewayContract) ListConvergenceHistoryJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*ConvergenceArchive, error) {
+	args, err := jsonArgs(payload, "scope", "state_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListConvergenceHistory(ctx, args[0], args[1])
+}
+
+// CommitStateClusterConvergenceInRoundJSON is CommitStateClusterConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) CommitStateClusterConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id", "cluster_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitStateClusterConvergenceInRound(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// CommitNationStateConvergenceInRoundJSON is CommitNationStateConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) CommitNationStateConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitNationStateConvergenceInRound(ctx, args[0], args[1], args[2], args[3])
+}
+
+// DeclareStateConvergenceInRoundJSON is DeclareStateConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) DeclareStateConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceSummary, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.DeclareStateConvergenceInRound(ctx, args[0], args[1], args[2], args[3])
+}
+
+// DeclareNationConvergenceInRoundJSON is DeclareNationConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) DeclareNationConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceSummary, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.DeclareNationConvergenceInRound(ctx, args[0], args[1], args[2])
+}
+
+// ReadStateConvergenceInRoundJSON is ReadStateConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) ReadStateConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*StateConvergence, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadStateConvergenceInRound(ctx, args[0], args[1], args[2])
+}
+
+// ListStateConvergenceInRoundJSON is ListStateConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) ListStateConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (map[string]*StateConvergence, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListStateConvergenceInRound(ctx, args[0], args[1])
+}
+
+// ListNationConvergenceInRoundJSON is ListNationConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) ListNationConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*NationConvergence, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListNationConvergenceInRound(ctx, args[0], args[1])
+}
+
+// RecordStateVerificationJSON is RecordStateVerification taking a JSON object payload.
+func (c *GatewayContract) RecordStateVerificationJSON(ctx contractapi.TransactionContextInterface, payload string) (*StateVerification, error) {
+	args, err := jsonArgs(payload, "state_id", "model_id", "result", "evidence_hash", "note")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordStateVerification(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// RecordStateVerificationInRoundJSON is RecordStateVerificationInRound taking a JSON object payload.
+func (c *GatewayContract) RecordStateVerificationInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*StateVerification, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id", "model_id", "result", "evidence_hash", "note")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordStateVerificationInRound(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6])
+}
+
+// ListStateVerificationsInRoundJSON is ListStateVerificationsInRound taking a JSON object payload.
+func (c *GatewayContract) ListStateVerificationsInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*StateVerification, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListStateVerificationsInRound(ctx, args[0], args[1])
+}
+
+// ListPendingStateModelsInRoundJSON is ListPendingStateModelsInRound taking a JSON object payload.
+func (c *GatewayContract) ListPendingStateModelsInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*ModelRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListPendingStateModelsInRound(ctx, args[0], args[1])
+}
+
+// CreateDIDJSON is CreateDID taking a JSON object payload.
+func (c *GatewayContract) CreateDIDJSON(ctx contractapi.TransactionContextInterface, payload string) (*DIDRecord, error) {
+	args, err := jsonArgs(payload, "did", "document")
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateDID(ctx, args[0], args[1])
+}
+
+// UpdateDIDDocumentJSON is UpdateDIDDocument taking a JSON object payload.
+func (c *GatewayContract) UpdateDIDDocumentJSON(ctx contractapi.TransactionContextInterface, payload string) (*DIDRecord, error) {
+	args, err := jsonArgs(payload, "did", "document")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateDIDDocument(ctx, args[0], args[1])
+}
+
+// SubmitEvaluationJSON is SubmitEvaluation taking a JSON object payload.
+func (c *GatewayContract) SubmitEvaluationJSON(ctx contractapi.TransactionContextInterface, payload string) (*EvaluationRecord, error) {
+	args, err := jsonArgs(payload, "model_id", "dataset_id", "metrics", "signature")
+	if err != nil {
+		return nil, err
+	}
+	return c.SubmitEvaluation(ctx, args[0], args[1], args[2], args[3])
+}
+
+// GetEvaluationConsensusJSON is GetEvaluationConsensus taking a JSON object payload.
+func (c *GatewayContract) GetEvaluationConsensusJSON(ctx contractapi.TransactionContextInterface, payload string) (*EvaluationConsensus, error) {
+	args, err := jsonArgs(payload, "model_id", "metric")
+	if err != nil {
+		return nil, err
+	}
+	return c.GetEvaluationConsensus(ctx, args[0], args[1])
+}
+
+// RecordEvaluationRunJSON is RecordEvaluationRun taking a JSON object payload.
+func (c *GatewayContract) RecordEvaluationRunJSON(ctx contractapi.TransactionContextInterface, payload string) (*EvaluationRun, error) {
+	args, err := jsonArgs(payload, "model_id", "dataset_hash", "metrics")
+	if err != nil {
+		return nil, err
+	}
+	return c.RecordEvaluationRun(ctx, args[0], args[1], args[2])
+}
+
+// FlagModelJSON is FlagModel taking a JSON object payload.
+func (c *GatewayContract) FlagModelJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelFlag, error) {
+	args, err := jsonArgs(payload, "model_id", "reason", "evidence_hash")
+	if err != nil {
+		return nil, err
+	}
+	return c.FlagModel(ctx, args[0], args[1], args[2])
+}
+
+// ListModelFlagsJSON is ListModelFlags taking a JSON object payload.
+func (c *GatewayContract) ListModelFlagsJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*ModelFlag, error) {
+	args, err := jsonArgs(payload, "node_id", "model_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListModelFlags(ctx, args[0], args[1])
+}
+
+// ReinstateNodeJSON is ReinstateNode taking a JSON object payload.
+func (c *GatewayContract) ReinstateNodeJSON(ctx contractapi.TransactionContextInterface, payload string) (*FlagTally, error) {
+	args, err := jsonArgs(payload, "node_id", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReinstateNode(ctx, args[0], args[1])
+}
+
+// RegisterTrainerJSON is RegisterTrainer taking a JSON object payload.
+func (c *GatewayContract) RegisterTrainerJSON(ctx contractapi.TransactionContextInterface, payload string) error {
+	args, err := jsonArgs(payload, "did", "node_id", "vc_hash", "public_key", "state", "cluster")
+	if err != nil {
+		return err
+	}
+	return c.RegisterTrainer(ctx, args[0], args[1], args[2], args[3], args[4], args[5])
+}
+
+// CommitDataJSON is CommitData taking a JSON object payload.
+func (c *GatewayContract) CommitDataJSON(ctx contractapi.TransactionContextInterface, payload string) (*DataRecord, error) {
+	args, err := jsonArgs(payload, "data_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitData(ctx, args[0], args[1])
+}
+
+// CommitModelJSON is CommitModel taking a JSON object payload.
+func (c *GatewayContract) CommitModelJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelRecord, error) {
+	args, err := jsonArgs(payload, "data_id", "layer", "scope_id", "payload", "parent_model_ids")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitModel(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// ListModelsJSON is ListModels taking a JSON object payload.
+func (c *GatewayContract) ListModelsJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelListPage, error) {
+	args, err := jsonArgs(payload, "layer", "scope_id", "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListModels(ctx, args[0], args[1], args[2], args[3])
+}
+
+// RecordWhitelistEntryJSON is RecordWhitelistEntry taking a JSON object payload.
+func (c *GatewayContract) RecordWhitelistEntryJSON(ctx contractapi.TransactionContextInterface, payload string) error {
+	args, err := jsonArgs(payload, "jwt_sub", "did", "node_id", "state", "cluster", "vc_hash", "public_key", "registered", "capabilities")
+	if err != nil {
+		return err
+	}
+	return c.RecordWhitelistEntry(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8])
+}
+
+// ListWhitelistJSON is ListWhitelist taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistListPage, error) {
+	args, err := jsonArgs(payload, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelist(ctx, args[0], args[1])
+}
+
+// CommitStateClusterConvergenceJSON is CommitStateClusterConvergence taking a JSON object payload.
+func (c *GatewayContract) CommitStateClusterConvergenceJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceRecord, error) {
+	args, err := jsonArgs(payload, "state_id", "cluster_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitStateClusterConvergence(ctx, args[0], args[1], args[2])
+}
+
+// CommitNationStateConvergenceJSON is CommitNationStateConvergence taking a JSON object payload.
+func (c *GatewayContract) CommitNationStateConvergenceJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceRecord, error) {
+	args, err := jsonArgs(payload, "state_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitNationStateConvergence(ctx, args[0], args[1])
+}
+
+// DeclareStateConvergenceJSON is DeclareStateConvergence taking a JSON object payload.
+func (c *GatewayContract) DeclareStateConvergenceJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceSummary, error) {
+	args, err := jsonArgs(payload, "state_id", "payload")
+	if err != nil {
+		return nil, err
+	}
+	return c.DeclareStateConvergence(ctx, args[0], args[1])
+}
+
+// NominateGlobalModelJSON is NominateGlobalModel taking a JSON object payload.
+func (c *GatewayContract) NominateGlobalModelJSON(ctx contractapi.TransactionContextInterface, payload string) (*GlobalCandidate, error) {
+	args, err := jsonArgs(payload, "model_id", "note")
+	if err != nil {
+		return nil, err
+	}
+	return c.NominateGlobalModel(ctx, args[0], args[1])
+}
+
+// ReviewGlobalCandidateJSON is ReviewGlobalCandidate taking a JSON object payload.
+func (c *GatewayContract) ReviewGlobalCandidateJSON(ctx contractapi.TransactionContextInterface, payload string) (*GlobalCandidate, error) {
+	args, err := jsonArgs(payload, "model_id", "decision", "note")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReviewGlobalCandidate(ctx, args[0], args[1], args[2])
+}
+
+// PublishGlobalModelJSON is PublishGlobalModel taking a JSON object payload.
+func (c *GatewayContract) PublishGlobalModelJSON(ctx contractapi.TransactionContextInterface, payload string) (*GlobalModel, error) {
+	args, err := jsonArgs(payload, "model_id", "model_cid", "model_hash")
+	if err != nil {
+		return nil, err
+	}
+	return c.PublishGlobalModel(ctx, args[0], args[1], args[2])
+}
+
+// CreateJobJSON is CreateJob taking a JSON object payload.
+func (c *GatewayContract) CreateJobJSON(ctx contractapi.TransactionContextInterface, payload string) (*Job, error) {
+	args, err := jsonArgs(payload, "job_id", "name", "description")
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateJob(ctx, args[0], args[1], args[2])
+}
+
+// UpdateJobJSON is UpdateJob taking a JSON object payload.
+func (c *GatewayContract) UpdateJobJSON(ctx contractapi.TransactionContextInterface, payload string) (*Job, error) {
+	args, err := jsonArgs(payload, "job_id", "name", "description")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateJob(ctx, args[0], args[1], args[2])
+}
+
+// UpsertTrainingConfigJSON is UpsertTrainingConfig taking a JSON object payload.
+func (c *GatewayContract) UpsertTrainingConfigJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingConfig, error) {
+	args, err := jsonArgs(payload, "job_id", "config")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpsertTrainingConfig(ctx, args[0], args[1])
+}
+
+// UpsertTrainingConfigWithFreezeJSON is UpsertTrainingConfigWithFreeze taking a JSON object payload.
+func (c *GatewayContract) UpsertTrainingConfigWithFreezeJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingConfig, error) {
+	args, err := jsonArgs(payload, "job_id", "config", "freeze")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpsertTrainingConfigWithFreeze(ctx, args[0], args[1], args[2])
+}
+
+// GetTrainingConfigVersionJSON is GetTrainingConfigVersion taking a JSON object payload.
+func (c *GatewayContract) GetTrainingConfigVersionJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingConfig, error) {
+	args, err := jsonArgs(payload, "job_id", "version")
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTrainingConfigVersion(ctx, args[0], args[1])
+}
+
+// CompleteJobJSON is CompleteJob taking a JSON object payload.
+func (c *GatewayContract) CompleteJobJSON(ctx contractapi.TransactionContextInterface, payload string) (*Job, error) {
+	args, err := jsonArgs(payload, "job_id", "final_model_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.CompleteJob(ctx, args[0], args[1])
+}
+
+// PublishKeyShareJSON is PublishKeyShare taking a JSON object payload.
+func (c *GatewayContract) PublishKeyShareJSON(ctx contractapi.TransactionContextInterface, payload string) (*KeyShare, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "public_key")
+	if err != nil {
+		return nil, err
+	}
+	return c.PublishKeyShare(ctx, args[0], args[1], args[2])
+}
+
+// ListKeySharesJSON is ListKeyShares taking a JSON object payload.
+func (c *GatewayContract) ListKeySharesJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*KeyShare, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListKeyShares(ctx, args[0], args[1])
+}
+
+// GetModelLineageJSON is GetModelLineage taking a JSON object payload.
+func (c *GatewayContract) GetModelLineageJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelLineage, error) {
+	args, err := jsonArgs(payload, "model_id", "max_depth")
+	if err != nil {
+		return nil, err
+	}
+	return c.GetModelLineage(ctx, args[0], args[1])
+}
+
+// CommitModelWithMetadataJSON is CommitModelWithMetadata taking a JSON object payload.
+func (c *GatewayContract) CommitModelWithMetadataJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelRecord, error) {
+	args, err := jsonArgs(payload, "data_id", "layer", "scope_id", "payload", "parent_model_ids", "job_id", "round", "model_hash", "signature", "metadata")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitModelWithMetadata(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8], args[9])
+}
+
+// QueryModelsJSON is QueryModels taking a JSON object payload.
+func (c *GatewayContract) QueryModelsJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelQueryPage, error) {
+	args, err := jsonArgs(payload, "filter", "page_size", "bookmark")
+	if err != nil {
+		return nil, err
+	}
+	return c.QueryModels(ctx, args[0], args[1], args[2])
+}
+
+// ListModelsFilteredJSON is ListModelsFiltered taking a JSON object payload.
+func (c *GatewayContract) ListModelsFilteredJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelListPage, error) {
+	args, err := jsonArgs(payload, "filter", "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListModelsFiltered(ctx, args[0], args[1], args[2])
+}
+
+// ListLatestModelsJSON is ListLatestModels taking a JSON object payload.
+func (c *GatewayContract) ListLatestModelsJSON(ctx contractapi.TransactionContextInterface, payload string) (map[string]*ModelRecord, error) {
+	args, err := jsonArgs(payload, "layer", "scope_ids")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListLatestModels(ctx, args[0], args[1])
+}
+
+// CommitNationAggregationJSON is CommitNationAggregation taking a JSON object payload.
+func (c *GatewayContract) CommitNationAggregationJSON(ctx contractapi.TransactionContextInterface, payload string) (*NationAggregation, error) {
+	args, err := jsonArgs(payload, "round", "model_cid", "states", "metadata")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitNationAggregation(ctx, args[0], args[1], args[2], args[3])
+}
+
+// AddRevokedVCHashJSON is AddRevokedVCHash taking a JSON object payload.
+func (c *GatewayContract) AddRevokedVCHashJSON(ctx contractapi.TransactionContextInterface, payload string) (*RevokedVC, error) {
+	args, err := jsonArgs(payload, "vc_hash", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.AddRevokedVCHash(ctx, args[0], args[1])
+}
+
+// StartRoundJSON is StartRound taking a JSON object payload.
+func (c *GatewayContract) StartRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingRound, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.StartRound(ctx, args[0], args[1], args[2])
+}
+
+// CloseRoundJSON is CloseRound taking a JSON object payload.
+func (c *GatewayContract) CloseRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingRound, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.CloseRound(ctx, args[0], args[1], args[2], args[3])
+}
+
+// ExpireRoundJSON is ExpireRound taking a JSON object payload.
+func (c *GatewayContract) ExpireRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingRound, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id", "round", "min_quorum")
+	if err != nil {
+		return nil, err
+	}
+	return c.ExpireRound(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// GetCurrentRoundJSON is GetCurrentRound taking a JSON object payload.
+func (c *GatewayContract) GetCurrentRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainingRound, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id")
+	if err != nil {
+		return nil, err
+	}
+	return c.GetCurrentRound(ctx, args[0], args[1], args[2])
+}
+
+// ListRoundModelsJSON is ListRoundModels taking a JSON object payload.
+func (c *GatewayContract) ListRoundModelsJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*ModelRecord, error) {
+	args, err := jsonArgs(payload, "job_id", "layer", "scope_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListRoundModels(ctx, args[0], args[1], args[2], args[3])
+}
+
+// CommitModelInRoundJSON is CommitModelInRound taking a JSON object payload.
+func (c *GatewayContract) CommitModelInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelRecord, error) {
+	args, err := jsonArgs(payload, "data_id", "job_id", "layer", "scope_id", "round", "payload", "parent_model_ids")
+	if err != nil {
+		return nil, err
+	}
+	return c.CommitModelInRound(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6])
+}
+
+// UpdateTrainerJSON is UpdateTrainer taking a JSON object payload.
+func (c *GatewayContract) UpdateTrainerJSON(ctx contractapi.TransactionContextInterface, payload string) (*TrainerUpdate, error) {
+	args, err := jsonArgs(payload, "did", "jwt_sub", "public_key", "state", "cluster", "reason", "requested_by")
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdateTrainer(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6])
+}
+
+// ListWhitelistByStateJSON is ListWhitelistByState taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistByStateJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistListPage, error) {
+	args, err := jsonArgs(payload, "state_id", "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelistByState(ctx, args[0], args[1], args[2])
+}
+
+// ListWhitelistByClusterJSON is ListWhitelistByCluster taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistByClusterJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistListPage, error) {
+	args, err := jsonArgs(payload, "cluster_id", "state_id", "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelistByCluster(ctx, args[0], args[1], args[2], args[3])
+}
+
+// ListWhitelistHierarchyJSON is ListWhitelistHierarchy taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistHierarchyJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistHierarchyPage, error) {
+	args, err := jsonArgs(payload, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelistHierarchy(ctx, args[0], args[1])
+}
+
+// DeactivateWhitelistEntryJSON is DeactivateWhitelistEntry taking a JSON object payload.
+func (c *GatewayContract) DeactivateWhitelistEntryJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistEntry, error) {
+	args, err := jsonArgs(payload, "jwt_sub", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.DeactivateWhitelistEntry(ctx, args[0], args[1])
+}
+
+// RemoveWhitelistEntryJSON is RemoveWhitelistEntry taking a JSON object payload.
+func (c *GatewayContract) RemoveWhitelistEntryJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistTombstone, error) {
+	args, err := jsonArgs(payload, "jwt_sub", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.RemoveWhitelistEntry(ctx, args[0], args[1])
+}
+
+// ListModelsPageJSON is ListModelsPage taking a JSON object payload.
+func (c *GatewayContract) ListModelsPageJSON(ctx contractapi.TransactionContextInterface, payload string) (*ModelBookmarkPage, error) {
+	args, err := jsonArgs(payload, "layer", "scope_id", "page_size", "bookmark", "total")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListModelsPage(ctx, args[0], args[1], args[2], args[3], args[4])
+}
+
+// ListWhitelistPageJSON is ListWhitelistPage taking a JSON object payload.
+func (c *GatewayContract) ListWhitelistPageJSON(ctx contractapi.TransactionContextInterface, payload string) (*WhitelistBookmarkPage, error) {
+	args, err := jsonArgs(payload, "page_size", "bookmark", "total")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListWhitelistPage(ctx, args[0], args[1], args[2])
+}
+
+// AcquireAggregationLeaseJSON is AcquireAggregationLease taking a JSON object payload.
+func (c *GatewayContract) AcquireAggregationLeaseJSON(ctx contractapi.TransactionContextInterface, payload string) (*AggregationLease, error) {
+	args, err := jsonArgs(payload, "job_id", "scope_id", "round", "ttl")
+	if err != nil {
+		return nil, err
+	}
+	return c.AcquireAggregationLease(ctx, args[0], args[1], args[2], args[3])
+}
+
+// RenewAggregationLeaseJSON is RenewAggregationLease taking a JSON object payload.
+func (c *GatewayContract) RenewAggregationLeaseJSON(ctx contractapi.TransactionContextInterface, payload string) (*AggregationLease, error) {
+	args, err := jsonArgs(payload, "job_id", "scope_id", "round", "ttl")
+	if err != nil {
+		return nil, err
+	}
+	return c.RenewAggregationLease(ctx, args[0], args[1], args[2], args[3])
+}
+
+// ReleaseAggregationLeaseJSON is ReleaseAggregationLease taking a JSON object payload.
+func (c *GatewayContract) ReleaseAggregationLeaseJSON(ctx contractapi.TransactionContextInterface, payload string) (*AggregationLease, error) {
+	args, err := jsonArgs(payload, "job_id", "scope_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ReleaseAggregationLease(ctx, args[0], args[1], args[2])
+}
+
+// GetAggregationLeaseJSON is GetAggregationLease taking a JSON object payload.
+func (c *GatewayContract) GetAggregationLeaseJSON(ctx contractapi.TransactionContextInterface, payload string) (*AggregationLease, error) {
+	args, err := jsonArgs(payload, "job_id", "scope_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAggregationLease(ctx, args[0], args[1], args[2])
+}
+
+// ApplyClusteringPlanJSON is ApplyClusteringPlan taking a JSON object payload.
+func (c *GatewayContract) ApplyClusteringPlanJSON(ctx contractapi.TransactionContextInterface, payload string) (*MembershipEpoch, error) {
+	args, err := jsonArgs(payload, "assignments", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ApplyClusteringPlan(ctx, args[0], args[1])
+}
+
+// ExportStatePageJSON is ExportStatePage taking a JSON object payload.
+func (c *GatewayContract) ExportStatePageJSON(ctx contractapi.TransactionContextInterface, payload string) (*SnapshotPage, error) {
+	args, err := jsonArgs(payload, "namespace", "page_size", "bookmark")
+	if err != nil {
+		return nil, err
+	}
+	return c.ExportStatePage(ctx, args[0], args[1], args[2])
+}
+
+// ChallengeConvergenceJSON is ChallengeConvergence taking a JSON object payload.
+func (c *GatewayContract) ChallengeConvergenceJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceDispute, error) {
+	args, err := jsonArgs(payload, "state_id", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ChallengeConvergence(ctx, args[0], args[1])
+}
+
+// ChallengeConvergenceInRoundJSON is ChallengeConvergenceInRound taking a JSON object payload.
+func (c *GatewayContract) ChallengeConvergenceInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceDispute, error) {
+	args, err := jsonArgs(payload, "job_id", "round", "state_id", "reason")
+	if err != nil {
+		return nil, err
+	}
+	return c.ChallengeConvergenceInRound(ctx, args[0], args[1], args[2], args[3])
+}
+
+// ResolveConvergenceDisputeJSON is ResolveConvergenceDispute taking a JSON object payload.
+func (c *GatewayContract) ResolveConvergenceDisputeJSON(ctx contractapi.TransactionContextInterface, payload string) (*ConvergenceDispute, error) {
+	args, err := jsonArgs(payload, "dispute_id", "decision", "note")
+	if err != nil {
+		return nil, err
+	}
+	return c.ResolveConvergenceDispute(ctx, args[0], args[1], args[2])
+}
+
+// ListConvergenceDisputesInRoundJSON is ListConvergenceDisputesInRound taking a JSON object payload.
+func (c *GatewayContract) ListConvergenceDisputesInRoundJSON(ctx contractapi.TransactionContextInterface, payload string) ([]*ConvergenceDispute, error) {
+	args, err := jsonArgs(payload, "job_id", "round")
+	if err != nil {
+		return nil, err
+	}
+	return c.ListConvergenceDisputesInRound(ctx, args[0], args[1])
+}
@@ -0,0 +1,146 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// KeyShare is the public key a trainer publishes for one cluster round of a secure
+// aggregation protocol.
+type KeyShare struct {
+	JobID       string `json:"job_id"`
+	ClusterID   string `json:"cluster_id"`
+	Round       int    `json:"round"`
+	NodeID      string `json:"node_id"`
+	PublicKey   string `json:"public_key"`
+	TxID        string `json:"tx_id"`
+	PublishedAt string `json:"published_at"`
+}
+
+const (
+	// keyShareIndexType keys shares by job, cluster, round and node; the value is the share.
+	keyShareIndexType = "keyshare~job~cluster~round~node"
+	keyShareLayer     = "cluster"
+	maxKeyShareBytes  = 1024
+)
+
+// PublishKeyShare stores the caller's public key share for a round of its cluster. publicKey
+// is base64 encoded. The cluster round must be open and each trainer publishes once per round.
+func (c *GatewayContract) PublishKeyShare(ctx contractapi.TransactionContextInterface, jobID, roundArg, publicKey string) (*KeyShare, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterID, err := trainerCluster(trainer)
+	if err != nil {
+		return nil, err
+	}
+	jobID, _, clusterID, err = normalizeRoundScope(jobID, keyShareLayer, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	publicKey = strings.TrimSpace(publicKey)
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("publicKey must be non-empty base64")
+	}
+	if len(key) > maxKeyShareBytes {
+		return nil, fmt.Errorf("publicKey must not exceed %d bytes", maxKeyShareBytes)
+	}
+	if err := requireOpenRound(ctx, jobID, keyShareLayer, clusterID, round); err != nil {
+		return nil, err
+	}
+	indexKey, err := keyShareKey(ctx, jobID, clusterID, round, trainer.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key share: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("key share for round %d already published by %s", round, trainer.NodeID)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	share := &KeyShare{
+		JobID:       jobID,
+		ClusterID:   clusterID,
+		Round:       round,
+		NodeID:      trainer.NodeID,
+		PublicKey:   publicKey,
+		TxID:        ctx.GetStub().GetTxID(),
+		PublishedAt: now,
+	}
+	payload, err := json.Marshal(share)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, indexKey, payload); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// ListKeyShares returns the key shares published for a round of the caller's cluster. Only
+// authorized trainers of that cluster can read them.
+func (c *GatewayContract) ListKeyShares(ctx contractapi.TransactionContextInterface, jobID, roundArg string) ([]*KeyShare, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterID, err := trainerCluster(trainer)
+	if err != nil {
+		return nil, err
+	}
+	jobID, _, clusterID, err = normalizeRoundScope(jobID, keyShareLayer, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(keyShareIndexType, []string{jobID, strings.ToLower(clusterID), fmt.Sprintf("%010d", round)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list key shares: %w", err)
+	}
+	defer iter.Close()
+	shares := make([]*KeyShare, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var share KeyShare
+		if err := json.Unmarshal(kv.Value, &share); err != nil {
+			return nil, err
+		}
+		shares = append(shares, &share)
+	}
+	return shares, nil
+}
+
+func trainerCluster(trainer *Trainer) (string, error) {
+	clusterID := strings.TrimSpace(trainer.Cluster)
+	if clusterID == "" {
+		return "", fmt.Errorf("trainer %s has no cluster", trainer.NodeID)
+	}
+	return clusterID, nil
+}
+
+func keyShareKey(ctx contractapi.TransactionContextInterface, jobID, clusterID string, round int, nodeID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(keyShareIndexType, []string{jobID, strings.ToLower(clusterID), fmt.Sprintf("%010d", round), nodeID})
+}
@@ -0,0 +1,236 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AggregationLease grants one aggregator the exclusive right to aggregate a round of a scope
+// until ExpiresAt. Active is computed against the transaction timestamp whenever the lease is
+// returned.
+type AggregationLease struct {
+	JobID      string `json:"job_id"`
+	ScopeID    string `json:"scope_id"`
+	Round      int    `json:"round"`
+	Holder     string `json:"holder"`
+	AcquiredAt string `json:"acquired_at"`
+	RenewedAt  string `json:"renewed_at,omitempty"`
+	ExpiresAt  string `json:"expires_at"`
+	ReleasedAt string `json:"released_at,omitempty"`
+	TxID       string `json:"tx_id"`
+	Active     bool   `json:"active"`
+}
+
+const (
+	aggregationLeasePrefix = "lease:aggregation:"
+	// maxAggregationLeaseTTL bounds a lease, so a crashed aggregator blocks its round for at
+	// most this long.
+	maxAggregationLeaseTTL = time.Hour
+)
+
+// AcquireAggregationLease grants the caller the aggregation lease of a round for ttlArg
+// seconds. It fails while another aggregator holds an active lease; the current holder
+// acquiring again extends its lease.
+func (c *GatewayContract) AcquireAggregationLease(ctx contractapi.TransactionContextInterface, jobID, scopeID, roundArg, ttlArg string) (*AggregationLease, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobID, scopeID, round, err := normalizeLeaseScope(jobID, scopeID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := parseLeaseTTL(ttlArg)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := readAggregationLease(ctx, jobID, scopeID, round)
+	if err != nil {
+		return nil, err
+	}
+	if lease != nil && lease.activeAt(now) && lease.Holder != trainer.NodeID {
+		return nil, fmt.Errorf("aggregation lease of round %d for %s is held by %s until %s", round, scopeID, lease.Holder, lease.ExpiresAt)
+	}
+	if lease == nil || !lease.activeAt(now) {
+		lease = &AggregationLease{JobID: jobID, ScopeID: scopeID, Round: round, Holder: trainer.NodeID, AcquiredAt: now.Format(time.RFC3339)}
+	} else {
+		lease.RenewedAt = now.Format(time.RFC3339)
+	}
+	lease.ExpiresAt = now.Add(ttl).Format(time.RFC3339)
+	if err := putAggregationLease(ctx, lease, now); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// RenewAggregationLease extends the caller's active lease to ttlArg seconds from now.
+func (c *GatewayContract) RenewAggregationLease(ctx contractapi.TransactionContextInterface, jobID, scopeID, roundArg, ttlArg string) (*AggregationLease, error) {
+	ttl, err := parseLeaseTTL(ttlArg)
+	if err != nil {
+		return nil, err
+	}
+	lease, now, err := c.requireHeldLease(ctx, jobID, scopeID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	lease.RenewedAt = now.Format(time.RFC3339)
+	lease.ExpiresAt = now.Add(ttl).Format(time.RFC3339)
+	if err := putAggregationLease(ctx, lease, now); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// ReleaseAggregationLease ends the caller's active lease, so another aggregator can take over
+// without waiting for it to expire.
+func (c *GatewayContract) ReleaseAggregationLease(ctx contractapi.TransactionContextInterface, jobID, scopeID, roundArg string) (*AggregationLease, error) {
+	lease, now, err := c.requireHeldLease(ctx, jobID, scopeID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	lease.ReleasedAt = now.Format(time.RFC3339)
+	if err := putAggregationLease(ctx, lease, now); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// GetAggregationLease returns the latest lease of a round, active or not.
+func (c *GatewayContract) GetAggregationLease(ctx contractapi.TransactionContextInterface, jobID, scopeID, roundArg string) (*AggregationLease, error) {
+	jobID, scopeID, round, err := normalizeLeaseScope(jobID, scopeID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := readAggregationLease(ctx, jobID, scopeID, round)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		return nil, fmt.Errorf("no aggregation lease for round %d of %s", round, scopeID)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lease.Active = lease.activeAt(now)
+	return lease, nil
+}
+
+// requireHeldLease returns the round's lease when the caller holds it and it is still active.
+func (c *GatewayContract) requireHeldLease(ctx contractapi.TransactionContextInterface, jobID, scopeID, roundArg string) (*AggregationLease, time.Time, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	jobID, scopeID, round, err := normalizeLeaseScope(jobID, scopeID, roundArg)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	lease, err := readAggregationLease(ctx, jobID, scopeID, round)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if lease == nil || !lease.activeAt(now) {
+		return nil, time.Time{}, fmt.Errorf("no active aggregation lease for round %d of %s", round, scopeID)
+	}
+	if lease.Holder != trainer.NodeID {
+		return nil, time.Time{}, fmt.Errorf("aggregation lease of round %d for %s is held by %s, not the caller", round, scopeID, lease.Holder)
+	}
+	return lease, now, nil
+}
+
+// requireAggregationLeaseHolder rejects an aggregation of a round while another aggregator
+// holds its active lease. Rounds nobody leased stay open to every aggregator.
+func requireAggregationLeaseHolder(ctx contractapi.TransactionContextInterface, jobID, scopeID string, round int, nodeID string) error {
+	lease, err := readAggregationLease(ctx, jobID, scopeID, round)
+	if err != nil || lease == nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if lease.activeAt(now) && lease.Holder != nodeID {
+		return fmt.Errorf("aggregation lease of round %d for %s is held by %s until %s", round, scopeID, lease.Holder, lease.ExpiresAt)
+	}
+	return nil
+}
+
+func (l *AggregationLease) activeAt(now time.Time) bool {
+	if l.ReleasedAt != "" {
+		return false
+	}
+	expires, err := time.Parse(time.RFC3339, l.ExpiresAt)
+	return err == nil && now.Before(expires)
+}
+
+func readAggregationLease(ctx contractapi.TransactionContextInterface, jobID, scopeID string, round int) (*AggregationLease, error) {
+	payload, err := ctx.GetStub().GetState(aggregationLeaseKey(jobID, scopeID, round))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aggregation lease: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var lease AggregationLease
+	if err := json.Unmarshal(payload, &lease); err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func putAggregationLease(ctx contractapi.TransactionContextInterface, lease *AggregationLease, now time.Time) error {
+	lease.TxID = ctx.GetStub().GetTxID()
+	lease.Active = lease.activeAt(now)
+	bytes, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, aggregationLeaseKey(lease.JobID, lease.ScopeID, lease.Round), bytes)
+}
+
+func normalizeLeaseScope(jobID, scopeID, roundArg string) (string, string, int, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	scopeID = strings.TrimSpace(scopeID)
+	if scopeID == "" {
+		return "", "", 0, errors.New("scope identifier is required")
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return "", "", 0, errors.New("round must be a positive integer")
+	}
+	return jobID, scopeID, round, nil
+}
+
+func parseLeaseTTL(raw string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || seconds < 1 {
+		return 0, errors.New("lease ttl must be a positive number of seconds")
+	}
+	ttl := time.Duration(seconds) * time.Second
+	if ttl > maxAggregationLeaseTTL {
+		return 0, fmt.Errorf("lease ttl must not exceed %d seconds", int(maxAggregationLeaseTTL.Seconds()))
+	}
+	return ttl, nil
+}
+
+func aggregationLeaseKey(jobID, scopeID string, round int) string {
+	return fmt.Sprintf("%s%s:%s:%010d", aggregationLeasePrefix, jobID, strings.ToLower(scopeID), round)
+}
@@ -0,0 +1,188 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// InputLimits bounds what a single transaction can write, so an accidental multi-megabyte
+// argument is refused before it reaches the ledger. A zero field falls back to its default.
+type InputLimits struct {
+	// MaxArgBytes bounds each transaction argument.
+	MaxArgBytes int `json:"max_arg_bytes"`
+	// MaxInputBytes bounds all arguments of a transaction together.
+	MaxInputBytes int `json:"max_input_bytes"`
+	// MaxValueBytes bounds each value written to the ledger.
+	MaxValueBytes int `json:"max_value_bytes"`
+	// MaxKeyBytes bounds each ledger key, composite keys included.
+	MaxKeyBytes int    `json:"max_key_bytes"`
+	UpdatedBy   string `json:"updated_by,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+// InputLimitError reports which limit a transaction exceeded. Fabric only carries the error
+// message back to the client, so the message embeds the error as JSON after
+// inputLimitErrorPrefix.
+type InputLimitError struct {
+	Limit    string `json:"limit"`
+	Function string `json:"function,omitempty"`
+	// Argument is the 1-based position of the offending argument.
+	Argument int `json:"argument,omitempty"`
+	// Key is the offending ledger key, truncated to maxReportedKeyBytes.
+	Key  string `json:"key,omitempty"`
+	Size int    `json:"size"`
+	Max  int    `json:"max"`
+}
+
+const (
+	inputLimitsKey        = "config:input-limits"
+	inputLimitErrorPrefix = "input limit exceeded: "
+	maxReportedKeyBytes   = 64
+
+	defaultMaxArgBytes   = 256 << 10
+	defaultMaxInputBytes = 1 << 20
+	defaultMaxValueBytes = 1 << 20
+	defaultMaxKeyBytes   = 512
+)
+
+func (e *InputLimitError) Error() string {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return inputLimitErrorPrefix + e.Limit
+	}
+	return inputLimitErrorPrefix + string(detail)
+}
+
+// SetInputLimits replaces the input limits. limitsArg is a JSON InputLimits; omitted or zero
+// fields restore their defaults.
+func (c *GatewayContract) SetInputLimits(ctx contractapi.TransactionContextInterface, limitsArg string) (*InputLimits, error) {
+	var limits InputLimits
+	if err := json.Unmarshal([]byte(limitsArg), &limits); err != nil {
+		return nil, errors.New("limits must be a JSON object")
+	}
+	for name, value := range map[string]int{
+		"max_arg_bytes":   limits.MaxArgBytes,
+		"max_input_bytes": limits.MaxInputBytes,
+		"max_value_bytes": limits.MaxValueBytes,
+		"max_key_bytes":   limits.MaxKeyBytes,
+	} {
+		if value < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", name)
+		}
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	limits.applyDefaults()
+	if limits.MaxArgBytes > limits.MaxInputBytes {
+		return nil, errors.New("max_arg_bytes must not be larger than max_input_bytes")
+	}
+	limits.UpdatedBy = clientID
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limits.UpdatedAt = now
+	payload, err := json.Marshal(limits)
+	if err != nil {
+		return nil, err
+	}
+	// Bypasses putState: a max_key_bytes below the length of inputLimitsKey must not lock it.
+	if err := ctx.GetStub().PutState(inputLimitsKey, payload); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// GetInputLimits returns the input limits in force.
+func (c *GatewayContract) GetInputLimits(ctx contractapi.TransactionContextInterface) (*InputLimits, error) {
+	return readInputLimits(ctx)
+}
+
+func readInputLimits(ctx contractapi.TransactionContextInterface) (*InputLimits, error) {
+	payload, err := ctx.GetStub().GetState(inputLimitsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input limits: %w", err)
+	}
+	limits := &InputLimits{}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, limits); err != nil {
+			return nil, err
+		}
+	}
+	limits.applyDefaults()
+	return limits, nil
+}
+
+func (l *InputLimits) applyDefaults() {
+	if l.MaxArgBytes == 0 {
+		l.MaxArgBytes = defaultMaxArgBytes
+	}
+	if l.MaxInputBytes == 0 {
+		l.MaxInputBytes = defaultMaxInputBytes
+	}
+	if l.MaxValueBytes == 0 {
+		l.MaxValueBytes = defaultMaxValueBytes
+	}
+	if l.MaxKeyBytes == 0 {
+		l.MaxKeyBytes = defaultMaxKeyBytes
+	}
+}
+
+// enforceInputLimits rejects transactions whose arguments exceed the input limits. It runs
+// ahead of every transaction, before any argument is parsed. SetInputLimits is exempt so
+// limits set too low can always be raised again.
+func enforceInputLimits(ctx contractapi.TransactionContextInterface) error {
+	args := ctx.GetStub().GetArgs()
+	if len(args) == 0 {
+		return nil
+	}
+	function := string(args[0])
+	if _, name, found := strings.Cut(function, ":"); found {
+		function = name
+	}
+	if function == "SetInputLimits" {
+		return nil
+	}
+	limits, err := readInputLimits(ctx)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for i, arg := range args[1:] {
+		if len(arg) > limits.MaxArgBytes {
+			return &InputLimitError{Limit: "max_arg_bytes", Function: function, Argument: i + 1, Size: len(arg), Max: limits.MaxArgBytes}
+		}
+		total += len(arg)
+	}
+	if total > limits.MaxInputBytes {
+		return &InputLimitError{Limit: "max_input_bytes", Function: function, Size: total, Max: limits.MaxInputBytes}
+	}
+	return nil
+}
+
+// putState writes a ledger entry after checking its key and value against the input limits.
+// Every write goes through it, so the limits also cover keys and records the chaincode
+// derives from its arguments.
+func putState(ctx contractapi.TransactionContextInterface, key string, value []byte) error {
+	limits, err := readInputLimits(ctx)
+	if err != nil {
+		return err
+	}
+	if len(key) > limits.MaxKeyBytes {
+		reported := key
+		if len(reported) > maxReportedKeyBytes {
+			reported = reported[:maxReportedKeyBytes]
+		}
+		return &InputLimitError{Limit: "max_key_bytes", Key: reported, Size: len(key), Max: limits.MaxKeyBytes}
+	}
+	if len(value) > limits.MaxValueBytes {
+		return &InputLimitError{Limit: "max_value_bytes", Key: key, Size: len(value), Max: limits.MaxValueBytes}
+	}
+	return ctx.GetStub().PutState(key, value)
+}
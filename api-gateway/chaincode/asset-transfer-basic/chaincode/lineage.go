@@ -0,0 +1,134 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// LineageNode is an ancestor of a model together with its distance from the requested model.
+type LineageNode struct {
+	Depth int          `json:"depth"`
+	Model *ModelRecord `json:"model"`
+}
+
+// ModelLineage lists every ancestor reachable through ParentModelIDs, breadth first.
+type ModelLineage struct {
+	ModelID   string         `json:"model_id"`
+	Model     *ModelRecord   `json:"model"`
+	Ancestors []*LineageNode `json:"ancestors"`
+	Missing   []string       `json:"missing,omitempty"`
+	Truncated bool           `json:"truncated"`
+}
+
+const defaultLineageDepth = 32
+
+// GetModelLineage walks the parents of a model up to maxDepth generations (default 32).
+func (c *GatewayContract) GetModelLineage(ctx contractapi.TransactionContextInterface, modelID, maxDepthArg string) (*ModelLineage, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil, errors.New("model identifier is required")
+	}
+	maxDepth := defaultLineageDepth
+	if strings.TrimSpace(maxDepthArg) != "" {
+		value, err := strconv.Atoi(strings.TrimSpace(maxDepthArg))
+		if err != nil || value < 1 {
+			return nil, errors.New("maxDepth must be a positive integer")
+		}
+		maxDepth = value
+	}
+	root, err := readModelRecord(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("model %s not found", modelID)
+	}
+	lineage := &ModelLineage{ModelID: modelID, Model: root, Ancestors: []*LineageNode{}}
+	visited := map[string]struct{}{modelID: {}}
+	frontier := []*ModelRecord{root}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var next []*ModelRecord
+		for _, record := range frontier {
+			for _, parentID := range record.ParentModelIDs {
+				if _, seen := visited[parentID]; seen {
+					continue
+				}
+				visited[parentID] = struct{}{}
+				if depth > maxDepth {
+					lineage.Truncated = true
+					continue
+				}
+				parent, err := readModelRecord(ctx, parentID)
+				if err != nil {
+					return nil, err
+				}
+				if parent == nil {
+					lineage.Missing = append(lineage.Missing, parentID)
+					continue
+				}
+				lineage.Ancestors = append(lineage.Ancestors, &LineageNode{Depth: depth, Model: parent})
+				next = append(next, parent)
+			}
+		}
+		frontier = next
+	}
+	return lineage, nil
+}
+
+func readModelRecord(ctx contractapi.TransactionContextInterface, modelID string) (*ModelRecord, error) {
+	payload, err := ctx.GetStub().GetState(modelKey(modelID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model record: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var record ModelRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// parseParentModelIDs decodes the optional JSON array of parents and checks each one exists.
+func parseParentModelIDs(ctx contractapi.TransactionContextInterface, modelID, raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+		return nil, fmt.Errorf("invalid parentModelIds: %w", err)
+	}
+	parents := make([]string, 0, len(ids))
+	seen := map[string]struct{}{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, errors.New("parent model identifiers must not be empty")
+		}
+		if id == strings.TrimSpace(modelID) {
+			return nil, errors.New("a model cannot be its own parent")
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		parent, err := readModelRecord(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("parent model %s not found", id)
+		}
+		parents = append(parents, id)
+	}
+	return parents, nil
+}
@@ -0,0 +1,365 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ClusterAssignment moves one node to a cluster, and optionally to another state, in a
+// clustering plan. The previous placement is filled in when the plan is applied.
+type ClusterAssignment struct {
+	NodeID          string `json:"node_id"`
+	State           string `json:"state,omitempty"`
+	Cluster         string `json:"cluster"`
+	PreviousState   string `json:"previous_state,omitempty"`
+	PreviousCluster string `json:"previous_cluster,omitempty"`
+}
+
+// MembershipEpoch is one applied clustering plan. Epoch 0 is the membership trainers
+// registered with; every ApplyClusteringPlan opens the next epoch.
+type MembershipEpoch struct {
+	Epoch       int                  `json:"epoch"`
+	Assignments []*ClusterAssignment `json:"assignments"`
+	Reason      string               `json:"reason,omitempty"`
+	AppliedBy   string               `json:"applied_by,omitempty"`
+	AppliedAt   string               `json:"applied_at,omitempty"`
+	TxID        string               `json:"tx_id,omitempty"`
+}
+
+const (
+	membershipCurrentKey  = "membership:current"
+	membershipEpochPrefix = "membership:epoch:"
+)
+
+// ApplyClusteringPlan moves whitelisted nodes to new clusters and states in one transaction
+// and opens a new membership epoch. planArg is a JSON array of assignments; a missing state
+// keeps the node's current one. The plan is rejected while a round of an affected cluster or
+// state is open, so no round sees its membership change under it.
+func (c *GatewayContract) ApplyClusteringPlan(ctx contractapi.TransactionContextInterface, planArg, reason string) (*MembershipEpoch, error) {
+	var plan []*ClusterAssignment
+	if err := json.Unmarshal([]byte(planArg), &plan); err != nil {
+		return nil, fmt.Errorf("clustering plan must be a JSON array of assignments: %w", err)
+	}
+	if len(plan) == 0 {
+		return nil, errors.New("clustering plan has no assignments")
+	}
+	seen := map[string]bool{}
+	for _, assignment := range plan {
+		if assignment == nil {
+			return nil, errors.New("clustering plan has an empty assignment")
+		}
+		assignment.NodeID = strings.TrimSpace(assignment.NodeID)
+		assignment.State = strings.TrimSpace(assignment.State)
+		assignment.Cluster = strings.TrimSpace(assignment.Cluster)
+		switch {
+		case assignment.NodeID == "":
+			return nil, errors.New("node_id is required for every assignment")
+		case assignment.Cluster == "":
+			return nil, fmt.Errorf("cluster is required for node %s", assignment.NodeID)
+		case seen[assignment.NodeID]:
+			return nil, fmt.Errorf("node %s is assigned more than once", assignment.NodeID)
+		}
+		seen[assignment.NodeID] = true
+	}
+
+	entries, err := whitelistEntriesByNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]*ClusterAssignment, 0, len(plan))
+	for _, assignment := range plan {
+		nodeEntries := entries[assignment.NodeID]
+		if len(nodeEntries) == 0 {
+			return nil, fmt.Errorf("node %s is not whitelisted", assignment.NodeID)
+		}
+		assignment.PreviousState = nodeEntries[0].State
+		assignment.PreviousCluster = nodeEntries[0].Cluster
+		if assignment.State == "" {
+			assignment.State = assignment.PreviousState
+		}
+		if strings.EqualFold(assignment.State, assignment.PreviousState) && strings.EqualFold(assignment.Cluster, assignment.PreviousCluster) {
+			continue
+		}
+		applied = append(applied, assignment)
+	}
+	if len(applied) == 0 {
+		return nil, errors.New("clustering plan changes nothing")
+	}
+	if err := requireNoOpenRounds(ctx, applied); err != nil {
+		return nil, err
+	}
+
+	current, err := currentMembershipEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	epoch := &MembershipEpoch{
+		Epoch:       current.Epoch + 1,
+		Assignments: applied,
+		Reason:      strings.TrimSpace(reason),
+		AppliedBy:   actor,
+		AppliedAt:   now,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	moved := map[string]*ClusterAssignment{}
+	for _, assignment := range applied {
+		for _, entry := range entries[assignment.NodeID] {
+			previous := *entry
+			entry.State = assignment.State
+			entry.Cluster = assignment.Cluster
+			if err := putWhitelistEntry(ctx, entry); err != nil {
+				return nil, err
+			}
+			if err := putWhitelistIndex(ctx, entry, &previous); err != nil {
+				return nil, err
+			}
+		}
+		moved[assignment.NodeID] = assignment
+	}
+	if err := moveTrainers(ctx, epoch, moved); err != nil {
+		return nil, err
+	}
+	if err := putMembershipEpoch(ctx, epoch); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, EventMembershipEpochApplied, epoch); err != nil {
+		return nil, err
+	}
+	return epoch, nil
+}
+
+// GetMembershipEpoch returns an applied membership epoch, or the current one when epochArg is
+// empty. Before any plan is applied the current epoch is 0 with no assignments.
+func (c *GatewayContract) GetMembershipEpoch(ctx contractapi.TransactionContextInterface, epochArg string) (*MembershipEpoch, error) {
+	raw := strings.TrimSpace(epochArg)
+	if raw == "" {
+		return currentMembershipEpoch(ctx)
+	}
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 {
+		return nil, errors.New("epoch must be a positive integer")
+	}
+	payload, err := ctx.GetStub().GetState(membershipEpochKey(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read membership epoch: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("membership epoch %d not found", number)
+	}
+	var epoch MembershipEpoch
+	if err := json.Unmarshal(payload, &epoch); err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
+
+// ListMembershipEpochs returns every applied membership epoch, oldest first.
+func (c *GatewayContract) ListMembershipEpochs(ctx contractapi.TransactionContextInterface) ([]*MembershipEpoch, error) {
+	iter, err := ctx.GetStub().GetStateByRange(membershipEpochPrefix, membershipEpochPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list membership epochs: %w", err)
+	}
+	defer iter.Close()
+	epochs := make([]*MembershipEpoch, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var epoch MembershipEpoch
+		if err := json.Unmarshal(kv.Value, &epoch); err != nil {
+			return nil, err
+		}
+		epochs = append(epochs, &epoch)
+	}
+	return epochs, nil
+}
+
+// requireNoOpenRounds rejects a plan while any job has an open round of a cluster a node
+// leaves or joins, or of a state a node leaves or joins.
+func requireNoOpenRounds(ctx contractapi.TransactionContextInterface, plan []*ClusterAssignment) error {
+	clusters := map[string]bool{}
+	states := map[string]bool{}
+	for _, assignment := range plan {
+		clusters[strings.ToLower(assignment.Cluster)] = true
+		clusters[strings.ToLower(assignment.PreviousCluster)] = true
+		if !strings.EqualFold(assignment.State, assignment.PreviousState) {
+			states[strings.ToLower(assignment.State)] = true
+			states[strings.ToLower(assignment.PreviousState)] = true
+		}
+	}
+	iter, err := ctx.GetStub().GetStateByRange(roundCurrentPrefix, roundCurrentPrefix+"~")
+	if err != nil {
+		return fmt.Errorf("failed to list rounds: %w", err)
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var round TrainingRound
+		if err := json.Unmarshal(kv.Value, &round); err != nil {
+			return err
+		}
+		if round.Status != roundStatusOpen {
+			continue
+		}
+		scope := strings.ToLower(round.ScopeID)
+		if (round.Layer == "cluster" && clusters[scope]) || (round.Layer == "state" && states[scope]) {
+			return fmt.Errorf("round %d of %s/%s/%s is open; close it before re-clustering", round.Round, round.JobID, round.Layer, round.ScopeID)
+		}
+	}
+	return nil
+}
+
+// whitelistEntriesByNode returns the whitelist entries grouped by node ID.
+func whitelistEntriesByNode(ctx contractapi.TransactionContextInterface) (map[string][]*WhitelistEntry, error) {
+	iter, err := ctx.GetStub().GetStateByRange(whitelistPrefix, whitelistPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+	entries := map[string][]*WhitelistEntry{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var entry WhitelistEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.JWTSub == "" {
+			continue
+		}
+		entries[entry.NodeID] = append(entries[entry.NodeID], &entry)
+	}
+	return entries, nil
+}
+
+// moveTrainers copies the new placement to the registered trainers of the moved nodes and
+// records each move in the trainer's update trail.
+func moveTrainers(ctx contractapi.TransactionContextInterface, epoch *MembershipEpoch, moved map[string]*ClusterAssignment) error {
+	iter, err := ctx.GetStub().GetStateByRange(trainerPrefix, trainerPrefix+"~")
+	if err != nil {
+		return fmt.Errorf("failed to list trainers: %w", err)
+	}
+	defer iter.Close()
+	var trainers []*Trainer
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var trainer Trainer
+		if err := json.Unmarshal(kv.Value, &trainer); err != nil {
+			return err
+		}
+		if moved[trainer.NodeID] != nil {
+			trainers = append(trainers, &trainer)
+		}
+	}
+	sort.Slice(trainers, func(i, j int) bool { return trainers[i].ClientID < trainers[j].ClientID })
+	reason := fmt.Sprintf("membership epoch %d", epoch.Epoch)
+	if epoch.Reason != "" {
+		reason += ": " + epoch.Reason
+	}
+	for _, trainer := range trainers {
+		assignment := moved[trainer.NodeID]
+		previous := &TrainerFields{PublicKey: trainer.PublicKey, State: trainer.State, Cluster: trainer.Cluster}
+		trainer.State = assignment.State
+		trainer.Cluster = assignment.Cluster
+		payload, err := json.Marshal(trainer)
+		if err != nil {
+			return err
+		}
+		if err := putState(ctx, trainerKey(trainer.ClientID), payload); err != nil {
+			return err
+		}
+		if err := putTrainerUpdate(ctx, &TrainerUpdate{
+			DID:         trainer.DID,
+			ClientID:    trainer.ClientID,
+			Previous:    previous,
+			Current:     &TrainerFields{PublicKey: trainer.PublicKey, State: trainer.State, Cluster: trainer.Cluster},
+			Reason:      reason,
+			RequestedBy: epoch.AppliedBy,
+			UpdatedAt:   epoch.AppliedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireEpochClusterMember rejects a cluster-layer model from a node that belongs to another
+// cluster in the current membership epoch. Before the first plan (epoch 0) every scope is
+// accepted, as it always was.
+func requireEpochClusterMember(epoch int, trainer *Trainer, layer, scopeID string) error {
+	if epoch == 0 || layer != "cluster" || trainer.Cluster == "" || strings.EqualFold(trainer.Cluster, scopeID) {
+		return nil
+	}
+	return fmt.Errorf("node %s belongs to cluster %s in membership epoch %d", trainer.NodeID, trainer.Cluster, epoch)
+}
+
+// requireEpochStateCluster rejects convergence for a cluster that has no whitelisted node in
+// the state during the current membership epoch.
+func requireEpochStateCluster(ctx contractapi.TransactionContextInterface, epoch int, stateID, clusterID string) error {
+	if epoch == 0 {
+		return nil
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(whitelistClusterIndexType, []string{strings.ToLower(clusterID), strings.ToLower(stateID)})
+	if err != nil {
+		return fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+	if !iter.HasNext() {
+		return fmt.Errorf("cluster %s is not part of state %s in membership epoch %d", clusterID, stateID, epoch)
+	}
+	return nil
+}
+
+// currentMembershipEpoch returns the latest applied epoch, or epoch 0 when none was applied.
+func currentMembershipEpoch(ctx contractapi.TransactionContextInterface) (*MembershipEpoch, error) {
+	payload, err := ctx.GetStub().GetState(membershipCurrentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read membership epoch: %w", err)
+	}
+	if len(payload) == 0 {
+		return &MembershipEpoch{Assignments: []*ClusterAssignment{}}, nil
+	}
+	var epoch MembershipEpoch
+	if err := json.Unmarshal(payload, &epoch); err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
+
+func putMembershipEpoch(ctx contractapi.TransactionContextInterface, epoch *MembershipEpoch) error {
+	bytes, err := json.Marshal(epoch)
+	if err != nil {
+		return err
+	}
+	if err := putState(ctx, membershipEpochKey(epoch.Epoch), bytes); err != nil {
+		return err
+	}
+	return putState(ctx, membershipCurrentKey, bytes)
+}
+
+func membershipEpochKey(epoch int) string {
+	return fmt.Sprintf("%s%010d", membershipEpochPrefix, epoch)
+}
@@ -0,0 +1,150 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// MigrationReport counts the records moved by MigrateCompositeKeys.
+type MigrationReport struct {
+	ModelsIndexed      int `json:"models_indexed"`
+	ModelsHashed       int `json:"models_hashed"`
+	WhitelistIndexed   int `json:"whitelist_indexed"`
+	StateRecordsMoved  int `json:"state_records_moved"`
+	NationRecordsMoved int `json:"nation_records_moved"`
+}
+
+// MigrateCompositeKeys upgrades ledgers written before composite keys were introduced: it
+// indexes every model record and whitelist entry, hashes model payloads recorded without a
+// payload hash, and moves convergence records from the legacy conv:* keys to their composite
+// keys. It is idempotent and safe to run more than once.
+func (c *GatewayContract) MigrateCompositeKeys(ctx contractapi.TransactionContextInterface) (*MigrationReport, error) {
+	report := &MigrationReport{}
+
+	models, err := ctx.GetStub().GetStateByRange(modelPrefix, modelPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan models: %w", err)
+	}
+	for models.HasNext() {
+		kv, err := models.Next()
+		if err != nil {
+			models.Close()
+			return nil, err
+		}
+		var record ModelRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil || record.ID == "" {
+			continue
+		}
+		if err := putModelIndex(ctx, &record); err != nil {
+			models.Close()
+			return nil, err
+		}
+		report.ModelsIndexed++
+		if record.PayloadHash == "" {
+			record.PayloadHash = payloadDigest(record.Payload)
+			payload, err := json.Marshal(&record)
+			if err != nil {
+				models.Close()
+				return nil, err
+			}
+			if err := putState(ctx, kv.Key, payload); err != nil {
+				models.Close()
+				return nil, err
+			}
+			report.ModelsHashed++
+		}
+		if err := putModelHashIndex(ctx, &record); err != nil {
+			models.Close()
+			return nil, err
+		}
+	}
+	models.Close()
+
+	whitelist, err := ctx.GetStub().GetStateByRange(whitelistPrefix, whitelistPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan whitelist: %w", err)
+	}
+	for whitelist.HasNext() {
+		kv, err := whitelist.Next()
+		if err != nil {
+			whitelist.Close()
+			return nil, err
+		}
+		var entry WhitelistEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil || entry.JWTSub == "" {
+			continue
+		}
+		if err := putWhitelistIndex(ctx, &entry, nil); err != nil {
+			whitelist.Close()
+			return nil, err
+		}
+		report.WhitelistIndexed++
+	}
+	whitelist.Close()
+
+	moved, err := migrateLegacyRange(ctx, stateConvPrefix, func(key string) (string, error) {
+		stateID, kind, clusterID := parseStateConvergenceKey(key)
+		switch {
+		case stateID == "":
+			return "", nil
+		case kind == "summary":
+			return stateSummaryKey(ctx, convergenceScope{}, stateID)
+		case kind == "cluster" && clusterID != "":
+			return stateClusterKey(ctx, convergenceScope{}, stateID, clusterID)
+		}
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.StateRecordsMoved = moved
+
+	moved, err = migrateLegacyRange(ctx, nationConvPrefix, func(key string) (string, error) {
+		switch kind, stateID := parseNationConvergenceKey(key); {
+		case kind == "summary":
+			return nationSummaryKey(ctx, convergenceScope{})
+		case kind == "state" && stateID != "":
+			return nationStateKey(ctx, convergenceScope{}, stateID)
+		}
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.NationRecordsMoved = moved
+	return report, nil
+}
+
+// migrateLegacyRange rewrites every key under prefix to the key returned by target and deletes
+// the original. Keys for which target returns "" are left untouched.
+func migrateLegacyRange(ctx contractapi.TransactionContextInterface, prefix string, target func(string) (string, error)) (int, error) {
+	iter, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan %s: %w", prefix, err)
+	}
+	defer iter.Close()
+	moved := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return moved, err
+		}
+		key, err := target(kv.Key)
+		if err != nil {
+			return moved, err
+		}
+		if key == "" {
+			continue
+		}
+		if err := putState(ctx, key, kv.Value); err != nil {
+			return moved, err
+		}
+		if err := ctx.GetStub().DelState(kv.Key); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
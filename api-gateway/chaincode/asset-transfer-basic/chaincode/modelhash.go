@@ -0,0 +1,108 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// modelHashIndexType indexes model records by the SHA-256 of their payload.
+const modelHashIndexType = "model~hash~id"
+
+// offchainPointer is what the gateway commits in place of a payload it stored off-chain. Its
+// sha256 covers the content itself and is used as the payload hash, unless the pointer
+// carries a digest of the payload the content stands for, as for offloaded envelopes.
+type offchainPointer struct {
+	Offchain *struct {
+		SHA256 string `json:"sha256"`
+		Digest string `json:"digest"`
+	} `json:"offchain"`
+}
+
+// encryptedEnvelope is what the gateway commits in place of a payload it encrypted. Its
+// digest is a keyed hash of the plaintext, so identical payloads of a job still share a
+// payload hash; envelopes sealed before it was keyed carry the plaintext's sha256 instead.
+type encryptedEnvelope struct {
+	Encrypted *struct {
+		Digest string `json:"digest"`
+		SHA256 string `json:"sha256"`
+	} `json:"encrypted"`
+}
+
+// GetModelByHash returns every model record whose payload hashes to hash (hex, optionally
+// prefixed with "sha256:"), in index order.
+func (c *GatewayContract) GetModelByHash(ctx contractapi.TransactionContextInterface, hash string) ([]*ModelRecord, error) {
+	hash = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(hash), "sha256:"))
+	if hash == "" {
+		return nil, errors.New("hash is required")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelHashIndexType, []string{hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up model hash: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*ModelRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("malformed model hash key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// payloadDigest is the payload hash of a model payload: the digest an off-chain pointer or
+// encrypted envelope carries for the payload it stands for, or else the payload's SHA-256.
+func payloadDigest(payload string) string {
+	if strings.HasPrefix(strings.TrimSpace(payload), "{") {
+		var pointer offchainPointer
+		if json.Unmarshal([]byte(payload), &pointer) == nil && pointer.Offchain != nil {
+			if digest := wrappedDigest(pointer.Offchain.Digest, pointer.Offchain.SHA256); digest != "" {
+				return digest
+			}
+		}
+		var envelope encryptedEnvelope
+		if json.Unmarshal([]byte(payload), &envelope) == nil && envelope.Encrypted != nil {
+			if digest := wrappedDigest(envelope.Encrypted.Digest, envelope.Encrypted.SHA256); digest != "" {
+				return digest
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// wrappedDigest prefers the digest a wrapper declares for its payload over its sha256.
+func wrappedDigest(digest, sum string) string {
+	if digest != "" {
+		return strings.ToLower(digest)
+	}
+	return strings.ToLower(sum)
+}
+
+func putModelHashIndex(ctx contractapi.TransactionContextInterface, record *ModelRecord) error {
+	if record.PayloadHash == "" {
+		return nil
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(modelHashIndexType, []string{record.PayloadHash, record.ID})
+	if err != nil {
+		return err
+	}
+	return putState(ctx, key, []byte{0x00})
+}
@@ -0,0 +1,87 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Well-known model metrics. Other names are accepted as long as their values are finite.
+const (
+	metricAccuracy   = "accuracy"
+	metricLoss       = "loss"
+	metricNumSamples = "num_samples"
+)
+
+// modelMetadata is the training metadata a trainer may commit with a model.
+type modelMetadata struct {
+	Metrics         map[string]float64 `json:"metrics"`
+	Hyperparameters json.RawMessage    `json:"hyperparameters"`
+}
+
+// CommitModelWithMetadata stores a model reference like CommitModel together with metadataArg,
+// a JSON object {"metrics":{name:number},"hyperparameters":{...}}. roundArg and the
+// attestation (modelHash, signature) are optional; when set the commit follows
+// CommitModelInRound and CommitAttestedModel rules.
+func (c *GatewayContract) CommitModelWithMetadata(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg, modelHash, signature, metadataArg string) (*ModelRecord, error) {
+	var metadata modelMetadata
+	if err := json.Unmarshal([]byte(metadataArg), &metadata); err != nil {
+		return nil, fmt.Errorf("invalid model metadata: %w", err)
+	}
+	if err := metadata.validate(); err != nil {
+		return nil, err
+	}
+	var attestation *modelAttestation
+	if strings.TrimSpace(modelHash) != "" || strings.TrimSpace(signature) != "" {
+		attestation = &modelAttestation{ModelHash: strings.TrimSpace(modelHash), Signature: strings.TrimSpace(signature)}
+	}
+	return c.commitModelInOptionalRound(ctx, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg, attestation, &metadata)
+}
+
+// validate checks that metrics are finite, that the well-known ones are in range and that
+// hyperparameters, when present, form a JSON object.
+func (m *modelMetadata) validate() error {
+	for name, value := range m.Metrics {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("metric names must not be empty")
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("metric %s must be a finite number", name)
+		}
+		switch name {
+		case metricAccuracy:
+			if value < 0 || value > 1 {
+				return errors.New("accuracy must be between 0 and 1")
+			}
+		case metricLoss:
+			if value < 0 {
+				return errors.New("loss must not be negative")
+			}
+		case metricNumSamples:
+			if value < 0 || value != math.Trunc(value) {
+				return errors.New("num_samples must be a non-negative integer")
+			}
+		}
+	}
+	if len(m.Hyperparameters) > 0 && !bytes.Equal(m.Hyperparameters, []byte("null")) {
+		var document map[string]any
+		if err := json.Unmarshal(m.Hyperparameters, &document); err != nil || document == nil {
+			return errors.New("hyperparameters must be a JSON object")
+		}
+	}
+	return nil
+}
+
+// hyperparameters returns the compacted hyperparameters document, or "" when there is none.
+func (m *modelMetadata) hyperparameters() string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, m.Hyperparameters); err != nil || compact.String() == "null" {
+		return ""
+	}
+	return compact.String()
+}
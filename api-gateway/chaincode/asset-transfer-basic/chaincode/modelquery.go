@@ -0,0 +1,390 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ModelFilter narrows model listings beyond layer/scope. Metric restricts the listing to
+// models reporting that metric, optionally within [MinMetric, MaxMetric]; Order ("asc" or
+// "desc") sorts by it, so that e.g. the first page of a descending accuracy listing holds
+// the top contributions.
+type ModelFilter struct {
+	Layer     string   `json:"layer"`
+	ScopeID   string   `json:"scope_id,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+	Since     string   `json:"since,omitempty"`
+	Until     string   `json:"until,omitempty"`
+	Metric    string   `json:"metric,omitempty"`
+	MinMetric *float64 `json:"min_metric,omitempty"`
+	MaxMetric *float64 `json:"max_metric,omitempty"`
+	Order     string   `json:"order,omitempty"`
+}
+
+// ModelQueryPage is one page of a CouchDB rich query; pass Bookmark back to continue.
+type ModelQueryPage struct {
+	Items    []*ModelRecord `json:"items"`
+	PageSize int            `json:"page_size"`
+	Count    int            `json:"count"`
+	Bookmark string         `json:"bookmark"`
+}
+
+const defaultModelQueryPageSize = 10
+
+// QueryModels runs a CouchDB selector over model records. It requires a CouchDB state database;
+// LevelDB networks, and listings sorted by a metric, should use ListModelsFiltered.
+func (c *GatewayContract) QueryModels(ctx contractapi.TransactionContextInterface, filterArg, pageSizeArg, bookmark string) (*ModelQueryPage, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	filter, err := parseModelFilter(filterArg)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Order != "" {
+		return nil, errors.New("sorting by metric is only supported by ListModelsFiltered")
+	}
+	pageSize := defaultModelQueryPageSize
+	if strings.TrimSpace(pageSizeArg) != "" {
+		value, err := strconv.Atoi(pageSizeArg)
+		if err != nil || value < 1 {
+			return nil, errors.New("pageSize must be a positive integer")
+		}
+		pageSize = value
+	}
+	query, err := json.Marshal(map[string]any{"selector": filter.selector()})
+	if err != nil {
+		return nil, err
+	}
+	iter, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(query), int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("rich query failed: %w", err)
+	}
+	defer iter.Close()
+	items := make([]*ModelRecord, 0, pageSize)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record ModelRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		items = append(items, &record)
+	}
+	return &ModelQueryPage{
+		Items:    items,
+		PageSize: pageSize,
+		Count:    int(metadata.GetFetchedRecordsCount()),
+		Bookmark: metadata.GetBookmark(),
+	}, nil
+}
+
+// ListModelsFiltered applies the same filter with a composite-key range scan so it works on LevelDB.
+func (c *GatewayContract) ListModelsFiltered(ctx contractapi.TransactionContextInterface, filterArg, pageArg, perPageArg string) (*ModelListPage, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	filter, err := parseModelFilter(filterArg)
+	if err != nil {
+		return nil, err
+	}
+	page := 1
+	if strings.TrimSpace(pageArg) != "" {
+		value, err := strconv.Atoi(pageArg)
+		if err != nil || value < 1 {
+			return nil, errors.New("page must be >= 1")
+		}
+		page = value
+	}
+	perPage := 10
+	if strings.TrimSpace(perPageArg) != "" {
+		value, err := strconv.Atoi(perPageArg)
+		if err != nil || value < 1 {
+			return nil, errors.New("perPage must be >= 1")
+		}
+		perPage = value
+	}
+	attributes := []string{filter.Layer}
+	if filter.ScopeID != "" {
+		attributes = append(attributes, strings.ToLower(filter.ScopeID))
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer iter.Close()
+
+	startIndex := (page - 1) * perPage
+	items := make([]*ModelRecord, 0, perPage)
+	// Sorted listings have to see every match before they can page.
+	var sorted []*ModelRecord
+	matched := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if record == nil || !filter.matches(record) {
+			continue
+		}
+		matched++
+		if filter.Order != "" {
+			sorted = append(sorted, record)
+			continue
+		}
+		if matched <= startIndex || len(items) >= perPage {
+			continue
+		}
+		items = append(items, record)
+	}
+	if filter.Order != "" {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i].Metrics[filter.Metric], sorted[j].Metrics[filter.Metric]
+			if filter.Order == "desc" {
+				return a > b
+			}
+			return a < b
+		})
+		if startIndex < len(sorted) {
+			items = append(items, sorted[startIndex:min(startIndex+perPage, len(sorted))]...)
+		}
+	}
+	return &ModelListPage{
+		Items:   items,
+		Page:    page,
+		PerPage: perPage,
+		Total:   matched,
+		HasMore: matched > startIndex+len(items),
+	}, nil
+}
+
+// CountModels returns the number of model references per layer, read from the layer index.
+func (c *GatewayContract) CountModels(ctx contractapi.TransactionContextInterface) (map[string]int, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count models: %w", err)
+	}
+	defer iter.Close()
+	counts := make(map[string]int)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		counts[parts[0]]++
+	}
+	return counts, nil
+}
+
+// ListLatestModels returns the latest model reference of each scope in the JSON array scopeIDs,
+// keyed by lower-cased scope. Scopes without models are left out.
+func (c *GatewayContract) ListLatestModels(ctx contractapi.TransactionContextInterface, layer, scopeIDs string) (map[string]*ModelRecord, error) {
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	if layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	var scopes []string
+	if err := json.Unmarshal([]byte(scopeIDs), &scopes); err != nil {
+		return nil, fmt.Errorf("invalid scopeIds: %w", err)
+	}
+	latest := make(map[string]*ModelRecord, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		record, err := latestModel(ctx, layer, scope)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			latest[scope] = record
+		}
+	}
+	return latest, nil
+}
+
+// latestModel picks the scope's model with the highest round, then the latest submitted_at.
+func latestModel(ctx contractapi.TransactionContextInterface, layer, scope string) (*ModelRecord, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, []string{layer, scope})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer iter.Close()
+	var latest *ModelRecord
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			continue
+		}
+		if latest == nil || record.RoundNumber > latest.RoundNumber ||
+			(record.RoundNumber == latest.RoundNumber && utcTimestamp(record.SubmittedAt) >= utcTimestamp(latest.SubmittedAt)) {
+			latest = record
+		}
+	}
+	return latest, nil
+}
+
+// ListRoundModels returns the models committed to one round of a job, in identifier order.
+// Unlike the paginated listings it is open to every identity, so the gateway can export a
+// training run with its admin identity.
+func (c *GatewayContract) ListRoundModels(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID, roundArg string) ([]*ModelRecord, error) {
+	jobID, layer, scopeID, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	round, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || round < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, []string{layer, strings.ToLower(scopeID), fmt.Sprintf("%010d", round)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*ModelRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		// The index is not keyed by job, so rounds of other jobs share it.
+		if record != nil && record.JobID == jobID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func parseModelFilter(raw string) (*ModelFilter, error) {
+	var filter ModelFilter
+	if err := json.Unmarshal([]byte(raw), &filter); err != nil {
+		return nil, fmt.Errorf("invalid model filter: %w", err)
+	}
+	filter.Layer = strings.ToLower(strings.TrimSpace(filter.Layer))
+	if filter.Layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	filter.ScopeID = strings.TrimSpace(filter.ScopeID)
+	filter.Owner = strings.TrimSpace(filter.Owner)
+	for _, bound := range []string{filter.Since, filter.Until} {
+		if bound == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, bound); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", bound, err)
+		}
+	}
+	filter.Metric = strings.TrimSpace(filter.Metric)
+	filter.Order = strings.ToLower(strings.TrimSpace(filter.Order))
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return nil, errors.New("order must be asc or desc")
+	}
+	if filter.Metric == "" && (filter.MinMetric != nil || filter.MaxMetric != nil || filter.Order != "") {
+		return nil, errors.New("metric is required to filter or sort by metric")
+	}
+	return &filter, nil
+}
+
+// selector builds the CouchDB selector. submitted_at is stored as UTC RFC3339, so string
+// comparison matches chronological order.
+func (f *ModelFilter) selector() map[string]any {
+	selector := map[string]any{"layer": f.Layer}
+	if f.ScopeID != "" {
+		selector["scope_id"] = f.ScopeID
+	}
+	if f.Owner != "" {
+		selector["owner"] = f.Owner
+	}
+	submitted := map[string]string{}
+	if f.Since != "" {
+		submitted["$gte"] = utcTimestamp(f.Since)
+	}
+	if f.Until != "" {
+		submitted["$lte"] = utcTimestamp(f.Until)
+	}
+	if len(submitted) > 0 {
+		selector["submitted_at"] = submitted
+	}
+	if f.Metric != "" {
+		metric := map[string]any{"$exists": true}
+		if f.MinMetric != nil {
+			metric["$gte"] = *f.MinMetric
+		}
+		if f.MaxMetric != nil {
+			metric["$lte"] = *f.MaxMetric
+		}
+		selector["metrics."+f.Metric] = metric
+	}
+	return selector
+}
+
+func (f *ModelFilter) matches(record *ModelRecord) bool {
+	if f.ScopeID != "" && !strings.EqualFold(record.ScopeID, f.ScopeID) {
+		return false
+	}
+	if f.Owner != "" && record.Owner != f.Owner {
+		return false
+	}
+	if f.Since != "" && record.SubmittedAt < utcTimestamp(f.Since) {
+		return false
+	}
+	if f.Until != "" && record.SubmittedAt > utcTimestamp(f.Until) {
+		return false
+	}
+	if f.Metric != "" {
+		value, ok := record.Metrics[f.Metric]
+		if !ok || (f.MinMetric != nil && value < *f.MinMetric) || (f.MaxMetric != nil && value > *f.MaxMetric) {
+			return false
+		}
+	}
+	return true
+}
+
+func utcTimestamp(value string) string {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
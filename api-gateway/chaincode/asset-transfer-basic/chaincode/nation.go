@@ -0,0 +1,205 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// NationAggregation records the nation global model produced for a round.
+type NationAggregation struct {
+	Round       int      `json:"round"`
+	ModelCID    string   `json:"model_cid"`
+	States      []string `json:"states"`
+	Aggregator  string   `json:"aggregator"`
+	Strategy    string   `json:"strategy,omitempty"`
+	SampleCount int      `json:"sample_count,omitempty"`
+	Metadata    string   `json:"metadata,omitempty"`
+	TxID        string   `json:"tx_id"`
+	RecordedAt  string   `json:"recorded_at"`
+}
+
+// nationAggregationMetadata carries the optional aggregation details supplied by the aggregator.
+type nationAggregationMetadata struct {
+	Strategy    string          `json:"strategy"`
+	SampleCount int             `json:"sample_count"`
+	Extra       json.RawMessage `json:"extra"`
+}
+
+const nationAggType = "nation~round"
+
+// CommitNationAggregation stores the nation global model CID for a round. When statesArg
+// (JSON array) is empty, the participating states are the ones that submitted nation
+// convergence. Each round can be committed once.
+func (c *GatewayContract) CommitNationAggregation(ctx contractapi.TransactionContextInterface, roundArg, modelCID, statesArg, metadataArg string) (*NationAggregation, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	round, err := parseNationRound(roundArg)
+	if err != nil {
+		return nil, err
+	}
+	modelCID = strings.TrimSpace(modelCID)
+	if modelCID == "" {
+		return nil, errors.New("model CID is required")
+	}
+	key, err := nationAggKey(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nation aggregation: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("nation aggregation for round %d already recorded", round)
+	}
+	states, err := c.participatingStates(ctx, statesArg)
+	if err != nil {
+		return nil, err
+	}
+	var metadata nationAggregationMetadata
+	if strings.TrimSpace(metadataArg) != "" {
+		if err := json.Unmarshal([]byte(metadataArg), &metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+		if metadata.SampleCount < 0 {
+			return nil, errors.New("sample_count must not be negative")
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := &NationAggregation{
+		Round:       round,
+		ModelCID:    modelCID,
+		States:      states,
+		Aggregator:  trainer.NodeID,
+		Strategy:    strings.TrimSpace(metadata.Strategy),
+		SampleCount: metadata.SampleCount,
+		TxID:        ctx.GetStub().GetTxID(),
+		RecordedAt:  now,
+	}
+	if len(metadata.Extra) > 0 && string(metadata.Extra) != "null" {
+		record.Metadata = string(metadata.Extra)
+	}
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadNationAggregation returns the nation aggregation for a round.
+func (c *GatewayContract) ReadNationAggregation(ctx contractapi.TransactionContextInterface, roundArg string) (*NationAggregation, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	round, err := parseNationRound(roundArg)
+	if err != nil {
+		return nil, err
+	}
+	key, err := nationAggKey(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nation aggregation: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("nation aggregation for round %d not found", round)
+	}
+	var record NationAggregation
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListNationAggregations returns every nation aggregation in round order.
+func (c *GatewayContract) ListNationAggregations(ctx contractapi.TransactionContextInterface) ([]*NationAggregation, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(nationAggType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nation aggregations: %w", err)
+	}
+	defer iter.Close()
+	records := make([]*NationAggregation, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var record NationAggregation
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// ListNationStates returns the states that submitted nation convergence, sorted.
+func (c *GatewayContract) ListNationStates(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	return c.participatingStates(ctx, "")
+}
+
+func (c *GatewayContract) participatingStates(ctx contractapi.TransactionContextInterface, statesArg string) ([]string, error) {
+	states := make([]string, 0)
+	if strings.TrimSpace(statesArg) != "" {
+		var requested []string
+		if err := json.Unmarshal([]byte(statesArg), &requested); err != nil {
+			return nil, fmt.Errorf("invalid states: %w", err)
+		}
+		seen := map[string]struct{}{}
+		for _, state := range requested {
+			state, err := normalizeIdentifier(state, "stateId")
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := seen[state]; dup {
+				continue
+			}
+			seen[state] = struct{}{}
+			states = append(states, state)
+		}
+	} else {
+		nation, err := c.listNationConvergence(ctx, convergenceScope{})
+		if err != nil {
+			return nil, err
+		}
+		for state := range nation.States {
+			states = append(states, state)
+		}
+	}
+	sort.Strings(states)
+	return states, nil
+}
+
+func parseNationRound(raw string) (int, error) {
+	round, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || round < 1 {
+		return 0, errors.New("round must be a positive integer")
+	}
+	return round, nil
+}
+
+func nationAggKey(ctx contractapi.TransactionContextInterface, round int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(nationAggType, []string{fmt.Sprintf("%010d", round)})
+}
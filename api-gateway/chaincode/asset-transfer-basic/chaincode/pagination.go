@@ -0,0 +1,207 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// ModelBookmarkPage is one page of ListModelsPage; pass Bookmark back to continue.
+type ModelBookmarkPage struct {
+	Items    []*ModelRecord `json:"items"`
+	PageSize int            `json:"page_size"`
+	Bookmark string         `json:"bookmark,omitempty"`
+	HasMore  bool           `json:"has_more"`
+	Total    *PageTotal     `json:"total,omitempty"`
+}
+
+// WhitelistBookmarkPage is one page of ListWhitelistPage; pass Bookmark back to continue.
+type WhitelistBookmarkPage struct {
+	Items    []*WhitelistEntry `json:"items"`
+	PageSize int               `json:"page_size"`
+	Bookmark string            `json:"bookmark,omitempty"`
+	HasMore  bool              `json:"has_more"`
+	Total    *PageTotal        `json:"total,omitempty"`
+}
+
+// PageTotal reports how many records a bookmark listing matches; it is only included when the
+// caller asked for it. Estimated marks a count that stopped at maxEstimatedTotal and is
+// therefore a lower bound.
+type PageTotal struct {
+	Count     int  `json:"count"`
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// Total modes accepted by the bookmark listings.
+const (
+	totalNone     = "none"
+	totalEstimate = "estimate"
+	totalExact    = "exact"
+
+	// maxEstimatedTotal bounds the keys an estimated total reads.
+	maxEstimatedTotal = 1000
+	maxBookmarkPage   = 500
+)
+
+// ListModelsPage lists the model references of a layer, optionally one scope, a page at a
+// time using the ledger's bookmark pagination, so each call reads at most one page of index
+// entries. totalArg is "none" (default), "estimate" or "exact"; the last reads every index
+// entry of the listing and is O(N).
+func (c *GatewayContract) ListModelsPage(ctx contractapi.TransactionContextInterface, layer, scopeID, pageSizeArg, bookmark, totalArg string) (*ModelBookmarkPage, error) {
+	if _, err := c.requireAuthorizedTrainer(ctx); err != nil {
+		return nil, err
+	}
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	if layer == "" {
+		return nil, errors.New("layer is required")
+	}
+	pageSize, err := parseBookmarkPageSize(pageSizeArg, 10)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := parseTotalMode(totalArg)
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{layer}
+	if scope := strings.ToLower(strings.TrimSpace(scopeID)); scope != "" {
+		attributes = append(attributes, scope)
+	}
+	iter, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(modelIndexType, attributes, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer iter.Close()
+	page := &ModelBookmarkPage{Items: make([]*ModelRecord, 0, pageSize), PageSize: pageSize}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, fmt.Errorf("model index references missing model %s", parts[3])
+		}
+		page.Items = append(page.Items, record)
+	}
+	page.Bookmark = metadata.GetBookmark()
+	page.HasMore = page.Bookmark != ""
+	if mode != totalNone {
+		counter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count models: %w", err)
+		}
+		defer counter.Close()
+		if page.Total, err = countPageTotal(counter, mode, nil); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// ListWhitelistPage lists whitelist entries a page at a time using the ledger's bookmark
+// pagination. totalArg works as in ListModelsPage.
+func (c *GatewayContract) ListWhitelistPage(ctx contractapi.TransactionContextInterface, pageSizeArg, bookmark, totalArg string) (*WhitelistBookmarkPage, error) {
+	pageSize, err := parseBookmarkPageSize(pageSizeArg, 50)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := parseTotalMode(totalArg)
+	if err != nil {
+		return nil, err
+	}
+	iter, metadata, err := ctx.GetStub().GetStateByRangeWithPagination(whitelistPrefix, whitelistPrefix+"~", int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+	page := &WhitelistBookmarkPage{Items: make([]*WhitelistEntry, 0, pageSize), PageSize: pageSize}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var entry WhitelistEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			return nil, err
+		}
+		if entry.JWTSub == "" {
+			continue
+		}
+		page.Items = append(page.Items, &entry)
+	}
+	page.Bookmark = metadata.GetBookmark()
+	page.HasMore = page.Bookmark != ""
+	if mode != totalNone {
+		counter, err := ctx.GetStub().GetStateByRange(whitelistPrefix, whitelistPrefix+"~")
+		if err != nil {
+			return nil, fmt.Errorf("failed to count whitelist: %w", err)
+		}
+		defer counter.Close()
+		// Whitelist entries are keyed directly, so counting reads their values to skip
+		// entries without a subject, as the listing does.
+		counted := func(value []byte) bool {
+			var entry WhitelistEntry
+			return json.Unmarshal(value, &entry) == nil && entry.JWTSub != ""
+		}
+		if page.Total, err = countPageTotal(counter, mode, counted); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func parseBookmarkPageSize(arg string, fallback int) (int, error) {
+	if strings.TrimSpace(arg) == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || value < 1 || value > maxBookmarkPage {
+		return 0, fmt.Errorf("pageSize must be an integer between 1 and %d", maxBookmarkPage)
+	}
+	return value, nil
+}
+
+func parseTotalMode(arg string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(arg)); mode {
+	case "":
+		return totalNone, nil
+	case totalNone, totalEstimate, totalExact:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("total must be %s, %s or %s", totalNone, totalEstimate, totalExact)
+	}
+}
+
+// countPageTotal counts the entries of iter that match, stopping at maxEstimatedTotal in
+// estimate mode. A nil match counts every entry.
+func countPageTotal(iter shim.StateQueryIteratorInterface, mode string, match func([]byte) bool) (*PageTotal, error) {
+	total := &PageTotal{}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		if match != nil && !match(kv.Value) {
+			continue
+		}
+		if mode == totalEstimate && total.Count == maxEstimatedTotal {
+			total.Estimated = true
+			return total, nil
+		}
+		total.Count++
+	}
+	return total, nil
+}
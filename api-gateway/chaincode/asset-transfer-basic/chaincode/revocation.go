@@ -0,0 +1,108 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// RevokedVC is an entry in the on-chain VC revocation list.
+type RevokedVC struct {
+	VCHash    string `json:"vc_hash"`
+	Reason    string `json:"reason,omitempty"`
+	RevokedBy string `json:"revoked_by"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+const revokedPrefix = "revoked:"
+
+var errTrainerRevoked = errors.New("trainer credential revoked")
+
+// AddRevokedVCHash adds a VC hash to the revocation list. Trainers registered with that VC
+// are rejected by every trainer-gated transaction from then on.
+func (c *GatewayContract) AddRevokedVCHash(ctx contractapi.TransactionContextInterface, vcHash, reason string) (*RevokedVC, error) {
+	vcHash = normalizeVCHash(vcHash)
+	if vcHash == "" {
+		return nil, errors.New("vcHash is required")
+	}
+	existing, err := ctx.GetStub().GetState(revokedKey(vcHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revocation list: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("vc %s already revoked", vcHash)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry := &RevokedVC{
+		VCHash:    vcHash,
+		Reason:    strings.TrimSpace(reason),
+		RevokedBy: clientID,
+		RevokedAt: now,
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, revokedKey(vcHash), payload); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// IsVCRevoked reports whether the VC hash is on the revocation list.
+func (c *GatewayContract) IsVCRevoked(ctx contractapi.TransactionContextInterface, vcHash string) (bool, error) {
+	vcHash = normalizeVCHash(vcHash)
+	if vcHash == "" {
+		return false, errors.New("vcHash is required")
+	}
+	return isVCRevoked(ctx, vcHash)
+}
+
+// ListRevokedVCs returns the full revocation list in hash order.
+func (c *GatewayContract) ListRevokedVCs(ctx contractapi.TransactionContextInterface) ([]*RevokedVC, error) {
+	iter, err := ctx.GetStub().GetStateByRange(revokedPrefix, revokedPrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revoked vcs: %w", err)
+	}
+	defer iter.Close()
+	entries := make([]*RevokedVC, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var entry RevokedVC
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func isVCRevoked(ctx contractapi.TransactionContextInterface, vcHash string) (bool, error) {
+	payload, err := ctx.GetStub().GetState(revokedKey(normalizeVCHash(vcHash)))
+	if err != nil {
+		return false, fmt.Errorf("failed to read revocation list: %w", err)
+	}
+	return len(payload) > 0, nil
+}
+
+// normalizeVCHash lowercases hex digests so lookups match regardless of the caller's casing.
+func normalizeVCHash(vcHash string) string {
+	return strings.ToLower(strings.TrimSpace(vcHash))
+}
+
+func revokedKey(vcHash string) string {
+	return revokedPrefix + vcHash
+}
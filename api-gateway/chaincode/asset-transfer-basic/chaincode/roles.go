@@ -0,0 +1,234 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// RoleEnforcement is the on-chain switch for attribute-based role checks.
+type RoleEnforcement struct {
+	Enabled   bool   `json:"enabled"`
+	Attribute string `json:"attribute"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Roles carried in the client certificate's role attribute. They match the gateway's JWT roles.
+const (
+	roleTrainer        = "trainer"
+	roleAggregator     = "aggregator"
+	roleAdmin          = "admin"
+	roleCentralChecker = "central_checker"
+	roleValidator      = "validator"
+
+	// roleAttribute is the Fabric CA attribute holding the role, e.g. registered with
+	// `--id.attrs 'role=aggregator:ecert'`.
+	roleAttribute      = "role"
+	roleEnforcementKey = "config:role-enforcement"
+)
+
+// rolePolicy lists the roles allowed to call each restricted function. Functions that are not
+// listed (reads, RegisterTrainer) stay open to every identity.
+var rolePolicy = map[string][]string{
+	"CommitData":              {roleTrainer, roleAggregator},
+	"CommitModel":             {roleTrainer, roleAggregator},
+	"CommitModelInRound":      {roleTrainer, roleAggregator},
+	"CommitAttestedModel":     {roleTrainer, roleAggregator},
+	"CommitModelWithMetadata": {roleTrainer, roleAggregator},
+	"CommitModels":            {roleTrainer, roleAggregator},
+	"PublishKeyShare":         {roleTrainer, roleAggregator},
+	"UpdateTrainer":           {roleTrainer, roleAggregator},
+
+	"CommitStateClusterConvergence":        {roleAggregator},
+	"CommitStateClusterConvergenceInRound": {roleAggregator},
+	"CommitNationStateConvergence":         {roleAggregator},
+	"CommitNationStateConvergenceInRound":  {roleAggregator},
+	"DeclareStateConvergence":              {roleAggregator, roleCentralChecker},
+	"DeclareStateConvergenceInRound":       {roleAggregator, roleCentralChecker},
+	"DeclareNationConvergence":             {roleAggregator, roleCentralChecker},
+	"DeclareNationConvergenceInRound":      {roleAggregator, roleCentralChecker},
+	"ResetConvergence":                     {roleAdmin},
+	"ResetConvergenceInRound":              {roleAdmin},
+	"RecordStateVerification":              {roleCentralChecker},
+	"RecordStateVerificationInRound":       {roleCentralChecker},
+	"ChallengeConvergence":                 {roleAggregator},
+	"ChallengeConvergenceInRound":          {roleAggregator},
+	"ResolveConvergenceDispute":            {roleAdmin},
+
+	"StartRound":              {roleAggregator, roleAdmin},
+	"CloseRound":              {roleAggregator, roleAdmin},
+	"ExpireRound":             {roleAggregator, roleAdmin},
+	"CommitNationAggregation": {roleAggregator},
+	"RecordAggregation":       {roleAggregator},
+	"AcquireAggregationLease": {roleAggregator},
+	"RenewAggregationLease":   {roleAggregator},
+	"ReleaseAggregationLease": {roleAggregator},
+	"RecordContribution":      {roleAggregator},
+	"NominateGlobalModel":     {roleAggregator},
+	"ReviewGlobalCandidate":   {roleCentralChecker},
+	"PublishGlobalModel":      {roleAggregator, roleAdmin},
+	"SubmitEvaluation":        {roleValidator},
+	"RecordEvaluationRun":     {roleValidator, roleAggregator, roleCentralChecker},
+	"FlagModel":               {roleAggregator, roleValidator, roleCentralChecker, roleAdmin},
+
+	"CreateJob":                      {roleAdmin},
+	"UpdateJob":                      {roleAdmin},
+	"UpsertTrainingConfig":           {roleAdmin},
+	"UpsertTrainingConfigWithFreeze": {roleAdmin},
+	"StartJob":                       {roleAdmin},
+	"CompleteJob":                    {roleAdmin, roleCentralChecker},
+	"ArchiveJob":                     {roleAdmin},
+
+	"RecordWhitelistEntry":          {roleAdmin},
+	"RecordAnchorReceipt":           {roleAdmin},
+	"RecordAuditBatch":              {roleAdmin},
+	"AddRevokedVCHash":              {roleAdmin},
+	"SetFlagThreshold":              {roleAdmin},
+	"SetConvergenceChallengeWindow": {roleAdmin},
+	"SetInputLimits":                {roleAdmin},
+	"ReinstateNode":                 {roleAdmin},
+	"MigrateCompositeKeys":          {roleAdmin},
+	"DisableRoleEnforcement":        {roleAdmin},
+
+	"DeactivateWhitelistEntry": {roleAdmin},
+	"ReactivateWhitelistEntry": {roleAdmin},
+	"RemoveWhitelistEntry":     {roleAdmin},
+	"ApplyClusteringPlan":      {roleAdmin},
+
+	"ExportStatePage":    {roleAdmin},
+	"ImportStateEntries": {roleAdmin},
+}
+
+// GetBeforeTransaction installs the input limit and role checks that run ahead of every
+// transaction.
+func (c *GatewayContract) GetBeforeTransaction() interface{} {
+	return beforeTransaction
+}
+
+func beforeTransaction(ctx contractapi.TransactionContextInterface) error {
+	if err := enforceInputLimits(ctx); err != nil {
+		return err
+	}
+	return enforceRolePolicy(ctx)
+}
+
+// EnableRoleEnforcement turns on attribute-based role checks. The caller's certificate must
+// carry role=admin, so enabling can never lock every admin out.
+func (c *GatewayContract) EnableRoleEnforcement(ctx contractapi.TransactionContextInterface) (*RoleEnforcement, error) {
+	role, err := callerRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if role != roleAdmin {
+		return nil, fmt.Errorf("role %q is not permitted to call EnableRoleEnforcement", role)
+	}
+	return putRoleEnforcement(ctx, true)
+}
+
+// DisableRoleEnforcement turns attribute-based role checks off again.
+func (c *GatewayContract) DisableRoleEnforcement(ctx contractapi.TransactionContextInterface) (*RoleEnforcement, error) {
+	return putRoleEnforcement(ctx, false)
+}
+
+// GetRoleEnforcement reports whether role checks are enforced.
+func (c *GatewayContract) GetRoleEnforcement(ctx contractapi.TransactionContextInterface) (*RoleEnforcement, error) {
+	return readRoleEnforcement(ctx)
+}
+
+// GetCallerRole returns the role attribute of the invoking identity ("" when it has none).
+func (c *GatewayContract) GetCallerRole(ctx contractapi.TransactionContextInterface) (string, error) {
+	return callerRole(ctx)
+}
+
+// enforceRolePolicy rejects calls to restricted functions from identities whose role
+// attribute is not allowed. It does nothing until role enforcement has been enabled.
+func enforceRolePolicy(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	if _, name, found := strings.Cut(function, ":"); found {
+		function = name
+	}
+	// JSON overloads share the policy of the positional function they wrap.
+	function = strings.TrimSuffix(function, jsonOverloadSuffix)
+	allowed, restricted := rolePolicy[function]
+	if !restricted {
+		return nil
+	}
+	config, err := readRoleEnforcement(ctx)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled {
+		return nil
+	}
+	role, err := callerRole(ctx)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range allowed {
+		if role == candidate {
+			return nil
+		}
+	}
+	if role == "" {
+		return fmt.Errorf("identity has no %q attribute; %s requires one of %s", roleAttribute, function, strings.Join(allowed, ", "))
+	}
+	return fmt.Errorf("role %q is not permitted to call %s", role, function)
+}
+
+func callerRole(ctx contractapi.TransactionContextInterface) (string, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return "", fmt.Errorf("failed to read identity attributes: %w", err)
+	}
+	if !found {
+		return "", nil
+	}
+	return strings.ToLower(strings.TrimSpace(value)), nil
+}
+
+func readRoleEnforcement(ctx contractapi.TransactionContextInterface) (*RoleEnforcement, error) {
+	payload, err := ctx.GetStub().GetState(roleEnforcementKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role enforcement: %w", err)
+	}
+	config := &RoleEnforcement{Attribute: roleAttribute}
+	if len(payload) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(payload, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func putRoleEnforcement(ctx contractapi.TransactionContextInterface, enabled bool) (*RoleEnforcement, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	if clientID == "" {
+		return nil, errors.New("client identity is required")
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := &RoleEnforcement{
+		Enabled:   enabled,
+		Attribute: roleAttribute,
+		UpdatedBy: clientID,
+		UpdatedAt: now,
+	}
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, roleEnforcementKey, bytes); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,231 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// roundCloseReasonDeadline marks rounds that ExpireRound closed.
+const roundCloseReasonDeadline = "deadline"
+
+// RoundDeadlineEvent is the payload of the RoundDeadlineExpired event. Aggregators proceed
+// with the participants when QuorumMet is set and wait for the next round otherwise.
+type RoundDeadlineEvent struct {
+	JobID        string   `json:"job_id"`
+	Layer        string   `json:"layer"`
+	ScopeID      string   `json:"scope_id"`
+	Round        int      `json:"round"`
+	Deadline     string   `json:"deadline"`
+	Participants []string `json:"participants"`
+	Stragglers   []string `json:"stragglers"`
+	MinQuorum    int      `json:"min_quorum"`
+	QuorumMet    bool     `json:"quorum_met"`
+	ClosedBy     string   `json:"closed_by"`
+	TxID         string   `json:"tx_id"`
+	Timestamp    string   `json:"timestamp"`
+}
+
+// ExpireRound closes the open round once its deadline has passed. It records who committed a
+// model to the round, which whitelisted nodes of a cluster did not, and whether the quorum
+// was met. minQuorumArg overrides the job's min_clients; with neither, one model is a quorum.
+func (c *GatewayContract) ExpireRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID, roundArg, minQuorumArg string) (*TrainingRound, error) {
+	jobID, layer, scopeID, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || number < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	minQuorum := 0
+	if raw := strings.TrimSpace(minQuorumArg); raw != "" {
+		if minQuorum, err = strconv.Atoi(raw); err != nil || minQuorum < 1 {
+			return nil, errors.New("minimum quorum must be a positive integer")
+		}
+	}
+	current, err := readCurrentRound(ctx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Round != number {
+		return nil, fmt.Errorf("round %d is not the current round", number)
+	}
+	if current.Status != roundStatusOpen {
+		return nil, fmt.Errorf("round %d is already closed", number)
+	}
+	if current.Deadline == "" {
+		return nil, fmt.Errorf("round %d has no deadline", number)
+	}
+	deadline, err := time.Parse(time.RFC3339, current.Deadline)
+	if err != nil {
+		return nil, fmt.Errorf("round %d has an invalid deadline %q", number, current.Deadline)
+	}
+	at, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := at.Format(time.RFC3339)
+	if at.Before(deadline) {
+		return nil, fmt.Errorf("round %d deadline %s has not passed", number, current.Deadline)
+	}
+	if minQuorum == 0 {
+		if minQuorum, err = jobMinClients(ctx, jobID); err != nil {
+			return nil, err
+		}
+	}
+	participants, err := roundParticipants(ctx, jobID, layer, scopeID, number)
+	if err != nil {
+		return nil, err
+	}
+	stragglers := make([]string, 0)
+	if layer == "cluster" {
+		if stragglers, err = clusterStragglers(ctx, scopeID, participants); err != nil {
+			return nil, err
+		}
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current.Status = roundStatusClosed
+	current.ClosedBy = actor
+	current.ClosedAt = now
+	current.CloseReason = roundCloseReasonDeadline
+	current.Participants = participants
+	current.Stragglers = stragglers
+	current.MinQuorum = minQuorum
+	current.QuorumMet = len(participants) >= minQuorum
+	if err := putRound(ctx, current); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, EventRoundDeadlineExpired, &RoundDeadlineEvent{
+		JobID:        jobID,
+		Layer:        layer,
+		ScopeID:      scopeID,
+		Round:        number,
+		Deadline:     current.Deadline,
+		Participants: participants,
+		Stragglers:   stragglers,
+		MinQuorum:    minQuorum,
+		QuorumMet:    current.QuorumMet,
+		ClosedBy:     actor,
+		TxID:         ctx.GetStub().GetTxID(),
+		Timestamp:    now,
+	}); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// roundDeadline returns when a round of the job started at startedAt closes, or "" when the
+// job has no config or its config sets no round_duration_sec.
+func roundDeadline(ctx contractapi.TransactionContextInterface, jobID, startedAt string) (string, error) {
+	doc, err := readTrainingConfigDocument(ctx, jobID)
+	if err != nil || doc == nil || doc.RoundDurationSec == 0 {
+		return "", err
+	}
+	started, err := time.Parse(time.RFC3339, startedAt)
+	if err != nil {
+		return "", err
+	}
+	return started.Add(time.Duration(doc.RoundDurationSec) * time.Second).Format(time.RFC3339), nil
+}
+
+// jobMinClients returns the job's min_clients, or 1 when it sets none.
+func jobMinClients(ctx contractapi.TransactionContextInterface, jobID string) (int, error) {
+	doc, err := readTrainingConfigDocument(ctx, jobID)
+	if err != nil {
+		return 0, err
+	}
+	if doc == nil || doc.MinClients == 0 {
+		return 1, nil
+	}
+	return doc.MinClients, nil
+}
+
+func readTrainingConfigDocument(ctx contractapi.TransactionContextInterface, jobID string) (*trainingConfigDocument, error) {
+	record, err := readTrainingConfig(ctx, jobID)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	var doc trainingConfigDocument
+	if err := json.Unmarshal([]byte(record.Config), &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode training config of %s: %w", jobID, err)
+	}
+	return &doc, nil
+}
+
+// roundParticipants returns the sorted owners of the models committed to a round of the job.
+func roundParticipants(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID string, round int) ([]string, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, []string{layer, strings.ToLower(scopeID), fmt.Sprintf("%010d", round)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer iter.Close()
+	seen := map[string]bool{}
+	participants := make([]string, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 {
+			return nil, fmt.Errorf("malformed model index key %q", kv.Key)
+		}
+		record, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if record == nil || record.JobID != jobID || seen[record.Owner] {
+			continue
+		}
+		seen[record.Owner] = true
+		participants = append(participants, record.Owner)
+	}
+	sort.Strings(participants)
+	return participants, nil
+}
+
+// clusterStragglers returns the sorted node IDs of the active whitelist entries of a cluster
+// that are not among the participants.
+func clusterStragglers(ctx contractapi.TransactionContextInterface, clusterID string, participants []string) ([]string, error) {
+	submitted := map[string]bool{}
+	for _, node := range participants {
+		submitted[node] = true
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(whitelistClusterIndexType, []string{strings.ToLower(clusterID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+	stragglers := make([]string, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 3 {
+			return nil, fmt.Errorf("malformed whitelist index key %q", kv.Key)
+		}
+		entry, err := readWhitelistEntry(ctx, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		if entry.Status == whitelistStatusDeactivated || submitted[entry.NodeID] {
+			continue
+		}
+		stragglers = append(stragglers, entry.NodeID)
+	}
+	sort.Strings(stragglers)
+	return stragglers, nil
+}
@@ -0,0 +1,313 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// TrainingRound tracks one federated round for a job/layer/scope triple.
+type TrainingRound struct {
+	JobID     string `json:"job_id"`
+	Layer     string `json:"layer"`
+	ScopeID   string `json:"scope_id"`
+	Round     int    `json:"round"`
+	Status    string `json:"status"`
+	StartedBy string `json:"started_by"`
+	StartedAt string `json:"started_at"`
+	ClosedBy  string `json:"closed_by,omitempty"`
+	ClosedAt  string `json:"closed_at,omitempty"`
+	// Deadline is when the submission window closes, set from the job's round_duration_sec.
+	Deadline string `json:"deadline,omitempty"`
+	// CloseReason is "deadline" when ExpireRound closed the round. Participants, Stragglers,
+	// MinQuorum and QuorumMet record the participation it observed.
+	CloseReason  string   `json:"close_reason,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+	Stragglers   []string `json:"stragglers,omitempty"`
+	MinQuorum    int      `json:"min_quorum,omitempty"`
+	QuorumMet    bool     `json:"quorum_met,omitempty"`
+	// Epoch is the membership epoch the round was started in; the round's membership cannot
+	// change while it is open.
+	Epoch int `json:"epoch,omitempty"`
+}
+
+const (
+	roundCurrentPrefix = "round:current:"
+	roundEntryPrefix   = "round:entry:"
+	roundStatusOpen    = "OPEN"
+	roundStatusClosed  = "CLOSED"
+	defaultRoundJobID  = "default"
+)
+
+// StartRound opens the next round for the job/layer/scope. The previous round must be closed.
+func (c *GatewayContract) StartRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID string) (*TrainingRound, error) {
+	jobID, layer, scopeID, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := readCurrentRound(ctx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if current != nil {
+		if current.Status == roundStatusOpen {
+			return nil, fmt.Errorf("round %d is still open for %s/%s/%s", current.Round, jobID, layer, scopeID)
+		}
+		next = current.Round + 1
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	round := &TrainingRound{
+		JobID:     jobID,
+		Layer:     layer,
+		ScopeID:   scopeID,
+		Round:     next,
+		Status:    roundStatusOpen,
+		StartedBy: actor,
+		StartedAt: now,
+	}
+	if round.Deadline, err = roundDeadline(ctx, jobID, now); err != nil {
+		return nil, err
+	}
+	membership, err := currentMembershipEpoch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	round.Epoch = membership.Epoch
+	if err := putRound(ctx, round); err != nil {
+		return nil, err
+	}
+	return round, nil
+}
+
+// CloseRound closes the currently open round; roundArg must name that round.
+func (c *GatewayContract) CloseRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID, roundArg string) (*TrainingRound, error) {
+	jobID, layer, scopeID, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || number < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	current, err := readCurrentRound(ctx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.Round != number {
+		return nil, fmt.Errorf("round %d is not the current round", number)
+	}
+	if current.Status != roundStatusOpen {
+		return nil, fmt.Errorf("round %d is already closed", number)
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current.Status = roundStatusClosed
+	current.ClosedBy = actor
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current.ClosedAt = now
+	if err := putRound(ctx, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// GetCurrentRound returns the latest round (open or closed) for the job/layer/scope.
+func (c *GatewayContract) GetCurrentRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID string) (*TrainingRound, error) {
+	jobID, layer, scopeID, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := readCurrentRound(ctx, jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("no round started for %s/%s/%s", jobID, layer, scopeID)
+	}
+	return current, nil
+}
+
+// ListCurrentRounds returns the latest round of every job/layer/scope, optionally for one job.
+func (c *GatewayContract) ListCurrentRounds(ctx contractapi.TransactionContextInterface, jobID string) ([]*TrainingRound, error) {
+	prefix := roundCurrentPrefix
+	if jobID = strings.TrimSpace(jobID); jobID != "" {
+		prefix += jobID + ":"
+	}
+	iter, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rounds: %w", err)
+	}
+	defer iter.Close()
+	rounds := make([]*TrainingRound, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var round TrainingRound
+		if err := json.Unmarshal(kv.Value, &round); err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, &round)
+	}
+	return rounds, nil
+}
+
+// ListRounds returns every round of a job, open and closed, ordered by layer, scope and round.
+func (c *GatewayContract) ListRounds(ctx contractapi.TransactionContextInterface, jobID string) ([]*TrainingRound, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	prefix := roundEntryPrefix + jobID + ":"
+	iter, err := ctx.GetStub().GetStateByRange(prefix, prefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rounds: %w", err)
+	}
+	defer iter.Close()
+	rounds := make([]*TrainingRound, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var round TrainingRound
+		if err := json.Unmarshal(kv.Value, &round); err != nil {
+			return nil, err
+		}
+		// A job ID that extends this one ("job" and "job:b") shares the key prefix.
+		if round.JobID == jobID {
+			rounds = append(rounds, &round)
+		}
+	}
+	return rounds, nil
+}
+
+// CommitModelInRound stores a model reference only while the named round is open.
+func (c *GatewayContract) CommitModelInRound(ctx contractapi.TransactionContextInterface, dataID, jobID, layer, scopeID, roundArg, payload, parentModelIDs string) (*ModelRecord, error) {
+	jobID, normalizedLayer, scope, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || number < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	if err := requireOpenRound(ctx, jobID, normalizedLayer, scope, number); err != nil {
+		return nil, err
+	}
+	return c.commitModel(ctx, dataID, layer, scopeID, payload, parentModelIDs, jobID, number, nil, nil)
+}
+
+// commitModelInOptionalRound commits like CommitModel when roundArg is empty and like
+// CommitModelInRound otherwise; jobID then defaults to the default job.
+func (c *GatewayContract) commitModelInOptionalRound(ctx contractapi.TransactionContextInterface, dataID, layer, scopeID, payload, parentModelIDs, jobID, roundArg string, attestation *modelAttestation, metadata *modelMetadata) (*ModelRecord, error) {
+	if strings.TrimSpace(roundArg) == "" {
+		return c.commitModel(ctx, dataID, layer, scopeID, payload, parentModelIDs, "", 0, attestation, metadata)
+	}
+	jobID, normalizedLayer, scope, err := normalizeRoundScope(jobID, layer, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(roundArg))
+	if err != nil || number < 1 {
+		return nil, errors.New("round must be a positive integer")
+	}
+	if err := requireOpenRound(ctx, jobID, normalizedLayer, scope, number); err != nil {
+		return nil, err
+	}
+	return c.commitModel(ctx, dataID, layer, scopeID, payload, parentModelIDs, jobID, number, attestation, metadata)
+}
+
+func requireOpenRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID string, number int) error {
+	current, err := readCurrentRound(ctx, jobID, layer, scopeID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case current == nil || number > current.Round:
+		return fmt.Errorf("round %d has not started", number)
+	case number < current.Round || current.Status != roundStatusOpen:
+		return fmt.Errorf("round %d is closed", number)
+	}
+	return nil
+}
+
+func readCurrentRound(ctx contractapi.TransactionContextInterface, jobID, layer, scopeID string) (*TrainingRound, error) {
+	payload, err := ctx.GetStub().GetState(roundCurrentKey(jobID, layer, scopeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read round: %w", err)
+	}
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	var round TrainingRound
+	if err := json.Unmarshal(payload, &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+func putRound(ctx contractapi.TransactionContextInterface, round *TrainingRound) error {
+	bytes, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+	if err := putState(ctx, roundCurrentKey(round.JobID, round.Layer, round.ScopeID), bytes); err != nil {
+		return err
+	}
+	return putState(ctx, roundEntryKey(round.JobID, round.Layer, round.ScopeID, round.Round), bytes)
+}
+
+// invokerName prefers the registered trainer's node ID and falls back to the client identity.
+func (c *GatewayContract) invokerName(ctx contractapi.TransactionContextInterface) (string, error) {
+	if trainer, err := c.requireAuthorizedTrainer(ctx); err == nil {
+		return trainer.NodeID, nil
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	return clientID, nil
+}
+
+func normalizeRoundScope(jobID, layer, scopeID string) (string, string, string, error) {
+	jobID = strings.TrimSpace(jobID)
+	if jobID == "" {
+		jobID = defaultRoundJobID
+	}
+	layer = strings.ToLower(strings.TrimSpace(layer))
+	if layer == "" {
+		return "", "", "", errors.New("layer is required")
+	}
+	scopeID = strings.TrimSpace(scopeID)
+	if scopeID == "" {
+		return "", "", "", errors.New("scope identifier is required")
+	}
+	return jobID, layer, scopeID, nil
+}
+
+func roundCurrentKey(jobID, layer, scopeID string) string {
+	return fmt.Sprintf("%s%s:%s:%s", roundCurrentPrefix, jobID, layer, scopeID)
+}
+
+func roundEntryKey(jobID, layer, scopeID string, round int) string {
+	return fmt.Sprintf("%s%s:%s:%s:%010d", roundEntryPrefix, jobID, layer, scopeID, round)
+}
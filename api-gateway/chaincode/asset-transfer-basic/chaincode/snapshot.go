@@ -0,0 +1,196 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// SnapshotEntry is one world state key and its raw value.
+type SnapshotEntry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// SnapshotPage is one page of ExportStatePage; pass Bookmark back to continue.
+type SnapshotPage struct {
+	Namespace string           `json:"namespace"`
+	Entries   []*SnapshotEntry `json:"entries"`
+	Bookmark  string           `json:"bookmark,omitempty"`
+	HasMore   bool             `json:"has_more"`
+}
+
+// SnapshotImport reports what ImportStateEntries wrote.
+type SnapshotImport struct {
+	Imported  int `json:"imported"`
+	Unchanged int `json:"unchanged"`
+}
+
+// snapshotNamespaces are the key prefixes and composite key object types (those containing
+// "~") that make up an experiment's state. Aggregation leases, anchor receipts and audit
+// batches describe the network that produced the state rather than the experiment, so they
+// are left out.
+var snapshotNamespaces = []string{
+	aggregationPrefix,
+	"config:",
+	"contrib:",
+	"conv:",
+	dataPrefix,
+	disputePrefix,
+	didPrefix,
+	evaluationPrefix,
+	flagTallyPrefix,
+	globalCandidatePrefix,
+	"globalmodel:",
+	jobPrefix,
+	"membership:",
+	modelPrefix,
+	revokedPrefix,
+	"round:",
+	trainerPrefix,
+	trainingConfigPrefix,
+	whitelistPrefix,
+	whitelistTombstonePrefix,
+
+	aggregationIndexType,
+	contributionType,
+	convHistoryType,
+	stateDisputeType,
+	jobStateDisputeType,
+	evaluationRunType,
+	stateConvType,
+	nationConvType,
+	jobStateConvType,
+	jobNationConvType,
+	flagIndexType,
+	globalModelType,
+	keyShareIndexType,
+	modelIndexType,
+	modelHashIndexType,
+	nationAggType,
+	trainerUpdateType,
+	trainingConfigVersionType,
+	stateVerificationType,
+	jobStateVerificationType,
+	whitelistStateIndexType,
+	whitelistClusterIndexType,
+}
+
+// ListSnapshotNamespaces lists the namespaces ExportStatePage accepts, in export order.
+func (c *GatewayContract) ListSnapshotNamespaces(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return append([]string(nil), snapshotNamespaces...), nil
+}
+
+// ExportStatePage returns one page of the raw keys and values of a snapshot namespace, so a
+// whole experiment can be copied key for key onto another network.
+func (c *GatewayContract) ExportStatePage(ctx contractapi.TransactionContextInterface, namespace, pageSizeArg, bookmark string) (*SnapshotPage, error) {
+	if !isSnapshotNamespace(namespace) {
+		return nil, fmt.Errorf("unknown snapshot namespace %q", namespace)
+	}
+	pageSize, err := parseBookmarkPageSize(pageSizeArg, 100)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		iter     shim.StateQueryIteratorInterface
+		metadata *peer.QueryResponseMetadata
+	)
+	if strings.Contains(namespace, "~") {
+		iter, metadata, err = ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(namespace, []string{}, int32(pageSize), bookmark)
+	} else {
+		iter, metadata, err = ctx.GetStub().GetStateByRangeWithPagination(namespace, namespace+"~", int32(pageSize), bookmark)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", namespace, err)
+	}
+	defer iter.Close()
+	page := &SnapshotPage{Namespace: namespace, Entries: make([]*SnapshotEntry, 0, pageSize)}
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		page.Entries = append(page.Entries, &SnapshotEntry{Key: kv.Key, Value: kv.Value})
+	}
+	page.Bookmark = metadata.GetBookmark()
+	page.HasMore = page.Bookmark != ""
+	return page, nil
+}
+
+// ImportStateEntries writes exported entries (JSON array) back to the world state. Keys
+// that already hold the same value are left alone, so an interrupted import can be re-run;
+// a key holding a different value fails the whole batch rather than mixing two experiments.
+func (c *GatewayContract) ImportStateEntries(ctx contractapi.TransactionContextInterface, entriesArg string) (*SnapshotImport, error) {
+	var entries []*SnapshotEntry
+	if err := json.Unmarshal([]byte(entriesArg), &entries); err != nil {
+		return nil, fmt.Errorf("invalid entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("at least one entry is required")
+	}
+	for _, entry := range entries {
+		if entry == nil || entry.Key == "" {
+			return nil, errors.New("entries must have a key")
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	stub := ctx.GetStub()
+	result := &SnapshotImport{}
+	for i, entry := range entries {
+		if i > 0 && entries[i-1].Key == entry.Key {
+			return nil, fmt.Errorf("key %q appears more than once", entry.Key)
+		}
+		if !isSnapshotKey(stub, entry.Key) {
+			return nil, fmt.Errorf("key %q is outside the snapshot namespaces", entry.Key)
+		}
+		if len(entry.Value) == 0 {
+			return nil, fmt.Errorf("key %q has no value", entry.Key)
+		}
+		existing, err := stub.GetState(entry.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", entry.Key, err)
+		}
+		if existing != nil {
+			if !bytes.Equal(existing, entry.Value) {
+				return nil, fmt.Errorf("key %q already holds different state", entry.Key)
+			}
+			result.Unchanged++
+			continue
+		}
+		if err := stub.PutState(entry.Key, entry.Value); err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+func isSnapshotNamespace(namespace string) bool {
+	for _, candidate := range snapshotNamespaces {
+		if candidate == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// isSnapshotKey reports whether key belongs to one of the snapshot namespaces.
+func isSnapshotKey(stub shim.ChaincodeStubInterface, key string) bool {
+	if strings.HasPrefix(key, "\x00") {
+		objectType, _, err := stub.SplitCompositeKey(key)
+		return err == nil && strings.Contains(objectType, "~") && isSnapshotNamespace(objectType)
+	}
+	for _, namespace := range snapshotNamespaces {
+		if !strings.Contains(namespace, "~") && strings.HasPrefix(key, namespace) {
+			return true
+		}
+	}
+	return false
+}
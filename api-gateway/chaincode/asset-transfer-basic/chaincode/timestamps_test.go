@@ -0,0 +1,150 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode"
+	"github.com/hyperledger/fabric-samples/asset-transfer-basic/chaincode-go/chaincode/mocks"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate counterfeiter -o mocks/transaction.go -fake-name TransactionContext . transactionContext
+type transactionContext interface {
+	contractapi.TransactionContextInterface
+}
+
+//go:generate counterfeiter -o mocks/chaincodestub.go -fake-name ChaincodeStub . chaincodeStub
+type chaincodeStub interface {
+	shim.ChaincodeStubInterface
+}
+
+//go:generate counterfeiter -o mocks/statequeryiterator.go -fake-name StateQueryIterator . stateQueryIterator
+type stateQueryIterator interface {
+	shim.StateQueryIteratorInterface
+}
+
+//go:generate counterfeiter -o mocks/clientidentity.go -fake-name ClientIdentity . clientIdentity
+type clientIdentity interface {
+	cid.ClientIdentity
+}
+
+// proposalTime is the timestamp of every simulated transaction. It is far from the wall
+// clock, so a record stamped with time.Now cannot match it by accident.
+var proposalTime = time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+const proposalTimestamp = "2024-05-06T07:08:09Z"
+
+// newEndorser simulates one endorsing peer: an in-memory world state and a transaction
+// context whose proposal carries proposalTime.
+func newEndorser() (*mocks.TransactionContext, map[string][]byte) {
+	state := map[string][]byte{}
+	stub := &mocks.ChaincodeStub{}
+	stub.GetStateStub = func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+	stub.PutStateStub = func(key string, value []byte) error {
+		state[key] = value
+		return nil
+	}
+	stub.DelStateStub = func(key string) error {
+		delete(state, key)
+		return nil
+	}
+	stub.CreateCompositeKeyStub = shim.CreateCompositeKey
+	stub.GetStateByPartialCompositeKeyReturns(&mocks.StateQueryIterator{}, nil)
+	stub.GetTxIDReturns("tx-1")
+	stub.GetTxTimestampReturns(timestamppb.New(proposalTime), nil)
+
+	identity := &mocks.ClientIdentity{}
+	identity.GetIDReturns("x509::CN=trainer-1", nil)
+	identity.GetMSPIDReturns("Org1MSP", nil)
+
+	ctx := &mocks.TransactionContext{}
+	ctx.GetStubReturns(stub)
+	ctx.GetClientIdentityReturns(identity)
+	return ctx, state
+}
+
+// endorse runs the transactions that stamp trainers, data, models and convergence records and
+// returns the timestamp each of them recorded.
+func endorse(t *testing.T, ctx contractapi.TransactionContextInterface) map[string]string {
+	t.Helper()
+	contract := &chaincode.GatewayContract{}
+	stamps := map[string]string{}
+
+	require.NoError(t, contract.RegisterTrainer(ctx, "did:nebula:trainer-1", "trainer-1", "vc-hash", "public-key", "state-a", "cluster-a"))
+	var trainer struct {
+		Registered string `json:"registered_at"`
+	}
+	require.NoError(t, json.Unmarshal(readTrainer(t, ctx), &trainer))
+	stamps["RegisterTrainer"] = trainer.Registered
+
+	data, err := contract.CommitData(ctx, "data-1", "payload")
+	require.NoError(t, err)
+	stamps["CommitData"] = data.SubmittedAt
+
+	model, err := contract.CommitModel(ctx, "model-1", "cluster", "cluster-a", "ipfs://model-1", "")
+	require.NoError(t, err)
+	stamps["CommitModel"] = model.SubmittedAt
+
+	cluster, err := contract.CommitStateClusterConvergence(ctx, "state-a", "cluster-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+	stamps["CommitStateClusterConvergence"] = cluster.SubmittedAt
+
+	nationState, err := contract.CommitNationStateConvergence(ctx, "state-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+	stamps["CommitNationStateConvergence"] = nationState.SubmittedAt
+
+	state, err := contract.DeclareStateConvergence(ctx, "state-a", `{"loss":0.1}`)
+	require.NoError(t, err)
+	stamps["DeclareStateConvergence"] = state.DeclaredAt
+
+	nation, err := contract.DeclareNationConvergence(ctx, `{"loss":0.1}`)
+	require.NoError(t, err)
+	stamps["DeclareNationConvergence"] = nation.DeclaredAt
+	return stamps
+}
+
+func readTrainer(t *testing.T, ctx contractapi.TransactionContextInterface) []byte {
+	t.Helper()
+	payload, err := ctx.GetStub().GetState("trainer:x509::CN=trainer-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	return payload
+}
+
+func TestRecordsUseTransactionTimestamp(t *testing.T) {
+	ctx, _ := newEndorser()
+	for function, stamp := range endorse(t, ctx) {
+		require.Equal(t, proposalTimestamp, stamp, function)
+	}
+}
+
+func TestEndorsersWriteIdenticalState(t *testing.T) {
+	first, firstState := newEndorser()
+	endorse(t, first)
+	// Let the wall clock move past the one-second resolution of the stored timestamps, so a
+	// regression to time.Now makes the two endorsements differ.
+	time.Sleep(1100 * time.Millisecond)
+	second, secondState := newEndorser()
+	endorse(t, second)
+
+	require.NotEmpty(t, firstState)
+	require.Equal(t, firstState, secondState)
+}
+
+func TestTimestampErrorFailsTransaction(t *testing.T) {
+	ctx, state := newEndorser()
+	ctx.GetStub().(*mocks.ChaincodeStub).GetTxTimestampReturns(nil, errors.New("proposal header missing"))
+
+	err := (&chaincode.GatewayContract{}).RegisterTrainer(ctx, "did:nebula:trainer-1", "trainer-1", "vc-hash", "public-key", "", "")
+	require.EqualError(t, err, "failed to read transaction timestamp: proposal header missing")
+	require.Empty(t, state)
+}
@@ -0,0 +1,156 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// trainerUpdateType keys the audit trail of trainer updates by DID, then time and transaction.
+const trainerUpdateType = "trainer~update"
+
+// TrainerFields are the trainer attributes UpdateTrainer can change.
+type TrainerFields struct {
+	PublicKey string `json:"public_key"`
+	State     string `json:"state,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+// TrainerUpdate records one UpdateTrainer call with the values it replaced.
+type TrainerUpdate struct {
+	DID         string         `json:"did"`
+	ClientID    string         `json:"client_id"`
+	JWTSub      string         `json:"jwt_sub,omitempty"`
+	Previous    *TrainerFields `json:"previous"`
+	Current     *TrainerFields `json:"current"`
+	Reason      string         `json:"reason,omitempty"`
+	RequestedBy string         `json:"requested_by,omitempty"`
+	UpdatedAt   string         `json:"updated_at"`
+	TxID        string         `json:"tx_id"`
+}
+
+// UpdateTrainer changes the invoking trainer's public key, state or cluster; empty values are
+// left as they are. The trainer's whitelist entry under jwtSub, when given, follows the change.
+// Previous values are kept in an on-chain audit trail readable with ListTrainerUpdates.
+func (c *GatewayContract) UpdateTrainer(ctx contractapi.TransactionContextInterface, did, jwtSub, publicKey, state, cluster, reason, requestedBy string) (*TrainerUpdate, error) {
+	trainer, err := c.requireAuthorizedTrainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(did) == "" {
+		return nil, errors.New("did is required")
+	}
+	if trainer.DID != strings.TrimSpace(did) {
+		return nil, fmt.Errorf("invoker is not the trainer registered as %s", did)
+	}
+	previous := &TrainerFields{PublicKey: trainer.PublicKey, State: trainer.State, Cluster: trainer.Cluster}
+	if value := strings.TrimSpace(publicKey); value != "" {
+		trainer.PublicKey = value
+	}
+	if value := strings.TrimSpace(state); value != "" {
+		trainer.State = value
+	}
+	if value := strings.TrimSpace(cluster); value != "" {
+		trainer.Cluster = value
+	}
+	current := &TrainerFields{PublicKey: trainer.PublicKey, State: trainer.State, Cluster: trainer.Cluster}
+	if *current == *previous {
+		return nil, errors.New("update changes nothing")
+	}
+	payload, err := json.Marshal(trainer)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, trainerKey(trainer.ClientID), payload); err != nil {
+		return nil, err
+	}
+
+	jwtSub = strings.ToLower(strings.TrimSpace(jwtSub))
+	if jwtSub != "" {
+		if err := updateWhitelistEntry(ctx, jwtSub, trainer); err != nil {
+			return nil, err
+		}
+	}
+
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	update := &TrainerUpdate{
+		DID:         trainer.DID,
+		ClientID:    trainer.ClientID,
+		JWTSub:      jwtSub,
+		Previous:    previous,
+		Current:     current,
+		Reason:      strings.TrimSpace(reason),
+		RequestedBy: strings.TrimSpace(requestedBy),
+		UpdatedAt:   now,
+	}
+	if err := putTrainerUpdate(ctx, update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// ListTrainerUpdates returns the audit trail of a trainer's updates, oldest first.
+func (c *GatewayContract) ListTrainerUpdates(ctx contractapi.TransactionContextInterface, did string) ([]*TrainerUpdate, error) {
+	did = strings.TrimSpace(did)
+	if did == "" {
+		return nil, errors.New("did is required")
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(trainerUpdateType, []string{did})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trainer updates: %w", err)
+	}
+	defer iter.Close()
+	updates := make([]*TrainerUpdate, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var update TrainerUpdate
+		if err := json.Unmarshal(kv.Value, &update); err != nil {
+			return nil, err
+		}
+		updates = append(updates, &update)
+	}
+	return updates, nil
+}
+
+// updateWhitelistEntry copies the trainer's key and placement to its whitelist entry and moves
+// the entry's index keys. The entry must belong to the trainer's DID.
+func updateWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub string, trainer *Trainer) error {
+	entry, err := requireWhitelistEntry(ctx, jwtSub)
+	if err != nil {
+		return err
+	}
+	if entry.DID != trainer.DID {
+		return fmt.Errorf("whitelist entry %s belongs to another trainer", jwtSub)
+	}
+	previous := *entry
+	entry.PublicKey = trainer.PublicKey
+	entry.State = trainer.State
+	entry.Cluster = trainer.Cluster
+	if err := putWhitelistEntry(ctx, entry); err != nil {
+		return err
+	}
+	return putWhitelistIndex(ctx, entry, &previous)
+}
+
+// putTrainerUpdate appends update to its trainer's audit trail.
+func putTrainerUpdate(ctx contractapi.TransactionContextInterface, update *TrainerUpdate) error {
+	update.TxID = ctx.GetStub().GetTxID()
+	key, err := ctx.GetStub().CreateCompositeKey(trainerUpdateType, []string{update.DID, update.UpdatedAt, update.TxID})
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, key, record)
+}
@@ -0,0 +1,111 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// currentTrainingConfigSchema is the newest training config schema_version the chaincode
+// understands. Documents without schema_version are read as version 1.
+const currentTrainingConfigSchema = 1
+
+// trainingConfigDocument is schema version 1 of a training config. Fields shared by every
+// model family are typed and validated; family-specific settings live in Params.
+type trainingConfigDocument struct {
+	SchemaVersion int     `json:"schema_version"`
+	Model         string  `json:"model,omitempty"`
+	Rounds        int     `json:"rounds,omitempty"`
+	LocalEpochs   int     `json:"local_epochs,omitempty"`
+	BatchSize     int     `json:"batch_size,omitempty"`
+	LearningRate  float64 `json:"learning_rate,omitempty"`
+	Optimizer     string  `json:"optimizer,omitempty"`
+	Aggregation   string  `json:"aggregation,omitempty"`
+	MinClients    int     `json:"min_clients,omitempty"`
+	// RoundDurationSec bounds each round's submission window; rounds without it never expire.
+	RoundDurationSec int                        `json:"round_duration_sec,omitempty"`
+	Params           map[string]json.RawMessage `json:"params,omitempty"`
+}
+
+// normalizeTrainingConfig validates a training config against its schema version and returns
+// it in canonical form. Top-level fields the schema does not know move into params.
+func normalizeTrainingConfig(raw string) (string, int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil || fields == nil {
+		return "", 0, errors.New("training config must be a JSON object")
+	}
+	doc := trainingConfigDocument{SchemaVersion: 1}
+	if value, ok := fields["schema_version"]; ok {
+		if err := json.Unmarshal(value, &doc.SchemaVersion); err != nil || doc.SchemaVersion < 1 {
+			return "", 0, errors.New("schema_version must be a positive integer")
+		}
+		if doc.SchemaVersion > currentTrainingConfigSchema {
+			return "", 0, fmt.Errorf("training config schema_version %d is not supported; the newest is %d", doc.SchemaVersion, currentTrainingConfigSchema)
+		}
+	}
+	if value, ok := fields["params"]; ok && !isJSONNull(value) {
+		if err := json.Unmarshal(value, &doc.Params); err != nil || doc.Params == nil {
+			return "", 0, errors.New("params must be a JSON object")
+		}
+	}
+	integers := map[string]*int{
+		"rounds":             &doc.Rounds,
+		"local_epochs":       &doc.LocalEpochs,
+		"batch_size":         &doc.BatchSize,
+		"min_clients":        &doc.MinClients,
+		"round_duration_sec": &doc.RoundDurationSec,
+	}
+	strs := map[string]*string{
+		"model":       &doc.Model,
+		"optimizer":   &doc.Optimizer,
+		"aggregation": &doc.Aggregation,
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		switch {
+		case name == "schema_version", name == "params":
+		case integers[name] != nil:
+			var number float64
+			if err := json.Unmarshal(value, &number); err != nil || number < 1 || number != math.Trunc(number) || number > math.MaxInt32 {
+				return "", 0, fmt.Errorf("%s must be a positive integer", name)
+			}
+			*integers[name] = int(number)
+		case strs[name] != nil:
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil || strings.TrimSpace(text) == "" {
+				return "", 0, fmt.Errorf("%s must be a non-empty string", name)
+			}
+			*strs[name] = strings.TrimSpace(text)
+		case name == "learning_rate":
+			if err := json.Unmarshal(value, &doc.LearningRate); err != nil || doc.LearningRate <= 0 || math.IsInf(doc.LearningRate, 0) {
+				return "", 0, errors.New("learning_rate must be a positive number")
+			}
+		default:
+			if _, clash := doc.Params[name]; clash {
+				return "", 0, fmt.Errorf("%s is given both at the top level and in params", name)
+			}
+			if doc.Params == nil {
+				doc.Params = map[string]json.RawMessage{}
+			}
+			doc.Params[name] = value
+		}
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", 0, err
+	}
+	return string(canonical), doc.SchemaVersion, nil
+}
+
+func isJSONNull(value json.RawMessage) bool {
+	return string(bytes.TrimSpace(value)) == "null"
+}
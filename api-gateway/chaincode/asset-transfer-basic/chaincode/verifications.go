@@ -0,0 +1,252 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// StateVerification is the central checker's verdict on the model a state submitted for a
+// convergence scope. A state has one verification per scope; verifying again replaces it, so
+// a failed state can pass once it resubmits.
+type StateVerification struct {
+	StateID      string `json:"state_id"`
+	ModelID      string `json:"model_id"`
+	PayloadHash  string `json:"payload_hash,omitempty"`
+	Result       string `json:"result"`
+	EvidenceHash string `json:"evidence_hash"`
+	Note         string `json:"note,omitempty"`
+	JobID        string `json:"job_id,omitempty"`
+	Round        int    `json:"round,omitempty"`
+	VerifiedBy   string `json:"verified_by"`
+	VerifiedAt   string `json:"verified_at"`
+	TxID         string `json:"tx_id"`
+}
+
+const (
+	verificationPass = "PASS"
+	verificationFail = "FAIL"
+
+	// Verifications share the convergence scoping: the legacy keyspace, or one prefixed with
+	// the job ID and padded round.
+	stateVerificationType    = "verification~state"
+	jobStateVerificationType = "verification~job~state"
+)
+
+// RecordStateVerification records the verdict, "pass" or "fail", on a state's model for the
+// legacy unscoped convergence. evidenceHash is the SHA-256 of the verification report.
+func (c *GatewayContract) RecordStateVerification(ctx contractapi.TransactionContextInterface, stateID, modelID, result, evidenceHash, note string) (*StateVerification, error) {
+	return c.recordStateVerification(ctx, convergenceScope{}, stateID, modelID, result, evidenceHash, note)
+}
+
+// RecordStateVerificationInRound records the verdict on a state's model for a job round.
+func (c *GatewayContract) RecordStateVerificationInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg, stateID, modelID, result, evidenceHash, note string) (*StateVerification, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return c.recordStateVerification(ctx, scope, stateID, modelID, result, evidenceHash, note)
+}
+
+func (c *GatewayContract) recordStateVerification(ctx contractapi.TransactionContextInterface, scope convergenceScope, stateID, modelID, result, evidenceHash, note string) (*StateVerification, error) {
+	if err := scope.requireRunningJob(ctx); err != nil {
+		return nil, err
+	}
+	stateID, err := normalizeIdentifier(stateID, "stateId")
+	if err != nil {
+		return nil, err
+	}
+	result = strings.ToUpper(strings.TrimSpace(result))
+	if result != verificationPass && result != verificationFail {
+		return nil, errors.New("result must be pass or fail")
+	}
+	evidenceHash = strings.ToLower(strings.TrimSpace(evidenceHash))
+	if decoded, err := hex.DecodeString(evidenceHash); err != nil || len(decoded) != 32 {
+		return nil, errors.New("evidenceHash must be a hex SHA-256 digest")
+	}
+	model, err := readModelRecord(ctx, strings.TrimSpace(modelID))
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model %s not found", strings.TrimSpace(modelID))
+	}
+	if model.Layer != "state" || !strings.EqualFold(model.ScopeID, stateID) {
+		return nil, fmt.Errorf("model %s is not a model of state %s", model.ID, stateID)
+	}
+	if model.JobID != scope.jobID || model.RoundNumber != scope.round {
+		return nil, fmt.Errorf("model %s was not committed%s", model.ID, scopeOrLegacy(scope))
+	}
+	actor, err := c.invokerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verification := &StateVerification{
+		StateID:      stateID,
+		ModelID:      model.ID,
+		PayloadHash:  model.PayloadHash,
+		Result:       result,
+		EvidenceHash: evidenceHash,
+		Note:         strings.TrimSpace(note),
+		JobID:        scope.jobID,
+		Round:        scope.round,
+		VerifiedBy:   actor,
+		VerifiedAt:   now,
+		TxID:         ctx.GetStub().GetTxID(),
+	}
+	bytes, err := json.Marshal(verification)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scope.verificationKey(ctx, stateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, key, bytes); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// ListStateVerifications returns the verification of every state for the legacy unscoped
+// convergence, in state order.
+func (c *GatewayContract) ListStateVerifications(ctx contractapi.TransactionContextInterface) ([]*StateVerification, error) {
+	return listStateVerifications(ctx, convergenceScope{})
+}
+
+// ListStateVerificationsInRound returns the verification of every state for a job round.
+func (c *GatewayContract) ListStateVerificationsInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg string) ([]*StateVerification, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return listStateVerifications(ctx, scope)
+}
+
+// ListPendingStateModels returns the state models of the legacy unscoped convergence that
+// have no verification yet, in state and model order.
+func (c *GatewayContract) ListPendingStateModels(ctx contractapi.TransactionContextInterface) ([]*ModelRecord, error) {
+	return listPendingStateModels(ctx, convergenceScope{})
+}
+
+// ListPendingStateModelsInRound returns the state models of a job round that have no
+// verification yet. A state verified on an earlier model lists its newer models again.
+func (c *GatewayContract) ListPendingStateModelsInRound(ctx contractapi.TransactionContextInterface, jobID, roundArg string) ([]*ModelRecord, error) {
+	scope, err := parseConvergenceScope(jobID, roundArg)
+	if err != nil {
+		return nil, err
+	}
+	return listPendingStateModels(ctx, scope)
+}
+
+func listStateVerifications(ctx contractapi.TransactionContextInterface, scope convergenceScope) ([]*StateVerification, error) {
+	iter, err := scope.partialVerificationKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state verifications: %w", err)
+	}
+	defer iter.Close()
+	verifications := make([]*StateVerification, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var verification StateVerification
+		if err := json.Unmarshal(kv.Value, &verification); err != nil {
+			return nil, fmt.Errorf("failed to decode verification %s: %w", kv.Key, err)
+		}
+		verifications = append(verifications, &verification)
+	}
+	return verifications, nil
+}
+
+func listPendingStateModels(ctx contractapi.TransactionContextInterface, scope convergenceScope) ([]*ModelRecord, error) {
+	verifications, err := listStateVerifications(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	verified := map[string]bool{}
+	for _, verification := range verifications {
+		verified[verification.ModelID] = true
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(modelIndexType, []string{"state"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state models: %w", err)
+	}
+	defer iter.Close()
+	pending := make([]*ModelRecord, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 4 || parts[2] != fmt.Sprintf("%010d", scope.round) || verified[parts[3]] {
+			continue
+		}
+		model, err := readModelRecord(ctx, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if model != nil && model.JobID == scope.jobID {
+			pending = append(pending, model)
+		}
+	}
+	return pending, nil
+}
+
+// requirePassingVerifications refuses a nation declaration while a state that reported toward
+// the nation in scope lacks a passing verification.
+func requirePassingVerifications(ctx contractapi.TransactionContextInterface, scope convergenceScope, states map[string]*ConvergenceRecord) error {
+	verifications, err := listStateVerifications(ctx, scope)
+	if err != nil {
+		return err
+	}
+	results := map[string]string{}
+	for _, verification := range verifications {
+		results[verification.StateID] = verification.Result
+	}
+	var missing []string
+	for stateID := range states {
+		if results[stateID] != verificationPass {
+			missing = append(missing, stateID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("state %s has no passing verification%s", strings.Join(missing, ", "), scope.describe())
+}
+
+func (s convergenceScope) verificationKey(ctx contractapi.TransactionContextInterface, stateID string) (string, error) {
+	if !s.scoped() {
+		return ctx.GetStub().CreateCompositeKey(stateVerificationType, []string{stateID})
+	}
+	return ctx.GetStub().CreateCompositeKey(jobStateVerificationType, append(s.prefix(), stateID))
+}
+
+func (s convergenceScope) partialVerificationKeys(ctx contractapi.TransactionContextInterface) (shim.StateQueryIteratorInterface, error) {
+	if !s.scoped() {
+		return ctx.GetStub().GetStateByPartialCompositeKey(stateVerificationType, nil)
+	}
+	return ctx.GetStub().GetStateByPartialCompositeKey(jobStateVerificationType, s.prefix())
+}
+
+// scopeOrLegacy describes the scope for errors, naming the legacy keyspace explicitly.
+func scopeOrLegacy(s convergenceScope) string {
+	if !s.scoped() {
+		return " outside a job round"
+	}
+	return s.describe()
+}
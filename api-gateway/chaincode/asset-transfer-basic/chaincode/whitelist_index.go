@@ -0,0 +1,259 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Whitelist entries stay under whitelist:<sub>; these indexes let the state and cluster
+// queries read only the matching entries. Attributes are lower-cased, with entries that lack
+// a state or cluster indexed under the placeholders below.
+const (
+	whitelistStateIndexType   = "whitelist~state~cluster~sub"
+	whitelistClusterIndexType = "whitelist~cluster~state~sub"
+
+	unknownStateID      = "unknown"
+	unassignedClusterID = "unassigned"
+)
+
+// WhitelistHierarchyPage is a page of states, each with every whitelisted node grouped by cluster.
+type WhitelistHierarchyPage struct {
+	States  []*WhitelistStateGroup `json:"states"`
+	Page    int                    `json:"page"`
+	PerPage int                    `json:"per_page"`
+	Total   int                    `json:"total"`
+	HasMore bool                   `json:"has_more"`
+}
+
+// WhitelistStateGroup lists the clusters of a state.
+type WhitelistStateGroup struct {
+	StateID  string                   `json:"state_id"`
+	Clusters []*WhitelistClusterGroup `json:"clusters"`
+}
+
+// WhitelistClusterGroup lists the nodes of a cluster.
+type WhitelistClusterGroup struct {
+	ClusterID string            `json:"cluster_id"`
+	Nodes     []*WhitelistEntry `json:"nodes"`
+}
+
+// ListWhitelistByState returns a page of the entries registered in a state, ordered by
+// cluster then subject.
+func (c *GatewayContract) ListWhitelistByState(ctx contractapi.TransactionContextInterface, stateID, pageArg, perPageArg string) (*WhitelistListPage, error) {
+	state, err := normalizeIdentifier(stateID, "stateId")
+	if err != nil {
+		return nil, err
+	}
+	page, perPage, err := parseWhitelistPage(pageArg, perPageArg)
+	if err != nil {
+		return nil, err
+	}
+	return listWhitelistIndex(ctx, whitelistStateIndexType, []string{state}, page, perPage)
+}
+
+// ListWhitelistByCluster returns a page of the entries registered in a cluster, optionally
+// narrowed to one state when cluster IDs repeat across states.
+func (c *GatewayContract) ListWhitelistByCluster(ctx contractapi.TransactionContextInterface, clusterID, stateID, pageArg, perPageArg string) (*WhitelistListPage, error) {
+	cluster, err := normalizeIdentifier(clusterID, "clusterId")
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{cluster}
+	if state := strings.ToLower(strings.TrimSpace(stateID)); state != "" {
+		attributes = append(attributes, state)
+	}
+	page, perPage, err := parseWhitelistPage(pageArg, perPageArg)
+	if err != nil {
+		return nil, err
+	}
+	return listWhitelistIndex(ctx, whitelistClusterIndexType, attributes, page, perPage)
+}
+
+// ListWhitelistHierarchy returns a page of states with their entries grouped by cluster.
+// Paging counts states, so a state's clusters are never split across pages.
+func (c *GatewayContract) ListWhitelistHierarchy(ctx contractapi.TransactionContextInterface, pageArg, perPageArg string) (*WhitelistHierarchyPage, error) {
+	page, perPage, err := parseWhitelistPage(pageArg, perPageArg)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(whitelistStateIndexType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist hierarchy: %w", err)
+	}
+	defer iter.Close()
+
+	start := (page - 1) * perPage
+	states := make([]*WhitelistStateGroup, 0, perPage)
+	total := 0
+	var current *WhitelistStateGroup
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 3 {
+			return nil, fmt.Errorf("malformed whitelist index key %q", kv.Key)
+		}
+		if current == nil || current.StateID != parts[0] {
+			total++
+			current = &WhitelistStateGroup{StateID: parts[0]}
+			if total > start && len(states) < perPage {
+				states = append(states, current)
+			}
+		}
+		if total <= start || total > start+perPage {
+			continue
+		}
+		entry, err := readWhitelistEntry(ctx, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		clusters := current.Clusters
+		if len(clusters) == 0 || clusters[len(clusters)-1].ClusterID != parts[1] {
+			current.Clusters = append(clusters, &WhitelistClusterGroup{ClusterID: parts[1]})
+		}
+		group := current.Clusters[len(current.Clusters)-1]
+		group.Nodes = append(group.Nodes, entry)
+	}
+	return &WhitelistHierarchyPage{
+		States:  states,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		HasMore: total > start+len(states),
+	}, nil
+}
+
+func listWhitelistIndex(ctx contractapi.TransactionContextInterface, indexType string, attributes []string, page, perPage int) (*WhitelistListPage, error) {
+	iter, err := ctx.GetStub().GetStateByPartialCompositeKey(indexType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list whitelist: %w", err)
+	}
+	defer iter.Close()
+
+	start := (page - 1) * perPage
+	total := 0
+	items := make([]*WhitelistEntry, 0, perPage)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		total++
+		if total <= start || len(items) >= perPage {
+			continue
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) != 3 {
+			return nil, fmt.Errorf("malformed whitelist index key %q", kv.Key)
+		}
+		entry, err := readWhitelistEntry(ctx, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, entry)
+	}
+	return &WhitelistListPage{
+		Items:   items,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		HasMore: total > start+len(items),
+	}, nil
+}
+
+func readWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub string) (*WhitelistEntry, error) {
+	raw, err := ctx.GetStub().GetState(whitelistKey(jwtSub))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whitelist entry %s: %w", jwtSub, err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("whitelist index references missing entry %s", jwtSub)
+	}
+	var entry WhitelistEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// whitelistIndexKeys returns the state and cluster index keys of an entry.
+func whitelistIndexKeys(ctx contractapi.TransactionContextInterface, entry *WhitelistEntry) ([]string, error) {
+	state := strings.ToLower(strings.TrimSpace(entry.State))
+	if state == "" {
+		state = unknownStateID
+	}
+	cluster := strings.ToLower(strings.TrimSpace(entry.Cluster))
+	if cluster == "" {
+		cluster = unassignedClusterID
+	}
+	byState, err := ctx.GetStub().CreateCompositeKey(whitelistStateIndexType, []string{state, cluster, entry.JWTSub})
+	if err != nil {
+		return nil, err
+	}
+	byCluster, err := ctx.GetStub().CreateCompositeKey(whitelistClusterIndexType, []string{cluster, state, entry.JWTSub})
+	if err != nil {
+		return nil, err
+	}
+	return []string{byState, byCluster}, nil
+}
+
+// putWhitelistIndex indexes entry, dropping the keys of previous when it moved state or cluster.
+func putWhitelistIndex(ctx contractapi.TransactionContextInterface, entry, previous *WhitelistEntry) error {
+	keys, err := whitelistIndexKeys(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		stale, err := whitelistIndexKeys(ctx, previous)
+		if err != nil {
+			return err
+		}
+		for i, key := range stale {
+			if key == keys[i] {
+				continue
+			}
+			if err := ctx.GetStub().DelState(key); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range keys {
+		if err := putState(ctx, key, []byte{0x00}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseWhitelistPage(pageArg, perPageArg string) (int, int, error) {
+	page := 1
+	if strings.TrimSpace(pageArg) != "" {
+		value, err := strconv.Atoi(pageArg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid page parameter: %w", err)
+		}
+		if value < 1 {
+			return 0, 0, errors.New("page must be >= 1")
+		}
+		page = value
+	}
+	perPage := 50
+	if strings.TrimSpace(perPageArg) != "" {
+		value, err := strconv.Atoi(perPageArg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid perPage parameter: %w", err)
+		}
+		if value < 1 {
+			return 0, 0, errors.New("perPage must be >= 1")
+		}
+		perPage = value
+	}
+	return page, perPage, nil
+}
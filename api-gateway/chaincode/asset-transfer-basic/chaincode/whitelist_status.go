@@ -0,0 +1,231 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Whitelist entry statuses. Entries written before statuses existed carry none and count as
+// active.
+const (
+	whitelistStatusActive      = "active"
+	whitelistStatusDeactivated = "deactivated"
+
+	// whitelistStatusRemoved only appears on trainer records, once their entry is removed.
+	whitelistStatusRemoved = "removed"
+)
+
+// errTrainerDeactivated refuses trainers whose whitelist entry is deactivated or removed.
+var errTrainerDeactivated = errors.New("trainer deactivated")
+
+// whitelistTombstonePrefix keys the records left behind by RemoveWhitelistEntry. It sits
+// outside the whitelist: range so listings never see tombstones.
+const whitelistTombstonePrefix = "whitelist-tombstone:"
+
+// WhitelistTombstone records a whitelist entry that was removed, and blocks the subject from
+// being recorded again.
+type WhitelistTombstone struct {
+	JWTSub    string `json:"jwt_sub"`
+	DID       string `json:"did"`
+	NodeID    string `json:"node_id"`
+	State     string `json:"state,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	RemovedBy string `json:"removed_by"`
+	RemovedAt string `json:"removed_at"`
+}
+
+// DeactivateWhitelistEntry marks an entry deactivated. It stays listed, with its status, so
+// its history is kept, but convergence and client selection skip it.
+func (c *GatewayContract) DeactivateWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub, reason string) (*WhitelistEntry, error) {
+	entry, err := requireWhitelistEntry(ctx, jwtSub)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status == whitelistStatusDeactivated {
+		return nil, fmt.Errorf("whitelist entry %s is already deactivated", entry.JWTSub)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	entry.Status = whitelistStatusDeactivated
+	entry.StatusReason = strings.TrimSpace(reason)
+	entry.StatusChangedBy = clientID
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry.StatusChangedAt = now
+	if err := setTrainerWhitelistStatus(ctx, entry, whitelistStatusDeactivated); err != nil {
+		return nil, err
+	}
+	return entry, putWhitelistEntry(ctx, entry)
+}
+
+// ReactivateWhitelistEntry returns a deactivated entry to active.
+func (c *GatewayContract) ReactivateWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub string) (*WhitelistEntry, error) {
+	entry, err := requireWhitelistEntry(ctx, jwtSub)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != whitelistStatusDeactivated {
+		return nil, fmt.Errorf("whitelist entry %s is not deactivated", entry.JWTSub)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	entry.Status = whitelistStatusActive
+	entry.StatusReason = ""
+	entry.StatusChangedBy = clientID
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry.StatusChangedAt = now
+	if err := setTrainerWhitelistStatus(ctx, entry, ""); err != nil {
+		return nil, err
+	}
+	return entry, putWhitelistEntry(ctx, entry)
+}
+
+// RemoveWhitelistEntry deletes an entry and its index keys and leaves a tombstone in its
+// place, so RecordWhitelistEntry refuses the subject from then on.
+func (c *GatewayContract) RemoveWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub, reason string) (*WhitelistTombstone, error) {
+	entry, err := requireWhitelistEntry(ctx, jwtSub)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve client identity: %w", err)
+	}
+	keys, err := whitelistIndexKeys(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range append(keys, whitelistKey(entry.JWTSub)) {
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return nil, err
+		}
+	}
+	now, err := txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tombstone := &WhitelistTombstone{
+		JWTSub:    entry.JWTSub,
+		DID:       entry.DID,
+		NodeID:    entry.NodeID,
+		State:     entry.State,
+		Cluster:   entry.Cluster,
+		Reason:    strings.TrimSpace(reason),
+		RemovedBy: clientID,
+		RemovedAt: now,
+	}
+	payload, err := json.Marshal(tombstone)
+	if err != nil {
+		return nil, err
+	}
+	if err := putState(ctx, whitelistTombstoneKey(entry.JWTSub), payload); err != nil {
+		return nil, err
+	}
+	if err := setTrainerWhitelistStatus(ctx, entry, whitelistStatusRemoved); err != nil {
+		return nil, err
+	}
+	return tombstone, nil
+}
+
+// setTrainerWhitelistStatus copies a whitelist status change to the registered trainers of
+// the entry's node and DID, where requireAuthorizedTrainer checks it on every trainer call.
+// An empty status marks them active again.
+func setTrainerWhitelistStatus(ctx contractapi.TransactionContextInterface, entry *WhitelistEntry, status string) error {
+	iter, err := ctx.GetStub().GetStateByRange(trainerPrefix, trainerPrefix+"~")
+	if err != nil {
+		return fmt.Errorf("failed to list trainers: %w", err)
+	}
+	defer iter.Close()
+	var trainers []*Trainer
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var trainer Trainer
+		if err := json.Unmarshal(kv.Value, &trainer); err != nil {
+			return err
+		}
+		if trainer.NodeID == entry.NodeID && (entry.DID == "" || trainer.DID == entry.DID) && trainer.WhitelistStatus != status {
+			trainers = append(trainers, &trainer)
+		}
+	}
+	for _, trainer := range trainers {
+		trainer.WhitelistStatus = status
+		payload, err := json.Marshal(trainer)
+		if err != nil {
+			return err
+		}
+		if err := putState(ctx, trainerKey(trainer.ClientID), payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRemovedWhitelistEntries returns every whitelist tombstone in subject order.
+func (c *GatewayContract) ListRemovedWhitelistEntries(ctx contractapi.TransactionContextInterface) ([]*WhitelistTombstone, error) {
+	iter, err := ctx.GetStub().GetStateByRange(whitelistTombstonePrefix, whitelistTombstonePrefix+"~")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list removed whitelist entries: %w", err)
+	}
+	defer iter.Close()
+	tombstones := make([]*WhitelistTombstone, 0)
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance iterator: %w", err)
+		}
+		var tombstone WhitelistTombstone
+		if err := json.Unmarshal(kv.Value, &tombstone); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, &tombstone)
+	}
+	return tombstones, nil
+}
+
+func requireWhitelistEntry(ctx contractapi.TransactionContextInterface, jwtSub string) (*WhitelistEntry, error) {
+	jwtSub = strings.ToLower(strings.TrimSpace(jwtSub))
+	if jwtSub == "" {
+		return nil, errors.New("jwtSub is required")
+	}
+	raw, err := ctx.GetStub().GetState(whitelistKey(jwtSub))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whitelist entry: %w", err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("whitelist entry %s not found", jwtSub)
+	}
+	var entry WhitelistEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func putWhitelistEntry(ctx contractapi.TransactionContextInterface, entry *WhitelistEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return putState(ctx, whitelistKey(entry.JWTSub), payload)
+}
+
+func whitelistTombstoneKey(jwtSub string) string {
+	return whitelistTombstonePrefix + strings.ToLower(strings.TrimSpace(jwtSub))
+}